@@ -0,0 +1,72 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"orderbook/internal/backfill"
+	"orderbook/internal/exchange"
+	"orderbook/internal/storage"
+)
+
+func main() {
+	// Parse command line flags
+	var file = flag.String("file", "", "External depth data file (.gz files are decompressed)")
+	var format = flag.String("format", string(backfill.FormatTardis), "Input format (tardis, binance)")
+	var exchangeName = flag.String("exchange", "", "Exchange the data is stored under (e.g., binancef)")
+	var symbol = flag.String("symbol", "", "Symbol the data is stored under (default: symbol found in the data)")
+	var storageDriver = flag.String("storage", string(storage.DriverSQLite), "Storage backend to import into (sqlite, clickhouse)")
+	var storageDSN = flag.String("storage-dsn", "orderbook.db", "Storage DSN (SQLite file path or ClickHouse HTTP URL)")
+	flag.Parse()
+
+	if *file == "" || *exchangeName == "" {
+		log.Fatal("-file and -exchange are required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	store, err := storage.New(storage.Config{
+		Driver: storage.Driver(*storageDriver),
+		DSN:    *storageDSN,
+	})
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	if store == nil {
+		log.Fatal("-storage is required")
+	}
+	defer store.Close()
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open input: %v", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(*file, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			log.Fatalf("Failed to decompress input: %v", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	result, err := backfill.Import(ctx, r, backfill.Options{
+		Format:   backfill.Format(*format),
+		Exchange: exchange.ExchangeName(*exchangeName),
+		Symbol:   strings.ToUpper(*symbol),
+	}, store)
+	log.Printf("Imported %d snapshots and %d updates from %s", result.Snapshots, result.Updates, *file)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+}
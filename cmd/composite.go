@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"orderbook/internal/config"
+	"orderbook/internal/conversion"
+	"orderbook/internal/exchange"
+	"orderbook/internal/factory"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// errStreamClosed is returned by streamBook when the exchange stops sending updates
+var errStreamClosed = errors.New("connection closed")
+
+// streamBook connects ex, loads its snapshot into ob and keeps ob up to date until
+// stop is closed or the connection ends. Unlike the main exchanges, the book is not
+// published, recorded or analyzed.
+func streamBook(ctx context.Context, cfg config.Config, exCfg config.ExchangeConfig, ex exchange.Exchange, ob *orderbook.OrderBook, stop <-chan struct{}) error {
+	ob.SetCapabilities(ex.Capabilities())
+	if watcher, ok := ex.(exchange.StallWatcher); ok {
+		watcher.SetStaleTimeout(cfg.StaleTimeoutFor(exCfg))
+	}
+
+	if err := ex.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer ex.Close()
+
+	policy := cfg.SnapshotPolicyFor(exCfg)
+	getSnapshot := func() (*exchange.Snapshot, error) {
+		return exchange.FetchSnapshot(ctx, exCfg.Name, policy, ex.GetSnapshot)
+	}
+	snapshot, err := getSnapshot()
+	if err != nil {
+		return fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	if err := ob.LoadSnapshot(snapshot); err != nil {
+		return fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	updatesDone := make(chan struct{})
+	go func() {
+		defer close(updatesDone)
+		updates := ex.Updates()
+		for update := range updates {
+			applyUpdate(ob, update, len(updates) == 0)
+		}
+	}()
+	ob.ProcessBufferedEvents()
+
+	ticker := time.NewTicker(cfg.App.ReinitCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ob.CheckAndReinitialize(getSnapshot)
+		case <-updatesDone:
+			return errStreamClosed
+		case <-stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// compositeLeg is a book merged into a composite, keyed for the converter by
+// exchange and symbol (the primary book by exchange alone)
+type compositeLeg struct {
+	key  string
+	book *orderbook.OrderBook
+}
+
+// startComposite streams the books of the exchange's symbol in every extra composite
+// quote and returns a book merging them with primary in primary's quote currency,
+// rebuilt on the composite interval until stop is closed. Books quoted in a
+// currency without a conversion rate are merged at par.
+func startComposite(ctx context.Context, opts runOptions, exCfg config.ExchangeConfig, primary *orderbook.OrderBook, primaryQuote string, stop <-chan struct{}) *orderbook.OrderBook {
+	cfg := opts.cfg
+	venue := string(exCfg.Name)
+	legs := []compositeLeg{{key: venue, book: primary}}
+	merged := map[string]bool{primaryQuote: true}
+
+	for _, quote := range cfg.App.Composite.Quotes {
+		legCfg := exCfg
+		legCfg.Symbol = types.BaseAsset(exCfg.Symbol) + quote
+		ex, err := opts.newExchange(factory.ExchangeConfig{
+			Name:        legCfg.Name,
+			Symbol:      legCfg.Symbol,
+			Depth:       legCfg.Depth,
+			UpdateSpeed: legCfg.UpdateSpeed,
+			Category:    legCfg.Category,
+			SigFigs:     legCfg.SigFigs,
+			Mantissa:    legCfg.Mantissa,
+			TopOfBook:   legCfg.TopOfBook,
+		})
+		if err != nil {
+			log.Printf("[%s] %s not merged, failed to create exchange: %v", venue, legCfg.Symbol, err)
+			continue
+		}
+		// Venues may map several quotes to the same book (e.g., Coinbase USDT to USD)
+		legQuote := quoteCurrency(ex, legCfg.Symbol)
+		if merged[legQuote] {
+			continue
+		}
+		merged[legQuote] = true
+
+		leg := compositeLeg{key: venue + ":" + legCfg.Symbol, book: orderbook.New()}
+		if opts.converter != nil {
+			opts.converter.SetQuote(leg.key, legQuote)
+		}
+		legs = append(legs, leg)
+		go func() {
+			if err := streamBook(ctx, cfg, legCfg, ex, leg.book, stop); err != nil {
+				log.Printf("[%s] %s no longer merged, %v", venue, legCfg.Symbol, err)
+			}
+		}()
+		log.Printf("[%s] Merging %s into the %s book", venue, legCfg.Symbol, exCfg.Symbol)
+	}
+
+	composite := orderbook.New()
+	composite.LoadComposite(compositeLegs(legs, opts.converter))
+	go func() {
+		ticker := time.NewTicker(cfg.App.Composite.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				composite.LoadComposite(compositeLegs(legs, opts.converter))
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return composite
+}
+
+// compositeLegs returns the legs with the factors converting their prices to the
+// quote currency of the first (primary) leg
+func compositeLegs(legs []compositeLeg, converter *conversion.Converter) []orderbook.CompositeLeg {
+	result := make([]orderbook.CompositeLeg, len(legs))
+	one := decimal.NewFromInt(1)
+	primaryFactor := one
+	if converter != nil {
+		primaryFactor, _ = converter.Factor(legs[0].key)
+	}
+	for i, leg := range legs {
+		factor := one
+		if converter != nil && i > 0 {
+			legFactor, _ := converter.Factor(leg.key)
+			factor = legFactor.Div(primaryFactor)
+		}
+		result[i] = orderbook.CompositeLeg{Book: leg.book, Factor: factor}
+	}
+	return result
+}
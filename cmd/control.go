@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/websocket"
+)
+
+// exchangeControl tracks the running exchanges so the server can inspect and act on them
+type exchangeControl struct {
+	mu      sync.Mutex
+	running map[string]*runningExchange
+}
+
+// runningExchange receives the actions requested for one exchange
+type runningExchange struct {
+	ex       exchange.Exchange
+	resync   chan struct{} // Signaled to reload the book from a fresh snapshot
+	drop     chan struct{} // Closed to disconnect the exchange
+	dropOnce sync.Once
+}
+
+func newExchangeControl() *exchangeControl {
+	return &exchangeControl{running: make(map[string]*runningExchange)}
+}
+
+// register records a connected exchange and returns its action channels
+func (c *exchangeControl) register(name string, ex exchange.Exchange) *runningExchange {
+	running := &runningExchange{
+		ex:     ex,
+		resync: make(chan struct{}, 1),
+		drop:   make(chan struct{}),
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running[name] = running
+	return running
+}
+
+// unregister forgets an exchange once it stopped, unless it was already replaced
+func (c *exchangeControl) unregister(name string, running *runningExchange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running[name] == running {
+		delete(c.running, name)
+	}
+}
+
+func (c *exchangeControl) lookup(name string) (*runningExchange, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	running, ok := c.running[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", websocket.ErrUnknownExchange, name)
+	}
+	return running, nil
+}
+
+// Exchange returns the adapter of a running exchange
+func (c *exchangeControl) Exchange(name string) (exchange.Exchange, bool) {
+	running, err := c.lookup(name)
+	if err != nil {
+		return nil, false
+	}
+	return running.ex, true
+}
+
+// Resync asks the exchange goroutine to reload the book; a pending request is not repeated
+func (c *exchangeControl) Resync(name string) error {
+	running, err := c.lookup(name)
+	if err != nil {
+		return err
+	}
+	select {
+	case running.resync <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Drop disconnects the exchange until the next symbol change
+func (c *exchangeControl) Drop(name string) error {
+	running, err := c.lookup(name)
+	if err != nil {
+		return err
+	}
+	running.dropOnce.Do(func() { close(running.drop) })
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"orderbook/internal/bookdiff"
+	"orderbook/internal/exchange"
+	"orderbook/internal/storage"
+
+	"github.com/shopspring/decimal"
+)
+
+func main() {
+	// Parse command line flags
+	var beforeFile = flag.String("before", "", "Earlier snapshot file (JSON-encoded snapshot, e.g. a journal checkpoint)")
+	var afterFile = flag.String("after", "", "Later snapshot file (JSON-encoded snapshot)")
+	var from = flag.String("from", "", "Earlier book time in storage (RFC 3339, e.g. 2024-05-01T12:00:00Z)")
+	var to = flag.String("to", "", "Later book time in storage (RFC 3339)")
+	var exchangeName = flag.String("exchange", "", "Exchange of the stored books (e.g., binancef)")
+	var symbol = flag.String("symbol", "BTCUSDT", "Symbol of the stored books")
+	var bands = flag.String("bands", "0.5,2,10", "Comma-separated bands of mid price in percent to report liquidity shifts for")
+	var storageDriver = flag.String("storage", string(storage.DriverSQLite), "Storage backend to read stored books from (sqlite, clickhouse)")
+	var storageDSN = flag.String("storage-dsn", "orderbook.db", "Storage DSN (SQLite file path or ClickHouse HTTP URL)")
+	flag.Parse()
+
+	var bandList []decimal.Decimal
+	for _, band := range strings.Split(*bands, ",") {
+		percent, err := decimal.NewFromString(strings.TrimSpace(band))
+		if err != nil || !percent.IsPositive() {
+			log.Fatalf("Invalid band %q", band)
+		}
+		bandList = append(bandList, percent.Div(decimal.NewFromInt(100)))
+	}
+
+	var before, after *exchange.Snapshot
+	switch {
+	case *beforeFile != "" && *afterFile != "":
+		before, after = readSnapshot(*beforeFile), readSnapshot(*afterFile)
+
+	case *from != "" && *to != "":
+		if *exchangeName == "" {
+			log.Fatal("-exchange is required with -from and -to")
+		}
+		fromTime, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			log.Fatalf("Invalid -from: %v", err)
+		}
+		toTime, err := time.Parse(time.RFC3339, *to)
+		if err != nil {
+			log.Fatalf("Invalid -to: %v", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		store, err := storage.New(storage.Config{
+			Driver: storage.Driver(*storageDriver),
+			DSN:    *storageDSN,
+		})
+		if err != nil {
+			log.Fatalf("Failed to open storage: %v", err)
+		}
+		if store == nil {
+			log.Fatal("-storage is required")
+		}
+		defer store.Close()
+
+		name, sym := exchange.ExchangeName(*exchangeName), strings.ToUpper(*symbol)
+		if before, err = bookdiff.Reconstruct(ctx, store, name, sym, fromTime); err != nil {
+			log.Fatalf("Failed to load the earlier book: %v", err)
+		}
+		if after, err = bookdiff.Reconstruct(ctx, store, name, sym, toTime); err != nil {
+			log.Fatalf("Failed to load the later book: %v", err)
+		}
+
+	default:
+		log.Fatal("Either -before and -after, or -from and -to are required")
+	}
+
+	result, err := bookdiff.Diff(before, after, bandList)
+	if err != nil {
+		log.Fatalf("Diff failed: %v", err)
+	}
+	if err := bookdiff.Print(os.Stdout, result); err != nil {
+		log.Fatalf("Failed to print the diff: %v", err)
+	}
+}
+
+// readSnapshot decodes the snapshot file at path
+func readSnapshot(path string) *exchange.Snapshot {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read snapshot: %v", err)
+	}
+	var snapshot exchange.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		log.Fatalf("Failed to decode snapshot %s: %v", path, err)
+	}
+	return &snapshot
+}
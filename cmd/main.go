@@ -2,133 +2,890 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"orderbook/internal/analytics"
 	"orderbook/internal/config"
+	"orderbook/internal/conversion"
+	"orderbook/internal/diagnostics"
+	"orderbook/internal/display"
+	"orderbook/internal/distributed"
 	"orderbook/internal/exchange"
 	"orderbook/internal/factory"
+	"orderbook/internal/journal"
+	"orderbook/internal/logging"
 	"orderbook/internal/orderbook"
+	"orderbook/internal/pipeline"
+	"orderbook/internal/reporting"
+	"orderbook/internal/scripting"
+	"orderbook/internal/shard"
+	"orderbook/internal/storage"
+	"orderbook/internal/tracing"
+	"orderbook/internal/transport"
+	"orderbook/internal/types"
 	"orderbook/internal/websocket"
 
 	"github.com/shopspring/decimal"
 )
 
 func main() {
+	// Apply the log level to every standard log line
+	logging.Install()
+
+	// Defaults, overridden by ORDERBOOK_* environment variables and then by flags
+	cfg := config.NewMultiExchange(buildExchangeConfigs("BTCUSDT", getExchangeNames()))
+	if err := cfg.ApplyEnv(); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+
 	// Parse command line flags
-	var symbol = flag.String("symbol", "BTCUSDT", "Trading symbol to monitor")
-	var logInterval = flag.Duration("log-interval", 10*time.Second, "Interval for logging orderbook stats")
+	var symbol = flag.String("symbol", cfg.Exchanges[0].Symbol, "Trading symbol to monitor")
+	var exchanges = flag.String("exchanges", joinExchangeNames(cfg.ExchangeNames()), "Comma-separated exchanges to connect")
+	var logInterval = flag.Duration("log-interval", cfg.Display.UpdateInterval, "Interval for logging orderbook stats")
+	var compact = flag.Bool("compact", cfg.Display.Compact, "Log one line of stats per exchange (colors are disabled by NO_COLOR)")
+	var output = flag.String("output", cfg.Display.Output, "Format of the periodic stats on stdout: text or json (one object per interval, logs stay on stderr)")
+	var storageDriver = flag.String("storage", cfg.Storage.Driver, "Storage backend for recording (sqlite, clickhouse)")
+	var storageDSN = flag.String("storage-dsn", cfg.Storage.DSN, "Storage DSN (SQLite file path or ClickHouse HTTP URL)")
+	var fixedPoint = flag.Bool("fixed-point", cfg.App.FixedPoint, "Use the fixed-point engine for instruments with precision metadata")
+	var statsInterval = flag.Duration("stats-interval", cfg.App.StatsInterval, "Recompute liquidity stats on this interval instead of on every update (0 = every update)")
+	var port = flag.String("port", cfg.Server.Port, "Port served when no -listen is given")
+	var ladderBuckets = flag.Int("ladder-buckets", cfg.Server.LadderBuckets, "Buckets at the current tick on each side of the mid in consolidated ladder messages")
+	var depthMaxDistance = flag.Float64("depth-max-distance", cfg.Server.DepthMaxDistancePct, "Only publish levels within this fraction of mid in orderbook messages and depth responses, e.g. 0.02 (0 = all levels)")
+	var depthMinQuantity = flag.Float64("depth-min-quantity", cfg.Server.DepthMinQuantity, "Only publish levels of at least this quantity in orderbook messages and depth responses (0 = all levels)")
+	var tailDistance = flag.Float64("tail-distance", cfg.Server.TailDistancePct, "Collapse published levels beyond this fraction of mid into one tail level per side, e.g. 0.05 (0 = disabled)")
+	var listeners listenerFlags
+	flag.Var(&listeners, "listen", "Address to serve on, repeatable: [unix:]address[=readonly|=control] (default :<port>=control)")
+	var record = flag.String("record", cfg.Server.Record, "Record WebSocket broadcasts to this file for replay (cmd/replay)")
+	var recordChunked = flag.Bool("record-chunked", cfg.Server.Chunked, "Make -record a directory of compressed chunks indexed by time, for long sessions")
+	var snapshotInterval = flag.Duration("snapshot-interval", cfg.App.SnapshotInterval, "Persist full books on wall-clock boundaries of this interval, e.g. 1m or 1h (requires -storage, 0 = disabled)")
+	var snapshotTimeout = flag.Duration("snapshot-timeout", cfg.App.SnapshotTimeout, "Timeout of each snapshot attempt (0 = adapter default, 10s or 30s for WebSocket snapshots)")
+	var snapshotAttempts = flag.Int("snapshot-attempts", cfg.App.SnapshotAttempts, "Snapshot attempts, with exponential backoff, before an exchange is given up")
+	var depths = flag.String("depth", "", "Per-exchange subscription or snapshot depth, e.g. bybit=50,kraken=10 (Binance, Bybit, Kraken, OKX, Asterdex)")
+	var updateSpeeds = flag.String("update-speed", "", "Per-exchange depth stream frequency, e.g. binance=1000ms,binancef=500ms (Binance)")
+	var categories = flag.String("category", "", "Per-exchange product category, e.g. bybitf=inverse (Bybit: spot, linear, inverse, option)")
+	var sigFigs = flag.String("sig-figs", "", "Per-exchange significant figures of server-side aggregated price levels, e.g. hyperliquidf=4 (Hyperliquid: 2 to 5, default: full precision)")
+	var instruments = flag.String("instrument", "", "Per-exchange instrument ID used verbatim instead of converting -symbol, e.g. binancef=BTCUSDT_250627,okx=BTC-USD-250627 (dated futures, options)")
+	var mantissas = flag.String("mantissa", "", "Per-exchange aggregation step mantissa with 5 significant figures, e.g. hyperliquidf=2 (Hyperliquid: 1, 2 or 5)")
+	var maxBookAges = flag.String("max-book-age", "", "Per-exchange book expiry: clear a book after this long without data until fresh data arrives, e.g. okx=10s")
+	var reinitIntervals = flag.String("reinit-interval", "", "Per-exchange interval between reinitialization checks, e.g. binancef=1s (default: "+cfg.App.ReinitCheckInterval.String()+")")
+	var reinitBuffers = flag.String("reinit-buffer", "", "Per-exchange buffered events past which a book is reloaded from a snapshot, e.g. binancef=500 (default: "+strconv.Itoa(cfg.App.MaxBufferSize)+")")
+	var makerFees = flag.String("maker-fees", "", "Per-exchange maker fees in bps, e.g. binance=7.5,okx=8 (default: base tier fees)")
+	var takerFees = flag.String("taker-fees", "", "Per-exchange taker fees in bps used by POST /api/route and -fee-adjusted, e.g. binance=7.5,okx=8 (default: base tier fees)")
+	var quoteRate = flag.String("quote-rate", cfg.QuoteRateSpec(), "Stablecoin pair feed normalizing USD books (Kraken, Coinbase) to its base, as exchange:SYMBOL (none = disabled)")
+	var compositeQuotes = flag.String("composite-quotes", cfg.CompositeQuotesSpec(), "Extra quote currencies whose books are merged into each exchange's book after quote normalization, e.g. USDC,USD (none = disabled)")
+	var feeAdjusted = flag.Bool("fee-adjusted", cfg.App.FeeAdjusted, "Publish prices net of taker fees (bids lowered, asks raised) so cross-venue spreads are capturable")
+	var candleMicroprice = flag.Bool("candle-microprice", cfg.App.Candles.Microprice, "Also build microprice candles (mid weighted by the size on the opposite side) next to the mid candles")
+	var summaryInterval = flag.Duration("summary-interval", cfg.App.Summary.Interval, "Log a per-exchange session summary on this interval (0 = only on exit)")
+	var summaryFile = flag.String("summary-file", cfg.App.Summary.File, "Also write the session summary to this JSON file")
+	var reportDir = flag.String("report-dir", cfg.App.Report.Dir, "Write the daily per-exchange SLA report to this directory as JSON and Markdown")
+	var reportKeep = flag.Int("report-keep", cfg.App.Report.Keep, "Days of SLA reports served at /api/reports")
+	var watchlist = flag.String("watchlist", cfg.WatchlistSpec(), "Symbols monitored in turn, e.g. BTCUSDT,ETHUSDT,SOLUSDT, switching every -watchlist-interval (none = disabled)")
+	var watchlistInterval = flag.Duration("watchlist-interval", cfg.App.Watchlist.Interval, "Time spent on each -watchlist symbol, startup included")
+	var tracingExporter = flag.String("tracing", cfg.App.Tracing.Exporter, "Span exporter tracing updates from receipt to broadcast: none, stderr (JSON lines) or otlp (OTLP/HTTP JSON)")
+	var tracingEndpoint = flag.String("tracing-endpoint", cfg.App.Tracing.Endpoint, "OTLP/HTTP collector URL for -tracing otlp (default http://localhost:4318)")
+	var tracingSample = flag.Float64("tracing-sample", cfg.App.Tracing.SampleRatio, "Fraction of updates and broadcasts traced, 0 to 1")
+	var scripts = flag.String("scripts", cfg.App.Pipeline.Scripts, "JSON file of scripts evaluated on stats ticks or book updates, publishing signal messages")
+	var adminToken = flag.String("admin-token", cfg.Server.AdminToken, "Bearer token enabling the /admin/ endpoints (prefer "+config.EnvAdminToken+", flags are visible in ps)")
+	var logLevel = flag.String("log-level", cfg.Server.LogLevel, "Log level: debug, info or error (changeable at runtime through PUT /admin/log-level)")
+	var contractSizes = flag.String("contract-size", "", "Per-exchange base units per contract for the contracts quantity unit, e.g. okx=0.01 (default: 1)")
+	var topOfBook = flag.String("top-of-book", "", "Per-exchange top-of-book mode streaming the best bid and ask only, e.g. binance=true,bybitf=true (Binance, Bybit)")
+	var quantityUnit = flag.String("quantity-unit", cfg.App.QuantityUnit, "Default unit of orderbook and stats quantities: base, quote (notional) or contracts; clients may select another with set_unit")
+	var crossedPolicy = flag.String("crossed-policy", cfg.App.CrossedPolicy, "Healing of crossed books: clean (drop the stale crossing levels), resync (reload from a snapshot) or ignore (flag only)")
+	var gapResyncCooldown = flag.Duration("gap-resync-cooldown", cfg.App.GapResyncCooldown, "Resync a book as soon as a sequence gap proves updates were missed, at most once per this delay (0 = only resync once 100 events are buffered)")
+	var shards = flag.Int("shards", cfg.App.Shards, "Workers applying exchange updates, each exchange pinned to one (0 = GOMAXPROCS)")
+	var shardQueue = flag.Int("shard-queue", cfg.App.ShardQueueSize, "Updates queued per worker before exchange readers block")
+	var shardLockThreads = flag.Bool("shard-lock-threads", cfg.App.ShardLockThreads, "Lock each worker to its own OS thread, so it can be pinned to a CPU with taskset")
+	var averageWindows = flag.String("average-windows", cfg.AverageWindowsSpec(), "Windows of the time-weighted spread and liquidity averages in stats, ascending, e.g. 1m,5m,1h (none = disabled)")
+	var anomalyZScore = flag.Float64("anomaly-zscore", cfg.App.Anomaly.ZScore, "Flag spreads and 0.5% depths this many standard deviations from their rolling mean as anomalies (0 = disabled)")
+	var warmup = flag.Duration("warmup", cfg.App.Warmup.Duration, "Hold back a book after startup or a resync until it ran this long without gaps, so half-built books are not broadcast or displayed (0 = disabled)")
+	var oiInterval = flag.Duration("oi-interval", cfg.App.OpenInterest.Interval, "Poll the open interest of derivatives venues on this interval into their stats and stats history (0 = disabled)")
+	var oiWindow = flag.Duration("oi-window", cfg.App.OpenInterest.Window, "Window over which the open interest change in stats is measured")
+	var warmupEvents = flag.Int("warmup-events", cfg.App.Warmup.Events, "Also hold back a book until it applied this many updates without gaps (0 = disabled)")
+	var dialTimeout = flag.Duration("dial-timeout", cfg.App.Transport.DialTimeout, "Timeout of each exchange connection attempt, DNS lookup included")
+	var dnsCacheTTL = flag.Duration("dns-cache-ttl", cfg.App.Transport.DNSCacheTTL, "Reuse DNS answers of exchange hosts for this long, and past it while the resolver fails (0 = no cache)")
+	var maxConnsPerHost = flag.Int("max-conns-per-host", cfg.App.Transport.MaxConnsPerHost, "REST and WebSocket connections open to one exchange host, each; further dials wait (0 = unlimited)")
+	var happyEyeballs = flag.Bool("happy-eyeballs", cfg.App.Transport.HappyEyeballs, "Race IPv6 and IPv4 addresses of exchange hosts instead of trying them in order")
+	var journalFile = flag.String("journal", cfg.App.Journal.File, "Write-ahead journal of book checkpoints and the symbol, so a restart resumes venues from recent checkpoints (empty = disabled)")
+	var journalInterval = flag.Duration("journal-interval", cfg.App.Journal.Interval, "Interval between journal checkpoints of every book")
+	var journalMaxAge = flag.Duration("journal-max-age", cfg.App.Journal.MaxAge, "Oldest journal checkpoint a venue resumes from on restart")
+	var aggregator = flag.String("aggregator", cfg.App.Distributed.Aggregator, "Run as a collector: stream the -exchanges to the aggregator at this collect URL, e.g. ws://aggregator:8086/collect, instead of serving books")
+	var collectors = flag.Bool("collectors", cfg.App.Distributed.Collectors, "Run as an aggregator: take the exchanges from collectors connecting to /collect on control listeners instead of running the adapters")
+	var collectorID = flag.String("collector-id", cfg.App.Distributed.CollectorID, "ID this collector reports to the aggregator (default host:pid)")
+	var debugAddr = flag.String("debug-addr", cfg.Server.DebugAddr, "Serve pprof, expvar and GC stats under /debug/ on this address, e.g. 127.0.0.1:6060 (unauthenticated, empty = disabled)")
+	var namespaces = flag.String("namespaces", cfg.NamespacesSpec(), "Independent monitors served under /ws/{name} and /{name}/api/..., e.g. spot=BTCUSDT,alts=ETHUSDT:okx+bybit (the first also answers /ws; empty = one monitor)")
+	var healthcheck = flag.Bool("healthcheck", false, "Probe the /health endpoint on the first -listen address (or -port) and exit with its status (for container HEALTHCHECK)")
 	flag.Parse()
+	symbolSet := false
+	flag.Visit(func(f *flag.Flag) {
+		symbolSet = symbolSet || f.Name == "symbol"
+	})
+
+	if len(listeners) == 0 {
+		for _, spec := range cfg.Server.Listeners {
+			if err := listeners.Set(spec); err != nil {
+				log.Fatalf("Invalid %s: %v", config.EnvListen, err)
+			}
+		}
+	}
+
+	if *healthcheck {
+		os.Exit(runHealthcheck(*port, listeners))
+	}
+
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		log.Fatalf("Invalid -log-level: %v", err)
+	}
+	logging.SetLevel(level)
+
+	names, err := config.ParseExchangeNames(*exchanges)
+	if err != nil {
+		log.Fatalf("Invalid -exchanges: %v", err)
+	}
+	cfg.SelectExchanges(names)
+	if err := cfg.SetDepths(*depths); err != nil {
+		log.Fatalf("Invalid -depth: %v", err)
+	}
+	if err := cfg.SetUpdateSpeeds(*updateSpeeds); err != nil {
+		log.Fatalf("Invalid -update-speed: %v", err)
+	}
+	if err := cfg.SetCategories(*categories); err != nil {
+		log.Fatalf("Invalid -category: %v", err)
+	}
+	if err := cfg.SetSigFigs(*sigFigs); err != nil {
+		log.Fatalf("Invalid -sig-figs: %v", err)
+	}
+	if err := cfg.SetMantissas(*mantissas); err != nil {
+		log.Fatalf("Invalid -mantissa: %v", err)
+	}
+	if err := cfg.SetInstruments(*instruments); err != nil {
+		log.Fatalf("Invalid -instrument: %v", err)
+	}
+	if err := cfg.SetMaxBookAges(*maxBookAges); err != nil {
+		log.Fatalf("Invalid -max-book-age: %v", err)
+	}
+	if err := cfg.SetReinitIntervals(*reinitIntervals); err != nil {
+		log.Fatalf("Invalid -reinit-interval: %v", err)
+	}
+	if err := cfg.SetReinitBuffers(*reinitBuffers); err != nil {
+		log.Fatalf("Invalid -reinit-buffer: %v", err)
+	}
+	if err := cfg.SetContractSizes(*contractSizes); err != nil {
+		log.Fatalf("Invalid -contract-size: %v", err)
+	}
+	if err := cfg.SetTopOfBook(*topOfBook); err != nil {
+		log.Fatalf("Invalid -top-of-book: %v", err)
+	}
+	if err := cfg.SetMakerFees(*makerFees); err != nil {
+		log.Fatalf("Invalid -maker-fees: %v", err)
+	}
+	if err := cfg.SetTakerFees(*takerFees); err != nil {
+		log.Fatalf("Invalid -taker-fees: %v", err)
+	}
+	cfg.App.FeeAdjusted = *feeAdjusted
+	cfg.App.Candles.Microprice = *candleMicroprice
+	if err := cfg.SetQuoteRate(*quoteRate); err != nil {
+		log.Fatalf("Invalid -quote-rate: %v", err)
+	}
+	if err := cfg.SetAverageWindows(*averageWindows); err != nil {
+		log.Fatalf("Invalid -average-windows: %v", err)
+	}
+	if err := cfg.SetCompositeQuotes(*compositeQuotes); err != nil {
+		log.Fatalf("Invalid -composite-quotes: %v", err)
+	}
+	if err := cfg.SetNamespaces(*namespaces); err != nil {
+		log.Fatalf("Invalid -namespaces: %v", err)
+	}
+	if err := cfg.SetWatchlist(*watchlist); err != nil {
+		log.Fatalf("Invalid -watchlist: %v", err)
+	}
+	if n := len(cfg.App.Watchlist.Symbols); n > 0 {
+		if n < 2 {
+			log.Fatalf("Invalid -watchlist: %s (at least two symbols)", *watchlist)
+		}
+		if *watchlistInterval <= 0 {
+			log.Fatalf("Invalid -watchlist-interval: %v (must be positive)", *watchlistInterval)
+		}
+		if len(cfg.Server.Namespaces) > 0 {
+			log.Fatalf("-watchlist cannot be combined with -namespaces")
+		}
+	}
+	cfg.App.Watchlist.Interval = *watchlistInterval
+	if _, err := types.ParseCrossedPolicy(*crossedPolicy); err != nil {
+		log.Fatalf("Invalid -crossed-policy: %v", err)
+	}
+	cfg.App.CrossedPolicy = *crossedPolicy
+	cfg.App.GapResyncCooldown = *gapResyncCooldown
+	if *ladderBuckets < 1 {
+		log.Fatalf("Invalid -ladder-buckets: %d (at least 1)", *ladderBuckets)
+	}
+	cfg.Server.LadderBuckets = *ladderBuckets
+	if *depthMaxDistance < 0 || *depthMinQuantity < 0 {
+		log.Fatalf("Invalid -depth-max-distance or -depth-min-quantity: must not be negative")
+	}
+	cfg.Server.DepthMaxDistancePct = *depthMaxDistance
+	cfg.Server.DepthMinQuantity = *depthMinQuantity
+	if *tailDistance < 0 {
+		log.Fatalf("Invalid -tail-distance: %v (must not be negative)", *tailDistance)
+	}
+	cfg.Server.TailDistancePct = *tailDistance
+	outputFormat, err := display.ParseFormat(*output)
+	if err != nil {
+		log.Fatalf("Invalid -output: %v", err)
+	}
+	cfg.Display.Output = *output
+	if _, err := types.ParseQuantityUnit(*quantityUnit); err != nil {
+		log.Fatalf("Invalid -quantity-unit: %v", err)
+	}
+	cfg.App.QuantityUnit = *quantityUnit
+	cfg.App.FixedPoint = *fixedPoint
+	cfg.App.Shards = *shards
+	cfg.App.ShardQueueSize = *shardQueue
+	cfg.App.ShardLockThreads = *shardLockThreads
+	cfg.App.StatsInterval = *statsInterval
+	cfg.App.SnapshotInterval = *snapshotInterval
+	cfg.App.SnapshotTimeout = *snapshotTimeout
+	cfg.App.SnapshotAttempts = *snapshotAttempts
+	cfg.App.Summary.Interval = *summaryInterval
+	cfg.App.Summary.File = *summaryFile
+	if *reportKeep < 1 {
+		log.Fatalf("Invalid -report-keep: %d (at least 1)", *reportKeep)
+	}
+	cfg.App.Report.Dir = *reportDir
+	cfg.App.Report.Keep = *reportKeep
+	cfg.App.Pipeline.Scripts = *scripts
+	cfg.App.Tracing.Exporter = *tracingExporter
+	cfg.App.Tracing.Endpoint = *tracingEndpoint
+	cfg.App.Tracing.SampleRatio = *tracingSample
+	cfg.App.Anomaly.ZScore = *anomalyZScore
+	cfg.App.Warmup.Duration = *warmup
+	cfg.App.Warmup.Events = *warmupEvents
+	cfg.App.OpenInterest.Interval = *oiInterval
+	cfg.App.OpenInterest.Window = *oiWindow
+	cfg.App.Transport.DialTimeout = *dialTimeout
+	cfg.App.Transport.DNSCacheTTL = *dnsCacheTTL
+	cfg.App.Transport.MaxConnsPerHost = *maxConnsPerHost
+	cfg.App.Transport.HappyEyeballs = *happyEyeballs
+	cfg.App.Journal.File = *journalFile
+	cfg.App.Journal.Interval = *journalInterval
+	cfg.App.Journal.MaxAge = *journalMaxAge
+	cfg.App.Distributed.Aggregator = *aggregator
+	cfg.App.Distributed.Collectors = *collectors
+	cfg.App.Distributed.CollectorID = *collectorID
+
+	// Share one dialer, DNS cache and connection pool between the exchange adapters
+	transport.SetDefault(transport.New(transport.Config{
+		DialTimeout:     cfg.App.Transport.DialTimeout,
+		DNSCacheTTL:     cfg.App.Transport.DNSCacheTTL,
+		MaxConnsPerHost: cfg.App.Transport.MaxConnsPerHost,
+		HappyEyeballs:   cfg.App.Transport.HappyEyeballs,
+	}))
+
+	// Profiling endpoints for live performance investigations
+	if *debugAddr != "" {
+		go func() {
+			if err := diagnostics.Serve(*debugAddr); err != nil {
+				log.Printf("Debug listener stopped: %v", err)
+			}
+		}()
+		log.Printf("Debug endpoints on http://%s/debug/", *debugAddr)
+	}
 
 	// Set up signal handling
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
 
+	// A collector only streams its exchanges to the aggregator, which serves the books
+	if cfg.App.Distributed.Aggregator != "" {
+		runCollector(cfg, names, interrupt)
+		return
+	}
+
 	log.Printf("Starting multi-exchange orderbook monitor for %s", *symbol)
 	log.Printf("Log interval: %v", *logInterval)
 
-	runMultiExchange(*symbol, *logInterval, interrupt)
+	store, err := storage.New(storage.Config{
+		Driver: storage.Driver(*storageDriver),
+		DSN:    *storageDSN,
+	})
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	if store != nil {
+		log.Printf("Recording to %s storage", *storageDriver)
+		defer store.Close()
+	} else if *snapshotInterval > 0 {
+		log.Printf("Warning: -snapshot-interval has no effect without -storage")
+	}
+
+	var recorder *websocket.Recorder
+	if *record != "" {
+		if *recordChunked {
+			recorder, err = websocket.NewChunkedRecorder(*record, websocket.DefaultChunkSize)
+		} else {
+			recorder, err = websocket.NewRecorder(*record)
+		}
+		if err != nil {
+			log.Fatalf("Failed to open recording: %v", err)
+		}
+		log.Printf("Recording WebSocket broadcasts to %s", *record)
+		defer func() {
+			if err := recorder.Close(); err != nil {
+				log.Printf("Failed to close recording: %v", err)
+			}
+		}()
+	}
+
+	// Trace sampled updates from receipt to publication
+	exporter, err := tracing.NewExporter(cfg.App.Tracing.Exporter, cfg.App.Tracing.Service, cfg.App.Tracing.Endpoint, os.Stderr)
+	if err != nil {
+		log.Fatalf("Invalid -tracing: %v", err)
+	}
+	if exporter != nil {
+		tracer := tracing.NewTracer(cfg.App.Tracing.SampleRatio, exporter)
+		tracing.SetTracer(tracer)
+		defer tracer.Stop()
+		log.Printf("Tracing %g of updates to %s", cfg.App.Tracing.SampleRatio, cfg.App.Tracing.Exporter)
+	}
+
+	opts := runOptions{
+		cfg:           cfg,
+		exchanges:     names,
+		logInterval:   *logInterval,
+		display:       display.Options{Format: outputFormat, Compact: *compact, Color: display.ColorEnabled()},
+		store:         store,
+		fixedPoint:    *fixedPoint,
+		statsInterval: *statsInterval,
+		recorder:      recorder,
+		port:          *port,
+		listeners:     listeners,
+		adminToken:    *adminToken,
+		keepSymbol:    symbolSet,
+	}
+	if len(cfg.Server.Namespaces) > 0 {
+		runNamespaces(opts, interrupt)
+		return
+	}
+	initialSymbol := *symbol
+	if len(cfg.App.Watchlist.Symbols) > 0 && !symbolSet {
+		initialSymbol = cfg.App.Watchlist.Symbols[0]
+	}
+	runMultiExchange(initialSymbol, opts, interrupt)
+}
+
+// runCollector streams the exchanges to the aggregator until interrupted
+func runCollector(cfg config.Config, names []exchange.ExchangeName, interrupt chan os.Signal) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		distributed.RunCollector(ctx, distributed.CollectorConfig{
+			URL:       cfg.App.Distributed.Aggregator,
+			ID:        cfg.App.Distributed.CollectorID,
+			Exchanges: names,
+		})
+	}()
+	log.Printf("Collecting %s for the aggregator at %s", joinExchangeNames(names), cfg.App.Distributed.Aggregator)
+
+	<-interrupt
+	log.Println("Interrupt received, shutting down...")
+	cancel()
+	<-done
+	log.Println("Collector stopped. Goodbye!")
+}
+
+// runOptions holds the command line options shared by all exchange goroutines
+type runOptions struct {
+	cfg           config.Config
+	exchanges     []exchange.ExchangeName
+	logInterval   time.Duration
+	display       display.Options
+	store         storage.Storage
+	fixedPoint    bool
+	statsInterval time.Duration
+	recorder      *websocket.Recorder
+	port          string
+	listeners     []websocket.Listener
+	session       *analytics.SessionTracker
+	reports       *reporting.Tracker                                      // Daily SLA reports of every exchange
+	openInterest  *analytics.OpenInterestTracker                          // Open interest change of every venue
+	converter     *conversion.Converter                                   // Normalizes books quoted in other currencies, nil when disabled
+	adminToken    string                                                  // Enables the admin endpoints when set
+	control       *exchangeControl                                        // Running exchanges, acted on by resync requests and the admin endpoints
+	server        *websocket.Server                                       // Publishes the level changes of every book
+	pipeline      *pipeline.Pipeline                                      // Feeds the changes and stats of every book to the analytics processors
+	pool          *shard.Pool                                             // Workers applying the updates of every exchange
+	namespace     string                                                  // Name of the monitor when several are served, empty otherwise
+	namespaces    *websocket.Namespaces                                   // Serves the monitor under its namespace instead of on its own listeners
+	journal       *journal.Journal                                        // Checkpoints the books for the next run, nil when disabled
+	newExchange   func(factory.ExchangeConfig) (exchange.Exchange, error) // Creates the exchanges, run locally or by collectors
+	keepSymbol    bool                                                    // The symbol was given explicitly and is not restored from the journal
+}
+
+// runNamespaces runs one monitor per configured namespace, each with its own books,
+// exchanges and clients, served from shared listeners until interrupted
+func runNamespaces(opts runOptions, interrupt chan os.Signal) {
+	namespaces := websocket.NewNamespaces(opts.port, opts.listeners)
+	namespaces.SetDefault(opts.cfg.Server.Namespaces[0].Name)
+
+	var wg sync.WaitGroup
+	interrupts := make([]chan os.Signal, len(opts.cfg.Server.Namespaces))
+	for i, ns := range opts.cfg.Server.Namespaces {
+		nsOpts := opts
+		nsOpts.cfg = opts.cfg.ForNamespace(ns)
+		nsOpts.cfg.App.Summary.File = namespaceFile(opts.cfg.App.Summary.File, ns.Name)
+		nsOpts.cfg.App.Journal.File = namespaceFile(opts.cfg.App.Journal.File, ns.Name)
+		nsOpts.keepSymbol = true
+		nsOpts.exchanges = nsOpts.cfg.ExchangeNames()
+		nsOpts.namespace = ns.Name
+		nsOpts.namespaces = namespaces
+		if i > 0 {
+			nsOpts.recorder = nil // A recording replays a single monitor, the default one
+		}
+		interrupts[i] = make(chan os.Signal, 1)
+		log.Printf("Namespace %s: %s on %s", ns.Name, ns.Symbol, joinExchangeNames(nsOpts.exchanges))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runMultiExchange(ns.Symbol, nsOpts, interrupts[i])
+		}()
+	}
+	go func() {
+		if err := namespaces.Start(); err != nil {
+			log.Fatalf("WebSocket server error: %v", err)
+		}
+	}()
+
+	sig := <-interrupt
+	for _, ch := range interrupts {
+		ch <- sig
+	}
+	wg.Wait()
+}
+
+// namespaceFile returns path with the namespace inserted before its extension, so
+// monitors do not overwrite each other's files
+func namespaceFile(path, namespace string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + namespace + ext
+}
+
+// runHealthcheck probes the health endpoint of a local instance on its first
+// listener, or on port without listeners, and returns the process exit code
+func runHealthcheck(port string, listeners []websocket.Listener) int {
+	client := http.Client{Timeout: 3 * time.Second}
+	url := "http://127.0.0.1:" + port + "/health"
+	if len(listeners) > 0 {
+		listener := listeners[0]
+		if listener.Network == "unix" {
+			client.Transport = &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", listener.Address)
+				},
+			}
+			url = "http://unix/health"
+		} else {
+			url = "http://" + loopbackAddress(listener.Address) + "/health"
+		}
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "healthcheck failed: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "healthcheck failed: %s\n", resp.Status)
+		return 1
+	}
+	return 0
+}
+
+// loopbackAddress replaces the wildcard host of a TCP listen address with the
+// loopback address, e.g. ":8086" becomes "127.0.0.1:8086"
+func loopbackAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// joinExchangeNames formats exchange names as a comma-separated list
+func joinExchangeNames(names []exchange.ExchangeName) string {
+	items := make([]string, len(names))
+	for i, name := range names {
+		items[i] = string(name)
+	}
+	return strings.Join(items, ",")
+}
+
+// listenerFlags collects repeated -listen flags
+type listenerFlags []websocket.Listener
+
+func (f *listenerFlags) String() string {
+	return fmt.Sprint([]websocket.Listener(*f))
+}
+
+func (f *listenerFlags) Set(spec string) error {
+	listener, err := websocket.ParseListener(spec)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, listener)
+	return nil
 }
 
 type orderbookWithName struct {
 	name string
 	ob   *orderbook.OrderBook
+	ex   exchange.Exchange
+	view types.Display // Renders the book's stats on the console
 }
 
-const (
-	colorReset   = "\033[0m"
-	colorYellow  = "\033[33m"
-	colorGreen   = "\033[32m"
-	colorRed     = "\033[31m"
-	colorMagenta = "\033[35m"
-	colorBold    = "\033[1m"
-)
-
 func getExchangeNames() []exchange.ExchangeName {
 	return []exchange.ExchangeName{
 		exchange.Binancef,
+		exchange.Binancefc,
 		exchange.Binance,
 		exchange.Bybitf,
 		exchange.Bybit,
 		exchange.Kraken,
+		exchange.Krakenf,
 		exchange.OKX,
 		exchange.Coinbase,
+		exchange.Coinbasef,
 		exchange.Asterdexf,
+		exchange.Asterdex,
 		exchange.BingX,
 		exchange.Hyperliquidf,
 	}
 }
 
-func runMultiExchange(initialSymbol string, logInterval time.Duration, interrupt chan os.Signal) {
-	ctx := context.Background()
-	orderbooksMap := make(map[string]*orderbook.OrderBook)
-	var obMutex sync.Mutex
+func runMultiExchange(initialSymbol string, opts runOptions, interrupt chan os.Signal) {
+	// Cancelled on return to stop the analytics loops and the quote rate feed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	books := orderbook.NewBookRegistry()
 	symbolChange := make(chan string, 1)
 	currentSymbol := initialSymbol
 
 	// Start WebSocket server
-	wsServer := websocket.NewServer(orderbooksMap, "8086", symbolChange)
-	go func() {
-		if err := wsServer.Start(); err != nil {
-			log.Fatalf("WebSocket server error: %v", err)
+	wsServer := websocket.NewServer(books, opts.port, symbolChange)
+	if opts.recorder != nil {
+		wsServer.SetRecorder(opts.recorder)
+	}
+	fees := make(map[string]types.FeeSchedule, len(opts.cfg.App.Fees))
+	for name, fee := range opts.cfg.App.Fees {
+		fees[string(name)] = fee
+	}
+	wsServer.SetFees(fees)
+	wsServer.SetFeeAdjusted(opts.cfg.App.FeeAdjusted)
+	contractSizes := make(map[string]decimal.Decimal)
+	for _, ex := range opts.cfg.Exchanges {
+		if ex.ContractSize > 0 {
+			contractSizes[string(ex.Name)] = decimal.NewFromFloat(ex.ContractSize)
 		}
-	}()
+	}
+	wsServer.SetContractSizes(contractSizes)
+	wsServer.SetQuantityUnit(types.QuantityUnit(opts.cfg.App.QuantityUnit))
+	wsServer.SetLadderBuckets(opts.cfg.Server.LadderBuckets)
+	wsServer.SetDepthFilter(orderbook.FilterConfig{
+		MaxDistancePct: opts.cfg.Server.DepthMaxDistancePct,
+		MinQuantity:    opts.cfg.Server.DepthMinQuantity,
+	})
+	wsServer.SetTailBucket(opts.cfg.Server.TailDistancePct)
+	if opts.namespaces == nil {
+		for _, listener := range opts.listeners {
+			wsServer.AddListener(listener)
+		}
+	}
+	opts.control = newExchangeControl()
+	wsServer.SetExchangeControl(opts.control)
+	opts.server = wsServer
+	opts.pool = shard.New(shard.Config{
+		Shards:      opts.cfg.App.Shards,
+		QueueSize:   opts.cfg.App.ShardQueueSize,
+		LockThreads: opts.cfg.App.ShardLockThreads,
+	})
+	opts.pool.Start()
+	wsServer.SetShardPool(opts.pool)
+
+	// Run the exchange adapters in-process, or take the exchanges from collectors
+	opts.newExchange = factory.NewExchange
+	if distributedCfg := opts.cfg.App.Distributed; distributedCfg.Collectors {
+		hub := distributed.NewHub(distributedCfg.ConnectTimeout)
+		wsServer.SetCollectors(hub)
+		opts.newExchange = hub.Exchange
+		log.Printf("Accepting collectors on /collect of control listeners")
+	}
+
+	// Resume the symbol and books of the previous run from the journal
+	if path := opts.cfg.App.Journal.File; path != "" {
+		j, err := journal.Open(path)
+		if err != nil {
+			log.Fatalf("Failed to open journal: %v", err)
+		}
+		defer j.Close()
+		opts.journal = j
+		if state, ok := j.State(); ok && !opts.keepSymbol && state.Symbol != "" && state.Symbol != currentSymbol {
+			log.Printf("Restoring symbol %s from the journal", state.Symbol)
+			currentSymbol = state.Symbol
+		}
+		log.Printf("Journaling book checkpoints to %s every %v", path, opts.cfg.App.Journal.Interval)
+	}
+	if opts.adminToken != "" {
+		wsServer.SetAdmin(opts.adminToken)
+		log.Printf("Admin endpoints enabled under /admin/")
+	}
+
+	// Normalize books quoted in other currencies (e.g., USD) to the common quote (e.g., USDT)
+	if rate := opts.cfg.App.QuoteRate; rate.Exchange != "" {
+		opts.converter = conversion.NewConverter(types.BaseAsset(rate.Symbol))
+		wsServer.SetConverter(opts.converter)
+		go runQuoteRate(ctx, opts.cfg, opts.converter)
+	}
+	if opts.namespaces != nil {
+		if err := opts.namespaces.Add(opts.namespace, wsServer); err != nil {
+			log.Fatalf("Failed to serve namespace %s: %v", opts.namespace, err)
+		}
+	} else {
+		go func() {
+			if err := wsServer.Start(); err != nil {
+				log.Fatalf("WebSocket server error: %v", err)
+			}
+		}()
+	}
+
+	// Start cross-venue lead-lag analysis
+	leadLagCfg := opts.cfg.App.LeadLag
+	leadLag := analytics.NewLeadLagDetector(analytics.LeadLagConfig{
+		SampleInterval: leadLagCfg.SampleInterval,
+		Window:         leadLagCfg.Window,
+		MaxLag:         leadLagCfg.MaxLag,
+	})
+	go runLeadLag(ctx, leadLag, leadLagCfg, books, wsServer, opts.converter)
+
+	// Start fair value deviation monitoring
+	fairValueCfg := opts.cfg.App.FairValue
+	fairValue := analytics.NewFairValueMonitor(fairValueCfg.ThresholdBps, fairValueCfg.MinVenues)
+	go runFairValue(ctx, fairValue, fairValueCfg, books, opts.converter)
+
+	// Start composite liquidity ranking
+	scoreCfg := opts.cfg.App.LiquidityScore
+	scorer := analytics.NewLiquidityScorer(analytics.LiquidityScoreConfig{
+		Weights: analytics.LiquidityWeights{
+			Spread:    scoreCfg.SpreadWeight,
+			Depth05:   scoreCfg.Depth05Weight,
+			Depth2:    scoreCfg.Depth2Weight,
+			Uptime:    scoreCfg.UptimeWeight,
+			Staleness: scoreCfg.StalenessWeight,
+		},
+		UptimeHorizon: scoreCfg.UptimeHorizon,
+		MaxStaleness:  scoreCfg.MaxStaleness,
+	})
+	go runLiquidityScore(ctx, scorer, scoreCfg, books, wsServer)
+
+	// Build price candles from the books
+	candleCfg := opts.cfg.App.Candles
+	candles := analytics.NewCandleBuilder(analytics.CandleConfig{
+		Intervals:  candleCfg.Intervals,
+		History:    candleCfg.History,
+		Microprice: candleCfg.Microprice,
+	})
+	wsServer.SetCandles(candles)
+
+	// Track the daily SLA of every exchange
+	opts.reports = reporting.NewTracker(opts.cfg.App.Report.Keep)
+	wsServer.SetReports(opts.reports)
+	go runCandles(ctx, candles, candleCfg, books, wsServer, opts.converter)
+
+	// Run the analytics processors fed with the changes and stats of every book
+	pipelineCfg := opts.cfg.App.Pipeline
+	opts.pipeline = pipeline.New(pipelineCfg.QueueSize, func(output interface{}) {
+		publishAnalytics(wsServer, output)
+	})
+
+	// Flag levels repeatedly refilled at the touch
+	icebergCfg := opts.cfg.App.Iceberg
+	icebergs := analytics.NewIcebergDetector(analytics.IcebergConfig{
+		NearTouchBps:  icebergCfg.NearTouchBps,
+		RefillWindow:  icebergCfg.RefillWindow,
+		SizeTolerance: icebergCfg.SizeTolerance,
+		MinRefills:    icebergCfg.MinRefills,
+	})
+	if err := opts.pipeline.Register(pipeline.NewIcebergProcessor(icebergs)); err != nil {
+		log.Fatalf("Failed to register iceberg processor: %v", err)
+	}
+
+	// Measure how quickly books replenish after sweeps
+	resilienceCfg := opts.cfg.App.Resilience
+	resilience := analytics.NewResilienceTracker(analytics.ResilienceConfig{
+		MinSweepNotional: resilienceCfg.MinSweepNotional,
+		BandBps:          resilienceCfg.BandBps,
+		MaxWait:          resilienceCfg.MaxWait,
+		Window:           resilienceCfg.Window,
+	})
+	if err := opts.pipeline.Register(pipeline.NewResilienceProcessor(resilience)); err != nil {
+		log.Fatalf("Failed to register resilience processor: %v", err)
+	}
+	go runResilience(ctx, resilience, resilienceCfg, books)
+
+	// Flag spreads and depths far from their recent distribution
+	if anomalyCfg := opts.cfg.App.Anomaly; anomalyCfg.ZScore > 0 {
+		anomalies := analytics.NewAnomalyDetector(analytics.AnomalyConfig{
+			Window:     anomalyCfg.Window,
+			MinSamples: anomalyCfg.MinSamples,
+			ZScore:     anomalyCfg.ZScore,
+			Cooldown:   anomalyCfg.Cooldown,
+		})
+		if err := opts.pipeline.Register(pipeline.NewAnomalyProcessor(anomalies)); err != nil {
+			log.Fatalf("Failed to register anomaly processor: %v", err)
+		}
+	}
+
+	// Evaluate user scripts computing custom signals
+	if pipelineCfg.Scripts != "" {
+		scripts, err := scripting.Load(pipelineCfg.Scripts)
+		if err != nil {
+			log.Fatalf("Failed to load scripts: %v", err)
+		}
+		if err := opts.pipeline.Register(scripting.NewProcessor(scripts)); err != nil {
+			log.Fatalf("Failed to register script processor: %v", err)
+		}
+		log.Printf("Loaded %d scripts from %s", len(scripts), pipelineCfg.Scripts)
+	}
+
+	opts.pipeline.Start()
+	go runPipelineStats(ctx, opts.pipeline, pipelineCfg, books)
+
+	// Measure the open interest change of the polled venues
+	opts.openInterest = analytics.NewOpenInterestTracker(opts.cfg.App.OpenInterest.Window)
+
+	// Summarize the session periodically and on exit
+	opts.session = analytics.NewSessionTracker(time.Now())
+	if opts.cfg.App.Summary.Interval > 0 {
+		go func() {
+			ticker := time.NewTicker(opts.cfg.App.Summary.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					reportSession(opts.session, opts.cfg.App.Summary.File)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
 
 	// Main loop to handle symbol changes
 	for {
 		log.Printf("Starting exchanges for symbol: %s", currentSymbol)
+		wsServer.SetTickLevels(resolveTickLevels(ctx, opts, currentSymbol))
+		if opts.journal != nil {
+			if err := opts.journal.WriteState(journal.State{Symbol: currentSymbol, Time: time.Now()}); err != nil {
+				log.Printf("Failed to journal state: %v", err)
+			}
+		}
 
 		// Start all exchanges with current symbol
 		done := make(chan struct{})
 		exchangesDone := make(chan struct{})
 
 		go func() {
-			startExchangesForSymbol(ctx, currentSymbol, orderbooksMap, &obMutex, logInterval, done, interrupt)
+			startExchangesForSymbol(ctx, currentSymbol, books, opts, done, interrupt)
 			close(exchangesDone)
 		}()
 
+		// The watchlist moves on to its next symbol unless the symbol is changed first
+		var rotate <-chan time.Time
+		if len(opts.cfg.App.Watchlist.Symbols) > 0 {
+			rotate = time.After(opts.cfg.App.Watchlist.Interval)
+		}
+
 		// Wait for either symbol change or interrupt
+		var newSymbol string
 		select {
-		case newSymbol := <-symbolChange:
+		case newSymbol = <-symbolChange:
 			log.Printf("Symbol change requested: %s -> %s", currentSymbol, newSymbol)
-			currentSymbol = newSymbol
-
-			// Signal exchanges to stop
-			close(done)
 
-			// Wait for all exchanges to cleanly shut down
-			<-exchangesDone
-
-			// Clear orderbooks map
-			obMutex.Lock()
-			for k := range orderbooksMap {
-				delete(orderbooksMap, k)
-			}
-			obMutex.Unlock()
-
-			log.Printf("All exchanges stopped. Restarting with symbol: %s", currentSymbol)
-			time.Sleep(500 * time.Millisecond)
+		case <-rotate:
+			newSymbol = nextWatchlistSymbol(opts.cfg.App.Watchlist.Symbols, currentSymbol)
+			log.Printf("Watchlist rotation: %s -> %s", currentSymbol, newSymbol)
 
 		case <-interrupt:
 			log.Println("Interrupt received, shutting down...")
 			close(done)
 			<-exchangesDone
+			reportSession(opts.session, opts.cfg.App.Summary.File)
+			if report, ok := opts.reports.Current(); ok {
+				writeReport(report, opts.cfg.App.Report.Dir)
+			}
 			log.Println("All exchanges closed. Goodbye!")
 			return
 		}
+		currentSymbol = newSymbol
+
+		// Signal exchanges to stop
+		close(done)
+
+		// Wait for all exchanges to cleanly shut down
+		<-exchangesDone
+
+		// Drop the books left by the previous symbol
+		books.Clear()
+		leadLag.Reset()
+		fairValue.Reset()
+		candles.Reset()
+		opts.pipeline.Reset()
+		opts.openInterest.Reset()
+
+		log.Printf("All exchanges stopped. Restarting with symbol: %s", currentSymbol)
+		time.Sleep(500 * time.Millisecond)
 	}
 }
 
-func startExchangesForSymbol(ctx context.Context, symbol string, orderbooksMap map[string]*orderbook.OrderBook, obMutex *sync.Mutex, logInterval time.Duration, done chan struct{}, interrupt chan os.Signal) {
-	cfg := config.NewMultiExchange(buildExchangeConfigs(symbol))
+// nextWatchlistSymbol returns the watchlist symbol after current, the first one
+// when current is not on the watchlist
+func nextWatchlistSymbol(watchlist []string, current string) string {
+	return watchlist[(slices.Index(watchlist, current)+1)%len(watchlist)]
+}
+
+func startExchangesForSymbol(ctx context.Context, symbol string, books *orderbook.BookRegistry, opts runOptions, done chan struct{}, interrupt chan os.Signal) {
+	cfg := opts.cfg
+	cfg.Exchanges = opts.cfg.ExchangesForSymbol(symbol)
 
 	var wg sync.WaitGroup
+	var obMutex sync.Mutex // Guards orderbooks
 	orderbooks := make([]*orderbookWithName, 0, len(cfg.Exchanges))
+	console := display.NewConsole(os.Stdout, opts.display)
+	startup := newStartupReport(symbol, len(cfg.Exchanges))
 
 	// Create an orderbook for each exchange
 	for _, exConfig := range cfg.Exchanges {
@@ -138,60 +895,227 @@ func startExchangesForSymbol(ctx context.Context, symbol string, orderbooksMap m
 
 			log.Printf("[%s] Starting connection...", exCfg.Name)
 
+			// Announce lifecycle changes; nothing is published once the feed stopped
+			var stopped atomic.Bool
+			publishStatus := func(status websocket.ExchangeStatus, detail string) {
+				if !stopped.Load() {
+					opts.server.PublishExchangeStatus(string(exCfg.Name), exCfg.Symbol, status, detail)
+				}
+			}
+			fail := func(err error) {
+				startup.record(exCfg.Name, err)
+				publishStatus(websocket.StatusDisconnected, err.Error())
+			}
+
 			// Create exchange-specific orderbook
 			ob := orderbook.New()
+			if err := ob.SetSpreadHorizons(cfg.App.SpreadHorizons, cfg.App.SpreadWindow); err != nil {
+				log.Printf("[%s] Invalid spread horizons: %v", exCfg.Name, err)
+				startup.record(exCfg.Name, err)
+				return
+			}
+			if err := ob.SetAverageWindows(cfg.App.AverageWindows); err != nil {
+				log.Printf("[%s] Invalid average windows: %v", exCfg.Name, err)
+				startup.record(exCfg.Name, err)
+				return
+			}
+			ob.SetPruneConfig(orderbook.PruneConfig{
+				MaxDistancePct: cfg.App.PruneMaxDistancePct,
+				MaxLevels:      cfg.App.PruneMaxLevels,
+			})
+			ob.SetStatsInterval(opts.statsInterval)
+			ob.SetWarmup(orderbook.WarmupConfig{
+				Duration: cfg.App.Warmup.Duration,
+				Events:   cfg.App.Warmup.Events,
+			})
+			ob.SetFilters(orderbook.NewFilters(orderbook.FilterConfig{
+				MaxDistancePct: cfg.App.FilterMaxDistancePct,
+				MinQuantity:    cfg.App.FilterMinQuantity,
+			})...)
+			ob.SetCrossedPolicy(types.CrossedPolicy(cfg.App.CrossedPolicy))
+			ob.SetMaxAge(exCfg.MaxBookAge)
+			ob.SetReinitConfig(orderbook.ReinitConfig{
+				CheckInterval: cfg.ReinitIntervalFor(exCfg),
+				BufferSize:    cfg.ReinitBufferFor(exCfg),
+			})
+			ob.SetDeltaHandler(func(delta orderbook.BookDelta) {
+				opts.server.PublishBookDelta(string(exCfg.Name), delta)
+				opts.pipeline.Delta(string(exCfg.Name), delta)
+			})
+			ob.SetCrossedHandler(func(event orderbook.CrossedBook) {
+				log.Printf("[%s] Warning: crossed book, bid %s >= ask %s (%s, %d levels removed)",
+					exCfg.Name, event.BestBid, event.BestAsk, event.Policy, event.Removed)
+			})
+			// Definitive sequence gaps are served by the reinitialization loop right away
+			gapResync := make(chan struct{}, 1)
+			ob.SetGapResync(cfg.App.GapResyncCooldown, func() {
+				select {
+				case gapResync <- struct{}{}:
+				default:
+				}
+			})
 
 			// Create exchange instance
-			ex, err := factory.NewExchange(factory.ExchangeConfig{
-				Name:   exCfg.Name,
-				Symbol: exCfg.Symbol,
+			ex, err := opts.newExchange(factory.ExchangeConfig{
+				Name:        exCfg.Name,
+				Symbol:      exCfg.Symbol,
+				Depth:       exCfg.Depth,
+				UpdateSpeed: exCfg.UpdateSpeed,
+				Category:    exCfg.Category,
+				SigFigs:     exCfg.SigFigs,
+				Mantissa:    exCfg.Mantissa,
+				Instrument:  exCfg.Instrument,
+				TopOfBook:   exCfg.TopOfBook,
 			})
 			if err != nil {
 				log.Printf("[%s] Failed to create exchange: %v", exCfg.Name, err)
+				startup.record(exCfg.Name, err)
 				return
 			}
 
+			// Adapt update handling to how the venue delivers the book
+			ob.SetCapabilities(ex.Capabilities())
+
+			// Record the quote of the book so its prices can be normalized
+			if opts.converter != nil {
+				opts.converter.SetQuote(string(exCfg.Name), quoteCurrency(ex, exCfg.Symbol))
+			}
+
+			// Reconnect when the venue stops sending data without closing the socket
+			if watcher, ok := ex.(exchange.StallWatcher); ok {
+				watcher.SetStaleTimeout(cfg.StaleTimeoutFor(exCfg))
+			}
+
+			// Switch to the fixed-point engine when the instrument precision is known
+			if opts.fixedPoint {
+				enableFixedPoint(ctx, ex, ob)
+			}
+
 			// Connect
+			publishStatus(websocket.StatusConnecting, "")
 			if err := ex.Connect(ctx); err != nil {
 				log.Printf("[%s] Failed to connect: %v", exCfg.Name, err)
+				fail(err)
 				return
 			}
 			defer ex.Close()
+			publishStatus(websocket.StatusConnected, "")
 
-			// Get snapshot
-			snapshot, err := ex.GetSnapshot(ctx)
-			if err != nil {
-				log.Printf("[%s] Failed to get snapshot: %v", exCfg.Name, err)
-				return
-			}
-
-			if err := ob.LoadSnapshot(snapshot); err != nil {
-				log.Printf("[%s] Failed to load snapshot: %v", exCfg.Name, err)
-				return
-			}
+			// Accept admin actions on this exchange
+			running := opts.control.register(string(exCfg.Name), ex)
+			defer opts.control.unregister(string(exCfg.Name), running)
 
-			// Process updates in background
+			// Process updates on the shard the exchange is pinned to. Updates received
+			// before the book is loaded are buffered by it.
+			lane := opts.pool.Assign(string(exCfg.Name)+":"+exCfg.Symbol, func(update *exchange.DepthUpdate, last bool) {
+				applyUpdate(ob, update, last)
+			})
 			updatesDone := make(chan struct{})
 			go func() {
 				defer close(updatesDone)
-				for update := range ex.Updates() {
-					ob.HandleDepthUpdate(update)
+				defer opts.pool.Release(lane)
+				updates := ex.Updates()
+				for update := range updates {
+					lane.Submit(update, len(updates) == 0)
 				}
 			}()
 
-			// Reinitialization check
+			// Serve the book checkpointed by the previous run while it catches up
+			key := orderbook.BookKey{Exchange: string(exCfg.Name), Symbol: symbol}
+			snapshotPolicy := cfg.SnapshotPolicyFor(exCfg)
+			resumed := false
+			if checkpoint := resumeCheckpoint(opts.journal, cfg.App.Journal, exCfg.Name, symbol); checkpoint != nil {
+				placeholder := orderbook.New()
+				if err := placeholder.Restore(checkpoint); err != nil {
+					log.Printf("[%s] Failed to resume from checkpoint: %v", exCfg.Name, err)
+				} else {
+					log.Printf("[%s] Resumed from checkpoint of %v ago (lastUpdateId=%d), catching up",
+						exCfg.Name, time.Since(checkpoint.Timestamp).Round(time.Second), checkpoint.LastUpdateID)
+					books.Set(key, placeholder)
+					defer books.Delete(key)
+					publishStatus(websocket.StatusResyncing, "resumed from checkpoint")
+					resumed = catchUp(ctx, exCfg.Name, ex, ob, checkpoint, snapshotPolicy)
+				}
+			}
+
+			// Get snapshot unless the checkpoint was caught up
+			// Retry with backoff so a transient failure does not drop the exchange
+			if !resumed {
+				snapshot, err := exchange.FetchSnapshot(ctx, exCfg.Name, snapshotPolicy, ex.GetSnapshot)
+				if err != nil {
+					log.Printf("[%s] Failed to get snapshot: %v", exCfg.Name, err)
+					fail(err)
+					return
+				}
+
+				if err := ob.LoadSnapshot(snapshot); err != nil {
+					log.Printf("[%s] Failed to load snapshot: %v", exCfg.Name, err)
+					fail(err)
+					return
+				}
+				recordSnapshot(ctx, opts.store, snapshot)
+			}
+
+			// Reinitialization check and memory pruning
 			go func() {
-				ticker := time.NewTicker(cfg.App.ReinitCheckInterval)
+				// The check interval can be changed at runtime through the admin API
+				checkInterval := ob.ReinitConfig().CheckInterval
+				ticker := time.NewTicker(checkInterval)
 				defer ticker.Stop()
+				pruneTicker := time.NewTicker(cfg.App.PruneInterval)
+				defer pruneTicker.Stop()
+				var statsTick <-chan time.Time // nil keeps stats synchronous with updates
+				if opts.statsInterval > 0 {
+					statsTicker := time.NewTicker(opts.statsInterval)
+					defer statsTicker.Stop()
+					statsTick = statsTicker.C
+				}
+				var lastReconnects int64
+				// Every reload of the book goes through getSnapshot
+				getSnapshot := func() (*exchange.Snapshot, error) {
+					publishStatus(websocket.StatusResyncing, "")
+					snapshot, err := exchange.FetchSnapshot(ctx, exCfg.Name, snapshotPolicy, ex.GetSnapshot)
+					if err == nil {
+						recordSnapshot(ctx, opts.store, snapshot)
+					}
+					return snapshot, err
+				}
 
 				for {
 					select {
+					case <-statsTick:
+						span := tracing.Start("stats")
+						span.SetAttribute("exchange", string(exCfg.Name))
+						ob.RefreshStats()
+						span.End()
+					case <-pruneTicker.C:
+						if pruned := ob.Prune(); pruned > 0 {
+							log.Printf("[%s] Pruned %d far-from-mid levels", exCfg.Name, pruned)
+						}
+					case <-running.resync:
+						log.Printf("[%s] Resync requested, reloading orderbook", exCfg.Name)
+						ob.Reinitialize(getSnapshot)
+					case <-gapResync:
+						ob.CheckAndReinitialize(getSnapshot)
+						publishStatus(feedStatus(ex, ob), "")
 					case <-ticker.C:
-						ob.CheckAndReinitialize(func() (*exchange.Snapshot, error) {
-							return ex.GetSnapshot(ctx)
-						})
+						// A reconnected stream no longer continues the loaded book
+						if reconnects := ex.Health().Reconnects; reconnects != lastReconnects {
+							lastReconnects = reconnects
+							log.Printf("[%s] Reconnected after stall, resyncing orderbook", exCfg.Name)
+							ob.Reinitialize(getSnapshot)
+						} else {
+							ob.CheckAndReinitialize(getSnapshot)
+						}
+						publishStatus(feedStatus(ex, ob), "")
+						if interval := ob.ReinitConfig().CheckInterval; interval > 0 && interval != checkInterval {
+							checkInterval = interval
+							ticker.Reset(interval)
+						}
 					case <-updatesDone:
 						return
+					case <-running.drop:
+						return
 					case <-done:
 						return
 					case <-interrupt:
@@ -200,45 +1124,85 @@ func startExchangesForSymbol(ctx context.Context, symbol string, orderbooksMap m
 				}
 			}()
 
-			ob.ProcessBufferedEvents()
+			if !resumed {
+				ob.ProcessBufferedEvents()
+			}
 			log.Printf("[%s] Orderbook initialized", exCfg.Name)
+			startup.record(exCfg.Name, nil)
+			publishStatus(websocket.StatusInitialized, "")
 
 			// Add orderbook to shared collections
 			obMutex.Lock()
 			orderbooks = append(orderbooks, &orderbookWithName{
 				name: string(exCfg.Name),
 				ob:   ob,
+				ex:   ex,
+				view: console.View(viewName(opts.namespace, exCfg.Name)),
 			})
-			orderbooksMap[string(exCfg.Name)] = ob
 			obMutex.Unlock()
+			published := ob
+			compositeStop := make(chan struct{})
+			defer close(compositeStop)
+			// An explicit instrument has no counterparts in other quotes
+			if len(cfg.App.Composite.Quotes) > 0 && exCfg.Instrument == "" {
+				// Publish the exchange's book merged with its books in the other quotes
+				published = startComposite(ctx, opts, exCfg, ob, quoteCurrency(ex, exCfg.Symbol), compositeStop)
+			}
+			books.Set(key, published)
+
+			// Poll the open interest of derivatives venues into the published stats
+			if provider, ok := ex.(exchange.OpenInterestProvider); ok && cfg.App.OpenInterest.Interval > 0 {
+				pollStop := make(chan struct{})
+				defer close(pollStop)
+				go pollOpenInterest(ctx, provider, published, opts.openInterest, string(exCfg.Name), cfg.App.OpenInterest.Interval, pollStop)
+			}
+
+			// Feed the venue's trades into the effective and realized spreads
+			if streamer, ok := ex.(exchange.TradeStreamer); ok && ex.Capabilities().Trades {
+				tradeStop := make(chan struct{})
+				defer close(tradeStop)
+				go recordTrades(streamer.Trades(), published, string(exCfg.Name), tradeStop)
+			}
 
 			// Wait for shutdown
+			reason := "shutdown"
 			select {
 			case <-updatesDone:
 				log.Printf("[%s] Connection closed", exCfg.Name)
+				reason = "connection closed"
+			case <-running.drop:
+				log.Printf("[%s] Dropped, disconnecting until the next symbol change", exCfg.Name)
+				reason = "dropped"
 			case <-done:
 				log.Printf("[%s] Shutting down...", exCfg.Name)
 			case <-interrupt:
 				log.Printf("[%s] Shutting down...", exCfg.Name)
 			}
+			publishStatus(websocket.StatusDisconnected, reason)
+			stopped.Store(true)
 
-			// Remove from map on shutdown
-			obMutex.Lock()
-			delete(orderbooksMap, string(exCfg.Name))
-			obMutex.Unlock()
+			// Remove from the registry on shutdown
+			books.Delete(key)
 		}(exConfig)
 	}
 
 	// Centralized logging ticker
 	go func() {
-		ticker := time.NewTicker(logInterval)
+		ticker := time.NewTicker(opts.logInterval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
 				obMutex.Lock()
-				printCombinedStats(orderbooks)
+				for _, obn := range orderbooks {
+					stats := obn.ob.GetStats()
+					obn.view.UpdateData(nil, nil, stats, obn.ob.IsReady(), stats.BufferedEvents)
+				}
+				console.Flush()
+				recordStats(ctx, opts.store, symbol, orderbooks)
+				sampleSession(opts.session, symbol, orderbooks)
+				sampleReports(opts.reports, opts.cfg.App.Report.Dir, symbol, orderbooks)
 				obMutex.Unlock()
 			case <-done:
 				return
@@ -248,77 +1212,687 @@ func startExchangesForSymbol(ctx context.Context, symbol string, orderbooksMap m
 		}
 	}()
 
+	// Clock-aligned snapshots, taken for every exchange at the same boundary
+	if opts.store != nil && cfg.App.SnapshotInterval > 0 {
+		go func() {
+			for {
+				at := nextAlignedTime(time.Now(), cfg.App.SnapshotInterval)
+				timer := time.NewTimer(time.Until(at))
+				select {
+				case <-timer.C:
+					obMutex.Lock()
+					recordAlignedSnapshots(ctx, opts.store, symbol, at, orderbooks)
+					obMutex.Unlock()
+				case <-done:
+					timer.Stop()
+					return
+				case <-interrupt:
+					timer.Stop()
+					return
+				}
+			}
+		}()
+	}
+
+	// Periodic checkpoints for the journal, which resumes the books after a restart
+	if opts.journal != nil {
+		go func() {
+			ticker := time.NewTicker(cfg.App.Journal.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					obMutex.Lock()
+					checkpointBooks(opts.journal, cfg.App.Journal, symbol, orderbooks)
+					obMutex.Unlock()
+				case <-done:
+					return
+				case <-interrupt:
+					return
+				}
+			}
+		}()
+	}
+
 	wg.Wait()
+
+	// Checkpoint the final state of the books so a restart loses as little as possible
+	if opts.journal != nil {
+		obMutex.Lock()
+		checkpointBooks(opts.journal, cfg.App.Journal, symbol, orderbooks)
+		obMutex.Unlock()
+	}
 }
 
-func buildExchangeConfigs(symbol string) []config.ExchangeConfig {
-	names := getExchangeNames()
-	configs := make([]config.ExchangeConfig, len(names))
-	for i, name := range names {
-		configs[i] = config.ExchangeConfig{
-			Name:   name,
-			Symbol: symbol,
+// runLeadLag samples venue mid prices and periodically publishes lead-lag reports
+func runLeadLag(ctx context.Context, detector *analytics.LeadLagDetector, cfg config.LeadLagConfig, books *orderbook.BookRegistry, wsServer *websocket.Server, converter *conversion.Converter) {
+	sampleTicker := time.NewTicker(cfg.SampleInterval)
+	defer sampleTicker.Stop()
+	publishTicker := time.NewTicker(cfg.PublishInterval)
+	defer publishTicker.Stop()
+
+	for {
+		select {
+		case <-sampleTicker.C:
+			mids := make(map[string]decimal.Decimal)
+			for _, entry := range books.List() {
+				name, ob := entry.Key.Exchange, entry.Book
+				if !ob.IsReady() {
+					continue
+				}
+				stats := ob.GetStats()
+				if stats.BestBid.IsZero() || stats.BestAsk.IsZero() {
+					continue
+				}
+				mids[name] = converter.Convert(name, stats.BestBid.Add(stats.BestAsk).Div(decimal.NewFromInt(2)))
+			}
+			detector.Sample(mids)
+
+		case <-publishTicker.C:
+			wsServer.Publish(websocket.NewLeadLagMessage(detector.Report()))
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runFairValue periodically compares each venue's mid with the fair value of the consolidated book
+func runFairValue(ctx context.Context, monitor *analytics.FairValueMonitor, cfg config.FairValueConfig, registry *orderbook.BookRegistry, converter *conversion.Converter) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			entries := registry.List()
+			books := make(map[string]*orderbook.OrderBook, len(entries))
+			for _, entry := range entries {
+				books[entry.Key.Exchange] = entry.Book
+			}
+
+			quotes := make([]analytics.VenueQuote, 0, len(books))
+			for name, ob := range books {
+				if !ob.IsReady() {
+					continue
+				}
+				stats := ob.GetStats()
+				if stats.BestBid.IsZero() || stats.BestAsk.IsZero() {
+					continue
+				}
+				mid := stats.BestBid.Add(stats.BestAsk).Div(decimal.NewFromInt(2))
+				band := mid.Mul(decimal.NewFromFloat(fairValueBand))
+				quotes = append(quotes, analytics.VenueQuote{
+					Venue: name,
+					Mid:   converter.Convert(name, mid),
+					Bids:  fairValueLevels(ob, orderbook.Bids, mid.Sub(band), decimal.Zero, name, converter),
+					Asks:  fairValueLevels(ob, orderbook.Asks, decimal.Zero, mid.Add(band), name, converter),
+				})
+			}
+
+			report, ok := monitor.Evaluate(quotes)
+			if !ok {
+				continue
+			}
+			for _, deviation := range report.Deviations {
+				if ob, exists := books[deviation.Venue]; exists {
+					ob.SetFairValueDeviation(report.FairValue, deviation.DeviationBps, deviation.Alert)
+				}
+			}
+		}
+	}
+}
+
+// fairValueBand is the distance from mid, as a fraction, of the levels each
+// venue contributes to the consolidated book of the fair value
+const fairValueBand = 0.005
+
+// fairValueLevels returns the levels of side priced within [from, to], converted to the common quote
+func fairValueLevels(ob *orderbook.OrderBook, side orderbook.Side, from, to decimal.Decimal, venue string, converter *conversion.Converter) []types.PriceLevel {
+	var levels []types.PriceLevel
+	ob.Range(side, from, to, func(level types.PriceLevel) bool {
+		levels = append(levels, types.PriceLevel{Price: converter.Convert(venue, level.Price), Quantity: level.Quantity})
+		return true
+	})
+	return levels
+}
+
+// runResilience periodically records each venue's resilience after sweeps in its book's stats
+func runResilience(ctx context.Context, tracker *analytics.ResilienceTracker, cfg config.ResilienceConfig, books *orderbook.BookRegistry) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, entry := range books.List() {
+				report := tracker.Report(entry.Key.Exchange, now)
+				entry.Book.SetResilience(report.Sweeps, report.Recovered, report.Median)
+			}
 		}
 	}
-	return configs
 }
 
-func printCombinedStats(orderbooks []*orderbookWithName) {
-	if len(orderbooks) == 0 {
+// pollOpenInterest records the venue's open interest and its change in the book's
+// stats on every interval until stop is closed. Venues without open interest stop
+// after the first poll.
+func pollOpenInterest(ctx context.Context, provider exchange.OpenInterestProvider, ob *orderbook.OrderBook, tracker *analytics.OpenInterestTracker, name string, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		openInterest, err := provider.GetOpenInterest(ctx)
+		switch {
+		case errors.Is(err, exchange.ErrNoOpenInterest):
+			return
+		case err != nil:
+			log.Printf("[%s] Failed to get open interest: %v", name, err)
+		default:
+			ob.SetOpenInterest(openInterest, tracker.Record(name, time.Now(), openInterest))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// recordTrades feeds trades into the book's spread estimator until stop is closed
+func recordTrades(trades <-chan *exchange.Trade, ob *orderbook.OrderBook, name string, stop <-chan struct{}) {
+	for {
+		select {
+		case trade := <-trades:
+			if err := ob.RecordTrade(trade); err != nil {
+				log.Printf("[%s] Failed to record trade: %v", name, err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// runLiquidityScore periodically ranks venues by composite liquidity score and publishes the ranking
+func runLiquidityScore(ctx context.Context, scorer *analytics.LiquidityScorer, cfg config.LiquidityScoreConfig, books *orderbook.BookRegistry, wsServer *websocket.Server) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			entries := books.List()
+			venues := make([]analytics.VenueLiquidity, 0, len(entries))
+			for _, entry := range entries {
+				name, ob := entry.Key.Exchange, entry.Book
+				if !ob.IsReady() {
+					continue
+				}
+				stats := ob.GetStats()
+				if stats.BestBid.IsZero() || stats.BestAsk.IsZero() {
+					continue
+				}
+				venue := analytics.VenueLiquidity{
+					Venue:   name,
+					Mid:     stats.BestBid.Add(stats.BestAsk).Div(decimal.NewFromInt(2)),
+					Spread:  stats.Spread,
+					Depth05: stats.BidLiquidity05Pct.Add(stats.AskLiquidity05Pct),
+					Depth2:  stats.BidLiquidity2Pct.Add(stats.AskLiquidity2Pct),
+					Uptime:  now.Sub(stats.ConnectionTime),
+				}
+				if !stats.LastEventTime.IsZero() {
+					venue.Staleness = now.Sub(stats.LastEventTime)
+				}
+				venues = append(venues, venue)
+			}
+
+			wsServer.PublishRanking(scorer.Rank(venues, now))
+		}
+	}
+}
+
+// runPipelineStats periodically passes the statistics of every book to the analytics processors
+func runPipelineStats(ctx context.Context, p *pipeline.Pipeline, cfg config.PipelineConfig, books *orderbook.BookRegistry) {
+	ticker := time.NewTicker(cfg.StatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, entry := range books.List() {
+				if entry.Book.IsReady() {
+					p.Stats(entry.Key.Exchange, entry.Book.GetStats())
+				}
+			}
+		}
+	}
+}
+
+// publishAnalytics logs and publishes the output of the analytics processors
+func publishAnalytics(wsServer *websocket.Server, output interface{}) {
+	switch out := output.(type) {
+	case analytics.IcebergSignal:
+		side := "ask"
+		if out.IsBid {
+			side = "bid"
+		}
+		log.Printf("[%s] Warning: probable iceberg %s at %s, refilled %d times to ~%s (%s added back)",
+			out.Venue, side, out.Price, out.Refills, out.Displayed, out.Refilled)
+		wsServer.Publish(websocket.NewIcebergMessage(out))
+	case analytics.AnomalySignal:
+		log.Printf("[%s] Warning: %s anomaly, %.4g against %.4g +/- %.4g (z-score %.1f)",
+			out.Venue, out.Metric, out.Value, out.Mean, out.StdDev, out.ZScore)
+		wsServer.Publish(websocket.NewAnomalyMessage(out))
+	case scripting.Signal:
+		log.Printf("[%s] Script %s: %g", out.Venue, out.Script, out.Value)
+		wsServer.Publish(websocket.NewSignalMessage(out))
+	}
+}
+
+// runCandles periodically samples the mid (and microprice) of every book into
+// candles and publishes the ones each sample closes
+func runCandles(ctx context.Context, builder *analytics.CandleBuilder, cfg config.CandleConfig, books *orderbook.BookRegistry, wsServer *websocket.Server, converter *conversion.Converter) {
+	ticker := time.NewTicker(cfg.SampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			var closed []analytics.Candle
+			for _, entry := range books.List() {
+				name, view := entry.Key.Exchange, entry.Book.View()
+				if view == nil || len(view.Bids) == 0 || len(view.Asks) == 0 {
+					continue
+				}
+				bid, ask := view.Bids[0], view.Asks[0]
+				mid := converter.Convert(name, bid.Price.Add(ask.Price).Div(decimal.NewFromInt(2)))
+				var microprice decimal.Decimal
+				if cfg.Microprice {
+					microprice = converter.Convert(name, analytics.Microprice(bid.Price, bid.Quantity, ask.Price, ask.Quantity))
+				}
+				closed = append(closed, builder.Sample(name, now, mid, microprice)...)
+			}
+			wsServer.PublishCandles(closed)
+		}
+	}
+}
+
+// runQuoteRate streams the stablecoin pair of the quote conversion feed and keeps
+// the converter's rate for the pair's quote currency up to date
+func runQuoteRate(ctx context.Context, cfg config.Config, converter *conversion.Converter) {
+	feed := cfg.App.QuoteRate
+	quote := types.QuoteAsset(feed.Symbol)
+	exCfg := config.ExchangeConfig{Name: feed.Exchange, Symbol: feed.Symbol}
+
+	ex, err := factory.NewExchange(factory.ExchangeConfig{Name: feed.Exchange, Symbol: feed.Symbol})
+	if err != nil {
+		log.Printf("[%s] Quote conversion disabled: %v", feed.Exchange, err)
+		return
+	}
+	ob := orderbook.New()
+	stop := make(chan struct{})
+	go func() {
+		defer close(stop)
+		if err := streamBook(ctx, cfg, exCfg, ex, ob, nil); err != nil {
+			log.Printf("[%s] Quote conversion disabled, %v", feed.Exchange, err)
+		}
+	}()
+
+	ticker := time.NewTicker(feed.Interval)
+	defer ticker.Stop()
+	logged := false
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		stats := ob.GetStats()
+		if !ob.IsInitialized() || stats.BestBid.IsZero() || stats.BestAsk.IsZero() {
+			continue
+		}
+
+		// The pair's mid is the price of one unit of the common quote in the other currency
+		mid := stats.BestBid.Add(stats.BestAsk).Div(decimal.NewFromInt(2))
+		converter.SetRate(quote, decimal.NewFromInt(1).Div(mid))
+		if !logged {
+			log.Printf("[%s] Normalizing %s books to %s at 1 %s = %s %s", feed.Exchange, quote, converter.Target(), converter.Target(), mid, quote)
+			logged = true
+		}
+	}
+}
+
+// quoteCurrency returns the quote currency of an exchange's book for symbol
+func quoteCurrency(ex exchange.Exchange, symbol string) string {
+	if provider, ok := ex.(exchange.QuoteProvider); ok {
+		return provider.QuoteCurrency()
+	}
+	return types.QuoteAsset(symbol)
+}
+
+// enableFixedPoint switches ob to the fixed-point engine if ex exposes instrument metadata
+func enableFixedPoint(ctx context.Context, ex exchange.Exchange, ob *orderbook.OrderBook) {
+	provider, ok := ex.(exchange.InstrumentProvider)
+	if !ok {
 		return
 	}
 
-	fmt.Println()
+	info, err := provider.GetInstrumentInfo(ctx)
+	if err != nil {
+		log.Printf("[%s] Instrument metadata unavailable, using decimal engine: %v", ex.GetName(), err)
+		return
+	}
 
-	for i, obn := range orderbooks {
+	if err := ob.EnableFixedPoint(info.PriceDecimals, info.QtyDecimals); err != nil {
+		log.Printf("[%s] Failed to enable fixed-point engine: %v", ex.GetName(), err)
+		return
+	}
+	log.Printf("[%s] Using fixed-point engine (tick %s, step %s)", ex.GetName(), info.TickSize, info.StepSize)
+}
+
+// resolveTickLevels returns the tick levels of a symbol from its preset, or derives them
+// from the tick size of the first exchange that exposes instrument metadata
+func resolveTickLevels(ctx context.Context, opts runOptions, symbol string) []types.TickLevel {
+	if levels, ok := opts.cfg.TickLevelsFor(symbol); ok {
+		return levels
+	}
+
+	for _, exCfg := range buildExchangeConfigs(symbol, opts.exchanges) {
+		ex, err := factory.NewExchange(factory.ExchangeConfig{
+			Name:   exCfg.Name,
+			Symbol: exCfg.Symbol,
+		})
+		if err != nil {
+			continue
+		}
+		provider, ok := ex.(exchange.InstrumentProvider)
+		if !ok {
+			continue
+		}
+
+		info, err := provider.GetInstrumentInfo(ctx)
+		if err != nil {
+			continue
+		}
+		tickSize, err := strconv.ParseFloat(info.TickSize, 64)
+		if err != nil {
+			continue
+		}
+		log.Printf("[%s] No tick preset for %s, using multiples of tick size %s", exCfg.Name, symbol, info.TickSize)
+		return types.TickLevelsForTickSize(tickSize)
+	}
+
+	log.Printf("No tick preset or instrument metadata for %s, using default tick levels", symbol)
+	return types.DefaultTickLevels
+}
+
+// recordSnapshot persists a snapshot when storage is enabled
+func recordSnapshot(ctx context.Context, store storage.Storage, snapshot *exchange.Snapshot) {
+	if store == nil {
+		return
+	}
+	if err := store.WriteSnapshot(ctx, snapshot); err != nil {
+		log.Printf("[%s] Failed to record snapshot: %v", snapshot.Exchange, err)
+	}
+}
+
+// recordStats persists the current stats of every initialized orderbook when storage is enabled
+func recordStats(ctx context.Context, store storage.Storage, symbol string, orderbooks []*orderbookWithName) {
+	if store == nil {
+		return
+	}
+	now := time.Now()
+	for _, obn := range orderbooks {
 		if !obn.ob.IsInitialized() {
 			continue
 		}
+		err := store.WriteStats(ctx, storage.StatsRecord{
+			Exchange:  exchange.ExchangeName(obn.name),
+			Symbol:    symbol,
+			Timestamp: now,
+			Stats:     obn.ob.GetStats(),
+		})
+		if err != nil {
+			log.Printf("[%s] Failed to record stats: %v", obn.name, err)
+		}
+	}
+}
+
+// nextAlignedTime returns the first wall-clock boundary of interval after now
+// (e.g., the top of the next minute or hour, in UTC)
+func nextAlignedTime(now time.Time, interval time.Duration) time.Time {
+	return now.Truncate(interval).Add(interval)
+}
+
+// recordAlignedSnapshots persists the published book of every initialized orderbook stamped with at
+func recordAlignedSnapshots(ctx context.Context, store storage.Storage, symbol string, at time.Time, orderbooks []*orderbookWithName) {
+	for _, obn := range orderbooks {
+		view := obn.ob.View()
+		if view == nil {
+			continue
+		}
+		recordSnapshot(ctx, store, view.Snapshot(exchange.ExchangeName(obn.name), symbol, at))
+	}
+}
 
+// sampleSession records the counters and top of book of every orderbook in the session tracker
+func sampleSession(session *analytics.SessionTracker, symbol string, orderbooks []*orderbookWithName) {
+	now := time.Now()
+	for _, obn := range orderbooks {
 		stats := obn.ob.GetStats()
-		midPrice := stats.BestBid.Add(stats.BestAsk).Div(decimal.NewFromInt(2))
+		health := obn.ex.Health()
+		sample := analytics.SessionSample{
+			Exchange:   obn.name,
+			Symbol:     symbol,
+			Started:    stats.ConnectionTime,
+			Time:       now,
+			Reconnects: health.Reconnects,
+			Messages:   health.MessageCount,
+			Gaps:       stats.Gaps,
+			Resyncs:    stats.Resyncs,
+		}
+		if obn.ob.IsInitialized() {
+			sample.Spread = stats.Spread
+			sample.Liquidity2Pct = stats.BidLiquidity2Pct.Add(stats.AskLiquidity2Pct)
+		}
+		session.Sample(sample)
+	}
+}
+
+// sampleReports records the state of every orderbook in the SLA tracker and
+// writes the report of the previous day when a sample closes it
+func sampleReports(tracker *reporting.Tracker, dir, symbol string, orderbooks []*orderbookWithName) {
+	now := time.Now()
+	for _, obn := range orderbooks {
+		stats := obn.ob.GetStats()
+		sample := reporting.Sample{
+			Exchange: obn.name,
+			Symbol:   symbol,
+			Time:     now,
+			Started:  stats.ConnectionTime,
+			Up:       obn.ex.Health().Connected && obn.ob.IsReady(),
+			Resyncs:  stats.Resyncs,
+		}
+		if !stats.LastEventTime.IsZero() {
+			sample.Staleness = now.Sub(stats.LastEventTime)
+		}
+		if obn.ob.IsInitialized() {
+			sample.Spread = stats.Spread
+			sample.Depth2Pct = stats.BidLiquidity2Pct.Add(stats.AskLiquidity2Pct)
+		}
+		if closed := tracker.Sample(sample); closed != nil {
+			writeReport(*closed, dir)
+		}
+	}
+}
+
+// writeReport writes an SLA report to dir when set
+func writeReport(report reporting.Report, dir string) {
+	if dir == "" {
+		return
+	}
+	if err := reporting.WriteFiles(dir, report); err != nil {
+		log.Printf("Failed to write SLA report: %v", err)
+		return
+	}
+	log.Printf("Wrote SLA report of %s to %s", report.Date, dir)
+}
+
+// viewName returns the console label of an exchange, prefixed by its namespace when set
+func viewName(namespace string, name exchange.ExchangeName) string {
+	if namespace == "" {
+		return string(name)
+	}
+	return namespace + "/" + string(name)
+}
+
+// reportSession logs the session summary and writes it to path when set
+func reportSession(session *analytics.SessionTracker, path string) {
+	summary := session.Summary(time.Now())
+	log.Printf("Session summary (%s since %s):", summary.End.Sub(summary.Start).Round(time.Second), summary.Start.Format(time.RFC3339))
+	for _, ex := range summary.Exchanges {
+		log.Printf("[%s] %s uptime %s, %d reconnects, %d messages, %d gaps, %d resyncs, avg spread %s, avg 2%% liquidity %s",
+			ex.Exchange, ex.Symbol,
+			time.Duration(ex.UptimeSeconds*float64(time.Second)).Round(time.Second),
+			ex.Reconnects, ex.Messages, ex.Gaps, ex.Resyncs,
+			ex.AvgSpread.StringFixed(2), ex.AvgLiquidity2Pct.StringFixed(4))
+	}
+
+	if path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		log.Printf("Failed to encode session summary: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		log.Printf("Failed to write session summary: %v", err)
+	}
+}
 
-		// print exchange name
-		fmt.Printf("%s%s%s", colorBold, obn.name, colorReset)
-		// Print exchange header
-		fmt.Printf("  Mid: %s%10s%s │ Spread: %s%8s%s | BB: %s%10s%s │ BA: %s%10s%s\n",
-			colorYellow, midPrice.StringFixed(2), colorReset,
-			colorMagenta, stats.Spread.StringFixed(4), colorReset,
-			colorGreen, stats.BestBid.StringFixed(2), colorReset,
-			colorRed, stats.BestAsk.StringFixed(2), colorReset)
+// resumeCheckpoint returns the journaled checkpoint of the book of name for symbol
+// if it is recent enough to resume from
+func resumeCheckpoint(j *journal.Journal, cfg config.JournalConfig, name exchange.ExchangeName, symbol string) *exchange.Snapshot {
+	if j == nil {
+		return nil
+	}
+	checkpoint, ok := j.Checkpoint(name, symbol)
+	if !ok || time.Since(checkpoint.Timestamp) > cfg.MaxAge {
+		return nil
+	}
+	return checkpoint
+}
 
-		// Print depth metrics
-		fmt.Printf("  DEPTH 0.5%% Bids: %s%9s%s │ Asks: %s%9s%s │ Δ: %s%10s%s\n",
-			colorGreen, stats.BidLiquidity05Pct.StringFixed(2), colorReset,
-			colorRed, stats.AskLiquidity05Pct.StringFixed(2), colorReset,
-			getDeltaColor(stats.DeltaLiquidity05Pct), stats.DeltaLiquidity05Pct.StringFixed(2), colorReset)
+// resumeDepth is the depth of the snapshot fetched to catch a resumed book up
+// after a gap; the deeper levels come from the checkpoint
+const resumeDepth = 100
 
-		fmt.Printf("  DEPTH 2%%:  Bids: %s%9s%s │ Asks: %s%9s%s │ Δ: %s%10s%s\n",
-			colorGreen, stats.BidLiquidity2Pct.StringFixed(2), colorReset,
-			colorRed, stats.AskLiquidity2Pct.StringFixed(2), colorReset,
-			getDeltaColor(stats.DeltaLiquidity2Pct), stats.DeltaLiquidity2Pct.StringFixed(2), colorReset)
+// resumeWait bounds how long catchUp waits for the first buffered update
+const resumeWait = 2 * time.Second
 
-		fmt.Printf("  DEPTH 10%%  Bids: %s%9s%s │ Asks: %s%9s%s │ Δ: %s%10s%s\n",
-			colorGreen, stats.BidLiquidity10Pct.StringFixed(2), colorReset,
-			colorRed, stats.AskLiquidity10Pct.StringFixed(2), colorReset,
-			getDeltaColor(stats.DeltaLiquidity10Pct), stats.DeltaLiquidity10Pct.StringFixed(2), colorReset)
+// catchUp initializes ob from the journaled checkpoint and the updates buffered
+// since the stream started, bridging a gap with a shallow snapshot where the venue
+// offers one. It reports false when the book must be loaded from a full snapshot:
+// the venue does not sequence its updates against REST snapshots, or the gap
+// cannot be bridged.
+func catchUp(ctx context.Context, name exchange.ExchangeName, ex exchange.Exchange, ob *orderbook.OrderBook, checkpoint *exchange.Snapshot, policy exchange.RetryPolicy) bool {
+	caps := ex.Capabilities()
+	if !caps.SequenceIDs || caps.SnapshotSource != exchange.SnapshotREST || caps.FullDepth {
+		return false
+	}
 
-		fmt.Printf("  TOTAL QTY: Bids: %s%9s%s │ Asks: %s%9s%s\n",
-			colorGreen, stats.TotalBidsQty.StringFixed(2), colorReset,
-			colorRed, stats.TotalAsksQty.StringFixed(2), colorReset)
+	// Wait for the stream so its first update can be checked against the checkpoint
+	deadline := time.Now().Add(resumeWait)
+	for ob.GetBufferLength() == 0 && time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
 
-		// Print separator between exchanges (but not after the last one)
-		if i < len(orderbooks)-1 {
-			fmt.Println()
+	var getShallow func(depth int) (*exchange.Snapshot, error)
+	if shallow, ok := ex.(exchange.ShallowSnapshotter); ok {
+		getShallow = func(depth int) (*exchange.Snapshot, error) {
+			return exchange.FetchSnapshot(ctx, name, policy, func(ctx context.Context) (*exchange.Snapshot, error) {
+				return shallow.GetShallowSnapshot(ctx, depth)
+			})
 		}
 	}
+	if err := ob.Resume(checkpoint, resumeDepth, getShallow); err != nil {
+		log.Printf("[%s] Failed to catch up from checkpoint: %v, loading a full snapshot", name, err)
+		return false
+	}
+	return true
 }
 
-func getDeltaColor(delta decimal.Decimal) string {
-	if delta.GreaterThan(decimal.Zero) {
-		return colorGreen
-	} else if delta.LessThan(decimal.Zero) {
-		return colorRed
+// checkpointBooks journals the published view of every ready book, keeping
+// cfg.Levels levels per side
+func checkpointBooks(j *journal.Journal, cfg config.JournalConfig, symbol string, orderbooks []*orderbookWithName) {
+	snapshots := make([]*exchange.Snapshot, 0, len(orderbooks))
+	for _, obn := range orderbooks {
+		view := obn.ob.View()
+		if view == nil || !obn.ob.IsReady() {
+			continue
+		}
+		capped := *view
+		if cfg.Levels > 0 {
+			capped.Bids = view.Bids[:min(len(view.Bids), cfg.Levels)]
+			capped.Asks = view.Asks[:min(len(view.Asks), cfg.Levels)]
+		}
+		snapshots = append(snapshots, capped.Snapshot(exchange.ExchangeName(obn.name), symbol, view.PublishedAt))
 	}
-	return colorYellow
+	if err := j.WriteCheckpoints(snapshots); err != nil {
+		log.Printf("Failed to write journal checkpoints: %v", err)
+	}
+}
+
+// applyUpdate applies an update to its book, publishing the lock-free view once
+// the last update of a burst is applied, and ends the update's trace
+func applyUpdate(ob *orderbook.OrderBook, update *exchange.DepthUpdate, last bool) {
+	update.Trace.End()
+	trace := update.Trace.Parent()
+	apply := trace.Child("apply")
+	ob.HandleDepthUpdate(update)
+	apply.End()
+	if last {
+		publish := trace.Child("publish")
+		ob.PublishView()
+		publish.End()
+	}
+	trace.End()
+}
+
+// feedStatus derives the lifecycle state of a running exchange from its connection
+// and book: a lost connection freezes the book, an uninitialized book is reloading
+func feedStatus(ex exchange.Exchange, ob *orderbook.OrderBook) websocket.ExchangeStatus {
+	switch {
+	case !ex.Health().Connected:
+		return websocket.StatusStale
+	case !ob.IsInitialized():
+		return websocket.StatusResyncing
+	}
+	return websocket.StatusInitialized
+}
+
+func buildExchangeConfigs(symbol string, names []exchange.ExchangeName) []config.ExchangeConfig {
+	configs := make([]config.ExchangeConfig, len(names))
+	for i, name := range names {
+		configs[i] = config.ExchangeConfig{
+			Name:   name,
+			Symbol: symbol,
+		}
+	}
+	return configs
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"orderbook/internal/websocket"
+)
+
+func main() {
+	// Parse command line flags
+	var file = flag.String("file", "", "Recording file or -record-chunked directory written by the monitor's -record flag")
+	var port = flag.String("port", "8086", "Port serving /ws")
+	var speed = flag.Float64("speed", 1, "Playback speed multiplier")
+	var loop = flag.Bool("loop", false, "Restart the recording when it ends")
+	var from = flag.String("from", "", "Start at this time (RFC 3339, e.g. 2024-05-01T12:00:00Z), seeking through the index of chunked recordings")
+	flag.Parse()
+
+	if *file == "" {
+		log.Fatal("-file is required")
+	}
+	var start time.Time
+	if *from != "" {
+		var err error
+		if start, err = time.Parse(time.RFC3339, *from); err != nil {
+			log.Fatalf("Invalid -from: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	server := websocket.NewReplayServer(websocket.ReplayConfig{
+		Path:  *file,
+		Port:  *port,
+		Speed: *speed,
+		Loop:  *loop,
+		From:  start,
+	})
+	if err := server.Start(ctx); err != nil {
+		log.Fatalf("Replay server error: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"orderbook/internal/websocket"
+)
+
+func main() {
+	// Parse command line flags
+	var schemaPath = flag.String("schema", "", "Write the JSON Schema of the wire protocol to this file")
+	var tsPath = flag.String("ts", "", "Write TypeScript declarations of the wire protocol to this file")
+	var protoPath = flag.String("proto", "", "Write the protobuf schema of format=pb frames to this file")
+	flag.Parse()
+
+	if *schemaPath == "" && *tsPath == "" && *protoPath == "" {
+		log.Fatal("-schema, -ts or -proto is required")
+	}
+
+	if *schemaPath != "" {
+		data, err := json.MarshalIndent(websocket.Schema(), "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to encode schema: %v", err)
+		}
+		if err := os.WriteFile(*schemaPath, append(data, '\n'), 0644); err != nil {
+			log.Fatalf("Failed to write schema: %v", err)
+		}
+	}
+	if *tsPath != "" {
+		if err := os.WriteFile(*tsPath, []byte(websocket.TypeScript()), 0644); err != nil {
+			log.Fatalf("Failed to write TypeScript declarations: %v", err)
+		}
+	}
+	if *protoPath != "" {
+		if err := os.WriteFile(*protoPath, []byte(websocket.Proto()), 0644); err != nil {
+			log.Fatalf("Failed to write protobuf schema: %v", err)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Command soaktest runs the orderbook engine against mock exchanges that inject
+// sequence gaps, duplicates, bursts and disconnects, and checks that every book
+// keeps converging to the venue's book while memory and goroutines stay flat.
+//
+//	go run ./cmd/soaktest -duration 4h -exchanges 8 -rate 500
+//
+// It exits 1 when an invariant was violated.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"orderbook/internal/config"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/shard"
+	"orderbook/internal/types"
+	"orderbook/internal/websocket"
+)
+
+// soakConfig holds the command line options
+type soakConfig struct {
+	duration       time.Duration
+	exchanges      int
+	mock           mockConfig
+	seed           int64
+	fixedPoint     bool
+	checkInterval  time.Duration
+	convergeWithin time.Duration
+	warmup         time.Duration
+	maxHeapGrowth  float64
+	port           string
+}
+
+// venue is a mock exchange and the book the engine keeps for it
+type venue struct {
+	mock *mockExchange
+	ob   *orderbook.OrderBook
+}
+
+func main() {
+	var cfg soakConfig
+	flag.DurationVar(&cfg.duration, "duration", time.Hour, "How long to run")
+	flag.IntVar(&cfg.exchanges, "exchanges", 4, "Number of mock exchanges")
+	flag.IntVar(&cfg.mock.Rate, "rate", 200, "Updates per second per exchange")
+	flag.IntVar(&cfg.mock.Levels, "levels", 1000, "Levels per side of each mock book")
+	flag.DurationVar(&cfg.mock.FaultInterval, "fault-interval", 10*time.Second, "Mean interval between faults per exchange (0 = none)")
+	flag.IntVar(&cfg.mock.BurstSize, "burst", 2000, "Updates sent back to back by a burst fault")
+	flag.DurationVar(&cfg.mock.SnapshotDelay, "snapshot-delay", 50*time.Millisecond, "Latency of mock snapshots")
+	flag.Int64Var(&cfg.seed, "seed", 1, "Random seed of the mock feeds")
+	flag.BoolVar(&cfg.fixedPoint, "fixed-point", false, "Use the fixed-point engine")
+	flag.DurationVar(&cfg.checkInterval, "check-interval", 30*time.Second, "Interval between invariant checks")
+	flag.DurationVar(&cfg.convergeWithin, "converge-within", 30*time.Second, "Time a quiesced book has to match its venue")
+	flag.DurationVar(&cfg.warmup, "warmup", 2*time.Minute, "Time before the memory baseline is taken")
+	flag.Float64Var(&cfg.maxHeapGrowth, "max-heap-growth", 1.5, "Largest allowed heap size relative to the baseline")
+	flag.StringVar(&cfg.port, "port", "", "Also serve the WebSocket feed on this port (empty = disabled)")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+
+	violations := run(ctx, cfg)
+	if violations > 0 {
+		log.Printf("FAIL: %d invariant violations", violations)
+		os.Exit(1)
+	}
+	log.Printf("PASS")
+}
+
+// run starts the venues and checks the invariants until ctx is done, returning the number of violations
+func run(ctx context.Context, cfg soakConfig) int {
+	app := config.Default().App
+	pool := shard.New(shard.Config{Shards: app.Shards, QueueSize: app.ShardQueueSize})
+	pool.Start()
+
+	venues := make([]*venue, cfg.exchanges)
+	books := orderbook.NewBookRegistry()
+	for i := range venues {
+		v, err := startVenue(ctx, cfg, app, pool, fmt.Sprintf("mock%d", i), cfg.seed+int64(i))
+		if err != nil {
+			log.Printf("Failed to start mock%d: %v", i, err)
+			return 1
+		}
+		venues[i] = v
+		books.Set(orderbook.BookKey{Exchange: string(v.mock.GetName()), Symbol: v.mock.GetSymbol()}, v.ob)
+	}
+	if cfg.port != "" {
+		server := websocket.NewServer(books, cfg.port, make(chan string, 1))
+		go func() {
+			if err := server.Start(); err != nil {
+				log.Printf("WebSocket server error: %v", err)
+			}
+		}()
+	}
+	log.Printf("Soaking %d exchanges at %d updates/s for %v", cfg.exchanges, cfg.mock.Rate, cfg.duration)
+
+	start := time.Now()
+	ticker := time.NewTicker(cfg.checkInterval)
+	defer ticker.Stop()
+	violations := 0
+	var baseline *runtime.MemStats
+	baselineGoroutines := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			for _, v := range venues {
+				v.mock.Close()
+			}
+			report(venues, time.Since(start))
+			return violations
+		case <-ticker.C:
+		}
+
+		for _, v := range venues {
+			if err := checkVenue(ctx, v, cfg.convergeWithin); err != nil {
+				violations++
+				log.Printf("[%s] VIOLATION: %v", v.mock.GetName(), err)
+			}
+		}
+
+		runtime.GC()
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		goroutines := runtime.NumGoroutine()
+		switch {
+		case baseline == nil && time.Since(start) >= cfg.warmup:
+			baseline = &mem
+			baselineGoroutines = goroutines
+			log.Printf("Memory baseline: heap %d KB, %d goroutines", mem.HeapAlloc/1024, goroutines)
+		case baseline != nil:
+			if float64(mem.HeapAlloc) > float64(baseline.HeapAlloc)*cfg.maxHeapGrowth {
+				violations++
+				log.Printf("VIOLATION: heap grew from %d KB to %d KB", baseline.HeapAlloc/1024, mem.HeapAlloc/1024)
+			}
+			if goroutines > baselineGoroutines+cfg.exchanges {
+				violations++
+				log.Printf("VIOLATION: goroutines grew from %d to %d", baselineGoroutines, goroutines)
+			}
+		}
+		log.Printf("%v elapsed: heap %d KB, %d goroutines, %d violations", time.Since(start).Round(time.Second), mem.HeapAlloc/1024, goroutines, violations)
+	}
+}
+
+// startVenue connects a mock exchange and runs its book the way cmd/main does:
+// updates on a shard, and reinitialization and pruning on a maintenance loop
+func startVenue(ctx context.Context, cfg soakConfig, app config.AppConfig, pool *shard.Pool, name string, seed int64) (*venue, error) {
+	mock := newMockExchange(name, seed, cfg.mock)
+	ob := orderbook.New()
+	ob.SetCapabilities(mock.Capabilities())
+	ob.SetPruneConfig(orderbook.PruneConfig{MaxDistancePct: app.PruneMaxDistancePct, MaxLevels: app.PruneMaxLevels})
+	if cfg.fixedPoint {
+		if err := ob.EnableFixedPoint(1, 3); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mock.Connect(ctx); err != nil {
+		return nil, err
+	}
+	getSnapshot := func() (*exchange.Snapshot, error) {
+		return mock.GetSnapshot(ctx)
+	}
+	snapshot, err := getSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	if err := ob.LoadSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+
+	lane := pool.Assign(name, func(update *exchange.DepthUpdate, last bool) {
+		ob.HandleDepthUpdate(update)
+		if last {
+			ob.PublishView()
+		}
+	})
+	go func() {
+		defer pool.Release(lane)
+		updates := mock.Updates()
+		for update := range updates {
+			lane.Submit(update, len(updates) == 0)
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(app.ReinitCheckInterval)
+		defer ticker.Stop()
+		pruneTicker := time.NewTicker(app.PruneInterval)
+		defer pruneTicker.Stop()
+		var lastReconnects int64
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-pruneTicker.C:
+				ob.Prune()
+			case <-ticker.C:
+				if reconnects := mock.Health().Reconnects; reconnects != lastReconnects {
+					lastReconnects = reconnects
+					ob.Reinitialize(getSnapshot)
+				} else {
+					ob.CheckAndReinitialize(getSnapshot)
+				}
+			}
+		}
+	}()
+
+	ob.ProcessBufferedEvents()
+	return &venue{mock: mock, ob: ob}, nil
+}
+
+// checkVenue freezes the venue's book and waits for the engine's copy to catch up
+// with it, then requires both to be identical and uncrossed
+func checkVenue(ctx context.Context, v *venue, within time.Duration) error {
+	last := v.mock.Quiesce()
+	defer v.mock.Resume()
+
+	deadline := time.Now().Add(within)
+	for !v.ob.IsInitialized() || v.ob.GetLastUpdateID() < last {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("book did not catch up within %v: initialized=%v lastUpdateId=%d, venue at %d, %d events buffered",
+				within, v.ob.IsInitialized(), v.ob.GetLastUpdateID(), last, v.ob.GetBufferLength())
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	bids, asks := v.mock.Book()
+	if err := compareSide("bid", v.ob.GetBids(), bids); err != nil {
+		return err
+	}
+	if err := compareSide("ask", v.ob.GetAsks(), asks); err != nil {
+		return err
+	}
+	if stats := v.ob.GetStats(); stats.CrossedBook {
+		return fmt.Errorf("book crossed: bid %s >= ask %s", stats.BestBid, stats.BestAsk)
+	}
+	return nil
+}
+
+// compareSide reports the first differences between a side of the engine's book and the venue's
+func compareSide(side string, book map[string]types.PriceLevel, venue map[int64]int64) error {
+	var diffs []string
+	seen := make(map[int64]bool, len(book))
+	for _, level := range book {
+		price := level.Price.Shift(1).IntPart()
+		qty := level.Quantity.Shift(3).IntPart()
+		seen[price] = true
+		if venue[price] != qty {
+			diffs = append(diffs, fmt.Sprintf("%s %s: engine %s, venue %s", side, formatPrice(price), formatQuantity(qty), formatQuantity(venue[price])))
+		}
+	}
+	for price, qty := range venue {
+		if !seen[price] {
+			diffs = append(diffs, fmt.Sprintf("%s %s: engine missing, venue %s", side, formatPrice(price), formatQuantity(qty)))
+		}
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	sort.Strings(diffs)
+	if len(diffs) > 5 {
+		diffs = append(diffs[:5], fmt.Sprintf("%d more", len(diffs)-5))
+	}
+	return fmt.Errorf("book differs from venue: %s", strings.Join(diffs, "; "))
+}
+
+// report logs the faults injected and the engine counters of every venue
+func report(venues []*venue, elapsed time.Duration) {
+	log.Printf("Soak summary after %v", elapsed.Round(time.Second))
+	for _, v := range venues {
+		stats := v.ob.GetStats()
+		faults := v.mock.Faults()
+		log.Printf("[%s] %d updates, faults: %d gaps %d duplicates %d bursts %d disconnects; engine: %d gaps %d resyncs %d overflows %d dropped",
+			v.mock.GetName(), v.mock.Health().MessageCount,
+			faults[faultGap], faults[faultDuplicate], faults[faultBurst], faults[faultDisconnect],
+			stats.Gaps, stats.Resyncs, stats.BufferOverflows, stats.EventsDropped)
+	}
+}
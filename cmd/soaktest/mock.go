@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+// Faults injected into the mock feeds
+const (
+	faultGap        = "gap"        // Updates withheld while the book keeps changing
+	faultDuplicate  = "duplicate"  // The previous update sent again
+	faultBurst      = "burst"      // Updates sent back to back without pacing
+	faultDisconnect = "disconnect" // Silence, lost updates, then a reconnect
+)
+
+var faultKinds = []string{faultGap, faultDuplicate, faultBurst, faultDisconnect}
+
+// mockConfig shapes the feed of a mock exchange
+type mockConfig struct {
+	Rate          int           // Updates per second
+	Levels        int           // Levels per side around the touch
+	FaultInterval time.Duration // Mean interval between faults, 0 disables them
+	BurstSize     int           // Updates sent by a burst fault
+	SnapshotDelay time.Duration // Latency of GetSnapshot
+}
+
+// mockExchange is an exchange adapter whose book is generated locally. It keeps
+// the true book so the engine's copy can be compared with it.
+type mockExchange struct {
+	name   exchange.ExchangeName
+	config mockConfig
+	rng    *rand.Rand // Owned by the producer goroutine
+
+	mu     sync.Mutex // Guards the book and sequence below
+	bids   map[int64]int64
+	asks   map[int64]int64
+	mid    int64 // Lowest ask tick, bids are below it
+	lastID int64
+	quiet  bool // Only empty updates are sent, so the book stops changing
+
+	updates    chan *exchange.DepthUpdate
+	cancel     context.CancelFunc
+	done       chan struct{}
+	connected  atomic.Bool
+	messages   atomic.Int64
+	reconnects atomic.Int64
+	faultMu    sync.Mutex
+	faults     map[string]int64
+}
+
+// Prices are ticks of 0.1 and quantities lots of 0.001
+const (
+	startMid = 500000
+	maxLots  = 5000
+)
+
+func newMockExchange(name string, seed int64, config mockConfig) *mockExchange {
+	m := &mockExchange{
+		name:    exchange.ExchangeName(name),
+		config:  config,
+		rng:     rand.New(rand.NewSource(seed)),
+		bids:    make(map[int64]int64),
+		asks:    make(map[int64]int64),
+		mid:     startMid,
+		lastID:  1,
+		updates: make(chan *exchange.DepthUpdate, 1000),
+		done:    make(chan struct{}),
+		faults:  make(map[string]int64),
+	}
+	for i := int64(0); i < int64(config.Levels); i++ {
+		m.bids[m.mid-1-i] = 1 + m.rng.Int63n(maxLots)
+		m.asks[m.mid+i] = 1 + m.rng.Int63n(maxLots)
+	}
+	return m
+}
+
+func (m *mockExchange) GetName() exchange.ExchangeName { return m.name }
+func (m *mockExchange) GetSymbol() string              { return "BTCUSDT" }
+func (m *mockExchange) IsConnected() bool              { return m.connected.Load() }
+func (m *mockExchange) Updates() <-chan *exchange.DepthUpdate {
+	return m.updates
+}
+
+func (m *mockExchange) Capabilities() exchange.Capabilities {
+	return exchange.Capabilities{SequenceIDs: true, SnapshotSource: exchange.SnapshotREST}
+}
+
+func (m *mockExchange) Health() exchange.HealthStatus {
+	return exchange.HealthStatus{
+		Connected:    m.connected.Load(),
+		MessageCount: m.messages.Load(),
+		Reconnects:   m.reconnects.Load(),
+	}
+}
+
+// Connect starts the feed
+func (m *mockExchange) Connect(ctx context.Context) error {
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.connected.Store(true)
+	go m.run(ctx)
+	return nil
+}
+
+// Close stops the feed and closes the updates channel
+func (m *mockExchange) Close() error {
+	if m.cancel != nil {
+		m.cancel()
+		<-m.done
+	}
+	return nil
+}
+
+// GetSnapshot returns the true book after the configured latency
+func (m *mockExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
+	select {
+	case <-time.After(m.config.SnapshotDelay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &exchange.Snapshot{
+		Exchange:     m.name,
+		Symbol:       m.GetSymbol(),
+		LastUpdateID: m.lastID,
+		Bids:         formatLevels(m.bids),
+		Asks:         formatLevels(m.asks),
+		Timestamp:    time.Now(),
+	}, nil
+}
+
+// Quiesce stops changing the book, sending only empty updates so the sequence
+// keeps advancing, and returns the ID of the last change
+func (m *mockExchange) Quiesce() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quiet = true
+	return m.lastID
+}
+
+// Resume lets the book change again
+func (m *mockExchange) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quiet = false
+}
+
+// Book returns a copy of the true book
+func (m *mockExchange) Book() (bids, asks map[int64]int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return copyLevels(m.bids), copyLevels(m.asks)
+}
+
+// Faults returns the number of faults injected per kind
+func (m *mockExchange) Faults() map[string]int64 {
+	m.faultMu.Lock()
+	defer m.faultMu.Unlock()
+	faults := make(map[string]int64, len(m.faults))
+	for kind, count := range m.faults {
+		faults[kind] = count
+	}
+	return faults
+}
+
+// run paces updates at the configured rate and injects faults at random
+func (m *mockExchange) run(ctx context.Context) {
+	defer close(m.done)
+	defer close(m.updates)
+	defer m.connected.Store(false)
+
+	ticker := time.NewTicker(time.Second / time.Duration(max(m.config.Rate, 1)))
+	defer ticker.Stop()
+	nextFault := m.nextFault(time.Now())
+
+	var previous *exchange.DepthUpdate
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if !nextFault.IsZero() && now.After(nextFault) && !m.isQuiet() {
+				nextFault = m.nextFault(now)
+				if !m.inject(ctx, previous) {
+					return
+				}
+				continue
+			}
+			previous = m.next()
+			if !m.send(ctx, previous) {
+				return
+			}
+		}
+	}
+}
+
+// nextFault draws the time of the next fault, zero when faults are disabled
+func (m *mockExchange) nextFault(now time.Time) time.Time {
+	if m.config.FaultInterval <= 0 {
+		return time.Time{}
+	}
+	return now.Add(time.Duration(m.rng.ExpFloat64() * float64(m.config.FaultInterval)))
+}
+
+// inject performs a random fault and reports whether the feed is still running
+func (m *mockExchange) inject(ctx context.Context, previous *exchange.DepthUpdate) bool {
+	kind := faultKinds[m.rng.Intn(len(faultKinds))]
+	if kind == faultDuplicate && previous == nil {
+		kind = faultBurst
+	}
+	m.faultMu.Lock()
+	m.faults[kind]++
+	m.faultMu.Unlock()
+
+	switch kind {
+	case faultGap:
+		for i := 1 + m.rng.Intn(20); i > 0; i-- {
+			m.next()
+		}
+	case faultDuplicate:
+		return m.send(ctx, previous)
+	case faultBurst:
+		for i := 0; i < m.config.BurstSize; i++ {
+			if !m.send(ctx, m.next()) {
+				return false
+			}
+		}
+	case faultDisconnect:
+		m.connected.Store(false)
+		silence := time.Duration(500+m.rng.Intn(4500)) * time.Millisecond
+		lost := int(silence.Seconds() * float64(m.config.Rate))
+		for i := 0; i < lost; i++ {
+			m.next()
+		}
+		select {
+		case <-time.After(silence):
+		case <-ctx.Done():
+			return false
+		}
+		m.reconnects.Add(1)
+		m.connected.Store(true)
+	}
+	return true
+}
+
+// send delivers an update, blocking while the consumer is behind
+func (m *mockExchange) send(ctx context.Context, update *exchange.DepthUpdate) bool {
+	select {
+	case m.updates <- update:
+		m.messages.Add(1)
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (m *mockExchange) isQuiet() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.quiet
+}
+
+// next changes the true book and returns the update describing the change.
+// Most changes are near the touch; the touch itself drifts by one tick, removing
+// the levels the other side crosses.
+func (m *mockExchange) next() *exchange.DepthUpdate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	now := time.Now()
+	update := &exchange.DepthUpdate{
+		Exchange:       m.name,
+		Symbol:         m.GetSymbol(),
+		EventTime:      now,
+		LocalEventTime: now,
+		FirstUpdateID:  m.lastID,
+		FinalUpdateID:  m.lastID,
+		PrevUpdateID:   m.lastID - 1,
+	}
+	if m.quiet {
+		return update
+	}
+
+	bids := make(map[int64]int64)
+	asks := make(map[int64]int64)
+	// The touch stays within half the book of its start, bounding the book size
+	drift := int64(m.config.Levels / 2)
+	switch m.rng.Intn(20) {
+	case 0:
+		if m.mid < startMid+drift {
+			// Touch moves up, the lowest ask is taken out
+			if _, ok := m.asks[m.mid]; ok {
+				asks[m.mid] = 0
+			}
+			m.mid++
+		}
+	case 1:
+		if m.mid > startMid-drift {
+			// Touch moves down, the highest bid is taken out
+			if _, ok := m.bids[m.mid-1]; ok {
+				bids[m.mid-1] = 0
+			}
+			m.mid--
+		}
+	}
+
+	for i := 1 + m.rng.Intn(10); i > 0; i-- {
+		offset := min(int64(m.rng.ExpFloat64()*float64(m.config.Levels)/5), int64(m.config.Levels-1))
+		qty := int64(0)
+		if m.rng.Intn(10) >= 3 {
+			qty = 1 + m.rng.Int63n(maxLots)
+		}
+		if m.rng.Intn(2) == 0 {
+			bids[m.mid-1-offset] = qty
+		} else {
+			asks[m.mid+offset] = qty
+		}
+	}
+
+	update.Bids = m.applyChanges(m.bids, bids)
+	update.Asks = m.applyChanges(m.asks, asks)
+	return update
+}
+
+// applyChanges applies changes to one side of the true book and returns them as
+// levels, leaving out deletes of levels that do not exist
+func (m *mockExchange) applyChanges(side, changes map[int64]int64) []exchange.PriceLevel {
+	levels := make([]exchange.PriceLevel, 0, len(changes))
+	for price, qty := range changes {
+		if qty == 0 {
+			if _, ok := side[price]; !ok {
+				continue
+			}
+			delete(side, price)
+		} else {
+			side[price] = qty
+		}
+		levels = append(levels, exchange.PriceLevel{Price: formatPrice(price), Quantity: formatQuantity(qty)})
+	}
+	return levels
+}
+
+func formatLevels(side map[int64]int64) []exchange.PriceLevel {
+	levels := make([]exchange.PriceLevel, 0, len(side))
+	for price, qty := range side {
+		levels = append(levels, exchange.PriceLevel{Price: formatPrice(price), Quantity: formatQuantity(qty)})
+	}
+	return levels
+}
+
+func formatPrice(ticks int64) string {
+	return fmt.Sprintf("%d.%d", ticks/10, ticks%10)
+}
+
+func formatQuantity(lots int64) string {
+	return fmt.Sprintf("%d.%03d", lots/1000, lots%1000)
+}
+
+func copyLevels(side map[int64]int64) map[int64]int64 {
+	levels := make(map[int64]int64, len(side))
+	for price, qty := range side {
+		levels[price] = qty
+	}
+	return levels
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"orderbook/internal/exchange"
+)
+
+// startupReport collects how the exchanges of a symbol started and logs a
+// summary once every one of them has either initialized or failed
+type startupReport struct {
+	symbol  string
+	mu      sync.Mutex
+	pending int
+	started []string
+	failed  map[string]error
+}
+
+func newStartupReport(symbol string, exchanges int) *startupReport {
+	return &startupReport{symbol: symbol, pending: exchanges, failed: make(map[string]error)}
+}
+
+// record stores the outcome of an exchange, nil meaning it initialized
+func (r *startupReport) record(name exchange.ExchangeName, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pending == 0 {
+		return
+	}
+	if err != nil {
+		r.failed[string(name)] = err
+	} else {
+		r.started = append(r.started, string(name))
+	}
+	r.pending--
+	if r.pending == 0 {
+		r.log()
+	}
+}
+
+// log writes the summary (must be called with mutex locked)
+func (r *startupReport) log() {
+	total := len(r.started) + len(r.failed)
+	log.Printf("Startup %s: %d/%d exchanges running", r.symbol, len(r.started), total)
+
+	names := make([]string, 0, len(r.failed))
+	for name := range r.failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Printf("Startup %s: [%s] not running: %s", r.symbol, name, failureReason(r.failed[name]))
+	}
+}
+
+// failureReason describes a startup failure by its kind, followed by the error
+func failureReason(err error) string {
+	var kind string
+	switch {
+	case errors.Is(err, exchange.ErrSymbolNotFound):
+		kind = "symbol not listed"
+	case errors.Is(err, exchange.ErrAuth):
+		kind = "authentication rejected"
+	case errors.Is(err, exchange.ErrRateLimited):
+		kind = "rate limited"
+		if retryAfter, _ := exchange.RetryAfter(err); retryAfter > 0 {
+			kind += fmt.Sprintf(", retry after %v", retryAfter)
+		}
+	case errors.Is(err, exchange.ErrConnClosed):
+		kind = "connection closed"
+	default:
+		return err.Error()
+	}
+	return fmt.Sprintf("%s (%v)", kind, err)
+}
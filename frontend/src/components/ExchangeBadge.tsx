@@ -1,6 +1,7 @@
 import type { SVGProps } from 'react';
 import { Tooltip, TooltipContent, TooltipTrigger } from '@/components/ui/tooltip';
 import { Badge } from '@/components/ui/badge';
+import { getBaseExchangeName, isFuturesExchange } from '@/utils/calculations';
 import asterLogo from '@/assets/aster.png';
 import bingxLogo from '@/assets/bingx.png';
 import hyperliquidLogo from '@/assets/hyperliquid.png';
@@ -94,14 +95,14 @@ type ExchangeBadgeProps = {
  * Determines if an exchange is perpetual futures based on naming convention
  */
 function isPerps(exchange: string): boolean {
-  return exchange.endsWith('f');
+  return isFuturesExchange(exchange);
 }
 
 /**
- * Gets the clean exchange name without the 'f' suffix
+ * Gets the clean exchange name without the 'f' or 'fc' suffix
  */
 function getCleanExchangeName(exchange: string): string {
-  return exchange.replace(/f$/, '');
+  return getBaseExchangeName(exchange);
 }
 
 /**
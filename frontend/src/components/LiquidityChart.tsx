@@ -10,6 +10,7 @@ import {
 } from '@/components/ui/chart';
 import { ExchangeBadge } from '@/components/ExchangeBadge';
 import type { ChartDataPoint } from '@/types';
+import { isFuturesExchange } from '@/utils/calculations';
 
 type LiquidityChartProps = {
   title: string;
@@ -32,7 +33,7 @@ export const LiquidityChart = memo(function LiquidityChart({
 
   // Custom tick component to render exchange badges
   const CustomTick = ({ x, y, payload }: any) => {
-    const isPerps = payload.value.endsWith('f');
+    const isPerps = isFuturesExchange(payload.value);
 
     return (
       <g transform={`translate(${x},${y})`}>
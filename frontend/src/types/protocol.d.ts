@@ -0,0 +1,358 @@
+// Code generated by cmd/schemagen from internal/websocket; DO NOT EDIT.
+
+export type AnomalyMetric = 'spread' | 'depth05';
+
+export type BookSide = 'bid' | 'ask';
+
+export type ExchangeStatus = 'connecting' | 'connected' | 'initialized' | 'stale' | 'resyncing' | 'disconnected';
+
+export type MessageType = 'orderbook' | 'stats' | 'leadlag' | 'ticks' | 'ranking' | 'welcome' | 'bookdelta' | 'candle' | 'iceberg' | 'signal' | 'exchange_status' | 'anomaly' | 'ladder' | 'snapshot';
+
+export type Side = 'buy' | 'sell';
+
+export type ClientMessage = {
+  type: string;
+  tick?: number;
+  symbol?: string;
+  version?: number;
+  exchange?: string;
+  channel?: string;
+  unit?: string;
+  id?: string;
+  levels?: number;
+};
+
+export type WelcomeMessage = {
+  type: MessageType;
+  version: number;
+  supported: number[];
+  feeAdjusted?: boolean;
+  quote?: string;
+  unit?: string;
+  rate?: string;
+};
+
+export type PriceLevel = {
+  price: string;
+  quantity: string;
+  cumulative: string;
+  tail?: boolean;
+};
+
+export type OrderbookMessage = {
+  type: MessageType;
+  v?: number;
+  exchange: string;
+  seq?: number;
+  checksum?: number;
+  bids: PriceLevel[];
+  asks: PriceLevel[];
+  timestamp: number;
+};
+
+export type WindowAverage = {
+  window: string;
+  coveredMs: number;
+  spreadBps: string;
+  bidLiquidity05Pct: string;
+  askLiquidity05Pct: string;
+  bidLiquidity2Pct: string;
+  askLiquidity2Pct: string;
+  bidLiquidity10Pct: string;
+  askLiquidity10Pct: string;
+};
+
+export type StatsMessage = {
+  type: MessageType;
+  v?: number;
+  exchange: string;
+  bestBid: string;
+  bestAsk: string;
+  midPrice: string;
+  spread: string;
+  bidLiquidity05Pct: string;
+  askLiquidity05Pct: string;
+  deltaLiquidity05Pct: string;
+  bidLiquidity2Pct: string;
+  askLiquidity2Pct: string;
+  deltaLiquidity2Pct: string;
+  bidLiquidity10Pct: string;
+  askLiquidity10Pct: string;
+  deltaLiquidity10Pct: string;
+  totalBidsQty: string;
+  totalAsksQty: string;
+  totalDelta: string;
+  effectiveSpreadBps: string;
+  realizedSpreadBps: Record<string, string>;
+  averages: WindowAverage[];
+  fairValue: string;
+  fairValueDeviationBps: string;
+  fairValueAlert: boolean;
+  resilienceSweeps: number;
+  resilienceRecovered: string;
+  resilienceMs: number;
+  bidConcentration2Pct: string;
+  askConcentration2Pct: string;
+  openInterest: string;
+  openInterestChange: string;
+  prunedLevels: number;
+  eventLatencyMs: number;
+  eventsPerSecond: string;
+  eventsProcessed: number;
+  eventsBuffered: number;
+  eventsDropped: number;
+  gaps: number;
+  resyncs: number;
+  bufferOverflows: number;
+  crossedBook: boolean;
+  crossedBooks: number;
+  malformedLevels: number;
+  malformedMessages: number;
+  applyTimeNs: number;
+  timestamp: number;
+};
+
+export type TickLevelsMessage = {
+  type: MessageType;
+  v?: number;
+  levels: number[];
+  current: number;
+};
+
+export type LeadLagPair = {
+  leader: string;
+  follower: string;
+  lagMs: number;
+  correlation: number;
+};
+
+export type LeadLagMessage = {
+  type: MessageType;
+  v?: number;
+  pairs: LeadLagPair[];
+  scores: Record<string, number>;
+  timestamp: number;
+};
+
+export type VenueRanking = {
+  exchange: string;
+  rank: number;
+  score: number;
+  spreadBps: number;
+  spreadScore: number;
+  depth05PctScore: number;
+  depth2PctScore: number;
+  uptimeScore: number;
+  freshnessScore: number;
+};
+
+export type RankingMessage = {
+  type: MessageType;
+  v?: number;
+  venues: VenueRanking[];
+  timestamp: number;
+};
+
+export type LevelChange = {
+  side: BookSide;
+  price: string;
+  oldQuantity: string;
+  newQuantity: string;
+};
+
+export type BookDeltaMessage = {
+  type: MessageType;
+  v?: number;
+  exchange: string;
+  seq: number;
+  snapshot?: boolean;
+  changes: LevelChange[];
+  timestamp: number;
+};
+
+export type CandleBar = {
+  exchange: string;
+  source: string;
+  interval: string;
+  start: number;
+  open: string;
+  high: string;
+  low: string;
+  close: string;
+  samples: number;
+  closed: boolean;
+};
+
+export type CandleMessage = {
+  type: MessageType;
+  v?: number;
+  candles: CandleBar[];
+  timestamp: number;
+};
+
+export type IcebergMessage = {
+  type: MessageType;
+  v?: number;
+  exchange: string;
+  side: BookSide;
+  price: string;
+  displayed: string;
+  refills: number;
+  refilled: string;
+  timestamp: number;
+};
+
+export type SignalMessage = {
+  type: MessageType;
+  v?: number;
+  script: string;
+  exchange: string;
+  value: number;
+  timestamp: number;
+};
+
+export type ExchangeStatusMessage = {
+  type: MessageType;
+  v?: number;
+  exchange: string;
+  symbol?: string;
+  status: ExchangeStatus;
+  detail?: string;
+  timestamp: number;
+};
+
+export type AnomalyMessage = {
+  type: MessageType;
+  v?: number;
+  exchange: string;
+  metric: AnomalyMetric;
+  value: number;
+  mean: number;
+  stdDev: number;
+  zScore: number;
+  timestamp: number;
+};
+
+export type LadderBucket = {
+  price: string;
+  bids: string;
+  asks: string;
+  imbalance: string;
+};
+
+export type LadderMessage = {
+  type: MessageType;
+  v?: number;
+  tick: number;
+  mid: string;
+  venues: string[];
+  buckets: LadderBucket[];
+  timestamp: number;
+};
+
+export type SnapshotMessage = {
+  type: MessageType;
+  v?: number;
+  id?: string;
+  exchange: string;
+  symbol?: string;
+  tick?: number;
+  bids?: PriceLevel[];
+  asks?: PriceLevel[];
+  timestamp: number;
+  feeAdjusted?: boolean;
+  error?: string;
+};
+
+export type DepthResponse = {
+  exchange: string;
+  tick: number;
+  bids: PriceLevel[];
+  asks: PriceLevel[];
+  timestamp: number;
+  feeAdjusted?: boolean;
+};
+
+export type LiquidityResponse = {
+  exchange: string;
+  side: BookSide;
+  price: string;
+  quantity: string;
+  notional: string;
+  levels: number;
+  timestamp: number;
+  feeAdjusted?: boolean;
+};
+
+export type BookInfo = {
+  exchange: string;
+  symbol: string;
+  initialized: boolean;
+  ready: boolean;
+  lastUpdateId: number;
+  bidLevels: number;
+  askLevels: number;
+};
+
+export type BooksResponse = {
+  books: BookInfo[];
+  timestamp: number;
+};
+
+export type CandlesResponse = {
+  exchange: string;
+  source: string;
+  interval: string;
+  candles: CandleBar[];
+  timestamp: number;
+};
+
+export type ReportsResponse = {
+  dates: string[];
+};
+
+export type HealthResponse = {
+  status: string;
+  exchanges: Record<string, boolean>;
+  initialized: number;
+};
+
+export type FeedEvent = {
+  kind: string;
+  detail?: string;
+  timestamp: number;
+};
+
+export type FeedEventsResponse = {
+  exchange: string;
+  gaps: number;
+  resyncs: number;
+  bufferOverflows: number;
+  events: FeedEvent[];
+};
+
+export type RouteRequest = {
+  side: Side;
+  quantity: string;
+};
+
+export type Fill = {
+  exchange: string;
+  quantity: string;
+  notional: string;
+  fee: string;
+  avgPrice: string;
+};
+
+export type RouteResponse = {
+  side: Side;
+  requested: string;
+  filled: string;
+  notional: string;
+  fees: string;
+  cost: string;
+  avgPrice: string;
+  fills: Fill[];
+  bestSingle?: Fill;
+  timestamp: number;
+};
@@ -1,5 +1,10 @@
 import type { StatsData, MarketFilter } from '@/types';
 
+/**
+ * Suffix of futures exchange names: 'f', or 'fc' for coin-margined futures
+ */
+const FUTURES_SUFFIX = /fc?$/;
+
 /**
  * Filters exchanges based on market type
  */
@@ -8,8 +13,8 @@ export function filterExchangesByMarket(
   filter: MarketFilter
 ): boolean {
   if (filter === 'all') return true;
-  if (filter === 'spot') return !exchange.endsWith('f');
-  if (filter === 'perps') return exchange.endsWith('f');
+  if (filter === 'spot') return !isFuturesExchange(exchange);
+  if (filter === 'perps') return isFuturesExchange(exchange);
   return true;
 }
 
@@ -58,17 +63,17 @@ export function formatLargeNumber(value: number): string {
 }
 
 /**
- * Extracts the base exchange name (without 'f' suffix for futures)
+ * Extracts the base exchange name (without 'f' or 'fc' suffix for futures)
  */
 export function getBaseExchangeName(exchange: string): string {
-  return exchange.endsWith('f') ? exchange.slice(0, -1) : exchange;
+  return exchange.replace(FUTURES_SUFFIX, '');
 }
 
 /**
  * Checks if an exchange is a futures/perps market
  */
 export function isFuturesExchange(exchange: string): boolean {
-  return exchange.endsWith('f');
+  return FUTURES_SUFFIX.test(exchange);
 }
 
 /**
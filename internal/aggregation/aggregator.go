@@ -1,6 +1,8 @@
 package aggregation
 
 import (
+	"sort"
+
 	"github.com/shopspring/decimal"
 	"orderbook/internal/types"
 )
@@ -8,18 +10,20 @@ import (
 // Aggregator handles price aggregation based on tick levels
 type Aggregator struct {
 	currentTick types.TickLevel
+	tickSize    decimal.Decimal
 }
 
 // New creates a new Aggregator instance
 func New(tick types.TickLevel) *Aggregator {
-	return &Aggregator{
-		currentTick: tick,
-	}
+	a := &Aggregator{}
+	a.SetTickLevel(tick)
+	return a
 }
 
 // SetTickLevel updates the tick level for aggregation
 func (a *Aggregator) SetTickLevel(tick types.TickLevel) {
 	a.currentTick = tick
+	a.tickSize = decimal.NewFromFloat(float64(tick))
 }
 
 // GetTickLevel returns the current tick level
@@ -27,100 +31,138 @@ func (a *Aggregator) GetTickLevel() types.TickLevel {
 	return a.currentTick
 }
 
-// AggregateBids aggregates bid price levels by tick size (floors prices)
+// AggregateBids aggregates bid price levels by tick size (floors prices),
+// sorted by price descending (best first)
 func (a *Aggregator) AggregateBids(levels []types.PriceLevel) []types.PriceLevel {
 	if len(levels) == 0 {
 		return levels
 	}
 
-	tickMap := make(map[string]types.PriceLevel)
-
+	buckets := make(map[int64]decimal.Decimal)
 	for _, level := range levels {
-		roundedPrice := a.roundToTickBid(level.Price)
-		key := roundedPrice.String()
-
-		if existing, exists := tickMap[key]; exists {
-			// Aggregate quantity
-			tickMap[key] = types.PriceLevel{
-				Price:    roundedPrice,
-				Quantity: existing.Quantity.Add(level.Quantity),
-			}
-		} else {
-			tickMap[key] = types.PriceLevel{
-				Price:    roundedPrice,
-				Quantity: level.Quantity,
-			}
-		}
-	}
-
-	// Convert map back to slice
-	aggregated := make([]types.PriceLevel, 0, len(tickMap))
-	for _, level := range tickMap {
-		aggregated = append(aggregated, level)
+		key := a.BidBucket(level.Price)
+		buckets[key] = buckets[key].Add(level.Quantity)
 	}
-
-	return aggregated
+	return a.sortedLevels(buckets, true)
 }
 
-// AggregateAsks aggregates ask price levels by tick size (ceils prices)
+// AggregateAsks aggregates ask price levels by tick size (ceils prices),
+// sorted by price ascending (best first)
 func (a *Aggregator) AggregateAsks(levels []types.PriceLevel) []types.PriceLevel {
 	if len(levels) == 0 {
 		return levels
 	}
 
-	tickMap := make(map[string]types.PriceLevel)
-
+	buckets := make(map[int64]decimal.Decimal)
 	for _, level := range levels {
-		roundedPrice := a.roundToTickAsk(level.Price)
-		key := roundedPrice.String()
-
-		if existing, exists := tickMap[key]; exists {
-			// Aggregate quantity
-			tickMap[key] = types.PriceLevel{
-				Price:    roundedPrice,
-				Quantity: existing.Quantity.Add(level.Quantity),
-			}
-		} else {
-			tickMap[key] = types.PriceLevel{
-				Price:    roundedPrice,
-				Quantity: level.Quantity,
-			}
-		}
+		key := a.AskBucket(level.Price)
+		buckets[key] = buckets[key].Add(level.Quantity)
 	}
+	return a.sortedLevels(buckets, false)
+}
 
-	// Convert map back to slice
-	aggregated := make([]types.PriceLevel, 0, len(tickMap))
-	for _, level := range tickMap {
-		aggregated = append(aggregated, level)
+// BidBucket returns the stable bucket key of a bid price: floor(price / tick).
+// The bucket price is key * tick.
+func (a *Aggregator) BidBucket(price decimal.Decimal) int64 {
+	if a.tickSize.IsZero() {
+		return price.IntPart()
 	}
+	return price.Div(a.tickSize).Floor().IntPart()
+}
 
-	return aggregated
+// AskBucket returns the stable bucket key of an ask price: ceil(price / tick).
+// The bucket price is key * tick.
+func (a *Aggregator) AskBucket(price decimal.Decimal) int64 {
+	if a.tickSize.IsZero() {
+		return price.IntPart()
+	}
+	return price.Div(a.tickSize).Ceil().IntPart()
+}
+
+// BucketPrice returns the price of a bucket key
+func (a *Aggregator) BucketPrice(key int64) decimal.Decimal {
+	return decimal.NewFromInt(key).Mul(a.tickSize)
 }
 
 // roundToTickBid rounds a bid price DOWN to maintain proper spread
 func (a *Aggregator) roundToTickBid(price decimal.Decimal) decimal.Decimal {
-	tickSize := decimal.NewFromFloat(float64(a.currentTick))
-	if tickSize.IsZero() {
+	if a.tickSize.IsZero() {
 		return price
 	}
-
-	// Floor bids: floor(price / tickSize) * tickSize
-	divided := price.Div(tickSize)
-	floored := divided.Floor() // Floor to lower integer
-	return floored.Mul(tickSize)
+	return a.BucketPrice(a.BidBucket(price))
 }
 
 // roundToTickAsk rounds an ask price UP to maintain proper spread
 func (a *Aggregator) roundToTickAsk(price decimal.Decimal) decimal.Decimal {
-	tickSize := decimal.NewFromFloat(float64(a.currentTick))
-	if tickSize.IsZero() {
+	if a.tickSize.IsZero() {
 		return price
 	}
+	return a.BucketPrice(a.AskBucket(price))
+}
+
+// sortedLevels converts buckets to price levels, best price first
+func (a *Aggregator) sortedLevels(buckets map[int64]decimal.Decimal, isBid bool) []types.PriceLevel {
+	keys := make([]int64, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	if isBid {
+		sort.Slice(keys, func(i, j int) bool { return keys[i] > keys[j] })
+	} else {
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	}
+
+	aggregated := make([]types.PriceLevel, len(keys))
+	for i, key := range keys {
+		aggregated[i] = types.PriceLevel{
+			Price:    a.BucketPrice(key),
+			Quantity: buckets[key],
+		}
+	}
+	return aggregated
+}
 
-	// Ceiling asks: ceil(price / tickSize) * tickSize
-	divided := price.Div(tickSize)
-	ceiled := divided.Ceil() // Ceiling to higher integer
-	return ceiled.Mul(tickSize)
+// Cumulative returns the running quantity totals of levels sorted best first
+func Cumulative(levels []types.PriceLevel) []decimal.Decimal {
+	sums := make([]decimal.Decimal, len(levels))
+	total := decimal.Zero
+	for i, level := range levels {
+		total = total.Add(level.Quantity)
+		sums[i] = total
+	}
+	return sums
+}
+
+// CollapseTail folds the aggregated levels at or beyond edge into a single tail
+// level at the bucket price of edge, keeping the total quantity of the side in
+// the last level. Levels are sorted best first; edge is below the mid for bids
+// and above it for asks. It reports whether the last level is a tail.
+func (a *Aggregator) CollapseTail(levels []types.PriceLevel, edge decimal.Decimal, isBid bool) ([]types.PriceLevel, bool) {
+	if isBid {
+		edge = a.roundToTickBid(edge)
+	} else {
+		edge = a.roundToTickAsk(edge)
+	}
+
+	near := len(levels)
+	for i, level := range levels {
+		if (isBid && level.Price.LessThanOrEqual(edge)) || (!isBid && level.Price.GreaterThanOrEqual(edge)) {
+			near = i
+			break
+		}
+	}
+	if near == len(levels) {
+		return levels, false
+	}
+
+	tail := types.PriceLevel{Price: edge}
+	for _, level := range levels[near:] {
+		tail.Quantity = tail.Quantity.Add(level.Quantity)
+	}
+	collapsed := make([]types.PriceLevel, near+1)
+	copy(collapsed, levels[:near])
+	collapsed[near] = tail
+	return collapsed, true
 }
 
 // FilterLevels filters price levels based on best ask price to remove outliers
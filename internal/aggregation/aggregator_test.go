@@ -244,6 +244,126 @@ func TestFilterLevels(t *testing.T) {
 	}
 }
 
+func TestAggregateSortedWithCumulative(t *testing.T) {
+	agg := New(types.Tick10)
+	levels := []types.PriceLevel{
+		{Price: decimal.NewFromFloat(49985), Quantity: decimal.NewFromFloat(3)},
+		{Price: decimal.NewFromFloat(50005), Quantity: decimal.NewFromFloat(1)},
+		{Price: decimal.NewFromFloat(49995), Quantity: decimal.NewFromFloat(2)},
+	}
+
+	bids := agg.AggregateBids(levels)
+	expectedBids := []string{"50000", "49990", "49980"}
+	for i, expected := range expectedBids {
+		if bids[i].Price.String() != expected {
+			t.Errorf("Bid %d: Expected %s, got %s", i, expected, bids[i].Price.String())
+		}
+	}
+
+	asks := agg.AggregateAsks(levels)
+	expectedAsks := []string{"49990", "50000", "50010"}
+	for i, expected := range expectedAsks {
+		if asks[i].Price.String() != expected {
+			t.Errorf("Ask %d: Expected %s, got %s", i, expected, asks[i].Price.String())
+		}
+	}
+
+	cumulative := Cumulative(bids)
+	expectedCumulative := []string{"1", "3", "6"}
+	for i, expected := range expectedCumulative {
+		if cumulative[i].String() != expected {
+			t.Errorf("Cumulative %d: Expected %s, got %s", i, expected, cumulative[i].String())
+		}
+	}
+}
+
+func TestCollapseTail(t *testing.T) {
+	agg := New(types.Tick10)
+	bids := agg.AggregateBids([]types.PriceLevel{
+		{Price: decimal.NewFromFloat(50005), Quantity: decimal.NewFromFloat(1)},
+		{Price: decimal.NewFromFloat(49995), Quantity: decimal.NewFromFloat(2)},
+		{Price: decimal.NewFromFloat(49985), Quantity: decimal.NewFromFloat(3)},
+		{Price: decimal.NewFromFloat(40000), Quantity: decimal.NewFromFloat(4)},
+	})
+
+	// The edge is floored to 49980, whose bucket starts the tail
+	collapsed, tail := agg.CollapseTail(bids, decimal.NewFromFloat(49987), true)
+	if !tail || len(collapsed) != 3 {
+		t.Fatalf("Expected 3 levels and a tail, got %v (tail %v)", collapsed, tail)
+	}
+	if collapsed[2].Price.String() != "49980" || collapsed[2].Quantity.String() != "7" {
+		t.Errorf("Expected tail 49980@7, got %s@%s", collapsed[2].Price, collapsed[2].Quantity)
+	}
+	if collapsed[1].Price.String() != "49990" {
+		t.Errorf("Expected near levels unchanged, got %v", collapsed)
+	}
+
+	asks := agg.AggregateAsks([]types.PriceLevel{
+		{Price: decimal.NewFromFloat(50005), Quantity: decimal.NewFromFloat(1)},
+		{Price: decimal.NewFromFloat(50015), Quantity: decimal.NewFromFloat(2)},
+	})
+	collapsed, tail = agg.CollapseTail(asks, decimal.NewFromFloat(50011), false)
+	if !tail || len(collapsed) != 2 || collapsed[1].Price.String() != "50020" || collapsed[1].Quantity.String() != "2" {
+		t.Errorf("Expected ask tail 50020@2, got %v (tail %v)", collapsed, tail)
+	}
+
+	// Nothing beyond the edge
+	collapsed, tail = agg.CollapseTail(asks, decimal.NewFromFloat(60000), false)
+	if tail || len(collapsed) != 2 {
+		t.Errorf("Expected no tail, got %v", collapsed)
+	}
+}
+
+func TestIncrementalMatchesFullAggregation(t *testing.T) {
+	agg := New(types.Tick1)
+	inc := NewIncremental(types.Tick1)
+
+	book := map[string]types.PriceLevel{
+		"50000.5": {Price: decimal.RequireFromString("50000.5"), Quantity: decimal.RequireFromString("1")},
+		"50000.2": {Price: decimal.RequireFromString("50000.2"), Quantity: decimal.RequireFromString("2")},
+		"49999.9": {Price: decimal.RequireFromString("49999.9"), Quantity: decimal.RequireFromString("0.5")},
+	}
+	levels := func() []types.PriceLevel {
+		result := make([]types.PriceLevel, 0, len(book))
+		for _, level := range book {
+			result = append(result, level)
+		}
+		return result
+	}
+	inc.Reset(levels(), nil)
+
+	deltas := []struct {
+		price, qty string
+	}{
+		{"50000.2", "0.75"}, // Modify
+		{"50000.5", "0"},    // Remove
+		{"50001.3", "4"},    // Add in a new bucket
+		{"49999.1", "0.25"}, // Add to an existing bucket
+	}
+	for _, d := range deltas {
+		price := decimal.RequireFromString(d.price)
+		qty := decimal.RequireFromString(d.qty)
+		inc.UpdateBid(price, book[d.price].Quantity, qty)
+		if qty.IsZero() {
+			delete(book, d.price)
+		} else {
+			book[d.price] = types.PriceLevel{Price: price, Quantity: qty}
+		}
+	}
+
+	expected := agg.AggregateBids(levels())
+	got := inc.Bids()
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d buckets, got %d", len(expected), len(got))
+	}
+	for i := range expected {
+		if !got[i].Price.Equal(expected[i].Price) || !got[i].Quantity.Equal(expected[i].Quantity) {
+			t.Errorf("Bucket %d: Expected %s@%s, got %s@%s", i,
+				expected[i].Price, expected[i].Quantity, got[i].Price, got[i].Quantity)
+		}
+	}
+}
+
 // Benchmarks
 
 func BenchmarkAggregateBids(b *testing.B) {
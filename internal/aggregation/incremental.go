@@ -0,0 +1,74 @@
+package aggregation
+
+import (
+	"github.com/shopspring/decimal"
+	"orderbook/internal/types"
+)
+
+// Incremental maintains aggregated buckets from level deltas instead of
+// rebuilding them from the full book on every read
+type Incremental struct {
+	agg  *Aggregator
+	bids map[int64]decimal.Decimal // Bucket key -> total quantity
+	asks map[int64]decimal.Decimal
+}
+
+// NewIncremental creates an empty incremental aggregator
+func NewIncremental(tick types.TickLevel) *Incremental {
+	return &Incremental{
+		agg:  New(tick),
+		bids: make(map[int64]decimal.Decimal),
+		asks: make(map[int64]decimal.Decimal),
+	}
+}
+
+// GetTickLevel returns the tick level of the buckets
+func (inc *Incremental) GetTickLevel() types.TickLevel {
+	return inc.agg.GetTickLevel()
+}
+
+// Reset rebuilds the buckets from full books
+func (inc *Incremental) Reset(bids, asks []types.PriceLevel) {
+	inc.bids = make(map[int64]decimal.Decimal)
+	inc.asks = make(map[int64]decimal.Decimal)
+	for _, level := range bids {
+		inc.UpdateBid(level.Price, decimal.Zero, level.Quantity)
+	}
+	for _, level := range asks {
+		inc.UpdateAsk(level.Price, decimal.Zero, level.Quantity)
+	}
+}
+
+// UpdateBid applies a bid level change from oldQty to newQty (zero when absent)
+func (inc *Incremental) UpdateBid(price, oldQty, newQty decimal.Decimal) {
+	applyDelta(inc.bids, inc.agg.BidBucket(price), newQty.Sub(oldQty))
+}
+
+// UpdateAsk applies an ask level change from oldQty to newQty (zero when absent)
+func (inc *Incremental) UpdateAsk(price, oldQty, newQty decimal.Decimal) {
+	applyDelta(inc.asks, inc.agg.AskBucket(price), newQty.Sub(oldQty))
+}
+
+// Bids returns the aggregated bids sorted by price descending
+func (inc *Incremental) Bids() []types.PriceLevel {
+	return inc.agg.sortedLevels(inc.bids, true)
+}
+
+// Asks returns the aggregated asks sorted by price ascending
+func (inc *Incremental) Asks() []types.PriceLevel {
+	return inc.agg.sortedLevels(inc.asks, false)
+}
+
+// applyDelta adds delta to a bucket, removing it once empty
+func applyDelta(buckets map[int64]decimal.Decimal, key int64, delta decimal.Decimal) {
+	if delta.IsZero() {
+		return
+	}
+
+	total := buckets[key].Add(delta)
+	if total.Sign() <= 0 {
+		delete(buckets, key)
+		return
+	}
+	buckets[key] = total
+}
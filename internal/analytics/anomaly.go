@@ -0,0 +1,151 @@
+package analytics
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// AnomalyConfig holds the parameters of the AnomalyDetector
+type AnomalyConfig struct {
+	Window     int           // Samples of each metric in the rolling mean and standard deviation
+	MinSamples int           // Samples required before a metric is checked
+	ZScore     float64       // Distance from the mean, in standard deviations, that is anomalous
+	Cooldown   time.Duration // Minimum delay between two anomalies of the same venue and metric
+}
+
+// AnomalyMetric identifies a monitored book statistic
+type AnomalyMetric string
+
+// Monitored metrics
+const (
+	AnomalySpread AnomalyMetric = "spread"  // Spread in bps of mid
+	AnomalyDepth  AnomalyMetric = "depth05" // Bid plus ask quantity within 0.5% of mid
+)
+
+// anomalyMetrics lists the metrics in the order of the values passed to Observe
+var anomalyMetrics = []AnomalyMetric{AnomalySpread, AnomalyDepth}
+
+// AnomalySignal flags a value far from the recent distribution of its metric
+type AnomalySignal struct {
+	Venue  string
+	Time   time.Time
+	Metric AnomalyMetric
+	Value  float64
+	Mean   float64 // Rolling mean before the value
+	StdDev float64 // Rolling standard deviation before the value
+	ZScore float64 // (Value - Mean) / StdDev
+}
+
+// rollingSeries keeps the latest samples of one metric
+type rollingSeries struct {
+	samples []float64
+	next    int // Position of the next sample once the ring is full
+	flagged time.Time
+}
+
+// add appends a sample, replacing the oldest once window samples are kept
+func (s *rollingSeries) add(value float64, window int) {
+	if len(s.samples) < window {
+		s.samples = append(s.samples, value)
+		return
+	}
+	s.samples[s.next] = value
+	s.next = (s.next + 1) % window
+}
+
+// meanStdDev returns the mean and population standard deviation of the samples
+func (s *rollingSeries) meanStdDev() (float64, float64) {
+	var sum float64
+	for _, sample := range s.samples {
+		sum += sample
+	}
+	mean := sum / float64(len(s.samples))
+	var squares float64
+	for _, sample := range s.samples {
+		squares += (sample - mean) * (sample - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(s.samples)))
+}
+
+// AnomalyDetector tracks the rolling mean and standard deviation of the spread and
+// 0.5% depth of every venue and flags values whose z-score reaches the configured
+// threshold. Each value is compared with the samples before it, then added to them,
+// so a lasting change of regime stops being flagged once the window caught up.
+type AnomalyDetector struct {
+	mu     sync.Mutex
+	config AnomalyConfig
+	venues map[string][]*rollingSeries // One series per entry of anomalyMetrics
+}
+
+// NewAnomalyDetector creates a new AnomalyDetector instance
+func NewAnomalyDetector(config AnomalyConfig) *AnomalyDetector {
+	if config.Window < 2 {
+		config.Window = 2
+	}
+	if config.MinSamples < 2 {
+		config.MinSamples = 2
+	}
+	return &AnomalyDetector{
+		config: config,
+		venues: make(map[string][]*rollingSeries),
+	}
+}
+
+// Observe records the spread (in bps of mid) and 0.5% depth of venue at t and
+// returns the metrics found anomalous. Metrics that never varied within the window
+// are not checked, as any change would have an infinite z-score.
+func (d *AnomalyDetector) Observe(venue string, t time.Time, spreadBps, depth float64) []AnomalySignal {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	series, ok := d.venues[venue]
+	if !ok {
+		series = make([]*rollingSeries, len(anomalyMetrics))
+		for i := range series {
+			series[i] = &rollingSeries{}
+		}
+		d.venues[venue] = series
+	}
+
+	var signals []AnomalySignal
+	for i, value := range []float64{spreadBps, depth} {
+		s := series[i]
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		if len(s.samples) >= d.config.MinSamples && t.Sub(s.flagged) >= d.config.Cooldown {
+			mean, stdDev := s.meanStdDev()
+			if stdDev > 0 {
+				if z := (value - mean) / stdDev; math.Abs(z) >= d.config.ZScore {
+					s.flagged = t
+					signals = append(signals, AnomalySignal{
+						Venue:  venue,
+						Time:   t,
+						Metric: anomalyMetrics[i],
+						Value:  value,
+						Mean:   mean,
+						StdDev: stdDev,
+						ZScore: z,
+					})
+				}
+			}
+		}
+		s.add(value, d.config.Window)
+	}
+	return signals
+}
+
+// ResetVenue discards the samples of venue
+func (d *AnomalyDetector) ResetVenue(venue string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.venues, venue)
+}
+
+// Reset discards the samples of every venue, e.g. after a symbol change
+func (d *AnomalyDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.venues = make(map[string][]*rollingSeries)
+}
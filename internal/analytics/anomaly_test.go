@@ -0,0 +1,70 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	detector := NewAnomalyDetector(AnomalyConfig{
+		Window:     20,
+		MinSamples: 10,
+		ZScore:     4,
+		Cooldown:   time.Minute,
+	})
+	base := time.Unix(1700000000, 0)
+
+	// The spread alternates between 1 and 2 bps and the depth between 9 and 11
+	for i := 0; i < 20; i++ {
+		spread, depth := 1.0, 9.0
+		if i%2 == 1 {
+			spread, depth = 2, 11
+		}
+		if signals := detector.Observe("okx", base.Add(time.Duration(i)*time.Second), spread, depth); len(signals) != 0 {
+			t.Fatalf("sample %d: Expected no anomaly in the normal range, got %+v", i, signals)
+		}
+	}
+
+	// The spread widens to 10 bps while half the depth is pulled
+	at := base.Add(20 * time.Second)
+	signals := detector.Observe("okx", at, 10, 5)
+	if len(signals) != 2 {
+		t.Fatalf("Expected spread and depth anomalies, got %+v", signals)
+	}
+	spread, depth := signals[0], signals[1]
+	if spread.Metric != AnomalySpread || spread.Venue != "okx" || !spread.Time.Equal(at) || spread.Mean != 1.5 || spread.StdDev != 0.5 || spread.ZScore != 17 {
+		t.Errorf("Expected a spread z-score of 17 against 1.5 +/- 0.5, got %+v", spread)
+	}
+	if depth.Metric != AnomalyDepth || depth.ZScore != -5 {
+		t.Errorf("Expected a depth z-score of -5, got %+v", depth)
+	}
+
+	// Further spikes of the same metrics wait for the cooldown
+	if signals := detector.Observe("okx", at.Add(time.Second), 20, 1); len(signals) != 0 {
+		t.Errorf("Expected no anomaly within the cooldown, got %+v", signals)
+	}
+
+	tests := []struct {
+		name    string
+		samples []float64
+		value   float64
+		flagged bool
+	}{
+		{"too few samples", []float64{1, 2, 1}, 50, false},
+		{"constant metric", []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, 2, false},
+		{"below threshold", []float64{1, 2, 1, 2, 1, 2, 1, 2, 1, 2}, 3, false},
+		{"non-finite value", []float64{1, 2, 1, 2, 1, 2, 1, 2, 1, 2}, math.Inf(1), false},
+	}
+
+	for _, tt := range tests {
+		detector.Reset()
+		for i, sample := range tt.samples {
+			detector.Observe("bybit", base.Add(time.Duration(i)*time.Second), sample, 10)
+		}
+		signals := detector.Observe("bybit", base.Add(time.Minute), tt.value, 10)
+		if (len(signals) > 0) != tt.flagged {
+			t.Errorf("%s: Expected flagged=%v, got %+v", tt.name, tt.flagged, signals)
+		}
+	}
+}
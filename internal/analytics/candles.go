@@ -0,0 +1,199 @@
+package analytics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CandleSource is the price a candle is built from
+type CandleSource string
+
+const (
+	CandleMid        CandleSource = "mid"        // (best bid + best ask) / 2
+	CandleMicroprice CandleSource = "microprice" // Mid weighted by the size on the opposite side
+)
+
+// CandleConfig holds the parameters of the CandleBuilder
+type CandleConfig struct {
+	Intervals  []time.Duration // Candle intervals built for every venue (e.g., 1s, 5s, 1m)
+	History    int             // Closed candles kept per venue, source and interval
+	Microprice bool            // Also build microprice candles
+}
+
+// Candle is the OHLC of a venue's price over one interval
+type Candle struct {
+	Venue    string
+	Source   CandleSource
+	Interval time.Duration
+	Start    time.Time // Start of the interval, aligned on wall-clock multiples of Interval
+	Open     decimal.Decimal
+	High     decimal.Decimal
+	Low      decimal.Decimal
+	Close    decimal.Decimal
+	Samples  int  // Prices sampled in the interval
+	Closed   bool // False for the candle still being built
+}
+
+// candleKey identifies a candle series
+type candleKey struct {
+	venue    string
+	source   CandleSource
+	interval time.Duration
+}
+
+// candleSeries is the closed candles of a series, oldest first, and the one being built
+type candleSeries struct {
+	closed  []Candle
+	current *Candle
+}
+
+// CandleBuilder aggregates sampled book prices into OHLC candles per venue, so
+// prices can be charted without a trade feed
+type CandleBuilder struct {
+	mu     sync.Mutex
+	config CandleConfig
+	series map[candleKey]*candleSeries
+}
+
+// NewCandleBuilder creates a new CandleBuilder instance
+func NewCandleBuilder(config CandleConfig) *CandleBuilder {
+	if config.History < 1 {
+		config.History = 1
+	}
+	return &CandleBuilder{
+		config: config,
+		series: make(map[candleKey]*candleSeries),
+	}
+}
+
+// Microprice returns the mid weighted by the size on the opposite side, which
+// leans towards the side more likely to be hit. It is the mid when both sizes are zero.
+func Microprice(bid, bidQty, ask, askQty decimal.Decimal) decimal.Decimal {
+	total := bidQty.Add(askQty)
+	if total.IsZero() {
+		return bid.Add(ask).Div(decimal.NewFromInt(2))
+	}
+	return bid.Mul(askQty).Add(ask.Mul(bidQty)).Div(total)
+}
+
+// Sample adds the venue's prices at t to every interval and returns the candles
+// the sample closed. The microprice is ignored unless enabled; zero prices are skipped.
+func (b *CandleBuilder) Sample(venue string, t time.Time, mid, microprice decimal.Decimal) []Candle {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var closed []Candle
+	prices := []struct {
+		source CandleSource
+		price  decimal.Decimal
+	}{
+		{CandleMid, mid},
+		{CandleMicroprice, microprice},
+	}
+	for _, p := range prices {
+		if p.price.IsZero() || (p.source == CandleMicroprice && !b.config.Microprice) {
+			continue
+		}
+		for _, interval := range b.config.Intervals {
+			if candle, ok := b.add(candleKey{venue: venue, source: p.source, interval: interval}, t, p.price); ok {
+				closed = append(closed, candle)
+			}
+		}
+	}
+	return closed
+}
+
+// add applies a price to a series and returns the candle it closed, if any (must be called with mutex locked)
+func (b *CandleBuilder) add(key candleKey, t time.Time, price decimal.Decimal) (Candle, bool) {
+	series, ok := b.series[key]
+	if !ok {
+		series = &candleSeries{}
+		b.series[key] = series
+	}
+
+	start := t.Truncate(key.interval)
+	current := series.current
+	if current != nil && !start.After(current.Start) {
+		// Samples arriving late for a closed interval are folded into the current one
+		current.High = decimal.Max(current.High, price)
+		current.Low = decimal.Min(current.Low, price)
+		current.Close = price
+		current.Samples++
+		return Candle{}, false
+	}
+
+	series.current = &Candle{
+		Venue:    key.venue,
+		Source:   key.source,
+		Interval: key.interval,
+		Start:    start,
+		Open:     price,
+		High:     price,
+		Low:      price,
+		Close:    price,
+		Samples:  1,
+	}
+	if current == nil {
+		return Candle{}, false
+	}
+
+	current.Closed = true
+	series.closed = append(series.closed, *current)
+	if excess := len(series.closed) - b.config.History; excess > 0 {
+		series.closed = append(series.closed[:0], series.closed[excess:]...)
+	}
+	return *current, true
+}
+
+// History returns up to limit of the venue's latest candles, oldest first, ending
+// with the one being built. It returns false if the source and interval are not built.
+func (b *CandleBuilder) History(venue string, source CandleSource, interval time.Duration, limit int) ([]Candle, bool) {
+	if !b.Builds(source, interval) {
+		return nil, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	series, ok := b.series[candleKey{venue: venue, source: source, interval: interval}]
+	if !ok {
+		return []Candle{}, true
+	}
+	candles := make([]Candle, 0, len(series.closed)+1)
+	candles = append(candles, series.closed...)
+	if series.current != nil {
+		candles = append(candles, *series.current)
+	}
+	if limit > 0 && len(candles) > limit {
+		candles = candles[len(candles)-limit:]
+	}
+	return candles, true
+}
+
+// Builds reports whether candles of the source and interval are built
+func (b *CandleBuilder) Builds(source CandleSource, interval time.Duration) bool {
+	switch source {
+	case CandleMid:
+	case CandleMicroprice:
+		if !b.config.Microprice {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, configured := range b.config.Intervals {
+		if configured == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset discards every candle, e.g. after a symbol change
+func (b *CandleBuilder) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.series = make(map[candleKey]*candleSeries)
+}
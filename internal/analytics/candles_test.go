@@ -0,0 +1,92 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCandleBuilder(t *testing.T) {
+	builder := NewCandleBuilder(CandleConfig{
+		Intervals:  []time.Duration{time.Second, 5 * time.Second},
+		History:    2,
+		Microprice: true,
+	})
+	base := time.Unix(1700000000, 0)
+	price := func(v int64) decimal.Decimal { return decimal.NewFromInt(v) }
+
+	samples := []struct {
+		offset time.Duration
+		mid    int64
+	}{
+		{0, 100},
+		{200 * time.Millisecond, 104},
+		{600 * time.Millisecond, 98},
+		{900 * time.Millisecond, 101},
+		{1100 * time.Millisecond, 102}, // Closes the first 1s candle
+		{2500 * time.Millisecond, 103}, // Closes the second
+		{5 * time.Second, 99},          // Closes the third and the first 5s candle
+	}
+	var closed []Candle
+	for _, s := range samples {
+		closed = append(closed, builder.Sample("okx", base.Add(s.offset), price(s.mid), decimal.Zero)...)
+	}
+
+	if len(closed) != 4 {
+		t.Fatalf("Expected 4 closed candles, got %+v", closed)
+	}
+	first := closed[0]
+	if !first.Start.Equal(base) || first.Interval != time.Second || !first.Closed || first.Samples != 4 {
+		t.Errorf("Expected a closed 1s candle at %v with 4 samples, got %+v", base, first)
+	}
+	if !first.Open.Equal(price(100)) || !first.High.Equal(price(104)) || !first.Low.Equal(price(98)) || !first.Close.Equal(price(101)) {
+		t.Errorf("Expected OHLC 100/104/98/101, got %v/%v/%v/%v", first.Open, first.High, first.Low, first.Close)
+	}
+	five := closed[3]
+	if five.Interval != 5*time.Second || five.Samples != 6 || !five.High.Equal(price(104)) || !five.Close.Equal(price(103)) {
+		t.Errorf("Expected a 5s candle of 6 samples, high 104 and close 103, got %+v", five)
+	}
+
+	// History keeps the 2 latest closed candles plus the one being built
+	history, ok := builder.History("okx", CandleMid, time.Second, 0)
+	if !ok || len(history) != 3 {
+		t.Fatalf("Expected 3 candles, got %+v", history)
+	}
+	if !history[0].Start.Equal(base.Add(time.Second)) || history[2].Closed || !history[2].Open.Equal(price(99)) {
+		t.Errorf("Expected history from %v ending with the open candle, got %+v", base.Add(time.Second), history)
+	}
+	if history, _ := builder.History("okx", CandleMid, time.Second, 1); len(history) != 1 || history[0].Closed {
+		t.Errorf("Expected limit 1 to return the open candle, got %+v", history)
+	}
+
+	// Zero microprices are skipped, unknown intervals are not built
+	if history, ok := builder.History("okx", CandleMicroprice, time.Second, 0); !ok || len(history) != 0 {
+		t.Errorf("Expected no microprice candles, got %+v", history)
+	}
+	if _, ok := builder.History("okx", CandleMid, time.Minute, 0); ok {
+		t.Errorf("Expected 1m candles not to be built")
+	}
+
+	builder.Reset()
+	if history, _ := builder.History("okx", CandleMid, time.Second, 0); len(history) != 0 {
+		t.Errorf("Expected no candles after Reset, got %+v", history)
+	}
+}
+
+func TestMicroprice(t *testing.T) {
+	tests := []struct {
+		bidQty, askQty int64
+		want           string
+	}{
+		{1, 1, "100.5"},
+		{3, 1, "100.75"}, // Heavier bid leans towards the ask
+		{0, 0, "100.5"},
+	}
+	for _, tt := range tests {
+		got := Microprice(decimal.NewFromInt(100), decimal.NewFromInt(tt.bidQty), decimal.NewFromInt(101), decimal.NewFromInt(tt.askQty))
+		if got.String() != tt.want {
+			t.Errorf("bid qty %d, ask qty %d: Expected %s, got %s", tt.bidQty, tt.askQty, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package analytics
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// VenueQuote is a venue's mid price and the levels it contributes to the
+// consolidated book, priced in the common quote
+type VenueQuote struct {
+	Venue string
+	Mid   decimal.Decimal
+	Bids  []types.PriceLevel // Levels near the touch (e.g., within 0.5% of mid)
+	Asks  []types.PriceLevel
+}
+
+// VenueDeviation describes how far a venue's mid is from the fair value
+type VenueDeviation struct {
+	Venue        string
+	Mid          decimal.Decimal
+	DeviationBps decimal.Decimal // (mid - fair value) / fair value in basis points
+	Alert        bool            // True when |DeviationBps| exceeds the threshold
+}
+
+// FairValueReport is the result of a fair value evaluation
+type FairValueReport struct {
+	Timestamp  time.Time
+	FairValue  decimal.Decimal
+	Deviations []VenueDeviation
+}
+
+// FairValueMonitor computes a fair value from the consolidated book of all venues
+// and flags venues whose mid deviates from it by more than a threshold
+type FairValueMonitor struct {
+	mu           sync.Mutex
+	thresholdBps decimal.Decimal
+	minVenues    int
+	alerting     map[string]bool
+}
+
+// NewFairValueMonitor creates a new FairValueMonitor instance
+func NewFairValueMonitor(thresholdBps float64, minVenues int) *FairValueMonitor {
+	if minVenues < 2 {
+		minVenues = 2
+	}
+	return &FairValueMonitor{
+		thresholdBps: decimal.NewFromFloat(thresholdBps),
+		minVenues:    minVenues,
+		alerting:     make(map[string]bool),
+	}
+}
+
+// Evaluate computes the fair value from the given quotes and each venue's deviation.
+// It returns false if there are not enough valid venues to compute a fair value.
+func (m *FairValueMonitor) Evaluate(quotes []VenueQuote) (FairValueReport, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fairValue, ok := m.consolidatedMid(quotes)
+	if !ok {
+		return FairValueReport{}, false
+	}
+
+	report := FairValueReport{
+		Timestamp:  time.Now(),
+		FairValue:  fairValue,
+		Deviations: make([]VenueDeviation, 0, len(quotes)),
+	}
+
+	for _, quote := range quotes {
+		if quote.Mid.IsZero() {
+			continue
+		}
+
+		deviation := quote.Mid.Sub(fairValue).Div(fairValue).Mul(decimal.NewFromInt(10000))
+		alert := deviation.Abs().GreaterThan(m.thresholdBps)
+
+		// Only log state transitions to avoid flooding the output
+		if alert && !m.alerting[quote.Venue] {
+			log.Printf("[%s] Fair value alert: mid %s deviates %s bps from fair value %s",
+				quote.Venue, quote.Mid.StringFixed(2), deviation.StringFixed(2), fairValue.StringFixed(2))
+		} else if !alert && m.alerting[quote.Venue] {
+			log.Printf("[%s] Fair value alert cleared: deviation %s bps", quote.Venue, deviation.StringFixed(2))
+		}
+		m.alerting[quote.Venue] = alert
+
+		report.Deviations = append(report.Deviations, VenueDeviation{
+			Venue:        quote.Venue,
+			Mid:          quote.Mid,
+			DeviationBps: deviation,
+			Alert:        alert,
+		})
+	}
+
+	sort.Slice(report.Deviations, func(i, j int) bool {
+		return report.Deviations[i].Venue < report.Deviations[j].Venue
+	})
+
+	return report, true
+}
+
+// Reset clears alert state (e.g., after a symbol change)
+func (m *FairValueMonitor) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alerting = make(map[string]bool)
+}
+
+// consolidatedMid merges the near-touch levels of the venues into one book and
+// returns the midpoint of its depth-weighted bid and ask prices, so deeper
+// levels move the fair value more. Venues without a mid or without levels on
+// both sides are left out (must be called with mutex locked).
+func (m *FairValueMonitor) consolidatedMid(quotes []VenueQuote) (decimal.Decimal, bool) {
+	bidNotional, bidQty := decimal.Zero, decimal.Zero
+	askNotional, askQty := decimal.Zero, decimal.Zero
+	venues := 0
+
+	for _, quote := range quotes {
+		if quote.Mid.IsZero() || len(quote.Bids) == 0 || len(quote.Asks) == 0 {
+			continue
+		}
+		for _, level := range quote.Bids {
+			bidNotional = bidNotional.Add(level.Price.Mul(level.Quantity))
+			bidQty = bidQty.Add(level.Quantity)
+		}
+		for _, level := range quote.Asks {
+			askNotional = askNotional.Add(level.Price.Mul(level.Quantity))
+			askQty = askQty.Add(level.Quantity)
+		}
+		venues++
+	}
+
+	if venues < m.minVenues || !bidQty.IsPositive() || !askQty.IsPositive() {
+		return decimal.Zero, false
+	}
+	bid := bidNotional.Div(bidQty)
+	ask := askNotional.Div(askQty)
+	return bid.Add(ask).Div(decimal.NewFromInt(2)), true
+}
@@ -0,0 +1,116 @@
+package analytics
+
+import (
+	"testing"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// fairValueQuote builds a venue quote with one bid and one ask level, given as price and quantity pairs
+func fairValueQuote(venue string, mid float64, bid, ask []float64) VenueQuote {
+	q := VenueQuote{Venue: venue, Mid: decimal.NewFromFloat(mid)}
+	if bid != nil {
+		q.Bids = []types.PriceLevel{{Price: decimal.NewFromFloat(bid[0]), Quantity: decimal.NewFromFloat(bid[1])}}
+	}
+	if ask != nil {
+		q.Asks = []types.PriceLevel{{Price: decimal.NewFromFloat(ask[0]), Quantity: decimal.NewFromFloat(ask[1])}}
+	}
+	return q
+}
+
+func TestFairValueConsolidatedBook(t *testing.T) {
+	tests := []struct {
+		name      string
+		quotes    []VenueQuote
+		ok        bool
+		fairValue string
+	}{
+		{
+			name:      "equal depth",
+			quotes:    []VenueQuote{fairValueQuote("a", 100, []float64{99, 1}, []float64{101, 1}), fairValueQuote("b", 102, []float64{101, 1}, []float64{103, 1})},
+			ok:        true,
+			fairValue: "101",
+		},
+		{
+			// Bids average (99 + 3 * 100) / 4, asks (101 + 102) / 2
+			name:      "deeper levels weigh more",
+			quotes:    []VenueQuote{fairValueQuote("a", 100, []float64{99, 1}, []float64{101, 1}), fairValueQuote("b", 101, []float64{100, 3}, []float64{102, 1})},
+			ok:        true,
+			fairValue: "100.625",
+		},
+		{
+			name: "one-sided book left out",
+			quotes: []VenueQuote{
+				fairValueQuote("a", 100, []float64{99, 1}, []float64{101, 1}),
+				fairValueQuote("b", 102, []float64{101, 1}, []float64{103, 1}),
+				fairValueQuote("c", 90, []float64{89, 100}, nil),
+			},
+			ok:        true,
+			fairValue: "101",
+		},
+		{
+			name:   "too few venues with both sides",
+			quotes: []VenueQuote{fairValueQuote("a", 100, []float64{99, 1}, []float64{101, 1}), fairValueQuote("c", 90, nil, []float64{91, 1})},
+		},
+		{
+			name:   "venue without mid left out",
+			quotes: []VenueQuote{fairValueQuote("a", 100, []float64{99, 1}, []float64{101, 1}), fairValueQuote("b", 0, []float64{99, 1}, []float64{101, 1})},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		report, ok := NewFairValueMonitor(100, 2).Evaluate(tt.quotes)
+		if ok != tt.ok {
+			t.Errorf("%s: Expected ok=%v, got %v", tt.name, tt.ok, ok)
+			continue
+		}
+		if ok && report.FairValue.String() != tt.fairValue {
+			t.Errorf("%s: Expected fair value %s, got %s", tt.name, tt.fairValue, report.FairValue)
+		}
+	}
+}
+
+func TestFairValueDeviationAlerts(t *testing.T) {
+	// Both books average 100, the mids sit 50bps on either side
+	quotes := []VenueQuote{
+		fairValueQuote("okx", 100.5, []float64{99, 1}, []float64{101, 1}),
+		fairValueQuote("binance", 99.5, []float64{99, 1}, []float64{101, 1}),
+	}
+
+	tests := []struct {
+		thresholdBps float64
+		alert        bool
+	}{
+		{50, false}, // At the threshold
+		{49.9, true},
+	}
+	for _, tt := range tests {
+		report, ok := NewFairValueMonitor(tt.thresholdBps, 2).Evaluate(quotes)
+		if !ok || report.FairValue.String() != "100" || len(report.Deviations) != 2 {
+			t.Fatalf("Expected fair value 100 with 2 deviations, got %+v", report)
+		}
+		// Sorted by venue
+		binance, okx := report.Deviations[0], report.Deviations[1]
+		if binance.Venue != "binance" || binance.DeviationBps.String() != "-50" || okx.DeviationBps.String() != "50" {
+			t.Errorf("Expected binance at -50bps and okx at 50bps, got %+v", report.Deviations)
+		}
+		if binance.Alert != tt.alert || okx.Alert != tt.alert {
+			t.Errorf("Threshold %vbps: Expected alert=%v, got %v and %v", tt.thresholdBps, tt.alert, binance.Alert, okx.Alert)
+		}
+	}
+
+	// The alert clears once the venue moves back within the threshold
+	monitor := NewFairValueMonitor(20, 2)
+	if report, _ := monitor.Evaluate(quotes); !report.Deviations[0].Alert {
+		t.Fatalf("Expected binance to alert at -50bps")
+	}
+	quotes[1].Mid = decimal.NewFromInt(100)
+	if report, _ := monitor.Evaluate(quotes); report.Deviations[0].Alert {
+		t.Errorf("Expected the binance alert to clear at the fair value, got %+v", report.Deviations[0])
+	}
+}
@@ -0,0 +1,186 @@
+package analytics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// maxIcebergLevels bounds the levels tracked per venue; levels not seen within
+// the refill window are dropped once it is exceeded
+const maxIcebergLevels = 256
+
+// IcebergConfig holds the parameters of the IcebergDetector
+type IcebergConfig struct {
+	NearTouchBps  float64       // Levels within this distance of the best price of their side are tracked
+	RefillWindow  time.Duration // Maximum delay between a level being consumed and refilled
+	SizeTolerance float64       // Refills within this fraction of the displayed size count as the same size
+	MinRefills    int           // Consecutive refills before a level is flagged
+}
+
+// LevelChange is one changed price level of a venue's book
+type LevelChange struct {
+	IsBid       bool
+	Price       decimal.Decimal
+	OldQuantity decimal.Decimal // Zero for a new level
+	NewQuantity decimal.Decimal // Zero for a removed level
+}
+
+// IcebergSignal flags a level near the touch that was repeatedly consumed and
+// refilled to a similar size, the footprint of a probable iceberg order
+type IcebergSignal struct {
+	Venue     string
+	Time      time.Time
+	IsBid     bool
+	Price     decimal.Decimal
+	Displayed decimal.Decimal // Size shown before each consumption
+	Refills   int
+	Refilled  decimal.Decimal // Quantity added back by the refills, a lower bound of the hidden size
+}
+
+// icebergKey identifies a tracked level
+type icebergKey struct {
+	isBid bool
+	price string
+}
+
+// icebergLevel is the refill streak of a level
+type icebergLevel struct {
+	displayed  decimal.Decimal // Size before the first consumption of the streak
+	depletedAt time.Time       // Time of the pending consumption, zero once refilled
+	refills    int
+	refilled   decimal.Decimal
+	flagged    bool
+	lastSeen   time.Time
+}
+
+// IcebergDetector watches the level changes near the touch of every venue for
+// levels that are consumed and refilled to a similar size within short intervals.
+// Book deltas do not tell trades from cancels, so signals are probable, not certain.
+type IcebergDetector struct {
+	mu        sync.Mutex
+	config    IcebergConfig
+	nearTouch decimal.Decimal // NearTouchBps as a fraction
+	tolerance decimal.Decimal
+	venues    map[string]map[icebergKey]*icebergLevel
+}
+
+// NewIcebergDetector creates a new IcebergDetector instance
+func NewIcebergDetector(config IcebergConfig) *IcebergDetector {
+	if config.MinRefills < 1 {
+		config.MinRefills = 1
+	}
+	return &IcebergDetector{
+		config:    config,
+		nearTouch: decimal.NewFromFloat(config.NearTouchBps).Div(decimal.NewFromInt(10000)),
+		tolerance: decimal.NewFromFloat(config.SizeTolerance),
+		venues:    make(map[string]map[icebergKey]*icebergLevel),
+	}
+}
+
+// Observe applies the level changes of one update of venue, given the touch after
+// the update, and returns the levels flagged by it. Each level is flagged once
+// per refill streak.
+func (d *IcebergDetector) Observe(venue string, t time.Time, bestBid, bestAsk decimal.Decimal, changes []LevelChange) []IcebergSignal {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	levels, ok := d.venues[venue]
+	if !ok {
+		levels = make(map[icebergKey]*icebergLevel)
+		d.venues[venue] = levels
+	}
+
+	var signals []IcebergSignal
+	for _, change := range changes {
+		key := icebergKey{isBid: change.IsBid, price: change.Price.String()}
+		best := bestAsk
+		if change.IsBid {
+			best = bestBid
+		}
+		if !d.isNearTouch(change.IsBid, change.Price, best) {
+			delete(levels, key)
+			continue
+		}
+
+		level, ok := levels[key]
+		if !ok {
+			level = &icebergLevel{}
+			levels[key] = level
+		}
+		if !level.depletedAt.IsZero() && t.Sub(level.depletedAt) > d.config.RefillWindow {
+			// Not refilled in time, the streak is over
+			*level = icebergLevel{}
+		}
+		level.lastSeen = t
+
+		consumed := change.OldQuantity.Sub(change.NewQuantity)
+		switch {
+		case consumed.IsPositive() && consumed.Mul(decimal.NewFromInt(2)).GreaterThanOrEqual(change.OldQuantity):
+			// At least half of the level went away
+			if level.depletedAt.IsZero() {
+				if level.refills == 0 {
+					level.displayed = change.OldQuantity
+				}
+				level.depletedAt = t
+			}
+		case consumed.IsNegative() && !level.depletedAt.IsZero():
+			if change.NewQuantity.Sub(level.displayed).Abs().GreaterThan(level.displayed.Mul(d.tolerance)) {
+				*level = icebergLevel{lastSeen: t}
+				continue
+			}
+			level.depletedAt = time.Time{}
+			level.refills++
+			level.refilled = level.refilled.Add(consumed.Neg())
+			if level.refills >= d.config.MinRefills && !level.flagged {
+				level.flagged = true
+				signals = append(signals, IcebergSignal{
+					Venue:     venue,
+					Time:      t,
+					IsBid:     change.IsBid,
+					Price:     change.Price,
+					Displayed: level.displayed,
+					Refills:   level.refills,
+					Refilled:  level.refilled,
+				})
+			}
+		}
+	}
+
+	if len(levels) > maxIcebergLevels {
+		for key, level := range levels {
+			if t.Sub(level.lastSeen) > d.config.RefillWindow {
+				delete(levels, key)
+			}
+		}
+	}
+	return signals
+}
+
+// isNearTouch reports whether price is within the configured distance of the best
+// price of its side, or better than it (e.g., a consumed best level)
+func (d *IcebergDetector) isNearTouch(isBid bool, price, best decimal.Decimal) bool {
+	if best.IsZero() {
+		return false
+	}
+	distance := best.Sub(price)
+	if !isBid {
+		distance = distance.Neg()
+	}
+	return distance.LessThanOrEqual(best.Mul(d.nearTouch))
+}
+
+// ResetVenue discards the tracked levels of venue, e.g. after its book was reloaded
+func (d *IcebergDetector) ResetVenue(venue string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.venues, venue)
+}
+
+// Reset discards every tracked level, e.g. after a symbol change
+func (d *IcebergDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.venues = make(map[string]map[icebergKey]*icebergLevel)
+}
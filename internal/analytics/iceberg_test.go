@@ -0,0 +1,83 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestIcebergDetector(t *testing.T) {
+	detector := NewIcebergDetector(IcebergConfig{
+		NearTouchBps:  5,
+		RefillWindow:  time.Second,
+		SizeTolerance: 0.2,
+		MinRefills:    3,
+	})
+	d := decimal.RequireFromString
+	base := time.Unix(1700000000, 0)
+	bid, ask := d("100"), d("100.1")
+
+	// The best ask is consumed and reappears at about the same size three times
+	steps := []struct {
+		offset   time.Duration
+		old, new string
+		signals  int
+	}{
+		{0, "0", "5", 0},
+		{100 * time.Millisecond, "5", "0", 0},
+		{300 * time.Millisecond, "0", "5", 0},
+		{400 * time.Millisecond, "5", "1", 0},
+		{600 * time.Millisecond, "1", "5.5", 0},
+		{700 * time.Millisecond, "5.5", "0", 0},
+		{900 * time.Millisecond, "0", "4.5", 1},
+		{time.Second, "4.5", "0", 0},
+		{1200 * time.Millisecond, "0", "5", 0}, // Flagged once per streak
+	}
+	for i, step := range steps {
+		changes := []LevelChange{{Price: ask, OldQuantity: d(step.old), NewQuantity: d(step.new)}}
+		signals := detector.Observe("okx", base.Add(step.offset), bid, ask, changes)
+		if len(signals) != step.signals {
+			t.Fatalf("step %d: Expected %d signals, got %+v", i, step.signals, signals)
+		}
+		if len(signals) == 1 {
+			s := signals[0]
+			if s.Venue != "okx" || s.IsBid || !s.Price.Equal(ask) || !s.Displayed.Equal(d("5")) || s.Refills != 3 || !s.Refilled.Equal(d("14")) {
+				t.Errorf("Expected an ask iceberg at 100.1 showing 5 with 3 refills of 14, got %+v", s)
+			}
+		}
+	}
+
+	// Slow refills, different sizes and levels away from the touch break the streak
+	tests := []struct {
+		name    string
+		price   string
+		gap     time.Duration
+		refill  string
+		isBid   bool
+		flagged bool
+	}{
+		{"bid at the touch", "100", 100 * time.Millisecond, "2", true, true},
+		{"slow refills", "100", 2 * time.Second, "2", true, false},
+		{"different size", "100", 100 * time.Millisecond, "4", true, false},
+		{"away from the touch", "99", 100 * time.Millisecond, "2", true, false},
+	}
+	for _, tt := range tests {
+		detector.Reset()
+		at := base
+		flagged := false
+		for i := 0; i < 3; i++ {
+			consume := []LevelChange{{IsBid: tt.isBid, Price: d(tt.price), OldQuantity: d("2"), NewQuantity: d("0")}}
+			detector.Observe("binance", at, bid, ask, consume)
+			at = at.Add(tt.gap)
+			refill := []LevelChange{{IsBid: tt.isBid, Price: d(tt.price), OldQuantity: d("0"), NewQuantity: d(tt.refill)}}
+			if len(detector.Observe("binance", at, bid, ask, refill)) > 0 {
+				flagged = true
+			}
+			at = at.Add(tt.gap)
+		}
+		if flagged != tt.flagged {
+			t.Errorf("%s: Expected flagged %v, got %v", tt.name, tt.flagged, flagged)
+		}
+	}
+}
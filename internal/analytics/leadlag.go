@@ -0,0 +1,232 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LeadLagConfig holds configuration for the lead-lag detector
+type LeadLagConfig struct {
+	SampleInterval time.Duration // Interval between mid-price samples
+	Window         int           // Number of samples kept per venue
+	MaxLag         int           // Maximum lag (in samples) tested in each direction
+}
+
+// PairLeadLag describes the lead-lag relationship between two venues
+type PairLeadLag struct {
+	Leader      string
+	Follower    string
+	LagSamples  int           // Lag at which correlation peaks (0 = simultaneous)
+	Lag         time.Duration // LagSamples * SampleInterval
+	Correlation float64       // Peak cross-correlation of mid-price returns
+}
+
+// LeadLagReport is a periodic summary of price discovery leadership
+type LeadLagReport struct {
+	Timestamp time.Time
+	Pairs     []PairLeadLag
+	// Scores ranks venues by leadership in [-1, 1] (positive = leads price discovery)
+	Scores map[string]float64
+}
+
+// LeadLagDetector cross-correlates mid-price changes between venues
+type LeadLagDetector struct {
+	mu      sync.Mutex
+	config  LeadLagConfig
+	samples map[string][]float64 // mid prices per venue, aligned on sample ticks
+	ticks   int                  // number of samples taken
+}
+
+// NewLeadLagDetector creates a new LeadLagDetector instance
+func NewLeadLagDetector(config LeadLagConfig) *LeadLagDetector {
+	if config.Window < 2 {
+		config.Window = 2
+	}
+	if config.MaxLag < 0 {
+		config.MaxLag = 0
+	}
+	return &LeadLagDetector{
+		config:  config,
+		samples: make(map[string][]float64),
+	}
+}
+
+// Sample records the current mid price of each venue; venues missing from mids
+// carry their previous mid forward so that all series stay aligned
+func (d *LeadLagDetector) Sample(mids map[string]decimal.Decimal) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for venue, mid := range mids {
+		if mid.IsZero() {
+			continue
+		}
+		if _, exists := d.samples[venue]; !exists {
+			// Backfill new venues with their first mid so they align with existing series
+			fill := min(d.ticks, d.config.Window)
+			series := make([]float64, fill)
+			value := mid.InexactFloat64()
+			for i := range series {
+				series[i] = value
+			}
+			d.samples[venue] = series
+		}
+	}
+
+	for venue, series := range d.samples {
+		value := math.NaN()
+		if mid, ok := mids[venue]; ok && !mid.IsZero() {
+			value = mid.InexactFloat64()
+		} else if len(series) > 0 {
+			value = series[len(series)-1]
+		}
+		if math.IsNaN(value) {
+			continue
+		}
+		series = append(series, value)
+		if len(series) > d.config.Window {
+			series = series[len(series)-d.config.Window:]
+		}
+		d.samples[venue] = series
+	}
+
+	d.ticks++
+}
+
+// Remove drops a venue from the detector
+func (d *LeadLagDetector) Remove(venue string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.samples, venue)
+}
+
+// Reset clears all samples (e.g., after a symbol change)
+func (d *LeadLagDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.samples = make(map[string][]float64)
+	d.ticks = 0
+}
+
+// Report computes the current lead-lag relationships between all venue pairs
+func (d *LeadLagDetector) Report() LeadLagReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	venues := make([]string, 0, len(d.samples))
+	returns := make(map[string][]float64, len(d.samples))
+	for venue, series := range d.samples {
+		r := logReturns(series)
+		if len(r) <= d.config.MaxLag+1 {
+			continue
+		}
+		venues = append(venues, venue)
+		returns[venue] = r
+	}
+	sort.Strings(venues)
+
+	report := LeadLagReport{
+		Timestamp: time.Now(),
+		Scores:    make(map[string]float64, len(venues)),
+	}
+	pairCounts := make(map[string]int, len(venues))
+
+	for i := 0; i < len(venues); i++ {
+		for j := i + 1; j < len(venues); j++ {
+			a, b := venues[i], venues[j]
+			lag, corr, ok := peakCrossCorrelation(returns[a], returns[b], d.config.MaxLag)
+			if !ok {
+				continue
+			}
+
+			pair := PairLeadLag{Leader: a, Follower: b, LagSamples: lag, Correlation: corr}
+			if lag < 0 {
+				pair.Leader, pair.Follower = b, a
+				pair.LagSamples = -lag
+			}
+			pair.Lag = time.Duration(pair.LagSamples) * d.config.SampleInterval
+			report.Pairs = append(report.Pairs, pair)
+
+			pairCounts[a]++
+			pairCounts[b]++
+			if pair.LagSamples > 0 && corr > 0 {
+				report.Scores[pair.Leader] += corr
+				report.Scores[pair.Follower] -= corr
+			}
+		}
+	}
+
+	for venue, count := range pairCounts {
+		report.Scores[venue] /= float64(count)
+	}
+
+	return report
+}
+
+// logReturns converts a price series into log returns
+func logReturns(series []float64) []float64 {
+	if len(series) < 2 {
+		return nil
+	}
+	returns := make([]float64, len(series)-1)
+	for i := 1; i < len(series); i++ {
+		returns[i-1] = math.Log(series[i] / series[i-1])
+	}
+	return returns
+}
+
+// peakCrossCorrelation finds the lag in [-maxLag, maxLag] that maximizes corr(a[t], b[t+lag]).
+// A positive lag means a leads b.
+func peakCrossCorrelation(a, b []float64, maxLag int) (int, float64, bool) {
+	n := min(len(a), len(b))
+	a, b = a[len(a)-n:], b[len(b)-n:]
+
+	bestLag, bestCorr, found := 0, 0.0, false
+	for lag := -maxLag; lag <= maxLag; lag++ {
+		var x, y []float64
+		if lag >= 0 {
+			x, y = a[:n-lag], b[lag:]
+		} else {
+			x, y = a[-lag:], b[:n+lag]
+		}
+		corr, ok := pearson(x, y)
+		if !ok {
+			continue
+		}
+		if !found || corr > bestCorr {
+			bestLag, bestCorr, found = lag, corr, true
+		}
+	}
+	return bestLag, bestCorr, found
+}
+
+// pearson returns the Pearson correlation coefficient of two equal-length series
+func pearson(x, y []float64) (float64, bool) {
+	n := len(x)
+	if n < 2 || n != len(y) {
+		return 0, false
+	}
+
+	var sumX, sumY float64
+	for i := 0; i < n; i++ {
+		sumX += x[i]
+		sumY += y[i]
+	}
+	meanX, meanY := sumX/float64(n), sumY/float64(n)
+
+	var cov, varX, varY float64
+	for i := 0; i < n; i++ {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varX*varY), true
+}
@@ -0,0 +1,83 @@
+package analytics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLeadLagDetectsLeader(t *testing.T) {
+	detector := NewLeadLagDetector(LeadLagConfig{
+		SampleInterval: 100 * time.Millisecond,
+		Window:         500,
+		MaxLag:         5,
+	})
+
+	// "fast" moves first; "slow" repeats the same path 3 samples later
+	rng := rand.New(rand.NewSource(1))
+	path := make([]float64, 500)
+	price := 50000.0
+	for i := range path {
+		price += rng.NormFloat64() * 5
+		path[i] = price
+	}
+
+	for i := range path {
+		slow := path[0]
+		if i >= 3 {
+			slow = path[i-3]
+		}
+		detector.Sample(map[string]decimal.Decimal{
+			"fast": decimal.NewFromFloat(path[i]),
+			"slow": decimal.NewFromFloat(slow),
+		})
+	}
+
+	report := detector.Report()
+	if len(report.Pairs) != 1 {
+		t.Fatalf("Expected 1 pair, got %d", len(report.Pairs))
+	}
+
+	pair := report.Pairs[0]
+	if pair.Leader != "fast" || pair.Follower != "slow" {
+		t.Errorf("Expected fast to lead slow, got leader=%s follower=%s", pair.Leader, pair.Follower)
+	}
+	if pair.LagSamples != 3 {
+		t.Errorf("Expected lag of 3 samples, got %d", pair.LagSamples)
+	}
+	if pair.Lag != 300*time.Millisecond {
+		t.Errorf("Expected lag of 300ms, got %v", pair.Lag)
+	}
+	if report.Scores["fast"] <= 0 || report.Scores["slow"] >= 0 {
+		t.Errorf("Expected positive score for fast and negative for slow, got %v", report.Scores)
+	}
+}
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		name     string
+		x, y     []float64
+		expected float64
+		ok       bool
+	}{
+		{name: "Perfect positive", x: []float64{1, 2, 3}, y: []float64{2, 4, 6}, expected: 1, ok: true},
+		{name: "Perfect negative", x: []float64{1, 2, 3}, y: []float64{3, 2, 1}, expected: -1, ok: true},
+		{name: "Zero variance", x: []float64{1, 1, 1}, y: []float64{1, 2, 3}, ok: false},
+		{name: "Too short", x: []float64{1}, y: []float64{1}, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corr, ok := pearson(tt.x, tt.y)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok=%v, got %v", tt.ok, ok)
+			}
+			if ok && math.Abs(corr-tt.expected) > 1e-9 {
+				t.Errorf("Expected %f, got %f", tt.expected, corr)
+			}
+		})
+	}
+}
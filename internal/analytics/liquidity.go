@@ -0,0 +1,151 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LiquidityWeights sets how much each component contributes to the composite score.
+// Weights are relative; they are normalized by their sum.
+type LiquidityWeights struct {
+	Spread    float64 // Tightness of the spread relative to the tightest venue
+	Depth05   float64 // Bid plus ask size within 0.5% of mid relative to the deepest venue
+	Depth2    float64 // Bid plus ask size within 2% of mid relative to the deepest venue
+	Uptime    float64 // Time since the feed connected, saturating at UptimeHorizon
+	Staleness float64 // Time since the last update, zero at MaxStaleness
+}
+
+// LiquidityScoreConfig holds the parameters of the LiquidityScorer
+type LiquidityScoreConfig struct {
+	Weights       LiquidityWeights
+	UptimeHorizon time.Duration // Uptime at which a venue earns the full uptime component
+	MaxStaleness  time.Duration // Silence at which a venue earns no staleness component
+}
+
+// VenueLiquidity is a point-in-time reading of one venue's book
+type VenueLiquidity struct {
+	Venue     string
+	Mid       decimal.Decimal
+	Spread    decimal.Decimal
+	Depth05   decimal.Decimal // Bid plus ask size within 0.5% of mid
+	Depth2    decimal.Decimal // Bid plus ask size within 2% of mid
+	Uptime    time.Duration
+	Staleness time.Duration
+}
+
+// LiquidityScore is the composite score of one venue. Components are in [0, 1];
+// Score is their weighted average scaled to [0, 100].
+type LiquidityScore struct {
+	Venue     string
+	Rank      int // 1 is the best venue
+	Score     float64
+	SpreadBps float64
+	Spread    float64 // Spread component
+	Depth05   float64 // 0.5% depth component
+	Depth2    float64 // 2% depth component
+	Uptime    float64 // Uptime component
+	Staleness float64 // Staleness component
+}
+
+// LiquidityRanking lists venues from best to worst execution conditions
+type LiquidityRanking struct {
+	Timestamp time.Time
+	Scores    []LiquidityScore
+}
+
+// LiquidityScorer ranks venues by a weighted composite of spread tightness,
+// depth near the touch, feed uptime and feed freshness
+type LiquidityScorer struct {
+	cfg         LiquidityScoreConfig
+	totalWeight float64
+}
+
+// NewLiquidityScorer creates a new LiquidityScorer instance
+func NewLiquidityScorer(cfg LiquidityScoreConfig) *LiquidityScorer {
+	w := cfg.Weights
+	return &LiquidityScorer{
+		cfg:         cfg,
+		totalWeight: w.Spread + w.Depth05 + w.Depth2 + w.Uptime + w.Staleness,
+	}
+}
+
+// Rank scores the given venues and sorts them by score, best first.
+// Venues without a mid price are skipped.
+func (s *LiquidityScorer) Rank(venues []VenueLiquidity, now time.Time) LiquidityRanking {
+	ranking := LiquidityRanking{Timestamp: now}
+
+	// Spread and depth are scored against the best venue in this round
+	spreads := make([]float64, len(venues))
+	var minSpread, maxDepth05, maxDepth2 float64
+	for i, venue := range venues {
+		if !venue.Mid.IsPositive() {
+			continue
+		}
+		spreads[i] = venue.Spread.Div(venue.Mid).Mul(decimal.NewFromInt(10000)).InexactFloat64()
+		if spreads[i] > 0 && (minSpread == 0 || spreads[i] < minSpread) {
+			minSpread = spreads[i]
+		}
+		maxDepth05 = max(maxDepth05, venue.Depth05.InexactFloat64())
+		maxDepth2 = max(maxDepth2, venue.Depth2.InexactFloat64())
+	}
+
+	for i, venue := range venues {
+		if !venue.Mid.IsPositive() {
+			continue
+		}
+
+		score := LiquidityScore{
+			Venue:     venue.Venue,
+			SpreadBps: spreads[i],
+			Spread:    ratio(minSpread, spreads[i]), // Locked or crossed books score 0
+			Depth05:   ratio(venue.Depth05.InexactFloat64(), maxDepth05),
+			Depth2:    ratio(venue.Depth2.InexactFloat64(), maxDepth2),
+			Uptime:    1,
+			Staleness: 1,
+		}
+		if s.cfg.UptimeHorizon > 0 {
+			score.Uptime = clamp01(float64(venue.Uptime) / float64(s.cfg.UptimeHorizon))
+		}
+		if s.cfg.MaxStaleness > 0 {
+			// Event times come from the exchange clock, so skew may make staleness negative
+			score.Staleness = clamp01(1 - float64(venue.Staleness)/float64(s.cfg.MaxStaleness))
+		}
+
+		if s.totalWeight > 0 {
+			w := s.cfg.Weights
+			score.Score = 100 * (w.Spread*score.Spread +
+				w.Depth05*score.Depth05 +
+				w.Depth2*score.Depth2 +
+				w.Uptime*score.Uptime +
+				w.Staleness*score.Staleness) / s.totalWeight
+		}
+		ranking.Scores = append(ranking.Scores, score)
+	}
+
+	sort.SliceStable(ranking.Scores, func(i, j int) bool {
+		if ranking.Scores[i].Score != ranking.Scores[j].Score {
+			return ranking.Scores[i].Score > ranking.Scores[j].Score
+		}
+		return ranking.Scores[i].Venue < ranking.Scores[j].Venue
+	})
+	for i := range ranking.Scores {
+		ranking.Scores[i].Rank = i + 1
+	}
+
+	return ranking
+}
+
+// ratio returns a/b clamped to [0, 1], or 0 when b is not positive
+func ratio(a, b float64) float64 {
+	if b <= 0 || a <= 0 {
+		return 0
+	}
+	return min(a/b, 1)
+}
+
+// clamp01 limits v to [0, 1]
+func clamp01(v float64) float64 {
+	return min(max(v, 0), 1)
+}
@@ -0,0 +1,52 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLiquidityScorerRank(t *testing.T) {
+	scorer := NewLiquidityScorer(LiquidityScoreConfig{
+		Weights:       LiquidityWeights{Spread: 1, Depth05: 1, Depth2: 1, Uptime: 1, Staleness: 1},
+		UptimeHorizon: 10 * time.Minute,
+		MaxStaleness:  10 * time.Second,
+	})
+
+	venues := []VenueLiquidity{
+		// Tightest and deepest, but only just connected and going stale
+		{Venue: "okx", Mid: decimal.NewFromInt(10000), Spread: decimal.NewFromInt(1), Depth05: decimal.NewFromInt(100), Depth2: decimal.NewFromInt(400), Uptime: 5 * time.Minute, Staleness: 5 * time.Second},
+		// Twice the spread and half the depth, fully up and fresh
+		{Venue: "binance", Mid: decimal.NewFromInt(10000), Spread: decimal.NewFromInt(2), Depth05: decimal.NewFromInt(50), Depth2: decimal.NewFromInt(200), Uptime: time.Hour},
+		{Venue: "kraken", Spread: decimal.NewFromInt(1)},
+	}
+
+	ranking := scorer.Rank(venues, time.Unix(1700000000, 0))
+	if len(ranking.Scores) != 2 {
+		t.Fatalf("Expected 2 ranked venues (no mid skipped), got %+v", ranking.Scores)
+	}
+
+	// okx: (1 + 1 + 1 + 0.5 + 0.5) / 5, binance: (0.5 + 0.5 + 0.5 + 1 + 1) / 5
+	tests := []struct {
+		venue string
+		rank  int
+		score float64
+	}{
+		{"okx", 1, 80},
+		{"binance", 2, 70},
+	}
+	for i, tt := range tests {
+		got := ranking.Scores[i]
+		if got.Venue != tt.venue || got.Rank != tt.rank {
+			t.Errorf("Expected %s at rank %d, got %s at rank %d", tt.venue, tt.rank, got.Venue, got.Rank)
+		}
+		if math.Abs(got.Score-tt.score) > 1e-9 {
+			t.Errorf("%s: Expected score %v, got %v", tt.venue, tt.score, got.Score)
+		}
+	}
+	if ranking.Scores[0].SpreadBps != 1 {
+		t.Errorf("Expected okx spread 1bps, got %v", ranking.Scores[0].SpreadBps)
+	}
+}
@@ -0,0 +1,56 @@
+package analytics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// oiSample is an open interest reading of a venue
+type oiSample struct {
+	at    time.Time
+	value decimal.Decimal
+}
+
+// OpenInterestTracker keeps the open interest readings of each venue over a
+// trailing window to report the change of the open interest over the window
+type OpenInterestTracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples map[string][]oiSample
+}
+
+// NewOpenInterestTracker creates a new OpenInterestTracker instance
+func NewOpenInterestTracker(window time.Duration) *OpenInterestTracker {
+	return &OpenInterestTracker{
+		window:  window,
+		samples: make(map[string][]oiSample),
+	}
+}
+
+// Record adds a reading of venue and returns the change since the latest reading
+// at least a window old, or since the oldest reading while the window is filling
+func (t *OpenInterestTracker) Record(venue string, at time.Time, value decimal.Decimal) decimal.Decimal {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	samples := append(t.samples[venue], oiSample{at: at, value: value})
+	// Keep a single reading older than the window as the baseline
+	cutoff := at.Add(-t.window)
+	drop := 0
+	for drop+1 < len(samples) && !samples[drop+1].at.After(cutoff) {
+		drop++
+	}
+	samples = samples[drop:]
+	t.samples[venue] = samples
+
+	return value.Sub(samples[0].value)
+}
+
+// Reset drops the readings of every venue
+func (t *OpenInterestTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples = make(map[string][]oiSample)
+}
@@ -0,0 +1,39 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOpenInterestTracker(t *testing.T) {
+	tracker := NewOpenInterestTracker(time.Minute)
+	d := decimal.RequireFromString
+	base := time.Unix(1700000000, 0)
+
+	if change := tracker.Record("binancef", base, d("100")); !change.IsZero() {
+		t.Errorf("Expected no change on the first reading, got %s", change)
+	}
+	// The window is filling, changes are measured from the oldest reading
+	if change := tracker.Record("binancef", base.Add(30*time.Second), d("110")); !change.Equal(d("10")) {
+		t.Errorf("Expected a change of 10, got %s", change)
+	}
+	if change := tracker.Record("binancef", base.Add(60*time.Second), d("120")); !change.Equal(d("20")) {
+		t.Errorf("Expected a change of 20, got %s", change)
+	}
+	// The first reading is older than the window, the 30s one is now the baseline
+	if change := tracker.Record("binancef", base.Add(90*time.Second), d("105")); !change.Equal(d("-5")) {
+		t.Errorf("Expected a change of -5, got %s", change)
+	}
+
+	// Venues are tracked independently
+	if change := tracker.Record("bybitf", base.Add(90*time.Second), d("50")); !change.IsZero() {
+		t.Errorf("Expected no change on the first bybitf reading, got %s", change)
+	}
+
+	tracker.Reset()
+	if change := tracker.Record("binancef", base.Add(120*time.Second), d("130")); !change.IsZero() {
+		t.Errorf("Expected no change after a reset, got %s", change)
+	}
+}
@@ -0,0 +1,218 @@
+package analytics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ResilienceConfig holds the parameters of the ResilienceTracker
+type ResilienceConfig struct {
+	MinSweepNotional float64       // Notional removed near the touch of one side by one update that counts as a sweep
+	BandBps          float64       // Distance from the swept price within which liquidity must reappear
+	MaxWait          time.Duration // Sweeps not replenished after this long count as unrecovered
+	Window           int           // Latest sweeps kept per venue
+}
+
+// Resilience summarizes how quickly a venue's book replenished after its latest sweeps
+type Resilience struct {
+	Sweeps    int           // Sweeps measured
+	Recovered float64       // Fraction of the sweeps replenished within MaxWait
+	Median    time.Duration // Median time to replenish of the recovered sweeps
+}
+
+// pendingSweep is a sweep waiting for its liquidity to reappear
+type pendingSweep struct {
+	isBid     bool
+	low, high decimal.Decimal // Band in which liquidity must reappear
+	removed   decimal.Decimal // Quantity swept
+	added     decimal.Decimal // Net quantity added back in the band since the sweep
+	start     time.Time
+}
+
+// sweepOutcome is the result of a measured sweep
+type sweepOutcome struct {
+	recovered bool
+	elapsed   time.Duration
+}
+
+// resilienceVenue holds the pending sweeps and latest outcomes of a venue
+type resilienceVenue struct {
+	pending  []*pendingSweep
+	outcomes []sweepOutcome
+}
+
+// ResilienceTracker detects sweeps, large removals of liquidity at the touch, and
+// measures how long the book takes to show the same quantity again near the swept price
+type ResilienceTracker struct {
+	mu        sync.Mutex
+	config    ResilienceConfig
+	minSweep  decimal.Decimal
+	bandRatio decimal.Decimal // BandBps as a fraction
+	venues    map[string]*resilienceVenue
+}
+
+// NewResilienceTracker creates a new ResilienceTracker instance
+func NewResilienceTracker(config ResilienceConfig) *ResilienceTracker {
+	if config.Window < 1 {
+		config.Window = 1
+	}
+	return &ResilienceTracker{
+		config:    config,
+		minSweep:  decimal.NewFromFloat(config.MinSweepNotional),
+		bandRatio: decimal.NewFromFloat(config.BandBps).Div(decimal.NewFromInt(10000)),
+		venues:    make(map[string]*resilienceVenue),
+	}
+}
+
+// Observe applies the level changes of one update of venue, given the touch after
+// the update: changes inside the band of pending sweeps count towards their
+// replenishment, then removals near the touch are checked for a new sweep per side
+func (r *ResilienceTracker) Observe(venue string, t time.Time, bestBid, bestAsk decimal.Decimal, changes []LevelChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.venues[venue]
+	if !ok {
+		v = &resilienceVenue{}
+		r.venues[venue] = v
+	}
+	r.expire(v, t)
+
+	for _, change := range changes {
+		delta := change.NewQuantity.Sub(change.OldQuantity)
+		for _, sweep := range v.pending {
+			if sweep.isBid == change.IsBid && !change.Price.LessThan(sweep.low) && !change.Price.GreaterThan(sweep.high) {
+				sweep.added = sweep.added.Add(delta)
+			}
+		}
+	}
+	remaining := v.pending[:0]
+	for _, sweep := range v.pending {
+		if sweep.added.GreaterThanOrEqual(sweep.removed) {
+			r.record(v, sweepOutcome{recovered: true, elapsed: t.Sub(sweep.start)})
+			continue
+		}
+		remaining = append(remaining, sweep)
+	}
+	v.pending = remaining
+
+	for _, isBid := range []bool{true, false} {
+		best := bestAsk
+		if isBid {
+			best = bestBid
+		}
+		if sweep, ok := r.detectSweep(isBid, best, changes); ok {
+			sweep.start = t
+			v.pending = append(v.pending, sweep)
+		}
+	}
+}
+
+// detectSweep sums the quantity removed from one side near the touch and returns
+// a sweep if its notional reaches the threshold (must be called with mutex locked)
+func (r *ResilienceTracker) detectSweep(isBid bool, best decimal.Decimal, changes []LevelChange) (*pendingSweep, bool) {
+	var removed, notional, anchor decimal.Decimal
+	for _, change := range changes {
+		if change.IsBid != isBid || !change.NewQuantity.LessThan(change.OldQuantity) {
+			continue
+		}
+		// Swept levels are at or better than the new best price, or within the band of it
+		if !best.IsZero() {
+			distance := best.Sub(change.Price)
+			if !isBid {
+				distance = distance.Neg()
+			}
+			if distance.GreaterThan(best.Mul(r.bandRatio)) {
+				continue
+			}
+		}
+		quantity := change.OldQuantity.Sub(change.NewQuantity)
+		removed = removed.Add(quantity)
+		notional = notional.Add(quantity.Mul(change.Price))
+		if anchor.IsZero() || (isBid && change.Price.GreaterThan(anchor)) || (!isBid && change.Price.LessThan(anchor)) {
+			anchor = change.Price
+		}
+	}
+	if removed.IsZero() || notional.LessThan(r.minSweep) {
+		return nil, false
+	}
+
+	band := anchor.Mul(r.bandRatio)
+	return &pendingSweep{
+		isBid:   isBid,
+		low:     anchor.Sub(band),
+		high:    anchor.Add(band),
+		removed: removed,
+		added:   decimal.Zero,
+	}, true
+}
+
+// expire records the pending sweeps older than MaxWait as unrecovered (must be called with mutex locked)
+func (r *ResilienceTracker) expire(v *resilienceVenue, now time.Time) {
+	remaining := v.pending[:0]
+	for _, sweep := range v.pending {
+		if now.Sub(sweep.start) > r.config.MaxWait {
+			r.record(v, sweepOutcome{elapsed: r.config.MaxWait})
+			continue
+		}
+		remaining = append(remaining, sweep)
+	}
+	v.pending = remaining
+}
+
+// record adds an outcome, keeping the latest Window (must be called with mutex locked)
+func (r *ResilienceTracker) record(v *resilienceVenue, outcome sweepOutcome) {
+	v.outcomes = append(v.outcomes, outcome)
+	if excess := len(v.outcomes) - r.config.Window; excess > 0 {
+		v.outcomes = append(v.outcomes[:0], v.outcomes[excess:]...)
+	}
+}
+
+// Report returns the resilience of venue over its latest measured sweeps, after
+// counting the sweeps still pending at now beyond MaxWait as unrecovered
+func (r *ResilienceTracker) Report(venue string, now time.Time) Resilience {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.venues[venue]
+	if !ok {
+		return Resilience{}
+	}
+	r.expire(v, now)
+
+	report := Resilience{Sweeps: len(v.outcomes)}
+	if report.Sweeps == 0 {
+		return report
+	}
+	var elapsed []time.Duration
+	for _, outcome := range v.outcomes {
+		if outcome.recovered {
+			elapsed = append(elapsed, outcome.elapsed)
+		}
+	}
+	report.Recovered = float64(len(elapsed)) / float64(report.Sweeps)
+	if len(elapsed) > 0 {
+		sort.Slice(elapsed, func(i, j int) bool { return elapsed[i] < elapsed[j] })
+		report.Median = elapsed[len(elapsed)/2]
+	}
+	return report
+}
+
+// ResetVenue discards the pending sweeps of venue, e.g. after its book was reloaded
+func (r *ResilienceTracker) ResetVenue(venue string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if v, ok := r.venues[venue]; ok {
+		v.pending = nil
+	}
+}
+
+// Reset discards every sweep, e.g. after a symbol change
+func (r *ResilienceTracker) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.venues = make(map[string]*resilienceVenue)
+}
@@ -0,0 +1,52 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestResilienceTracker(t *testing.T) {
+	tracker := NewResilienceTracker(ResilienceConfig{
+		MinSweepNotional: 1000,
+		BandBps:          10,
+		MaxWait:          5 * time.Second,
+		Window:           10,
+	})
+	d := decimal.RequireFromString
+	base := time.Unix(1700000000, 0)
+	change := func(isBid bool, price, old, new string) LevelChange {
+		return LevelChange{IsBid: isBid, Price: d(price), OldQuantity: d(old), NewQuantity: d(new)}
+	}
+
+	// Two ask levels worth 20 * 100 are swept in one update, then refilled within the band in 2s
+	tracker.Observe("okx", base, d("99.9"), d("100.2"), []LevelChange{
+		change(false, "100", "12", "0"),
+		change(false, "100.1", "8", "0"),
+	})
+	tracker.Observe("okx", base.Add(time.Second), d("99.9"), d("100"), []LevelChange{
+		change(false, "100", "0", "15"),
+		change(false, "101", "0", "50"), // Outside the band
+	})
+	if report := tracker.Report("okx", base.Add(time.Second)); report.Sweeps != 0 {
+		t.Fatalf("Expected the sweep to be pending, got %+v", report)
+	}
+	tracker.Observe("okx", base.Add(2*time.Second), d("99.9"), d("100"), []LevelChange{change(false, "100.05", "0", "5")})
+	report := tracker.Report("okx", base.Add(2*time.Second))
+	if report.Sweeps != 1 || report.Recovered != 1 || report.Median != 2*time.Second {
+		t.Errorf("Expected 1 sweep recovered in 2s, got %+v", report)
+	}
+
+	// Small removals are not sweeps, sweeps never refilled count as unrecovered
+	tracker.Observe("okx", base.Add(3*time.Second), d("99.9"), d("100"), []LevelChange{change(true, "99.9", "5", "0")})
+	tracker.Observe("okx", base.Add(3*time.Second), d("99.8"), d("100"), []LevelChange{change(true, "99.9", "20", "0")})
+	report = tracker.Report("okx", base.Add(9*time.Second))
+	if report.Sweeps != 2 || report.Recovered != 0.5 || report.Median != 2*time.Second {
+		t.Errorf("Expected 2 sweeps, half recovered with a 2s median, got %+v", report)
+	}
+
+	if report := tracker.Report("binance", base); report.Sweeps != 0 {
+		t.Errorf("Expected no sweeps for an unknown venue, got %+v", report)
+	}
+}
@@ -0,0 +1,143 @@
+package analytics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SessionSample is a point-in-time reading of one exchange feed
+type SessionSample struct {
+	Exchange      string
+	Symbol        string
+	Started       time.Time // Creation time of the feed; counters restart with a new feed
+	Time          time.Time
+	Reconnects    int64
+	Messages      int64
+	Gaps          int64
+	Resyncs       int64
+	Spread        decimal.Decimal // Zero when the book has no top of book
+	Liquidity2Pct decimal.Decimal // Bid plus ask size within 2% of mid
+}
+
+// ExchangeSummary holds the statistics of one exchange and symbol over a session
+type ExchangeSummary struct {
+	Exchange         string          `json:"exchange"`
+	Symbol           string          `json:"symbol"`
+	UptimeSeconds    float64         `json:"uptimeSeconds"`
+	Reconnects       int64           `json:"reconnects"`
+	Messages         int64           `json:"messages"`
+	Gaps             int64           `json:"gaps"`
+	Resyncs          int64           `json:"resyncs"`
+	AvgSpread        decimal.Decimal `json:"avgSpread"`
+	AvgLiquidity2Pct decimal.Decimal `json:"avgLiquidity2Pct"`
+	Samples          int             `json:"samples"`
+}
+
+// SessionSummary is a rollup of every exchange seen since the session started
+type SessionSummary struct {
+	Start     time.Time         `json:"start"`
+	End       time.Time         `json:"end"`
+	Exchanges []ExchangeSummary `json:"exchanges"`
+}
+
+// sessionFeed accumulates the samples of one exchange and symbol
+type sessionFeed struct {
+	banked       ExchangeSummary // Totals of feeds that were replaced
+	current      SessionSample   // Latest sample of the live feed
+	spreadSum    decimal.Decimal
+	spreadCount  int
+	liquiditySum decimal.Decimal
+	samples      int
+}
+
+// SessionTracker accumulates per-exchange statistics over a whole session,
+// across reconnects and feed restarts
+type SessionTracker struct {
+	mu    sync.Mutex
+	start time.Time
+	feeds map[[2]string]*sessionFeed // Keyed by exchange and symbol
+}
+
+// NewSessionTracker creates a new SessionTracker for a session started at start
+func NewSessionTracker(start time.Time) *SessionTracker {
+	return &SessionTracker{
+		start: start,
+		feeds: make(map[[2]string]*sessionFeed),
+	}
+}
+
+// Sample records a reading of an exchange feed. Counters are cumulative per feed;
+// a sample with a new Started time banks the totals of the previous feed.
+func (t *SessionTracker) Sample(s SessionSample) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := [2]string{s.Exchange, s.Symbol}
+	feed, ok := t.feeds[key]
+	if !ok {
+		feed = &sessionFeed{}
+		t.feeds[key] = feed
+	} else if !feed.current.Started.Equal(s.Started) {
+		feed.bank()
+	}
+	feed.current = s
+
+	feed.samples++
+	if s.Spread.IsPositive() {
+		feed.spreadSum = feed.spreadSum.Add(s.Spread)
+		feed.spreadCount++
+	}
+	feed.liquiditySum = feed.liquiditySum.Add(s.Liquidity2Pct)
+}
+
+// bank adds the counters of the current feed to the banked totals
+func (f *sessionFeed) bank() {
+	f.banked.UptimeSeconds += f.current.Time.Sub(f.current.Started).Seconds()
+	f.banked.Reconnects += f.current.Reconnects
+	f.banked.Messages += f.current.Messages
+	f.banked.Gaps += f.current.Gaps
+	f.banked.Resyncs += f.current.Resyncs
+}
+
+// Summary returns the session totals and averages per exchange, sorted by exchange and symbol
+func (t *SessionTracker) Summary(now time.Time) SessionSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary := SessionSummary{
+		Start:     t.start,
+		End:       now,
+		Exchanges: make([]ExchangeSummary, 0, len(t.feeds)),
+	}
+	for key, feed := range t.feeds {
+		current := feed.current
+		s := feed.banked
+		s.Exchange = key[0]
+		s.Symbol = key[1]
+		s.UptimeSeconds += current.Time.Sub(current.Started).Seconds()
+		s.Reconnects += current.Reconnects
+		s.Messages += current.Messages
+		s.Gaps += current.Gaps
+		s.Resyncs += current.Resyncs
+		s.Samples = feed.samples
+		if feed.spreadCount > 0 {
+			s.AvgSpread = feed.spreadSum.Div(decimal.NewFromInt(int64(feed.spreadCount)))
+		}
+		if feed.samples > 0 {
+			s.AvgLiquidity2Pct = feed.liquiditySum.Div(decimal.NewFromInt(int64(feed.samples)))
+		}
+		summary.Exchanges = append(summary.Exchanges, s)
+	}
+
+	sort.Slice(summary.Exchanges, func(i, j int) bool {
+		a, b := summary.Exchanges[i], summary.Exchanges[j]
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		return a.Symbol < b.Symbol
+	})
+	return summary
+}
@@ -0,0 +1,45 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSessionTrackerBanksRestartedFeeds(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tracker := NewSessionTracker(start)
+
+	first := start
+	second := start.Add(time.Minute)
+	samples := []SessionSample{
+		{Exchange: "binance", Symbol: "BTCUSDT", Started: first, Time: start.Add(10 * time.Second), Messages: 100, Gaps: 1, Spread: decimal.NewFromInt(2), Liquidity2Pct: decimal.NewFromInt(10)},
+		{Exchange: "binance", Symbol: "BTCUSDT", Started: first, Time: start.Add(30 * time.Second), Messages: 300, Gaps: 1, Reconnects: 1, Spread: decimal.NewFromInt(4), Liquidity2Pct: decimal.NewFromInt(20)},
+		// The feed was restarted: its counters begin again from zero
+		{Exchange: "binance", Symbol: "BTCUSDT", Started: second, Time: second.Add(20 * time.Second), Messages: 50, Resyncs: 1, Liquidity2Pct: decimal.NewFromInt(30)},
+		{Exchange: "okx", Symbol: "BTCUSDT", Started: first, Time: start.Add(5 * time.Second), Messages: 7},
+	}
+	for _, s := range samples {
+		tracker.Sample(s)
+	}
+
+	summary := tracker.Summary(start.Add(2 * time.Minute))
+	if len(summary.Exchanges) != 2 || summary.Exchanges[0].Exchange != "binance" {
+		t.Fatalf("Expected binance and okx summaries, got %+v", summary.Exchanges)
+	}
+
+	binance := summary.Exchanges[0]
+	if binance.UptimeSeconds != 50 {
+		t.Errorf("Expected uptime 50s, got %v", binance.UptimeSeconds)
+	}
+	if binance.Messages != 350 || binance.Gaps != 1 || binance.Reconnects != 1 || binance.Resyncs != 1 {
+		t.Errorf("Expected 350 messages, 1 gap, 1 reconnect, 1 resync, got %+v", binance)
+	}
+	if !binance.AvgSpread.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("Expected average spread 3 (empty spreads skipped), got %s", binance.AvgSpread)
+	}
+	if !binance.AvgLiquidity2Pct.Equal(decimal.NewFromInt(20)) {
+		t.Errorf("Expected average liquidity 20, got %s", binance.AvgLiquidity2Pct)
+	}
+}
@@ -0,0 +1,85 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/storage"
+)
+
+// Format represents supported external depth data formats
+type Format string
+
+const (
+	// FormatTardis is the Tardis incremental_book_L2 CSV format
+	// (exchange,symbol,timestamp,local_timestamp,is_snapshot,side,price,amount)
+	FormatTardis Format = "tardis"
+
+	// FormatBinance is JSON lines of Binance depth stream events (raw or combined stream)
+	// and REST depth snapshots, as written by common Binance collectors
+	FormatBinance Format = "binance"
+)
+
+// Options configures an import
+type Options struct {
+	Format   Format
+	Exchange exchange.ExchangeName // Exchange the records are stored under
+	Symbol   string                // Overrides the symbol found in the data when set
+}
+
+// Result counts the records written by an import
+type Result struct {
+	Snapshots int
+	Updates   int
+}
+
+// Import reads depth data in the given format and writes it to store as snapshots
+// and updates, so it can be queried and replayed like recorded data
+func Import(ctx context.Context, r io.Reader, opts Options, store storage.Storage) (Result, error) {
+	if opts.Exchange == "" {
+		return Result{}, fmt.Errorf("exchange is required")
+	}
+
+	w := &writer{ctx: ctx, store: store}
+	var err error
+	switch opts.Format {
+	case FormatTardis:
+		err = importTardis(r, opts, w)
+	case FormatBinance:
+		err = importBinance(r, opts, w)
+	default:
+		return Result{}, fmt.Errorf("unsupported format: %s", opts.Format)
+	}
+	return w.result, err
+}
+
+// writer writes records to storage and counts them
+type writer struct {
+	ctx    context.Context
+	store  storage.Storage
+	result Result
+}
+
+func (w *writer) snapshot(snapshot *exchange.Snapshot) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if err := w.store.WriteSnapshot(w.ctx, snapshot); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	w.result.Snapshots++
+	return nil
+}
+
+func (w *writer) update(update *exchange.DepthUpdate) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if err := w.store.WriteUpdate(w.ctx, update); err != nil {
+		return fmt.Errorf("failed to write update: %w", err)
+	}
+	w.result.Updates++
+	return nil
+}
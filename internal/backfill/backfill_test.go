@@ -0,0 +1,105 @@
+package backfill
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/storage"
+)
+
+func newTestStore(t *testing.T) storage.Storage {
+	t.Helper()
+	store, err := storage.NewSQLite(filepath.Join(t.TempDir(), "orderbook.db"))
+	if err != nil {
+		t.Fatalf("NewSQLite() failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestImportTardis(t *testing.T) {
+	input := `exchange,symbol,timestamp,local_timestamp,is_snapshot,side,price,amount
+binance-futures,BTCUSDT,1700000000000000,1700000000001000,true,ask,50001,2
+binance-futures,BTCUSDT,1700000000000000,1700000000001000,true,bid,50000,1
+binance-futures,BTCUSDT,1700000000500000,1700000000501000,false,bid,50000,0
+binance-futures,BTCUSDT,1700000000500000,1700000000501000,false,bid,49999,3
+binance-futures,BTCUSDT,1700000001000000,1700000001001000,false,ask,50002,4
+`
+	store := newTestStore(t)
+	ctx := context.Background()
+	result, err := Import(ctx, strings.NewReader(input), Options{Format: FormatTardis, Exchange: exchange.Binancef}, store)
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if result.Snapshots != 1 || result.Updates != 2 {
+		t.Fatalf("Expected 1 snapshot and 2 updates, got %+v", result)
+	}
+
+	snapshots, err := store.QuerySnapshots(ctx, storage.Query{Exchange: exchange.Binancef})
+	if err != nil {
+		t.Fatalf("QuerySnapshots() failed: %v", err)
+	}
+	if len(snapshots) != 1 || len(snapshots[0].Bids) != 1 || len(snapshots[0].Asks) != 1 || snapshots[0].LastUpdateID != 1 {
+		t.Fatalf("Expected one snapshot with 1 bid and 1 ask at update 1, got %+v", snapshots)
+	}
+
+	updates, err := store.QueryUpdates(ctx, storage.Query{Exchange: exchange.Binancef})
+	if err != nil {
+		t.Fatalf("QueryUpdates() failed: %v", err)
+	}
+	if len(updates) != 2 || len(updates[0].Bids) != 2 || updates[0].PrevUpdateID != 1 || updates[1].PrevUpdateID != updates[0].FinalUpdateID {
+		t.Fatalf("Expected 2 continuous updates, got %+v", updates)
+	}
+	if !updates[1].EventTime.Equal(time.UnixMicro(1700000001000000)) {
+		t.Errorf("Expected event time %v, got %v", time.UnixMicro(1700000001000000), updates[1].EventTime)
+	}
+}
+
+func TestImportBinance(t *testing.T) {
+	input := `{"lastUpdateId":100,"bids":[["50000","1"]],"asks":[["50001","2"]]}
+{"stream":"btcusdt@depth@100ms","data":{"e":"depthUpdate","E":1700000000000,"s":"BTCUSDT","U":99,"u":101,"b":[["50000","0"]],"a":[]}}
+{"e":"depthUpdate","E":1700000000100,"s":"BTCUSDT","U":102,"u":102,"b":[],"a":[["50001","3"]]}
+`
+	store := newTestStore(t)
+	ctx := context.Background()
+	result, err := Import(ctx, strings.NewReader(input), Options{Format: FormatBinance, Exchange: exchange.Binance}, store)
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if result.Snapshots != 1 || result.Updates != 2 {
+		t.Fatalf("Expected 1 snapshot and 2 updates, got %+v", result)
+	}
+
+	// The spot snapshot takes the time and symbol of the next event
+	snapshots, err := store.QuerySnapshots(ctx, storage.Query{Exchange: exchange.Binance, Symbol: "BTCUSDT"})
+	if err != nil {
+		t.Fatalf("QuerySnapshots() failed: %v", err)
+	}
+	if len(snapshots) != 1 || !snapshots[0].Timestamp.Equal(time.UnixMilli(1700000000000)) {
+		t.Fatalf("Expected one snapshot at the first event time, got %+v", snapshots)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		input string
+	}{
+		{"no exchange", Options{Format: FormatTardis}, ""},
+		{"unknown format", Options{Format: "csv", Exchange: exchange.Binance}, ""},
+		{"missing column", Options{Format: FormatTardis, Exchange: exchange.Binance}, "symbol,timestamp\n"},
+		{"invalid side", Options{Format: FormatTardis, Exchange: exchange.Binance}, "symbol,timestamp,is_snapshot,side,price,amount\nBTCUSDT,1,true,buy,1,1\n"},
+		{"dangling snapshot", Options{Format: FormatBinance, Exchange: exchange.Binance}, `{"lastUpdateId":1,"bids":[],"asks":[]}`},
+	}
+
+	for _, tt := range tests {
+		if _, err := Import(context.Background(), strings.NewReader(tt.input), tt.opts, newTestStore(t)); err == nil {
+			t.Errorf("%s: Expected error, got nil", tt.name)
+		}
+	}
+}
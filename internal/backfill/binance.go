@@ -0,0 +1,118 @@
+package backfill
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+	"orderbook/internal/exchange/binancecompat"
+)
+
+// binanceLine probes which kind of record a JSON line holds
+type binanceLine struct {
+	Data         json.RawMessage `json:"data"`         // Combined stream payload
+	LastUpdateID *int64          `json:"lastUpdateId"` // Present in REST snapshots
+}
+
+// binanceSnapshot is a REST depth snapshot; futures snapshots also carry times
+type binanceSnapshot struct {
+	binancecompat.SnapshotResponse
+	EventTime int64 `json:"E"`
+}
+
+// importBinance imports JSON lines of Binance depth events and snapshots. Spot
+// snapshots carry no time and are stamped with the time of the next event.
+func importBinance(r io.Reader, opts Options, w *writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var pending *exchange.Snapshot // Snapshot waiting for a timestamp
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+
+		var probe binanceLine
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if probe.LastUpdateID != nil {
+			var snap binanceSnapshot
+			if err := json.Unmarshal(data, &snap); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			if pending != nil {
+				return fmt.Errorf("line %d: snapshot without a timestamp followed by another snapshot", line)
+			}
+			snapshot := &exchange.Snapshot{
+				Exchange:     opts.Exchange,
+				Symbol:       opts.Symbol,
+				LastUpdateID: snap.LastUpdateID,
+				Bids:         baseexchange.ConvertLevels(snap.Bids),
+				Asks:         baseexchange.ConvertLevels(snap.Asks),
+			}
+			if snap.EventTime == 0 {
+				pending = snapshot
+				continue
+			}
+			snapshot.Timestamp = time.UnixMilli(snap.EventTime)
+			if err := w.snapshot(snapshot); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if len(probe.Data) > 0 {
+			data = probe.Data
+		}
+		var event binancecompat.DepthUpdate
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if event.EventType != "depthUpdate" {
+			continue
+		}
+
+		update := &exchange.DepthUpdate{
+			Exchange:      opts.Exchange,
+			Symbol:        event.Symbol,
+			EventTime:     time.UnixMilli(event.EventTime),
+			FirstUpdateID: event.FirstUpdateID,
+			FinalUpdateID: event.FinalUpdateID,
+			PrevUpdateID:  event.PrevUpdateID,
+			Bids:          baseexchange.ConvertLevels(event.Bids),
+			Asks:          baseexchange.ConvertLevels(event.Asks),
+		}
+		update.LocalEventTime = update.EventTime
+		if opts.Symbol != "" {
+			update.Symbol = opts.Symbol
+		}
+
+		if pending != nil {
+			pending.Timestamp = update.EventTime
+			if pending.Symbol == "" {
+				pending.Symbol = update.Symbol
+			}
+			if err := w.snapshot(pending); err != nil {
+				return err
+			}
+			pending = nil
+		}
+		if err := w.update(update); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	if pending != nil {
+		return fmt.Errorf("snapshot without a timestamp at end of input")
+	}
+	return nil
+}
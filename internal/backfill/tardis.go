@@ -0,0 +1,124 @@
+package backfill
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+// tardisColumns are the incremental_book_L2 columns read by the importer
+var tardisColumns = []string{"symbol", "timestamp", "is_snapshot", "side", "price", "amount"}
+
+// tardisBatch collects consecutive rows that form one snapshot or one update
+type tardisBatch struct {
+	snapshot  bool
+	symbol    string
+	timestamp int64 // Microseconds since epoch
+	bids      []exchange.PriceLevel
+	asks      []exchange.PriceLevel
+}
+
+// importTardis imports a Tardis incremental_book_L2 CSV. Consecutive snapshot rows
+// form one snapshot and update rows sharing a timestamp form one update. Tardis has
+// no update IDs, so consecutive IDs are assigned to keep the stored stream continuous.
+func importTardis(r io.Reader, opts Options, w *writer) error {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range tardisColumns {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("missing column: %s", name)
+		}
+	}
+
+	var batch *tardisBatch
+	var seq int64
+	flush := func() error {
+		if batch == nil {
+			return nil
+		}
+		defer func() { batch = nil }()
+
+		timestamp := time.UnixMicro(batch.timestamp)
+		seq++
+		if batch.snapshot {
+			return w.snapshot(&exchange.Snapshot{
+				Exchange:     opts.Exchange,
+				Symbol:       batch.symbol,
+				LastUpdateID: seq,
+				Bids:         batch.bids,
+				Asks:         batch.asks,
+				Timestamp:    timestamp,
+			})
+		}
+		return w.update(&exchange.DepthUpdate{
+			Exchange:       opts.Exchange,
+			Symbol:         batch.symbol,
+			EventTime:      timestamp,
+			LocalEventTime: timestamp,
+			FirstUpdateID:  seq,
+			FinalUpdateID:  seq,
+			PrevUpdateID:   seq - 1,
+			Bids:           batch.bids,
+			Asks:           batch.asks,
+		})
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		timestamp, err := strconv.ParseInt(record[columns["timestamp"]], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid timestamp: %w", line, err)
+		}
+		snapshot := record[columns["is_snapshot"]] == "true"
+		symbol := opts.Symbol
+		if symbol == "" {
+			symbol = record[columns["symbol"]]
+		}
+
+		// Snapshot rows continue the snapshot; update rows split on timestamp
+		if batch != nil && (batch.snapshot != snapshot || (!snapshot && batch.timestamp != timestamp)) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if batch == nil {
+			batch = &tardisBatch{snapshot: snapshot, symbol: symbol, timestamp: timestamp}
+		}
+
+		level := exchange.PriceLevel{
+			Price:    record[columns["price"]],
+			Quantity: record[columns["amount"]],
+		}
+		switch side := record[columns["side"]]; side {
+		case "bid":
+			batch.bids = append(batch.bids, level)
+		case "ask":
+			batch.asks = append(batch.asks, level)
+		default:
+			return fmt.Errorf("line %d: invalid side: %s", line, side)
+		}
+	}
+
+	return flush()
+}
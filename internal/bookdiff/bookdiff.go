@@ -0,0 +1,318 @@
+package bookdiff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/storage"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultBands are the depth bands liquidity shifts are reported for (fraction of mid price)
+var DefaultBands = []decimal.Decimal{
+	decimal.NewFromFloat(0.005),
+	decimal.NewFromFloat(0.02),
+	decimal.NewFromFloat(0.10),
+}
+
+// Side identifies a side of the book
+type Side string
+
+const (
+	Bid Side = "bid"
+	Ask Side = "ask"
+)
+
+// LevelChange is a price level that differs between two books. Before is zero
+// for an added level and After is zero for a removed one.
+type LevelChange struct {
+	Side   Side
+	Price  decimal.Decimal
+	Before decimal.Decimal
+	After  decimal.Decimal
+}
+
+// BandShift is the liquidity within a band of mid in both books. Each book is
+// measured around its own mid.
+type BandShift struct {
+	Band       decimal.Decimal // Fraction of mid price (e.g., 0.02)
+	BidsBefore decimal.Decimal
+	BidsAfter  decimal.Decimal
+	AsksBefore decimal.Decimal
+	AsksAfter  decimal.Decimal
+}
+
+// BidShift returns the change of the bid liquidity within the band
+func (b BandShift) BidShift() decimal.Decimal {
+	return b.BidsAfter.Sub(b.BidsBefore)
+}
+
+// AskShift returns the change of the ask liquidity within the band
+func (b BandShift) AskShift() decimal.Decimal {
+	return b.AsksAfter.Sub(b.AsksBefore)
+}
+
+// Result is the difference between two books
+type Result struct {
+	From, To  time.Time
+	MidBefore decimal.Decimal
+	MidAfter  decimal.Decimal
+	Added     []LevelChange // Levels only in the later book
+	Removed   []LevelChange // Levels only in the earlier book
+	Changed   []LevelChange // Levels in both books with different quantities
+	Bands     []BandShift
+}
+
+// book is a side-keyed view of a snapshot with normalized prices
+type book struct {
+	bids, asks map[string]level
+}
+
+// level is a parsed price level
+type level struct {
+	price, quantity decimal.Decimal
+}
+
+// Diff compares the snapshot before with the later snapshot after. Levels are
+// matched by price, bids are listed best first, then asks best first.
+func Diff(before, after *exchange.Snapshot, bands []decimal.Decimal) (*Result, error) {
+	from, err := parseBook(before)
+	if err != nil {
+		return nil, fmt.Errorf("earlier snapshot: %w", err)
+	}
+	to, err := parseBook(after)
+	if err != nil {
+		return nil, fmt.Errorf("later snapshot: %w", err)
+	}
+
+	result := &Result{
+		From:      before.Timestamp,
+		To:        after.Timestamp,
+		MidBefore: from.mid(),
+		MidAfter:  to.mid(),
+	}
+	result.diffSide(Bid, from.bids, to.bids)
+	result.diffSide(Ask, from.asks, to.asks)
+	for _, band := range bands {
+		shift := BandShift{Band: band}
+		shift.BidsBefore, shift.AsksBefore = from.depth(band)
+		shift.BidsAfter, shift.AsksAfter = to.depth(band)
+		result.Bands = append(result.Bands, shift)
+	}
+	return result, nil
+}
+
+// diffSide appends the level changes of one side, best price first
+func (r *Result) diffSide(side Side, before, after map[string]level) {
+	var added, removed, changed []LevelChange
+	for key, old := range before {
+		current, ok := after[key]
+		switch {
+		case !ok:
+			removed = append(removed, LevelChange{Side: side, Price: old.price, Before: old.quantity})
+		case !current.quantity.Equal(old.quantity):
+			changed = append(changed, LevelChange{Side: side, Price: old.price, Before: old.quantity, After: current.quantity})
+		}
+	}
+	for key, current := range after {
+		if _, ok := before[key]; !ok {
+			added = append(added, LevelChange{Side: side, Price: current.price, After: current.quantity})
+		}
+	}
+
+	for _, changes := range [][]LevelChange{added, removed, changed} {
+		sort.Slice(changes, func(i, j int) bool {
+			if side == Bid {
+				return changes[i].Price.GreaterThan(changes[j].Price)
+			}
+			return changes[i].Price.LessThan(changes[j].Price)
+		})
+	}
+	r.Added = append(r.Added, added...)
+	r.Removed = append(r.Removed, removed...)
+	r.Changed = append(r.Changed, changed...)
+}
+
+// parseBook indexes the levels of a snapshot by normalized price, dropping empty levels
+func parseBook(snapshot *exchange.Snapshot) (*book, error) {
+	b := &book{bids: make(map[string]level), asks: make(map[string]level)}
+	if err := addLevels(b.bids, snapshot.Bids); err != nil {
+		return nil, err
+	}
+	if err := addLevels(b.asks, snapshot.Asks); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// addLevels sets the levels into side, removing levels with a zero quantity
+func addLevels(side map[string]level, levels []exchange.PriceLevel) error {
+	for _, l := range levels {
+		price, err := decimal.NewFromString(l.Price)
+		if err != nil {
+			return fmt.Errorf("invalid price %q: %w", l.Price, err)
+		}
+		quantity, err := decimal.NewFromString(l.Quantity)
+		if err != nil {
+			return fmt.Errorf("invalid quantity %q: %w", l.Quantity, err)
+		}
+		key := price.String()
+		if quantity.IsZero() {
+			delete(side, key)
+			continue
+		}
+		side[key] = level{price: price, quantity: quantity}
+	}
+	return nil
+}
+
+// mid returns the mean of the best bid and best ask, zero for a one-sided book
+func (b *book) mid() decimal.Decimal {
+	var bestBid, bestAsk decimal.Decimal
+	for _, l := range b.bids {
+		if l.price.GreaterThan(bestBid) {
+			bestBid = l.price
+		}
+	}
+	for _, l := range b.asks {
+		if bestAsk.IsZero() || l.price.LessThan(bestAsk) {
+			bestAsk = l.price
+		}
+	}
+	if bestBid.IsZero() || bestAsk.IsZero() {
+		return decimal.Zero
+	}
+	return bestBid.Add(bestAsk).Div(decimal.NewFromInt(2))
+}
+
+// depth returns the bid and ask quantity within band of mid
+func (b *book) depth(band decimal.Decimal) (bids, asks decimal.Decimal) {
+	mid := b.mid()
+	if mid.IsZero() {
+		return decimal.Zero, decimal.Zero
+	}
+	low := mid.Mul(decimal.NewFromInt(1).Sub(band))
+	high := mid.Mul(decimal.NewFromInt(1).Add(band))
+	for _, l := range b.bids {
+		if l.price.GreaterThanOrEqual(low) {
+			bids = bids.Add(l.quantity)
+		}
+	}
+	for _, l := range b.asks {
+		if l.price.LessThanOrEqual(high) {
+			asks = asks.Add(l.quantity)
+		}
+	}
+	return bids, asks
+}
+
+// Reconstruct rebuilds the book of name and symbol at the given time from the
+// latest stored snapshot at or before it and the stored updates since then.
+// Updates already covered by the snapshot's update ID are skipped.
+func Reconstruct(ctx context.Context, store storage.Storage, name exchange.ExchangeName, symbol string, at time.Time) (*exchange.Snapshot, error) {
+	snapshots, err := store.QuerySnapshots(ctx, storage.Query{Exchange: name, Symbol: symbol, To: at})
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("no %s %s snapshot at or before %s", name, symbol, at.Format(time.RFC3339))
+	}
+	base := snapshots[len(snapshots)-1]
+
+	updates, err := store.QueryUpdates(ctx, storage.Query{Exchange: name, Symbol: symbol, From: base.Timestamp, To: at})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := parseBook(base)
+	if err != nil {
+		return nil, err
+	}
+	lastID := base.LastUpdateID
+	for _, update := range updates {
+		if base.LastUpdateID != 0 || update.FinalUpdateID != 0 {
+			if update.FinalUpdateID <= lastID {
+				continue
+			}
+			lastID = update.FinalUpdateID
+		} else if !update.EventTime.After(base.Timestamp) {
+			continue
+		}
+		if err := addLevels(b.bids, update.Bids); err != nil {
+			return nil, err
+		}
+		if err := addLevels(b.asks, update.Asks); err != nil {
+			return nil, err
+		}
+	}
+
+	return &exchange.Snapshot{
+		Exchange:     name,
+		Symbol:       symbol,
+		LastUpdateID: lastID,
+		Bids:         b.levels(b.bids, true),
+		Asks:         b.levels(b.asks, false),
+		Timestamp:    at,
+	}, nil
+}
+
+// levels returns the levels of side sorted best first
+func (b *book) levels(side map[string]level, descending bool) []exchange.PriceLevel {
+	sorted := make([]level, 0, len(side))
+	for _, l := range side {
+		sorted = append(sorted, l)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if descending {
+			return sorted[i].price.GreaterThan(sorted[j].price)
+		}
+		return sorted[i].price.LessThan(sorted[j].price)
+	})
+
+	levels := make([]exchange.PriceLevel, len(sorted))
+	for i, l := range sorted {
+		levels[i] = exchange.PriceLevel{Price: l.price.String(), Quantity: l.quantity.String()}
+	}
+	return levels
+}
+
+// Print writes the result as text: the level changes of each kind followed by
+// the liquidity shift of every band
+func Print(w io.Writer, r *Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "From %s (mid %s) to %s (mid %s)\n",
+		r.From.UTC().Format(time.RFC3339Nano), r.MidBefore, r.To.UTC().Format(time.RFC3339Nano), r.MidAfter)
+
+	sections := []struct {
+		title   string
+		changes []LevelChange
+	}{
+		{"Added", r.Added},
+		{"Removed", r.Removed},
+		{"Changed", r.Changed},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(tw, "\n%s levels (%d)\n", section.title, len(section.changes))
+		if len(section.changes) == 0 {
+			continue
+		}
+		fmt.Fprintln(tw, "side\tprice\tbefore\tafter\tchange\t")
+		for _, c := range section.changes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", c.Side, c.Price, c.Before, c.After, c.After.Sub(c.Before))
+		}
+	}
+
+	fmt.Fprintln(tw, "\nNet liquidity shift per band")
+	fmt.Fprintln(tw, "band\tbids before\tbids after\tbid shift\tasks before\tasks after\task shift\t")
+	for _, b := range r.Bands {
+		fmt.Fprintf(tw, "%s%%\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			b.Band.Mul(decimal.NewFromInt(100)), b.BidsBefore, b.BidsAfter, b.BidShift(), b.AsksBefore, b.AsksAfter, b.AskShift())
+	}
+	return tw.Flush()
+}
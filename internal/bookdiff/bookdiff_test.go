@@ -0,0 +1,121 @@
+package bookdiff
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/storage"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDiff(t *testing.T) {
+	d := decimal.RequireFromString
+	base := time.Unix(1700000000, 0)
+	before := &exchange.Snapshot{
+		Bids:      []exchange.PriceLevel{{Price: "100", Quantity: "5"}, {Price: "99.0", Quantity: "2"}, {Price: "92", Quantity: "10"}},
+		Asks:      []exchange.PriceLevel{{Price: "101", Quantity: "3"}, {Price: "102", Quantity: "1"}},
+		Timestamp: base,
+	}
+	after := &exchange.Snapshot{
+		Bids:      []exchange.PriceLevel{{Price: "100", Quantity: "1"}, {Price: "99", Quantity: "2"}, {Price: "92", Quantity: "10"}},
+		Asks:      []exchange.PriceLevel{{Price: "101", Quantity: "3"}, {Price: "100.5", Quantity: "4"}},
+		Timestamp: base.Add(time.Second),
+	}
+
+	result, err := Diff(before, after, DefaultBands)
+	if err != nil {
+		t.Fatalf("Diff() failed: %v", err)
+	}
+
+	// 99.0 and 99 are the same level
+	if len(result.Changed) != 1 || result.Changed[0].Side != Bid || !result.Changed[0].Price.Equal(d("100")) ||
+		!result.Changed[0].Before.Equal(d("5")) || !result.Changed[0].After.Equal(d("1")) {
+		t.Errorf("Expected the 100 bid to change from 5 to 1, got %+v", result.Changed)
+	}
+	if len(result.Added) != 1 || result.Added[0].Side != Ask || !result.Added[0].Price.Equal(d("100.5")) {
+		t.Errorf("Expected the 100.5 ask to be added, got %+v", result.Added)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].Side != Ask || !result.Removed[0].Price.Equal(d("102")) {
+		t.Errorf("Expected the 102 ask to be removed, got %+v", result.Removed)
+	}
+
+	// The 2% band is measured around each book's own mid (100.5, then 100.25)
+	band := result.Bands[1]
+	if !band.BidsBefore.Equal(d("7")) || !band.BidsAfter.Equal(d("3")) || !band.BidShift().Equal(d("-4")) {
+		t.Errorf("Expected 2%% bids to shift from 7 to 3, got %+v", band)
+	}
+	if !band.AsksBefore.Equal(d("4")) || !band.AsksAfter.Equal(d("7")) || !band.AskShift().Equal(d("3")) {
+		t.Errorf("Expected 2%% asks to shift from 4 to 7, got %+v", band)
+	}
+	// The 92 bid is only inside the 10% band
+	if wide := result.Bands[2]; !wide.BidsBefore.Equal(d("17")) {
+		t.Errorf("Expected 17 bids within 10%%, got %s", wide.BidsBefore)
+	}
+
+	var out bytes.Buffer
+	if err := Print(&out, result); err != nil {
+		t.Fatalf("Print() failed: %v", err)
+	}
+	for _, want := range []string{"Added levels (1)", "Removed levels (1)", "Changed levels (1)", "2%"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestReconstruct(t *testing.T) {
+	store, err := storage.NewSQLite(filepath.Join(t.TempDir(), "orderbook.db"))
+	if err != nil {
+		t.Fatalf("NewSQLite() failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	base := time.UnixMilli(1700000000000)
+	err = store.WriteSnapshot(ctx, &exchange.Snapshot{
+		Exchange: exchange.Binancef, Symbol: "BTCUSDT", LastUpdateID: 10,
+		Bids:      []exchange.PriceLevel{{Price: "50000", Quantity: "1"}},
+		Asks:      []exchange.PriceLevel{{Price: "50001", Quantity: "2"}},
+		Timestamp: base,
+	})
+	if err != nil {
+		t.Fatalf("WriteSnapshot() failed: %v", err)
+	}
+	updates := []*exchange.DepthUpdate{
+		// Covered by the snapshot
+		{FirstUpdateID: 9, FinalUpdateID: 10, Bids: []exchange.PriceLevel{{Price: "50000", Quantity: "9"}}, EventTime: base},
+		{FirstUpdateID: 11, FinalUpdateID: 11, Bids: []exchange.PriceLevel{{Price: "50000", Quantity: "0"}, {Price: "49999", Quantity: "3"}}, EventTime: base.Add(time.Second)},
+		// After the requested time
+		{FirstUpdateID: 12, FinalUpdateID: 12, Asks: []exchange.PriceLevel{{Price: "50001", Quantity: "0"}}, EventTime: base.Add(3 * time.Second)},
+	}
+	for _, update := range updates {
+		update.Exchange, update.Symbol = exchange.Binancef, "BTCUSDT"
+		if err := store.WriteUpdate(ctx, update); err != nil {
+			t.Fatalf("WriteUpdate() failed: %v", err)
+		}
+	}
+
+	book, err := Reconstruct(ctx, store, exchange.Binancef, "BTCUSDT", base.Add(2*time.Second))
+	if err != nil {
+		t.Fatalf("Reconstruct() failed: %v", err)
+	}
+	if len(book.Bids) != 1 || book.Bids[0].Price != "49999" || book.Bids[0].Quantity != "3" {
+		t.Errorf("Expected a single 49999 bid of 3, got %+v", book.Bids)
+	}
+	if len(book.Asks) != 1 || book.Asks[0].Price != "50001" {
+		t.Errorf("Expected the 50001 ask to remain, got %+v", book.Asks)
+	}
+	if book.LastUpdateID != 11 {
+		t.Errorf("Expected last update ID 11, got %d", book.LastUpdateID)
+	}
+
+	if _, err := Reconstruct(ctx, store, exchange.Binancef, "BTCUSDT", base.Add(-time.Second)); err == nil {
+		t.Error("Expected an error without an earlier snapshot")
+	}
+}
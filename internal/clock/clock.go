@@ -0,0 +1,175 @@
+// Package clock abstracts the time source so time-dependent behavior (expiry,
+// reinitialization intervals, staleness, broadcast cadence) can be driven by
+// tests. Production code uses Real; tests use a Fake advanced by hand.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer fires once on its channel, like time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker fires periodically on its channel, like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the system clock
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// Fake is a clock that only moves when Advance is called. Timers and tickers
+// fire during Advance; like their time counterparts they drop ticks the
+// receiver is not ready for.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a pending timer or ticker
+type fakeWaiter struct {
+	clock  *Fake
+	at     time.Time
+	period time.Duration // 0 for timers
+	c      chan time.Time
+}
+
+// NewFake creates a fake clock set to start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now returns the fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After returns a channel receiving the fake time once d has been advanced
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer creates a timer firing once d has been advanced
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.add(d, 0)
+}
+
+// NewTicker creates a ticker firing every d of advanced time
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.add(d, d)}
+}
+
+// Waiters returns the number of pending timers and tickers, so tests can wait
+// for a goroutine to start waiting before advancing
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// Advance moves the clock forward by d, firing the timers and tickers due on the way in order
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := f.now.Add(d)
+	for {
+		sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+		if len(f.waiters) == 0 || f.waiters[0].at.After(end) {
+			break
+		}
+
+		w := f.waiters[0]
+		f.now = w.at
+		select {
+		case w.c <- w.at:
+		default:
+		}
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			f.waiters = f.waiters[1:]
+		}
+	}
+	f.now = end
+}
+
+// add registers a waiter due after d, firing at once when d is not positive
+func (f *Fake) add(d, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{clock: f, at: f.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	if d <= 0 && period == 0 {
+		w.c <- f.now
+		return w
+	}
+	f.waiters = append(f.waiters, w)
+	return w
+}
+
+// remove unregisters a waiter and reports whether it was pending
+func (f *Fake) remove(w *fakeWaiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.c }
+func (w *fakeWaiter) Stop() bool          { return w.clock.remove(w) }
+
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.c }
+func (t fakeTicker) Stop()               { t.w.clock.remove(t.w) }
@@ -0,0 +1,55 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	timer := f.NewTimer(time.Second)
+	ticker := f.NewTicker(300 * time.Millisecond)
+	stopped := f.NewTimer(time.Second)
+	if !stopped.Stop() || f.Waiters() != 2 {
+		t.Fatalf("Expected the stopped timer removed, got %d waiters", f.Waiters())
+	}
+
+	f.Advance(500 * time.Millisecond)
+	if at := <-ticker.C(); !at.Equal(start.Add(300 * time.Millisecond)) {
+		t.Errorf("Expected tick at 300ms, got %v", at.Sub(start))
+	}
+	select {
+	case <-timer.C():
+		t.Errorf("Expected the timer not to fire before 1s")
+	default:
+	}
+
+	// Ticks the receiver missed are dropped, like time.Ticker
+	f.Advance(time.Second)
+	if at := <-timer.C(); !at.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected timer at 1s, got %v", at.Sub(start))
+	}
+	if at := <-ticker.C(); !at.Equal(start.Add(600 * time.Millisecond)) {
+		t.Errorf("Expected the first pending tick at 600ms, got %v", at.Sub(start))
+	}
+	select {
+	case <-ticker.C():
+		t.Errorf("Expected missed ticks dropped")
+	default:
+	}
+	if f.Since(start) != 1500*time.Millisecond {
+		t.Errorf("Expected 1.5s elapsed, got %v", f.Since(start))
+	}
+
+	ticker.Stop()
+	if f.Waiters() != 0 {
+		t.Errorf("Expected no waiters after the timer fired and the ticker stopped, got %d", f.Waiters())
+	}
+	select {
+	case <-f.After(0):
+	default:
+		t.Errorf("Expected After(0) to fire at once")
+	}
+}
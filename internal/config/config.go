@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"orderbook/internal/exchange"
@@ -12,26 +15,287 @@ type Config struct {
 	Exchanges []ExchangeConfig
 	Display   DisplayConfig
 	App       AppConfig
+	Storage   StorageConfig
+	Server    ServerConfig
+}
+
+// ServerConfig holds WebSocket/REST server configuration
+type ServerConfig struct {
+	Port       string            // Port served when no listeners are configured
+	Listeners  []string          // Listener specs ("[unix:]address[=readonly|=control]")
+	Record     string            // File recording every broadcast, empty disables
+	Chunked    bool              // Record to a directory of compressed, indexed chunks instead of one file
+	AdminToken string            // Bearer token of the /admin/ endpoints, empty disables them
+	LogLevel   string            // "debug", "info" or "error"
+	DebugAddr  string            // Address of the pprof/expvar debug listener, empty disables it
+	Namespaces []NamespaceConfig // Independent monitors served under /ws/{name}, empty serves one monitor
+	// Buckets on each side of the mid bucket of consolidated ladder messages
+	LadderBuckets int
+	// Levels published in orderbook messages and depth responses: within this
+	// fraction of mid and of at least this quantity, 0 disables each filter
+	DepthMaxDistancePct float64
+	DepthMinQuantity    float64
+	// Collapse published levels beyond this fraction of mid into one tail level
+	// per side, 0 disables it
+	TailDistancePct float64
+}
+
+// NamespaceConfig holds a monitor served under its own WebSocket path, with its
+// own books, clients and controls
+type NamespaceConfig struct {
+	Name      string
+	Symbol    string
+	Exchanges []exchange.ExchangeName // Empty uses the configured exchanges
+}
+
+// namespacePattern restricts namespace names to path-safe identifiers
+var namespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// reservedNamespaces are the first path segments of the routes of a single server
+var reservedNamespaces = map[string]bool{"ws": true, "api": true, "admin": true, "health": true}
+
+// ValidateNamespace checks that name can be used as a namespace
+func ValidateNamespace(name string) error {
+	if !namespacePattern.MatchString(name) {
+		return fmt.Errorf("invalid namespace %q (lowercase letters, digits, - and _)", name)
+	}
+	if reservedNamespaces[name] {
+		return fmt.Errorf("namespace %q is reserved", name)
+	}
+	return nil
+}
+
+// StorageConfig holds persistent storage configuration
+type StorageConfig struct {
+	Driver string // "" (disabled), "sqlite" or "clickhouse"
+	DSN    string // SQLite file path or ClickHouse HTTP URL
 }
 
 // ExchangeConfig holds exchange-specific configuration
 type ExchangeConfig struct {
-	Name   exchange.ExchangeName
-	Symbol string
+	Name            exchange.ExchangeName
+	Symbol          string
+	StaleTimeout    time.Duration // Overrides AppConfig.StaleTimeout when non-zero
+	SnapshotTimeout time.Duration // Overrides AppConfig.SnapshotTimeout when non-zero
+	Depth           int           // Subscription or snapshot depth, 0 uses the adapter default
+	UpdateSpeed     string        // Depth stream frequency (e.g., "100ms"), empty uses the adapter default
+	Category        string        // Product category (e.g., "inverse" on Bybit), empty uses the adapter default
+	SigFigs         int           // Significant figures of server-side aggregated price levels (Hyperliquid), 0 for full precision
+	Mantissa        int           // Aggregation step mantissa with 5 significant figures (Hyperliquid), 0 for the venue default
+	Instrument      string        // Venue instrument ID used verbatim instead of converting Symbol (e.g., "BTC-USD-250627" on OKX), kept across symbol changes
+	MaxBookAge      time.Duration // Clear the book after this long without data until fresh data arrives, 0 disables
+	ReinitInterval  time.Duration // Overrides AppConfig.ReinitCheckInterval when non-zero
+	ReinitBuffer    int           // Overrides AppConfig.MaxBufferSize when non-zero
+	ContractSize    float64       // Base units per contract for the contracts quantity unit, 0 counts one base unit
+	TopOfBook       bool          // Stream the best bid and ask only (Binance bookTicker, Bybit depth 1)
 }
 
 // DisplayConfig holds display-related configuration
 type DisplayConfig struct {
 	Top            int
 	UpdateInterval time.Duration
+	Compact        bool   // One line of stats per exchange
+	Output         string // Stats format: "text" or "json" (one object per interval on stdout)
 }
 
 // AppConfig holds general application configuration
 type AppConfig struct {
-	DefaultTickLevel    types.TickLevel
-	ReinitCheckInterval time.Duration
-	MaxBufferSize       int
-	UpdateChannelSize   int
+	DefaultTickLevel     types.TickLevel
+	TickPreset           string // Base asset whose tick preset is used (e.g., "ETH"), empty selects by symbol
+	ReinitCheckInterval  time.Duration
+	MaxBufferSize        int // Buffered events past which a book is reloaded from a snapshot
+	UpdateChannelSize    int
+	FixedPoint           bool            // Use the fixed-point engine when instrument metadata is available
+	StatsInterval        time.Duration   // Interval between liquidity band recomputations, 0 recomputes on every update
+	StaleTimeout         time.Duration   // Reconnect an exchange after this long without messages, 0 disables
+	SnapshotTimeout      time.Duration   // Per-attempt snapshot timeout, 0 keeps the adapter default
+	SnapshotAttempts     int             // Snapshot attempts at startup and reinitialization
+	SnapshotBackoff      time.Duration   // Delay before the first snapshot retry, doubled after each failure
+	SnapshotMaxBackoff   time.Duration   // Upper bound of the snapshot retry delay
+	PruneInterval        time.Duration   // Interval between orderbook pruning passes
+	PruneMaxDistancePct  float64         // Drop levels further than this fraction from mid, 0 disables
+	PruneMaxLevels       int             // Max levels kept per side, 0 disables
+	FilterMaxDistancePct float64         // Ignore incoming levels further than this fraction from mid, 0 disables
+	FilterMinQuantity    float64         // Ignore incoming levels smaller than this quantity, 0 disables
+	CrossedPolicy        string          // Healing of crossed books: "clean", "resync" or "ignore"
+	GapResyncCooldown    time.Duration   // Minimum delay between resyncs triggered by definitive sequence gaps, 0 only resyncs on buffer overflow
+	QuantityUnit         string          // Default unit of published quantities: "base", "quote" or "contracts"
+	Shards               int             // Workers applying exchange updates, 0 uses GOMAXPROCS
+	ShardQueueSize       int             // Updates queued per worker before the exchange readers block
+	ShardLockThreads     bool            // Lock each worker to its own OS thread
+	SnapshotInterval     time.Duration   // Persist full books on wall-clock boundaries of this interval (e.g., 1m, 1h), 0 disables
+	SpreadHorizons       []time.Duration // Realized spread horizons, ascending
+	SpreadWindow         int             // Trades kept in rolling spread averages
+	AverageWindows       []time.Duration // Windows of the time-weighted spread and liquidity averages, ascending
+	LeadLag              LeadLagConfig
+	FairValue            FairValueConfig
+	LiquidityScore       LiquidityScoreConfig
+	Candles              CandleConfig
+	Iceberg              IcebergConfig
+	Resilience           ResilienceConfig
+	OpenInterest         OpenInterestConfig
+	Anomaly              AnomalyConfig
+	Pipeline             PipelineConfig
+	Summary              SummaryConfig
+	Report               ReportConfig
+	Watchlist            WatchlistConfig
+	Fees                 map[exchange.ExchangeName]types.FeeSchedule // Maker/taker fees used by the router and fee-adjusted prices
+	FeeAdjusted          bool                                        // Publish prices net of taker fees (bids lowered, asks raised)
+	QuoteRate            QuoteRateConfig
+	Composite            CompositeConfig
+	Tracing              TracingConfig
+	Transport            TransportConfig
+	Warmup               WarmupConfig
+	Journal              JournalConfig
+	Distributed          DistributedConfig
+}
+
+// DistributedConfig holds the role of the process in distributed mode, where
+// collectors run the exchange adapters and an aggregator maintains the books
+type DistributedConfig struct {
+	Aggregator     string        // Collect endpoint of the aggregator to stream the exchanges to, which makes the process a collector
+	Collectors     bool          // Take the exchanges from collectors connecting to /collect instead of running the adapters
+	CollectorID    string        // ID reported by a collector, empty uses host:pid
+	ConnectTimeout time.Duration // Wait of the aggregator for the collector of each exchange
+}
+
+// JournalConfig holds the write-ahead journal of book checkpoints and engine state
+// that lets a restarted engine resume venues instead of cold-starting them
+type JournalConfig struct {
+	File     string        // Journal file, empty disables journaling
+	Interval time.Duration // Interval between checkpoints of every book
+	MaxAge   time.Duration // Checkpoints older than this are not resumed from
+	Levels   int           // Levels kept per side in a checkpoint, 0 keeps all
+}
+
+// WarmupConfig holds the stability a book must show after initialization or a
+// resync before it is broadcast and displayed
+type WarmupConfig struct {
+	Duration time.Duration // Time without gaps or resyncs, 0 disables
+	Events   int           // Updates applied without gaps or resyncs, 0 disables
+}
+
+// TransportConfig holds the connection settings shared by the exchange adapters
+type TransportConfig struct {
+	DialTimeout     time.Duration // Timeout of each dial, DNS lookup included
+	DNSCacheTTL     time.Duration // Lifetime of cached DNS answers, 0 disables caching
+	MaxConnsPerHost int           // REST and WebSocket connections open to one host, each, 0 is unlimited
+	HappyEyeballs   bool          // Race IPv6 and IPv4 when a host has both
+}
+
+// TracingConfig holds configuration for the tracing of the update path
+type TracingConfig struct {
+	Exporter    string  // Span exporter: "none", "stderr" or "otlp"
+	Endpoint    string  // OTLP/HTTP collector URL, empty uses http://localhost:4318
+	Service     string  // service.name of the exported spans
+	SampleRatio float64 // Fraction of updates and broadcasts traced
+}
+
+// QuoteRateConfig holds the stablecoin pair feed used to normalize books quoted in
+// other currencies (e.g., Kraken and Coinbase in USD) to the pair's base (e.g., USDT)
+type QuoteRateConfig struct {
+	Exchange exchange.ExchangeName // Exchange streaming the pair, empty disables conversion
+	Symbol   string                // Pair whose base is the common quote (e.g., "USDTUSD")
+	Interval time.Duration         // Interval between rate updates
+}
+
+// CompositeConfig holds the extra quote currencies whose books are merged into each
+// exchange's book, so venues splitting liquidity across stablecoins compare fairly
+type CompositeConfig struct {
+	Quotes   []string      // Extra quote currencies (e.g., "USDC", "USD"), empty disables composites
+	Interval time.Duration // Interval between rebuilds of the composite books
+}
+
+// LiquidityScoreConfig holds configuration for the composite venue ranking
+type LiquidityScoreConfig struct {
+	Interval        time.Duration // Interval between rankings
+	SpreadWeight    float64       // Weight of spread tightness
+	Depth05Weight   float64       // Weight of liquidity within 0.5% of mid
+	Depth2Weight    float64       // Weight of liquidity within 2% of mid
+	UptimeWeight    float64       // Weight of feed uptime
+	StalenessWeight float64       // Weight of feed freshness
+	UptimeHorizon   time.Duration // Uptime that earns the full uptime weight
+	MaxStaleness    time.Duration // Silence that loses the full staleness weight
+}
+
+// CandleConfig holds configuration for the OHLC candles built from book prices
+type CandleConfig struct {
+	SampleInterval time.Duration   // Interval between price samples
+	Intervals      []time.Duration // Candle intervals built per exchange
+	History        int             // Closed candles kept per exchange, price and interval
+	Microprice     bool            // Also build candles of the microprice
+}
+
+// IcebergConfig holds configuration for the detection of levels refilled at the touch
+type IcebergConfig struct {
+	NearTouchBps  float64       // Levels within this distance (in basis points) of the best price are watched
+	RefillWindow  time.Duration // Maximum delay between a level being consumed and refilled
+	SizeTolerance float64       // Refills within this fraction of the displayed size count as the same size
+	MinRefills    int           // Consecutive refills before a level is flagged
+}
+
+// ResilienceConfig holds configuration for the time-to-replenish measurement after sweeps
+type ResilienceConfig struct {
+	Interval         time.Duration // Interval between updates of the published metric
+	MinSweepNotional float64       // Notional (in quote currency) removed at the touch by one update that counts as a sweep
+	BandBps          float64       // Distance (in basis points) from the swept price within which liquidity must reappear
+	MaxWait          time.Duration // Sweeps not replenished after this long count as unrecovered
+	Window           int           // Latest sweeps the metric is computed over
+}
+
+// OpenInterestConfig holds configuration for the open interest polled from derivatives venues
+type OpenInterestConfig struct {
+	Interval time.Duration // Interval between polls of each venue, 0 disables polling
+	Window   time.Duration // Window over which the open interest change is measured
+}
+
+// AnomalyConfig holds configuration for the detection of spread and depth spikes
+type AnomalyConfig struct {
+	ZScore     float64       // Distance from the rolling mean, in standard deviations, that is anomalous, 0 disables
+	Window     int           // Stats samples in the rolling mean and standard deviation
+	MinSamples int           // Samples required before a venue is checked
+	Cooldown   time.Duration // Minimum delay between two anomalies of the same venue and metric
+}
+
+// PipelineConfig holds configuration for the pipeline feeding the analytics processors
+type PipelineConfig struct {
+	QueueSize     int           // Events queued per processor before new ones are dropped
+	StatsInterval time.Duration // Interval between the book statistics passed to processors
+	Scripts       string        // JSON file of scripts evaluated on book events, empty disables scripting
+}
+
+// SummaryConfig holds configuration for session summaries
+type SummaryConfig struct {
+	Interval time.Duration // Interval between periodic summaries, 0 only summarizes on exit
+	File     string        // JSON file rewritten with the latest summary, empty disables
+}
+
+// ReportConfig holds configuration for the daily feed SLA reports
+type ReportConfig struct {
+	Dir  string // Directory the report of each day is written to as JSON and Markdown, empty disables
+	Keep int    // Days of reports served by the REST endpoints
+}
+
+// WatchlistConfig holds configuration for the watchlist rotation, which cycles
+// the monitored symbol through a list of symbols
+type WatchlistConfig struct {
+	Symbols  []string      // Symbols monitored in turn, empty disables the rotation
+	Interval time.Duration // Time spent on each symbol, startup included
+}
+
+// FairValueConfig holds configuration for fair value deviation monitoring
+type FairValueConfig struct {
+	Interval     time.Duration // Interval between fair value evaluations
+	ThresholdBps float64       // Deviation (in basis points) that raises an alert
+	MinVenues    int           // Minimum venues required to compute a fair value
+}
+
+// LeadLagConfig holds configuration for cross-venue lead-lag analysis
+type LeadLagConfig struct {
+	SampleInterval  time.Duration // Interval between mid-price samples
+	Window          int           // Number of samples kept per venue
+	MaxLag          int           // Maximum lag (in samples) tested in each direction
+	PublishInterval time.Duration // Interval between published lead-lag reports
 }
 
 // Default returns the default configuration for BTCUSDT on Binance Futures
@@ -45,13 +309,141 @@ func Default() Config {
 		},
 		Display: DisplayConfig{
 			Top:            10,
-			UpdateInterval: 2 * time.Second,
+			UpdateInterval: 10 * time.Second,
+			Output:         "text",
+		},
+		Server: ServerConfig{
+			Port:          "8086",
+			LogLevel:      "info",
+			LadderBuckets: types.DefaultLadderBuckets,
 		},
 		App: AppConfig{
-			DefaultTickLevel:    types.Tick1,
-			ReinitCheckInterval: 5 * time.Second,
-			MaxBufferSize:       100,
-			UpdateChannelSize:   1000,
+			DefaultTickLevel:     types.Tick1,
+			ReinitCheckInterval:  5 * time.Second,
+			MaxBufferSize:        100,
+			UpdateChannelSize:    1000,
+			StaleTimeout:         30 * time.Second,
+			SnapshotAttempts:     5,
+			SnapshotBackoff:      time.Second,
+			SnapshotMaxBackoff:   30 * time.Second,
+			PruneInterval:        30 * time.Second,
+			PruneMaxDistancePct:  0.5,
+			PruneMaxLevels:       10000,
+			FilterMaxDistancePct: 0.5,
+			CrossedPolicy:        "clean",
+			GapResyncCooldown:    10 * time.Second,
+			QuantityUnit:         "base",
+			ShardQueueSize:       1024,
+			SpreadHorizons:       []time.Duration{time.Second, 5 * time.Second, 30 * time.Second},
+			SpreadWindow:         500,
+			AverageWindows:       []time.Duration{time.Minute, 5 * time.Minute, time.Hour},
+			LeadLag: LeadLagConfig{
+				SampleInterval:  100 * time.Millisecond,
+				Window:          600,
+				MaxLag:          10,
+				PublishInterval: 10 * time.Second,
+			},
+			FairValue: FairValueConfig{
+				Interval:     time.Second,
+				ThresholdBps: 25,
+				MinVenues:    3,
+			},
+			LiquidityScore: LiquidityScoreConfig{
+				Interval:        5 * time.Second,
+				SpreadWeight:    0.3,
+				Depth05Weight:   0.25,
+				Depth2Weight:    0.15,
+				UptimeWeight:    0.1,
+				StalenessWeight: 0.2,
+				UptimeHorizon:   15 * time.Minute,
+				MaxStaleness:    10 * time.Second,
+			},
+			Candles: CandleConfig{
+				SampleInterval: 100 * time.Millisecond,
+				Intervals:      []time.Duration{time.Second, 5 * time.Second, time.Minute},
+				History:        500,
+			},
+			Iceberg: IcebergConfig{
+				NearTouchBps:  2,
+				RefillWindow:  2 * time.Second,
+				SizeTolerance: 0.25,
+				MinRefills:    3,
+			},
+			Resilience: ResilienceConfig{
+				Interval:         time.Second,
+				MinSweepNotional: 50000,
+				BandBps:          10,
+				MaxWait:          30 * time.Second,
+				Window:           50,
+			},
+			OpenInterest: OpenInterestConfig{
+				Interval: 30 * time.Second,
+				Window:   time.Hour,
+			},
+			Anomaly: AnomalyConfig{
+				ZScore:     4,
+				Window:     300,
+				MinSamples: 60,
+				Cooldown:   time.Minute,
+			},
+			Pipeline: PipelineConfig{
+				QueueSize:     1024,
+				StatsInterval: time.Second,
+			},
+			Summary: SummaryConfig{
+				Interval: time.Hour,
+			},
+			Report: ReportConfig{
+				Keep: 30,
+			},
+			Watchlist: WatchlistConfig{
+				Interval: 30 * time.Second,
+			},
+			QuoteRate: QuoteRateConfig{
+				Exchange: exchange.Kraken,
+				Symbol:   "USDTUSD",
+				Interval: time.Second,
+			},
+			Tracing: TracingConfig{
+				Exporter:    "none",
+				Service:     "orderbook",
+				SampleRatio: 0.01,
+			},
+			Transport: TransportConfig{
+				DialTimeout:     10 * time.Second,
+				DNSCacheTTL:     5 * time.Minute,
+				MaxConnsPerHost: 16,
+				HappyEyeballs:   true,
+			},
+			Composite: CompositeConfig{
+				Interval: 250 * time.Millisecond,
+			},
+			Distributed: DistributedConfig{
+				ConnectTimeout: 30 * time.Second,
+			},
+			Journal: JournalConfig{
+				Interval: 30 * time.Second,
+				MaxAge:   5 * time.Minute,
+				Levels:   1000,
+			},
+			// Base tier fees
+			Fees: map[exchange.ExchangeName]types.FeeSchedule{
+				exchange.Binance:      {MakerBps: 10, TakerBps: 10},
+				exchange.Binancef:     {MakerBps: 2, TakerBps: 5},
+				exchange.Binancefc:    {MakerBps: 2, TakerBps: 5},
+				exchange.Bybit:        {MakerBps: 10, TakerBps: 10},
+				exchange.Bybitf:       {MakerBps: 2, TakerBps: 5.5},
+				exchange.Kraken:       {MakerBps: 25, TakerBps: 40},
+				exchange.Krakenf:      {MakerBps: 2, TakerBps: 5},
+				exchange.Hyperliquidf: {MakerBps: 1.5, TakerBps: 4.5},
+				exchange.OKX:          {MakerBps: 8, TakerBps: 10},
+				exchange.Coinbase:     {MakerBps: 40, TakerBps: 60},
+				exchange.Coinbasef:    {MakerBps: 0, TakerBps: 3},
+				exchange.Asterdex:     {MakerBps: 10, TakerBps: 10},
+				exchange.Asterdexf:    {MakerBps: 1, TakerBps: 3.5},
+				exchange.BingX:        {MakerBps: 10, TakerBps: 10},
+				exchange.BingXf:       {MakerBps: 2, TakerBps: 5},
+			},
 		},
 	}
 }
@@ -75,6 +467,98 @@ func NewMultiExchange(exchanges []ExchangeConfig) Config {
 	return cfg
 }
 
+// SelectExchanges replaces the exchange list with names, in order, keeping the
+// settings of exchanges that were already configured
+func (c *Config) SelectExchanges(names []exchange.ExchangeName) {
+	symbol := ""
+	if len(c.Exchanges) > 0 {
+		symbol = c.Exchanges[0].Symbol
+	}
+	existing := make(map[exchange.ExchangeName]ExchangeConfig, len(c.Exchanges))
+	for _, ex := range c.Exchanges {
+		existing[ex.Name] = ex
+	}
+
+	c.Exchanges = make([]ExchangeConfig, len(names))
+	for i, name := range names {
+		ex, ok := existing[name]
+		if !ok {
+			ex = ExchangeConfig{Name: name, Symbol: symbol}
+		}
+		c.Exchanges[i] = ex
+	}
+}
+
+// ForNamespace returns a copy of the configuration monitoring the symbol and
+// exchanges of ns
+func (c *Config) ForNamespace(ns NamespaceConfig) Config {
+	cfg := *c
+	cfg.Exchanges = c.ExchangesForSymbol(ns.Symbol)
+	if len(ns.Exchanges) > 0 {
+		cfg.SelectExchanges(ns.Exchanges)
+	}
+	return cfg
+}
+
+// ExchangesForSymbol returns copies of the exchange configurations trading symbol
+func (c *Config) ExchangesForSymbol(symbol string) []ExchangeConfig {
+	exchanges := make([]ExchangeConfig, len(c.Exchanges))
+	for i, ex := range c.Exchanges {
+		ex.Symbol = symbol
+		exchanges[i] = ex
+	}
+	return exchanges
+}
+
+// StaleTimeoutFor returns the heartbeat watchdog timeout of an exchange
+func (c *Config) StaleTimeoutFor(ex ExchangeConfig) time.Duration {
+	if ex.StaleTimeout > 0 {
+		return ex.StaleTimeout
+	}
+	return c.App.StaleTimeout
+}
+
+// ReinitIntervalFor returns the reinitialization check interval of an exchange
+func (c *Config) ReinitIntervalFor(ex ExchangeConfig) time.Duration {
+	if ex.ReinitInterval > 0 {
+		return ex.ReinitInterval
+	}
+	return c.App.ReinitCheckInterval
+}
+
+// ReinitBufferFor returns the buffered events past which an exchange's book is reloaded
+func (c *Config) ReinitBufferFor(ex ExchangeConfig) int {
+	if ex.ReinitBuffer > 0 {
+		return ex.ReinitBuffer
+	}
+	return c.App.MaxBufferSize
+}
+
+// SnapshotPolicyFor returns the snapshot timeout and retry policy of an exchange
+func (c *Config) SnapshotPolicyFor(ex ExchangeConfig) exchange.RetryPolicy {
+	timeout := c.App.SnapshotTimeout
+	if ex.SnapshotTimeout > 0 {
+		timeout = ex.SnapshotTimeout
+	}
+	return exchange.RetryPolicy{
+		Timeout:    timeout,
+		Attempts:   c.App.SnapshotAttempts,
+		Backoff:    c.App.SnapshotBackoff,
+		MaxBackoff: c.App.SnapshotMaxBackoff,
+	}
+}
+
+// TickLevelsFor returns the tick levels of a symbol from the configured or matching preset.
+// It reports false when no preset applies and the levels must come from instrument metadata.
+func (c *Config) TickLevelsFor(symbol string) ([]types.TickLevel, bool) {
+	if c.App.TickPreset != "" {
+		if levels, ok := types.TickPresets[strings.ToUpper(c.App.TickPreset)]; ok {
+			return levels, true
+		}
+	}
+	return types.TickLevelsForSymbol(symbol)
+}
+
 // SetTickLevel updates the default tick level
 func (c *Config) SetTickLevel(tick types.TickLevel) {
 	c.App.DefaultTickLevel = tick
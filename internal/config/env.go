@@ -0,0 +1,761 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"orderbook/internal/display"
+	"orderbook/internal/exchange"
+	"orderbook/internal/logging"
+	"orderbook/internal/tracing"
+	"orderbook/internal/types"
+)
+
+// Environment variables read by ApplyEnv
+const (
+	EnvSymbol            = "ORDERBOOK_SYMBOL"              // Trading symbol (e.g., "BTCUSDT")
+	EnvExchanges         = "ORDERBOOK_EXCHANGES"           // Comma-separated exchange names (e.g., "binancef,bybit")
+	EnvPort              = "ORDERBOOK_PORT"                // WebSocket/REST port
+	EnvListen            = "ORDERBOOK_LISTEN"              // Comma-separated listener specs, replaces the port
+	EnvRecord            = "ORDERBOOK_RECORD"              // Broadcast recording file
+	EnvRecordChunked     = "ORDERBOOK_RECORD_CHUNKED"      // Record to compressed, indexed chunks ("true", "false")
+	EnvLogInterval       = "ORDERBOOK_LOG_INTERVAL"        // Console stats interval (e.g., "10s")
+	EnvCompact           = "ORDERBOOK_COMPACT"             // One console line per exchange ("true", "false")
+	EnvOutput            = "ORDERBOOK_OUTPUT"              // Console stats format ("text", "json")
+	EnvStorage           = "ORDERBOOK_STORAGE"             // Storage driver ("sqlite", "clickhouse")
+	EnvStorageDSN        = "ORDERBOOK_STORAGE_DSN"         // Storage DSN
+	EnvFixedPoint        = "ORDERBOOK_FIXED_POINT"         // Use the fixed-point engine ("true", "false")
+	EnvStatsInterval     = "ORDERBOOK_STATS_INTERVAL"      // Liquidity stats interval, "0" for every update
+	EnvStaleTimeout      = "ORDERBOOK_STALE_TIMEOUT"       // Heartbeat watchdog timeout, "0" disables
+	EnvTickPreset        = "ORDERBOOK_TICK_PRESET"         // Tick preset base asset (e.g., "ETH")
+	EnvPruneMaxDistance  = "ORDERBOOK_PRUNE_MAX_DISTANCE"  // Prune distance from mid as a fraction
+	EnvPruneMaxLevels    = "ORDERBOOK_PRUNE_MAX_LEVELS"    // Max levels kept per side
+	EnvFilterMaxDistance = "ORDERBOOK_FILTER_MAX_DISTANCE" // Ingestion distance filter as a fraction
+	EnvFilterMinQuantity = "ORDERBOOK_FILTER_MIN_QUANTITY" // Ingestion minimum quantity
+	EnvDepthMaxDistance  = "ORDERBOOK_DEPTH_MAX_DISTANCE"  // Published depth distance filter as a fraction, "0" disables
+	EnvDepthMinQuantity  = "ORDERBOOK_DEPTH_MIN_QUANTITY"  // Published depth minimum quantity, "0" disables
+	EnvTailDistance      = "ORDERBOOK_TAIL_DISTANCE"       // Published depth tail bucket distance as a fraction, "0" disables
+	EnvSummaryInterval   = "ORDERBOOK_SUMMARY_INTERVAL"    // Session summary interval, "0" only on exit
+	EnvSummaryFile       = "ORDERBOOK_SUMMARY_FILE"        // Session summary JSON file
+	EnvReportDir         = "ORDERBOOK_REPORT_DIR"          // Directory of the daily SLA reports
+	EnvReportKeep        = "ORDERBOOK_REPORT_KEEP"         // Days of SLA reports served over REST
+	EnvWatchlist         = "ORDERBOOK_WATCHLIST"           // Symbols monitored in turn (e.g., "BTCUSDT,ETHUSDT,SOLUSDT"), "none" disables
+	EnvWatchlistInterval = "ORDERBOOK_WATCHLIST_INTERVAL"  // Time spent on each watchlist symbol (e.g., "30s")
+	EnvScripts           = "ORDERBOOK_SCRIPTS"             // JSON file of scripts evaluated on book events
+	EnvTracing           = "ORDERBOOK_TRACING"             // Span exporter ("none", "stderr", "otlp")
+	EnvTracingEndpoint   = "ORDERBOOK_TRACING_ENDPOINT"    // OTLP/HTTP collector URL (e.g., "http://otel:4318")
+	EnvTracingSample     = "ORDERBOOK_TRACING_SAMPLE"      // Fraction of updates traced (e.g., "0.01")
+	EnvSnapshotInterval  = "ORDERBOOK_SNAPSHOT_INTERVAL"   // Clock-aligned snapshot interval (e.g., "1m"), "0" disables
+	EnvSnapshotTimeout   = "ORDERBOOK_SNAPSHOT_TIMEOUT"    // Per-attempt snapshot timeout, "0" keeps adapter defaults
+	EnvSnapshotAttempts  = "ORDERBOOK_SNAPSHOT_ATTEMPTS"   // Snapshot attempts before giving up
+	EnvDepth             = "ORDERBOOK_DEPTH"               // Per-exchange depth (e.g., "bybit=50,kraken=10")
+	EnvUpdateSpeed       = "ORDERBOOK_UPDATE_SPEED"        // Per-exchange stream frequency (e.g., "binance=1000ms")
+	EnvCategory          = "ORDERBOOK_CATEGORY"            // Per-exchange product category (e.g., "bybitf=inverse")
+	EnvSigFigs           = "ORDERBOOK_SIG_FIGS"            // Per-exchange aggregation significant figures (e.g., "hyperliquidf=5")
+	EnvMantissa          = "ORDERBOOK_MANTISSA"            // Per-exchange aggregation mantissa (e.g., "hyperliquidf=2")
+	EnvInstrument        = "ORDERBOOK_INSTRUMENT"          // Per-exchange explicit instrument ID (e.g., "binancef=BTCUSDT_250627")
+	EnvMaxBookAge        = "ORDERBOOK_MAX_BOOK_AGE"        // Per-exchange book expiry (e.g., "okx=10s")
+	EnvReinitInterval    = "ORDERBOOK_REINIT_INTERVAL"     // Per-exchange reinitialization check interval (e.g., "binancef=1s")
+	EnvReinitBuffer      = "ORDERBOOK_REINIT_BUFFER"       // Per-exchange buffered events triggering a resync (e.g., "binancef=500")
+	EnvContractSize      = "ORDERBOOK_CONTRACT_SIZE"       // Per-exchange base units per contract (e.g., "okx=0.01")
+	EnvTopOfBook         = "ORDERBOOK_TOP_OF_BOOK"         // Per-exchange top-of-book mode (e.g., "binance=true,bybitf=true")
+	EnvMakerFees         = "ORDERBOOK_MAKER_FEES"          // Per-exchange maker fees in bps (e.g., "binance=7.5,okx=8")
+	EnvTakerFees         = "ORDERBOOK_TAKER_FEES"          // Per-exchange taker fees in bps (e.g., "binance=7.5,okx=8")
+	EnvFeeAdjusted       = "ORDERBOOK_FEE_ADJUSTED"        // Publish prices net of taker fees ("true", "false")
+	EnvCandleMicroprice  = "ORDERBOOK_CANDLE_MICROPRICE"   // Also build microprice candles ("true", "false")
+	EnvQuoteRate         = "ORDERBOOK_QUOTE_RATE"          // Quote conversion feed (e.g., "kraken:USDTUSD"), "none" disables
+	EnvCompositeQuotes   = "ORDERBOOK_COMPOSITE_QUOTES"    // Extra quotes merged into each exchange's book (e.g., "USDC,USD")
+	EnvAdminToken        = "ORDERBOOK_ADMIN_TOKEN"         // Bearer token enabling the /admin/ endpoints
+	EnvLogLevel          = "ORDERBOOK_LOG_LEVEL"           // Log level ("debug", "info", "error")
+	EnvDebugAddr         = "ORDERBOOK_DEBUG_ADDR"          // pprof/expvar debug listener address (e.g., "127.0.0.1:6060")
+	EnvNamespaces        = "ORDERBOOK_NAMESPACES"          // Monitors served under /ws/{name} (e.g., "spot=BTCUSDT,alts=ETHUSDT:okx+bybit")
+	EnvCrossedPolicy     = "ORDERBOOK_CROSSED_POLICY"      // Healing of crossed books ("clean", "resync", "ignore")
+	EnvGapResync         = "ORDERBOOK_GAP_RESYNC_COOLDOWN" // Minimum delay between gap-triggered resyncs (e.g., "10s"), "0" disables
+	EnvQuantityUnit      = "ORDERBOOK_QUANTITY_UNIT"       // Default unit of published quantities ("base", "quote", "contracts")
+	EnvShards            = "ORDERBOOK_SHARDS"              // Update workers, "0" for GOMAXPROCS
+	EnvShardQueue        = "ORDERBOOK_SHARD_QUEUE"         // Updates queued per worker
+	EnvShardLockThreads  = "ORDERBOOK_SHARD_LOCK_THREADS"  // Lock workers to OS threads ("true", "false")
+	EnvWarmup            = "ORDERBOOK_WARMUP"              // Stable time before a book is published (e.g., "3s"), "0" disables
+	EnvWarmupEvents      = "ORDERBOOK_WARMUP_EVENTS"       // Updates without gaps before a book is published
+	EnvDialTimeout       = "ORDERBOOK_DIAL_TIMEOUT"        // Exchange dial timeout, DNS included (e.g., "5s")
+	EnvDNSCacheTTL       = "ORDERBOOK_DNS_CACHE_TTL"       // Lifetime of cached DNS answers, "0" disables caching
+	EnvMaxConnsPerHost   = "ORDERBOOK_MAX_CONNS_PER_HOST"  // Connections per exchange host, "0" for unlimited
+	EnvHappyEyeballs     = "ORDERBOOK_HAPPY_EYEBALLS"      // Race IPv6 and IPv4 ("true", "false")
+	EnvAverageWindows    = "ORDERBOOK_AVERAGE_WINDOWS"     // Time-weighted average windows (e.g., "1m,5m,1h"), "none" disables
+	EnvAnomalyZScore     = "ORDERBOOK_ANOMALY_ZSCORE"      // Spread and depth z-score flagged as an anomaly, "0" disables
+	EnvOIInterval        = "ORDERBOOK_OI_INTERVAL"         // Open interest polling interval (e.g., "30s"), "0" disables
+	EnvOIWindow          = "ORDERBOOK_OI_WINDOW"           // Window of the open interest change (e.g., "1h")
+	EnvJournal           = "ORDERBOOK_JOURNAL"             // Write-ahead journal file of book checkpoints and state
+	EnvJournalInterval   = "ORDERBOOK_JOURNAL_INTERVAL"    // Interval between book checkpoints (e.g., "30s")
+	EnvJournalMaxAge     = "ORDERBOOK_JOURNAL_MAX_AGE"     // Oldest checkpoint resumed from on restart (e.g., "5m")
+	EnvAggregator        = "ORDERBOOK_AGGREGATOR"          // Run as a collector streaming to this aggregator (e.g., "ws://aggregator:8086/collect")
+	EnvCollectors        = "ORDERBOOK_COLLECTORS"          // Take the exchanges from collectors ("true", "false")
+	EnvCollectorID       = "ORDERBOOK_COLLECTOR_ID"        // ID reported by a collector
+)
+
+// ApplyEnv overrides the configuration with the ORDERBOOK_* environment variables that are set
+func (c *Config) ApplyEnv() error {
+	return c.applyEnv(os.LookupEnv)
+}
+
+// applyEnv overrides the configuration from lookup, which reports whether a variable is set
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	if value, ok := lookup(EnvExchanges); ok {
+		names, err := ParseExchangeNames(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvExchanges, err)
+		}
+		c.SelectExchanges(names)
+	}
+	if value, ok := lookup(EnvSymbol); ok {
+		for i := range c.Exchanges {
+			c.Exchanges[i].Symbol = strings.ToUpper(value)
+		}
+	}
+
+	if value, ok := lookup(EnvPort); ok {
+		if _, err := strconv.ParseUint(value, 10, 16); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvPort, err)
+		}
+		c.Server.Port = value
+	}
+	if value, ok := lookup(EnvListen); ok {
+		c.Server.Listeners = splitList(value)
+	}
+	if value, ok := lookup(EnvRecord); ok {
+		c.Server.Record = value
+	}
+	if value, ok := lookup(EnvAdminToken); ok {
+		c.Server.AdminToken = value
+	}
+	if value, ok := lookup(EnvDebugAddr); ok {
+		c.Server.DebugAddr = value
+	}
+	if value, ok := lookup(EnvLogLevel); ok {
+		if _, err := logging.ParseLevel(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvLogLevel, err)
+		}
+		c.Server.LogLevel = value
+	}
+	if value, ok := lookup(EnvStorage); ok {
+		c.Storage.Driver = value
+	}
+	if value, ok := lookup(EnvStorageDSN); ok {
+		c.Storage.DSN = value
+	}
+	if value, ok := lookup(EnvTickPreset); ok {
+		c.App.TickPreset = value
+	}
+	if value, ok := lookup(EnvCrossedPolicy); ok {
+		if _, err := types.ParseCrossedPolicy(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvCrossedPolicy, err)
+		}
+		c.App.CrossedPolicy = value
+	}
+	if value, ok := lookup(EnvOutput); ok {
+		if _, err := display.ParseFormat(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvOutput, err)
+		}
+		c.Display.Output = value
+	}
+	if value, ok := lookup(EnvQuantityUnit); ok {
+		if _, err := types.ParseQuantityUnit(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvQuantityUnit, err)
+		}
+		c.App.QuantityUnit = value
+	}
+	if value, ok := lookup(EnvSummaryFile); ok {
+		c.App.Summary.File = value
+	}
+	if value, ok := lookup(EnvReportDir); ok {
+		c.App.Report.Dir = value
+	}
+	if value, ok := lookup(EnvScripts); ok {
+		c.App.Pipeline.Scripts = value
+	}
+	if value, ok := lookup(EnvJournal); ok {
+		c.App.Journal.File = value
+	}
+	if value, ok := lookup(EnvAggregator); ok {
+		c.App.Distributed.Aggregator = value
+	}
+	if value, ok := lookup(EnvCollectorID); ok {
+		c.App.Distributed.CollectorID = value
+	}
+	if value, ok := lookup(EnvTracing); ok {
+		if err := tracing.ValidateExporter(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvTracing, err)
+		}
+		c.App.Tracing.Exporter = value
+	}
+	if value, ok := lookup(EnvTracingEndpoint); ok {
+		c.App.Tracing.Endpoint = value
+	}
+
+	durations := []struct {
+		name   string
+		target *time.Duration
+	}{
+		{EnvLogInterval, &c.Display.UpdateInterval},
+		{EnvStatsInterval, &c.App.StatsInterval},
+		{EnvStaleTimeout, &c.App.StaleTimeout},
+		{EnvSummaryInterval, &c.App.Summary.Interval},
+		{EnvSnapshotInterval, &c.App.SnapshotInterval},
+		{EnvSnapshotTimeout, &c.App.SnapshotTimeout},
+		{EnvWarmup, &c.App.Warmup.Duration},
+		{EnvDialTimeout, &c.App.Transport.DialTimeout},
+		{EnvDNSCacheTTL, &c.App.Transport.DNSCacheTTL},
+		{EnvJournalInterval, &c.App.Journal.Interval},
+		{EnvJournalMaxAge, &c.App.Journal.MaxAge},
+		{EnvOIInterval, &c.App.OpenInterest.Interval},
+		{EnvOIWindow, &c.App.OpenInterest.Window},
+		{EnvGapResync, &c.App.GapResyncCooldown},
+		{EnvWatchlistInterval, &c.App.Watchlist.Interval},
+	}
+	for _, d := range durations {
+		if value, ok := lookup(d.name); ok {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", d.name, err)
+			}
+			*d.target = parsed
+		}
+	}
+
+	floats := []struct {
+		name   string
+		target *float64
+	}{
+		{EnvPruneMaxDistance, &c.App.PruneMaxDistancePct},
+		{EnvFilterMaxDistance, &c.App.FilterMaxDistancePct},
+		{EnvFilterMinQuantity, &c.App.FilterMinQuantity},
+		{EnvDepthMaxDistance, &c.Server.DepthMaxDistancePct},
+		{EnvDepthMinQuantity, &c.Server.DepthMinQuantity},
+		{EnvTailDistance, &c.Server.TailDistancePct},
+		{EnvTracingSample, &c.App.Tracing.SampleRatio},
+		{EnvAnomalyZScore, &c.App.Anomaly.ZScore},
+	}
+	for _, f := range floats {
+		if value, ok := lookup(f.name); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", f.name, err)
+			}
+			*f.target = parsed
+		}
+	}
+
+	ints := []struct {
+		name   string
+		target *int
+	}{
+		{EnvPruneMaxLevels, &c.App.PruneMaxLevels},
+		{EnvSnapshotAttempts, &c.App.SnapshotAttempts},
+		{EnvShards, &c.App.Shards},
+		{EnvShardQueue, &c.App.ShardQueueSize},
+		{EnvWarmupEvents, &c.App.Warmup.Events},
+		{EnvMaxConnsPerHost, &c.App.Transport.MaxConnsPerHost},
+		{EnvReportKeep, &c.App.Report.Keep},
+	}
+	for _, i := range ints {
+		if value, ok := lookup(i.name); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", i.name, err)
+			}
+			*i.target = parsed
+		}
+	}
+	if value, ok := lookup(EnvDepth); ok {
+		if err := c.SetDepths(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvDepth, err)
+		}
+	}
+	if value, ok := lookup(EnvUpdateSpeed); ok {
+		if err := c.SetUpdateSpeeds(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvUpdateSpeed, err)
+		}
+	}
+	if value, ok := lookup(EnvCategory); ok {
+		if err := c.SetCategories(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvCategory, err)
+		}
+	}
+	if value, ok := lookup(EnvSigFigs); ok {
+		if err := c.SetSigFigs(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvSigFigs, err)
+		}
+	}
+	if value, ok := lookup(EnvMantissa); ok {
+		if err := c.SetMantissas(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvMantissa, err)
+		}
+	}
+	if value, ok := lookup(EnvInstrument); ok {
+		if err := c.SetInstruments(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvInstrument, err)
+		}
+	}
+	if value, ok := lookup(EnvMaxBookAge); ok {
+		if err := c.SetMaxBookAges(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvMaxBookAge, err)
+		}
+	}
+	if value, ok := lookup(EnvReinitInterval); ok {
+		if err := c.SetReinitIntervals(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvReinitInterval, err)
+		}
+	}
+	if value, ok := lookup(EnvReinitBuffer); ok {
+		if err := c.SetReinitBuffers(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvReinitBuffer, err)
+		}
+	}
+	if value, ok := lookup(EnvContractSize); ok {
+		if err := c.SetContractSizes(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvContractSize, err)
+		}
+	}
+	if value, ok := lookup(EnvTopOfBook); ok {
+		if err := c.SetTopOfBook(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvTopOfBook, err)
+		}
+	}
+	if value, ok := lookup(EnvQuoteRate); ok {
+		if err := c.SetQuoteRate(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvQuoteRate, err)
+		}
+	}
+	if value, ok := lookup(EnvNamespaces); ok {
+		if err := c.SetNamespaces(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvNamespaces, err)
+		}
+	}
+	if value, ok := lookup(EnvCompositeQuotes); ok {
+		if err := c.SetCompositeQuotes(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvCompositeQuotes, err)
+		}
+	}
+	if value, ok := lookup(EnvWatchlist); ok {
+		if err := c.SetWatchlist(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvWatchlist, err)
+		}
+	}
+	if value, ok := lookup(EnvAverageWindows); ok {
+		if err := c.SetAverageWindows(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvAverageWindows, err)
+		}
+	}
+	if value, ok := lookup(EnvMakerFees); ok {
+		if err := c.SetMakerFees(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvMakerFees, err)
+		}
+	}
+	if value, ok := lookup(EnvTakerFees); ok {
+		if err := c.SetTakerFees(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvTakerFees, err)
+		}
+	}
+
+	bools := []struct {
+		name   string
+		target *bool
+	}{
+		{EnvFixedPoint, &c.App.FixedPoint},
+		{EnvCompact, &c.Display.Compact},
+		{EnvRecordChunked, &c.Server.Chunked},
+		{EnvFeeAdjusted, &c.App.FeeAdjusted},
+		{EnvCandleMicroprice, &c.App.Candles.Microprice},
+		{EnvShardLockThreads, &c.App.ShardLockThreads},
+		{EnvHappyEyeballs, &c.App.Transport.HappyEyeballs},
+		{EnvCollectors, &c.App.Distributed.Collectors},
+	}
+	for _, b := range bools {
+		if value, ok := lookup(b.name); ok {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", b.name, err)
+			}
+			*b.target = parsed
+		}
+	}
+
+	return nil
+}
+
+// SetDepths sets the depth of configured exchanges from "name=depth" pairs separated by commas
+func (c *Config) SetDepths(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 0 {
+			return fmt.Errorf("invalid depth for %s: %s", ex.Name, value)
+		}
+		ex.Depth = depth
+		return nil
+	})
+}
+
+// SetUpdateSpeeds sets the stream frequency of configured exchanges from "name=speed" pairs separated by commas
+func (c *Config) SetUpdateSpeeds(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		ex.UpdateSpeed = value
+		return nil
+	})
+}
+
+// SetCategories sets the product category of configured exchanges from "name=category" pairs separated by commas
+func (c *Config) SetCategories(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		ex.Category = strings.ToLower(value)
+		return nil
+	})
+}
+
+// SetSigFigs sets the aggregation significant figures of configured exchanges from "name=figures" pairs separated by commas
+func (c *Config) SetSigFigs(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		figures, err := strconv.Atoi(value)
+		if err != nil || figures < 0 {
+			return fmt.Errorf("invalid significant figures for %s: %s", ex.Name, value)
+		}
+		ex.SigFigs = figures
+		return nil
+	})
+}
+
+// SetMantissas sets the aggregation mantissa of configured exchanges from "name=mantissa" pairs separated by commas
+func (c *Config) SetMantissas(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		mantissa, err := strconv.Atoi(value)
+		if err != nil || mantissa < 0 {
+			return fmt.Errorf("invalid mantissa for %s: %s", ex.Name, value)
+		}
+		ex.Mantissa = mantissa
+		return nil
+	})
+}
+
+// SetInstruments sets the explicit instrument ID of configured exchanges from "name=instrument" pairs separated by commas
+func (c *Config) SetInstruments(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		ex.Instrument = value
+		return nil
+	})
+}
+
+// SetMaxBookAges sets the book expiry of configured exchanges from "name=duration" pairs separated by commas
+func (c *Config) SetMaxBookAges(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		age, err := time.ParseDuration(value)
+		if err != nil || age < 0 {
+			return fmt.Errorf("invalid max book age for %s: %s", ex.Name, value)
+		}
+		ex.MaxBookAge = age
+		return nil
+	})
+}
+
+// SetReinitIntervals sets the reinitialization check interval of configured exchanges from "name=duration" pairs separated by commas
+func (c *Config) SetReinitIntervals(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid reinit interval for %s: %s", ex.Name, value)
+		}
+		ex.ReinitInterval = interval
+		return nil
+	})
+}
+
+// SetReinitBuffers sets the buffered events triggering a resync of configured exchanges from "name=count" pairs separated by commas
+func (c *Config) SetReinitBuffers(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid reinit buffer for %s: %s", ex.Name, value)
+		}
+		ex.ReinitBuffer = size
+		return nil
+	})
+}
+
+// SetContractSizes sets the contract size of configured exchanges from "name=size" pairs separated by commas
+func (c *Config) SetContractSizes(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		size, err := strconv.ParseFloat(value, 64)
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid contract size for %s: %s", ex.Name, value)
+		}
+		ex.ContractSize = size
+		return nil
+	})
+}
+
+// SetTopOfBook sets the top-of-book mode of configured exchanges from "name=bool" pairs separated by commas
+func (c *Config) SetTopOfBook(spec string) error {
+	return c.setExchangeValues(spec, func(ex *ExchangeConfig, value string) error {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid top-of-book mode for %s: %s", ex.Name, value)
+		}
+		ex.TopOfBook = enabled
+		return nil
+	})
+}
+
+// SetQuoteRate sets the conversion feed from an "exchange:SYMBOL" spec; an empty spec
+// or "none" disables quote conversion
+func (c *Config) SetQuoteRate(spec string) error {
+	if spec == "" || spec == "none" {
+		c.App.QuoteRate.Exchange = ""
+		return nil
+	}
+	name, symbol, ok := strings.Cut(spec, ":")
+	if !ok || name == "" || symbol == "" {
+		return fmt.Errorf("expected exchange:SYMBOL, got %q", spec)
+	}
+	c.App.QuoteRate.Exchange = exchange.ExchangeName(strings.ToLower(name))
+	c.App.QuoteRate.Symbol = strings.ToUpper(symbol)
+	return nil
+}
+
+// SetCompositeQuotes sets the extra quote currencies merged into each exchange's book
+// from a comma-separated list (e.g., "USDC,USD"); an empty spec or "none" disables composites
+func (c *Config) SetCompositeQuotes(spec string) error {
+	c.App.Composite.Quotes = nil
+	if spec == "" || spec == "none" {
+		return nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		quote := strings.ToUpper(strings.TrimSpace(part))
+		if quote == "" {
+			return fmt.Errorf("empty quote currency in %q", spec)
+		}
+		if slices.Contains(c.App.Composite.Quotes, quote) {
+			return fmt.Errorf("duplicate quote currency %s", quote)
+		}
+		c.App.Composite.Quotes = append(c.App.Composite.Quotes, quote)
+	}
+	return nil
+}
+
+// SetWatchlist sets the symbols monitored in turn from a comma-separated list
+// (e.g., "BTCUSDT,ETHUSDT"); an empty spec or "none" disables the rotation
+func (c *Config) SetWatchlist(spec string) error {
+	c.App.Watchlist.Symbols = nil
+	if spec == "none" {
+		return nil
+	}
+	for _, item := range splitList(spec) {
+		symbol := strings.ToUpper(item)
+		if slices.Contains(c.App.Watchlist.Symbols, symbol) {
+			return fmt.Errorf("duplicate symbol %s", symbol)
+		}
+		c.App.Watchlist.Symbols = append(c.App.Watchlist.Symbols, symbol)
+	}
+	return nil
+}
+
+// WatchlistSpec returns the watchlist in the format accepted by SetWatchlist
+func (c *Config) WatchlistSpec() string {
+	return strings.Join(c.App.Watchlist.Symbols, ",")
+}
+
+// SetNamespaces sets the monitors served under /ws/{name} from comma-separated
+// "name=SYMBOL[:exchange+exchange]" items. An empty spec serves one monitor.
+func (c *Config) SetNamespaces(spec string) error {
+	c.Server.Namespaces = nil
+	for _, item := range splitList(spec) {
+		name, target, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("expected name=SYMBOL[:exchanges], got %q", item)
+		}
+		ns := NamespaceConfig{Name: strings.TrimSpace(name)}
+		if err := ValidateNamespace(ns.Name); err != nil {
+			return err
+		}
+		if slices.ContainsFunc(c.Server.Namespaces, func(existing NamespaceConfig) bool { return existing.Name == ns.Name }) {
+			return fmt.Errorf("duplicate namespace %s", ns.Name)
+		}
+		symbol, exchanges, hasExchanges := strings.Cut(target, ":")
+		ns.Symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if ns.Symbol == "" {
+			return fmt.Errorf("no symbol for namespace %s", ns.Name)
+		}
+		if hasExchanges {
+			names, err := ParseExchangeNames(strings.ReplaceAll(exchanges, "+", ","))
+			if err != nil {
+				return fmt.Errorf("namespace %s: %w", ns.Name, err)
+			}
+			ns.Exchanges = names
+		}
+		c.Server.Namespaces = append(c.Server.Namespaces, ns)
+	}
+	return nil
+}
+
+// NamespacesSpec returns the namespaces in the format accepted by SetNamespaces
+func (c *Config) NamespacesSpec() string {
+	items := make([]string, len(c.Server.Namespaces))
+	for i, ns := range c.Server.Namespaces {
+		items[i] = ns.Name + "=" + ns.Symbol
+		if len(ns.Exchanges) > 0 {
+			exchanges := make([]string, len(ns.Exchanges))
+			for j, name := range ns.Exchanges {
+				exchanges[j] = string(name)
+			}
+			items[i] += ":" + strings.Join(exchanges, "+")
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// CompositeQuotesSpec returns the composite quotes in the format accepted by SetCompositeQuotes
+func (c *Config) CompositeQuotesSpec() string {
+	return strings.Join(c.App.Composite.Quotes, ",")
+}
+
+// SetAverageWindows sets the windows of the time-weighted averages from a
+// comma-separated list of ascending durations (e.g., "1m,5m,1h"); an empty spec
+// or "none" disables them
+func (c *Config) SetAverageWindows(spec string) error {
+	c.App.AverageWindows = nil
+	if spec == "none" {
+		return nil
+	}
+	for _, item := range splitList(spec) {
+		window, err := time.ParseDuration(item)
+		if err != nil {
+			return err
+		}
+		if window <= 0 {
+			return fmt.Errorf("invalid average window %v", window)
+		}
+		if n := len(c.App.AverageWindows); n > 0 && window <= c.App.AverageWindows[n-1] {
+			return fmt.Errorf("average windows must be strictly ascending: %s", spec)
+		}
+		c.App.AverageWindows = append(c.App.AverageWindows, window)
+	}
+	return nil
+}
+
+// AverageWindowsSpec returns the average windows in the format accepted by SetAverageWindows
+func (c *Config) AverageWindowsSpec() string {
+	if len(c.App.AverageWindows) == 0 {
+		return "none"
+	}
+	items := make([]string, len(c.App.AverageWindows))
+	for i, window := range c.App.AverageWindows {
+		items[i] = window.String()
+	}
+	return strings.Join(items, ",")
+}
+
+// QuoteRateSpec returns the conversion feed in the format accepted by SetQuoteRate
+func (c *Config) QuoteRateSpec() string {
+	if c.App.QuoteRate.Exchange == "" {
+		return "none"
+	}
+	return string(c.App.QuoteRate.Exchange) + ":" + c.App.QuoteRate.Symbol
+}
+
+// SetMakerFees overrides maker fees from "name=bps" pairs separated by commas
+func (c *Config) SetMakerFees(spec string) error {
+	return c.setFees(spec, func(fee *types.FeeSchedule, bps float64) { fee.MakerBps = bps })
+}
+
+// SetTakerFees overrides taker fees from "name=bps" pairs separated by commas
+func (c *Config) SetTakerFees(spec string) error {
+	return c.setFees(spec, func(fee *types.FeeSchedule, bps float64) { fee.TakerBps = bps })
+}
+
+// setFees applies each "name=bps" pair of spec to the fee table. Exchanges need
+// not be configured, so the table can be shared across setups.
+func (c *Config) setFees(spec string, set func(fee *types.FeeSchedule, bps float64)) error {
+	fees := make(map[exchange.ExchangeName]types.FeeSchedule, len(c.App.Fees))
+	for name, fee := range c.App.Fees {
+		fees[name] = fee
+	}
+	for _, item := range splitList(spec) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("expected name=value, got %q", item)
+		}
+		bps, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || bps < 0 {
+			return fmt.Errorf("invalid fee for %s: %s", name, value)
+		}
+		key := exchange.ExchangeName(strings.ToLower(strings.TrimSpace(name)))
+		fee := fees[key]
+		set(&fee, bps)
+		fees[key] = fee
+	}
+	c.App.Fees = fees
+	return nil
+}
+
+// setExchangeValues applies each "name=value" pair of spec to the named exchange
+func (c *Config) setExchangeValues(spec string, set func(ex *ExchangeConfig, value string) error) error {
+	for _, item := range splitList(spec) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("expected name=value, got %q", item)
+		}
+		ex := c.exchange(exchange.ExchangeName(strings.ToLower(strings.TrimSpace(name))))
+		if ex == nil {
+			return fmt.Errorf("exchange not configured: %s", name)
+		}
+		if err := set(ex, strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exchange returns the configuration of a named exchange, or nil if it is not configured
+func (c *Config) exchange(name exchange.ExchangeName) *ExchangeConfig {
+	for i := range c.Exchanges {
+		if c.Exchanges[i].Name == name {
+			return &c.Exchanges[i]
+		}
+	}
+	return nil
+}
+
+// ExchangeNames returns the names of the configured exchanges
+func (c *Config) ExchangeNames() []exchange.ExchangeName {
+	names := make([]exchange.ExchangeName, len(c.Exchanges))
+	for i, ex := range c.Exchanges {
+		names[i] = ex.Name
+	}
+	return names
+}
+
+// ParseExchangeNames parses a comma-separated list of exchange names
+func ParseExchangeNames(value string) ([]exchange.ExchangeName, error) {
+	items := splitList(value)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no exchanges listed")
+	}
+
+	names := make([]exchange.ExchangeName, len(items))
+	for i, item := range items {
+		names[i] = exchange.ExchangeName(strings.ToLower(item))
+	}
+	return names, nil
+}
+
+// splitList splits a comma-separated value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
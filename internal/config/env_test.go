@@ -0,0 +1,294 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/types"
+)
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		EnvSymbol:            "ethusdt",
+		EnvExchanges:         "binance, OKX",
+		EnvPort:              "9000",
+		EnvListen:            ":9000=control, unix:/tmp/ob.sock",
+		EnvLogInterval:       "30s",
+		EnvStatsInterval:     "250ms",
+		EnvFixedPoint:        "true",
+		EnvCompact:           "true",
+		EnvRecordChunked:     "true",
+		EnvOutput:            "json",
+		EnvFilterMinQuantity: "0.5",
+		EnvDepthMaxDistance:  "0.02",
+		EnvTailDistance:      "0.05",
+		EnvPruneMaxLevels:    "500",
+		EnvDepth:             "okx=400",
+		EnvUpdateSpeed:       "binance=1000ms",
+		EnvCategory:          "okx=Inverse",
+		EnvSigFigs:           "okx=5",
+		EnvMantissa:          "okx=2",
+		EnvInstrument:        "okx=BTC-USD-250627",
+		EnvMaxBookAge:        "okx=10s",
+		EnvReinitInterval:    "binance=1s",
+		EnvReinitBuffer:      "binance=500",
+		EnvContractSize:      "binance=0.001",
+		EnvTopOfBook:         "binance=true",
+		EnvMakerFees:         "binance=2",
+		EnvTakerFees:         "binance=7.5, coinbase=0",
+		EnvFeeAdjusted:       "1",
+		EnvCandleMicroprice:  "true",
+		EnvQuoteRate:         "coinbase:usdt-usd",
+		EnvCompositeQuotes:   "usdc, usd",
+		EnvScripts:           "scripts.json",
+		EnvReportDir:         "reports",
+		EnvReportKeep:        "7",
+		EnvWatchlist:         "btcusdt, ethusdt,solusdt",
+		EnvWatchlistInterval: "1m",
+		EnvNamespaces:        "spot=btcusdt, alts=ethusdt:okx+bybit",
+		EnvTracing:           "otlp",
+		EnvTracingSample:     "0.5",
+		EnvLogLevel:          "debug",
+		EnvDebugAddr:         "127.0.0.1:6060",
+		EnvCrossedPolicy:     "resync",
+		EnvQuantityUnit:      "quote",
+		EnvShards:            "4",
+		EnvShardLockThreads:  "true",
+		EnvWarmup:            "3s",
+		EnvWarmupEvents:      "100",
+		EnvDialTimeout:       "5s",
+		EnvDNSCacheTTL:       "0",
+		EnvMaxConnsPerHost:   "4",
+		EnvHappyEyeballs:     "false",
+		EnvAverageWindows:    "30s, 15m",
+		EnvAnomalyZScore:     "3.5",
+		EnvJournal:           "/var/lib/orderbook/journal.wal",
+		EnvJournalInterval:   "10s",
+		EnvJournalMaxAge:     "2m",
+		EnvOIInterval:        "1m",
+		EnvOIWindow:          "4h",
+		EnvGapResync:         "30s",
+		EnvAggregator:        "ws://aggregator:8086/collect",
+		EnvCollectors:        "true",
+		EnvCollectorID:       "eu-1",
+	}
+	cfg := NewMultiExchange([]ExchangeConfig{{Name: exchange.Binancef, Symbol: "BTCUSDT"}})
+	if err := cfg.applyEnv(lookupMap(env)); err != nil {
+		t.Fatalf("applyEnv() failed: %v", err)
+	}
+
+	names := cfg.ExchangeNames()
+	if len(names) != 2 || names[0] != exchange.Binance || names[1] != exchange.OKX {
+		t.Errorf("Expected exchanges [binance okx], got %v", names)
+	}
+	for _, ex := range cfg.Exchanges {
+		if ex.Symbol != "ETHUSDT" {
+			t.Errorf("Expected symbol ETHUSDT for %s, got %s", ex.Name, ex.Symbol)
+		}
+	}
+	if cfg.Exchanges[0].UpdateSpeed != "1000ms" || cfg.Exchanges[1].Depth != 400 || cfg.Exchanges[1].MaxBookAge != 10*time.Second {
+		t.Errorf("Expected binance at 1000ms and okx at depth 400 expiring after 10s, got %+v", cfg.Exchanges)
+	}
+	if interval, size := cfg.ReinitIntervalFor(cfg.Exchanges[0]), cfg.ReinitBufferFor(cfg.Exchanges[0]); interval != time.Second || size != 500 {
+		t.Errorf("Expected binance reinit checks every 1s past 500 events, got %v and %d", interval, size)
+	}
+	if interval, size := cfg.ReinitIntervalFor(cfg.Exchanges[1]), cfg.ReinitBufferFor(cfg.Exchanges[1]); interval != 5*time.Second || size != 100 {
+		t.Errorf("Expected okx on the default reinit checks, got %v and %d", interval, size)
+	}
+	if cfg.Exchanges[1].Instrument != "BTC-USD-250627" {
+		t.Errorf("Expected okx instrument BTC-USD-250627, got %q", cfg.Exchanges[1].Instrument)
+	}
+	if cfg.Exchanges[1].Category != "inverse" || cfg.Exchanges[1].SigFigs != 5 || cfg.Exchanges[1].Mantissa != 2 {
+		t.Errorf("Expected okx category inverse aggregated at 5 figures with mantissa 2, got %+v", cfg.Exchanges[1])
+	}
+	if cfg.Server.Port != "9000" {
+		t.Errorf("Expected port 9000, got %s", cfg.Server.Port)
+	}
+	if len(cfg.Server.Listeners) != 2 || cfg.Server.Listeners[1] != "unix:/tmp/ob.sock" {
+		t.Errorf("Expected 2 listeners, got %v", cfg.Server.Listeners)
+	}
+	if !cfg.Exchanges[0].TopOfBook || cfg.Exchanges[1].TopOfBook {
+		t.Errorf("Expected binance in top-of-book mode only, got %+v", cfg.Exchanges)
+	}
+	if cfg.Exchanges[0].ContractSize != 0.001 || cfg.App.QuantityUnit != "quote" {
+		t.Errorf("Expected contract size 0.001 and quantity unit quote, got %v and %s", cfg.Exchanges[0].ContractSize, cfg.App.QuantityUnit)
+	}
+	if cfg.App.CrossedPolicy != "resync" {
+		t.Errorf("Expected crossed policy resync, got %s", cfg.App.CrossedPolicy)
+	}
+	if cfg.App.Shards != 4 || !cfg.App.ShardLockThreads {
+		t.Errorf("Expected 4 shards locked to threads, got %d (%v)", cfg.App.Shards, cfg.App.ShardLockThreads)
+	}
+	if cfg.Server.LogLevel != "debug" {
+		t.Errorf("Expected log level debug, got %s", cfg.Server.LogLevel)
+	}
+	if cfg.Server.DebugAddr != "127.0.0.1:6060" {
+		t.Errorf("Expected debug listener 127.0.0.1:6060, got %s", cfg.Server.DebugAddr)
+	}
+	if cfg.Display.UpdateInterval != 30*time.Second {
+		t.Errorf("Expected log interval 30s, got %v", cfg.Display.UpdateInterval)
+	}
+	if cfg.App.StatsInterval != 250*time.Millisecond {
+		t.Errorf("Expected stats interval 250ms, got %v", cfg.App.StatsInterval)
+	}
+	if !cfg.App.FixedPoint {
+		t.Errorf("Expected fixed point enabled")
+	}
+	if cfg.App.FilterMinQuantity != 0.5 {
+		t.Errorf("Expected min quantity 0.5, got %v", cfg.App.FilterMinQuantity)
+	}
+	if cfg.Server.DepthMaxDistancePct != 0.02 {
+		t.Errorf("Expected depth max distance 0.02, got %v", cfg.Server.DepthMaxDistancePct)
+	}
+	if cfg.App.Report.Dir != "reports" || cfg.App.Report.Keep != 7 {
+		t.Errorf("Expected reports kept 7 days in reports, got %+v", cfg.App.Report)
+	}
+	if cfg.WatchlistSpec() != "BTCUSDT,ETHUSDT,SOLUSDT" || cfg.App.Watchlist.Interval != time.Minute {
+		t.Errorf("Expected a watchlist of 3 symbols rotated every minute, got %+v", cfg.App.Watchlist)
+	}
+	if cfg.Server.TailDistancePct != 0.05 {
+		t.Errorf("Expected tail distance 0.05, got %v", cfg.Server.TailDistancePct)
+	}
+	if cfg.App.PruneMaxLevels != 500 {
+		t.Errorf("Expected 500 max levels, got %d", cfg.App.PruneMaxLevels)
+	}
+	fees := cfg.App.Fees
+	if fees[exchange.Binance] != (types.FeeSchedule{MakerBps: 2, TakerBps: 7.5}) || fees[exchange.Coinbase].TakerBps != 0 || fees[exchange.OKX].TakerBps != 10 {
+		t.Errorf("Expected binance 2/7.5bps, coinbase taker 0bps and okx default taker 10bps, got %v", fees)
+	}
+	if Default().App.Fees[exchange.Binance].TakerBps != 10 {
+		t.Errorf("Expected overrides not to change the default fee table")
+	}
+	if !cfg.Server.Chunked {
+		t.Errorf("Expected chunked recording")
+	}
+	if !cfg.Display.Compact || cfg.Display.Output != "json" {
+		t.Errorf("Expected compact json display, got %+v", cfg.Display)
+	}
+	if !cfg.App.FeeAdjusted {
+		t.Errorf("Expected fee-adjusted prices enabled")
+	}
+	if !cfg.App.Candles.Microprice {
+		t.Errorf("Expected microprice candles enabled")
+	}
+	if cfg.QuoteRateSpec() != "coinbase:USDT-USD" {
+		t.Errorf("Expected quote rate feed coinbase:USDT-USD, got %s", cfg.QuoteRateSpec())
+	}
+	if cfg.App.Tracing.Exporter != "otlp" || cfg.App.Tracing.SampleRatio != 0.5 {
+		t.Errorf("Expected otlp tracing of half the updates, got %+v", cfg.App.Tracing)
+	}
+	if cfg.App.Pipeline.Scripts != "scripts.json" {
+		t.Errorf("Expected scripts scripts.json, got %s", cfg.App.Pipeline.Scripts)
+	}
+	if cfg.NamespacesSpec() != "spot=BTCUSDT,alts=ETHUSDT:okx+bybit" {
+		t.Errorf("Expected namespaces spot=BTCUSDT,alts=ETHUSDT:okx+bybit, got %s", cfg.NamespacesSpec())
+	}
+	alts := cfg.ForNamespace(cfg.Server.Namespaces[1])
+	if names := alts.ExchangeNames(); len(names) != 2 || names[0] != exchange.OKX || alts.Exchanges[1].Symbol != "ETHUSDT" {
+		t.Errorf("Expected okx and bybit on ETHUSDT, got %+v", alts.Exchanges)
+	}
+	if transport := cfg.App.Transport; transport.DialTimeout != 5*time.Second || transport.DNSCacheTTL != 0 || transport.MaxConnsPerHost != 4 || transport.HappyEyeballs {
+		t.Errorf("Expected 5s dials, no DNS cache, 4 connections per host and no happy eyeballs, got %+v", transport)
+	}
+	if cfg.App.Warmup.Duration != 3*time.Second || cfg.App.Warmup.Events != 100 {
+		t.Errorf("Expected a warm-up of 3s and 100 updates, got %+v", cfg.App.Warmup)
+	}
+	if cfg.App.Anomaly.ZScore != 3.5 {
+		t.Errorf("Expected anomaly z-score 3.5, got %v", cfg.App.Anomaly.ZScore)
+	}
+	if journal := cfg.App.Journal; journal.File != "/var/lib/orderbook/journal.wal" || journal.Interval != 10*time.Second || journal.MaxAge != 2*time.Minute {
+		t.Errorf("Expected a journal checkpointing every 10s and resumed up to 2m, got %+v", journal)
+	}
+	if oi := cfg.App.OpenInterest; oi.Interval != time.Minute || oi.Window != 4*time.Hour {
+		t.Errorf("Expected open interest polled every 1m with a 4h window, got %+v", oi)
+	}
+	if cfg.App.GapResyncCooldown != 30*time.Second {
+		t.Errorf("Expected a 30s gap resync cooldown, got %v", cfg.App.GapResyncCooldown)
+	}
+	if distributed := cfg.App.Distributed; distributed.Aggregator != "ws://aggregator:8086/collect" || !distributed.Collectors || distributed.CollectorID != "eu-1" {
+		t.Errorf("Expected the aggregator URL, collectors and collector ID eu-1, got %+v", distributed)
+	}
+	if cfg.AverageWindowsSpec() != "30s,15m0s" {
+		t.Errorf("Expected average windows 30s,15m0s, got %s", cfg.AverageWindowsSpec())
+	}
+	if cfg.CompositeQuotesSpec() != "USDC,USD" {
+		t.Errorf("Expected composite quotes USDC,USD, got %s", cfg.CompositeQuotesSpec())
+	}
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{EnvExchanges, " , "},
+		{EnvPort, "http"},
+		{EnvPort, "70000"},
+		{EnvLogInterval, "10"},
+		{EnvFixedPoint, "maybe"},
+		{EnvFilterMaxDistance, "far"},
+		{EnvPruneMaxLevels, "1.5"},
+		{EnvDepth, "binancef"},
+		{EnvDepth, "binancef=-1"},
+		{EnvDepth, "kraken=10"},
+		{EnvMaxBookAge, "binancef=10"},
+		{EnvReinitInterval, "binancef=0s"},
+		{EnvReinitBuffer, "binancef=lots"},
+		{EnvContractSize, "binancef=0"},
+		{EnvTopOfBook, "binancef=sometimes"},
+		{EnvSigFigs, "binancef=-1"},
+		{EnvMantissa, "binancef=two"},
+		{EnvTakerFees, "okx=-1"},
+		{EnvMakerFees, "okx"},
+		{EnvFeeAdjusted, "yes"},
+		{EnvCompact, "maybe"},
+		{EnvOutput, "xml"},
+		{EnvTracing, "jaeger"},
+		{EnvCandleMicroprice, "both"},
+		{EnvQuoteRate, "kraken"},
+		{EnvCompositeQuotes, "usdc,,usd"},
+		{EnvCompositeQuotes, "USDC,usdc"},
+		{EnvNamespaces, "spot"},
+		{EnvNamespaces, "Spot=BTCUSDT"},
+		{EnvNamespaces, "api=BTCUSDT"},
+		{EnvNamespaces, "spot=BTCUSDT,spot=ETHUSDT"},
+		{EnvNamespaces, "spot=:okx"},
+		{EnvNamespaces, "spot=BTCUSDT:"},
+		{EnvLogLevel, "verbose"},
+		{EnvCrossedPolicy, "heal"},
+		{EnvQuantityUnit, "usd"},
+		{EnvShardQueue, "big"},
+		{EnvWarmup, "3"},
+		{EnvWarmupEvents, "3s"},
+		{EnvDialTimeout, "5"},
+		{EnvDNSCacheTTL, "forever"},
+		{EnvMaxConnsPerHost, "many"},
+		{EnvHappyEyeballs, "sometimes"},
+		{EnvAverageWindows, "5m,1m"},
+		{EnvAverageWindows, "1m,hour"},
+		{EnvAnomalyZScore, "high"},
+		{EnvJournalInterval, "30"},
+		{EnvJournalMaxAge, "long"},
+		{EnvOIInterval, "30"},
+		{EnvGapResync, "soon"},
+		{EnvReportKeep, "week"},
+		{EnvWatchlist, "BTCUSDT,btcusdt"},
+		{EnvWatchlistInterval, "30"},
+		{EnvCollectors, "some"},
+	}
+
+	for _, tt := range tests {
+		cfg := Default()
+		if err := cfg.applyEnv(lookupMap(map[string]string{tt.name: tt.value})); err == nil {
+			t.Errorf("%s=%q: Expected error, got nil", tt.name, tt.value)
+		}
+	}
+}
+
+func lookupMap(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
@@ -0,0 +1,76 @@
+package conversion
+
+import (
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// Converter normalizes the prices of venues quoted in different currencies
+// (e.g., Kraken and Coinbase in USD) to a common quote currency (e.g., USDT)
+// using live conversion rates
+type Converter struct {
+	mu     sync.RWMutex
+	target string
+	rates  map[string]decimal.Decimal // Units of target per unit of each quote currency
+	quotes map[string]string          // Quote currency of each venue
+}
+
+// NewConverter creates a new Converter to the target quote currency
+func NewConverter(target string) *Converter {
+	return &Converter{
+		target: target,
+		rates:  make(map[string]decimal.Decimal),
+		quotes: make(map[string]string),
+	}
+}
+
+// Target returns the common quote currency
+func (c *Converter) Target() string {
+	return c.target
+}
+
+// SetQuote records the quote currency of a venue's book
+func (c *Converter) SetQuote(venue, quote string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.quotes[venue] = quote
+}
+
+// SetRate records how many units of the target one unit of quote is worth
+func (c *Converter) SetRate(quote string, rate decimal.Decimal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rates[quote] = rate
+}
+
+// Factor returns the multiplier converting a venue's prices to the target. Venues
+// quoted in the target, or with an unknown quote, have a factor of one. It reports
+// false when the venue needs a rate that is not available yet.
+func (c *Converter) Factor(venue string) (decimal.Decimal, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	quote, ok := c.quotes[venue]
+	if !ok || quote == "" || quote == c.target {
+		return decimal.NewFromInt(1), true
+	}
+	rate, ok := c.rates[quote]
+	if !ok {
+		return decimal.NewFromInt(1), false
+	}
+	return rate, true
+}
+
+// Convert returns price converted to the target, or unchanged if no rate is
+// available. A nil Converter leaves prices unchanged.
+func (c *Converter) Convert(venue string, price decimal.Decimal) decimal.Decimal {
+	if c == nil {
+		return price
+	}
+	factor, _ := c.Factor(venue)
+	if factor.Equal(decimal.NewFromInt(1)) {
+		return price
+	}
+	return price.Mul(factor)
+}
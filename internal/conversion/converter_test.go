@@ -0,0 +1,38 @@
+package conversion
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestConverter(t *testing.T) {
+	c := NewConverter("USDT")
+	c.SetQuote("binance", "USDT")
+	c.SetQuote("kraken", "USD")
+	price := decimal.NewFromInt(50000)
+
+	if _, ok := c.Factor("kraken"); ok {
+		t.Errorf("Expected no kraken factor before the USD rate is known")
+	}
+	if !c.Convert("kraken", price).Equal(price) {
+		t.Errorf("Expected kraken prices unchanged without a rate")
+	}
+
+	// 1 USDT = 0.9995 USD
+	c.SetRate("USD", decimal.NewFromInt(1).Div(decimal.RequireFromString("0.9995")))
+
+	tests := []struct {
+		venue    string
+		expected string
+	}{
+		{"binance", "50000.00"},
+		{"kraken", "50025.01"},
+		{"okx", "50000.00"}, // Unknown quote
+	}
+	for _, tt := range tests {
+		if got := c.Convert(tt.venue, price).StringFixed(2); got != tt.expected {
+			t.Errorf("%s: Expected %s, got %s", tt.venue, tt.expected, got)
+		}
+	}
+}
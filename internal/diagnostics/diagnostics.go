@@ -0,0 +1,103 @@
+// Package diagnostics serves runtime profiling endpoints on a separate debug
+// listener: net/http/pprof, expvar and GC statistics. The listener has no
+// authentication, so it should be bound to a loopback or private address.
+package diagnostics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// recentPauses is the number of most recent GC pauses reported
+const recentPauses = 16
+
+// GCStats is the body of the /debug/gc endpoint
+type GCStats struct {
+	NumGC          int64     `json:"numGC"`
+	LastGC         time.Time `json:"lastGC"`
+	PauseTotalNs   int64     `json:"pauseTotalNs"`
+	RecentPausesNs []int64   `json:"recentPausesNs"` // Most recent first
+	HeapAlloc      uint64    `json:"heapAlloc"`
+	HeapObjects    uint64    `json:"heapObjects"`
+	NextGC         uint64    `json:"nextGC"`
+	GCCPUFraction  float64   `json:"gcCpuFraction"`
+	Goroutines     int       `json:"goroutines"`
+	GOMAXPROCS     int       `json:"gomaxprocs"`
+}
+
+var publishOnce sync.Once
+
+// ReadGCStats returns the current GC statistics
+func ReadGCStats() GCStats {
+	var gc debug.GCStats
+	gc.Pause = make([]time.Duration, 0, recentPauses)
+	debug.ReadGCStats(&gc)
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := GCStats{
+		NumGC:          gc.NumGC,
+		LastGC:         gc.LastGC,
+		PauseTotalNs:   gc.PauseTotal.Nanoseconds(),
+		RecentPausesNs: make([]int64, 0, min(len(gc.Pause), recentPauses)),
+		HeapAlloc:      mem.HeapAlloc,
+		HeapObjects:    mem.HeapObjects,
+		NextGC:         mem.NextGC,
+		GCCPUFraction:  mem.GCCPUFraction,
+		Goroutines:     runtime.NumGoroutine(),
+		GOMAXPROCS:     runtime.GOMAXPROCS(0),
+	}
+	for i := 0; i < len(gc.Pause) && i < recentPauses; i++ {
+		stats.RecentPausesNs = append(stats.RecentPausesNs, gc.Pause[i].Nanoseconds())
+	}
+	return stats
+}
+
+// Handler returns the debug endpoints:
+//   - /debug/pprof/ profiles (heap, goroutine, CPU via /debug/pprof/profile?seconds=30, trace, ...)
+//   - /debug/vars expvar variables, including memstats and the "gc" variable
+//   - /debug/gc GC statistics and recent pause times
+func Handler() http.Handler {
+	publishOnce.Do(func() {
+		expvar.Publish("gc", expvar.Func(func() interface{} { return ReadGCStats() }))
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/gc", handleGC)
+	return mux
+}
+
+// handleGC serves ReadGCStats as JSON
+func handleGC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ReadGCStats()); err != nil {
+		log.Printf("Failed to encode GC stats: %v", err)
+	}
+}
+
+// Serve serves Handler on addr until the listener fails
+func Serve(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		return fmt.Errorf("debug listener %s: %w", addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	runtime.GC()
+	handler := Handler()
+
+	tests := []struct {
+		path     string
+		contains string
+	}{
+		{"/debug/pprof/", "goroutine"},
+		{"/debug/pprof/cmdline", ""},
+		{"/debug/vars", `"gc":`},
+		{"/debug/gc", `"numGC":`},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: Expected status 200, got %d", tt.path, rec.Code)
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), tt.contains) {
+			t.Errorf("%s: Expected body to contain %q, got %.200s", tt.path, tt.contains, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/gc", nil))
+	var stats GCStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to decode GC stats: %v", err)
+	}
+	if stats.NumGC < 1 || len(stats.RecentPausesNs) == 0 || stats.Goroutines < 1 {
+		t.Errorf("Expected at least one GC with its pause and a goroutine, got %+v", stats)
+	}
+}
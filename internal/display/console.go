@@ -0,0 +1,348 @@
+// Package display renders the books of every exchange to the terminal
+package display
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	colorReset   = "\033[0m"
+	colorYellow  = "\033[33m"
+	colorGreen   = "\033[32m"
+	colorRed     = "\033[31m"
+	colorMagenta = "\033[35m"
+	colorBold    = "\033[1m"
+)
+
+// Format selects how the console renders books
+type Format string
+
+const (
+	FormatText Format = "text" // Human-readable blocks or lines
+	FormatJSON Format = "json" // One JSON object per flush, for jq and log shippers
+)
+
+// ParseFormat parses "text" or "json"
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case FormatText, FormatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (expected text or json)", s)
+}
+
+// Options selects how the console renders books
+type Options struct {
+	Format  Format // FormatText when empty
+	Compact bool   // One line per exchange instead of a block
+	Color   bool   // ANSI colors, see ColorEnabled
+}
+
+// ColorEnabled reports whether colors may be used, following the NO_COLOR
+// convention (https://no-color.org): any non-empty value disables them
+func ColorEnabled() bool {
+	return os.Getenv("NO_COLOR") == ""
+}
+
+// Console renders the stats of several exchanges together, with numbers aligned
+// across exchanges. Each exchange feeds its own View; Flush renders them all.
+type Console struct {
+	mu    sync.Mutex
+	out   io.Writer
+	opts  Options
+	views []*View // In registration order
+}
+
+// NewConsole creates a console writing to out
+func NewConsole(out io.Writer, opts Options) *Console {
+	return &Console{out: out, opts: opts}
+}
+
+// View returns the types.Display of an exchange, rendered in registration order
+func (c *Console) View(name string) *View {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	view := &View{console: c, name: name}
+	c.views = append(c.views, view)
+	return view
+}
+
+// Flush renders the latest data of every initialized view
+func (c *Console) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var books []book
+	for _, view := range c.views {
+		if view.initialized {
+			books = append(books, book{name: view.name, stats: view.stats})
+		}
+	}
+	c.render(books)
+}
+
+// View is the display of one exchange within a Console. It only renders stats,
+// so the levels and aggregator passed to it are ignored.
+type View struct {
+	console     *Console
+	name        string
+	stats       types.Stats // Guarded by the console mutex
+	initialized bool
+}
+
+// DisplayOrderBook implements types.Display, rendering this exchange alone
+func (v *View) DisplayOrderBook(bids, asks map[string]types.PriceLevel, stats types.Stats, initialized bool, bufferLen int) {
+	v.UpdateData(bids, asks, stats, initialized, bufferLen)
+	if !initialized {
+		return
+	}
+	v.console.mu.Lock()
+	defer v.console.mu.Unlock()
+	v.console.render([]book{{name: v.name, stats: stats}})
+}
+
+// UpdateData implements types.Display, recording the data for the next Flush
+func (v *View) UpdateData(bids, asks map[string]types.PriceLevel, stats types.Stats, initialized bool, bufferLen int) {
+	v.console.mu.Lock()
+	defer v.console.mu.Unlock()
+	v.stats = stats
+	v.initialized = initialized
+}
+
+// SetAggregator implements types.Display
+func (v *View) SetAggregator(aggregator types.PriceAggregator) {}
+
+// Run implements types.Display; the console is not interactive
+func (v *View) Run() error { return nil }
+
+// Quit implements types.Display
+func (v *View) Quit() {}
+
+// book is an exchange's stats being rendered
+type book struct {
+	name  string
+	stats types.Stats
+}
+
+// Columns of a rendered book. Columns stacked in block mode share a width.
+const (
+	colMid = iota
+	colSpread
+	colBestBid
+	colBestAsk
+	colBids05
+	colAsks05
+	colDelta05
+	colBids2
+	colAsks2
+	colDelta2
+	colBids10
+	colAsks10
+	colDelta10
+	colTotalBids
+	colTotalAsks
+	columnCount
+)
+
+// columnGroup maps each column to the group whose width it shares
+var columnGroup = [columnCount]int{
+	colMid: colMid, colSpread: colSpread, colBestBid: colMid, colBestAsk: colMid,
+	colBids05: colBids05, colAsks05: colAsks05, colDelta05: colDelta05,
+	colBids2: colBids05, colAsks2: colAsks05, colDelta2: colDelta05,
+	colBids10: colBids05, colAsks10: colAsks05, colDelta10: colDelta05,
+	colTotalBids: colBids05, colTotalAsks: colAsks05,
+}
+
+// quantityDecimals is the precision of rendered quantities
+const quantityDecimals = 2
+
+// priceDecimals returns the decimals showing about six significant digits of
+// price, so low-priced symbols are not rounded to zero
+func priceDecimals(price decimal.Decimal) int32 {
+	value := price.InexactFloat64()
+	if value <= 0 {
+		return 2
+	}
+	return int32(min(max(5-int(math.Floor(math.Log10(value))), 2), 10))
+}
+
+// render writes books with columns aligned across them (must be called with mutex locked)
+func (c *Console) render(books []book) {
+	if len(books) == 0 {
+		return
+	}
+	if c.opts.Format == FormatJSON {
+		c.renderJSON(books)
+		return
+	}
+
+	// Share the price precision so decimal points line up
+	var decimals int32
+	for _, b := range books {
+		decimals = max(decimals, priceDecimals(b.stats.BestBid.Add(b.stats.BestAsk).Div(decimal.NewFromInt(2))))
+	}
+
+	nameWidth := 0
+	var widths [columnCount]int
+	rows := make([][columnCount]string, len(books))
+	for i, b := range books {
+		s := b.stats
+		rows[i] = [columnCount]string{
+			colMid:       s.BestBid.Add(s.BestAsk).Div(decimal.NewFromInt(2)).StringFixed(decimals),
+			colSpread:    s.Spread.StringFixed(decimals + 2),
+			colBestBid:   s.BestBid.StringFixed(decimals),
+			colBestAsk:   s.BestAsk.StringFixed(decimals),
+			colBids05:    s.BidLiquidity05Pct.StringFixed(quantityDecimals),
+			colAsks05:    s.AskLiquidity05Pct.StringFixed(quantityDecimals),
+			colDelta05:   s.DeltaLiquidity05Pct.StringFixed(quantityDecimals),
+			colBids2:     s.BidLiquidity2Pct.StringFixed(quantityDecimals),
+			colAsks2:     s.AskLiquidity2Pct.StringFixed(quantityDecimals),
+			colDelta2:    s.DeltaLiquidity2Pct.StringFixed(quantityDecimals),
+			colBids10:    s.BidLiquidity10Pct.StringFixed(quantityDecimals),
+			colAsks10:    s.AskLiquidity10Pct.StringFixed(quantityDecimals),
+			colDelta10:   s.DeltaLiquidity10Pct.StringFixed(quantityDecimals),
+			colTotalBids: s.TotalBidsQty.StringFixed(quantityDecimals),
+			colTotalAsks: s.TotalAsksQty.StringFixed(quantityDecimals),
+		}
+		nameWidth = max(nameWidth, len(b.name))
+		for col, text := range rows[i] {
+			group := columnGroup[col]
+			widths[group] = max(widths[group], len(text))
+		}
+	}
+
+	var out strings.Builder
+	if !c.opts.Compact {
+		out.WriteString("\n")
+	}
+	for i, b := range books {
+		row := rows[i]
+		cell := func(col int, color string) string {
+			return c.paint(color, fmt.Sprintf("%*s", widths[columnGroup[col]], row[col]))
+		}
+		delta := func(col int, value decimal.Decimal) string {
+			return cell(col, deltaColor(value))
+		}
+		name := c.paint(colorBold, fmt.Sprintf("%-*s", nameWidth, b.name))
+		s := b.stats
+
+		if c.opts.Compact {
+			fmt.Fprintf(&out, "%s  Mid %s  Spr %s  BB %s  BA %s │ Δ0.5%% %s  Δ2%% %s  Δ10%% %s │ %7.1f/s\n",
+				name, cell(colMid, colorYellow), cell(colSpread, colorMagenta),
+				cell(colBestBid, colorGreen), cell(colBestAsk, colorRed),
+				delta(colDelta05, s.DeltaLiquidity05Pct), delta(colDelta2, s.DeltaLiquidity2Pct),
+				delta(colDelta10, s.DeltaLiquidity10Pct), s.EventsPerSecond)
+			continue
+		}
+
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		fmt.Fprintf(&out, "%s  Mid: %s │ Spread: %s │ BB: %s │ BA: %s\n",
+			name, cell(colMid, colorYellow), cell(colSpread, colorMagenta),
+			cell(colBestBid, colorGreen), cell(colBestAsk, colorRed))
+		fmt.Fprintf(&out, "  DEPTH 0.5%% Bids: %s │ Asks: %s │ Δ: %s\n",
+			cell(colBids05, colorGreen), cell(colAsks05, colorRed), delta(colDelta05, s.DeltaLiquidity05Pct))
+		fmt.Fprintf(&out, "  DEPTH 2%%   Bids: %s │ Asks: %s │ Δ: %s\n",
+			cell(colBids2, colorGreen), cell(colAsks2, colorRed), delta(colDelta2, s.DeltaLiquidity2Pct))
+		fmt.Fprintf(&out, "  DEPTH 10%%  Bids: %s │ Asks: %s │ Δ: %s\n",
+			cell(colBids10, colorGreen), cell(colAsks10, colorRed), delta(colDelta10, s.DeltaLiquidity10Pct))
+		fmt.Fprintf(&out, "  TOTAL QTY  Bids: %s │ Asks: %s\n",
+			cell(colTotalBids, colorGreen), cell(colTotalAsks, colorRed))
+		fmt.Fprintf(&out, "  EVENTS:    %7.1f/s │ Applied: %d │ Buffered: %d │ Dropped: %d │ Apply: %v\n",
+			s.EventsPerSecond, s.EventsProcessed, s.EventsBuffered, s.EventsDropped, s.ApplyTime)
+	}
+	io.WriteString(c.out, out.String())
+}
+
+// jsonBook is the stats of one exchange in FormatJSON
+type jsonBook struct {
+	Exchange            string  `json:"exchange"`
+	MidPrice            string  `json:"midPrice"`
+	Spread              string  `json:"spread"`
+	BestBid             string  `json:"bestBid"`
+	BestAsk             string  `json:"bestAsk"`
+	BidLiquidity05Pct   string  `json:"bidLiquidity05Pct"`
+	AskLiquidity05Pct   string  `json:"askLiquidity05Pct"`
+	DeltaLiquidity05Pct string  `json:"deltaLiquidity05Pct"`
+	BidLiquidity2Pct    string  `json:"bidLiquidity2Pct"`
+	AskLiquidity2Pct    string  `json:"askLiquidity2Pct"`
+	DeltaLiquidity2Pct  string  `json:"deltaLiquidity2Pct"`
+	BidLiquidity10Pct   string  `json:"bidLiquidity10Pct"`
+	AskLiquidity10Pct   string  `json:"askLiquidity10Pct"`
+	DeltaLiquidity10Pct string  `json:"deltaLiquidity10Pct"`
+	TotalBidsQty        string  `json:"totalBidsQty"`
+	TotalAsksQty        string  `json:"totalAsksQty"`
+	EventsPerSecond     float64 `json:"eventsPerSecond"`
+	EventsProcessed     int64   `json:"eventsProcessed"`
+	EventsBuffered      int64   `json:"eventsBuffered"`
+	EventsDropped       int64   `json:"eventsDropped"`
+	ApplyTimeMicros     int64   `json:"applyTimeMicros"`
+}
+
+// jsonStats is the line written per flush in FormatJSON
+type jsonStats struct {
+	Timestamp int64      `json:"timestamp"`
+	Exchanges []jsonBook `json:"exchanges"`
+}
+
+// renderJSON writes books as one JSON line (must be called with mutex locked)
+func (c *Console) renderJSON(books []book) {
+	line := jsonStats{Timestamp: time.Now().UnixMilli(), Exchanges: make([]jsonBook, len(books))}
+	for i, b := range books {
+		s := b.stats
+		line.Exchanges[i] = jsonBook{
+			Exchange:            b.name,
+			MidPrice:            s.BestBid.Add(s.BestAsk).Div(decimal.NewFromInt(2)).String(),
+			Spread:              s.Spread.String(),
+			BestBid:             s.BestBid.String(),
+			BestAsk:             s.BestAsk.String(),
+			BidLiquidity05Pct:   s.BidLiquidity05Pct.String(),
+			AskLiquidity05Pct:   s.AskLiquidity05Pct.String(),
+			DeltaLiquidity05Pct: s.DeltaLiquidity05Pct.String(),
+			BidLiquidity2Pct:    s.BidLiquidity2Pct.String(),
+			AskLiquidity2Pct:    s.AskLiquidity2Pct.String(),
+			DeltaLiquidity2Pct:  s.DeltaLiquidity2Pct.String(),
+			BidLiquidity10Pct:   s.BidLiquidity10Pct.String(),
+			AskLiquidity10Pct:   s.AskLiquidity10Pct.String(),
+			DeltaLiquidity10Pct: s.DeltaLiquidity10Pct.String(),
+			TotalBidsQty:        s.TotalBidsQty.String(),
+			TotalAsksQty:        s.TotalAsksQty.String(),
+			EventsPerSecond:     s.EventsPerSecond,
+			EventsProcessed:     s.EventsProcessed,
+			EventsBuffered:      s.EventsBuffered,
+			EventsDropped:       s.EventsDropped,
+			ApplyTimeMicros:     s.ApplyTime.Microseconds(),
+		}
+	}
+	// Encoder writes the object and its newline in one call
+	json.NewEncoder(c.out).Encode(line)
+}
+
+// paint wraps text in color when colors are enabled
+func (c *Console) paint(color, text string) string {
+	if !c.opts.Color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// deltaColor returns the color of a liquidity imbalance
+func deltaColor(delta decimal.Decimal) string {
+	if delta.GreaterThan(decimal.Zero) {
+		return colorGreen
+	} else if delta.LessThan(decimal.Zero) {
+		return colorRed
+	}
+	return colorYellow
+}
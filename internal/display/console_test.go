@@ -0,0 +1,86 @@
+package display
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+func stats(bid, ask, delta string) types.Stats {
+	bestBid, bestAsk := decimal.RequireFromString(bid), decimal.RequireFromString(ask)
+	return types.Stats{
+		BestBid:            bestBid,
+		BestAsk:            bestAsk,
+		Spread:             bestAsk.Sub(bestBid),
+		DeltaLiquidity2Pct: decimal.RequireFromString(delta),
+	}
+}
+
+func TestConsoleCompact(t *testing.T) {
+	var out bytes.Buffer
+	console := NewConsole(&out, Options{Compact: true})
+	views := []types.Display{console.View("binance"), console.View("okx"), console.View("kraken")}
+	views[0].UpdateData(nil, nil, stats("0.00001234", "0.00001236", "1500000"), true, 0)
+	views[1].UpdateData(nil, nil, stats("0.00001233", "0.00001237", "-2"), true, 0)
+	views[2].UpdateData(nil, nil, types.Stats{}, false, 0)
+	console.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per initialized exchange, got %q", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "okx      Mid 0.0000123500  Spr") {
+		t.Errorf("Expected padded names and low prices at full precision, got %q", lines[1])
+	}
+	for _, sep := range []string{"Spr", "Δ2%", "/s"} {
+		if strings.Index(lines[0], sep) != strings.Index(lines[1], sep) {
+			t.Errorf("Expected %s aligned across exchanges, got %q and %q", sep, lines[0], lines[1])
+		}
+	}
+	if strings.Contains(out.String(), "\033[") {
+		t.Errorf("Expected no colors, got %q", out.String())
+	}
+}
+
+func TestConsoleBlock(t *testing.T) {
+	var out bytes.Buffer
+	console := NewConsole(&out, Options{Color: true})
+	console.View("binance").DisplayOrderBook(nil, nil, stats("65000.1", "65000.2", "0"), true, 0)
+
+	rendered := out.String()
+	if !strings.Contains(rendered, colorBold+"binance"+colorReset) || !strings.Contains(rendered, colorYellow+"65000.15"+colorReset) {
+		t.Errorf("Expected the colored name and mid, got %q", rendered)
+	}
+	if strings.Count(rendered, "\n") != 7 {
+		t.Errorf("Expected a blank line and six lines of stats, got %q", rendered)
+	}
+}
+
+func TestConsoleJSON(t *testing.T) {
+	var out bytes.Buffer
+	console := NewConsole(&out, Options{Format: FormatJSON, Color: true})
+	console.View("binance").UpdateData(nil, nil, stats("100", "101", "-3.5"), true, 0)
+	console.View("okx").UpdateData(nil, nil, stats("100.5", "101", "0"), true, 0)
+	console.Flush()
+	console.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one JSON line per flush, got %q", out.String())
+	}
+	var decoded jsonStats
+	if err := json.Unmarshal([]byte(lines[0]), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if len(decoded.Exchanges) != 2 || decoded.Exchanges[0].MidPrice != "100.5" || decoded.Exchanges[0].DeltaLiquidity2Pct != "-3.5" {
+		t.Errorf("Expected binance first with mid 100.5 and Δ2%% -3.5, got %+v", decoded.Exchanges)
+	}
+	if strings.Contains(out.String(), "\033[") {
+		t.Errorf("Expected no colors in JSON, got %q", out.String())
+	}
+}
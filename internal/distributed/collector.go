@@ -0,0 +1,288 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/factory"
+	"orderbook/internal/transport"
+
+	"github.com/gorilla/websocket"
+)
+
+// Collector reconnection backoff bounds
+const (
+	collectorMinBackoff = time.Second
+	collectorMaxBackoff = 30 * time.Second
+)
+
+// healthInterval is the interval between the health reports of a collected feed
+const healthInterval = time.Second
+
+// CollectorConfig holds the configuration of a collector process
+type CollectorConfig struct {
+	URL       string                  // Aggregator collect endpoint (e.g., "ws://aggregator:8086/collect")
+	ID        string                  // Collector ID reported to the aggregator, empty uses host:pid
+	Exchanges []exchange.ExchangeName // Exchanges collected, one aggregator connection each
+	// NewExchange creates the adapters, nil uses factory.NewExchange
+	NewExchange func(factory.ExchangeConfig) (exchange.Exchange, error)
+}
+
+// RunCollector streams the exchanges of config to the aggregator until ctx is
+// done, reconnecting with backoff whenever the aggregator connection is lost
+func RunCollector(ctx context.Context, config CollectorConfig) {
+	if config.ID == "" {
+		host, _ := os.Hostname()
+		config.ID = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	if config.NewExchange == nil {
+		config.NewExchange = factory.NewExchange
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range config.Exchanges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			backoff := collectorMinBackoff
+			for {
+				start := time.Now()
+				err := collect(ctx, config, name)
+				if ctx.Err() != nil {
+					return
+				}
+				if time.Since(start) > collectorMaxBackoff {
+					backoff = collectorMinBackoff
+				}
+				log.Printf("[%s] Aggregator connection lost: %v (reconnecting in %v)", name, err, backoff)
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return
+				}
+				backoff = min(backoff*2, collectorMaxBackoff)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// collectedFeed is a symbol streamed by a collector
+type collectedFeed struct {
+	ex     exchange.Exchange
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// collectorSession is a collector's connection to the aggregator for one exchange
+type collectorSession struct {
+	config  CollectorConfig
+	name    exchange.ExchangeName
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	feeds   map[string]*collectedFeed
+}
+
+// collect serves one aggregator connection for exchange name until it fails
+func collect(ctx context.Context, config CollectorConfig, name exchange.ExchangeName) error {
+	dialer := transport.Default().Dialer(10 * time.Second)
+	conn, _, err := dialer.DialContext(ctx, config.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to aggregator: %w", err)
+	}
+	defer conn.Close()
+
+	s := &collectorSession{
+		config: config,
+		name:   name,
+		conn:   conn,
+		feeds:  make(map[string]*collectedFeed),
+	}
+	defer s.stopAll()
+	if err := s.send(Message{Type: MessageHello, Exchange: name, Collector: config.ID}); err != nil {
+		return err
+	}
+	log.Printf("[%s] Collecting for %s", name, config.URL)
+
+	// Unblock the read below on shutdown
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// Let the aggregator tell a silent collector from an idle one
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go s.heartbeat(heartbeatCtx)
+
+	for {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+		switch msg.Type {
+		case MessageSubscribe:
+			s.subscribe(ctx, msg)
+		case MessageUnsubscribe:
+			s.unsubscribe(msg.Symbol)
+		case MessageSnapshotRequest:
+			go s.snapshot(ctx, msg)
+		case MessageError:
+			return fmt.Errorf("aggregator rejected the collector: %s", msg.Error)
+		}
+	}
+}
+
+// send writes a message to the aggregator
+func (s *collectorSession) send(msg Message) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.conn.WriteJSON(msg)
+}
+
+// heartbeat sends a heartbeat every healthInterval until ctx is done
+func (s *collectorSession) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.send(Message{Type: MessageHeartbeat}); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// subscribe starts streaming a symbol, replacing its current stream
+func (s *collectorSession) subscribe(ctx context.Context, msg Message) {
+	s.unsubscribe(msg.Symbol)
+
+	feedCtx, cancel := context.WithCancel(ctx)
+	feed := &collectedFeed{cancel: cancel, done: make(chan struct{})}
+	s.mu.Lock()
+	s.feeds[msg.Symbol] = feed
+	s.mu.Unlock()
+	go s.stream(feedCtx, feed, msg)
+}
+
+// unsubscribe stops streaming a symbol
+func (s *collectorSession) unsubscribe(symbol string) {
+	s.mu.Lock()
+	feed, ok := s.feeds[symbol]
+	delete(s.feeds, symbol)
+	s.mu.Unlock()
+	if ok {
+		feed.cancel()
+		<-feed.done
+	}
+}
+
+// stopAll stops every stream
+func (s *collectorSession) stopAll() {
+	s.mu.Lock()
+	symbols := make([]string, 0, len(s.feeds))
+	for symbol := range s.feeds {
+		symbols = append(symbols, symbol)
+	}
+	s.mu.Unlock()
+	for _, symbol := range symbols {
+		s.unsubscribe(symbol)
+	}
+}
+
+// stream runs the adapter of a subscription and forwards its updates and health
+func (s *collectorSession) stream(ctx context.Context, feed *collectedFeed, msg Message) {
+	defer close(feed.done)
+	fail := func(err error) {
+		log.Printf("[%s] %s: %v", s.name, msg.Symbol, err)
+		s.send(Message{Type: MessageError, Symbol: msg.Symbol, Error: err.Error()})
+	}
+
+	ex, err := s.config.NewExchange(factory.ExchangeConfig{
+		Name:        s.name,
+		Symbol:      msg.Symbol,
+		Depth:       msg.Depth,
+		UpdateSpeed: msg.UpdateSpeed,
+		Category:    msg.Category,
+		SigFigs:     msg.SigFigs,
+		Mantissa:    msg.Mantissa,
+		Instrument:  msg.Instrument,
+		TopOfBook:   msg.TopOfBook,
+	})
+	if err != nil {
+		fail(err)
+		return
+	}
+	if watcher, ok := ex.(exchange.StallWatcher); ok {
+		watcher.SetStaleTimeout(time.Duration(msg.StaleTimeout) * time.Millisecond)
+	}
+	if err := ex.Connect(ctx); err != nil {
+		fail(fmt.Errorf("failed to connect: %w", err))
+		return
+	}
+	defer ex.Close()
+
+	s.mu.Lock()
+	feed.ex = ex
+	s.mu.Unlock()
+	if err := s.send(Message{Type: MessageReady, Symbol: msg.Symbol}); err != nil {
+		return
+	}
+	log.Printf("[%s] Streaming %s to the aggregator", s.name, msg.Symbol)
+
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	updates := ex.Updates()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				fail(exchange.ErrConnClosed)
+				return
+			}
+			if update.Trace != nil {
+				update.Trace.End()
+			}
+			if err := s.send(Message{Type: MessageUpdate, Symbol: msg.Symbol, Update: newUpdate(update)}); err != nil {
+				return
+			}
+		case <-ticker.C:
+			health := ex.Health()
+			if err := s.send(Message{Type: MessageHealth, Symbol: msg.Symbol, Health: &health}); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// snapshot answers a snapshot request with the adapter of its symbol
+func (s *collectorSession) snapshot(ctx context.Context, msg Message) {
+	answer := Message{Type: MessageSnapshot, Symbol: msg.Symbol, ID: msg.ID}
+	s.mu.Lock()
+	var ex exchange.Exchange
+	if feed, ok := s.feeds[msg.Symbol]; ok {
+		ex = feed.ex
+	}
+	s.mu.Unlock()
+
+	if ex == nil {
+		answer.Error = "symbol not streamed"
+	} else if snapshot, err := ex.GetSnapshot(ctx); err != nil {
+		answer.Error = err.Error()
+	} else {
+		answer.Snapshot = snapshot
+	}
+	if err := s.send(answer); err != nil {
+		log.Printf("[%s] Failed to send %s snapshot: %v", s.name, msg.Symbol, err)
+	}
+}
@@ -0,0 +1,273 @@
+package distributed
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/factory"
+)
+
+// fakeExchange is an adapter streaming the updates pushed to it
+type fakeExchange struct {
+	config  factory.ExchangeConfig
+	updates chan *exchange.DepthUpdate
+}
+
+func (f *fakeExchange) GetName() exchange.ExchangeName        { return f.config.Name }
+func (f *fakeExchange) GetSymbol() string                     { return f.config.Symbol }
+func (f *fakeExchange) Connect(ctx context.Context) error     { return nil }
+func (f *fakeExchange) Close() error                          { return nil }
+func (f *fakeExchange) Updates() <-chan *exchange.DepthUpdate { return f.updates }
+func (f *fakeExchange) IsConnected() bool                     { return true }
+func (f *fakeExchange) Health() exchange.HealthStatus         { return exchange.HealthStatus{Connected: true} }
+func (f *fakeExchange) Capabilities() exchange.Capabilities   { return exchange.Capabilities{} }
+
+func (f *fakeExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
+	return &exchange.Snapshot{
+		LastUpdateID: 10,
+		Bids:         []exchange.PriceLevel{{Price: "50000", Quantity: "1"}},
+		Asks:         []exchange.PriceLevel{{Price: "50001", Quantity: "2"}},
+	}, nil
+}
+
+// startCollector runs a collector of name whose adapters are sent on adapters
+func startCollector(url, id string, name exchange.ExchangeName, adapters chan *fakeExchange) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RunCollector(ctx, CollectorConfig{
+			URL:       url,
+			ID:        id,
+			Exchanges: []exchange.ExchangeName{name},
+			NewExchange: func(config factory.ExchangeConfig) (exchange.Exchange, error) {
+				fake := &fakeExchange{config: config, updates: make(chan *exchange.DepthUpdate, 10)}
+				adapters <- fake
+				return fake, nil
+			},
+		})
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// waitFor polls cond for up to 5 seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCollectorToAggregator(t *testing.T) {
+	hub := NewHub(5 * time.Second)
+	server := httptest.NewServer(hub)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	adapters := make(chan *fakeExchange, 4)
+	stopCollector := startCollector(url, "collector-1", exchange.OKX, adapters)
+
+	ex, err := hub.Exchange(factory.ExchangeConfig{Name: exchange.OKX, Symbol: "BTCUSDT", Depth: 400})
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := ex.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	adapter := <-adapters
+	if adapter.config.Symbol != "BTCUSDT" || adapter.config.Depth != 400 {
+		t.Errorf("Expected the collector to stream BTCUSDT at depth 400, got %+v", adapter.config)
+	}
+	if collectors := hub.CollectorStates(); len(collectors) != 1 || collectors[0].ID != "collector-1" || len(collectors[0].Leads) != 1 {
+		t.Errorf("Expected okx BTCUSDT led by collector-1, got %+v", collectors)
+	}
+
+	snapshot, err := ex.GetSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("GetSnapshot failed: %v", err)
+	}
+	if snapshot.LastUpdateID != 10 || snapshot.Exchange != exchange.OKX || snapshot.Symbol != "BTCUSDT" || snapshot.Asks[0].Quantity != "2" {
+		t.Errorf("Expected the collector's snapshot at update 10, got %+v", snapshot)
+	}
+
+	adapter.updates <- &exchange.DepthUpdate{
+		FirstUpdateID: 11,
+		FinalUpdateID: 12,
+		PrevUpdateID:  10,
+		Bids:          []exchange.PriceLevel{{Price: "50000", Quantity: "0"}},
+	}
+	select {
+	case update := <-ex.Updates():
+		if update.Exchange != exchange.OKX || update.Symbol != "BTCUSDT" || update.FinalUpdateID != 12 || update.PrevUpdateID != 10 || update.Bids[0].Quantity != "0" {
+			t.Errorf("Expected update 11-12 of okx BTCUSDT, got %+v", update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the update")
+	}
+
+	// Losing the collector leaves the feed disconnected until one streams it again
+	stopCollector()
+	waitFor(t, "the feed to disconnect", func() bool { return !ex.IsConnected() })
+	if _, err := ex.GetSnapshot(ctx); err == nil {
+		t.Errorf("Expected snapshots to fail without a collector")
+	}
+
+	stopCollector = startCollector(url, "collector-3", exchange.OKX, adapters)
+	defer stopCollector()
+	<-adapters
+	waitFor(t, "the feed to reconnect", ex.IsConnected)
+	if reconnects := ex.Health().Reconnects; reconnects != 1 {
+		t.Errorf("Expected the new collector stream to count as a reconnect, got %d", reconnects)
+	}
+
+	ex.Close()
+	if _, ok := <-ex.Updates(); ok {
+		t.Errorf("Expected the update channel to be closed")
+	}
+}
+
+// receive returns the next update of ex
+func receive(t *testing.T, ex exchange.Exchange) *exchange.DepthUpdate {
+	t.Helper()
+	select {
+	case update := <-ex.Updates():
+		return update
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for an update")
+		return nil
+	}
+}
+
+func TestCollectorFailover(t *testing.T) {
+	hub := NewHub(5 * time.Second)
+	server := httptest.NewServer(hub)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	leaderAdapters := make(chan *fakeExchange, 4)
+	stopLeader := startCollector(url, "collector-1", exchange.Binance, leaderAdapters)
+	defer stopLeader()
+
+	ex, err := hub.Exchange(factory.ExchangeConfig{Name: exchange.Binance, Symbol: "BTCUSDT"})
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	defer ex.Close()
+	if err := ex.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	leader := <-leaderAdapters
+
+	standbyAdapters := make(chan *fakeExchange, 4)
+	stopStandby := startCollector(url, "collector-2", exchange.Binance, standbyAdapters)
+	defer stopStandby()
+	standby := <-standbyAdapters
+	waitFor(t, "the standby stream", func() bool {
+		states := hub.CollectorStates()
+		return len(states) == 2 && states[1].Streams == 1
+	})
+
+	// Both collectors stream the same updates; only the leader's are forwarded
+	leader.updates <- &exchange.DepthUpdate{FirstUpdateID: 11, FinalUpdateID: 12, Connection: 1, ConnectionSeq: 1}
+	first := receive(t, ex)
+	if first.FinalUpdateID != 12 {
+		t.Errorf("Expected update 12 from the leader, got %d", first.FinalUpdateID)
+	}
+	standby.updates <- &exchange.DepthUpdate{FirstUpdateID: 11, FinalUpdateID: 12, Connection: 1, ConnectionSeq: 1}
+	standby.updates <- &exchange.DepthUpdate{FirstUpdateID: 13, FinalUpdateID: 14, Connection: 1, ConnectionSeq: 2}
+	remote := ex.(*RemoteExchange)
+	waitFor(t, "the standby updates", func() bool {
+		remote.mu.Lock()
+		defer remote.mu.Unlock()
+		for _, s := range remote.streams {
+			if s.lastFinal == 14 {
+				return true
+			}
+		}
+		return false
+	})
+
+	// Losing the leader replays the standby's updates the book has not seen
+	stopLeader()
+	replayed := receive(t, ex)
+	if replayed.FirstUpdateID != 13 || replayed.FinalUpdateID != 14 {
+		t.Errorf("Expected update 13-14 replayed from the standby, got %d-%d", replayed.FirstUpdateID, replayed.FinalUpdateID)
+	}
+	if replayed.Connection == first.Connection || replayed.ConnectionSeq != 2 {
+		t.Errorf("Expected the standby's second update on another connection than %d, got connection %d seq %d",
+			first.Connection, replayed.Connection, replayed.ConnectionSeq)
+	}
+	standby.updates <- &exchange.DepthUpdate{FirstUpdateID: 15, FinalUpdateID: 15}
+	if update := receive(t, ex); update.FinalUpdateID != 15 {
+		t.Errorf("Expected update 15 from the new leader, got %d", update.FinalUpdateID)
+	}
+
+	health := ex.Health()
+	if !health.Connected || health.Failovers != 1 || health.Reconnects != 0 {
+		t.Errorf("Expected a gapless failover without reconnect, got %+v", health)
+	}
+	if states := hub.CollectorStates(); len(states) != 1 || states[0].ID != "collector-2" || len(states[0].Leads) != 1 {
+		t.Errorf("Expected collector-2 to lead BTCUSDT, got %+v", states)
+	}
+}
+
+func TestStalledCollectorDoesNotBlockHub(t *testing.T) {
+	hub := NewHub(5 * time.Second)
+	server := httptest.NewServer(hub)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	adapters := make(chan *fakeExchange, 4)
+	stopCollector := startCollector(url, "collector-1", exchange.OKX, adapters)
+	defer stopCollector()
+	waitFor(t, "the collector", func() bool { return len(hub.CollectorStates()) == 1 })
+
+	// Holding the write lock stalls every send to the collector
+	hub.mu.Lock()
+	c := hub.collectors[exchange.OKX][0]
+	hub.mu.Unlock()
+	c.writeMu.Lock()
+
+	ex, err := hub.Exchange(factory.ExchangeConfig{Name: exchange.OKX, Symbol: "BTCUSDT"})
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	remote := ex.(*RemoteExchange)
+	subscribed := make(chan struct{})
+	go func() {
+		hub.subscribe(remote)
+		close(subscribed)
+	}()
+	waitFor(t, "the feed to register the collector", func() bool {
+		remote.mu.Lock()
+		defer remote.mu.Unlock()
+		return remote.streams[c] != nil
+	})
+
+	states := make(chan []CollectorState)
+	go func() { states <- hub.CollectorStates() }()
+	select {
+	case <-states:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the hub to stay available while a subscription is stalled")
+	}
+
+	c.writeMu.Unlock()
+	<-subscribed
+	if adapter := <-adapters; adapter.config.Symbol != "BTCUSDT" {
+		t.Errorf("Expected the collector to stream BTCUSDT once unstalled, got %s", adapter.config.Symbol)
+	}
+	hub.unsubscribe(remote)
+}
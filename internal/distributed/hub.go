@@ -0,0 +1,274 @@
+package distributed
+
+import (
+	"cmp"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/factory"
+
+	"github.com/gorilla/websocket"
+)
+
+// writeTimeout bounds each write to a collector connection
+const writeTimeout = 10 * time.Second
+
+// collectorTimeout is the silence after which a collector is considered lost
+const collectorTimeout = 10 * time.Second
+
+// DefaultConnectTimeout is how long a remote exchange waits for a collector by default
+const DefaultConnectTimeout = 30 * time.Second
+
+// feedKey identifies a feed subscribed by the aggregator
+type feedKey struct {
+	exchange exchange.ExchangeName
+	symbol   string
+}
+
+// collectorConn is the connection of a collector to the hub
+type collectorConn struct {
+	id          string
+	exchange    exchange.ExchangeName
+	connectedAt time.Time
+	conn        *websocket.Conn
+	writeMu     sync.Mutex
+}
+
+// send writes a message to the collector
+func (c *collectorConn) send(msg Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteJSON(msg)
+}
+
+// CollectorState describes a collector connected to the hub
+type CollectorState struct {
+	Exchange    exchange.ExchangeName `json:"exchange"`
+	ID          string                `json:"id"`
+	ConnectedAt int64                 `json:"connectedAt"`
+	Streams     int                   `json:"streams"` // Feeds the collector streams
+	Leads       []string              `json:"leads"`   // Symbols whose stream is forwarded from the collector
+}
+
+// Hub is the aggregator's end of distributed mode. It accepts collector
+// connections on ServeHTTP and hands out remote exchanges fed by them. Several
+// collectors may connect for the same exchange; they all stream its feeds and
+// each feed forwards one of them, failing over to another if it is lost.
+type Hub struct {
+	mu             sync.Mutex
+	upgrader       websocket.Upgrader
+	collectors     map[exchange.ExchangeName][]*collectorConn // Longest-connected first
+	feeds          map[feedKey]*RemoteExchange
+	nextID         int64 // Last snapshot request ID
+	connectTimeout time.Duration
+}
+
+// NewHub creates a hub whose remote exchanges wait connectTimeout for a collector
+// when connecting (DefaultConnectTimeout when not positive)
+func NewHub(connectTimeout time.Duration) *Hub {
+	if connectTimeout <= 0 {
+		connectTimeout = DefaultConnectTimeout
+	}
+	return &Hub{
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  64 * 1024,
+			WriteBufferSize: 16 * 1024,
+		},
+		collectors:     make(map[exchange.ExchangeName][]*collectorConn),
+		feeds:          make(map[feedKey]*RemoteExchange),
+		connectTimeout: connectTimeout,
+	}
+}
+
+// Exchange returns an exchange fed by the collector of config.Name, to be used in
+// place of the adapter factory.NewExchange would create
+func (h *Hub) Exchange(config factory.ExchangeConfig) (exchange.Exchange, error) {
+	// The local adapter is never connected; it describes the feed
+	local, err := factory.NewExchange(config)
+	if err != nil {
+		return nil, err
+	}
+	return newRemoteExchange(h, config, local), nil
+}
+
+// CollectorStates returns the connected collectors, by exchange and connection time
+func (h *Hub) CollectorStates() []CollectorState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var states []CollectorState
+	for _, collectors := range h.collectors {
+		for _, c := range collectors {
+			state := CollectorState{Exchange: c.exchange, ID: c.id, ConnectedAt: c.connectedAt.UnixMilli(), Leads: []string{}}
+			for key, feed := range h.feeds {
+				if key.exchange != c.exchange {
+					continue
+				}
+				if feed.streaming(c) {
+					state.Streams++
+				}
+				if feed.leads(c) {
+					state.Leads = append(state.Leads, key.symbol)
+				}
+			}
+			slices.Sort(state.Leads)
+			states = append(states, state)
+		}
+	}
+	slices.SortFunc(states, func(a, b CollectorState) int {
+		return cmp.Or(cmp.Compare(a.Exchange, b.Exchange), cmp.Compare(a.ConnectedAt, b.ConnectedAt))
+	})
+	return states
+}
+
+// ServeHTTP upgrades a collector connection and serves it until it closes
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Collector upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	var hello Message
+	if err := conn.ReadJSON(&hello); err != nil || hello.Type != MessageHello || !factory.ValidateExchangeName(string(hello.Exchange)) {
+		log.Printf("Collector %s rejected: expected a hello for a supported exchange", r.RemoteAddr)
+		return
+	}
+	c := &collectorConn{id: hello.Collector, exchange: hello.Exchange, connectedAt: time.Now(), conn: conn}
+	if c.id == "" {
+		c.id = r.RemoteAddr
+	}
+	h.attach(c)
+	defer h.detach(c)
+
+	for {
+		var msg Message
+		conn.SetReadDeadline(time.Now().Add(collectorTimeout))
+		if err := conn.ReadJSON(&msg); err != nil {
+			log.Printf("[%s] Collector %s disconnected: %v", c.exchange, c.id, err)
+			return
+		}
+		h.dispatch(c, msg)
+	}
+}
+
+// attach adds c to the collectors of its exchange and subscribes it to the
+// exchange's feeds
+func (h *Hub) attach(c *collectorConn) {
+	h.mu.Lock()
+	h.collectors[c.exchange] = append(h.collectors[c.exchange], c)
+	if n := len(h.collectors[c.exchange]); n > 1 {
+		log.Printf("[%s] Collector %s connected (%d collectors)", c.exchange, c.id, n)
+	} else {
+		log.Printf("[%s] Collector %s connected", c.exchange, c.id)
+	}
+	// Feeds are registered under the lock so a concurrent detach drops them,
+	// but the subscriptions are sent outside it so a stalled collector does
+	// not block dispatch for every other collector
+	var feeds []*RemoteExchange
+	for key, feed := range h.feeds {
+		if key.exchange == c.exchange {
+			feed.attach(c)
+			feeds = append(feeds, feed)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, feed := range feeds {
+		feed.subscribeOn(c)
+	}
+}
+
+// detach removes c, failing its feeds over to the other collectors of the
+// exchange, or leaving them disconnected until another collector attaches
+func (h *Hub) detach(c *collectorConn) {
+	h.mu.Lock()
+	h.collectors[c.exchange] = slices.DeleteFunc(h.collectors[c.exchange], func(o *collectorConn) bool { return o == c })
+	if len(h.collectors[c.exchange]) == 0 {
+		delete(h.collectors, c.exchange)
+	}
+	var feeds []*RemoteExchange
+	for key, feed := range h.feeds {
+		if key.exchange == c.exchange {
+			feeds = append(feeds, feed)
+		}
+	}
+	h.mu.Unlock()
+
+	// Failing over may block on a full update queue, so it runs outside the lock
+	for _, feed := range feeds {
+		feed.detach(c)
+	}
+}
+
+// dispatch hands a collector message to the feed of its symbol
+func (h *Hub) dispatch(c *collectorConn, msg Message) {
+	h.mu.Lock()
+	feed := h.feeds[feedKey{exchange: c.exchange, symbol: msg.Symbol}]
+	h.mu.Unlock()
+	if feed == nil {
+		return
+	}
+
+	switch msg.Type {
+	case MessageReady:
+		feed.ready(c)
+	case MessageUpdate:
+		if msg.Update != nil {
+			feed.push(c, msg.Update)
+		}
+	case MessageHealth:
+		if msg.Health != nil {
+			feed.setHealth(c, *msg.Health)
+		}
+	case MessageSnapshot:
+		feed.answer(msg)
+	case MessageError:
+		log.Printf("[%s] Collector %s: %s %s", c.exchange, c.id, msg.Symbol, msg.Error)
+		feed.failed(c)
+	}
+}
+
+// subscribe registers feed and subscribes the exchange's collectors
+func (h *Hub) subscribe(feed *RemoteExchange) {
+	h.mu.Lock()
+	h.feeds[feed.key()] = feed
+	collectors := slices.Clone(h.collectors[feed.config.Name])
+	for _, c := range collectors {
+		feed.attach(c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range collectors {
+		feed.subscribeOn(c)
+	}
+}
+
+// unsubscribe removes feed, telling its collectors to stop streaming it
+func (h *Hub) unsubscribe(feed *RemoteExchange) {
+	h.mu.Lock()
+	if h.feeds[feed.key()] != feed {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.feeds, feed.key())
+	collectors := slices.Clone(h.collectors[feed.config.Name])
+	h.mu.Unlock()
+
+	for _, c := range collectors {
+		c.send(Message{Type: MessageUnsubscribe, Symbol: feed.config.Symbol})
+	}
+}
+
+// requestID returns a new snapshot request ID
+func (h *Hub) requestID() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextID++
+	return h.nextID
+}
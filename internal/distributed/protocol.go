@@ -0,0 +1,109 @@
+// Package distributed splits the engine across processes: collectors run the
+// exchange adapters and stream canonical updates to a central aggregator, which
+// maintains the books and serves clients as if the adapters ran in-process.
+//
+// Collectors dial the aggregator's /collect endpoint, one WebSocket connection per
+// exchange, and exchange JSON messages with it. The aggregator subscribes the
+// symbols it monitors; the collector runs an adapter per symbol and forwards its
+// updates, health and, on request, snapshots.
+//
+// Several collectors may stream the same exchange for redundancy. The aggregator
+// forwards the stream of one of them per symbol and fails over to another when
+// that collector is lost.
+package distributed
+
+import (
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+// MessageType identifies a message between a collector and the aggregator
+type MessageType string
+
+const (
+	MessageHello           MessageType = "hello"            // Collector → aggregator: the exchange collected, first message
+	MessageSubscribe       MessageType = "subscribe"        // Aggregator → collector: start streaming a symbol
+	MessageUnsubscribe     MessageType = "unsubscribe"      // Aggregator → collector: stop streaming a symbol
+	MessageReady           MessageType = "ready"            // Collector → aggregator: the symbol's adapter connected
+	MessageUpdate          MessageType = "update"           // Collector → aggregator: a canonical depth update
+	MessageHealth          MessageType = "health"           // Collector → aggregator: the adapter's health, every second
+	MessageSnapshotRequest MessageType = "snapshot_request" // Aggregator → collector: fetch a snapshot
+	MessageSnapshot        MessageType = "snapshot"         // Collector → aggregator: the snapshot requested, or its error
+	MessageError           MessageType = "error"            // Collector → aggregator: the symbol's adapter failed or stopped
+	MessageHeartbeat       MessageType = "heartbeat"        // Collector → aggregator: the collector is alive, every second
+)
+
+// Message is the envelope of every collector protocol message
+type Message struct {
+	Type         MessageType            `json:"type"`
+	Exchange     exchange.ExchangeName  `json:"exchange,omitempty"`
+	Collector    string                 `json:"collector,omitempty"` // Collector ID, sent with hello
+	Symbol       string                 `json:"symbol,omitempty"`
+	Depth        int                    `json:"depth,omitempty"`          // Subscription depth, 0 for the adapter default
+	UpdateSpeed  string                 `json:"updateSpeed,omitempty"`    // Stream frequency, empty for the adapter default
+	Category     string                 `json:"category,omitempty"`       // Product category, empty for the adapter default
+	SigFigs      int                    `json:"sigFigs,omitempty"`        // Book aggregation significant figures, 0 for full precision
+	Mantissa     int                    `json:"mantissa,omitempty"`       // Book aggregation mantissa, 0 for the venue default
+	Instrument   string                 `json:"instrument,omitempty"`     // Venue instrument ID used verbatim, empty converts the symbol
+	TopOfBook    bool                   `json:"topOfBook,omitempty"`      // Stream the best bid and ask only
+	StaleTimeout int64                  `json:"staleTimeoutMs,omitempty"` // Stall watchdog of the adapter, 0 disables
+	ID           int64                  `json:"id,omitempty"`             // Matches a snapshot to its request
+	Update       *Update                `json:"update,omitempty"`
+	Snapshot     *exchange.Snapshot     `json:"snapshot,omitempty"`
+	Health       *exchange.HealthStatus `json:"health,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// Update is a canonical depth update as sent by collectors
+type Update struct {
+	EventTime      time.Time             `json:"eventTime"`
+	LocalEventTime time.Time             `json:"localEventTime"`
+	FirstUpdateID  int64                 `json:"firstUpdateId"`
+	FinalUpdateID  int64                 `json:"finalUpdateId"`
+	PrevUpdateID   int64                 `json:"prevUpdateId"`
+	Bids           []exchange.PriceLevel `json:"bids"`
+	Asks           []exchange.PriceLevel `json:"asks"`
+	Snapshot       bool                  `json:"snapshot,omitempty"`
+	ReceiveTime    time.Time             `json:"receiveTime"`   // Receipt by the collector's adapter
+	Connection     int64                 `json:"connection"`    // Generation of the collector adapter's connection
+	ConnectionSeq  int64                 `json:"connectionSeq"` // Position of the update on that connection
+}
+
+// newUpdate converts a depth update for the wire, dropping its trace span
+func newUpdate(update *exchange.DepthUpdate) *Update {
+	return &Update{
+		EventTime:      update.EventTime,
+		LocalEventTime: update.LocalEventTime,
+		FirstUpdateID:  update.FirstUpdateID,
+		FinalUpdateID:  update.FinalUpdateID,
+		PrevUpdateID:   update.PrevUpdateID,
+		Bids:           update.Bids,
+		Asks:           update.Asks,
+		Snapshot:       update.Snapshot,
+		ReceiveTime:    update.ReceiveTime,
+		Connection:     update.Connection,
+		ConnectionSeq:  update.ConnectionSeq,
+	}
+}
+
+// depthUpdate converts the update back to the canonical form of the exchange and
+// symbol. Connection is the generation the aggregator assigned to the collector
+// connection that produced the update.
+func (u *Update) depthUpdate(name exchange.ExchangeName, symbol string, connection int64) *exchange.DepthUpdate {
+	return &exchange.DepthUpdate{
+		Exchange:       name,
+		Symbol:         symbol,
+		EventTime:      u.EventTime,
+		LocalEventTime: u.LocalEventTime,
+		FirstUpdateID:  u.FirstUpdateID,
+		FinalUpdateID:  u.FinalUpdateID,
+		PrevUpdateID:   u.PrevUpdateID,
+		Bids:           u.Bids,
+		Asks:           u.Asks,
+		Snapshot:       u.Snapshot,
+		ReceiveTime:    u.ReceiveTime,
+		Connection:     connection,
+		ConnectionSeq:  u.ConnectionSeq,
+	}
+}
@@ -0,0 +1,527 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/factory"
+	"orderbook/internal/types"
+)
+
+// defaultSnapshotTimeout bounds a snapshot request whose context has no deadline
+const defaultSnapshotTimeout = 30 * time.Second
+
+// standbyBacklog bounds the updates a standby stream keeps to bridge a switchover
+const standbyBacklog = 1000
+
+// resubscribeDelay is how long a feed waits before resubscribing a collector whose
+// adapter failed
+const resubscribeDelay = 5 * time.Second
+
+// errNoCollector is returned while no collector streams the feed
+var errNoCollector = errors.New("no collector ready")
+
+// RemoteExchange is an exchange whose adapter runs in a collector process. Every
+// collector of the exchange streams the feed; the hub forwards the stream of one
+// of them, the leader, and keeps the others as hot standbys. When the leader's
+// stream is lost, the longest-connected collector with a live stream takes over.
+// On venues with sequence IDs the standby's recent updates bridge the switchover
+// and updates are deduplicated by update ID, so the book carries on without a
+// gap; otherwise the switchover counts as a reconnect so the book is resynced.
+type RemoteExchange struct {
+	hub          *Hub
+	config       factory.ExchangeConfig
+	local        exchange.Exchange // Unconnected adapter describing the feed
+	sequenced    bool              // Updates carry sequence IDs shared by every collector's stream
+	staleTimeout time.Duration
+
+	updates chan *exchange.DepthUpdate
+	sendMu  sync.Mutex // Serializes forwarding with the closing of updates
+	closed  bool
+
+	mu          sync.Mutex
+	order       []*collectorConn // Collectors subscribed to the feed, longest-connected first
+	streams     map[*collectorConn]*collectorStream
+	leader      *collectorConn // Collector whose stream is forwarded, nil while none is
+	started     bool           // A stream was forwarded
+	readyCh     chan struct{}  // Closed when the feed is first ready
+	readyOnce   sync.Once
+	lastFinal   int64 // Final update ID of the latest update forwarded, 0 when unknown
+	reconnects  int64 // Stream restarts the book must resync after
+	failovers   int64 // Switches of the forwarded stream to another collector
+	generations int64 // Connection generations assigned to collector adapter connections
+	pending     map[int64]chan Message
+}
+
+// collectorStream is the stream of a feed by one collector
+type collectorStream struct {
+	ready      bool                  // The collector reported the feed ready
+	health     exchange.HealthStatus // Latest health reported by the collector
+	lastFinal  int64                 // Final update ID of the latest update received
+	connection int64                 // Adapter connection of the latest update received
+	generation int64                 // Connection generation the feed assigned to that adapter connection
+	backlog    []*Update             // Updates not yet forwarded, kept while standby
+}
+
+// live reports whether the stream is ready from a connected adapter
+func (s *collectorStream) live() bool {
+	return s != nil && s.ready && s.health.Connected
+}
+
+// keep buffers an update of a standby stream, dropping the updates up to
+// forwarded and the oldest beyond standbyBacklog
+func (s *collectorStream) keep(update *Update, forwarded int64) {
+	s.backlog = append(s.backlog, update)
+	drop := 0
+	for drop < len(s.backlog) && (s.backlog[drop].FinalUpdateID <= forwarded || len(s.backlog)-drop > standbyBacklog) {
+		drop++
+	}
+	s.backlog = s.backlog[drop:]
+}
+
+// newRemoteExchange creates a remote exchange of hub described by local
+func newRemoteExchange(hub *Hub, config factory.ExchangeConfig, local exchange.Exchange) *RemoteExchange {
+	return &RemoteExchange{
+		hub:       hub,
+		config:    config,
+		local:     local,
+		sequenced: local.Capabilities().SequenceIDs,
+		updates:   make(chan *exchange.DepthUpdate, 1000),
+		streams:   make(map[*collectorConn]*collectorStream),
+		readyCh:   make(chan struct{}),
+		pending:   make(map[int64]chan Message),
+	}
+}
+
+func (r *RemoteExchange) key() feedKey {
+	return feedKey{exchange: r.config.Name, symbol: r.config.Symbol}
+}
+
+// GetName returns the exchange name
+func (r *RemoteExchange) GetName() exchange.ExchangeName {
+	return r.config.Name
+}
+
+// GetSymbol returns the trading symbol
+func (r *RemoteExchange) GetSymbol() string {
+	return r.config.Symbol
+}
+
+// Capabilities describes the feed of the collector's adapter
+func (r *RemoteExchange) Capabilities() exchange.Capabilities {
+	return r.local.Capabilities()
+}
+
+// QuoteCurrency returns the quote currency of the collected book
+func (r *RemoteExchange) QuoteCurrency() string {
+	if provider, ok := r.local.(exchange.QuoteProvider); ok {
+		return provider.QuoteCurrency()
+	}
+	return types.QuoteAsset(r.config.Symbol)
+}
+
+// SetStaleTimeout sets the stall watchdog of the collector's adapter. It must be
+// called before Connect.
+func (r *RemoteExchange) SetStaleTimeout(timeout time.Duration) {
+	r.staleTimeout = timeout
+}
+
+// Connect subscribes the feed and waits until a collector streams it
+func (r *RemoteExchange) Connect(ctx context.Context) error {
+	r.hub.subscribe(r)
+	timer := time.NewTimer(r.hub.connectTimeout)
+	defer timer.Stop()
+	select {
+	case <-r.readyCh:
+		return nil
+	case <-ctx.Done():
+		r.hub.unsubscribe(r)
+		return ctx.Err()
+	case <-timer.C:
+		r.hub.unsubscribe(r)
+		return fmt.Errorf("%w for %s %s within %v", errNoCollector, r.config.Name, r.config.Symbol, r.hub.connectTimeout)
+	}
+}
+
+// Close unsubscribes the feed and closes its update channel
+func (r *RemoteExchange) Close() error {
+	r.mu.Lock()
+	r.order = nil
+	r.streams = make(map[*collectorConn]*collectorStream)
+	r.leader = nil
+	r.failPending(exchange.ErrConnClosed.Error())
+	r.mu.Unlock()
+
+	r.hub.unsubscribe(r)
+
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+	if !r.closed {
+		r.closed = true
+		close(r.updates)
+	}
+	return nil
+}
+
+// GetSnapshot asks the leading collector for a snapshot
+func (r *RemoteExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultSnapshotTimeout)
+		defer cancel()
+	}
+
+	id := r.hub.requestID()
+	r.mu.Lock()
+	c := r.leader
+	if s := r.streams[c]; s == nil || !s.ready {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w for %s %s", errNoCollector, r.config.Name, r.config.Symbol)
+	}
+	answer := make(chan Message, 1)
+	r.pending[id] = answer
+	r.mu.Unlock()
+
+	if err := c.send(Message{Type: MessageSnapshotRequest, Symbol: r.config.Symbol, ID: id}); err != nil {
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+		return nil, fmt.Errorf("failed to request snapshot from collector %s: %w", c.id, err)
+	}
+
+	select {
+	case msg := <-answer:
+		if msg.Error != "" {
+			return nil, fmt.Errorf("collector snapshot failed: %s", msg.Error)
+		}
+		if msg.Snapshot == nil {
+			return nil, fmt.Errorf("collector sent an empty snapshot")
+		}
+		snapshot := msg.Snapshot
+		snapshot.Exchange = r.config.Name
+		snapshot.Symbol = r.config.Symbol
+		return snapshot, nil
+	case <-ctx.Done():
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+		return nil, fmt.Errorf("collector snapshot: %w", ctx.Err())
+	}
+}
+
+// Updates returns the channel of the updates streamed by the leading collector
+func (r *RemoteExchange) Updates() <-chan *exchange.DepthUpdate {
+	return r.updates
+}
+
+// IsConnected reports whether a collector streams the feed from a connected adapter
+func (r *RemoteExchange) IsConnected() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.streams[r.leader].live()
+}
+
+// Health returns the health of the leading collector's adapter. Reconnects counts
+// the stream restarts the book must resync after, Failovers the switches to
+// another collector.
+func (r *RemoteExchange) Health() exchange.HealthStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var health exchange.HealthStatus
+	if s := r.streams[r.leader]; s != nil {
+		health = s.health
+	}
+	health.Connected = r.streams[r.leader].live()
+	health.Reconnects = r.reconnects
+	health.Failovers = r.failovers
+	return health
+}
+
+// leads reports whether c is the leading collector of the feed
+func (r *RemoteExchange) leads(c *collectorConn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.leader == c
+}
+
+// streaming reports whether collector c streams the feed
+func (r *RemoteExchange) streaming(c *collectorConn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.streams[c]
+	return ok && s.ready
+}
+
+// attach registers collector c as a stream of the feed; the hub subscribes
+// the feed on c with subscribeOn once it has released its lock
+func (r *RemoteExchange) attach(c *collectorConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.streams[c]; !ok {
+		r.order = append(r.order, c)
+	}
+	r.streams[c] = &collectorStream{}
+}
+
+// subscribeOn asks collector c to stream the feed
+func (r *RemoteExchange) subscribeOn(c *collectorConn) {
+	err := c.send(Message{
+		Type:         MessageSubscribe,
+		Symbol:       r.config.Symbol,
+		Depth:        r.config.Depth,
+		UpdateSpeed:  r.config.UpdateSpeed,
+		Category:     r.config.Category,
+		SigFigs:      r.config.SigFigs,
+		Mantissa:     r.config.Mantissa,
+		Instrument:   r.config.Instrument,
+		TopOfBook:    r.config.TopOfBook,
+		StaleTimeout: r.staleTimeout.Milliseconds(),
+	})
+	if err != nil {
+		log.Printf("[%s] Failed to subscribe %s on collector %s: %v", r.config.Name, r.config.Symbol, c.id, err)
+	}
+}
+
+// resubscribe asks collector c to stream the feed again if its adapter has not
+// recovered since it failed
+func (r *RemoteExchange) resubscribe(c *collectorConn) {
+	r.mu.Lock()
+	s, ok := r.streams[c]
+	retry := ok && !s.ready
+	r.mu.Unlock()
+	if retry {
+		r.subscribeOn(c)
+	}
+}
+
+// detach drops collector c, failing over if it led the feed
+func (r *RemoteExchange) detach(c *collectorConn) {
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+	r.mu.Lock()
+	if _, ok := r.streams[c]; !ok {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.streams, c)
+	r.order = slices.DeleteFunc(r.order, func(o *collectorConn) bool { return o == c })
+	if r.leader == c {
+		// Snapshot requests are sent to the leader only
+		r.leader = nil
+		r.failPending("collector disconnected")
+	}
+	replay, generation := r.elect()
+	r.mu.Unlock()
+	r.forward(replay, generation)
+}
+
+// failPending answers every pending snapshot request with an error (must be
+// called with mutex locked)
+func (r *RemoteExchange) failPending(reason string) {
+	for id, answer := range r.pending {
+		answer <- Message{Type: MessageSnapshot, ID: id, Error: reason}
+		delete(r.pending, id)
+	}
+}
+
+// ready marks the feed streamed by c
+func (r *RemoteExchange) ready(c *collectorConn) {
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+	r.mu.Lock()
+	s, ok := r.streams[c]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	*s = collectorStream{ready: true, health: exchange.HealthStatus{Connected: true, LastPing: time.Now()}}
+	if c == r.leader && r.started {
+		// The leader's adapter restarted: its stream does not continue the book
+		r.restart()
+	}
+	replay, generation := r.elect()
+	r.mu.Unlock()
+	r.forward(replay, generation)
+}
+
+// failed marks the stream of c down after its adapter failed, and resubscribes
+// it after resubscribeDelay
+func (r *RemoteExchange) failed(c *collectorConn) {
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+	r.mu.Lock()
+	s, ok := r.streams[c]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	s.ready = false
+	s.backlog = nil
+	replay, generation := r.elect()
+	r.mu.Unlock()
+	r.forward(replay, generation)
+	time.AfterFunc(resubscribeDelay, func() { r.resubscribe(c) })
+}
+
+// setHealth records the health of the adapter of collector c
+func (r *RemoteExchange) setHealth(c *collectorConn, health exchange.HealthStatus) {
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+	r.mu.Lock()
+	s, ok := r.streams[c]
+	if !ok || !s.ready {
+		r.mu.Unlock()
+		return
+	}
+	if reconnects := health.Reconnects - s.health.Reconnects; reconnects > 0 {
+		// The adapter reconnected after a stall, breaking the continuity of its stream
+		if c == r.leader {
+			r.reconnects += reconnects - 1
+			r.restart()
+		} else {
+			s.lastFinal = 0
+			s.backlog = nil
+		}
+	}
+	s.health = health
+	replay, generation := r.elect()
+	r.mu.Unlock()
+	r.forward(replay, generation)
+}
+
+// answer hands a snapshot to its request
+func (r *RemoteExchange) answer(msg Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if answer, ok := r.pending[msg.ID]; ok {
+		delete(r.pending, msg.ID)
+		answer <- msg
+	}
+}
+
+// push forwards an update streamed by the leading collector c, or keeps it to
+// bridge a switchover if c is a standby. It blocks while the queue is full.
+func (r *RemoteExchange) push(c *collectorConn, update *Update) {
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+	r.mu.Lock()
+	s, ok := r.streams[c]
+	if !ok || !s.ready {
+		r.mu.Unlock()
+		return
+	}
+	if s.generation == 0 || update.Connection != s.connection {
+		// A new adapter connection: the stream's earlier updates do not lead up to it
+		r.generations++
+		s.connection = update.Connection
+		s.generation = r.generations
+		s.backlog = nil
+	}
+	s.lastFinal = update.FinalUpdateID
+	if c != r.leader {
+		if r.sequenced {
+			s.keep(update, r.lastFinal)
+		}
+		r.mu.Unlock()
+		return
+	}
+	if r.sequenced {
+		if !update.Snapshot && update.FinalUpdateID <= r.lastFinal {
+			// Already forwarded from the previous leader's stream
+			r.mu.Unlock()
+			return
+		}
+		r.lastFinal = update.FinalUpdateID
+	}
+	generation := s.generation
+	r.mu.Unlock()
+	r.forward([]*Update{update}, generation)
+}
+
+// forward queues updates of connection generation on the update channel (must be
+// called with sendMu locked)
+func (r *RemoteExchange) forward(updates []*Update, generation int64) {
+	if r.closed {
+		return
+	}
+	for _, update := range updates {
+		r.updates <- update.depthUpdate(r.config.Name, r.config.Symbol, generation)
+	}
+}
+
+// elect picks the stream to forward: the leader's while it is live, otherwise
+// the live stream of the longest-connected collector. It returns the updates to
+// replay to bridge a switchover and their connection generation (must be called
+// with mutex locked).
+func (r *RemoteExchange) elect() ([]*Update, int64) {
+	if r.streams[r.leader].live() {
+		return nil, 0
+	}
+	for _, c := range r.order {
+		if s := r.streams[c]; s.live() {
+			return r.switchTo(c), s.generation
+		}
+	}
+	return nil, 0
+}
+
+// switchTo makes c the leader, returning the updates of its backlog the book has
+// not seen (must be called with mutex locked)
+func (r *RemoteExchange) switchTo(c *collectorConn) []*Update {
+	previous := r.leader
+	r.leader = c
+	r.readyOnce.Do(func() { close(r.readyCh) })
+	s := r.streams[c]
+	backlog := s.backlog
+	s.backlog = nil
+	if !r.started {
+		r.started = true
+		return nil
+	}
+
+	r.failovers++
+	if previous != nil {
+		log.Printf("[%s] %s failing over from collector %s to %s", r.config.Name, r.config.Symbol, previous.id, c.id)
+	} else {
+		log.Printf("[%s] %s failing over to collector %s", r.config.Name, r.config.Symbol, c.id)
+	}
+	for len(backlog) > 0 && backlog[0].FinalUpdateID <= r.lastFinal {
+		backlog = backlog[1:]
+	}
+	if !r.continues(s, backlog) {
+		// The new stream does not continue the book built from the previous one
+		r.restart()
+		return nil
+	}
+	if len(backlog) > 0 {
+		r.lastFinal = backlog[len(backlog)-1].FinalUpdateID
+	}
+	return backlog
+}
+
+// continues reports whether stream s, whose unforwarded updates are backlog,
+// carries on from the latest update forwarded (must be called with mutex locked)
+func (r *RemoteExchange) continues(s *collectorStream, backlog []*Update) bool {
+	if !r.sequenced || r.lastFinal == 0 || s.lastFinal == 0 {
+		return false
+	}
+	if len(backlog) == 0 {
+		// The stream is level with or behind the updates forwarded
+		return true
+	}
+	first := backlog[0]
+	return (first.FirstUpdateID > 0 && first.FirstUpdateID <= r.lastFinal+1) || first.PrevUpdateID == r.lastFinal
+}
+
+// restart counts a break in the forwarded stream, after which the book resyncs
+// (must be called with mutex locked)
+func (r *RemoteExchange) restart() {
+	r.reconnects++
+	r.lastFinal = 0
+}
@@ -1,294 +1,49 @@
 package asterdex
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
 	"strings"
-	"sync/atomic"
-	"time"
 
-	"github.com/gorilla/websocket"
 	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/binancecompat"
 )
 
 // FuturesExchange implements the Exchange interface for Asterdex Futures
 type FuturesExchange struct {
-	symbol     string
-	wsURL      string
-	restURL    string
-	wsConn     *websocket.Conn
-	updateChan chan *exchange.DepthUpdate
-	done       chan struct{}
-	ctx        context.Context
-	cancel     context.CancelFunc
-	health     atomic.Value // stores exchange.HealthStatus
+	*binancecompat.Client
 }
 
-// Config holds configuration for Asterdex Futures exchange
+// Config holds configuration for Asterdex exchanges
 type Config struct {
-	Symbol string
+	Symbol        string
+	SnapshotDepth int    // Snapshot levels, 0 uses 1000
+	Instrument    string // Exchange symbol used verbatim instead of Symbol, empty uses Symbol
 }
 
 // NewFuturesExchange creates a new Asterdex Futures exchange instance
 func NewFuturesExchange(config Config) *FuturesExchange {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	symbol := strings.ToLower(config.Symbol)
-	wsURL := fmt.Sprintf("wss://fstream.asterdex.com/ws/%s@depth", symbol)
-	restURL := fmt.Sprintf("https://fapi.asterdex.com/fapi/v1/depth?symbol=%s&limit=1000", strings.ToUpper(config.Symbol))
-
-	ex := &FuturesExchange{
-		symbol:     config.Symbol,
-		wsURL:      wsURL,
-		restURL:    restURL,
-		updateChan: make(chan *exchange.DepthUpdate, 1000),
-		done:       make(chan struct{}),
-		ctx:        ctx,
-		cancel:     cancel,
-	}
-
-	ex.health.Store(exchange.HealthStatus{
-		Connected:    false,
-		LastPing:     time.Time{},
-		MessageCount: 0,
-		ErrorCount:   0,
-	})
-
-	return ex
-}
-
-// GetName returns the exchange name
-func (e *FuturesExchange) GetName() exchange.ExchangeName {
-	return exchange.Asterdexf
-}
-
-// GetSymbol returns the trading symbol
-func (e *FuturesExchange) GetSymbol() string {
-	return e.symbol
-}
-
-// Connect establishes WebSocket connection to Asterdex Futures
-func (e *FuturesExchange) Connect(ctx context.Context) error {
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, e.wsURL, nil)
-	if err != nil {
-		e.incrementErrorCount()
-		return fmt.Errorf("websocket connection failed: %w", err)
-	}
-
-	e.wsConn = conn
-	e.updateConnectionStatus(true)
-	log.Printf("[%s] WebSocket connected successfully", e.GetName())
-
-	go e.readMessages()
-
-	return nil
-}
-
-// Close closes the WebSocket connection
-func (e *FuturesExchange) Close() error {
-	if e.cancel != nil {
-		e.cancel()
-	}
-
-	if e.wsConn != nil {
-		select {
-		case <-e.done:
-		default:
-			close(e.done)
-		}
-
-		err := e.wsConn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-		if err != nil {
-			log.Printf("[%s] Error sending close message: %v", e.GetName(), err)
-		}
-
-		select {
-		case <-time.After(time.Second):
-		}
-
-		e.updateConnectionStatus(false)
-		return e.wsConn.Close()
-	}
-	return nil
-}
-
-// GetSnapshot fetches the initial orderbook snapshot via REST API
-func (e *FuturesExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
-	log.Printf("[%s] Fetching orderbook snapshot...", e.GetName())
-
-	req, err := http.NewRequestWithContext(ctx, "GET", e.restURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		e.incrementErrorCount()
-		return nil, fmt.Errorf("failed to get snapshot: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var asterdexSnapshot SnapshotResponse
-	if err := json.NewDecoder(resp.Body).Decode(&asterdexSnapshot); err != nil {
-		e.incrementErrorCount()
-		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
-	}
-
-	snapshot := e.convertSnapshot(&asterdexSnapshot)
-	return snapshot, nil
-}
-
-// Updates returns a channel that receives depth updates
-func (e *FuturesExchange) Updates() <-chan *exchange.DepthUpdate {
-	return e.updateChan
-}
-
-// IsConnected checks if the WebSocket connection is active
-func (e *FuturesExchange) IsConnected() bool {
-	return e.wsConn != nil
-}
-
-// Health returns connection health information
-func (e *FuturesExchange) Health() exchange.HealthStatus {
-	if status, ok := e.health.Load().(exchange.HealthStatus); ok {
-		return status
-	}
-	return exchange.HealthStatus{}
-}
-
-// readMessages continuously reads WebSocket messages
-func (e *FuturesExchange) readMessages() {
-	defer close(e.updateChan)
-	defer e.updateConnectionStatus(false)
-
-	for {
-		select {
-		case <-e.ctx.Done():
-			log.Printf("[%s] Context cancelled, stopping message reading", e.GetName())
-			return
-		case <-e.done:
-			return
-		default:
-			var msg DepthUpdate
-			if err := e.wsConn.ReadJSON(&msg); err != nil {
-				e.incrementErrorCount()
-				log.Printf("[%s] WebSocket read error: %v", e.GetName(), err)
-				return
-			}
-
-			e.incrementMessageCount()
-			e.updateLastPing()
-
-			canonicalUpdate := e.convertDepthUpdate(&msg)
-
-			select {
-			case e.updateChan <- canonicalUpdate:
-			case <-e.ctx.Done():
-				return
-			case <-e.done:
-				return
-			default:
-				log.Printf("[%s] Warning: update channel full, skipping update", e.GetName())
-			}
-		}
-	}
-}
-
-// convertSnapshot converts Asterdex snapshot to canonical format
-func (e *FuturesExchange) convertSnapshot(snapshot *SnapshotResponse) *exchange.Snapshot {
-	bids := make([]exchange.PriceLevel, len(snapshot.Bids))
-	for i, bid := range snapshot.Bids {
-		bids[i] = exchange.PriceLevel{
-			Price:    bid[0],
-			Quantity: bid[1],
-		}
-	}
-
-	asks := make([]exchange.PriceLevel, len(snapshot.Asks))
-	for i, ask := range snapshot.Asks {
-		asks[i] = exchange.PriceLevel{
-			Price:    ask[0],
-			Quantity: ask[1],
-		}
-	}
-
-	return &exchange.Snapshot{
-		Exchange:     e.GetName(),
-		Symbol:       e.symbol,
-		LastUpdateID: snapshot.LastUpdateID,
-		Bids:         bids,
-		Asks:         asks,
-		Timestamp:    time.Now(),
-	}
-}
-
-// convertDepthUpdate converts Asterdex depth update to canonical format
-func (e *FuturesExchange) convertDepthUpdate(update *DepthUpdate) *exchange.DepthUpdate {
-	bids := make([]exchange.PriceLevel, len(update.Bids))
-	for i, bid := range update.Bids {
-		bids[i] = exchange.PriceLevel{
-			Price:    bid[0],
-			Quantity: bid[1],
-		}
-	}
-
-	asks := make([]exchange.PriceLevel, len(update.Asks))
-	for i, ask := range update.Asks {
-		asks[i] = exchange.PriceLevel{
-			Price:    ask[0],
-			Quantity: ask[1],
-		}
+	if config.Instrument != "" {
+		config.Symbol = config.Instrument
 	}
+	symbol := strings.ToLower(config.Symbol)
 
-	return &exchange.DepthUpdate{
-		Exchange:      e.GetName(),
-		Symbol:        update.Symbol,
-		EventTime:     time.UnixMilli(update.EventTime),
-		FirstUpdateID: update.FirstUpdateID,
-		FinalUpdateID: update.FinalUpdateID,
-		PrevUpdateID:  update.PrevUpdateID,
-		Bids:          bids,
-		Asks:          asks,
+	return &FuturesExchange{
+		Client: binancecompat.NewClient(binancecompat.Config{
+			Name:            exchange.Asterdexf,
+			Symbol:          config.Symbol,
+			WSURL:           fmt.Sprintf("wss://fstream.asterdex.com/ws/%s@depth", symbol),
+			RestURL:         fmt.Sprintf("https://fapi.asterdex.com/fapi/v1/depth?symbol=%s&limit=%d", strings.ToUpper(config.Symbol), snapshotDepth(config.SnapshotDepth)),
+			ExchangeInfoURL: "https://fapi.asterdex.com/fapi/v1/exchangeInfo",
+			TimeURL:         "https://fapi.asterdex.com/fapi/v1/time",
+			OpenInterestURL: "https://fapi.asterdex.com/fapi/v1/openInterest?symbol=" + strings.ToUpper(config.Symbol),
+		}),
 	}
 }
 
-// updateConnectionStatus updates the connection status in health
-func (e *FuturesExchange) updateConnectionStatus(connected bool) {
-	status := e.Health()
-	status.Connected = connected
-	if !connected {
-		now := time.Now()
-		status.ReconnectTime = &now
+// snapshotDepth returns the configured snapshot depth or the 1000-level default
+func snapshotDepth(depth int) int {
+	if depth <= 0 {
+		return 1000
 	}
-	e.health.Store(status)
-}
-
-// incrementMessageCount increments the message count in health
-func (e *FuturesExchange) incrementMessageCount() {
-	status := e.Health()
-	status.MessageCount++
-	e.health.Store(status)
-}
-
-// incrementErrorCount increments the error count in health
-func (e *FuturesExchange) incrementErrorCount() {
-	status := e.Health()
-	status.ErrorCount++
-	e.health.Store(status)
-}
-
-// updateLastPing updates the last ping time in health
-func (e *FuturesExchange) updateLastPing() {
-	status := e.Health()
-	status.LastPing = time.Now()
-	e.health.Store(status)
+	return depth
 }
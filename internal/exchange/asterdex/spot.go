@@ -0,0 +1,33 @@
+package asterdex
+
+import (
+	"fmt"
+	"strings"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/binancecompat"
+)
+
+// SpotExchange implements the Exchange interface for Asterdex Spot
+type SpotExchange struct {
+	*binancecompat.Client
+}
+
+// NewSpotExchange creates a new Asterdex Spot exchange instance
+func NewSpotExchange(config Config) *SpotExchange {
+	if config.Instrument != "" {
+		config.Symbol = config.Instrument
+	}
+	symbol := strings.ToLower(config.Symbol)
+
+	return &SpotExchange{
+		Client: binancecompat.NewClient(binancecompat.Config{
+			Name:            exchange.Asterdex,
+			Symbol:          config.Symbol,
+			WSURL:           fmt.Sprintf("wss://sstream.asterdex.com/ws/%s@depth", symbol),
+			RestURL:         fmt.Sprintf("https://sapi.asterdex.com/api/v1/depth?symbol=%s&limit=%d", strings.ToUpper(config.Symbol), snapshotDepth(config.SnapshotDepth)),
+			ExchangeInfoURL: "https://sapi.asterdex.com/api/v1/exchangeInfo",
+			TimeURL:         "https://sapi.asterdex.com/api/v1/time",
+		}),
+	}
+}
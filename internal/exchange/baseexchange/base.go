@@ -0,0 +1,600 @@
+// Package baseexchange provides the connection lifecycle, health tracking and
+// channel plumbing shared by all exchange adapters. Adapters embed a *Base and
+// only implement subscription, message parsing and symbol mapping.
+package baseexchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"orderbook/internal/clock"
+	"orderbook/internal/exchange"
+	"orderbook/internal/tracing"
+	"orderbook/internal/transport"
+)
+
+// Handler implements the venue-specific parts of a WebSocket adapter
+type Handler interface {
+	// Subscribe sends subscription requests once the connection is established
+	Subscribe() error
+
+	// HandleMessage parses a raw WebSocket message and emits updates through the Base
+	HandleMessage(messageType int, data []byte) error
+}
+
+// Poller implements the venue-specific part of a REST polling adapter
+type Poller interface {
+	// Poll fetches the current book and emits it through the Base
+	Poll(ctx context.Context) error
+}
+
+// Config holds the connection settings of an adapter
+type Config struct {
+	Name         exchange.ExchangeName
+	Symbol       string        // Symbol reported by GetSymbol
+	WSURL        string        // WebSocket endpoint (WebSocket adapters)
+	Header       http.Header   // Optional handshake headers
+	Keepalive    Keepalive     // Client heartbeat required by the venue
+	PollInterval time.Duration // Polling interval (REST adapters)
+	StaleTimeout time.Duration // Force a reconnect after this long without messages, 0 disables
+	Clock        ClockSource   // Venue server time used to correct event times, nil disables
+	LocalClock   clock.Clock   // Time source of the watchdog, keepalive, polling and health stamps, nil uses the system clock
+}
+
+// Reconnect backoff bounds used after a stall
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Base implements the venue-independent parts of the Exchange interface
+type Base struct {
+	config     Config
+	clock      clock.Clock
+	handler    Handler
+	poller     Poller
+	wsConn     *websocket.Conn
+	writeMu    sync.Mutex // Serializes writes and guards wsConn swaps on reconnect
+	updateChan chan *exchange.DepthUpdate
+	done       chan struct{}
+	ctx        context.Context
+	cancel     context.CancelFunc
+	health     atomic.Value // stores exchange.HealthStatus
+	running    atomic.Bool
+
+	lastMessage atomic.Int64                 // Unix nanoseconds of the last received frame
+	connection  atomic.Int64                 // Generation of the current connection, incremented by every dial
+	connSeq     atomic.Int64                 // Updates stamped on the current connection
+	stalled     atomic.Bool                  // Set by the watchdog until the connection is replaced
+	clockOffset atomic.Int64                 // Venue clock minus local clock, in nanoseconds
+	trace       atomic.Pointer[tracing.Span] // Span of the message being handled, when sampled
+
+	snapshots SnapshotWaiter // Snapshot received over the stream
+}
+
+// New creates a Base for a WebSocket adapter
+func New(config Config, handler Handler) *Base {
+	b := newBase(config)
+	b.handler = handler
+	return b
+}
+
+// NewPolling creates a Base for a REST polling adapter
+func NewPolling(config Config, poller Poller) *Base {
+	b := newBase(config)
+	b.poller = poller
+	return b
+}
+
+func newBase(config Config) *Base {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if config.LocalClock == nil {
+		config.LocalClock = clock.Real
+	}
+	b := &Base{
+		config:     config,
+		clock:      config.LocalClock,
+		updateChan: make(chan *exchange.DepthUpdate, 1000),
+		done:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	b.health.Store(exchange.HealthStatus{
+		Connected:    false,
+		LastPing:     time.Time{},
+		MessageCount: 0,
+		ErrorCount:   0,
+	})
+
+	return b
+}
+
+// GetName returns the exchange name
+func (b *Base) GetName() exchange.ExchangeName {
+	return b.config.Name
+}
+
+// GetSymbol returns the trading symbol
+func (b *Base) GetSymbol() string {
+	return b.config.Symbol
+}
+
+// Connect establishes the WebSocket connection and subscribes, or starts the polling loop
+func (b *Base) Connect(ctx context.Context) error {
+	if b.poller != nil {
+		b.connection.Store(1)
+		b.SetConnected(true)
+		b.running.Store(true)
+		log.Printf("[%s] Starting REST polling (interval: %v)", b.config.Name, b.config.PollInterval)
+
+		go b.pollLoop()
+		b.startClockSync()
+		return nil
+	}
+
+	if err := b.dial(ctx); err != nil {
+		return err
+	}
+
+	b.running.Store(true)
+	go b.readMessages()
+	if b.config.Keepalive.Interval > 0 {
+		go b.pingLoop()
+	}
+	if b.config.StaleTimeout > 0 {
+		go b.watchdog()
+	}
+	b.startClockSync()
+
+	return nil
+}
+
+// startClockSync starts clock offset estimation when the venue has a clock source
+func (b *Base) startClockSync() {
+	if b.config.Clock != nil {
+		go b.clockLoop()
+	}
+}
+
+// SetStaleTimeout enables the heartbeat watchdog for WebSocket adapters.
+// It must be called before Connect and has no effect on polling adapters.
+func (b *Base) SetStaleTimeout(timeout time.Duration) {
+	b.config.StaleTimeout = timeout
+}
+
+// dial opens the WebSocket connection and sends the subscription
+func (b *Base) dial(ctx context.Context) error {
+	dialer := transport.Default().Dialer(10 * time.Second)
+
+	conn, _, err := dialer.DialContext(ctx, b.config.WSURL, b.config.Header)
+	if err != nil {
+		b.RecordError()
+		return fmt.Errorf("websocket connection failed: %w", err)
+	}
+
+	b.writeMu.Lock()
+	b.wsConn = conn
+	b.writeMu.Unlock()
+	b.connection.Add(1)
+	b.connSeq.Store(0)
+	b.lastMessage.Store(b.clock.Now().UnixNano())
+	b.SetConnected(true)
+	log.Printf("[%s] WebSocket connected successfully", b.config.Name)
+
+	if err := b.handler.Subscribe(); err != nil {
+		b.RecordError()
+		conn.Close()
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	return nil
+}
+
+// Close stops the adapter and closes the WebSocket connection gracefully
+func (b *Base) Close() error {
+	if b.cancel != nil {
+		b.cancel()
+	}
+
+	select {
+	case <-b.done:
+	default:
+		close(b.done)
+	}
+
+	if b.poller != nil {
+		b.SetConnected(false)
+		log.Printf("[%s] Polling stopped", b.config.Name)
+		return nil
+	}
+
+	conn := b.conn()
+	if conn != nil {
+		err := b.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		if err != nil {
+			log.Printf("[%s] Error sending close message: %v", b.config.Name, err)
+		}
+
+		select {
+		case <-time.After(time.Second):
+		}
+
+		b.SetConnected(false)
+		return conn.Close()
+	}
+	return nil
+}
+
+// conn returns the current WebSocket connection
+func (b *Base) conn() *websocket.Conn {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	return b.wsConn
+}
+
+// Updates returns a channel that receives depth updates
+func (b *Base) Updates() <-chan *exchange.DepthUpdate {
+	return b.updateChan
+}
+
+// IsConnected reports whether the adapter has been started
+func (b *Base) IsConnected() bool {
+	return b.running.Load()
+}
+
+// Health returns connection health information
+func (b *Base) Health() exchange.HealthStatus {
+	if status, ok := b.health.Load().(exchange.HealthStatus); ok {
+		return status
+	}
+	return exchange.HealthStatus{}
+}
+
+// Context returns the adapter lifetime context, cancelled by Close
+func (b *Base) Context() context.Context {
+	return b.ctx
+}
+
+// WriteJSON sends a JSON message, serialized with other writes
+func (b *Base) WriteJSON(v interface{}) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	if b.wsConn == nil {
+		return exchange.ErrConnClosed
+	}
+	return b.wsConn.WriteJSON(v)
+}
+
+// WriteMessage sends a raw message, serialized with other writes
+func (b *Base) WriteMessage(messageType int, data []byte) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	if b.wsConn == nil {
+		return exchange.ErrConnClosed
+	}
+	return b.wsConn.WriteMessage(messageType, data)
+}
+
+// Emit queues a depth update, dropping it if the channel is full.
+// It returns false once the adapter is shutting down.
+func (b *Base) Emit(update *exchange.DepthUpdate) bool {
+	b.Stamp(update)
+	update.Trace = b.trace.Load().Child("queue")
+
+	select {
+	case b.updateChan <- update:
+		return true
+	case <-b.ctx.Done():
+		return false
+	case <-b.done:
+		return false
+	default:
+		log.Printf("[%s] Warning: update channel full, skipping update", b.config.Name)
+		return true
+	}
+}
+
+// Stamp sets the local event time of an update and its provenance: the time its
+// message was received and its position on the current connection
+func (b *Base) Stamp(update *exchange.DepthUpdate) {
+	update.LocalEventTime = b.LocalTime(update.EventTime)
+	if b.poller != nil {
+		update.ReceiveTime = b.clock.Now()
+	} else {
+		update.ReceiveTime = time.Unix(0, b.lastMessage.Load())
+	}
+	update.Connection = b.connection.Load()
+	update.ConnectionSeq = b.connSeq.Add(1)
+}
+
+// resetSnapshot discards the stored snapshot so the next stream snapshot is kept
+func (b *Base) resetSnapshot() {
+	b.snapshots.Reset()
+}
+
+// SetSnapshot stores the initial snapshot received over the stream.
+// Only the first snapshot is kept; it returns false if one was already stored.
+func (b *Base) SetSnapshot(snapshot *exchange.Snapshot) bool {
+	return b.snapshots.Set(snapshot)
+}
+
+// FailSnapshot makes WaitForSnapshot return err until a snapshot arrives, for
+// venues that reject the subscription instead of sending a book
+func (b *Base) FailSnapshot(err error) {
+	b.snapshots.Fail(err)
+}
+
+// HasSnapshot reports whether the initial snapshot has been stored
+func (b *Base) HasSnapshot() bool {
+	return b.snapshots.Has()
+}
+
+// WaitForSnapshot blocks until SetSnapshot or FailSnapshot is called, the adapter
+// is closed or the timeout expires. A deadline on ctx replaces the timeout, so
+// callers can configure the wait.
+func (b *Base) WaitForSnapshot(ctx context.Context, timeout time.Duration) (*exchange.Snapshot, error) {
+	log.Printf("[%s] Waiting for orderbook snapshot from WebSocket...", b.config.Name)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	timer := b.clock.NewTimer(timeout)
+	defer timer.Stop()
+
+	// Closing the adapter ends the wait
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stop := context.AfterFunc(b.ctx, func() { cancel(exchange.ErrConnClosed) })
+	defer stop()
+
+	return b.snapshots.Wait(ctx, timer.C())
+}
+
+// readMessages continuously reads WebSocket messages and hands them to the handler,
+// reconnecting when the watchdog closes a stalled connection
+func (b *Base) readMessages() {
+	defer close(b.updateChan)
+	defer b.SetConnected(false)
+
+	for {
+		err := b.readConn(b.conn())
+		if err == nil {
+			return
+		}
+
+		if !b.stalled.Load() {
+			b.RecordError()
+			log.Printf("[%s] WebSocket read error: %v", b.config.Name, err)
+			return
+		}
+
+		if !b.reconnect() {
+			return
+		}
+	}
+}
+
+// readConn reads from conn until it fails. It returns nil when the adapter is closed.
+func (b *Base) readConn(conn *websocket.Conn) error {
+	for {
+		select {
+		case <-b.ctx.Done():
+			log.Printf("[%s] Context cancelled, stopping message reading", b.config.Name)
+			return nil
+		case <-b.done:
+			return nil
+		default:
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				select {
+				case <-b.ctx.Done():
+					return nil
+				case <-b.done:
+					return nil
+				default:
+					return err
+				}
+			}
+			b.lastMessage.Store(b.clock.Now().UnixNano())
+
+			// Updates emitted by the handler continue the message's trace
+			trace := tracing.Start("update")
+			trace.SetAttribute("exchange", string(b.config.Name))
+			parse := trace.Child("parse")
+			b.trace.Store(trace)
+			if err := b.handler.HandleMessage(messageType, message); err != nil {
+				log.Printf("[%s] Error handling message: %v", b.config.Name, err)
+			}
+			b.trace.Store(nil)
+			parse.End()
+		}
+	}
+}
+
+// reconnect replaces a stalled connection, retrying with exponential backoff.
+// It returns false if the adapter was closed before a connection succeeded.
+func (b *Base) reconnect() bool {
+	delay := minReconnectDelay
+	for {
+		b.resetSnapshot()
+		err := b.dial(b.ctx)
+		if err == nil {
+			b.stalled.Store(false)
+			b.recordReconnect()
+			log.Printf("[%s] Reconnected after stall", b.config.Name)
+			return true
+		}
+		log.Printf("[%s] Reconnect failed, retrying in %v: %v", b.config.Name, delay, err)
+
+		select {
+		case <-b.ctx.Done():
+			return false
+		case <-b.done:
+			return false
+		case <-b.clock.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// watchdog closes the connection when no message arrives within StaleTimeout,
+// which makes the read loop reconnect
+func (b *Base) watchdog() {
+	interval := b.config.StaleTimeout / 4
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	ticker := b.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-b.done:
+			return
+		case <-ticker.C():
+			if b.stalled.Load() {
+				continue
+			}
+
+			silence := b.clock.Since(time.Unix(0, b.lastMessage.Load()))
+			if silence < b.config.StaleTimeout {
+				continue
+			}
+
+			log.Printf("[%s] No messages for %v, forcing reconnect", b.config.Name, silence.Round(time.Millisecond))
+			b.stalled.Store(true)
+			b.recordStall()
+			if conn := b.conn(); conn != nil {
+				conn.Close()
+			}
+		}
+	}
+}
+
+// pingLoop sends keepalive messages at the configured interval
+func (b *Base) pingLoop() {
+	messageType, payload := b.config.Keepalive.message()
+	ticker := b.clock.NewTicker(b.config.Keepalive.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-b.done:
+			return
+		case <-ticker.C():
+			if err := b.WriteMessage(messageType, payload); err != nil {
+				log.Printf("[%s] Failed to send ping: %v", b.config.Name, err)
+			}
+		}
+	}
+}
+
+// pollLoop calls the poller at the configured interval
+func (b *Base) pollLoop() {
+	defer close(b.updateChan)
+	defer b.SetConnected(false)
+
+	ticker := b.clock.NewTicker(b.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			log.Printf("[%s] Context cancelled, stopping polling", b.config.Name)
+			return
+		case <-b.done:
+			return
+		case <-ticker.C():
+			if err := b.poller.Poll(b.ctx); err != nil {
+				log.Printf("[%s] Failed to poll: %v", b.config.Name, err)
+			}
+		}
+	}
+}
+
+// Now returns the current time of the adapter's clock, for stamping snapshots and events
+func (b *Base) Now() time.Time {
+	return b.clock.Now()
+}
+
+// SetConnected updates the connection status in health
+func (b *Base) SetConnected(connected bool) {
+	status := b.Health()
+	status.Connected = connected
+	if !connected {
+		now := b.clock.Now()
+		status.ReconnectTime = &now
+	}
+	b.health.Store(status)
+}
+
+// RecordMessage increments the message count and refreshes the last ping time
+func (b *Base) RecordMessage() {
+	status := b.Health()
+	status.MessageCount++
+	status.LastPing = b.clock.Now()
+	b.health.Store(status)
+}
+
+// recordStall increments the stall count and marks the connection as down
+func (b *Base) recordStall() {
+	status := b.Health()
+	status.Stalls++
+	status.Connected = false
+	now := b.clock.Now()
+	status.ReconnectTime = &now
+	b.health.Store(status)
+}
+
+// recordClockOffset stores the estimated clock offset in health
+func (b *Base) recordClockOffset(offset time.Duration) {
+	status := b.Health()
+	status.ClockOffset = offset
+	b.health.Store(status)
+}
+
+// recordReconnect increments the reconnect count in health
+func (b *Base) recordReconnect() {
+	status := b.Health()
+	status.Reconnects++
+	b.health.Store(status)
+}
+
+// RecordError increments the error count in health
+func (b *Base) RecordError() {
+	status := b.Health()
+	status.ErrorCount++
+	b.health.Store(status)
+}
+
+// ConvertLevels converts [price, quantity] pairs to canonical price levels,
+// skipping malformed entries
+func ConvertLevels(levels [][]string) []exchange.PriceLevel {
+	result := make([]exchange.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		result = append(result, exchange.PriceLevel{
+			Price:    level[0],
+			Quantity: level[1],
+		})
+	}
+	return result
+}
@@ -0,0 +1,340 @@
+package baseexchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"orderbook/internal/clock"
+	"orderbook/internal/exchange"
+)
+
+// echoHandler emits one update per text message and stores the first as snapshot
+type echoHandler struct {
+	base       *Base
+	subscribed bool
+}
+
+func (h *echoHandler) Subscribe() error {
+	h.subscribed = true
+	return h.base.WriteJSON(map[string]string{"op": "subscribe"})
+}
+
+func (h *echoHandler) HandleMessage(messageType int, data []byte) error {
+	h.base.RecordMessage()
+	h.base.SetSnapshot(&exchange.Snapshot{Exchange: h.base.GetName(), LastUpdateID: 1})
+	h.base.Emit(&exchange.DepthUpdate{Exchange: h.base.GetName(), Symbol: string(data)})
+	return nil
+}
+
+func newTestServer(t *testing.T, messages []string) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade() failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// Wait for the subscription before streaming
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+		for _, msg := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+				return
+			}
+		}
+		conn.ReadMessage() // Block until the client closes
+	}))
+}
+
+func TestBaseLifecycle(t *testing.T) {
+	messages := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}
+	server := newTestServer(t, messages)
+	defer server.Close()
+
+	handler := &echoHandler{}
+	handler.base = New(Config{
+		Name:   exchange.Binance,
+		Symbol: "BTCUSDT",
+		WSURL:  "ws" + strings.TrimPrefix(server.URL, "http"),
+	}, handler)
+	b := handler.base
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := b.Connect(ctx); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	if !handler.subscribed || !b.IsConnected() {
+		t.Fatalf("Expected subscribed and connected adapter")
+	}
+
+	snapshot, err := b.WaitForSnapshot(ctx, time.Second)
+	if err != nil {
+		t.Fatalf("WaitForSnapshot() failed: %v", err)
+	}
+	if snapshot.LastUpdateID != 1 {
+		t.Errorf("Expected snapshot LastUpdateID 1, got %d", snapshot.LastUpdateID)
+	}
+
+	for _, expected := range messages {
+		select {
+		case update := <-b.Updates():
+			if update.Symbol != expected {
+				t.Errorf("Expected update %s, got %s", expected, update.Symbol)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for update %s", expected)
+		}
+	}
+
+	health := b.Health()
+	if !health.Connected || health.MessageCount != int64(len(messages)) {
+		t.Errorf("Expected connected with %d messages, got %+v", len(messages), health)
+	}
+
+	if err := b.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+	if b.Health().Connected {
+		t.Errorf("Expected disconnected after Close()")
+	}
+}
+
+func TestWaitForSnapshotFails(t *testing.T) {
+	b := New(Config{Name: exchange.Kraken, Symbol: "BTC/XYZ"}, &echoHandler{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	rejected := fmt.Errorf("%w: Currency pair not supported", exchange.ErrSymbolNotFound)
+	b.FailSnapshot(rejected)
+	if _, err := b.WaitForSnapshot(ctx, time.Second); !errors.Is(err, exchange.ErrSymbolNotFound) {
+		t.Errorf("Expected the subscription error, got %v", err)
+	}
+
+	// A snapshot arriving later replaces the failure
+	b.SetSnapshot(&exchange.Snapshot{LastUpdateID: 7})
+	if snapshot, err := b.WaitForSnapshot(ctx, time.Second); err != nil || snapshot.LastUpdateID != 7 {
+		t.Errorf("Expected snapshot 7, got %v, %v", snapshot, err)
+	}
+
+	b.resetSnapshot()
+	b.Close()
+	if _, err := b.WaitForSnapshot(ctx, time.Second); !errors.Is(err, exchange.ErrConnClosed) {
+		t.Errorf("Expected ErrConnClosed after Close, got %v", err)
+	}
+}
+
+func TestConvertLevels(t *testing.T) {
+	levels := ConvertLevels([][]string{{"100.5", "2"}, {"bad"}, {"99", "0", "extra"}})
+
+	if len(levels) != 2 {
+		t.Fatalf("Expected 2 levels, got %d", len(levels))
+	}
+	if levels[0].Price != "100.5" || levels[0].Quantity != "2" {
+		t.Errorf("Expected 100.5@2, got %s@%s", levels[0].Price, levels[0].Quantity)
+	}
+	if levels[1].Price != "99" || levels[1].Quantity != "0" {
+		t.Errorf("Expected 99@0, got %s@%s", levels[1].Price, levels[1].Quantity)
+	}
+}
+
+func TestBaseReconnectsAfterStall(t *testing.T) {
+	// Each connection sends one message and then goes silent without closing
+	server := newTestServer(t, []string{"BTCUSDT"})
+	defer server.Close()
+
+	handler := &echoHandler{}
+	handler.base = New(Config{
+		Name:         exchange.Binance,
+		Symbol:       "BTCUSDT",
+		WSURL:        "ws" + strings.TrimPrefix(server.URL, "http"),
+		StaleTimeout: 200 * time.Millisecond,
+	}, handler)
+	b := handler.base
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := b.Connect(ctx); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+
+	// One update per connection: the initial one and the one after reconnecting
+	for i := 0; i < 2; i++ {
+		select {
+		case update := <-b.Updates():
+			if update.Connection != int64(i+1) || update.ConnectionSeq != 1 || update.ReceiveTime.IsZero() {
+				t.Errorf("Expected the first update of connection %d, got connection %d seq %d received %v",
+					i+1, update.Connection, update.ConnectionSeq, update.ReceiveTime)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for update %d", i+1)
+		}
+	}
+
+	health := b.Health()
+	if health.Stalls < 1 || health.Reconnects < 1 {
+		t.Errorf("Expected at least one stall and reconnect, got %+v", health)
+	}
+	if !b.HasSnapshot() {
+		t.Errorf("Expected snapshot from the new connection")
+	}
+}
+
+func TestWatchdogFollowsClock(t *testing.T) {
+	server := newTestServer(t, []string{"BTCUSDT"})
+	defer server.Close()
+
+	fake := clock.NewFake(time.Unix(1700000000, 0))
+	handler := &echoHandler{}
+	handler.base = New(Config{
+		Name:         exchange.Binance,
+		Symbol:       "BTCUSDT",
+		WSURL:        "ws" + strings.TrimPrefix(server.URL, "http"),
+		StaleTimeout: time.Minute,
+		LocalClock:   fake,
+	}, handler)
+	b := handler.base
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := b.Connect(ctx); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	select {
+	case <-b.Updates():
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for the first update")
+	}
+
+	for fake.Waiters() == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("Expected the watchdog to wait on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Silence on the fake clock only counts once the clock is advanced
+	fake.Advance(59 * time.Second)
+	time.Sleep(50 * time.Millisecond)
+	if stalls := b.Health().Stalls; stalls != 0 {
+		t.Errorf("Expected no stall before StaleTimeout, got %d", stalls)
+	}
+
+	fake.Advance(time.Second)
+	select {
+	case <-b.Updates():
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for the update after reconnecting")
+	}
+	if health := b.Health(); health.Stalls != 1 || health.Reconnects != 1 {
+		t.Errorf("Expected one stall and reconnect, got %+v", health)
+	}
+}
+
+func TestKeepaliveSendsHeartbeat(t *testing.T) {
+	received := make(chan string, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade() failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		conn.ReadMessage() // Subscription
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		received <- string(payload)
+		conn.ReadMessage() // Block until the client closes
+	}))
+	defer server.Close()
+
+	handler := &echoHandler{}
+	handler.base = New(Config{
+		Name:      exchange.Bybit,
+		WSURL:     "ws" + strings.TrimPrefix(server.URL, "http"),
+		Keepalive: TextPing(50*time.Millisecond, `{"op":"ping"}`),
+	}, handler)
+	defer handler.base.Close()
+
+	if err := handler.base.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+
+	select {
+	case payload := <-received:
+		if payload != `{"op":"ping"}` {
+			t.Errorf("Expected {\"op\":\"ping\"}, got %s", payload)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for heartbeat")
+	}
+}
+
+func TestEstimateClockOffset(t *testing.T) {
+	skew := 1500 * time.Millisecond
+	source := func(ctx context.Context) (time.Time, error) {
+		return time.Now().Add(skew), nil
+	}
+
+	offset, _, err := estimateClockOffset(context.Background(), source)
+	if err != nil {
+		t.Fatalf("estimateClockOffset() failed: %v", err)
+	}
+	if diff := offset - skew; diff < -50*time.Millisecond || diff > 50*time.Millisecond {
+		t.Errorf("Expected offset near %v, got %v", skew, offset)
+	}
+
+	b := newBase(Config{Name: exchange.Binance})
+	b.clockOffset.Store(int64(offset))
+	venueTime := time.UnixMilli(1700000000000)
+	if local := b.LocalTime(venueTime); local.Sub(venueTime) != -offset {
+		t.Errorf("Expected local time shifted by %v, got %v", -offset, local.Sub(venueTime))
+	}
+	if !b.LocalTime(time.Time{}).IsZero() {
+		t.Errorf("Expected zero time to stay zero")
+	}
+}
+
+func TestUnixMilliField(t *testing.T) {
+	parse := UnixMilliField("serverTime")
+	tests := []struct {
+		body    string
+		wantErr bool
+	}{
+		{`{"serverTime":1700000000000}`, false},
+		{`{"serverTime":"1700000000000"}`, false},
+		{`{"other":1}`, true},
+		{`[]`, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parse([]byte(tt.body))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Expected error %v, got %v", tt.body, tt.wantErr, err)
+			continue
+		}
+		if !tt.wantErr && got.UnixMilli() != 1700000000000 {
+			t.Errorf("%s: Expected 1700000000000, got %d", tt.body, got.UnixMilli())
+		}
+	}
+}
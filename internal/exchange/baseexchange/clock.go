@@ -0,0 +1,126 @@
+package baseexchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/transport"
+)
+
+// ClockSource returns the current venue server time
+type ClockSource func(ctx context.Context) (time.Time, error)
+
+// Clock offset estimation settings
+const (
+	clockSamples        = 5               // Requests per estimate; the lowest-RTT sample wins
+	clockResyncPeriod   = 5 * time.Minute // Interval between estimates
+	clockRequestTimeout = 5 * time.Second
+)
+
+// RESTClock builds a ClockSource that requests url and extracts the server time with parse
+func RESTClock(url string, parse func(body []byte) (time.Time, error)) ClockSource {
+	return func(ctx context.Context) (time.Time, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := transport.Default().Client(clockRequestTimeout).Do(req)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get server time: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return time.Time{}, fmt.Errorf("failed to get server time: %w", exchange.StatusError(resp))
+		}
+
+		var body json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return time.Time{}, fmt.Errorf("failed to decode server time: %w", err)
+		}
+		return parse(body)
+	}
+}
+
+// UnixMilliField parses a JSON object whose field holds the server time in
+// milliseconds, either as a number or a string (e.g., {"serverTime":1700000000000})
+func UnixMilliField(field string) func(body []byte) (time.Time, error) {
+	return func(body []byte) (time.Time, error) {
+		var fields map[string]json.Number
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return time.Time{}, fmt.Errorf("failed to decode server time: %w", err)
+		}
+
+		ms, err := fields[field].Int64()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid server time field %s: %w", field, err)
+		}
+		return time.UnixMilli(ms), nil
+	}
+}
+
+// estimateClockOffset samples the venue clock and returns its offset from the
+// local clock (venue minus local), using the sample with the lowest round trip
+func estimateClockOffset(ctx context.Context, source ClockSource) (offset, rtt time.Duration, err error) {
+	rtt = -1
+	for i := 0; i < clockSamples; i++ {
+		sent := time.Now()
+		serverTime, sampleErr := source(ctx)
+		received := time.Now()
+		if sampleErr != nil {
+			err = sampleErr
+			continue
+		}
+
+		sampleRTT := received.Sub(sent)
+		if rtt < 0 || sampleRTT < rtt {
+			rtt = sampleRTT
+			// The server time is assumed to be read halfway through the round trip
+			offset = serverTime.Sub(sent.Add(sampleRTT / 2))
+		}
+	}
+
+	if rtt < 0 {
+		return 0, 0, err
+	}
+	return offset, rtt, nil
+}
+
+// clockLoop keeps the venue clock offset up to date
+func (b *Base) clockLoop() {
+	ticker := b.clock.NewTicker(clockResyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		offset, rtt, err := estimateClockOffset(b.ctx, b.config.Clock)
+		if err != nil {
+			log.Printf("[%s] Failed to estimate clock offset: %v", b.config.Name, err)
+		} else {
+			b.clockOffset.Store(int64(offset))
+			b.recordClockOffset(offset)
+			log.Printf("[%s] Clock offset %v (rtt %v)", b.config.Name, offset.Round(time.Millisecond), rtt.Round(time.Millisecond))
+		}
+
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-b.done:
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// LocalTime converts a venue timestamp to the local clock using the estimated offset
+func (b *Base) LocalTime(venueTime time.Time) time.Time {
+	if venueTime.IsZero() {
+		return venueTime
+	}
+	return venueTime.Add(-time.Duration(b.clockOffset.Load()))
+}
@@ -0,0 +1,32 @@
+package baseexchange
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"orderbook/internal/exchange"
+)
+
+// InverseQtyDecimals is the precision of base quantities normalized from inverse contracts
+const InverseQtyDecimals = 8
+
+// NormalizeInverse converts inverse contract quantities to base units in place.
+// A contract is worth a fixed notional in the quote currency, so the base
+// quantity of a level is contracts * contractSize / price.
+func NormalizeInverse(levels []exchange.PriceLevel, contractSize decimal.Decimal) error {
+	for i, level := range levels {
+		price, err := decimal.NewFromString(level.Price)
+		if err != nil {
+			return fmt.Errorf("invalid price %q: %w", level.Price, err)
+		}
+		contracts, err := decimal.NewFromString(level.Quantity)
+		if err != nil {
+			return fmt.Errorf("invalid quantity %q: %w", level.Quantity, err)
+		}
+		if contracts.IsZero() || !price.IsPositive() {
+			continue
+		}
+		levels[i].Quantity = contracts.Mul(contractSize).Div(price).StringFixed(InverseQtyDecimals)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package baseexchange
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Keepalive describes the client heartbeat a venue requires to keep an idle
+// connection open. The zero value sends nothing.
+type Keepalive struct {
+	Interval time.Duration // Time between heartbeats, 0 disables
+	Payload  []byte        // Application-level text heartbeat, nil sends WebSocket ping frames
+}
+
+// PingFrames sends WebSocket ping control frames (e.g., Coinbase)
+func PingFrames(interval time.Duration) Keepalive {
+	return Keepalive{Interval: interval}
+}
+
+// TextPing sends an application-level text heartbeat (e.g., Bybit `{"op":"ping"}`)
+func TextPing(interval time.Duration, payload string) Keepalive {
+	return Keepalive{Interval: interval, Payload: []byte(payload)}
+}
+
+// message returns the WebSocket message type and payload of one heartbeat
+func (k Keepalive) message() (int, []byte) {
+	if k.Payload == nil {
+		return websocket.PingMessage, nil
+	}
+	return websocket.TextMessage, k.Payload
+}
@@ -0,0 +1,61 @@
+package baseexchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/transport"
+)
+
+// restTimeout bounds each REST request of GetJSON and PostJSON
+const restTimeout = 10 * time.Second
+
+// GetJSON requests url and decodes the JSON response into v
+func GetJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	return doJSON(req, v)
+}
+
+// PostJSON posts body encoded as JSON to url and decodes the JSON response into v
+func PostJSON(ctx context.Context, url string, body, v interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doJSON(req, v)
+}
+
+// doJSON sends req and decodes the JSON response into v. Unexpected statuses
+// are converted with exchange.StatusError.
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := transport.Default().Client(restTimeout).Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return exchange.StatusError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
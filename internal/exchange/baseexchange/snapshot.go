@@ -0,0 +1,103 @@
+package baseexchange
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+// SnapshotWaiter holds the snapshot an adapter receives over its stream and
+// wakes the callers waiting for it. The zero value is ready to use.
+type SnapshotWaiter struct {
+	mu       sync.Mutex
+	snapshot *exchange.Snapshot
+	err      error         // Set by Fail until a snapshot arrives
+	ready    chan struct{} // Closed once snapshot or err is set
+}
+
+// readyChan returns the channel of the current wait (must be called with mutex locked)
+func (w *SnapshotWaiter) readyChan() chan struct{} {
+	if w.ready == nil {
+		w.ready = make(chan struct{})
+	}
+	return w.ready
+}
+
+// Set stores the snapshot and wakes the waiters. Only the first snapshot is
+// kept; it returns false if one was already stored.
+func (w *SnapshotWaiter) Set(snapshot *exchange.Snapshot) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.snapshot != nil {
+		return false
+	}
+	ready := w.readyChan()
+	w.snapshot = snapshot
+	if w.err != nil {
+		// Ready was closed by Fail
+		w.err = nil
+	} else {
+		close(ready)
+	}
+	return true
+}
+
+// Fail makes waiters return err until a snapshot arrives, for venues that
+// reject the subscription instead of sending a book
+func (w *SnapshotWaiter) Fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.snapshot != nil || w.err != nil {
+		return
+	}
+	w.err = err
+	close(w.readyChan())
+}
+
+// Reset discards the stored snapshot or failure so the next snapshot is kept
+func (w *SnapshotWaiter) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.snapshot != nil || w.err != nil {
+		w.snapshot = nil
+		w.err = nil
+		w.ready = make(chan struct{})
+	}
+}
+
+// Has reports whether a snapshot is stored
+func (w *SnapshotWaiter) Has() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.snapshot != nil
+}
+
+// Wait blocks until a snapshot or failure is stored, ctx is done or timeout
+// fires. A Reset between the wake-up and the read keeps waiting for the next one.
+func (w *SnapshotWaiter) Wait(ctx context.Context, timeout <-chan time.Time) (*exchange.Snapshot, error) {
+	for {
+		w.mu.Lock()
+		ready := w.readyChan()
+		w.mu.Unlock()
+
+		select {
+		case <-ready:
+			w.mu.Lock()
+			snapshot, err := w.snapshot, w.err
+			w.mu.Unlock()
+			if snapshot != nil || err != nil {
+				return snapshot, err
+			}
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled while waiting for snapshot: %w", context.Cause(ctx))
+		case <-timeout:
+			return nil, fmt.Errorf("timeout waiting for snapshot")
+		}
+	}
+}
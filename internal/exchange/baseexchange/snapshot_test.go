@@ -0,0 +1,54 @@
+package baseexchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+func TestSnapshotWaiter(t *testing.T) {
+	var w SnapshotWaiter
+	ctx := context.Background()
+
+	if _, err := w.Wait(ctx, time.After(10*time.Millisecond)); err == nil {
+		t.Errorf("Expected a timeout without a snapshot")
+	}
+
+	// Waiters blocked before the snapshot arrives are woken by Set
+	results := make(chan *exchange.Snapshot, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			snapshot, _ := w.Wait(ctx, nil)
+			results <- snapshot
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	if !w.Set(&exchange.Snapshot{LastUpdateID: 1}) {
+		t.Fatal("Expected the first snapshot to be stored")
+	}
+	if w.Set(&exchange.Snapshot{LastUpdateID: 2}) {
+		t.Errorf("Expected later snapshots to be ignored")
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case snapshot := <-results:
+			if snapshot == nil || snapshot.LastUpdateID != 1 {
+				t.Errorf("Expected snapshot 1, got %v", snapshot)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected Set to wake the waiters")
+		}
+	}
+
+	// After a reset the next snapshot is kept
+	w.Reset()
+	if w.Has() {
+		t.Errorf("Expected no snapshot after Reset")
+	}
+	w.Set(&exchange.Snapshot{LastUpdateID: 3})
+	if snapshot, err := w.Wait(ctx, nil); err != nil || snapshot.LastUpdateID != 3 {
+		t.Errorf("Expected snapshot 3, got %v, %v", snapshot, err)
+	}
+}
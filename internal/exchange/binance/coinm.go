@@ -0,0 +1,62 @@
+package binance
+
+import (
+	"fmt"
+	"strings"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/binancecompat"
+)
+
+// coinMTimeURL is the COIN-margined futures server time endpoint
+const coinMTimeURL = "https://dapi.binance.com/dapi/v1/time"
+
+// CoinMExchange implements the Exchange interface for Binance COIN-margined
+// (inverse) futures. Quantities are normalized from contracts to base units.
+type CoinMExchange struct {
+	*binancecompat.Client
+}
+
+// NewCoinMExchange creates a new Binance COIN-margined futures exchange instance
+func NewCoinMExchange(config Config) *CoinMExchange {
+	symbol := convertToCoinMSymbol(config.Symbol)
+	if config.Instrument != "" {
+		symbol = strings.ToUpper(config.Instrument)
+	}
+
+	return &CoinMExchange{
+		Client: binancecompat.NewClient(binancecompat.Config{
+			Name:            exchange.Binancefc,
+			Symbol:          symbol,
+			WSURL:           "wss://dstream.binance.com/stream?streams=" + strings.ToLower(symbol) + config.streamSuffix(futuresStreamSuffix(config.UpdateSpeed)),
+			RestURL:         fmt.Sprintf("https://dapi.binance.com/dapi/v1/depth?symbol=%s&limit=%d", symbol, futuresSnapshotDepth(config.SnapshotDepth)),
+			ExchangeInfoURL: "https://dapi.binance.com/dapi/v1/exchangeInfo",
+			TimeURL:         coinMTimeURL,
+			CombinedStream:  true,
+			Inverse:         true,
+			OpenInterestURL: "https://dapi.binance.com/dapi/v1/openInterest?symbol=" + symbol,
+			MarkPriceURL:    "https://dapi.binance.com/dapi/v1/premiumIndex?symbol=" + symbol,
+			BookTicker:      config.TopOfBook,
+		}),
+	}
+}
+
+// QuoteCurrency returns USD, the settlement notional of inverse contracts
+func (e *CoinMExchange) QuoteCurrency() string {
+	return "USD"
+}
+
+// convertToCoinMSymbol converts various symbol formats to a COIN-margined contract
+// Examples: BTCUSDT -> BTCUSD_PERP, BTCUSD -> BTCUSD_PERP, BTCUSD_250926 -> BTCUSD_250926
+func convertToCoinMSymbol(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if strings.Contains(symbol, "_") {
+		return symbol
+	}
+	for _, quote := range []string{"USDT", "USDC", "USD"} {
+		if base, ok := strings.CutSuffix(symbol, quote); ok {
+			return base + "USD_PERP"
+		}
+	}
+	return symbol + "USD_PERP"
+}
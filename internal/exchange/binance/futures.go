@@ -1,294 +1,96 @@
 package binance
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
 	"strings"
-	"sync/atomic"
-	"time"
 
-	"github.com/gorilla/websocket"
 	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/binancecompat"
 )
 
+// futuresTimeURL is the futures server time endpoint
+const futuresTimeURL = "https://fapi.binance.com/fapi/v1/time"
+
 // FuturesExchange implements the Exchange interface for Binance Futures
 type FuturesExchange struct {
-	symbol     string
-	wsURL      string
-	restURL    string
-	wsConn     *websocket.Conn
-	updateChan chan *exchange.DepthUpdate
-	done       chan struct{}
-	ctx        context.Context
-	cancel     context.CancelFunc
-	health     atomic.Value // stores exchange.HealthStatus
+	*binancecompat.Client
 }
 
-// Config holds configuration for Binance Futures exchange
+// Config holds configuration for Binance exchanges
 type Config struct {
-	Symbol string
+	Symbol        string
+	UpdateSpeed   string // Depth stream speed: 100ms (default) or 1000ms on spot, 100ms, 250ms (default) or 500ms on futures
+	SnapshotDepth int    // Snapshot levels, 0 uses 5000 on spot and 1000 on futures
+	Instrument    string // Exchange symbol used verbatim instead of Symbol (e.g., BTCUSDT_250627), empty uses Symbol
+	TopOfBook     bool   // Stream the best bid and ask only (bookTicker) instead of the diff depth
 }
 
-// NewFuturesExchange creates a new Binance Futures exchange instance
-func NewFuturesExchange(config Config) *FuturesExchange {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	symbol := strings.ToLower(config.Symbol)
-	wsURL := fmt.Sprintf("wss://fstream.binance.com/stream?streams=%s@depth", symbol)
-	restURL := fmt.Sprintf("https://fapi.binance.com/fapi/v1/depth?symbol=%s&limit=1000", strings.ToUpper(config.Symbol))
-
-	ex := &FuturesExchange{
-		symbol:     config.Symbol,
-		wsURL:      wsURL,
-		restURL:    restURL,
-		updateChan: make(chan *exchange.DepthUpdate, 1000),
-		done:       make(chan struct{}),
-		ctx:        ctx,
-		cancel:     cancel,
-	}
-
-	ex.health.Store(exchange.HealthStatus{
-		Connected:    false,
-		LastPing:     time.Time{},
-		MessageCount: 0,
-		ErrorCount:   0,
-	})
-
-	return ex
-}
-
-// GetName returns the exchange name
-func (e *FuturesExchange) GetName() exchange.ExchangeName {
-	return exchange.Binancef
-}
-
-// GetSymbol returns the trading symbol
-func (e *FuturesExchange) GetSymbol() string {
-	return e.symbol
-}
-
-// Connect establishes WebSocket connection to Binance Futures
-func (e *FuturesExchange) Connect(ctx context.Context) error {
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
+// bookTickerSuffix is the stream suffix of the best bid and ask
+const bookTickerSuffix = "@bookTicker"
 
-	conn, _, err := dialer.DialContext(ctx, e.wsURL, nil)
-	if err != nil {
-		e.incrementErrorCount()
-		return fmt.Errorf("websocket connection failed: %w", err)
-	}
-
-	e.wsConn = conn
-	e.updateConnectionStatus(true)
-	log.Printf("[%s] WebSocket connected successfully", e.GetName())
-
-	go e.readMessages()
-
-	return nil
-}
-
-// Close closes the WebSocket connection
-func (e *FuturesExchange) Close() error {
-	if e.cancel != nil {
-		e.cancel()
-	}
-
-	if e.wsConn != nil {
-		select {
-		case <-e.done:
-		default:
-			close(e.done)
-		}
-
-		err := e.wsConn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-		if err != nil {
-			log.Printf("[%s] Error sending close message: %v", e.GetName(), err)
-		}
-
-		select {
-		case <-time.After(time.Second):
-		}
-
-		e.updateConnectionStatus(false)
-		return e.wsConn.Close()
-	}
-	return nil
-}
-
-// GetSnapshot fetches the initial orderbook snapshot via REST API
-func (e *FuturesExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
-	log.Printf("[%s] Fetching orderbook snapshot...", e.GetName())
-
-	req, err := http.NewRequestWithContext(ctx, "GET", e.restURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		e.incrementErrorCount()
-		return nil, fmt.Errorf("failed to get snapshot: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var binanceSnapshot SnapshotResponse
-	if err := json.NewDecoder(resp.Body).Decode(&binanceSnapshot); err != nil {
-		e.incrementErrorCount()
-		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
-	}
-
-	snapshot := e.convertSnapshot(&binanceSnapshot)
-	return snapshot, nil
-}
-
-// Updates returns a channel that receives depth updates
-func (e *FuturesExchange) Updates() <-chan *exchange.DepthUpdate {
-	return e.updateChan
-}
-
-// IsConnected checks if the WebSocket connection is active
-func (e *FuturesExchange) IsConnected() bool {
-	return e.wsConn != nil
-}
+// Futures depth stream update speeds
+const (
+	UpdateSpeed250ms = "250ms"
+	UpdateSpeed500ms = "500ms"
+)
 
-// Health returns connection health information
-func (e *FuturesExchange) Health() exchange.HealthStatus {
-	if status, ok := e.health.Load().(exchange.HealthStatus); ok {
-		return status
+// NewFuturesExchange creates a new Binance Futures exchange instance
+func NewFuturesExchange(config Config) *FuturesExchange {
+	if config.Instrument != "" {
+		config.Symbol = config.Instrument
 	}
-	return exchange.HealthStatus{}
-}
-
-// readMessages continuously reads WebSocket messages
-func (e *FuturesExchange) readMessages() {
-	defer close(e.updateChan)
-	defer e.updateConnectionStatus(false)
-
-	for {
-		select {
-		case <-e.ctx.Done():
-			log.Printf("[%s] Context cancelled, stopping message reading", e.GetName())
-			return
-		case <-e.done:
-			return
-		default:
-			var msg WSMessage
-			if err := e.wsConn.ReadJSON(&msg); err != nil {
-				e.incrementErrorCount()
-				log.Printf("[%s] WebSocket read error: %v", e.GetName(), err)
-				return
-			}
-
-			e.incrementMessageCount()
-			e.updateLastPing()
-
-			canonicalUpdate := e.convertDepthUpdate(&msg.Data)
+	symbol := strings.ToLower(config.Symbol)
 
-			select {
-			case e.updateChan <- canonicalUpdate:
-			case <-e.ctx.Done():
-				return
-			case <-e.done:
-				return
-			default:
-				log.Printf("[%s] Warning: update channel full, skipping update", e.GetName())
-			}
-		}
+	return &FuturesExchange{
+		Client: binancecompat.NewClient(binancecompat.Config{
+			Name:            exchange.Binancef,
+			Symbol:          config.Symbol,
+			WSURL:           "wss://fstream.binance.com/stream?streams=" + symbol + config.streamSuffix(futuresStreamSuffix(config.UpdateSpeed)),
+			RestURL:         fmt.Sprintf("https://fapi.binance.com/fapi/v1/depth?symbol=%s&limit=%d", strings.ToUpper(config.Symbol), futuresSnapshotDepth(config.SnapshotDepth)),
+			ExchangeInfoURL: "https://fapi.binance.com/fapi/v1/exchangeInfo",
+			TimeURL:         futuresTimeURL,
+			CombinedStream:  true,
+			OpenInterestURL: "https://fapi.binance.com/fapi/v1/openInterest?symbol=" + strings.ToUpper(config.Symbol),
+			BookTicker:      config.TopOfBook,
+		}),
 	}
 }
 
-// convertSnapshot converts Binance snapshot to canonical format
-func (e *FuturesExchange) convertSnapshot(snapshot *SnapshotResponse) *exchange.Snapshot {
-	bids := make([]exchange.PriceLevel, len(snapshot.Bids))
-	for i, bid := range snapshot.Bids {
-		bids[i] = exchange.PriceLevel{
-			Price:    bid[0],
-			Quantity: bid[1],
-		}
-	}
-
-	asks := make([]exchange.PriceLevel, len(snapshot.Asks))
-	for i, ask := range snapshot.Asks {
-		asks[i] = exchange.PriceLevel{
-			Price:    ask[0],
-			Quantity: ask[1],
-		}
-	}
-
-	return &exchange.Snapshot{
-		Exchange:     e.GetName(),
-		Symbol:       e.symbol,
-		LastUpdateID: snapshot.LastUpdateID,
-		Bids:         bids,
-		Asks:         asks,
-		Timestamp:    time.Now(),
+// streamSuffix returns the bookTicker suffix in top-of-book mode, depth otherwise
+func (c Config) streamSuffix(depth string) string {
+	if c.TopOfBook {
+		return bookTickerSuffix
 	}
+	return depth
 }
 
-// convertDepthUpdate converts Binance depth update to canonical format
-func (e *FuturesExchange) convertDepthUpdate(update *DepthUpdate) *exchange.DepthUpdate {
-	bids := make([]exchange.PriceLevel, len(update.Bids))
-	for i, bid := range update.Bids {
-		bids[i] = exchange.PriceLevel{
-			Price:    bid[0],
-			Quantity: bid[1],
-		}
-	}
-
-	asks := make([]exchange.PriceLevel, len(update.Asks))
-	for i, ask := range update.Asks {
-		asks[i] = exchange.PriceLevel{
-			Price:    ask[0],
-			Quantity: ask[1],
-		}
-	}
-
-	return &exchange.DepthUpdate{
-		Exchange:      e.GetName(),
-		Symbol:        update.Symbol,
-		EventTime:     time.UnixMilli(update.EventTime),
-		FirstUpdateID: update.FirstUpdateID,
-		FinalUpdateID: update.FinalUpdateID,
-		PrevUpdateID:  update.PrevUpdateID,
-		Bids:          bids,
-		Asks:          asks,
+// futuresStreamSuffix returns the futures depth stream suffix for an update speed
+func futuresStreamSuffix(speed string) string {
+	switch speed {
+	case UpdateSpeed100ms, UpdateSpeed500ms:
+		return "@depth@" + speed
+	default:
+		return "@depth"
 	}
 }
 
-// updateConnectionStatus updates the connection status in health
-func (e *FuturesExchange) updateConnectionStatus(connected bool) {
-	status := e.Health()
-	status.Connected = connected
-	if !connected {
-		now := time.Now()
-		status.ReconnectTime = &now
+// futuresSnapshotDepth returns the configured futures snapshot depth or the 1000-level default
+func futuresSnapshotDepth(depth int) int {
+	if depth <= 0 {
+		return 1000
 	}
-	e.health.Store(status)
-}
-
-// incrementMessageCount increments the message count in health
-func (e *FuturesExchange) incrementMessageCount() {
-	status := e.Health()
-	status.MessageCount++
-	e.health.Store(status)
-}
-
-// incrementErrorCount increments the error count in health
-func (e *FuturesExchange) incrementErrorCount() {
-	status := e.Health()
-	status.ErrorCount++
-	e.health.Store(status)
+	return depth
 }
 
-// updateLastPing updates the last ping time in health
-func (e *FuturesExchange) updateLastPing() {
-	status := e.Health()
-	status.LastPing = time.Now()
-	e.health.Store(status)
+// NewFuturesMux creates a multiplexer streaming the futures depth of all symbols over one connection
+func NewFuturesMux(symbols []string) (*binancecompat.Mux, error) {
+	return binancecompat.NewMux(binancecompat.MuxConfig{
+		Name:            exchange.Binancef,
+		Symbols:         symbols,
+		StreamURL:       "wss://fstream.binance.com/stream",
+		StreamSuffix:    "@depth",
+		RestURL:         "https://fapi.binance.com/fapi/v1/depth?symbol=%s&limit=1000",
+		ExchangeInfoURL: "https://fapi.binance.com/fapi/v1/exchangeInfo",
+		TimeURL:         futuresTimeURL,
+	})
 }
@@ -0,0 +1,249 @@
+// Package binancecompat implements the REST snapshot and WebSocket diff-depth
+// protocol shared by Binance and venues that mirror its API (e.g., Asterdex).
+package binancecompat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+
+	"github.com/shopspring/decimal"
+)
+
+// Config holds the endpoints of a Binance-compatible market
+type Config struct {
+	Name            exchange.ExchangeName
+	Symbol          string
+	WSURL           string // Diff-depth stream URL
+	RestURL         string // Depth snapshot URL
+	SnapshotLimits  []int  // Depth limits appended to RestURL, tried in order when rate limited
+	ExchangeInfoURL string // Exchange information URL, empty if unsupported
+	TimeURL         string // Server time URL used to correct event times, empty if unsupported
+	CombinedStream  bool   // Messages are wrapped in a {"stream", "data"} envelope
+	Inverse         bool   // Quantities are contracts of a fixed quote notional, normalized to base units
+	OpenInterestURL string // Open interest URL of the symbol, empty if unsupported
+	MarkPriceURL    string // Mark price URL of the symbol, used to convert the open interest of inverse markets
+	BookTicker      bool   // WSURL streams the best bid and ask only (bookTicker); the first message is the snapshot
+}
+
+// diffDepthCapabilities describes the Binance diff-depth protocol
+var diffDepthCapabilities = exchange.Capabilities{
+	SequenceIDs:    true,
+	SnapshotSource: exchange.SnapshotREST,
+}
+
+// bookTickerCapabilities describes the bookTicker stream: every message is the
+// whole top of book
+var bookTickerCapabilities = exchange.Capabilities{
+	FullDepth:      true,
+	TopOfBook:      true,
+	SnapshotSource: exchange.SnapshotWebSocket,
+}
+
+// Client implements the Exchange interface for a Binance-compatible market
+type Client struct {
+	*baseexchange.Base
+	symbol          string
+	snapshots       snapshotSource
+	exchangeInfoURL string
+	combinedStream  bool
+	inverse         bool
+	contractSize    atomic.Pointer[decimal.Decimal] // Quote notional per contract, loaded on inverse markets
+	openInterestURL string
+	markPriceURL    string
+	bookTicker      bool
+}
+
+// NewClient creates a new Binance-compatible exchange client
+func NewClient(config Config) *Client {
+	c := &Client{
+		symbol: config.Symbol,
+		snapshots: snapshotSource{
+			name:    config.Name,
+			symbol:  config.Symbol,
+			restURL: config.RestURL,
+			limits:  config.SnapshotLimits,
+		},
+		exchangeInfoURL: config.ExchangeInfoURL,
+		combinedStream:  config.CombinedStream,
+		inverse:         config.Inverse,
+		openInterestURL: config.OpenInterestURL,
+		markPriceURL:    config.MarkPriceURL,
+		bookTicker:      config.BookTicker,
+	}
+	c.Base = baseexchange.New(baseexchange.Config{
+		Name:   config.Name,
+		Symbol: config.Symbol,
+		WSURL:  config.WSURL,
+		Clock:  serverClock(config.TimeURL),
+	}, c)
+	return c
+}
+
+// Capabilities reports sequenced diff-depth updates on top of a REST snapshot,
+// or whole top-of-book updates on the bookTicker stream
+func (c *Client) Capabilities() exchange.Capabilities {
+	if c.bookTicker {
+		return bookTickerCapabilities
+	}
+	return diffDepthCapabilities
+}
+
+// Connect loads the contract size of inverse markets, then opens the stream
+func (c *Client) Connect(ctx context.Context) error {
+	if err := c.loadContractSize(ctx); err != nil {
+		return fmt.Errorf("failed to load contract size: %w", err)
+	}
+	return c.Base.Connect(ctx)
+}
+
+// Subscribe is a no-op: the stream is selected by the WebSocket URL
+func (c *Client) Subscribe() error {
+	return nil
+}
+
+// GetSnapshot fetches the initial orderbook snapshot via REST API, or waits for
+// the first message of the bookTicker stream
+func (c *Client) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
+	if err := c.loadContractSize(ctx); err != nil {
+		c.RecordError()
+		return nil, fmt.Errorf("failed to load contract size: %w", err)
+	}
+	if c.bookTicker {
+		return c.WaitForSnapshot(ctx, 10*time.Second)
+	}
+	return c.normalizedSnapshot(c.snapshots.fetch(ctx))
+}
+
+// GetShallowSnapshot fetches a snapshot of at most depth levels per side via REST
+// API, or waits for the first message of the bookTicker stream
+func (c *Client) GetShallowSnapshot(ctx context.Context, depth int) (*exchange.Snapshot, error) {
+	if c.bookTicker {
+		return c.GetSnapshot(ctx)
+	}
+	if err := c.loadContractSize(ctx); err != nil {
+		c.RecordError()
+		return nil, fmt.Errorf("failed to load contract size: %w", err)
+	}
+	return c.normalizedSnapshot(c.snapshots.fetchDepth(ctx, depth))
+}
+
+// normalizedSnapshot normalizes the quantities of a fetched REST snapshot
+func (c *Client) normalizedSnapshot(snapshot *exchange.Snapshot, err error) (*exchange.Snapshot, error) {
+	if err != nil {
+		c.RecordError()
+		return nil, err
+	}
+	if err := c.normalize(snapshot.Bids, snapshot.Asks); err != nil {
+		c.RecordError()
+		return nil, fmt.Errorf("failed to normalize snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// HandleMessage parses a depth update, unwrapping combined stream messages
+func (c *Client) HandleMessage(messageType int, data []byte) error {
+	if c.bookTicker {
+		return c.handleBookTicker(data)
+	}
+
+	var update DepthUpdate
+	if c.combinedStream {
+		var msg WSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return fmt.Errorf("failed to decode message: %w", err)
+		}
+		update = msg.Data
+	} else if err := json.Unmarshal(data, &update); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	c.RecordMessage()
+	converted := convertDepthUpdate(c.GetName(), &update)
+	if err := c.normalize(converted.Bids, converted.Asks); err != nil {
+		return fmt.Errorf("failed to normalize update: %w", err)
+	}
+	c.Emit(converted)
+	return nil
+}
+
+// handleBookTicker parses a best bid and ask event into a top-of-book update.
+// The first one is stored as the snapshot.
+func (c *Client) handleBookTicker(data []byte) error {
+	var ticker BookTicker
+	if c.combinedStream {
+		var msg BookTickerMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return fmt.Errorf("failed to decode message: %w", err)
+		}
+		ticker = msg.Data
+	} else if err := json.Unmarshal(data, &ticker); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	c.RecordMessage()
+	update := convertBookTicker(c.GetName(), &ticker)
+	if update.EventTime.IsZero() {
+		// Spot tickers carry no event time
+		update.EventTime = c.Now()
+	}
+	if err := c.normalize(update.Bids, update.Asks); err != nil {
+		return fmt.Errorf("failed to normalize update: %w", err)
+	}
+	if !c.HasSnapshot() {
+		c.SetSnapshot(&exchange.Snapshot{
+			Exchange:     update.Exchange,
+			Symbol:       update.Symbol,
+			LastUpdateID: update.FinalUpdateID,
+			Bids:         append([]exchange.PriceLevel(nil), update.Bids...),
+			Asks:         append([]exchange.PriceLevel(nil), update.Asks...),
+			Timestamp:    update.EventTime,
+		})
+	}
+	c.Emit(update)
+	return nil
+}
+
+// serverClock returns a clock source reading {"serverTime": ms} from url, or nil if url is empty
+func serverClock(url string) baseexchange.ClockSource {
+	if url == "" {
+		return nil
+	}
+	return baseexchange.RESTClock(url, baseexchange.UnixMilliField("serverTime"))
+}
+
+// convertDepthUpdate converts a WebSocket depth update to canonical format
+func convertDepthUpdate(name exchange.ExchangeName, update *DepthUpdate) *exchange.DepthUpdate {
+	return &exchange.DepthUpdate{
+		Exchange:      name,
+		Symbol:        update.Symbol,
+		EventTime:     time.UnixMilli(update.EventTime),
+		FirstUpdateID: update.FirstUpdateID,
+		FinalUpdateID: update.FinalUpdateID,
+		PrevUpdateID:  update.PrevUpdateID,
+		Bids:          baseexchange.ConvertLevels(update.Bids),
+		Asks:          baseexchange.ConvertLevels(update.Asks),
+	}
+}
+
+// convertBookTicker converts a best bid and ask event to a canonical update
+// holding one level per side
+func convertBookTicker(name exchange.ExchangeName, ticker *BookTicker) *exchange.DepthUpdate {
+	update := &exchange.DepthUpdate{
+		Exchange:      name,
+		Symbol:        ticker.Symbol,
+		FirstUpdateID: ticker.UpdateID,
+		FinalUpdateID: ticker.UpdateID,
+		Bids:          []exchange.PriceLevel{{Price: ticker.BidPrice, Quantity: ticker.BidQty}},
+		Asks:          []exchange.PriceLevel{{Price: ticker.AskPrice, Quantity: ticker.AskQty}},
+	}
+	if ticker.EventTime > 0 {
+		update.EventTime = time.UnixMilli(ticker.EventTime)
+	}
+	return update
+}
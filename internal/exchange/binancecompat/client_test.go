@@ -0,0 +1,235 @@
+package binancecompat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"orderbook/internal/exchange"
+)
+
+func TestGetSnapshotFallsBackOnRateLimit(t *testing.T) {
+	var limits []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := r.URL.Query().Get("limit")
+		limits = append(limits, limit)
+		if limit == "5000" {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"lastUpdateId":42,"bids":[["100","1"]],"asks":[["101","2"]]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{
+		Name:           exchange.Binance,
+		Symbol:         "BTCUSDT",
+		RestURL:        server.URL + "/depth?symbol=BTCUSDT",
+		SnapshotLimits: []int{5000, 1000},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	snapshot, err := c.GetSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("GetSnapshot() failed: %v", err)
+	}
+	if snapshot.LastUpdateID != 42 {
+		t.Errorf("Expected LastUpdateID 42, got %d", snapshot.LastUpdateID)
+	}
+	if len(limits) != 2 || limits[0] != "5000" || limits[1] != "1000" {
+		t.Errorf("Expected limits [5000 1000], got %v", limits)
+	}
+}
+
+func TestGetShallowSnapshotLimitsDepth(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"lastUpdateId":42,"bids":[["100","1"]],"asks":[["101","2"]]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{
+		Name:    exchange.Binancef,
+		Symbol:  "BTCUSDT",
+		RestURL: server.URL + "/depth?symbol=BTCUSDT&limit=1000",
+	})
+
+	snapshot, err := c.GetShallowSnapshot(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("GetShallowSnapshot() failed: %v", err)
+	}
+	if snapshot.LastUpdateID != 42 {
+		t.Errorf("Expected LastUpdateID 42, got %d", snapshot.LastUpdateID)
+	}
+	if query != "limit=100&symbol=BTCUSDT" {
+		t.Errorf("Expected the limit to be replaced by 100, got %s", query)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", defaultRetryAfter},
+		{"invalid", defaultRetryAfter},
+		{"3", 3 * time.Second},
+		{"600", maxRetryAfter},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.expected {
+			t.Errorf("parseRetryAfter(%q): Expected %v, got %v", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestGetSnapshotReportsUnknownSymbol(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{
+		Name:    exchange.Binance,
+		Symbol:  "BTCXYZ",
+		RestURL: server.URL + "/depth?symbol=BTCXYZ",
+	})
+
+	_, err := c.GetSnapshot(context.Background())
+	if !errors.Is(err, exchange.ErrSymbolNotFound) {
+		t.Errorf("Expected ErrSymbolNotFound, got %v", err)
+	}
+}
+
+func TestInverseSnapshotNormalizesContracts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/exchangeInfo") {
+			w.Write([]byte(`{"symbols":[{"symbol":"BTCUSD_PERP","contractSize":100,"filters":[]}]}`))
+			return
+		}
+		w.Write([]byte(`{"lastUpdateId":7,"bids":[["50000","10"]],"asks":[["50100","0"]]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{
+		Name:            exchange.Binancefc,
+		Symbol:          "BTCUSD_PERP",
+		RestURL:         server.URL + "/depth?symbol=BTCUSD_PERP",
+		ExchangeInfoURL: server.URL + "/exchangeInfo",
+		Inverse:         true,
+	})
+
+	snapshot, err := c.GetSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("GetSnapshot() failed: %v", err)
+	}
+	// 10 contracts of 100 USD at 50000 is 0.02 BTC
+	if got := snapshot.Bids[0].Quantity; got != "0.02000000" {
+		t.Errorf("Expected bid quantity 0.02000000, got %s", got)
+	}
+	if got := snapshot.Asks[0].Quantity; got != "0" {
+		t.Errorf("Expected removed ask to keep quantity 0, got %s", got)
+	}
+}
+
+func TestContractSizeReportsRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(`{"code":-1003,"msg":"Way too many requests"}`))
+	}))
+	defer server.Close()
+
+	_, err := fetchContractSize(context.Background(), server.URL+"/exchangeInfo", "BTCUSD_PERP")
+	var rateLimited *exchange.RateLimitError
+	if !errors.As(err, &rateLimited) || rateLimited.Status != http.StatusTeapot || rateLimited.RetryAfter != 3*time.Second {
+		t.Errorf("Expected a 418 rate limit retrying after 3s, got %v", err)
+	}
+	if errors.Is(err, exchange.ErrSymbolNotFound) {
+		t.Errorf("Expected the rate limit not to be reported as an unknown symbol")
+	}
+}
+
+func TestInverseOpenInterestConvertsContracts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/exchangeInfo"):
+			w.Write([]byte(`{"symbols":[{"symbol":"BTCUSD_PERP","contractSize":100,"filters":[]}]}`))
+		case strings.HasSuffix(r.URL.Path, "/premiumIndex"):
+			w.Write([]byte(`[{"symbol":"BTCUSD_PERP","markPrice":"50000"}]`))
+		default:
+			w.Write([]byte(`{"symbol":"BTCUSD_PERP","openInterest":"1500"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(Config{
+		Name:            exchange.Binancefc,
+		Symbol:          "BTCUSD_PERP",
+		ExchangeInfoURL: server.URL + "/exchangeInfo",
+		Inverse:         true,
+		OpenInterestURL: server.URL + "/openInterest?symbol=BTCUSD_PERP",
+		MarkPriceURL:    server.URL + "/premiumIndex?symbol=BTCUSD_PERP",
+	})
+
+	oi, err := c.GetOpenInterest(context.Background())
+	if err != nil {
+		t.Fatalf("GetOpenInterest() failed: %v", err)
+	}
+	// 1500 contracts of 100 USD at 50000 is 3 BTC
+	if !oi.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("Expected open interest 3, got %s", oi)
+	}
+
+	spot := NewClient(Config{Name: exchange.Binance, Symbol: "BTCUSDT"})
+	if _, err := spot.GetOpenInterest(context.Background()); !errors.Is(err, exchange.ErrNoOpenInterest) {
+		t.Errorf("Expected ErrNoOpenInterest without an open interest URL, got %v", err)
+	}
+}
+
+func TestBookTickerUpdates(t *testing.T) {
+	c := NewClient(Config{Name: exchange.Binancef, Symbol: "BTCUSDT", CombinedStream: true, BookTicker: true})
+	defer c.Close()
+
+	if caps := c.Capabilities(); !caps.FullDepth || !caps.TopOfBook || caps.SnapshotSource != exchange.SnapshotWebSocket {
+		t.Errorf("Expected whole top-of-book updates on a WebSocket snapshot, got %+v", caps)
+	}
+
+	messages := []string{
+		`{"stream":"btcusdt@bookTicker","data":{"e":"bookTicker","u":400,"E":1700000000000,"s":"BTCUSDT","b":"50000.10","B":"3","a":"50000.20","A":"1.5"}}`,
+		`{"stream":"btcusdt@bookTicker","data":{"e":"bookTicker","u":401,"E":1700000000100,"s":"BTCUSDT","b":"50000.10","B":"2","a":"50000.30","A":"4"}}`,
+	}
+	for _, msg := range messages {
+		if err := c.HandleMessage(1, []byte(msg)); err != nil {
+			t.Fatalf("HandleMessage() failed: %v", err)
+		}
+	}
+
+	snapshot, err := c.GetSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("GetSnapshot() failed: %v", err)
+	}
+	if snapshot.LastUpdateID != 400 || snapshot.Bids[0].Quantity != "3" || snapshot.Asks[0].Price != "50000.20" {
+		t.Errorf("Expected the first ticker as snapshot, got %+v", snapshot)
+	}
+
+	<-c.Updates()
+	update := <-c.Updates()
+	if update.FinalUpdateID != 401 || len(update.Bids) != 1 || len(update.Asks) != 1 || update.Asks[0].Quantity != "4" {
+		t.Errorf("Expected one level per side at update 401, got %+v", update)
+	}
+	if !update.EventTime.Equal(time.UnixMilli(1700000000100)) {
+		t.Errorf("Expected the ticker event time, got %v", update.EventTime)
+	}
+}
@@ -0,0 +1,81 @@
+package binancecompat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+	"orderbook/internal/fixedpoint"
+	"orderbook/internal/transport"
+
+	"github.com/shopspring/decimal"
+)
+
+// fetchInstrumentInfo fetches exchange information and extracts precision metadata for symbol
+func fetchInstrumentInfo(ctx context.Context, url, symbol string) (*exchange.InstrumentInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := transport.Default().Client(10 * time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var info ExchangeInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode exchange info: %w", err)
+	}
+
+	for _, s := range info.Symbols {
+		if !strings.EqualFold(s.Symbol, symbol) {
+			continue
+		}
+
+		instrument := &exchange.InstrumentInfo{Symbol: s.Symbol}
+		for _, filter := range s.Filters {
+			switch filter.FilterType {
+			case "PRICE_FILTER":
+				instrument.TickSize = filter.TickSize
+			case "LOT_SIZE":
+				instrument.StepSize = filter.StepSize
+			}
+		}
+
+		if instrument.TickSize == "" || instrument.StepSize == "" {
+			return nil, fmt.Errorf("missing precision filters for %s", symbol)
+		}
+		if instrument.PriceDecimals, err = fixedpoint.DecimalPlaces(instrument.TickSize); err != nil {
+			return nil, fmt.Errorf("invalid tick size: %w", err)
+		}
+		if instrument.QtyDecimals, err = fixedpoint.DecimalPlaces(instrument.StepSize); err != nil {
+			return nil, fmt.Errorf("invalid step size: %w", err)
+		}
+		return instrument, nil
+	}
+
+	return nil, fmt.Errorf("%s in exchange info: %w", symbol, exchange.ErrSymbolNotFound)
+}
+
+// GetInstrumentInfo fetches precision metadata for the configured symbol
+func (c *Client) GetInstrumentInfo(ctx context.Context) (*exchange.InstrumentInfo, error) {
+	if c.exchangeInfoURL == "" {
+		return nil, fmt.Errorf("instrument metadata not available for %s", c.GetName())
+	}
+	info, err := fetchInstrumentInfo(ctx, c.exchangeInfoURL, c.symbol)
+	if err != nil || !c.inverse {
+		return info, err
+	}
+
+	// Normalized quantities are fractions of a contract, finer than the contract step
+	info.StepSize = decimal.New(1, -baseexchange.InverseQtyDecimals).String()
+	info.QtyDecimals = baseexchange.InverseQtyDecimals
+	return info, nil
+}
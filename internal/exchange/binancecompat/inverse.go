@@ -0,0 +1,87 @@
+package binancecompat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+	"orderbook/internal/transport"
+
+	"github.com/shopspring/decimal"
+)
+
+// fetchContractSize fetches the quote notional of one contract of symbol from exchange information
+func fetchContractSize(ctx context.Context, url, symbol string) (decimal.Decimal, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := transport.Default().Client(10 * time.Second).Do(req)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get exchange info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests, http.StatusTeapot:
+		return decimal.Zero, &exchange.RateLimitError{Status: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	default:
+		return decimal.Zero, fmt.Errorf("exchange info request failed: %w", exchange.StatusError(resp))
+	}
+
+	var info ExchangeInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to decode exchange info: %w", err)
+	}
+
+	for _, s := range info.Symbols {
+		if !strings.EqualFold(s.Symbol, symbol) {
+			continue
+		}
+		if !s.ContractSize.IsPositive() {
+			return decimal.Zero, fmt.Errorf("missing contract size for %s", symbol)
+		}
+		return s.ContractSize, nil
+	}
+
+	return decimal.Zero, fmt.Errorf("%s in exchange info: %w", symbol, exchange.ErrSymbolNotFound)
+}
+
+// loadContractSize fetches the contract size of an inverse market once
+func (c *Client) loadContractSize(ctx context.Context) error {
+	if !c.inverse || c.contractSize.Load() != nil {
+		return nil
+	}
+	if c.exchangeInfoURL == "" {
+		return fmt.Errorf("contract size not available for %s", c.GetName())
+	}
+
+	size, err := fetchContractSize(ctx, c.exchangeInfoURL, c.symbol)
+	if err != nil {
+		return err
+	}
+	c.contractSize.Store(&size)
+	return nil
+}
+
+// normalize converts the quantities of bids and asks to base units on inverse markets
+func (c *Client) normalize(bids, asks []exchange.PriceLevel) error {
+	if !c.inverse {
+		return nil
+	}
+	size := c.contractSize.Load()
+	if size == nil {
+		return fmt.Errorf("contract size of %s not loaded", c.symbol)
+	}
+	if err := baseexchange.NormalizeInverse(bids, *size); err != nil {
+		return err
+	}
+	return baseexchange.NormalizeInverse(asks, *size)
+}
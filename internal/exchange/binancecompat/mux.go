@@ -0,0 +1,243 @@
+package binancecompat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+)
+
+// MaxStreamsPerConnection is the number of streams Binance accepts on one combined connection
+const MaxStreamsPerConnection = 1024
+
+// MuxConfig holds the endpoints of a multiplexed Binance-compatible market
+type MuxConfig struct {
+	Name            exchange.ExchangeName
+	Symbols         []string
+	StreamURL       string // Combined stream base URL (e.g., "wss://stream.binance.com:9443/stream")
+	StreamSuffix    string // Depth stream suffix appended to the lowercase symbol (e.g., "@depth@100ms")
+	RestURL         string // Depth snapshot URL format with a %s placeholder for the uppercase symbol
+	SnapshotLimits  []int  // Depth limits appended to the snapshot URL, tried in order when rate limited
+	ExchangeInfoURL string // Exchange information URL listing all symbols, empty if unsupported
+	TimeURL         string // Server time URL used to correct event times, empty if unsupported
+}
+
+// Mux multiplexes the depth streams of many symbols over one combined-stream connection.
+// Each symbol is exposed as its own exchange.Exchange through Stream. The connection
+// is opened by the first connected stream and closed with the last one; it cannot be reopened.
+type Mux struct {
+	*baseexchange.Base
+	exchangeInfoURL string
+	streams         map[string]*Stream // Keyed by stream name
+	bySymbol        map[string]*Stream // Keyed by uppercase symbol
+
+	mu   sync.Mutex
+	refs int // Streams currently connected
+}
+
+// NewMux creates a multiplexer for the configured symbols
+func NewMux(config MuxConfig) (*Mux, error) {
+	if len(config.Symbols) == 0 {
+		return nil, fmt.Errorf("no symbols to multiplex")
+	}
+	if len(config.Symbols) > MaxStreamsPerConnection {
+		return nil, fmt.Errorf("too many symbols: %d (max %d per connection)", len(config.Symbols), MaxStreamsPerConnection)
+	}
+
+	m := &Mux{
+		exchangeInfoURL: config.ExchangeInfoURL,
+		streams:         make(map[string]*Stream, len(config.Symbols)),
+		bySymbol:        make(map[string]*Stream, len(config.Symbols)),
+	}
+
+	names := make([]string, 0, len(config.Symbols))
+	for _, symbol := range config.Symbols {
+		upperSymbol := strings.ToUpper(symbol)
+		if _, ok := m.bySymbol[upperSymbol]; ok {
+			return nil, fmt.Errorf("duplicate symbol: %s", symbol)
+		}
+
+		name := strings.ToLower(symbol) + config.StreamSuffix
+		stream := &Stream{
+			mux:    m,
+			symbol: symbol,
+			snapshots: snapshotSource{
+				name:    config.Name,
+				symbol:  symbol,
+				restURL: fmt.Sprintf(config.RestURL, upperSymbol),
+				limits:  config.SnapshotLimits,
+			},
+			updateChan: make(chan *exchange.DepthUpdate, 1000),
+		}
+		m.streams[name] = stream
+		m.bySymbol[upperSymbol] = stream
+		names = append(names, name)
+	}
+
+	m.Base = baseexchange.New(baseexchange.Config{
+		Name:   config.Name,
+		Symbol: strings.Join(config.Symbols, ","),
+		WSURL:  config.StreamURL + "?streams=" + strings.Join(names, "/"),
+		Clock:  serverClock(config.TimeURL),
+	}, m)
+	return m, nil
+}
+
+// Stream returns the exchange of a multiplexed symbol, or nil if it is not configured
+func (m *Mux) Stream(symbol string) *Stream {
+	return m.bySymbol[strings.ToUpper(symbol)]
+}
+
+// Subscribe is a no-op: the streams are selected by the WebSocket URL
+func (m *Mux) Subscribe() error {
+	return nil
+}
+
+// HandleMessage demultiplexes a combined stream message by stream name
+func (m *Mux) HandleMessage(messageType int, data []byte) error {
+	var msg WSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	stream, ok := m.streams[msg.Stream]
+	if !ok {
+		return fmt.Errorf("unknown stream: %s", msg.Stream)
+	}
+
+	m.RecordMessage()
+	stream.emit(convertDepthUpdate(m.GetName(), &msg.Data))
+	return nil
+}
+
+// connect opens the shared connection on the first call and counts the streams using it
+func (m *Mux) connect(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.refs == 0 {
+		if err := m.Base.Connect(ctx); err != nil {
+			return err
+		}
+		go m.closeStreamsOnExit()
+	}
+	m.refs++
+	return nil
+}
+
+// release closes the shared connection once the last stream is closed
+func (m *Mux) release() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.refs == 0 {
+		return nil
+	}
+	m.refs--
+	if m.refs == 0 {
+		return m.Base.Close()
+	}
+	return nil
+}
+
+// closeStreamsOnExit closes every stream channel once the shared read loop stops
+func (m *Mux) closeStreamsOnExit() {
+	for range m.Base.Updates() {
+	}
+	for _, stream := range m.streams {
+		close(stream.updateChan)
+	}
+}
+
+// Stream implements the Exchange interface for one symbol of a Mux
+type Stream struct {
+	mux        *Mux
+	symbol     string
+	snapshots  snapshotSource
+	updateChan chan *exchange.DepthUpdate
+	closeOnce  sync.Once
+}
+
+// GetName returns the exchange name
+func (s *Stream) GetName() exchange.ExchangeName {
+	return s.mux.GetName()
+}
+
+// GetSymbol returns the trading symbol
+func (s *Stream) GetSymbol() string {
+	return s.symbol
+}
+
+// Connect joins the shared connection, opening it if needed
+func (s *Stream) Connect(ctx context.Context) error {
+	return s.mux.connect(ctx)
+}
+
+// Close leaves the shared connection, closing it after the last stream
+func (s *Stream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.mux.release()
+	})
+	return err
+}
+
+// GetSnapshot fetches the orderbook snapshot of the symbol via REST API
+func (s *Stream) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
+	snapshot, err := s.snapshots.fetch(ctx)
+	if err != nil {
+		s.mux.RecordError()
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// GetInstrumentInfo fetches precision metadata for the symbol
+func (s *Stream) GetInstrumentInfo(ctx context.Context) (*exchange.InstrumentInfo, error) {
+	if s.mux.exchangeInfoURL == "" {
+		return nil, fmt.Errorf("instrument metadata not available for %s", s.GetName())
+	}
+	return fetchInstrumentInfo(ctx, s.mux.exchangeInfoURL, s.symbol)
+}
+
+// Updates returns a channel that receives the depth updates of the symbol
+func (s *Stream) Updates() <-chan *exchange.DepthUpdate {
+	return s.updateChan
+}
+
+// IsConnected reports whether the shared connection has been started
+func (s *Stream) IsConnected() bool {
+	return s.mux.IsConnected()
+}
+
+// Health returns the health of the shared connection
+func (s *Stream) Health() exchange.HealthStatus {
+	return s.mux.Health()
+}
+
+// SetStaleTimeout enables the heartbeat watchdog of the shared connection
+func (s *Stream) SetStaleTimeout(timeout time.Duration) {
+	s.mux.SetStaleTimeout(timeout)
+}
+
+// Capabilities reports sequenced diff-depth updates on top of a REST snapshot
+func (s *Stream) Capabilities() exchange.Capabilities {
+	return diffDepthCapabilities
+}
+
+// emit queues a depth update, dropping it if the channel is full
+func (s *Stream) emit(update *exchange.DepthUpdate) {
+	s.mux.Stamp(update)
+
+	select {
+	case s.updateChan <- update:
+	default:
+		log.Printf("[%s] Warning: %s update channel full, skipping update", s.GetName(), s.symbol)
+	}
+}
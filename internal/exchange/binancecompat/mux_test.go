@@ -0,0 +1,120 @@
+package binancecompat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"orderbook/internal/exchange"
+)
+
+func TestMuxDemultiplexesByStream(t *testing.T) {
+	messages := []string{
+		`{"stream":"ethusdt@depth","data":{"e":"depthUpdate","s":"ETHUSDT","U":1,"u":2,"b":[["3000","1"]],"a":[]}}`,
+		`{"stream":"btcusdt@depth","data":{"e":"depthUpdate","s":"BTCUSDT","U":5,"u":6,"b":[],"a":[["60000","2"]]}}`,
+	}
+
+	var streams string
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		streams = r.URL.Query().Get("streams")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade() failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		for _, msg := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+				return
+			}
+		}
+		conn.ReadMessage() // Block until the client closes
+	}))
+	defer server.Close()
+
+	mux, err := NewMux(MuxConfig{
+		Name:         exchange.Binance,
+		Symbols:      []string{"BTCUSDT", "ETHUSDT"},
+		StreamURL:    "ws" + strings.TrimPrefix(server.URL, "http") + "/stream",
+		StreamSuffix: "@depth",
+		RestURL:      server.URL + "/depth?symbol=%s",
+	})
+	if err != nil {
+		t.Fatalf("NewMux() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	btc, eth := mux.Stream("btcusdt"), mux.Stream("ETHUSDT")
+	if btc == nil || eth == nil {
+		t.Fatalf("Expected streams for both symbols")
+	}
+	if err := btc.Connect(ctx); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	if err := eth.Connect(ctx); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	if streams != "btcusdt@depth/ethusdt@depth" {
+		t.Errorf("Expected streams btcusdt@depth/ethusdt@depth, got %s", streams)
+	}
+
+	tests := []struct {
+		stream   *Stream
+		symbol   string
+		updateID int64
+	}{
+		{btc, "BTCUSDT", 6},
+		{eth, "ETHUSDT", 2},
+	}
+	for _, tt := range tests {
+		select {
+		case update := <-tt.stream.Updates():
+			if update.Symbol != tt.symbol || update.FinalUpdateID != tt.updateID {
+				t.Errorf("Expected %s update %d, got %s update %d", tt.symbol, tt.updateID, update.Symbol, update.FinalUpdateID)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for %s update", tt.symbol)
+		}
+	}
+
+	// The connection stays open until the last stream is closed
+	btc.Close()
+	if !mux.Health().Connected {
+		t.Errorf("Expected connection open while a stream is in use")
+	}
+	eth.Close()
+
+	select {
+	case _, ok := <-eth.Updates():
+		if ok {
+			t.Errorf("Expected no further updates")
+		}
+	case <-ctx.Done():
+		t.Fatalf("Timed out waiting for stream channel to close")
+	}
+}
+
+func TestNewMuxValidatesSymbols(t *testing.T) {
+	tests := []struct {
+		name    string
+		symbols []string
+	}{
+		{"empty", nil},
+		{"duplicate", []string{"BTCUSDT", "btcusdt"}},
+		{"too many", make([]string, MaxStreamsPerConnection+1)},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewMux(MuxConfig{Symbols: tt.symbols}); err == nil {
+			t.Errorf("%s: Expected error, got nil", tt.name)
+		}
+	}
+}
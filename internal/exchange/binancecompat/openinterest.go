@@ -0,0 +1,42 @@
+package binancecompat
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+)
+
+// GetOpenInterest fetches the open interest of the symbol in base units. Inverse
+// contracts are converted at the mark price.
+func (c *Client) GetOpenInterest(ctx context.Context) (decimal.Decimal, error) {
+	if c.openInterestURL == "" {
+		return decimal.Zero, fmt.Errorf("%s: %w", c.GetName(), exchange.ErrNoOpenInterest)
+	}
+
+	var oi OpenInterestResponse
+	if err := baseexchange.GetJSON(ctx, c.openInterestURL, &oi); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get open interest: %w", err)
+	}
+	if !c.inverse {
+		return oi.OpenInterest, nil
+	}
+
+	if err := c.loadContractSize(ctx); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to load contract size: %w", err)
+	}
+	// COIN-margined mark prices are listed per symbol, even when filtered by symbol
+	var indexes []PremiumIndex
+	if err := baseexchange.GetJSON(ctx, c.markPriceURL, &indexes); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get mark price: %w", err)
+	}
+	for _, index := range indexes {
+		if index.Symbol != c.symbol || !index.MarkPrice.IsPositive() {
+			continue
+		}
+		return oi.OpenInterest.Mul(*c.contractSize.Load()).Div(index.MarkPrice), nil
+	}
+	return decimal.Zero, fmt.Errorf("missing mark price for %s", c.symbol)
+}
@@ -0,0 +1,145 @@
+package binancecompat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+	"orderbook/internal/transport"
+)
+
+// Bounds of the wait between rate-limited snapshot requests
+const (
+	defaultRetryAfter = time.Second
+	maxRetryAfter     = 10 * time.Second
+)
+
+// errInvalidSymbol is the API error code of an unknown symbol
+const errInvalidSymbol = -1121
+
+// apiError is the body of a rejected REST request
+type apiError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// snapshotSource fetches REST depth snapshots of a single symbol
+type snapshotSource struct {
+	name    exchange.ExchangeName
+	symbol  string
+	restURL string // Depth snapshot URL
+	limits  []int  // Depth limits appended to restURL, tried in order when rate limited
+}
+
+// fetch requests a snapshot. When rate limited it retries with the next, cheaper, limit.
+func (s *snapshotSource) fetch(ctx context.Context) (*exchange.Snapshot, error) {
+	log.Printf("[%s] Fetching orderbook snapshot...", s.name)
+
+	if len(s.limits) == 0 {
+		return s.fetchURL(ctx, s.restURL)
+	}
+
+	var lastErr error
+	for _, limit := range s.limits {
+		if lastErr != nil {
+			log.Printf("[%s] %v, retrying snapshot with limit %d", s.name, lastErr, limit)
+		}
+
+		snapshot, err := s.fetchURL(ctx, fmt.Sprintf("%s&limit=%d", s.restURL, limit))
+		if err == nil {
+			return snapshot, nil
+		}
+
+		retryAfter, ok := exchange.RetryAfter(err)
+		if !ok {
+			return nil, err
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled while waiting to retry snapshot: %w", ctx.Err())
+		case <-time.After(retryAfter):
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get snapshot: %w", lastErr)
+}
+
+// fetchDepth requests a snapshot of at most depth levels per side, replacing
+// the limit of restURL
+func (s *snapshotSource) fetchDepth(ctx context.Context, depth int) (*exchange.Snapshot, error) {
+	parsed, err := url.Parse(s.restURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot URL: %w", err)
+	}
+	query := parsed.Query()
+	query.Set("limit", strconv.Itoa(depth))
+	parsed.RawQuery = query.Encode()
+
+	log.Printf("[%s] Fetching %d-level orderbook snapshot...", s.name, depth)
+	return s.fetchURL(ctx, parsed.String())
+}
+
+// fetchURL requests a single snapshot from url
+func (s *snapshotSource) fetchURL(ctx context.Context, url string) (*exchange.Snapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := transport.Default().Client(10 * time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests, http.StatusTeapot:
+		return nil, &exchange.RateLimitError{Status: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	case http.StatusBadRequest:
+		var apiErr apiError
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Code == errInvalidSymbol {
+			return nil, fmt.Errorf("%s: %w", s.symbol, exchange.ErrSymbolNotFound)
+		}
+		return nil, fmt.Errorf("unexpected snapshot status: %s", resp.Status)
+	default:
+		return nil, fmt.Errorf("snapshot request failed: %w", exchange.StatusError(resp))
+	}
+
+	var snapshotResp SnapshotResponse
+	if err := json.NewDecoder(resp.Body).Decode(&snapshotResp); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	return &exchange.Snapshot{
+		Exchange:     s.name,
+		Symbol:       s.symbol,
+		LastUpdateID: snapshotResp.LastUpdateID,
+		Bids:         baseexchange.ConvertLevels(snapshotResp.Bids),
+		Asks:         baseexchange.ConvertLevels(snapshotResp.Asks),
+		Timestamp:    time.Now(),
+	}, nil
+}
+
+// parseRetryAfter converts a Retry-After header in seconds to a bounded wait
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return defaultRetryAfter
+	}
+
+	wait := time.Duration(seconds) * time.Second
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
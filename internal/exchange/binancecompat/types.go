@@ -0,0 +1,78 @@
+package binancecompat
+
+import "github.com/shopspring/decimal"
+
+// SnapshotResponse represents the REST API response for a Binance-compatible order book snapshot
+type SnapshotResponse struct {
+	LastUpdateID int64      `json:"lastUpdateId"`
+	Bids         [][]string `json:"bids"`
+	Asks         [][]string `json:"asks"`
+}
+
+// WSMessage represents a combined stream WebSocket message
+type WSMessage struct {
+	Stream string      `json:"stream"`
+	Data   DepthUpdate `json:"data"`
+}
+
+// DepthUpdate represents a depth update event from a Binance-compatible WebSocket
+type DepthUpdate struct {
+	EventType       string     `json:"e"`  // Event type
+	EventTime       int64      `json:"E"`  // Event time
+	TransactionTime int64      `json:"T"`  // Transaction time (futures only)
+	Symbol          string     `json:"s"`  // Symbol
+	FirstUpdateID   int64      `json:"U"`  // First update ID in event
+	FinalUpdateID   int64      `json:"u"`  // Final update ID in event
+	PrevUpdateID    int64      `json:"pu"` // Final update ID in last stream (futures only)
+	Bids            [][]string `json:"b"`  // Bids to be updated
+	Asks            [][]string `json:"a"`  // Asks to be updated
+}
+
+// BookTickerMessage represents a combined stream bookTicker message
+type BookTickerMessage struct {
+	Stream string     `json:"stream"`
+	Data   BookTicker `json:"data"`
+}
+
+// BookTicker represents a best bid and ask event from a Binance-compatible bookTicker stream
+type BookTicker struct {
+	EventType string `json:"e"` // Event type (futures only)
+	UpdateID  int64  `json:"u"` // Order book update ID
+	EventTime int64  `json:"E"` // Event time (futures only)
+	Symbol    string `json:"s"` // Symbol
+	BidPrice  string `json:"b"` // Best bid price
+	BidQty    string `json:"B"` // Best bid quantity
+	AskPrice  string `json:"a"` // Best ask price
+	AskQty    string `json:"A"` // Best ask quantity
+}
+
+// ExchangeInfoResponse represents the REST API response for exchange information
+type ExchangeInfoResponse struct {
+	Symbols []SymbolInfo `json:"symbols"`
+}
+
+// SymbolInfo represents a single symbol entry in the exchange information
+type SymbolInfo struct {
+	Symbol       string          `json:"symbol"`
+	ContractSize decimal.Decimal `json:"contractSize"` // Quote notional per contract (inverse markets only)
+	Filters      []SymbolFilter  `json:"filters"`
+}
+
+// SymbolFilter represents a trading rule filter (PRICE_FILTER, LOT_SIZE, ...)
+type SymbolFilter struct {
+	FilterType string `json:"filterType"`
+	TickSize   string `json:"tickSize,omitempty"`
+	StepSize   string `json:"stepSize,omitempty"`
+}
+
+// OpenInterestResponse represents the open interest of a futures symbol
+type OpenInterestResponse struct {
+	Symbol       string          `json:"symbol"`
+	OpenInterest decimal.Decimal `json:"openInterest"` // Base units, or contracts on inverse markets
+}
+
+// PremiumIndex represents the mark price of a futures symbol
+type PremiumIndex struct {
+	Symbol    string          `json:"symbol"`
+	MarkPrice decimal.Decimal `json:"markPrice"`
+}
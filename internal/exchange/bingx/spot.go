@@ -6,248 +6,83 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
+	"net/http"
 	"strings"
-	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
 )
 
 const (
 	wsURL = "wss://open-api-ws.bingx.com/market"
 )
 
+// gzipHeader requests gzip-compressed frames from BingX
+var gzipHeader = http.Header{
+	"Accept-Encoding": {"gzip"},
+}
+
+// incrDepthCapabilities describes the BingX incremental depth stream
+var incrDepthCapabilities = exchange.Capabilities{
+	SequenceIDs:    true,
+	SnapshotSource: exchange.SnapshotWebSocket,
+}
+
 // SpotExchange implements the Exchange interface for BingX Spot
 type SpotExchange struct {
-	symbol         string
-	bingxSymbol    string // BingX format (e.g., BTC-USDT)
-	wsConn         *websocket.Conn
-	updateChan     chan *exchange.DepthUpdate
-	done           chan struct{}
-	ctx            context.Context
-	cancel         context.CancelFunc
-	health         atomic.Value
-	snapshotMutex  sync.Mutex
-	snapshot       *exchange.Snapshot
-	snapshotReady  chan struct{}
-	hasSnapshot    bool
+	*baseexchange.Base
+	symbol      string
+	bingxSymbol string // BingX format (e.g., BTC-USDT)
 }
 
 // NewSpotExchange creates a new BingX Spot exchange instance
 func NewSpotExchange(config Config) *SpotExchange {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	bingxSymbol := convertToBingXSymbol(config.Symbol)
-
 	ex := &SpotExchange{
-		symbol:        config.Symbol,
-		bingxSymbol:   bingxSymbol,
-		updateChan:    make(chan *exchange.DepthUpdate, 1000),
-		done:          make(chan struct{}),
-		ctx:           ctx,
-		cancel:        cancel,
-		snapshotReady: make(chan struct{}),
-		hasSnapshot:   false,
-	}
-
-	ex.health.Store(exchange.HealthStatus{
-		Connected:    false,
-		LastPing:     time.Time{},
-		MessageCount: 0,
-		ErrorCount:   0,
-	})
-
+		symbol:      config.Symbol,
+		bingxSymbol: convertToBingXSymbol(config.Symbol),
+	}
+	if config.Instrument != "" {
+		ex.bingxSymbol = config.Instrument
+	}
+	ex.Base = baseexchange.New(baseexchange.Config{
+		Name:   exchange.BingX,
+		Symbol: config.Symbol,
+		WSURL:  wsURL,
+		Header: gzipHeader,
+	}, ex)
 	return ex
 }
 
-// GetName returns the exchange name
-func (e *SpotExchange) GetName() exchange.ExchangeName {
-	return exchange.BingX
-}
-
-// GetSymbol returns the trading symbol
-func (e *SpotExchange) GetSymbol() string {
-	return e.symbol
-}
-
-// Connect establishes WebSocket connection to BingX Spot
-func (e *SpotExchange) Connect(ctx context.Context) error {
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	// Add gzip compression support
-	header := map[string][]string{
-		"Accept-Encoding": {"gzip"},
-	}
-
-	conn, _, err := dialer.DialContext(ctx, wsURL, header)
-	if err != nil {
-		e.incrementErrorCount()
-		return fmt.Errorf("websocket connection failed: %w", err)
-	}
-
-	e.wsConn = conn
-	e.updateConnectionStatus(true)
-	log.Printf("[%s] WebSocket connected successfully", e.GetName())
-
-	// Subscribe to incremental depth
-	subMsg := SubscriptionMessage{
-		ID:       uuid.New().String(),
-		ReqType:  "sub",
-		DataType: fmt.Sprintf("%s@incrDepth", e.bingxSymbol),
-	}
-
-	if err := conn.WriteJSON(subMsg); err != nil {
-		e.incrementErrorCount()
-		return fmt.Errorf("failed to subscribe: %w", err)
-	}
-
-	log.Printf("[%s] Subscribed to %s", e.GetName(), subMsg.DataType)
-
-	go e.readMessages()
-	go e.pingLoop()
-
-	return nil
-}
-
-// Close closes the WebSocket connection
-func (e *SpotExchange) Close() error {
-	if e.cancel != nil {
-		e.cancel()
-	}
-
-	if e.wsConn != nil {
-		select {
-		case <-e.done:
-		default:
-			close(e.done)
-		}
-
-		err := e.wsConn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-		if err != nil {
-			log.Printf("[%s] Error sending close message: %v", e.GetName(), err)
-		}
-
-		select {
-		case <-time.After(time.Second):
-		}
-
-		e.updateConnectionStatus(false)
-		return e.wsConn.Close()
-	}
-	return nil
+// Subscribe subscribes to incremental depth
+func (e *SpotExchange) Subscribe() error {
+	return subscribeDepth(e.Base, e.bingxSymbol)
 }
 
 // GetSnapshot waits for and returns the initial orderbook snapshot from WebSocket
 func (e *SpotExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
-	log.Printf("[%s] Waiting for initial snapshot from WebSocket...", e.GetName())
-
-	select {
-	case <-e.snapshotReady:
-		e.snapshotMutex.Lock()
-		snapshot := e.snapshot
-		e.snapshotMutex.Unlock()
-		return snapshot, nil
-	case <-ctx.Done():
-		return nil, fmt.Errorf("context cancelled while waiting for snapshot")
-	case <-time.After(30 * time.Second):
-		return nil, fmt.Errorf("timeout waiting for snapshot")
-	}
-}
-
-// Updates returns a channel that receives depth updates
-func (e *SpotExchange) Updates() <-chan *exchange.DepthUpdate {
-	return e.updateChan
-}
-
-// IsConnected checks if the WebSocket connection is active
-func (e *SpotExchange) IsConnected() bool {
-	return e.wsConn != nil
-}
-
-// Health returns connection health information
-func (e *SpotExchange) Health() exchange.HealthStatus {
-	if status, ok := e.health.Load().(exchange.HealthStatus); ok {
-		return status
-	}
-	return exchange.HealthStatus{}
+	return e.WaitForSnapshot(ctx, 30*time.Second)
 }
 
-// pingLoop sends periodic pings (not needed for BingX, they send pings to us)
-// But we keep the goroutine structure for consistency
-func (e *SpotExchange) pingLoop() {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-e.ctx.Done():
-			return
-		case <-e.done:
-			return
-		case <-ticker.C:
-			// BingX sends pings to us, we just respond with pong
-			// This is just a keepalive check
-			continue
-		}
-	}
-}
-
-// readMessages continuously reads WebSocket messages
-func (e *SpotExchange) readMessages() {
-	defer close(e.updateChan)
-	defer e.updateConnectionStatus(false)
-
-	for {
-		select {
-		case <-e.ctx.Done():
-			log.Printf("[%s] Context cancelled, stopping message reading", e.GetName())
-			return
-		case <-e.done:
-			return
-		default:
-			messageType, message, err := e.wsConn.ReadMessage()
-			if err != nil {
-				e.incrementErrorCount()
-				log.Printf("[%s] WebSocket read error: %v", e.GetName(), err)
-				return
-			}
-
-			if err := e.handleMessage(messageType, message); err != nil {
-				log.Printf("[%s] Error handling message: %v", e.GetName(), err)
-			}
-		}
-	}
+// Capabilities reports sequenced deltas on top of a WebSocket snapshot
+func (e *SpotExchange) Capabilities() exchange.Capabilities {
+	return incrDepthCapabilities
 }
 
-// handleMessage processes incoming WebSocket messages (text or binary/gzip)
-func (e *SpotExchange) handleMessage(messageType int, message []byte) error {
-	var decodedMsg string
-
-	if messageType == websocket.TextMessage {
-		decodedMsg = string(message)
-	} else if messageType == websocket.BinaryMessage {
-		// Decompress gzip
-		decoded, err := decodeGzip(message)
-		if err != nil {
-			e.incrementErrorCount()
-			return fmt.Errorf("failed to decode gzip: %w", err)
-		}
-		decodedMsg = decoded
-	} else {
-		return nil
+// HandleMessage processes incoming WebSocket messages (text or binary/gzip)
+func (e *SpotExchange) HandleMessage(messageType int, message []byte) error {
+	payload, err := decodeMessage(e.Base, messageType, message)
+	if err != nil || payload == nil {
+		return err
 	}
 
 	// Handle ping/pong
-	if strings.Contains(decodedMsg, "ping") || decodedMsg == "ping" {
-		if err := e.wsConn.WriteMessage(websocket.TextMessage, []byte("pong")); err != nil {
+	if strings.Contains(string(payload), "ping") {
+		if err := e.WriteMessage(websocket.TextMessage, []byte("pong")); err != nil {
 			log.Printf("[%s] Failed to send pong: %v", e.GetName(), err)
 		}
 		return nil
@@ -255,7 +90,7 @@ func (e *SpotExchange) handleMessage(messageType int, message []byte) error {
 
 	// Parse JSON message
 	var msg WSMessage
-	if err := json.Unmarshal([]byte(decodedMsg), &msg); err != nil {
+	if err := json.Unmarshal(payload, &msg); err != nil {
 		// Might be a non-JSON message like "pong", ignore
 		return nil
 	}
@@ -265,133 +100,100 @@ func (e *SpotExchange) handleMessage(messageType int, message []byte) error {
 		return fmt.Errorf("BingX error: code=%d, msg=%s", msg.Code, msg.Msg)
 	}
 
-	// Handle depth data
-	if msg.Data.Action == "all" {
-		// This is the initial snapshot
-		e.handleSnapshot(&msg)
-	} else if msg.Data.Action == "update" {
-		// This is an incremental update
-		e.handleUpdate(&msg)
-	}
-
-	e.incrementMessageCount()
-	e.updateLastPing()
+	bids := convertMapLevels(msg.Data.Bids)
+	asks := convertMapLevels(msg.Data.Asks)
+	handleDepth(e.Base, e.symbol, msg.Data.Action, msg.Data.LastUpdateID, bids, asks)
 
+	e.RecordMessage()
 	return nil
 }
 
-// handleSnapshot processes the initial full depth snapshot
-func (e *SpotExchange) handleSnapshot(msg *WSMessage) {
-	e.snapshotMutex.Lock()
-	defer e.snapshotMutex.Unlock()
-
-	if e.hasSnapshot {
-		// Already have snapshot, treat as update
-		return
+// subscribeDepth sends the incremental depth subscription
+func subscribeDepth(b *baseexchange.Base, bingxSymbol string) error {
+	subMsg := SubscriptionMessage{
+		ID:       uuid.New().String(),
+		ReqType:  "sub",
+		DataType: fmt.Sprintf("%s@incrDepth", bingxSymbol),
 	}
 
-	snapshot := e.convertSnapshot(&msg.Data)
-	e.snapshot = snapshot
-	e.hasSnapshot = true
-
-	log.Printf("[%s] Received initial snapshot with lastUpdateId=%d, bids=%d, asks=%d",
-		e.GetName(), snapshot.LastUpdateID, len(snapshot.Bids), len(snapshot.Asks))
-
-	// Signal that snapshot is ready
-	select {
-	case <-e.snapshotReady:
-	default:
-		close(e.snapshotReady)
+	if err := b.WriteJSON(subMsg); err != nil {
+		return err
 	}
-}
-
-// handleUpdate processes incremental depth updates
-func (e *SpotExchange) handleUpdate(msg *WSMessage) {
-	canonicalUpdate := e.convertDepthUpdate(&msg.Data)
 
-	select {
-	case e.updateChan <- canonicalUpdate:
-	case <-e.ctx.Done():
-		return
-	case <-e.done:
-		return
-	default:
-		log.Printf("[%s] Warning: update channel full, skipping update", e.GetName())
-	}
+	log.Printf("[%s] Subscribed to %s", b.GetName(), subMsg.DataType)
+	return nil
 }
 
-// convertSnapshot converts BingX snapshot to canonical format
-func (e *SpotExchange) convertSnapshot(data *DepthData) *exchange.Snapshot {
-	bids := make([]exchange.PriceLevel, 0, len(data.Bids))
-	for price, quantity := range data.Bids {
-		bids = append(bids, exchange.PriceLevel{
-			Price:    price,
-			Quantity: quantity,
-		})
-	}
-
-	asks := make([]exchange.PriceLevel, 0, len(data.Asks))
-	for price, quantity := range data.Asks {
-		asks = append(asks, exchange.PriceLevel{
-			Price:    price,
-			Quantity: quantity,
+// decodeMessage returns the text payload of a message, decompressing binary frames.
+// It returns nil for control frames.
+func decodeMessage(b *baseexchange.Base, messageType int, message []byte) ([]byte, error) {
+	switch messageType {
+	case websocket.TextMessage:
+		return message, nil
+	case websocket.BinaryMessage:
+		decoded, err := decodeGzip(message)
+		if err != nil {
+			b.RecordError()
+			return nil, fmt.Errorf("failed to decode gzip: %w", err)
+		}
+		return decoded, nil
+	default:
+		return nil, nil
+	}
+}
+
+// handleDepth stores the initial "all" snapshot and emits incremental updates
+func handleDepth(b *baseexchange.Base, symbol, action string, lastUpdateID int64, bids, asks []exchange.PriceLevel) {
+	switch action {
+	case "all":
+		// This is the initial snapshot; later full snapshots are ignored
+		snapshot := &exchange.Snapshot{
+			Exchange:     b.GetName(),
+			Symbol:       symbol,
+			LastUpdateID: lastUpdateID,
+			Bids:         bids,
+			Asks:         asks,
+			Timestamp:    b.Now(),
+		}
+		if b.SetSnapshot(snapshot) {
+			log.Printf("[%s] Received initial snapshot with lastUpdateId=%d, bids=%d, asks=%d",
+				b.GetName(), snapshot.LastUpdateID, len(snapshot.Bids), len(snapshot.Asks))
+		}
+	case "update":
+		b.Emit(&exchange.DepthUpdate{
+			Exchange:      b.GetName(),
+			Symbol:        symbol,
+			EventTime:     b.Now(),
+			FirstUpdateID: lastUpdateID,
+			FinalUpdateID: lastUpdateID,
+			PrevUpdateID:  lastUpdateID - 1,
+			Bids:          bids,
+			Asks:          asks,
 		})
 	}
-
-	return &exchange.Snapshot{
-		Exchange:     e.GetName(),
-		Symbol:       e.symbol,
-		LastUpdateID: data.LastUpdateID,
-		Bids:         bids,
-		Asks:         asks,
-		Timestamp:    time.Now(),
-	}
 }
 
-// convertDepthUpdate converts BingX depth update to canonical format
-func (e *SpotExchange) convertDepthUpdate(data *DepthData) *exchange.DepthUpdate {
-	bids := make([]exchange.PriceLevel, 0, len(data.Bids))
-	for price, quantity := range data.Bids {
-		bids = append(bids, exchange.PriceLevel{
+// convertMapLevels converts BingX spot map levels (price -> quantity) to canonical format
+func convertMapLevels(levels map[string]string) []exchange.PriceLevel {
+	result := make([]exchange.PriceLevel, 0, len(levels))
+	for price, quantity := range levels {
+		result = append(result, exchange.PriceLevel{
 			Price:    price,
 			Quantity: quantity,
 		})
 	}
-
-	asks := make([]exchange.PriceLevel, 0, len(data.Asks))
-	for price, quantity := range data.Asks {
-		asks = append(asks, exchange.PriceLevel{
-			Price:    price,
-			Quantity: quantity,
-		})
-	}
-
-	return &exchange.DepthUpdate{
-		Exchange:      e.GetName(),
-		Symbol:        e.symbol,
-		EventTime:     time.Now(),
-		FirstUpdateID: data.LastUpdateID,
-		FinalUpdateID: data.LastUpdateID,
-		PrevUpdateID:  data.LastUpdateID - 1,
-		Bids:          bids,
-		Asks:          asks,
-	}
+	return result
 }
 
 // decodeGzip decompresses gzip-encoded data
-func decodeGzip(data []byte) (string, error) {
+func decodeGzip(data []byte) ([]byte, error) {
 	reader, err := gzip.NewReader(bytes.NewReader(data))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer reader.Close()
 
-	decodedMsg, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return "", err
-	}
-
-	return string(decodedMsg), nil
+	return io.ReadAll(reader)
 }
 
 // convertToBingXSymbol converts various symbol formats to BingX format
@@ -421,43 +223,3 @@ func convertToBingXSymbol(symbol string) string {
 	log.Printf("[BingX] Warning: Could not convert symbol %s to BingX format, using as-is", symbol)
 	return symbol
 }
-
-// updateConnectionStatus updates the connection status in health
-func (e *SpotExchange) updateConnectionStatus(connected bool) {
-	status := e.Health()
-	status.Connected = connected
-	if !connected {
-		now := time.Now()
-		status.ReconnectTime = &now
-	}
-	e.health.Store(status)
-}
-
-// incrementMessageCount increments the message count in health
-func (e *SpotExchange) incrementMessageCount() {
-	status := e.Health()
-	status.MessageCount++
-	e.health.Store(status)
-}
-
-// incrementErrorCount increments the error count in health
-func (e *SpotExchange) incrementErrorCount() {
-	status := e.Health()
-	status.ErrorCount++
-	e.health.Store(status)
-}
-
-// updateLastPing updates the last ping time in health
-func (e *SpotExchange) updateLastPing() {
-	status := e.Health()
-	status.LastPing = time.Now()
-	e.health.Store(status)
-}
-
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
@@ -2,7 +2,8 @@ package bingx
 
 // Config holds configuration for BingX exchange
 type Config struct {
-	Symbol string
+	Symbol     string
+	Instrument string // BingX symbol used verbatim instead of converting Symbol, empty converts Symbol
 }
 
 // SubscriptionMessage represents the subscription request to BingX WebSocket
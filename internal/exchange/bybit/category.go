@@ -0,0 +1,118 @@
+package bybit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// Category is a Bybit v5 product category, each served on its own public stream
+type Category string
+
+// Bybit v5 product categories
+const (
+	CategorySpot    Category = "spot"    // Spot pairs, quantities in the base coin
+	CategoryLinear  Category = "linear"  // USDT and USDC margined contracts, quantities in the base coin
+	CategoryInverse Category = "inverse" // Coin margined contracts, quantities in 1 USD contracts
+	CategoryOption  Category = "option"  // USDC settled options, quantities in contracts of one underlying coin
+)
+
+// Orderbook depths supported by each category, ascending
+var categoryDepths = map[Category][]int{
+	CategorySpot:    {1, 50, 200, 1000},
+	CategoryLinear:  {1, 50, 200, 500, 1000},
+	CategoryInverse: {1, 50, 200, 500, 1000},
+	CategoryOption:  {25, 100},
+}
+
+// optionSymbol matches Bybit option instruments: base, expiry, strike and
+// call or put, optionally followed by the settle coin (e.g., BTC-27DEC24-60000-C)
+var optionSymbol = regexp.MustCompile(`^[A-Z0-9]+-[0-9]{1,2}[A-Z]{3}[0-9]{2}-[0-9]+(\.[0-9]+)?-[CP](-[A-Z]+)?$`)
+
+// inverseContractSize is the USD notional of one inverse contract
+var inverseContractSize = decimal.NewFromInt(1)
+
+// ParseCategory parses "spot", "linear", "inverse" or "option"
+func ParseCategory(s string) (Category, error) {
+	category := Category(strings.ToLower(s))
+	if _, ok := categoryDepths[category]; !ok {
+		return "", fmt.Errorf("unknown Bybit category %q (spot, linear, inverse, option)", s)
+	}
+	return category, nil
+}
+
+// CheckSymbol reports whether symbol names an instrument of the category. Options
+// have no spot-style books: their orderbook topics need an option instrument.
+func (c Category) CheckSymbol(symbol string) error {
+	if c == CategoryOption && !optionSymbol.MatchString(strings.ToUpper(symbol)) {
+		return fmt.Errorf("%q is not a Bybit option instrument (e.g., BTC-27DEC24-60000-C)", symbol)
+	}
+	return nil
+}
+
+// streamURL returns the public orderbook stream of the category
+func (c Category) streamURL() string {
+	return "wss://stream.bybit.com/v5/public/" + string(c)
+}
+
+// depth returns the smallest supported depth covering the requested one, or the
+// deepest supported depth. A depth of 0 selects the deepest.
+func (c Category) depth(requested int) int {
+	depths := categoryDepths[c]
+	if requested > 0 {
+		for _, depth := range depths {
+			if depth >= requested {
+				return depth
+			}
+		}
+	}
+	return depths[len(depths)-1]
+}
+
+// symbol converts a symbol to the category's naming
+// Examples: BTCUSDT -> BTCUSD (inverse), BTCUSDC -> BTCPERP (linear), BTC-27DEC24-60000-C (option)
+func (c Category) symbol(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	switch c {
+	case CategoryInverse:
+		if base, ok := strings.CutSuffix(symbol, "USDT"); ok {
+			return base + "USD"
+		}
+	case CategoryLinear:
+		if base, ok := strings.CutSuffix(symbol, "USDC"); ok {
+			return base + "PERP"
+		}
+	}
+	return symbol
+}
+
+// streamsTrades reports whether the category has a trade topic per symbol. Options
+// publish the trades of all the instruments of a base coin on one topic.
+func (c Category) streamsTrades() bool {
+	return c != CategoryOption
+}
+
+// quoteCurrency returns the quote currency of a book of the category for the requested symbol
+func (c Category) quoteCurrency(symbol string) string {
+	switch c {
+	case CategoryInverse:
+		return "USD"
+	case CategoryOption:
+		return "USDC"
+	}
+	return types.QuoteAsset(symbol)
+}
+
+// normalize converts the quantities of levels to base units in place
+func (c Category) normalize(levels []exchange.PriceLevel) error {
+	if c != CategoryInverse {
+		return nil
+	}
+	return baseexchange.NormalizeInverse(levels, inverseContractSize)
+}
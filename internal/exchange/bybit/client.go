@@ -0,0 +1,366 @@
+package bybit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+)
+
+// client implements the Bybit v5 orderbook stream shared by spot and futures
+type client struct {
+	*baseexchange.Base
+	category  Category
+	requested string // Symbol as configured, before conversion to the category's naming
+	symbol    string
+	depth     int
+	topOfBook bool
+	// Best level of each side in top-of-book mode, only accessed by HandleMessage
+	top [2][]exchange.PriceLevel
+	// Update ID ("u") of the last message forwarded, 0 while waiting for a
+	// snapshot. Bybit numbers the messages of a topic consecutively.
+	lastUpdate atomic.Int64
+	// Trades of the symbol, nil if the category has no trade topic per symbol
+	trades chan *exchange.Trade
+}
+
+// newClient creates a Bybit client for the public stream of a category. The depth
+// is rounded up to one the category supports, 0 uses the deepest; top-of-book mode
+// streams depth 1.
+func newClient(name exchange.ExchangeName, category Category, config Config) *client {
+	if config.TopOfBook {
+		config.Depth = 1
+	}
+	c := &client{
+		category:  category,
+		requested: config.Symbol,
+		symbol:    category.symbol(config.Symbol),
+		depth:     category.depth(config.Depth),
+		topOfBook: config.TopOfBook,
+	}
+	if config.Instrument != "" {
+		c.symbol = config.Instrument
+	}
+	if category.streamsTrades() {
+		c.trades = make(chan *exchange.Trade, 1000)
+	}
+	c.Base = baseexchange.New(baseexchange.Config{
+		Name:      name,
+		Symbol:    c.symbol,
+		WSURL:     category.streamURL(),
+		Keepalive: baseexchange.TextPing(20*time.Second, `{"op":"ping"}`),
+		Clock:     baseexchange.RESTClock("https://api.bybit.com/v5/market/time", baseexchange.UnixMilliField("time")),
+	}, c)
+	return c
+}
+
+// topic returns the orderbook stream at the configured depth
+func (c *client) topic() string {
+	return fmt.Sprintf("orderbook.%d.%s", c.depth, c.symbol)
+}
+
+// tradeTopic returns the public trade stream of the symbol
+func (c *client) tradeTopic() string {
+	return "publicTrade." + c.symbol
+}
+
+// Subscribe subscribes to the orderbook stream at the configured depth and to
+// the trade stream
+func (c *client) Subscribe() error {
+	if err := c.subscribeBook(); err != nil {
+		return err
+	}
+	if c.trades == nil {
+		return nil
+	}
+	if err := c.WriteJSON(SubscribeMessage{Op: "subscribe", Args: []string{c.tradeTopic()}}); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Subscribed to %s", c.GetName(), c.tradeTopic())
+	return nil
+}
+
+// subscribeBook subscribes to the orderbook stream at the configured depth
+func (c *client) subscribeBook() error {
+	// Every subscription starts with a snapshot
+	c.lastUpdate.Store(0)
+	if err := c.WriteJSON(SubscribeMessage{Op: "subscribe", Args: []string{c.topic()}}); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Subscribed to %s", c.GetName(), c.topic())
+	return nil
+}
+
+// resubscribe unsubscribes and subscribes to the book again, which makes Bybit
+// send a fresh snapshot
+func (c *client) resubscribe() error {
+	if err := c.WriteJSON(SubscribeMessage{Op: "unsubscribe", Args: []string{c.topic()}}); err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	return c.subscribeBook()
+}
+
+// Capabilities reports sequenced deltas on top of a WebSocket snapshot, or whole
+// top-of-book updates in top-of-book mode
+func (c *client) Capabilities() exchange.Capabilities {
+	if c.topOfBook {
+		return exchange.Capabilities{
+			FullDepth:      true,
+			SequenceIDs:    true,
+			SnapshotSource: exchange.SnapshotWebSocket,
+			TopOfBook:      true,
+			Trades:         c.trades != nil,
+		}
+	}
+	return exchange.Capabilities{
+		SequenceIDs:    true,
+		SnapshotSource: exchange.SnapshotWebSocket,
+		Trades:         c.trades != nil,
+	}
+}
+
+// Trades returns a channel that receives the trades of the symbol. It is nil
+// on options, which have no trade topic per instrument.
+func (c *client) Trades() <-chan *exchange.Trade {
+	return c.trades
+}
+
+// QuoteCurrency returns the quote of the category's book, which is USD for inverse contracts
+func (c *client) QuoteCurrency() string {
+	return c.category.quoteCurrency(c.requested)
+}
+
+// GetSnapshot waits for the first snapshot message from the WebSocket
+func (c *client) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
+	return c.WaitForSnapshot(ctx, 10*time.Second)
+}
+
+// HandleMessage processes a Bybit WebSocket message
+func (c *client) HandleMessage(messageType int, data []byte) error {
+	var msg WSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	if msg.Op != "" {
+		return c.handleControl(&msg)
+	}
+	if msg.RetCode != 0 {
+		c.RecordError()
+		return fmt.Errorf("service error %d: %s", msg.RetCode, msg.RetMsg)
+	}
+
+	// Skip messages without data
+	if msg.Topic == "" || len(msg.Data) == 0 {
+		return nil
+	}
+	if c.trades != nil && msg.Topic == c.tradeTopic() {
+		return c.handleTrades(&msg)
+	}
+
+	var book OrderbookData
+	if err := json.Unmarshal(msg.Data, &book); err != nil {
+		return fmt.Errorf("failed to decode orderbook: %w", err)
+	}
+	// Skip non-orderbook messages
+	if book.Symbol == "" {
+		return nil
+	}
+
+	c.RecordMessage()
+
+	if !c.advance(msg.Type, book.UpdateID) {
+		return nil
+	}
+
+	// Handle initial snapshot. Later snapshots (e.g., after a reconnect, a gap
+	// or a service restart) are forwarded as full books that reset the orderbook.
+	update, err := c.convertDepthUpdate(&msg, &book)
+	if err != nil {
+		c.RecordError()
+		return fmt.Errorf("failed to normalize update: %w", err)
+	}
+	if c.topOfBook {
+		c.mergeTop(update)
+	}
+	if msg.Type == "snapshot" && !c.HasSnapshot() {
+		c.storeSnapshot(update, time.UnixMilli(msg.TS))
+	}
+
+	c.Emit(update)
+	return nil
+}
+
+// advance tracks the update ID of a book message and reports whether to forward
+// it. A snapshot restarts the sequence; a delta must follow the previous update.
+// After a gap deltas are dropped until the snapshot requested by resubscribing.
+func (c *client) advance(kind string, id int64) bool {
+	if kind == "snapshot" {
+		c.lastUpdate.Store(id)
+		return true
+	}
+
+	last := c.lastUpdate.Load()
+	switch {
+	case last == 0:
+		// Waiting for the snapshot of a new subscription
+		return false
+	case id <= last:
+		// Already forwarded
+		return false
+	case id != last+1:
+		c.lastUpdate.Store(0)
+		c.RecordError()
+		log.Printf("[%s] Sequence gap: expected update %d, got %d, resubscribing for a fresh snapshot", c.GetName(), last+1, id)
+		if err := c.resubscribe(); err != nil {
+			log.Printf("[%s] Failed to resubscribe: %v", c.GetName(), err)
+		}
+		return false
+	}
+	c.lastUpdate.Store(id)
+	return true
+}
+
+// handleTrades forwards the trades of a trade message, dropping them if the
+// channel is full
+func (c *client) handleTrades(msg *WSMessage) error {
+	var trades []TradeData
+	if err := json.Unmarshal(msg.Data, &trades); err != nil {
+		return fmt.Errorf("failed to decode trades: %w", err)
+	}
+
+	c.RecordMessage()
+	for i := range trades {
+		trade, err := c.convertTrade(&trades[i])
+		if err != nil {
+			c.RecordError()
+			return fmt.Errorf("failed to normalize trade: %w", err)
+		}
+		select {
+		case c.trades <- trade:
+		default:
+			log.Printf("[%s] Warning: trade channel full, skipping trade", c.GetName())
+		}
+	}
+	return nil
+}
+
+// handleControl processes subscription acks and heartbeat replies
+func (c *client) handleControl(msg *WSMessage) error {
+	switch msg.Op {
+	case "subscribe":
+		if !msg.Success {
+			c.RecordError()
+			err := fmt.Errorf("subscription failed: %s", msg.RetMsg)
+			if strings.Contains(msg.RetMsg, "Invalid symbol") {
+				err = fmt.Errorf("%w: %s", exchange.ErrSymbolNotFound, msg.RetMsg)
+			}
+			c.FailSnapshot(err)
+			return err
+		}
+		log.Printf("[%s] Subscription confirmed", c.GetName())
+	case "unsubscribe":
+		// Followed by the subscription of a resync
+	case "ping", "pong":
+		// Heartbeat reply; the read loop already refreshed the connection activity
+	default:
+		log.Printf("[%s] Unhandled control message: op=%s ret_msg=%s", c.GetName(), msg.Op, msg.RetMsg)
+	}
+	return nil
+}
+
+// mergeTop applies a depth 1 message to the held best levels and replaces its
+// levels with the whole top of book
+func (c *client) mergeTop(update *exchange.DepthUpdate) {
+	if update.Snapshot {
+		c.top = [2][]exchange.PriceLevel{nil, nil}
+	}
+	c.top[0] = mergeTopLevel(c.top[0], update.Bids)
+	c.top[1] = mergeTopLevel(c.top[1], update.Asks)
+	update.Bids = append([]exchange.PriceLevel(nil), c.top[0]...)
+	update.Asks = append([]exchange.PriceLevel(nil), c.top[1]...)
+}
+
+// mergeTopLevel applies the changes of a depth 1 message to the best level of a
+// side: a new level replaces it and removing its price clears it
+func mergeTopLevel(best, changes []exchange.PriceLevel) []exchange.PriceLevel {
+	for _, level := range changes {
+		qty, err := decimal.NewFromString(level.Quantity)
+		switch {
+		case err != nil || !qty.IsZero():
+			best = []exchange.PriceLevel{level}
+		case len(best) > 0 && best[0].Price == level.Price:
+			best = nil
+		}
+	}
+	return best
+}
+
+// storeSnapshot stores the book of the initial snapshot update
+func (c *client) storeSnapshot(update *exchange.DepthUpdate, at time.Time) {
+	c.SetSnapshot(&exchange.Snapshot{
+		Exchange:     c.GetName(),
+		Symbol:       update.Symbol,
+		LastUpdateID: update.FinalUpdateID,
+		Bids:         append([]exchange.PriceLevel(nil), update.Bids...),
+		Asks:         append([]exchange.PriceLevel(nil), update.Asks...),
+		Timestamp:    at,
+	})
+}
+
+// convertDepthUpdate converts Bybit depth update to canonical format, with
+// quantities in base units. Update IDs are consecutive, so a delta continues
+// the update before it.
+func (c *client) convertDepthUpdate(msg *WSMessage, book *OrderbookData) (*exchange.DepthUpdate, error) {
+	bids := baseexchange.ConvertLevels(book.Bids)
+	asks := baseexchange.ConvertLevels(book.Asks)
+	if err := c.category.normalize(bids); err != nil {
+		return nil, err
+	}
+	if err := c.category.normalize(asks); err != nil {
+		return nil, err
+	}
+
+	return &exchange.DepthUpdate{
+		Exchange:      c.GetName(),
+		Symbol:        book.Symbol,
+		EventTime:     time.UnixMilli(msg.TS),
+		FirstUpdateID: book.UpdateID,
+		FinalUpdateID: book.UpdateID,
+		PrevUpdateID:  book.UpdateID - 1,
+		Bids:          bids,
+		Asks:          asks,
+		Snapshot:      msg.Type == "snapshot",
+	}, nil
+}
+
+// convertTrade converts a Bybit trade to canonical format, with the quantity in
+// base units
+func (c *client) convertTrade(trade *TradeData) (*exchange.Trade, error) {
+	level := []exchange.PriceLevel{{Price: trade.Price, Quantity: trade.Size}}
+	if err := c.category.normalize(level); err != nil {
+		return nil, err
+	}
+
+	side := exchange.TradeSideBuy
+	if trade.Side == "Sell" {
+		side = exchange.TradeSideSell
+	}
+	return &exchange.Trade{
+		Exchange:  c.GetName(),
+		Symbol:    trade.Symbol,
+		Price:     trade.Price,
+		Quantity:  level[0].Quantity,
+		Side:      side,
+		TradeTime: time.UnixMilli(trade.Time),
+	}, nil
+}
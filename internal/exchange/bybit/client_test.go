@@ -0,0 +1,229 @@
+package bybit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+func bookMessage(kind string, updateID int64) []byte {
+	return []byte(fmt.Sprintf(`{"topic":"orderbook.50.BTCUSDT","type":%q,"ts":1700000000000,"data":{"s":"BTCUSDT","b":[["100","1"]],"a":[],"u":%d,"seq":%d}}`,
+		kind, updateID, updateID*7))
+}
+
+func TestGapRequestsSnapshot(t *testing.T) {
+	c := newClient(exchange.Bybit, CategorySpot, Config{Symbol: "BTCUSDT", Depth: 50})
+	defer c.Close()
+
+	messages := []struct {
+		kind     string
+		updateID int64
+	}{
+		{"snapshot", 10},
+		{"delta", 11},
+		{"delta", 11}, // Duplicate
+		{"delta", 13}, // Gap, resubscribes
+		{"delta", 14}, // Dropped until the snapshot
+		{"snapshot", 20},
+		{"delta", 21},
+	}
+	for _, msg := range messages {
+		if err := c.HandleMessage(1, bookMessage(msg.kind, msg.updateID)); err != nil {
+			t.Fatalf("HandleMessage() failed: %v", err)
+		}
+	}
+
+	expected := []struct {
+		final    int64
+		prev     int64
+		snapshot bool
+	}{
+		{10, 9, true},
+		{11, 10, false},
+		{20, 19, true},
+		{21, 20, false},
+	}
+	for _, want := range expected {
+		select {
+		case update := <-c.Updates():
+			if update.FinalUpdateID != want.final || update.PrevUpdateID != want.prev || update.Snapshot != want.snapshot {
+				t.Errorf("Expected update %d (prev %d, snapshot %v), got %d (prev %d, snapshot %v)",
+					want.final, want.prev, want.snapshot, update.FinalUpdateID, update.PrevUpdateID, update.Snapshot)
+			}
+		default:
+			t.Fatalf("Expected update %d, got none", want.final)
+		}
+	}
+	select {
+	case update := <-c.Updates():
+		t.Errorf("Expected no more updates, got %d", update.FinalUpdateID)
+	default:
+	}
+	if errors := c.Health().ErrorCount; errors != 1 {
+		t.Errorf("Expected the gap to be counted as one error, got %d", errors)
+	}
+}
+
+func TestInverseCategoryNormalizesContracts(t *testing.T) {
+	c := newClient(exchange.Bybitf, CategoryInverse, Config{Symbol: "BTCUSDT", Depth: 300})
+	defer c.Close()
+
+	if c.GetSymbol() != "BTCUSD" || c.topic() != "orderbook.500.BTCUSD" {
+		t.Errorf("Expected orderbook.500.BTCUSD, got %s", c.topic())
+	}
+	if quote := c.QuoteCurrency(); quote != "USD" {
+		t.Errorf("Expected quote USD, got %s", quote)
+	}
+
+	msg := `{"topic":"orderbook.500.BTCUSD","type":"snapshot","ts":1700000000000,"data":{"s":"BTCUSD","b":[["50000","1000"]],"a":[["50010","0"]],"u":1}}`
+	if err := c.HandleMessage(1, []byte(msg)); err != nil {
+		t.Fatalf("HandleMessage() failed: %v", err)
+	}
+	update := <-c.Updates()
+	// 1000 contracts of 1 USD at 50000 is 0.02 BTC
+	if got := update.Bids[0].Quantity; got != "0.02000000" {
+		t.Errorf("Expected bid quantity 0.02000000, got %s", got)
+	}
+	if got := update.Asks[0].Quantity; got != "0" {
+		t.Errorf("Expected removed ask to keep quantity 0, got %s", got)
+	}
+}
+
+func TestCategoryMapping(t *testing.T) {
+	tests := []struct {
+		category Category
+		symbol   string
+		depth    int
+		want     string
+	}{
+		{CategorySpot, "BTCUSDT", 0, "orderbook.1000.BTCUSDT"},
+		{CategorySpot, "BTCUSDC", 500, "orderbook.1000.BTCUSDC"},
+		{CategoryLinear, "BTCUSDT", 50, "orderbook.50.BTCUSDT"},
+		{CategoryLinear, "ethusdc", 0, "orderbook.1000.ETHPERP"},
+		{CategoryInverse, "BTCUSD", 1, "orderbook.1.BTCUSD"},
+		{CategoryOption, "BTC-27DEC24-60000-C", 0, "orderbook.100.BTC-27DEC24-60000-C"},
+		{CategoryOption, "BTC-27DEC24-60000-C", 10, "orderbook.25.BTC-27DEC24-60000-C"},
+	}
+
+	for _, tt := range tests {
+		c := newClient(exchange.Bybitf, tt.category, Config{Symbol: tt.symbol, Depth: tt.depth})
+		if got := c.topic(); got != tt.want {
+			t.Errorf("%s %s at depth %d: Expected %s, got %s", tt.category, tt.symbol, tt.depth, tt.want, got)
+		}
+		c.Close()
+	}
+
+	// An explicit instrument bypasses the category's naming
+	dated := newClient(exchange.Bybitf, CategoryInverse, Config{Symbol: "BTCUSDT", Instrument: "BTCUSDH25"})
+	defer dated.Close()
+	if got := dated.topic(); got != "orderbook.1000.BTCUSDH25" {
+		t.Errorf("Expected orderbook.1000.BTCUSDH25, got %s", got)
+	}
+
+	if _, err := ParseCategory("futures"); err == nil {
+		t.Errorf("Expected an error for an unknown category")
+	}
+
+	// Options only have books for option instruments
+	for symbol, valid := range map[string]bool{
+		"BTC-27DEC24-60000-C":      true,
+		"eth-3JAN25-3500-P":        true,
+		"SOL-28MAR25-187.5-C-USDT": true,
+		"BTCUSDT":                  false,
+		"BTC-27DEC24":              false,
+		"BTC-27DEC24-60000-X":      false,
+	} {
+		if err := CategoryOption.CheckSymbol(symbol); (err == nil) != valid {
+			t.Errorf("CheckSymbol(%q): Expected valid=%v, got %v", symbol, valid, err)
+		}
+	}
+	if err := CategoryLinear.CheckSymbol("BTCUSDT"); err != nil {
+		t.Errorf("Expected linear symbols to pass, got %v", err)
+	}
+}
+
+func TestTopOfBookMergesDeltas(t *testing.T) {
+	c := newClient(exchange.Bybitf, CategoryLinear, Config{Symbol: "BTCUSDT", Depth: 200, TopOfBook: true})
+	defer c.Close()
+
+	if c.topic() != "orderbook.1.BTCUSDT" {
+		t.Errorf("Expected orderbook.1.BTCUSDT, got %s", c.topic())
+	}
+	if caps := c.Capabilities(); !caps.FullDepth || !caps.TopOfBook {
+		t.Errorf("Expected whole top-of-book updates, got %+v", caps)
+	}
+
+	messages := []string{
+		`{"topic":"orderbook.1.BTCUSDT","type":"snapshot","ts":1700000000000,"data":{"s":"BTCUSDT","b":[["100","1"]],"a":[["101","2"]],"u":1}}`,
+		// The bid moves up, the ask is unchanged
+		`{"topic":"orderbook.1.BTCUSDT","type":"delta","ts":1700000000010,"data":{"s":"BTCUSDT","b":[["100.5","3"],["100","0"]],"a":[],"u":2}}`,
+		// The ask is removed
+		`{"topic":"orderbook.1.BTCUSDT","type":"delta","ts":1700000000020,"data":{"s":"BTCUSDT","b":[],"a":[["101","0"]],"u":3}}`,
+	}
+	for _, msg := range messages {
+		if err := c.HandleMessage(1, []byte(msg)); err != nil {
+			t.Fatalf("HandleMessage() failed: %v", err)
+		}
+	}
+
+	expected := []struct {
+		bids, asks []exchange.PriceLevel
+	}{
+		{[]exchange.PriceLevel{{Price: "100", Quantity: "1"}}, []exchange.PriceLevel{{Price: "101", Quantity: "2"}}},
+		{[]exchange.PriceLevel{{Price: "100.5", Quantity: "3"}}, []exchange.PriceLevel{{Price: "101", Quantity: "2"}}},
+		{[]exchange.PriceLevel{{Price: "100.5", Quantity: "3"}}, nil},
+	}
+	for i, want := range expected {
+		update := <-c.Updates()
+		if fmt.Sprint(update.Bids) != fmt.Sprint(want.bids) || len(update.Asks) != len(want.asks) ||
+			(len(want.asks) > 0 && update.Asks[0] != want.asks[0]) {
+			t.Errorf("Update %d: Expected bids %v and asks %v, got %v and %v", i, want.bids, want.asks, update.Bids, update.Asks)
+		}
+	}
+}
+
+func TestTradeStream(t *testing.T) {
+	c := newClient(exchange.Bybitf, CategoryInverse, Config{Symbol: "BTCUSDT"})
+	defer c.Close()
+
+	if !c.Capabilities().Trades || c.tradeTopic() != "publicTrade.BTCUSD" {
+		t.Fatalf("Expected trades on publicTrade.BTCUSD, got %s (%+v)", c.tradeTopic(), c.Capabilities())
+	}
+
+	msg := `{"topic":"publicTrade.BTCUSD","type":"snapshot","ts":1700000000100,"data":[` +
+		`{"T":1700000000050,"s":"BTCUSD","S":"Buy","v":"500","p":"50000","L":"PlusTick","i":"a1","BT":false},` +
+		`{"T":1700000000060,"s":"BTCUSD","S":"Sell","v":"1000","p":"49990","L":"MinusTick","i":"a2","BT":false}]}`
+	if err := c.HandleMessage(1, []byte(msg)); err != nil {
+		t.Fatalf("HandleMessage() failed: %v", err)
+	}
+
+	expected := []exchange.Trade{
+		// 500 contracts of 1 USD at 50000 is 0.01 BTC
+		{Exchange: exchange.Bybitf, Symbol: "BTCUSD", Price: "50000", Quantity: "0.01000000", Side: exchange.TradeSideBuy, TradeTime: time.UnixMilli(1700000000050)},
+		{Exchange: exchange.Bybitf, Symbol: "BTCUSD", Price: "49990", Quantity: "0.02000400", Side: exchange.TradeSideSell, TradeTime: time.UnixMilli(1700000000060)},
+	}
+	for _, want := range expected {
+		select {
+		case trade := <-c.Trades():
+			if *trade != want {
+				t.Errorf("Expected trade %+v, got %+v", want, *trade)
+			}
+		default:
+			t.Fatalf("Expected trade at %s, got none", want.Price)
+		}
+	}
+	select {
+	case update := <-c.Updates():
+		t.Errorf("Expected trades to leave the book alone, got update %d", update.FinalUpdateID)
+	default:
+	}
+
+	// Options have no trade topic per instrument
+	option := newClient(exchange.Bybitf, CategoryOption, Config{Symbol: "BTC-27DEC24-60000-C"})
+	defer option.Close()
+	if option.Capabilities().Trades || option.Trades() != nil {
+		t.Errorf("Expected no trade stream on options")
+	}
+}
@@ -0,0 +1,43 @@
+package bybit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/shopspring/decimal"
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+)
+
+// tickersURL is the REST market data endpoint carrying the open interest
+const tickersURL = "https://api.bybit.com/v5/market/tickers"
+
+// GetOpenInterest fetches the open interest of linear and inverse contracts in
+// base units. Inverse contracts are converted at the mark price.
+func (c *client) GetOpenInterest(ctx context.Context) (decimal.Decimal, error) {
+	if c.category != CategoryLinear && c.category != CategoryInverse {
+		return decimal.Zero, fmt.Errorf("%s category: %w", c.category, exchange.ErrNoOpenInterest)
+	}
+
+	query := url.Values{"category": {string(c.category)}, "symbol": {c.symbol}}
+	var resp TickersResponse
+	if err := baseexchange.GetJSON(ctx, tickersURL+"?"+query.Encode(), &resp); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get tickers: %w", err)
+	}
+	if resp.RetCode != 0 {
+		return decimal.Zero, fmt.Errorf("tickers request failed: %s (code %d)", resp.RetMsg, resp.RetCode)
+	}
+	if len(resp.Result.List) == 0 {
+		return decimal.Zero, fmt.Errorf("%s in tickers: %w", c.symbol, exchange.ErrSymbolNotFound)
+	}
+
+	ticker := resp.Result.List[0]
+	if c.category != CategoryInverse {
+		return ticker.OpenInterest, nil
+	}
+	if !ticker.MarkPrice.IsPositive() {
+		return decimal.Zero, fmt.Errorf("missing mark price for %s", c.symbol)
+	}
+	return ticker.OpenInterest.Mul(inverseContractSize).Div(ticker.MarkPrice), nil
+}
@@ -1,12 +1,24 @@
 package bybit
 
+import (
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+)
+
 // WSMessage represents a WebSocket message from Bybit
 type WSMessage struct {
-	Topic string        `json:"topic"`
-	Type  string        `json:"type"` // "snapshot" or "delta"
-	TS    int64         `json:"ts"`
-	Data  OrderbookData `json:"data"`
-	CTS   int64         `json:"cts"` // matching engine timestamp
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"` // "snapshot" or "delta"
+	TS    int64           `json:"ts"`
+	Data  json.RawMessage `json:"data"` // OrderbookData, or []TradeData on trade topics
+	CTS   int64           `json:"cts"`  // matching engine timestamp
+
+	// Control message fields (subscription acks, pongs and service errors)
+	Op      string `json:"op"`       // "subscribe", "ping" or "pong"
+	Success bool   `json:"success"`  // Request outcome
+	RetMsg  string `json:"ret_msg"`  // Result message (e.g., "pong")
+	RetCode int    `json:"ret_code"` // Non-zero on service errors
 }
 
 // OrderbookData represents the orderbook data from Bybit
@@ -18,8 +30,34 @@ type OrderbookData struct {
 	SeqNum   int64      `json:"seq"`
 }
 
+// TradeData represents a public trade from Bybit
+type TradeData struct {
+	Time   int64  `json:"T"` // Trade timestamp in ms
+	Symbol string `json:"s"`
+	Side   string `json:"S"` // Taker side, "Buy" or "Sell"
+	Size   string `json:"v"`
+	Price  string `json:"p"`
+	ID     string `json:"i"`
+}
+
 // SubscribeMessage represents a subscription request
 type SubscribeMessage struct {
 	Op   string   `json:"op"`
 	Args []string `json:"args"`
 }
+
+// TickersResponse represents the REST tickers of a category
+type TickersResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		List []Ticker `json:"list"`
+	} `json:"result"`
+}
+
+// Ticker represents the market data of a symbol
+type Ticker struct {
+	Symbol       string          `json:"symbol"`
+	MarkPrice    decimal.Decimal `json:"markPrice"`
+	OpenInterest decimal.Decimal `json:"openInterest"` // Base units, or USD contracts on inverse
+}
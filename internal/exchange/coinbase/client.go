@@ -0,0 +1,176 @@
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+)
+
+// client implements the Advanced Trade level2 stream of Coinbase spot
+type client struct {
+	*baseexchange.Base
+	symbol string
+}
+
+// newClient creates a Coinbase client for a product
+func newClient(name exchange.ExchangeName, productID string) *client {
+	c := &client{symbol: productID}
+	c.Base = baseexchange.New(baseexchange.Config{
+		Name:      name,
+		Symbol:    productID,
+		WSURL:     "wss://advanced-trade-ws.coinbase.com",
+		Keepalive: baseexchange.PingFrames(30 * time.Second),
+		Clock:     baseexchange.RESTClock("https://api.coinbase.com/api/v3/brokerage/time", baseexchange.UnixMilliField("epochMillis")),
+	}, c)
+	return c
+}
+
+// Subscribe subscribes to the level2 channel
+func (e *client) Subscribe() error {
+	subscribeMsg := SubscribeRequest{
+		Type:       "subscribe",
+		ProductIDs: []string{e.symbol},
+		Channel:    "level2",
+	}
+
+	if err := e.WriteJSON(subscribeMsg); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Subscribed to level2 channel for %s", e.GetName(), e.symbol)
+	return nil
+}
+
+// GetSnapshot waits for the initial orderbook snapshot from the WebSocket
+func (e *client) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
+	return e.WaitForSnapshot(ctx, 10*time.Second)
+}
+
+// Capabilities reports unsequenced deltas on top of a WebSocket snapshot
+func (e *client) Capabilities() exchange.Capabilities {
+	return exchange.Capabilities{
+		SnapshotSource: exchange.SnapshotWebSocket,
+	}
+}
+
+// HandleMessage processes a Coinbase WebSocket message
+func (e *client) HandleMessage(messageType int, data []byte) error {
+	var msg WSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil
+	}
+
+	if msg.Channel != "l2_data" || len(msg.Events) == 0 {
+		return nil
+	}
+
+	e.RecordMessage()
+
+	event := msg.Events[0]
+
+	if event.Type == "snapshot" && !e.HasSnapshot() {
+		e.storeSnapshot(&event)
+	}
+
+	if event.Type == "update" {
+		e.Emit(e.convertDepthUpdate(&event))
+	}
+	return nil
+}
+
+// storeSnapshot converts and stores the initial snapshot
+func (e *client) storeSnapshot(event *Event) {
+	var allBids, allAsks []exchange.PriceLevel
+
+	for _, update := range event.Updates {
+		if update.NewQuantity == "0" {
+			continue
+		}
+
+		priceLevel := exchange.PriceLevel{
+			Price:    update.PriceLevel,
+			Quantity: update.NewQuantity,
+		}
+
+		if update.Side == "bid" {
+			allBids = append(allBids, priceLevel)
+		} else if update.Side == "ask" || update.Side == "offer" {
+			allAsks = append(allAsks, priceLevel)
+		}
+	}
+
+	e.SetSnapshot(&exchange.Snapshot{
+		Exchange:     e.GetName(),
+		Symbol:       event.ProductID,
+		LastUpdateID: 0,
+		Bids:         allBids,
+		Asks:         allAsks,
+		Timestamp:    e.Now(),
+	})
+}
+
+// convertDepthUpdate converts Coinbase depth update to canonical format
+func (e *client) convertDepthUpdate(event *Event) *exchange.DepthUpdate {
+	var bids []exchange.PriceLevel
+	var asks []exchange.PriceLevel
+
+	for _, update := range event.Updates {
+		priceLevel := exchange.PriceLevel{
+			Price:    update.PriceLevel,
+			Quantity: update.NewQuantity,
+		}
+
+		if update.Side == "bid" {
+			bids = append(bids, priceLevel)
+		} else if update.Side == "ask" || update.Side == "offer" {
+			asks = append(asks, priceLevel)
+		}
+	}
+
+	eventTime := e.Now()
+
+	return &exchange.DepthUpdate{
+		Exchange:      e.GetName(),
+		Symbol:        event.ProductID,
+		EventTime:     eventTime,
+		FirstUpdateID: 0,
+		FinalUpdateID: 0,
+		PrevUpdateID:  0,
+		Bids:          bids,
+		Asks:          asks,
+	}
+}
+
+// convertToCoinbaseSymbol converts various symbol formats to Coinbase format
+// Examples: BTCUSDT -> BTC-USD, BTC-USD -> BTC-USD
+func convertToCoinbaseSymbol(symbol string) string {
+	if strings.Contains(symbol, "-") {
+		return strings.ToUpper(symbol)
+	}
+
+	symbol = strings.ToUpper(symbol)
+
+	if strings.HasSuffix(symbol, "USDT") {
+		base := strings.TrimSuffix(symbol, "USDT")
+		return fmt.Sprintf("%s-USD", base)
+	}
+
+	if strings.HasSuffix(symbol, "USD") && !strings.HasSuffix(symbol, "USDT") {
+		base := strings.TrimSuffix(symbol, "USD")
+		return fmt.Sprintf("%s-USD", base)
+	}
+
+	if strings.HasSuffix(symbol, "USDC") {
+		base := strings.TrimSuffix(symbol, "USDC")
+		return fmt.Sprintf("%s-USDC", base)
+	}
+
+	log.Printf("[Coinbase] Warning: Could not convert symbol %s to Coinbase format, using as-is", symbol)
+	return symbol
+}
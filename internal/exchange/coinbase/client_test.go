@@ -0,0 +1,74 @@
+package coinbase
+
+import (
+	"context"
+	"testing"
+
+	"orderbook/internal/exchange"
+)
+
+func TestConvertToCoinbaseSymbol(t *testing.T) {
+	tests := map[string]string{
+		"BTCUSDT": "BTC-USD",
+		"ethusd":  "ETH-USD",
+		"SOLUSDC": "SOL-USDC",
+		"btc-eur": "BTC-EUR",
+	}
+	for symbol, want := range tests {
+		if got := convertToCoinbaseSymbol(symbol); got != want {
+			t.Errorf("convertToCoinbaseSymbol(%q): Expected %s, got %s", symbol, want, got)
+		}
+	}
+
+	e := NewSpotExchange(Config{Symbol: "SOLUSDC"})
+	defer e.Close()
+	if quote := e.QuoteCurrency(); quote != "USDC" {
+		t.Errorf("Expected quote USDC, got %s", quote)
+	}
+}
+
+func TestSpotLevel2(t *testing.T) {
+	e := NewSpotExchange(Config{Symbol: "BTCUSDT"})
+	defer e.Close()
+
+	messages := []string{
+		`{"channel":"subscriptions","events":[{"subscriptions":{"level2":["BTC-USD"]}}]}`,
+		`{"channel":"l2_data","timestamp":"2024-05-03T21:50:22.454Z","events":[{"type":"snapshot","product_id":"BTC-USD","updates":[` +
+			`{"side":"bid","event_time":"2024-05-03T21:50:22.454Z","price_level":"60000.01","new_quantity":"1.5"},` +
+			`{"side":"bid","event_time":"2024-05-03T21:50:22.454Z","price_level":"59999","new_quantity":"0"},` +
+			`{"side":"offer","event_time":"2024-05-03T21:50:22.454Z","price_level":"60000.02","new_quantity":"0.25"}]}]}`,
+		`{"channel":"l2_data","timestamp":"2024-05-03T21:50:22.500Z","events":[{"type":"update","product_id":"BTC-USD","updates":[` +
+			`{"side":"bid","event_time":"2024-05-03T21:50:22.499Z","price_level":"60000.01","new_quantity":"0"},` +
+			`{"side":"offer","event_time":"2024-05-03T21:50:22.499Z","price_level":"60000.05","new_quantity":"2"}]}]}`,
+	}
+	for _, msg := range messages {
+		if err := e.HandleMessage(1, []byte(msg)); err != nil {
+			t.Fatalf("HandleMessage() failed: %v", err)
+		}
+	}
+
+	snapshot, err := e.GetSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("GetSnapshot() failed: %v", err)
+	}
+	// Empty levels are left out of the snapshot
+	if snapshot.Symbol != "BTC-USD" || len(snapshot.Bids) != 1 || len(snapshot.Asks) != 1 ||
+		snapshot.Asks[0] != (exchange.PriceLevel{Price: "60000.02", Quantity: "0.25"}) {
+		t.Errorf("Expected one bid and one offer in the snapshot, got %+v", snapshot)
+	}
+
+	select {
+	case update := <-e.Updates():
+		if len(update.Bids) != 1 || update.Bids[0] != (exchange.PriceLevel{Price: "60000.01", Quantity: "0"}) ||
+			len(update.Asks) != 1 || update.Asks[0] != (exchange.PriceLevel{Price: "60000.05", Quantity: "2"}) {
+			t.Errorf("Expected the bid removed and an offer added, got %+v", update)
+		}
+	default:
+		t.Fatalf("Expected an update, got none")
+	}
+	select {
+	case update := <-e.Updates():
+		t.Errorf("Expected the snapshot to be stored rather than emitted, got %+v", update)
+	default:
+	}
+}
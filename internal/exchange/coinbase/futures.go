@@ -0,0 +1,263 @@
+package coinbase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+)
+
+// Environment variables holding the Coinbase International API key, which its
+// market data feed requires. The adapter reads them itself so the secret stays
+// out of the configuration forwarded to collectors.
+const (
+	EnvINTXKey        = "ORDERBOOK_COINBASE_INTX_KEY"
+	EnvINTXSecret     = "ORDERBOOK_COINBASE_INTX_SECRET" // Base64-encoded, as issued
+	EnvINTXPassphrase = "ORDERBOOK_COINBASE_INTX_PASSPHRASE"
+)
+
+const (
+	intxWSURL   = "wss://ws-md.international.coinbase.com"
+	intxRestURL = "https://api.international.coinbase.com"
+)
+
+// FuturesExchange implements the Exchange interface for the Coinbase International
+// Exchange (INTX) perpetuals, streamed from its LEVEL2 market data channel
+type FuturesExchange struct {
+	*baseexchange.Base
+	symbol     string
+	key        string
+	secret     string
+	passphrase string
+	restURL    string
+	quote      atomic.Pointer[string] // Quote asset of the instrument, loaded on Connect
+}
+
+// NewFuturesExchange creates a new Coinbase International perpetuals exchange instance.
+// Empty credentials in config are read from the environment.
+func NewFuturesExchange(config Config) *FuturesExchange {
+	symbol := convertToPerpInstrument(config.Symbol)
+	if config.Instrument != "" {
+		symbol = strings.ToUpper(config.Instrument)
+	}
+	e := &FuturesExchange{
+		symbol:     symbol,
+		key:        config.Key,
+		secret:     config.Secret,
+		passphrase: config.Passphrase,
+		restURL:    intxRestURL,
+	}
+	if e.key == "" {
+		e.key = os.Getenv(EnvINTXKey)
+		e.secret = os.Getenv(EnvINTXSecret)
+		e.passphrase = os.Getenv(EnvINTXPassphrase)
+	}
+	e.Base = baseexchange.New(baseexchange.Config{
+		Name:      exchange.Coinbasef,
+		Symbol:    symbol,
+		WSURL:     intxWSURL,
+		Keepalive: baseexchange.PingFrames(30 * time.Second),
+	}, e)
+	return e
+}
+
+// Capabilities reports unsequenced changes on top of a WebSocket snapshot
+func (e *FuturesExchange) Capabilities() exchange.Capabilities {
+	return exchange.Capabilities{
+		SnapshotSource: exchange.SnapshotWebSocket,
+	}
+}
+
+// QuoteCurrency returns the quote asset of the instrument as listed by the venue
+// once connected, and before that the quote in its name (USDC, in which the
+// perpetuals are margined and quoted, for "-PERP" instruments)
+func (e *FuturesExchange) QuoteCurrency() string {
+	if quote := e.quote.Load(); quote != nil {
+		return *quote
+	}
+	if _, quote, ok := strings.Cut(e.symbol, "-"); ok && quote != "PERP" {
+		return quote
+	}
+	return "USDC"
+}
+
+// Connect checks the credentials and the instrument, then opens the stream
+func (e *FuturesExchange) Connect(ctx context.Context) error {
+	if e.key == "" || e.secret == "" || e.passphrase == "" {
+		return fmt.Errorf("%w: Coinbase International market data requires an API key (%s, %s and %s)",
+			exchange.ErrAuth, EnvINTXKey, EnvINTXSecret, EnvINTXPassphrase)
+	}
+	if _, err := e.subscribeRequest(time.Now()); err != nil {
+		return err
+	}
+	if err := e.loadInstrument(ctx); err != nil {
+		return err
+	}
+	return e.Base.Connect(ctx)
+}
+
+// loadInstrument fetches the instrument to validate it and record its quote asset
+func (e *FuturesExchange) loadInstrument(ctx context.Context) error {
+	if e.quote.Load() != nil {
+		return nil
+	}
+
+	var instrument INTXInstrument
+	if err := baseexchange.GetJSON(ctx, e.restURL+"/api/v1/instruments/"+e.symbol, &instrument); err != nil {
+		return fmt.Errorf("failed to load instrument %s: %w", e.symbol, err)
+	}
+	if instrument.Symbol != e.symbol || instrument.QuoteAssetName == "" {
+		return fmt.Errorf("%w: %s", exchange.ErrSymbolNotFound, e.symbol)
+	}
+	e.quote.Store(&instrument.QuoteAssetName)
+	return nil
+}
+
+// Subscribe subscribes to the LEVEL2 channel with a signed request
+func (e *FuturesExchange) Subscribe() error {
+	request, err := e.subscribeRequest(time.Now())
+	if err != nil {
+		return err
+	}
+	if err := e.WriteJSON(request); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Subscribed to LEVEL2 channel for %s", e.GetName(), e.symbol)
+	return nil
+}
+
+// subscribeRequest builds the LEVEL2 subscription signed at now: the signature is
+// the HMAC-SHA256 of time, key, "CBINTLMD" and passphrase with the decoded secret
+func (e *FuturesExchange) subscribeRequest(now time.Time) (INTXSubscribeRequest, error) {
+	secret, err := base64.StdEncoding.DecodeString(e.secret)
+	if err != nil {
+		return INTXSubscribeRequest{}, fmt.Errorf("%w: %s is not base64: %v", exchange.ErrAuth, EnvINTXSecret, err)
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + e.key + "CBINTLMD" + e.passphrase))
+
+	return INTXSubscribeRequest{
+		Type:       "SUBSCRIBE",
+		ProductIDs: []string{e.symbol},
+		Channels:   []string{"LEVEL2"},
+		Time:       timestamp,
+		Key:        e.key,
+		Passphrase: e.passphrase,
+		Signature:  base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+	}, nil
+}
+
+// GetSnapshot waits for the initial orderbook snapshot from the WebSocket
+func (e *FuturesExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
+	return e.WaitForSnapshot(ctx, 10*time.Second)
+}
+
+// HandleMessage processes a Coinbase International WebSocket message
+func (e *FuturesExchange) HandleMessage(messageType int, data []byte) error {
+	var msg INTXMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	switch msg.Channel {
+	case "SUBSCRIPTIONS":
+		if msg.Type == "REJECT" {
+			e.RecordError()
+			err := fmt.Errorf("%w: subscription rejected: %s %s", exchange.ErrAuth, msg.Message, msg.Reason)
+			e.FailSnapshot(err)
+			return err
+		}
+		return nil
+	case "LEVEL2":
+	default:
+		return nil
+	}
+	if msg.ProductID != e.symbol {
+		return nil
+	}
+
+	e.RecordMessage()
+
+	// Later snapshots (e.g., after a reconnect) are forwarded as full books that
+	// reset the orderbook
+	update := e.convertLevel2(&msg)
+	if update.Snapshot && !e.HasSnapshot() {
+		e.SetSnapshot(&exchange.Snapshot{
+			Exchange:     update.Exchange,
+			Symbol:       update.Symbol,
+			LastUpdateID: update.FinalUpdateID,
+			Bids:         append([]exchange.PriceLevel(nil), update.Bids...),
+			Asks:         append([]exchange.PriceLevel(nil), update.Asks...),
+			Timestamp:    update.EventTime,
+		})
+	}
+	e.Emit(update)
+	return nil
+}
+
+// convertLevel2 converts a LEVEL2 snapshot or update to canonical format
+func (e *FuturesExchange) convertLevel2(msg *INTXMessage) *exchange.DepthUpdate {
+	eventTime, err := time.Parse(time.RFC3339Nano, msg.Time)
+	if err != nil {
+		eventTime = e.Now()
+	}
+
+	update := &exchange.DepthUpdate{
+		Exchange:      e.GetName(),
+		Symbol:        msg.ProductID,
+		EventTime:     eventTime,
+		FirstUpdateID: msg.Sequence,
+		FinalUpdateID: msg.Sequence,
+		Snapshot:      msg.Type == "SNAPSHOT",
+	}
+	if update.Snapshot {
+		update.Bids = baseexchange.ConvertLevels(msg.Bids)
+		update.Asks = baseexchange.ConvertLevels(msg.Asks)
+		return update
+	}
+
+	for _, change := range msg.Changes {
+		if len(change) < 3 {
+			continue
+		}
+		level := exchange.PriceLevel{Price: change[1], Quantity: change[2]}
+		switch change[0] {
+		case "BUY":
+			update.Bids = append(update.Bids, level)
+		case "SELL":
+			update.Asks = append(update.Asks, level)
+		}
+	}
+	return update
+}
+
+// convertToPerpInstrument converts various symbol formats to a Coinbase International perpetual
+// Examples: BTCUSDT -> BTC-PERP, ETH-USDC -> ETH-PERP, BTC-PERP-INTX -> BTC-PERP
+func convertToPerpInstrument(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	// Advanced Trade lists the perpetuals with an -INTX suffix
+	symbol = strings.TrimSuffix(symbol, "-INTX")
+	if base, _, ok := strings.Cut(symbol, "-"); ok {
+		return base + "-PERP"
+	}
+
+	for _, quote := range []string{"USDT", "USDC", "USD"} {
+		if base, ok := strings.CutSuffix(symbol, quote); ok {
+			return base + "-PERP"
+		}
+	}
+	return symbol + "-PERP"
+}
@@ -0,0 +1,161 @@
+package coinbase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+func TestConvertToPerpInstrument(t *testing.T) {
+	tests := map[string]string{
+		"BTCUSDT":       "BTC-PERP",
+		"ethusdc":       "ETH-PERP",
+		"SOL-USD":       "SOL-PERP",
+		"BTC-PERP":      "BTC-PERP",
+		"BTC-PERP-INTX": "BTC-PERP",
+		"DOGE":          "DOGE-PERP",
+	}
+	for symbol, want := range tests {
+		if got := convertToPerpInstrument(symbol); got != want {
+			t.Errorf("convertToPerpInstrument(%q): Expected %s, got %s", symbol, want, got)
+		}
+	}
+}
+
+func TestFuturesLevel2(t *testing.T) {
+	e := NewFuturesExchange(Config{Symbol: "BTCUSDT", Key: "key", Secret: "c2VjcmV0", Passphrase: "pass"})
+	defer e.Close()
+
+	messages := []string{
+		`{"channel":"SUBSCRIPTIONS","type":"SNAPSHOT","channels":[{"name":"LEVEL2","product_ids":["BTC-PERP"]}],"authenticated":true}`,
+		`{"channel":"LEVEL2","type":"SNAPSHOT","product_id":"BTC-PERP","time":"2024-05-03T21:50:22.454Z","sequence":1,` +
+			`"bids":[["60000.0","1.5"],["59999.5","2"]],"asks":[["60000.5","0.7"]]}`,
+		`{"channel":"LEVEL2","type":"UPDATE","product_id":"BTC-PERP","time":"2024-05-03T21:50:22.457Z","sequence":2,` +
+			`"changes":[["BUY","60000.0","0"],["SELL","60001.0","3.25"]]}`,
+		// Other instruments are ignored
+		`{"channel":"LEVEL2","type":"UPDATE","product_id":"ETH-PERP","time":"2024-05-03T21:50:22.458Z","sequence":9,"changes":[["BUY","3000","1"]]}`,
+	}
+	for _, msg := range messages {
+		if err := e.HandleMessage(1, []byte(msg)); err != nil {
+			t.Fatalf("HandleMessage() failed: %v", err)
+		}
+	}
+
+	snapshot, err := e.GetSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("GetSnapshot() failed: %v", err)
+	}
+	if snapshot.Symbol != "BTC-PERP" || len(snapshot.Bids) != 2 || len(snapshot.Asks) != 1 ||
+		snapshot.Bids[0] != (exchange.PriceLevel{Price: "60000.0", Quantity: "1.5"}) {
+		t.Errorf("Expected the LEVEL2 snapshot of BTC-PERP, got %+v", snapshot)
+	}
+	if want := time.Date(2024, 5, 3, 21, 50, 22, 454000000, time.UTC); !snapshot.Timestamp.Equal(want) {
+		t.Errorf("Expected snapshot time %v, got %v", want, snapshot.Timestamp)
+	}
+
+	first := <-e.Updates()
+	if !first.Snapshot || first.FinalUpdateID != 1 || len(first.Bids) != 2 {
+		t.Errorf("Expected the snapshot as a full book update, got %+v", first)
+	}
+	update := <-e.Updates()
+	if update.Snapshot || update.FinalUpdateID != 2 ||
+		len(update.Bids) != 1 || update.Bids[0] != (exchange.PriceLevel{Price: "60000.0", Quantity: "0"}) ||
+		len(update.Asks) != 1 || update.Asks[0] != (exchange.PriceLevel{Price: "60001.0", Quantity: "3.25"}) {
+		t.Errorf("Expected the bid removed and an ask added, got %+v", update)
+	}
+	select {
+	case other := <-e.Updates():
+		t.Errorf("Expected no update for other instruments, got %s", other.Symbol)
+	default:
+	}
+
+	// A rejected subscription fails the snapshot with the credentials error
+	rejected := NewFuturesExchange(Config{Symbol: "BTCUSDT", Key: "key", Secret: "c2VjcmV0", Passphrase: "pass"})
+	defer rejected.Close()
+	err = rejected.HandleMessage(1, []byte(`{"channel":"SUBSCRIPTIONS","type":"REJECT","message":"failed to subscribe","reason":"invalid signature"}`))
+	if !errors.Is(err, exchange.ErrAuth) {
+		t.Errorf("Expected an authentication error, got %v", err)
+	}
+}
+
+func TestFuturesSubscribeRequest(t *testing.T) {
+	e := NewFuturesExchange(Config{Symbol: "ETH-PERP", Key: "key", Secret: "c2VjcmV0", Passphrase: "pass"})
+	defer e.Close()
+
+	request, err := e.subscribeRequest(time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatalf("subscribeRequest() failed: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000keyCBINTLMDpass"))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); request.Signature != want {
+		t.Errorf("Expected signature %s, got %s", want, request.Signature)
+	}
+	if request.Type != "SUBSCRIBE" || request.Time != "1700000000" || request.ProductIDs[0] != "ETH-PERP" || request.Channels[0] != "LEVEL2" {
+		t.Errorf("Expected a LEVEL2 subscription to ETH-PERP at 1700000000, got %+v", request)
+	}
+
+	// Credentials are required before dialing
+	missing := NewFuturesExchange(Config{Symbol: "BTCUSDT", Key: "key"})
+	defer missing.Close()
+	if err := missing.Connect(context.Background()); !errors.Is(err, exchange.ErrAuth) {
+		t.Errorf("Expected an authentication error without a secret, got %v", err)
+	}
+	invalid := NewFuturesExchange(Config{Symbol: "BTCUSDT", Key: "key", Secret: "not base64!", Passphrase: "pass"})
+	defer invalid.Close()
+	if err := invalid.Connect(context.Background()); !errors.Is(err, exchange.ErrAuth) {
+		t.Errorf("Expected an authentication error for a secret that is not base64, got %v", err)
+	}
+}
+
+func TestFuturesQuoteCurrency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/instruments/BTC-PERP":
+			w.Write([]byte(`{"symbol":"BTC-PERP","type":"PERP","base_asset_name":"BTC","quote_asset_name":"USDC"}`))
+		case "/api/v1/instruments/BTC-USDT":
+			w.Write([]byte(`{"symbol":"BTC-USDT","type":"SPOT","base_asset_name":"BTC","quote_asset_name":"USDT"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		config     Config
+		nameQuote  string
+		venueQuote string
+	}{
+		{Config{Symbol: "BTCUSDT"}, "USDC", "USDC"},
+		{Config{Symbol: "BTCUSDT", Instrument: "btc-usdt"}, "USDT", "USDT"},
+	}
+	for _, tt := range tests {
+		e := NewFuturesExchange(tt.config)
+		e.restURL = server.URL
+		if got := e.QuoteCurrency(); got != tt.nameQuote {
+			t.Errorf("%s: Expected quote %s from the instrument name, got %s", e.symbol, tt.nameQuote, got)
+		}
+		if err := e.loadInstrument(context.Background()); err != nil {
+			t.Fatalf("%s: loadInstrument() failed: %v", e.symbol, err)
+		}
+		if got := e.QuoteCurrency(); got != tt.venueQuote {
+			t.Errorf("%s: Expected quote %s from the venue, got %s", e.symbol, tt.venueQuote, got)
+		}
+		e.Close()
+	}
+
+	unknown := NewFuturesExchange(Config{Symbol: "NOPEUSDT"})
+	defer unknown.Close()
+	unknown.restURL = server.URL
+	if err := unknown.loadInstrument(context.Background()); err == nil {
+		t.Errorf("Expected an error for an unlisted instrument")
+	}
+}
@@ -2,7 +2,13 @@ package coinbase
 
 // Config holds configuration for Coinbase exchange
 type Config struct {
-	Symbol string
+	Symbol     string
+	Instrument string // Product ID used verbatim instead of converting Symbol, empty converts Symbol
+	// Coinbase International API key, empty reads the ORDERBOOK_COINBASE_INTX_*
+	// environment variables (futures only)
+	Key        string
+	Secret     string // Base64-encoded
+	Passphrase string
 }
 
 // SubscribeRequest represents a subscription request to Coinbase WebSocket
@@ -33,3 +39,36 @@ type Update struct {
 	PriceLevel  string `json:"price_level"`  // price
 	NewQuantity string `json:"new_quantity"` // quantity (if "0", remove level)
 }
+
+// INTXSubscribeRequest represents a signed subscription to Coinbase International market data
+type INTXSubscribeRequest struct {
+	Type       string   `json:"type"` // "SUBSCRIBE"
+	ProductIDs []string `json:"product_ids"`
+	Channels   []string `json:"channels"`
+	Time       string   `json:"time"` // Unix seconds
+	Key        string   `json:"key"`
+	Passphrase string   `json:"passphrase"`
+	Signature  string   `json:"signature"`
+}
+
+// INTXMessage represents a WebSocket message from Coinbase International
+type INTXMessage struct {
+	Channel   string     `json:"channel"` // "LEVEL2" or "SUBSCRIPTIONS"
+	Type      string     `json:"type"`    // "SNAPSHOT", "UPDATE" or "REJECT"
+	ProductID string     `json:"product_id"`
+	Time      string     `json:"time"` // RFC 3339
+	Sequence  int64      `json:"sequence"`
+	Bids      [][]string `json:"bids"`    // [price, size], snapshots only
+	Asks      [][]string `json:"asks"`    // [price, size], snapshots only
+	Changes   [][]string `json:"changes"` // [side ("BUY" or "SELL"), price, size], updates only
+	Message   string     `json:"message"` // Rejection message
+	Reason    string     `json:"reason"`  // Rejection reason
+}
+
+// INTXInstrument represents an instrument listed on Coinbase International
+type INTXInstrument struct {
+	Symbol         string `json:"symbol"` // e.g., "BTC-PERP"
+	Type           string `json:"type"`   // "PERP" or "SPOT"
+	BaseAssetName  string `json:"base_asset_name"`
+	QuoteAssetName string `json:"quote_asset_name"`
+}
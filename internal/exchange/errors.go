@@ -0,0 +1,78 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Failures adapters wrap so callers can tell them apart with errors.Is
+var (
+	// ErrSymbolNotFound is returned when the venue does not list the symbol
+	ErrSymbolNotFound = errors.New("symbol not found")
+	// ErrRateLimited is matched by every RateLimitError
+	ErrRateLimited = errors.New("rate limited")
+	// ErrAuth is returned when the venue rejects the request's credentials or origin
+	ErrAuth = errors.New("authentication failed")
+	// ErrConnClosed is returned when the adapter was closed or its stream ended
+	ErrConnClosed = errors.New("connection closed")
+	// ErrNoOpenInterest is returned when the subscribed market has no open interest
+	ErrNoOpenInterest = errors.New("open interest not available")
+)
+
+// RateLimitError is returned when the venue throttles requests. It matches
+// ErrRateLimited and carries the wait the venue asked for.
+type RateLimitError struct {
+	Status     int           // HTTP status (429, or 418 when the IP is banned), 0 for stream errors
+	RetryAfter time.Duration // Wait requested by the venue, 0 when unknown
+}
+
+func (e *RateLimitError) Error() string {
+	msg := ErrRateLimited.Error()
+	if e.Status != 0 {
+		msg += fmt.Sprintf(" (HTTP %d)", e.Status)
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %v", e.RetryAfter)
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrRateLimited) hold for every RateLimitError
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAfter returns the wait requested by a rate limit in err's chain
+func RetryAfter(err error) (time.Duration, bool) {
+	var rateLimited *RateLimitError
+	if !errors.As(err, &rateLimited) {
+		return 0, false
+	}
+	return rateLimited.RetryAfter, true
+}
+
+// Retryable reports whether repeating the request may succeed. An unknown
+// symbol, rejected credentials and a closed adapter fail the same way again.
+func Retryable(err error) bool {
+	return !errors.Is(err, ErrSymbolNotFound) && !errors.Is(err, ErrAuth) && !errors.Is(err, ErrConnClosed)
+}
+
+// StatusError converts an unexpected HTTP response status to an error: 429 and
+// 418 become a RateLimitError with the Retry-After wait, 401 and 403 wrap ErrAuth
+func StatusError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusTeapot:
+		rateLimited := &RateLimitError{Status: resp.StatusCode}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			rateLimited.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return rateLimited
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrAuth, resp.Status)
+	default:
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+}
@@ -0,0 +1,42 @@
+package exchange
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		status     int
+		retryAfter string
+		target     error
+		wait       time.Duration
+	}{
+		{http.StatusTooManyRequests, "3", ErrRateLimited, 3 * time.Second},
+		{http.StatusTeapot, "", ErrRateLimited, 0},
+		{http.StatusForbidden, "", ErrAuth, 0},
+		{http.StatusUnauthorized, "", ErrAuth, 0},
+		{http.StatusInternalServerError, "", nil, 0},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Status: http.StatusText(tt.status), Header: http.Header{}}
+		resp.Header.Set("Retry-After", tt.retryAfter)
+		err := StatusError(resp)
+
+		if tt.target != nil && !errors.Is(err, tt.target) {
+			t.Errorf("HTTP %d: Expected %v, got %v", tt.status, tt.target, err)
+		}
+		if tt.target == nil && (errors.Is(err, ErrRateLimited) || errors.Is(err, ErrAuth)) {
+			t.Errorf("HTTP %d: Expected an unclassified error, got %v", tt.status, err)
+		}
+		if wait, _ := RetryAfter(err); wait != tt.wait {
+			t.Errorf("HTTP %d: Expected retry after %v, got %v", tt.status, tt.wait, wait)
+		}
+		if Retryable(err) == (tt.target == ErrAuth) {
+			t.Errorf("HTTP %d: Expected retryable=%v", tt.status, tt.target != ErrAuth)
+		}
+	}
+}
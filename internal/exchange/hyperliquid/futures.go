@@ -8,142 +8,100 @@ import (
 	"log"
 	"net/http"
 	"strings"
-	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+	"orderbook/internal/transport"
 )
 
 // FuturesExchange implements the Exchange interface for Hyperliquid
 type FuturesExchange struct {
-	symbol     string
-	wsURL      string
-	restURL    string
-	wsConn     *websocket.Conn
-	updateChan chan *exchange.DepthUpdate
-	done       chan struct{}
-	ctx        context.Context
-	cancel     context.CancelFunc
-	health     atomic.Value // stores exchange.HealthStatus
+	*baseexchange.Base
+	symbol   string
+	restURL  string
+	sigFigs  int
+	mantissa int
 }
 
 // Config holds configuration for Hyperliquid exchange
 type Config struct {
-	Symbol string
+	Symbol   string
+	SigFigs  int // Significant figures of server-side aggregated price levels (2 to 5), 0 for full precision
+	Mantissa int // Aggregation step mantissa (1, 2 or 5), only with 5 significant figures, 0 for 1
+	// Coin used verbatim instead of converting Symbol (e.g., "@107" for a spot pair), empty converts Symbol
+	Instrument string
+}
+
+// Validate checks the aggregation parameters
+func (c Config) Validate() error {
+	if c.SigFigs != 0 && (c.SigFigs < 2 || c.SigFigs > 5) {
+		return fmt.Errorf("invalid significant figures %d (2 to 5, 0 for full precision)", c.SigFigs)
+	}
+	switch {
+	case c.Mantissa == 0:
+	case c.SigFigs != 5:
+		return fmt.Errorf("mantissa requires 5 significant figures")
+	case c.Mantissa != 1 && c.Mantissa != 2 && c.Mantissa != 5:
+		return fmt.Errorf("invalid mantissa %d (1, 2 or 5)", c.Mantissa)
+	}
+	return nil
 }
 
 // NewFuturesExchange creates a new Hyperliquid exchange instance
 func NewFuturesExchange(config Config) *FuturesExchange {
-	ctx, cancel := context.WithCancel(context.Background())
-
 	// Convert XXXUSDT to XXX for Hyperliquid (e.g., BTCUSDT -> BTC)
 	symbol := strings.TrimSuffix(config.Symbol, "USDT")
-
-	ex := &FuturesExchange{
-		symbol:     symbol,
-		wsURL:      "wss://api.hyperliquid.xyz/ws",
-		restURL:    "https://api.hyperliquid.xyz/info",
-		updateChan: make(chan *exchange.DepthUpdate, 1000),
-		done:       make(chan struct{}),
-		ctx:        ctx,
-		cancel:     cancel,
+	if config.Instrument != "" {
+		symbol = config.Instrument
 	}
 
-	ex.health.Store(exchange.HealthStatus{
-		Connected:    false,
-		LastPing:     time.Time{},
-		MessageCount: 0,
-		ErrorCount:   0,
-	})
-
+	ex := &FuturesExchange{
+		symbol:   symbol,
+		restURL:  "https://api.hyperliquid.xyz/info",
+		sigFigs:  config.SigFigs,
+		mantissa: config.Mantissa,
+	}
+	ex.Base = baseexchange.New(baseexchange.Config{
+		Name:      exchange.Hyperliquidf,
+		Symbol:    symbol,
+		WSURL:     "wss://api.hyperliquid.xyz/ws",
+		Keepalive: baseexchange.TextPing(30*time.Second, `{"method":"ping"}`),
+	}, ex)
 	return ex
 }
 
-// GetName returns the exchange name
-func (e *FuturesExchange) GetName() exchange.ExchangeName {
-	return exchange.Hyperliquidf
-}
-
-// GetSymbol returns the trading symbol
-func (e *FuturesExchange) GetSymbol() string {
-	return e.symbol
-}
-
-// Connect establishes WebSocket connection to Hyperliquid
-func (e *FuturesExchange) Connect(ctx context.Context) error {
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, e.wsURL, nil)
-	if err != nil {
-		e.incrementErrorCount()
-		return fmt.Errorf("websocket connection failed: %w", err)
+// bookRequest returns the l2Book request of the coin at the configured aggregation,
+// shared by the WebSocket subscription and the REST snapshot
+func (e *FuturesExchange) bookRequest() map[string]interface{} {
+	request := map[string]interface{}{
+		"type": "l2Book",
+		"coin": e.symbol,
 	}
-
-	e.wsConn = conn
-	e.updateConnectionStatus(true)
-	log.Printf("[%s] WebSocket connected successfully", e.GetName())
-
-	// Subscribe to L2 book updates
-	subscription := SubscriptionMessage{
-		Method: "subscribe",
-		Subscription: map[string]interface{}{
-			"type": "l2Book",
-			"coin": e.symbol,
-		},
+	if e.sigFigs > 0 {
+		request["nSigFigs"] = e.sigFigs
 	}
-
-	if err := conn.WriteJSON(subscription); err != nil {
-		e.incrementErrorCount()
-		return fmt.Errorf("failed to send subscription: %w", err)
+	if e.mantissa > 0 {
+		request["mantissa"] = e.mantissa
 	}
-
-	go e.readMessages()
-
-	return nil
+	return request
 }
 
-// Close closes the WebSocket connection
-func (e *FuturesExchange) Close() error {
-	if e.cancel != nil {
-		e.cancel()
+// Subscribe subscribes to L2 book updates
+func (e *FuturesExchange) Subscribe() error {
+	subscription := SubscriptionMessage{
+		Method:       "subscribe",
+		Subscription: e.bookRequest(),
 	}
 
-	if e.wsConn != nil {
-		select {
-		case <-e.done:
-		default:
-			close(e.done)
-		}
-
-		err := e.wsConn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-		if err != nil {
-			log.Printf("[%s] Error sending close message: %v", e.GetName(), err)
-		}
-
-		select {
-		case <-time.After(time.Second):
-		}
-
-		e.updateConnectionStatus(false)
-		return e.wsConn.Close()
-	}
-	return nil
+	return e.WriteJSON(subscription)
 }
 
 // GetSnapshot fetches the initial orderbook snapshot via REST API
 func (e *FuturesExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
 	log.Printf("[%s] Fetching orderbook snapshot...", e.GetName())
 
-	requestBody := map[string]interface{}{
-		"type": "l2Book",
-		"coin": e.symbol,
-	}
-
-	jsonData, err := json.Marshal(requestBody)
+	jsonData, err := json.Marshal(e.bookRequest())
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -155,98 +113,66 @@ func (e *FuturesExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot,
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := transport.Default().Client(10 * time.Second).Do(req)
 	if err != nil {
-		e.incrementErrorCount()
+		e.RecordError()
 		return nil, fmt.Errorf("failed to get snapshot: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		e.RecordError()
+		return nil, fmt.Errorf("snapshot request failed: %w", exchange.StatusError(resp))
+	}
+
 	var hyperliquidSnapshot L2BookResponse
 	if err := json.NewDecoder(resp.Body).Decode(&hyperliquidSnapshot); err != nil {
-		e.incrementErrorCount()
+		e.RecordError()
 		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
 	}
+	// Unknown coins are answered with null
+	if hyperliquidSnapshot.Coin == "" {
+		return nil, fmt.Errorf("%s: %w", e.symbol, exchange.ErrSymbolNotFound)
+	}
 
 	snapshot := e.convertSnapshot(&hyperliquidSnapshot)
 	return snapshot, nil
 }
 
-// Updates returns a channel that receives depth updates
-func (e *FuturesExchange) Updates() <-chan *exchange.DepthUpdate {
-	return e.updateChan
-}
-
-// IsConnected checks if the WebSocket connection is active
-func (e *FuturesExchange) IsConnected() bool {
-	return e.wsConn != nil
-}
-
-// Health returns connection health information
-func (e *FuturesExchange) Health() exchange.HealthStatus {
-	if status, ok := e.health.Load().(exchange.HealthStatus); ok {
-		return status
+// Capabilities reports full books pushed on every l2Book message
+func (e *FuturesExchange) Capabilities() exchange.Capabilities {
+	return exchange.Capabilities{
+		FullDepth:      true,
+		SnapshotSource: exchange.SnapshotREST,
 	}
-	return exchange.HealthStatus{}
 }
 
-// readMessages continuously reads WebSocket messages
-func (e *FuturesExchange) readMessages() {
-	defer close(e.updateChan)
-	defer e.updateConnectionStatus(false)
-
-	for {
-		select {
-		case <-e.ctx.Done():
-			log.Printf("[%s] Context cancelled, stopping message reading", e.GetName())
-			return
-		case <-e.done:
-			return
-		default:
-			var msg WSMessage
-			if err := e.wsConn.ReadJSON(&msg); err != nil {
-				e.incrementErrorCount()
-				log.Printf("[%s] WebSocket read error: %v", e.GetName(), err)
-				return
-			}
-
-			e.incrementMessageCount()
-			e.updateLastPing()
-
-			// Handle subscription response
-			if msg.Channel == "subscriptionResponse" {
-				continue
-			}
+// HandleMessage processes a Hyperliquid WebSocket message
+func (e *FuturesExchange) HandleMessage(messageType int, data []byte) error {
+	var msg WSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
 
-			// Handle L2 book updates
-			if msg.Channel == "l2Book" {
-				var bookData WsBook
-				dataBytes, err := json.Marshal(msg.Data)
-				if err != nil {
-					log.Printf("[%s] Error marshalling book data: %v", e.GetName(), err)
-					continue
-				}
+	// Heartbeat reply to the keepalive ping
+	if msg.Channel == "pong" {
+		return nil
+	}
 
-				if err := json.Unmarshal(dataBytes, &bookData); err != nil {
-					log.Printf("[%s] Error unmarshalling book data: %v", e.GetName(), err)
-					continue
-				}
+	e.RecordMessage()
 
-				canonicalUpdate := e.convertDepthUpdate(&bookData)
+	// Handle L2 book updates; subscription responses are ignored
+	if msg.Channel != "l2Book" {
+		return nil
+	}
 
-				select {
-				case e.updateChan <- canonicalUpdate:
-				case <-e.ctx.Done():
-					return
-				case <-e.done:
-					return
-				default:
-					log.Printf("[%s] Warning: update channel full, skipping update", e.GetName())
-				}
-			}
-		}
+	var bookData WsBook
+	if err := json.Unmarshal(msg.Data, &bookData); err != nil {
+		return fmt.Errorf("failed to decode book data: %w", err)
 	}
+
+	e.Emit(e.convertDepthUpdate(&bookData))
+	return nil
 }
 
 // convertSnapshot converts Hyperliquid snapshot to canonical format
@@ -306,35 +232,3 @@ func (e *FuturesExchange) convertDepthUpdate(update *WsBook) *exchange.DepthUpda
 		Asks:          asks,
 	}
 }
-
-// updateConnectionStatus updates the connection status in health
-func (e *FuturesExchange) updateConnectionStatus(connected bool) {
-	status := e.Health()
-	status.Connected = connected
-	if !connected {
-		now := time.Now()
-		status.ReconnectTime = &now
-	}
-	e.health.Store(status)
-}
-
-// incrementMessageCount increments the message count in health
-func (e *FuturesExchange) incrementMessageCount() {
-	status := e.Health()
-	status.MessageCount++
-	e.health.Store(status)
-}
-
-// incrementErrorCount increments the error count in health
-func (e *FuturesExchange) incrementErrorCount() {
-	status := e.Health()
-	status.ErrorCount++
-	e.health.Store(status)
-}
-
-// updateLastPing updates the last ping time in health
-func (e *FuturesExchange) updateLastPing() {
-	status := e.Health()
-	status.LastPing = time.Now()
-	e.health.Store(status)
-}
\ No newline at end of file
@@ -0,0 +1,57 @@
+package hyperliquid
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSnapshotRequestsAggregation(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{"coin":"BTC","time":1700000000000,"levels":[[{"px":"60000","sz":"1","n":3}],[{"px":"60010","sz":"2","n":1}]]}`))
+	}))
+	defer server.Close()
+
+	e := NewFuturesExchange(Config{Symbol: "BTCUSDT", SigFigs: 5, Mantissa: 2})
+	defer e.Close()
+	e.restURL = server.URL
+
+	if _, err := e.GetSnapshot(context.Background()); err != nil {
+		t.Fatalf("GetSnapshot() failed: %v", err)
+	}
+	if request["coin"] != "BTC" || request["nSigFigs"] != float64(5) || request["mantissa"] != float64(2) {
+		t.Errorf("Expected BTC at 5 significant figures with mantissa 2, got %v", request)
+	}
+
+	full := NewFuturesExchange(Config{Symbol: "BTCUSDT"})
+	defer full.Close()
+	if _, ok := full.bookRequest()["nSigFigs"]; ok {
+		t.Errorf("Expected full precision books to omit nSigFigs")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{}, true},
+		{Config{SigFigs: 3}, true},
+		{Config{SigFigs: 5, Mantissa: 5}, true},
+		{Config{SigFigs: 1}, false},
+		{Config{SigFigs: 6}, false},
+		{Config{SigFigs: 4, Mantissa: 2}, false},
+		{Config{SigFigs: 5, Mantissa: 3}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v): Expected valid %v, got %v", tt.config, tt.valid, err)
+		}
+	}
+}
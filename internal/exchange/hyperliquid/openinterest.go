@@ -0,0 +1,40 @@
+package hyperliquid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+)
+
+// GetOpenInterest fetches the open interest of the perpetual in base units. Spot
+// pairs are not part of the perpetuals universe and have none.
+func (e *FuturesExchange) GetOpenInterest(ctx context.Context) (decimal.Decimal, error) {
+	// The response is a [meta, assetCtxs] pair, with one context per universe entry
+	var resp []json.RawMessage
+	if err := baseexchange.PostJSON(ctx, e.restURL, map[string]string{"type": "metaAndAssetCtxs"}, &resp); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get asset contexts: %w", err)
+	}
+	if len(resp) != 2 {
+		return decimal.Zero, fmt.Errorf("unexpected asset contexts response of %d elements", len(resp))
+	}
+
+	var meta PerpMeta
+	if err := json.Unmarshal(resp[0], &meta); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to decode meta: %w", err)
+	}
+	var ctxs []PerpAssetCtx
+	if err := json.Unmarshal(resp[1], &ctxs); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to decode asset contexts: %w", err)
+	}
+
+	for i, asset := range meta.Universe {
+		if asset.Name == e.symbol && i < len(ctxs) {
+			return ctxs[i].OpenInterest, nil
+		}
+	}
+	return decimal.Zero, fmt.Errorf("%s: %w", e.symbol, exchange.ErrNoOpenInterest)
+}
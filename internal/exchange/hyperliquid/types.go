@@ -1,5 +1,11 @@
 package hyperliquid
 
+import (
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+)
+
 // L2BookResponse represents the REST API response for Hyperliquid L2 book snapshot
 type L2BookResponse struct {
 	Coin   string       `json:"coin"`
@@ -35,6 +41,19 @@ type SubscriptionResponse struct {
 
 // WSMessage represents a generic WebSocket message
 type WSMessage struct {
-	Channel string      `json:"channel"`
-	Data    interface{} `json:"data"`
-}
\ No newline at end of file
+	Channel string          `json:"channel"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// PerpMeta represents the perpetuals universe of a metaAndAssetCtxs response
+type PerpMeta struct {
+	Universe []struct {
+		Name string `json:"name"`
+	} `json:"universe"`
+}
+
+// PerpAssetCtx represents the market data of a perpetual, in universe order
+type PerpAssetCtx struct {
+	OpenInterest decimal.Decimal `json:"openInterest"` // Base units
+	MarkPx       decimal.Decimal `json:"markPx"`
+}
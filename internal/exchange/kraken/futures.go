@@ -0,0 +1,280 @@
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+)
+
+// futuresTickerURL is the REST ticker endpoint of a product, carrying the open interest
+const futuresTickerURL = "https://futures.kraken.com/derivatives/api/v3/tickers/"
+
+// inverseContractSize is the USD notional of one inverse (PI_, FI_) contract
+var inverseContractSize = decimal.NewFromInt(1)
+
+// FuturesExchange implements the Exchange interface for Kraken Futures
+type FuturesExchange struct {
+	*baseexchange.Base
+	productID string
+	inverse   bool // Quantities are 1 USD contracts, normalized to base units
+	// Sequence number of the last message forwarded, 0 while waiting for a
+	// snapshot. Kraken Futures numbers the messages of a product consecutively.
+	lastSeq atomic.Int64
+}
+
+// NewFuturesExchange creates a new Kraken Futures exchange instance. Config.Depth
+// is ignored: the book feed always carries the full book.
+func NewFuturesExchange(config Config) *FuturesExchange {
+	// Convert symbol to a Kraken Futures product (e.g., BTCUSDT -> PF_XBTUSD)
+	productID := convertToFuturesProduct(config.Symbol)
+	if config.Instrument != "" {
+		productID = strings.ToUpper(config.Instrument)
+	}
+
+	ex := &FuturesExchange{
+		productID: productID,
+		inverse:   strings.HasPrefix(productID, "PI_") || strings.HasPrefix(productID, "FI_"),
+	}
+	ex.Base = baseexchange.New(baseexchange.Config{
+		Name:      exchange.Krakenf,
+		Symbol:    productID,
+		WSURL:     "wss://futures.kraken.com/ws/v1",
+		Keepalive: baseexchange.PingFrames(30 * time.Second),
+	}, ex)
+	return ex
+}
+
+// Subscribe subscribes to the book feed, which starts with a snapshot
+func (e *FuturesExchange) Subscribe() error {
+	e.lastSeq.Store(0)
+	if err := e.WriteJSON(FuturesSubscribeRequest{Event: "subscribe", Feed: "book", ProductIDs: []string{e.productID}}); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Subscribed to book feed for %s", e.GetName(), e.productID)
+	return nil
+}
+
+// resubscribe unsubscribes and subscribes again, which makes Kraken send a fresh snapshot
+func (e *FuturesExchange) resubscribe() error {
+	if err := e.WriteJSON(FuturesSubscribeRequest{Event: "unsubscribe", Feed: "book", ProductIDs: []string{e.productID}}); err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	return e.Subscribe()
+}
+
+// Capabilities reports sequenced deltas on top of a WebSocket snapshot
+func (e *FuturesExchange) Capabilities() exchange.Capabilities {
+	return exchange.Capabilities{
+		SequenceIDs:    true,
+		SnapshotSource: exchange.SnapshotWebSocket,
+	}
+}
+
+// QuoteCurrency returns USD, the quote of all Kraken Futures products
+func (e *FuturesExchange) QuoteCurrency() string {
+	return "USD"
+}
+
+// GetOpenInterest fetches the open interest of the product in base units. Inverse
+// contracts are converted at the mark price.
+func (e *FuturesExchange) GetOpenInterest(ctx context.Context) (decimal.Decimal, error) {
+	var resp FuturesTickerResponse
+	if err := baseexchange.GetJSON(ctx, futuresTickerURL+e.productID, &resp); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get ticker: %w", err)
+	}
+	if resp.Result != "success" {
+		return decimal.Zero, fmt.Errorf("ticker request failed: %s", resp.Error)
+	}
+	if !strings.EqualFold(resp.Ticker.Symbol, e.productID) {
+		return decimal.Zero, fmt.Errorf("%s in tickers: %w", e.productID, exchange.ErrSymbolNotFound)
+	}
+
+	if !e.inverse {
+		return resp.Ticker.OpenInterest, nil
+	}
+	if !resp.Ticker.MarkPrice.IsPositive() {
+		return decimal.Zero, fmt.Errorf("missing mark price for %s", e.productID)
+	}
+	return resp.Ticker.OpenInterest.Mul(inverseContractSize).Div(resp.Ticker.MarkPrice), nil
+}
+
+// GetSnapshot waits for the book snapshot from the WebSocket
+func (e *FuturesExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
+	return e.WaitForSnapshot(ctx, 10*time.Second)
+}
+
+// HandleMessage processes a Kraken Futures WebSocket message
+func (e *FuturesExchange) HandleMessage(messageType int, data []byte) error {
+	var msg FuturesMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	if msg.Event != "" {
+		return e.handleEvent(&msg)
+	}
+	if msg.Feed != "book_snapshot" && msg.Feed != "book" {
+		return nil
+	}
+
+	e.RecordMessage()
+
+	if !e.advance(&msg) {
+		return nil
+	}
+
+	update, err := e.convertDepthUpdate(&msg)
+	if err != nil {
+		e.RecordError()
+		return fmt.Errorf("failed to convert book message: %w", err)
+	}
+
+	// Later snapshots (after a reconnect or a gap) are forwarded as full books
+	// that reset the orderbook
+	if update.Snapshot && !e.HasSnapshot() {
+		e.SetSnapshot(&exchange.Snapshot{
+			Exchange:     e.GetName(),
+			Symbol:       msg.ProductID,
+			LastUpdateID: msg.Seq,
+			Bids:         append([]exchange.PriceLevel(nil), update.Bids...),
+			Asks:         append([]exchange.PriceLevel(nil), update.Asks...),
+			Timestamp:    update.EventTime,
+		})
+	}
+
+	e.Emit(update)
+	return nil
+}
+
+// advance tracks the sequence number of a book message and reports whether to
+// forward it. A snapshot restarts the sequence; an update must follow the previous
+// message. After a gap updates are dropped until the snapshot requested by resubscribing.
+func (e *FuturesExchange) advance(msg *FuturesMessage) bool {
+	if msg.Feed == "book_snapshot" {
+		e.lastSeq.Store(msg.Seq)
+		return true
+	}
+
+	last := e.lastSeq.Load()
+	switch {
+	case last == 0:
+		// Waiting for the snapshot of a new subscription
+		return false
+	case msg.Seq <= last:
+		// Already forwarded
+		return false
+	case msg.Seq != last+1:
+		e.lastSeq.Store(0)
+		e.RecordError()
+		log.Printf("[%s] Sequence gap: expected %d, got %d, resubscribing for a fresh snapshot", e.GetName(), last+1, msg.Seq)
+		if err := e.resubscribe(); err != nil {
+			log.Printf("[%s] Failed to resubscribe: %v", e.GetName(), err)
+		}
+		return false
+	}
+	e.lastSeq.Store(msg.Seq)
+	return true
+}
+
+// handleEvent processes subscription acks, info and error events
+func (e *FuturesExchange) handleEvent(msg *FuturesMessage) error {
+	switch msg.Event {
+	case "subscribed":
+		log.Printf("[%s] Subscription confirmed", e.GetName())
+	case "unsubscribed", "info":
+		// Connection banner, or followed by the subscription of a resync
+	case "error", "alert":
+		e.RecordError()
+		err := fmt.Errorf("subscription failed: %s", msg.Message)
+		if strings.Contains(strings.ToLower(msg.Message), "invalid product") {
+			err = fmt.Errorf("%w: %s", exchange.ErrSymbolNotFound, msg.Message)
+		}
+		e.FailSnapshot(err)
+		return err
+	default:
+		log.Printf("[%s] Unhandled event: %s %s", e.GetName(), msg.Event, msg.Message)
+	}
+	return nil
+}
+
+// convertDepthUpdate converts a book snapshot or single-level book update to
+// canonical format, with quantities in base units. Sequence numbers are
+// consecutive, so a message continues the one before it.
+func (e *FuturesExchange) convertDepthUpdate(msg *FuturesMessage) (*exchange.DepthUpdate, error) {
+	update := &exchange.DepthUpdate{
+		Exchange:      e.GetName(),
+		Symbol:        msg.ProductID,
+		EventTime:     time.UnixMilli(msg.Timestamp),
+		FirstUpdateID: msg.Seq,
+		FinalUpdateID: msg.Seq,
+		PrevUpdateID:  msg.Seq - 1,
+		Snapshot:      msg.Feed == "book_snapshot",
+	}
+
+	if update.Snapshot {
+		update.Bids = convertFuturesLevels(msg.Bids)
+		update.Asks = convertFuturesLevels(msg.Asks)
+	} else {
+		level := []exchange.PriceLevel{{Price: msg.Price.String(), Quantity: msg.Qty.String()}}
+		switch msg.Side {
+		case "buy":
+			update.Bids = level
+		case "sell":
+			update.Asks = level
+		default:
+			return nil, fmt.Errorf("unknown side %q", msg.Side)
+		}
+	}
+
+	if e.inverse {
+		if err := baseexchange.NormalizeInverse(update.Bids, inverseContractSize); err != nil {
+			return nil, err
+		}
+		if err := baseexchange.NormalizeInverse(update.Asks, inverseContractSize); err != nil {
+			return nil, err
+		}
+	}
+	return update, nil
+}
+
+// convertFuturesLevels converts Kraken Futures snapshot levels to canonical price levels
+func convertFuturesLevels(levels []FuturesLevel) []exchange.PriceLevel {
+	result := make([]exchange.PriceLevel, len(levels))
+	for i, level := range levels {
+		result[i] = exchange.PriceLevel{
+			Price:    level.Price.String(),
+			Quantity: level.Qty.String(),
+		}
+	}
+	return result
+}
+
+// convertToFuturesProduct converts various symbol formats to a Kraken Futures product
+// Examples: BTCUSDT -> PF_XBTUSD, ETHUSD -> PF_ETHUSD, PI_XBTUSD -> PI_XBTUSD
+func convertToFuturesProduct(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if strings.Contains(symbol, "_") {
+		return symbol
+	}
+
+	base := symbol
+	for _, quote := range []string{"USDT", "USDC", "USD"} {
+		if trimmed, ok := strings.CutSuffix(symbol, quote); ok {
+			base = trimmed
+			break
+		}
+	}
+	if base == "BTC" {
+		base = "XBT"
+	}
+	return "PF_" + base + "USD"
+}
@@ -0,0 +1,78 @@
+package kraken
+
+import (
+	"fmt"
+	"testing"
+)
+
+func futuresBookMessage(seq int64) []byte {
+	return []byte(fmt.Sprintf(`{"feed":"book","product_id":"PI_XBTUSD","side":"buy","seq":%d,"price":50000,"qty":1000,"timestamp":1700000000000}`, seq))
+}
+
+func TestFuturesGapRequestsSnapshot(t *testing.T) {
+	e := NewFuturesExchange(Config{Symbol: "PI_XBTUSD"})
+	defer e.Close()
+
+	snapshot := func(seq int64) []byte {
+		return []byte(fmt.Sprintf(`{"feed":"book_snapshot","product_id":"PI_XBTUSD","seq":%d,"timestamp":1700000000000,"bids":[{"price":50000,"qty":500}],"asks":[{"price":50000.5,"qty":2000}]}`, seq))
+	}
+	messages := [][]byte{
+		futuresBookMessage(5), // Before the snapshot
+		snapshot(10),
+		futuresBookMessage(11),
+		futuresBookMessage(11), // Duplicate
+		futuresBookMessage(13), // Gap, resubscribes
+		futuresBookMessage(14), // Dropped until the snapshot
+		snapshot(20),
+		futuresBookMessage(21),
+	}
+	for _, msg := range messages {
+		if err := e.HandleMessage(1, msg); err != nil {
+			t.Fatalf("HandleMessage() failed: %v", err)
+		}
+	}
+
+	expected := []struct {
+		seq      int64
+		snapshot bool
+	}{
+		{10, true},
+		{11, false},
+		{20, true},
+		{21, false},
+	}
+	for _, want := range expected {
+		select {
+		case update := <-e.Updates():
+			if update.FinalUpdateID != want.seq || update.PrevUpdateID != want.seq-1 || update.Snapshot != want.snapshot {
+				t.Errorf("Expected update %d (snapshot %v), got %d (prev %d, snapshot %v)",
+					want.seq, want.snapshot, update.FinalUpdateID, update.PrevUpdateID, update.Snapshot)
+			}
+			if !update.Snapshot && (len(update.Bids) != 1 || update.Bids[0].Quantity != "0.02000000") {
+				t.Errorf("Expected 1000 inverse contracts at 50000 to be 0.02 BTC, got %v", update.Bids)
+			}
+		default:
+			t.Fatalf("Expected update %d, got none", want.seq)
+		}
+	}
+	if !e.HasSnapshot() {
+		t.Errorf("Expected the first book snapshot to be stored")
+	}
+	if errors := e.Health().ErrorCount; errors != 1 {
+		t.Errorf("Expected the gap to be counted as one error, got %d", errors)
+	}
+}
+
+func TestConvertToFuturesProduct(t *testing.T) {
+	tests := map[string]string{
+		"BTCUSDT":   "PF_XBTUSD",
+		"ethusd":    "PF_ETHUSD",
+		"SOLUSDC":   "PF_SOLUSD",
+		"pi_xbtusd": "PI_XBTUSD",
+	}
+	for symbol, want := range tests {
+		if got := convertToFuturesProduct(symbol); got != want {
+			t.Errorf("convertToFuturesProduct(%q): Expected %s, got %s", symbol, want, got)
+		}
+	}
+}
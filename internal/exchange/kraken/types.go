@@ -1,8 +1,16 @@
 package kraken
 
+import (
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+)
+
 // Config holds configuration for Kraken exchange
 type Config struct {
-	Symbol string
+	Symbol     string
+	Depth      int    // Book depth (10, 25, 100, 500 or 1000), 0 uses 1000
+	Instrument string // Pair or futures product used verbatim instead of converting Symbol (e.g., FI_XBTUSD_250627), empty converts Symbol
 }
 
 // SubscribeRequest represents a subscription request to Kraken WebSocket v2
@@ -61,3 +69,45 @@ type PriceQty struct {
 	Price float64 `json:"price"`
 	Qty   float64 `json:"qty"`
 }
+
+// FuturesSubscribeRequest represents a subscription request to the Kraken Futures WebSocket
+type FuturesSubscribeRequest struct {
+	Event      string   `json:"event"` // "subscribe" or "unsubscribe"
+	Feed       string   `json:"feed"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+// FuturesMessage represents a Kraken Futures WebSocket message. Book snapshots
+// carry both sides; book updates carry a single level of one side.
+type FuturesMessage struct {
+	Event     string `json:"event"` // "subscribed", "unsubscribed", "info" or "error" on control messages
+	Message   string `json:"message"`
+	Feed      string `json:"feed"` // "book_snapshot", "book" or "heartbeat" on data messages
+	ProductID string `json:"product_id"`
+	Seq       int64  `json:"seq"`
+	Timestamp int64  `json:"timestamp"` // Unix milliseconds
+
+	Bids []FuturesLevel `json:"bids"` // book_snapshot only
+	Asks []FuturesLevel `json:"asks"` // book_snapshot only
+
+	Side  string      `json:"side"` // "buy" or "sell", book only
+	Price json.Number `json:"price"`
+	Qty   json.Number `json:"qty"`
+}
+
+// FuturesLevel represents a price level of a Kraken Futures book snapshot
+type FuturesLevel struct {
+	Price json.Number `json:"price"`
+	Qty   json.Number `json:"qty"`
+}
+
+// FuturesTickerResponse represents the REST ticker of a Kraken Futures product
+type FuturesTickerResponse struct {
+	Result string `json:"result"` // "success" or "error"
+	Error  string `json:"error"`
+	Ticker struct {
+		Symbol       string          `json:"symbol"`
+		MarkPrice    decimal.Decimal `json:"markPrice"`
+		OpenInterest decimal.Decimal `json:"openInterest"` // Base units, or USD contracts on inverse
+	} `json:"ticker"`
+}
@@ -6,125 +6,79 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+	"orderbook/internal/transport"
 )
 
 const (
 	pollInterval = 1 * time.Second
 	restBaseURL  = "https://www.okx.com/api/v5/market/books-full"
+	timeURL      = "https://www.okx.com/api/v5/public/time"
 )
 
 // SpotExchange implements the Exchange interface for OKX using REST polling
 type SpotExchange struct {
-	symbol     string
-	instId     string // OKX format (e.g., BTC-USDT)
-	restURL    string
-	updateChan chan *exchange.DepthUpdate
-	done       chan struct{}
-	ctx        context.Context
-	cancel     context.CancelFunc
-	health     atomic.Value
-	isRunning  bool
+	*baseexchange.Base
+	instId  string // OKX format (e.g., BTC-USDT)
+	restURL string
 }
 
 // NewSpotExchange creates a new OKX Spot exchange instance
 func NewSpotExchange(config Config) *SpotExchange {
-	ctx, cancel := context.WithCancel(context.Background())
-
 	instId := convertToOKXSymbol(config.Symbol)
-	restURL := fmt.Sprintf("%s?instId=%s&sz=5000", restBaseURL, instId)
-
-	ex := &SpotExchange{
-		symbol:     config.Symbol,
-		instId:     instId,
-		restURL:    restURL,
-		updateChan: make(chan *exchange.DepthUpdate, 1000),
-		done:       make(chan struct{}),
-		ctx:        ctx,
-		cancel:     cancel,
-		isRunning:  false,
-	}
-
-	ex.health.Store(exchange.HealthStatus{
-		Connected:    false,
-		LastPing:     time.Time{},
-		MessageCount: 0,
-		ErrorCount:   0,
-	})
-
-	return ex
-}
-
-// GetName returns the exchange name
-func (e *SpotExchange) GetName() exchange.ExchangeName {
-	return exchange.OKX
-}
-
-// GetSymbol returns the trading symbol
-func (e *SpotExchange) GetSymbol() string {
-	return e.symbol
-}
-
-// Connect starts the REST polling loop
-func (e *SpotExchange) Connect(ctx context.Context) error {
-	e.updateConnectionStatus(true)
-	log.Printf("[%s] Starting REST polling (interval: %v)", e.GetName(), pollInterval)
-
-	e.isRunning = true
-	go e.pollLoop()
-
-	return nil
-}
-
-// Close stops the polling loop
-func (e *SpotExchange) Close() error {
-	if e.cancel != nil {
-		e.cancel()
+	if config.Instrument != "" {
+		instId = config.Instrument
 	}
-
-	select {
-	case <-e.done:
-	default:
-		close(e.done)
+	depth := config.Depth
+	if depth <= 0 {
+		depth = 5000
 	}
 
-	e.updateConnectionStatus(false)
-	log.Printf("[%s] Polling stopped", e.GetName())
-	return nil
+	ex := &SpotExchange{
+		instId:  instId,
+		restURL: fmt.Sprintf("%s?instId=%s&sz=%d", restBaseURL, instId, depth),
+	}
+	ex.Base = baseexchange.NewPolling(baseexchange.Config{
+		Name:         exchange.OKX,
+		Symbol:       config.Symbol,
+		PollInterval: pollInterval,
+		Clock:        baseexchange.RESTClock(timeURL, parseServerTime),
+	}, ex)
+	return ex
 }
 
-// GetSnapshot fetches the orderbook snapshot via REST API (5000 levels)
+// GetSnapshot fetches the orderbook snapshot via REST API at the configured depth
 func (e *SpotExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", e.restURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := transport.Default().Client(10 * time.Second).Do(req)
 	if err != nil {
-		e.incrementErrorCount()
+		e.RecordError()
 		return nil, fmt.Errorf("failed to get snapshot: %w", err)
 	}
 	defer resp.Body.Close()
 
 	var okxResp OrderBookResponse
 	if err := json.NewDecoder(resp.Body).Decode(&okxResp); err != nil {
-		e.incrementErrorCount()
+		e.RecordError()
 		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
 	}
 
 	if okxResp.Code != "0" {
-		e.incrementErrorCount()
-		return nil, fmt.Errorf("API error: code=%s, msg=%s", okxResp.Code, okxResp.Msg)
+		e.RecordError()
+		return nil, apiError(okxResp.Code, okxResp.Msg)
 	}
 
 	if len(okxResp.Data) == 0 {
-		e.incrementErrorCount()
+		e.RecordError()
 		return nil, fmt.Errorf("empty response data")
 	}
 
@@ -132,60 +86,19 @@ func (e *SpotExchange) GetSnapshot(ctx context.Context) (*exchange.Snapshot, err
 	return snapshot, nil
 }
 
-// Updates returns a channel that receives depth updates
-func (e *SpotExchange) Updates() <-chan *exchange.DepthUpdate {
-	return e.updateChan
-}
-
-// IsConnected checks if the polling is active
-func (e *SpotExchange) IsConnected() bool {
-	return e.isRunning
-}
-
-// Health returns connection health information
-func (e *SpotExchange) Health() exchange.HealthStatus {
-	if status, ok := e.health.Load().(exchange.HealthStatus); ok {
-		return status
-	}
-	return exchange.HealthStatus{}
-}
-
-// pollLoop continuously polls REST endpoint every second
-func (e *SpotExchange) pollLoop() {
-	defer close(e.updateChan)
-	defer e.updateConnectionStatus(false)
-
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-e.ctx.Done():
-			log.Printf("[%s] Context cancelled, stopping polling", e.GetName())
-			return
-		case <-e.done:
-			return
-		case <-ticker.C:
-			e.poll()
-		}
-	}
-}
-
-// poll fetches snapshot and sends as update
-func (e *SpotExchange) poll() {
-	ctx, cancel := context.WithTimeout(e.ctx, 5*time.Second)
+// Poll fetches a snapshot and sends it as an update
+func (e *SpotExchange) Poll(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	snapshot, err := e.GetSnapshot(ctx)
 	if err != nil {
-		log.Printf("[%s] Failed to poll: %v", e.GetName(), err)
-		return
+		return err
 	}
 
-	e.incrementMessageCount()
-	e.updateLastPing()
+	e.RecordMessage()
 
-	update := &exchange.DepthUpdate{
+	e.Emit(&exchange.DepthUpdate{
 		Exchange:      e.GetName(),
 		Symbol:        e.instId,
 		EventTime:     snapshot.Timestamp,
@@ -194,47 +107,72 @@ func (e *SpotExchange) poll() {
 		PrevUpdateID:  0,
 		Bids:          snapshot.Bids,
 		Asks:          snapshot.Asks,
-	}
+	})
+	return nil
+}
 
-	select {
-	case e.updateChan <- update:
-	case <-e.ctx.Done():
-	case <-e.done:
-	default:
-		log.Printf("[%s] Warning: update channel full, skipping update", e.GetName())
+// Capabilities reports full books polled from the REST endpoint
+func (e *SpotExchange) Capabilities() exchange.Capabilities {
+	return exchange.Capabilities{
+		FullDepth:      true,
+		SnapshotSource: exchange.SnapshotREST,
 	}
 }
 
 // convertSnapshot converts OKX REST snapshot to canonical format
 func (e *SpotExchange) convertSnapshot(data *OrderBookData) *exchange.Snapshot {
-	bids := make([]exchange.PriceLevel, len(data.Bids))
-	for i, bid := range data.Bids {
-		if len(bid) >= 2 {
-			bids[i] = exchange.PriceLevel{
-				Price:    bid[0],
-				Quantity: bid[1],
-			}
-		}
-	}
-
-	asks := make([]exchange.PriceLevel, len(data.Asks))
-	for i, ask := range data.Asks {
-		if len(ask) >= 2 {
-			asks[i] = exchange.PriceLevel{
-				Price:    ask[0],
-				Quantity: ask[1],
-			}
-		}
-	}
-
 	return &exchange.Snapshot{
 		Exchange:     e.GetName(),
 		Symbol:       e.instId,
 		LastUpdateID: 0,
-		Bids:         bids,
-		Asks:         asks,
-		Timestamp:    time.Now(),
+		Bids:         baseexchange.ConvertLevels(data.Bids),
+		Asks:         baseexchange.ConvertLevels(data.Asks),
+		Timestamp:    parseTimestamp(data.Ts),
+	}
+}
+
+// OKX API error codes with a typed counterpart
+const (
+	codeRateLimited       = "50011"
+	codeInstrumentMissing = "51001"
+)
+
+// apiError converts an OKX API error to an error callers can classify
+func apiError(code, msg string) error {
+	switch code {
+	case codeRateLimited:
+		return &exchange.RateLimitError{}
+	case codeInstrumentMissing:
+		return fmt.Errorf("%s: %w", msg, exchange.ErrSymbolNotFound)
+	default:
+		return fmt.Errorf("API error: code=%s, msg=%s", code, msg)
+	}
+}
+
+// parseTimestamp converts an OKX millisecond timestamp, falling back to the local time
+func parseTimestamp(ts string) time.Time {
+	ms, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.UnixMilli(ms)
+}
+
+// parseServerTime extracts the server time from a public time response
+func parseServerTime(body []byte) (time.Time, error) {
+	var resp ServerTimeResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode server time: %w", err)
+	}
+	if resp.Code != "0" || len(resp.Data) == 0 {
+		return time.Time{}, fmt.Errorf("API error: code=%s, msg=%s", resp.Code, resp.Msg)
+	}
+
+	ms, err := strconv.ParseInt(resp.Data[0].Ts, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid server time %s: %w", resp.Data[0].Ts, err)
 	}
+	return time.UnixMilli(ms), nil
 }
 
 // convertToOKXSymbol converts various symbol formats to OKX format
@@ -264,35 +202,3 @@ func convertToOKXSymbol(symbol string) string {
 	log.Printf("[OKX] Warning: Could not convert symbol %s to OKX format, using as-is", symbol)
 	return symbol
 }
-
-// updateConnectionStatus updates the connection status in health
-func (e *SpotExchange) updateConnectionStatus(connected bool) {
-	status := e.Health()
-	status.Connected = connected
-	if !connected {
-		now := time.Now()
-		status.ReconnectTime = &now
-	}
-	e.health.Store(status)
-}
-
-// incrementMessageCount increments the message count in health
-func (e *SpotExchange) incrementMessageCount() {
-	status := e.Health()
-	status.MessageCount++
-	e.health.Store(status)
-}
-
-// incrementErrorCount increments the error count in health
-func (e *SpotExchange) incrementErrorCount() {
-	status := e.Health()
-	status.ErrorCount++
-	e.health.Store(status)
-}
-
-// updateLastPing updates the last ping time in health
-func (e *SpotExchange) updateLastPing() {
-	status := e.Health()
-	status.LastPing = time.Now()
-	e.health.Store(status)
-}
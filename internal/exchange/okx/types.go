@@ -2,7 +2,9 @@ package okx
 
 // Config holds configuration for OKX exchange
 type Config struct {
-	Symbol string
+	Symbol     string
+	Depth      int    // Levels per side fetched on each poll (max 5000), 0 uses 5000
+	Instrument string // Instrument ID used verbatim instead of converting Symbol (e.g., BTC-USD-250627), empty converts Symbol
 }
 
 // OrderBookResponse represents the REST API response for OKX order book
@@ -18,3 +20,12 @@ type OrderBookData struct {
 	Bids [][]string `json:"bids"` // [price, quantity, deprecated, order_count]
 	Ts   string     `json:"ts"`   // timestamp
 }
+
+// ServerTimeResponse represents the REST API response for the OKX server time
+type ServerTimeResponse struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		Ts string `json:"ts"` // Server time in milliseconds
+	} `json:"data"`
+}
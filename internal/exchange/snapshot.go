@@ -0,0 +1,69 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// RetryPolicy controls the timeout and retries of snapshot requests
+type RetryPolicy struct {
+	Timeout    time.Duration // Per-attempt timeout, 0 keeps the adapter default
+	Attempts   int           // Total attempts, values below 1 make a single attempt
+	Backoff    time.Duration // Delay before the first retry, doubled after each failure
+	MaxBackoff time.Duration // Upper bound of the delay, 0 leaves it unbounded
+}
+
+// FetchSnapshot calls get until it returns a snapshot, the attempts are exhausted
+// or ctx is cancelled, waiting with exponential backoff between attempts. It gives
+// up at once on errors that are not Retryable and waits at least as long as a
+// rate limit asks for.
+func FetchSnapshot(ctx context.Context, name ExchangeName, policy RetryPolicy, get func(context.Context) (*Snapshot, error)) (*Snapshot, error) {
+	attempts := max(policy.Attempts, 1)
+	backoff := policy.Backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var snapshot *Snapshot
+		snapshot, err = fetchOnce(ctx, policy.Timeout, get)
+		if err == nil {
+			return snapshot, nil
+		}
+		if !Retryable(err) {
+			return nil, fmt.Errorf("snapshot failed: %w", err)
+		}
+		if attempt >= attempts {
+			break
+		}
+
+		wait := backoff
+		if retryAfter, ok := RetryAfter(err); ok && retryAfter > wait {
+			wait = retryAfter
+		}
+		log.Printf("[%s] Snapshot attempt %d/%d failed: %v (retrying in %s)", name, attempt, attempts, err, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("context cancelled while retrying snapshot: %w", ctx.Err())
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+	return nil, fmt.Errorf("snapshot failed after %d attempts: %w", attempts, err)
+}
+
+// fetchOnce makes one snapshot attempt bounded by timeout when set
+func fetchOnce(ctx context.Context, timeout time.Duration, get func(context.Context) (*Snapshot, error)) (*Snapshot, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return get(ctx)
+}
@@ -0,0 +1,86 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFetchSnapshot(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	tests := []struct {
+		name     string
+		failures int
+		calls    int
+		ok       bool
+	}{
+		{"first attempt", 0, 1, true},
+		{"after retries", 2, 3, true},
+		{"exhausted", 5, 3, false},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		snapshot, err := FetchSnapshot(context.Background(), Binance, policy, func(ctx context.Context) (*Snapshot, error) {
+			calls++
+			if calls <= tt.failures {
+				return nil, errors.New("rate limited")
+			}
+			return &Snapshot{LastUpdateID: 1}, nil
+		})
+		if calls != tt.calls {
+			t.Errorf("%s: Expected %d calls, got %d", tt.name, tt.calls, calls)
+		}
+		if (err == nil) != tt.ok || (snapshot != nil) != tt.ok {
+			t.Errorf("%s: Expected ok=%v, got snapshot=%v err=%v", tt.name, tt.ok, snapshot, err)
+		}
+	}
+}
+
+func TestFetchSnapshotTimeout(t *testing.T) {
+	policy := RetryPolicy{Timeout: 10 * time.Millisecond, Attempts: 1}
+	_, err := FetchSnapshot(context.Background(), Binance, policy, func(ctx context.Context) (*Snapshot, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestFetchSnapshotClassifiesErrors(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+	tests := []struct {
+		name    string
+		err     error
+		calls   int
+		minWait time.Duration
+	}{
+		{"symbol not found", fmt.Errorf("BTCXYZ: %w", ErrSymbolNotFound), 1, 0},
+		{"auth", fmt.Errorf("%w: 403 Forbidden", ErrAuth), 1, 0},
+		{"closed", ErrConnClosed, 1, 0},
+		{"rate limited", &RateLimitError{Status: 429, RetryAfter: 20 * time.Millisecond}, 3, 40 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		start := time.Now()
+		_, err := FetchSnapshot(context.Background(), Binance, policy, func(ctx context.Context) (*Snapshot, error) {
+			calls++
+			return nil, tt.err
+		})
+		if calls != tt.calls {
+			t.Errorf("%s: Expected %d calls, got %d", tt.name, tt.calls, calls)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: Expected %v in the chain, got %v", tt.name, tt.err, err)
+		}
+		if elapsed := time.Since(start); elapsed < tt.minWait {
+			t.Errorf("%s: Expected to wait at least %v, waited %v", tt.name, tt.minWait, elapsed)
+		}
+	}
+}
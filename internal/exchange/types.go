@@ -3,6 +3,9 @@ package exchange
 import (
 	"context"
 	"time"
+
+	"github.com/shopspring/decimal"
+	"orderbook/internal/tracing"
 )
 
 // ExchangeName represents supported exchange identifiers
@@ -10,14 +13,18 @@ type ExchangeName string
 
 const (
 	Binancef     ExchangeName = "binancef"
+	Binancefc    ExchangeName = "binancefc"
 	Binance      ExchangeName = "binance"
 	Bybitf       ExchangeName = "bybitf"
 	Bybit        ExchangeName = "bybit"
 	Kraken       ExchangeName = "kraken"
+	Krakenf      ExchangeName = "krakenf"
 	Hyperliquidf ExchangeName = "hyperliquidf"
 	OKX          ExchangeName = "okx"
 	Coinbase     ExchangeName = "coinbase"
+	Coinbasef    ExchangeName = "coinbasef"
 	Asterdexf    ExchangeName = "asterdexf"
+	Asterdex     ExchangeName = "asterdex"
 	BingX        ExchangeName = "bingx"
 	BingXf       ExchangeName = "bingxf"
 )
@@ -47,6 +54,78 @@ type Exchange interface {
 
 	// Health returns connection health information
 	Health() HealthStatus
+
+	// Capabilities describes how the feed delivers the book
+	Capabilities() Capabilities
+}
+
+// SnapshotSource identifies where an adapter gets its initial book
+type SnapshotSource string
+
+const (
+	SnapshotREST      SnapshotSource = "rest"      // Fetched from a REST endpoint
+	SnapshotWebSocket SnapshotSource = "websocket" // First message of the WebSocket stream
+)
+
+// Capabilities describes the behavior of an exchange feed so consumers can adapt
+// to it instead of assuming per-venue behavior
+type Capabilities struct {
+	FullDepth      bool           // Every update is a full book that replaces all levels
+	SequenceIDs    bool           // Updates carry IDs that allow gap detection
+	Checksum       bool           // The venue publishes book checksums
+	SnapshotSource SnapshotSource // Source of the initial book
+	Trades         bool           // The adapter streams trades
+	TopOfBook      bool           // Updates carry the best bid and ask only
+}
+
+// StallWatcher is implemented by exchanges that can detect silent stalls
+type StallWatcher interface {
+	// SetStaleTimeout forces a reconnect when no message arrives within timeout (0 disables).
+	// It must be called before Connect.
+	SetStaleTimeout(timeout time.Duration)
+}
+
+// ShallowSnapshotter is implemented by exchanges whose REST snapshots can be
+// limited to the levels nearest the touch, which cost less than a full snapshot
+type ShallowSnapshotter interface {
+	// GetShallowSnapshot fetches a snapshot of at most depth levels per side
+	GetShallowSnapshot(ctx context.Context, depth int) (*Snapshot, error)
+}
+
+// TradeStreamer is implemented by exchanges that stream trades alongside the book
+type TradeStreamer interface {
+	// Trades returns a channel that receives trades in canonical format
+	Trades() <-chan *Trade
+}
+
+// QuoteProvider is implemented by exchanges whose book may be quoted in another
+// currency than the requested symbol (e.g., BTCUSDT is subscribed as BTC/USD)
+type QuoteProvider interface {
+	// QuoteCurrency returns the quote currency of the subscribed book (e.g., "USD")
+	QuoteCurrency() string
+}
+
+// OpenInterestProvider is implemented by derivatives exchanges that publish the
+// open interest of the subscribed contract
+type OpenInterestProvider interface {
+	// GetOpenInterest fetches the open interest in base units (e.g., BTC). It fails
+	// with ErrNoOpenInterest when the subscribed market has none (e.g., spot).
+	GetOpenInterest(ctx context.Context) (decimal.Decimal, error)
+}
+
+// InstrumentProvider is implemented by exchanges that expose instrument metadata
+type InstrumentProvider interface {
+	// GetInstrumentInfo fetches precision metadata for the configured symbol
+	GetInstrumentInfo(ctx context.Context) (*InstrumentInfo, error)
+}
+
+// InstrumentInfo describes the price and quantity precision of an instrument
+type InstrumentInfo struct {
+	Symbol        string // Exchange symbol
+	TickSize      string // Minimum price increment (e.g., "0.01")
+	StepSize      string // Minimum quantity increment (e.g., "0.00001")
+	PriceDecimals int    // Fractional digits of TickSize
+	QtyDecimals   int    // Fractional digits of StepSize
 }
 
 // Snapshot represents a canonical orderbook snapshot (normalized across exchanges)
@@ -61,14 +140,20 @@ type Snapshot struct {
 
 // DepthUpdate represents a canonical depth update event (normalized across exchanges)
 type DepthUpdate struct {
-	Exchange      ExchangeName // Exchange name
-	Symbol        string       // Trading symbol
-	EventTime     time.Time    // Event timestamp
-	FirstUpdateID int64        // First update ID in this event
-	FinalUpdateID int64        // Final update ID in this event
-	PrevUpdateID  int64        // Previous update ID (for continuity checking)
-	Bids          []PriceLevel // Updated bid levels
-	Asks          []PriceLevel // Updated ask levels
+	Exchange       ExchangeName  // Exchange name
+	Symbol         string        // Trading symbol
+	EventTime      time.Time     // Event timestamp (venue clock)
+	LocalEventTime time.Time     // EventTime corrected for the venue clock offset (local clock)
+	FirstUpdateID  int64         // First update ID in this event
+	FinalUpdateID  int64         // Final update ID in this event
+	PrevUpdateID   int64         // Previous update ID (for continuity checking)
+	Bids           []PriceLevel  // Updated bid levels
+	Asks           []PriceLevel  // Updated ask levels
+	Snapshot       bool          // Full book that replaces all levels (e.g., resent after reconnect)
+	ReceiveTime    time.Time     // Local time the message carrying the update was read
+	Connection     int64         // Generation of the adapter connection that produced the update, from 1, incremented on every reconnect
+	ConnectionSeq  int64         // Position of the update on its connection, from 1
+	Trace          *tracing.Span // Queueing span of a sampled update, a child of its message's span; nil when not traced
 }
 
 // PriceLevel represents a single price level [price, quantity]
@@ -84,4 +169,26 @@ type HealthStatus struct {
 	MessageCount  int64
 	ErrorCount    int64
 	ReconnectTime *time.Time
+	Stalls        int64         // Silent stalls detected by the heartbeat watchdog
+	Reconnects    int64         // Reconnections performed by the adapter after a stall
+	Failovers     int64         // Switches to another collector's stream (distributed mode)
+	ClockOffset   time.Duration // Estimated venue clock minus local clock
+}
+
+// TradeSide represents the aggressor side of a trade
+type TradeSide string
+
+const (
+	TradeSideBuy  TradeSide = "buy"
+	TradeSideSell TradeSide = "sell"
+)
+
+// Trade represents a canonical trade event (normalized across exchanges)
+type Trade struct {
+	Exchange  ExchangeName // Exchange name
+	Symbol    string       // Trading symbol
+	Price     string       // Trade price as string to avoid precision loss
+	Quantity  string       // Trade quantity as string to avoid precision loss
+	Side      TradeSide    // Aggressor side
+	TradeTime time.Time    // Trade timestamp
 }
@@ -16,67 +16,166 @@ import (
 
 // ExchangeConfig holds configuration for creating an exchange
 type ExchangeConfig struct {
-	Name   exchange.ExchangeName
-	Symbol string
+	Name        exchange.ExchangeName
+	Symbol      string
+	Depth       int    // Subscription or snapshot depth, 0 uses the adapter default (Binance, Bybit, Kraken, OKX, Asterdex)
+	UpdateSpeed string // Depth stream frequency, empty uses the adapter default (Binance)
+	Category    string // Product category, empty uses the adapter default (Bybit)
+	SigFigs     int    // Significant figures of server-side aggregated price levels, 0 for full precision (Hyperliquid)
+	Mantissa    int    // Aggregation step mantissa with 5 significant figures, 0 for the venue default (Hyperliquid)
+	Instrument  string // Venue instrument ID used verbatim instead of converting Symbol, empty converts Symbol
+	TopOfBook   bool   // Stream the best bid and ask only (Binance bookTicker, Bybit depth 1)
 }
 
 // NewExchange creates a new exchange instance based on the configuration
 func NewExchange(config ExchangeConfig) (exchange.Exchange, error) {
+	var category bybit.Category
+	if config.Category != "" {
+		if config.Name != exchange.Bybit && config.Name != exchange.Bybitf {
+			return nil, fmt.Errorf("%s does not support categories", config.Name)
+		}
+		parsed, err := bybit.ParseCategory(config.Category)
+		if err != nil {
+			return nil, err
+		}
+		category = parsed
+		symbol := config.Symbol
+		if config.Instrument != "" {
+			symbol = config.Instrument
+		}
+		if err := category.CheckSymbol(symbol); err != nil {
+			return nil, err
+		}
+	}
+	if (config.SigFigs != 0 || config.Mantissa != 0) && config.Name != exchange.Hyperliquidf {
+		return nil, fmt.Errorf("%s does not support book aggregation", config.Name)
+	}
+	if config.TopOfBook {
+		switch config.Name {
+		case exchange.Binance, exchange.Binancef, exchange.Binancefc, exchange.Bybit, exchange.Bybitf:
+		default:
+			return nil, fmt.Errorf("%s does not support top-of-book mode", config.Name)
+		}
+		if category == bybit.CategoryOption {
+			return nil, fmt.Errorf("%s options do not support top-of-book mode", config.Name)
+		}
+	}
+
 	switch config.Name {
 	case exchange.Binancef:
 		return binance.NewFuturesExchange(binance.Config{
-			Symbol: config.Symbol,
+			Symbol:        config.Symbol,
+			Instrument:    config.Instrument,
+			UpdateSpeed:   config.UpdateSpeed,
+			SnapshotDepth: config.Depth,
+			TopOfBook:     config.TopOfBook,
+		}), nil
+
+	case exchange.Binancefc:
+		return binance.NewCoinMExchange(binance.Config{
+			Symbol:        config.Symbol,
+			Instrument:    config.Instrument,
+			UpdateSpeed:   config.UpdateSpeed,
+			SnapshotDepth: config.Depth,
+			TopOfBook:     config.TopOfBook,
 		}), nil
 
 	case exchange.Binance:
 		return binance.NewSpotExchange(binance.Config{
-			Symbol: config.Symbol,
+			Symbol:        config.Symbol,
+			Instrument:    config.Instrument,
+			UpdateSpeed:   config.UpdateSpeed,
+			SnapshotDepth: config.Depth,
+			TopOfBook:     config.TopOfBook,
 		}), nil
 
 	case exchange.Bybitf:
 		return bybit.NewFuturesExchange(bybit.Config{
-			Symbol: config.Symbol,
+			Symbol:     config.Symbol,
+			Depth:      config.Depth,
+			Category:   category,
+			Instrument: config.Instrument,
+			TopOfBook:  config.TopOfBook,
 		}), nil
 
 	case exchange.Bybit:
 		return bybit.NewSpotExchange(bybit.Config{
-			Symbol: config.Symbol,
+			Symbol:     config.Symbol,
+			Depth:      config.Depth,
+			Category:   category,
+			Instrument: config.Instrument,
+			TopOfBook:  config.TopOfBook,
 		}), nil
 
 	case exchange.Kraken:
 		return kraken.NewSpotExchange(kraken.Config{
-			Symbol: config.Symbol,
+			Symbol:     config.Symbol,
+			Instrument: config.Instrument,
+			Depth:      config.Depth,
+		}), nil
+
+	case exchange.Krakenf:
+		return kraken.NewFuturesExchange(kraken.Config{
+			Symbol:     config.Symbol,
+			Instrument: config.Instrument,
 		}), nil
 
 	case exchange.OKX:
 		return okx.NewSpotExchange(okx.Config{
-			Symbol: config.Symbol,
+			Symbol:     config.Symbol,
+			Instrument: config.Instrument,
+			Depth:      config.Depth,
 		}), nil
 
 	case exchange.Coinbase:
 		return coinbase.NewSpotExchange(coinbase.Config{
-			Symbol: config.Symbol,
+			Symbol:     config.Symbol,
+			Instrument: config.Instrument,
+		}), nil
+
+	case exchange.Coinbasef:
+		return coinbase.NewFuturesExchange(coinbase.Config{
+			Symbol:     config.Symbol,
+			Instrument: config.Instrument,
 		}), nil
 
 	case exchange.Asterdexf:
 		return asterdex.NewFuturesExchange(asterdex.Config{
-			Symbol: config.Symbol,
+			Symbol:        config.Symbol,
+			Instrument:    config.Instrument,
+			SnapshotDepth: config.Depth,
+		}), nil
+
+	case exchange.Asterdex:
+		return asterdex.NewSpotExchange(asterdex.Config{
+			Symbol:        config.Symbol,
+			Instrument:    config.Instrument,
+			SnapshotDepth: config.Depth,
 		}), nil
 
 	case exchange.BingX:
 		return bingx.NewSpotExchange(bingx.Config{
-			Symbol: config.Symbol,
+			Symbol:     config.Symbol,
+			Instrument: config.Instrument,
 		}), nil
 
 	case exchange.BingXf:
 		return bingx.NewFuturesExchange(bingx.Config{
-			Symbol: config.Symbol,
+			Symbol:     config.Symbol,
+			Instrument: config.Instrument,
 		}), nil
 
 	case exchange.Hyperliquidf:
-		return hyperliquid.NewFuturesExchange(hyperliquid.Config{
-			Symbol: config.Symbol,
-		}), nil
+		hlConfig := hyperliquid.Config{
+			Symbol:     config.Symbol,
+			SigFigs:    config.SigFigs,
+			Mantissa:   config.Mantissa,
+			Instrument: config.Instrument,
+		}
+		if err := hlConfig.Validate(); err != nil {
+			return nil, err
+		}
+		return hyperliquid.NewFuturesExchange(hlConfig), nil
 
 	default:
 		return nil, fmt.Errorf("unknown exchange: %s", config.Name)
@@ -86,7 +185,7 @@ func NewExchange(config ExchangeConfig) (exchange.Exchange, error) {
 // ValidateExchangeName checks if the exchange name is supported
 func ValidateExchangeName(name string) bool {
 	switch exchange.ExchangeName(name) {
-	case exchange.Binancef, exchange.Binance, exchange.Bybitf, exchange.Bybit, exchange.Kraken, exchange.Hyperliquidf, exchange.OKX, exchange.Coinbase, exchange.Asterdexf, exchange.BingX, exchange.BingXf:
+	case exchange.Binancef, exchange.Binancefc, exchange.Binance, exchange.Bybitf, exchange.Bybit, exchange.Kraken, exchange.Krakenf, exchange.Hyperliquidf, exchange.OKX, exchange.Coinbase, exchange.Coinbasef, exchange.Asterdexf, exchange.Asterdex, exchange.BingX, exchange.BingXf:
 		return true
 	default:
 		return false
@@ -95,10 +194,10 @@ func ValidateExchangeName(name string) bool {
 
 // GetSupportedExchanges returns a list of all supported exchanges
 func GetSupportedExchanges() []exchange.ExchangeName {
-	return []exchange.ExchangeName{exchange.Binancef, exchange.Binance, exchange.Bybitf, exchange.Bybit, exchange.Kraken, exchange.Hyperliquidf, exchange.OKX, exchange.Coinbase, exchange.Asterdexf, exchange.BingX, exchange.BingXf}
+	return []exchange.ExchangeName{exchange.Binancef, exchange.Binancefc, exchange.Binance, exchange.Bybitf, exchange.Bybit, exchange.Kraken, exchange.Krakenf, exchange.Hyperliquidf, exchange.OKX, exchange.Coinbase, exchange.Coinbasef, exchange.Asterdexf, exchange.Asterdex, exchange.BingX, exchange.BingXf}
 }
 
 // GetImplementedExchanges returns a list of currently implemented exchanges
 func GetImplementedExchanges() []exchange.ExchangeName {
-	return []exchange.ExchangeName{exchange.Binancef, exchange.Binance, exchange.Bybitf, exchange.Bybit, exchange.Kraken, exchange.Hyperliquidf, exchange.OKX, exchange.Coinbase, exchange.Asterdexf, exchange.BingX, exchange.BingXf}
+	return []exchange.ExchangeName{exchange.Binancef, exchange.Binancefc, exchange.Binance, exchange.Bybitf, exchange.Bybit, exchange.Kraken, exchange.Krakenf, exchange.Hyperliquidf, exchange.OKX, exchange.Coinbase, exchange.Coinbasef, exchange.Asterdexf, exchange.Asterdex, exchange.BingX, exchange.BingXf}
 }
@@ -0,0 +1,122 @@
+package fixedpoint
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxDecimals is the largest supported number of fractional digits
+const MaxDecimals = 12
+
+var pow10 = [MaxDecimals + 1]int64{
+	1, 10, 100, 1000, 10000, 100000, 1000000, 10000000, 100000000,
+	1000000000, 10000000000, 100000000000, 1000000000000,
+}
+
+// Scale describes a fixed-point representation with a number of fractional digits
+type Scale struct {
+	decimals int
+}
+
+// NewScale creates a Scale with the given number of fractional digits
+func NewScale(decimals int) (Scale, error) {
+	if decimals < 0 || decimals > MaxDecimals {
+		return Scale{}, fmt.Errorf("unsupported decimals %d (max %d)", decimals, MaxDecimals)
+	}
+	return Scale{decimals: decimals}, nil
+}
+
+// Decimals returns the number of fractional digits
+func (s Scale) Decimals() int {
+	return s.decimals
+}
+
+// Parse converts a non-negative decimal string (e.g., "50000.10") to a scaled int64
+// without allocating. Extra fractional digits are accepted only if they are zeros.
+func (s Scale) Parse(str string) (int64, error) {
+	if len(str) == 0 {
+		return 0, fmt.Errorf("empty number")
+	}
+
+	var value int64
+	fracDigits := -1 // -1 until the decimal point is seen
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch {
+		case c == '.':
+			if fracDigits >= 0 {
+				return 0, fmt.Errorf("invalid number %q", str)
+			}
+			fracDigits = 0
+		case c >= '0' && c <= '9':
+			if fracDigits >= s.decimals {
+				if c != '0' {
+					return 0, fmt.Errorf("number %q exceeds %d decimals", str, s.decimals)
+				}
+				continue
+			}
+			if value > (1<<63-1-int64(c-'0'))/10 {
+				return 0, fmt.Errorf("number %q overflows", str)
+			}
+			value = value*10 + int64(c-'0')
+			if fracDigits >= 0 {
+				fracDigits++
+			}
+		default:
+			return 0, fmt.Errorf("invalid number %q", str)
+		}
+	}
+
+	if fracDigits < 0 {
+		fracDigits = 0
+	}
+	missing := s.decimals - fracDigits
+	if value > (1<<63-1)/pow10[missing] {
+		return 0, fmt.Errorf("number %q overflows", str)
+	}
+	return value * pow10[missing], nil
+}
+
+// Format converts a scaled int64 back to its canonical decimal string
+func (s Scale) Format(value int64) string {
+	return s.ToDecimal(value).String()
+}
+
+// ToDecimal converts a scaled int64 to a decimal
+func (s Scale) ToDecimal(value int64) decimal.Decimal {
+	return decimal.New(value, -int32(s.decimals))
+}
+
+// FromDecimalFloor converts a decimal to a scaled int64, rounding down
+func (s Scale) FromDecimalFloor(d decimal.Decimal) int64 {
+	return d.Shift(int32(s.decimals)).Floor().IntPart()
+}
+
+// FromDecimalCeil converts a decimal to a scaled int64, rounding up
+func (s Scale) FromDecimalCeil(d decimal.Decimal) int64 {
+	return d.Shift(int32(s.decimals)).Ceil().IntPart()
+}
+
+// DecimalPlaces returns the number of significant fractional digits in a
+// decimal string such as a tick size ("0.01000000" -> 2, "1" -> 0)
+func DecimalPlaces(str string) (int, error) {
+	if _, err := strconv.ParseFloat(str, 64); err != nil {
+		return 0, fmt.Errorf("invalid number %q: %w", str, err)
+	}
+
+	point := -1
+	last := -1
+	for i := 0; i < len(str); i++ {
+		if str[i] == '.' {
+			point = i
+		} else if point >= 0 && str[i] != '0' {
+			last = i
+		}
+	}
+	if point < 0 || last < 0 {
+		return 0, nil
+	}
+	return last - point, nil
+}
@@ -0,0 +1,60 @@
+package fixedpoint
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		decimals int
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{name: "Integer", decimals: 2, input: "50000", expected: 5000000},
+		{name: "Exact decimals", decimals: 2, input: "50000.12", expected: 5000012},
+		{name: "Fewer decimals", decimals: 3, input: "0.5", expected: 500},
+		{name: "Trailing zeros beyond scale", decimals: 2, input: "50000.10000000", expected: 5000010},
+		{name: "Significant digits beyond scale", decimals: 2, input: "50000.123", wantErr: true},
+		{name: "Zero", decimals: 8, input: "0.00000000", expected: 0},
+		{name: "Empty", decimals: 2, input: "", wantErr: true},
+		{name: "Negative", decimals: 2, input: "-1", wantErr: true},
+		{name: "Double point", decimals: 2, input: "1.2.3", wantErr: true},
+		{name: "Overflow", decimals: 12, input: "99999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale, err := NewScale(tt.decimals)
+			if err != nil {
+				t.Fatalf("NewScale() failed: %v", err)
+			}
+			result, err := scale.Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && result != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDecimalPlaces(t *testing.T) {
+	tests := map[string]int{
+		"0.01000000": 2,
+		"0.00001":    5,
+		"1":          0,
+		"1.00000000": 0,
+		"0.5":        1,
+	}
+
+	for input, expected := range tests {
+		result, err := DecimalPlaces(input)
+		if err != nil {
+			t.Fatalf("DecimalPlaces(%q) failed: %v", input, err)
+		}
+		if result != expected {
+			t.Errorf("DecimalPlaces(%q): expected %d, got %d", input, expected, result)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+// minCompactSize is the journal size below which it is never compacted
+const minCompactSize = 4 << 20
+
+// maxRecordSize bounds the length read from a record header, so a corrupt header
+// is detected instead of allocating gigabytes
+const maxRecordSize = 256 << 20
+
+// State is the engine configuration changed at runtime and restored on restart
+type State struct {
+	Symbol string    // Symbol monitored when the state was written
+	Time   time.Time // Time the state was written
+}
+
+// record is one journal entry, holding either a book checkpoint or the state
+type record struct {
+	Checkpoint *exchange.Snapshot `json:"checkpoint,omitempty"`
+	State      *State             `json:"state,omitempty"`
+}
+
+// checkpointKey identifies the checkpoint of one book
+type checkpointKey struct {
+	exchange exchange.ExchangeName
+	symbol   string
+}
+
+// Journal is a write-ahead log of book checkpoints and engine state, read back on
+// restart so venues resume from their latest checkpoint instead of cold-starting.
+// Each record is framed as a 4-byte big-endian length, the CRC-32 of the payload
+// and the JSON payload; a torn or corrupt tail (e.g., after a crash mid-write) is
+// truncated on open. The file is compacted to the latest record of every book when
+// it grew to four times its compacted size.
+type Journal struct {
+	mu          sync.Mutex
+	path        string
+	file        *os.File
+	size        int64 // Bytes written to the file
+	compactSize int64 // Size after the last compaction
+	checkpoints map[checkpointKey]*exchange.Snapshot
+	state       *State
+}
+
+// Open replays the journal at path, creating it if needed, and compacts it
+func Open(path string) (*Journal, error) {
+	j := &Journal{
+		path:        path,
+		checkpoints: make(map[checkpointKey]*exchange.Snapshot),
+	}
+	if err := j.replay(); err != nil {
+		return nil, err
+	}
+	if err := j.compact(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// replay loads the records of the journal file, ignoring a torn or corrupt tail
+func (j *Journal) replay() error {
+	file, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	for {
+		// The clean end of the file or a torn or corrupt record ends the replay;
+		// the compaction drops everything after it
+		payload, err := readRecord(r)
+		if err != nil {
+			return nil
+		}
+		var rec record
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return nil
+		}
+		j.apply(&rec)
+	}
+}
+
+// readRecord reads the payload of the next record, returning io.EOF at the end of the file
+func readRecord(r io.Reader) ([]byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length > maxRecordSize {
+		return nil, fmt.Errorf("record of %d bytes exceeds the maximum", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, fmt.Errorf("record checksum mismatch")
+	}
+	return payload, nil
+}
+
+// appendRecord appends the framed record to buf
+func appendRecord(buf []byte, rec *record) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode journal record: %w", err)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(payload))
+	return append(buf, payload...), nil
+}
+
+// apply keeps a record if it is the latest of its book or the latest state
+func (j *Journal) apply(rec *record) {
+	if cp := rec.Checkpoint; cp != nil {
+		j.checkpoints[checkpointKey{exchange: cp.Exchange, symbol: cp.Symbol}] = cp
+	}
+	if rec.State != nil {
+		j.state = rec.State
+	}
+}
+
+// compact rewrites the journal with the latest records only, through a temporary
+// file renamed over the journal, and reopens it for appending
+func (j *Journal) compact() error {
+	keys := make([]checkpointKey, 0, len(j.checkpoints))
+	for key := range j.checkpoints {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].exchange != keys[b].exchange {
+			return keys[a].exchange < keys[b].exchange
+		}
+		return keys[a].symbol < keys[b].symbol
+	})
+
+	var buf []byte
+	var err error
+	if j.state != nil {
+		if buf, err = appendRecord(buf, &record{State: j.state}); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if buf, err = appendRecord(buf, &record{Checkpoint: j.checkpoints[key]}); err != nil {
+			return err
+		}
+	}
+
+	tmp := j.path + ".tmp"
+	if err := writeFileSync(tmp, buf); err != nil {
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	j.file = file
+	j.size = int64(len(buf))
+	j.compactSize = j.size
+	return nil
+}
+
+// writeFileSync writes data to a new file at path and flushes it to disk
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// write appends records, flushes them to disk and compacts the journal once it grew enough
+func (j *Journal) write(records []*record) error {
+	if j.file == nil {
+		return fmt.Errorf("journal is closed")
+	}
+	var buf []byte
+	for _, rec := range records {
+		var err error
+		if buf, err = appendRecord(buf, rec); err != nil {
+			return err
+		}
+	}
+	if _, err := j.file.Write(buf); err != nil {
+		// Drop a partial record so the records appended later can be replayed
+		j.file.Truncate(j.size)
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	j.size += int64(len(buf))
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	for _, rec := range records {
+		j.apply(rec)
+	}
+
+	if j.size > minCompactSize && j.size > 4*j.compactSize {
+		return j.compact()
+	}
+	return nil
+}
+
+// WriteCheckpoints durably appends a checkpoint of each book
+func (j *Journal) WriteCheckpoints(snapshots []*exchange.Snapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	records := make([]*record, len(snapshots))
+	for i, snapshot := range snapshots {
+		records[i] = &record{Checkpoint: snapshot}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.write(records)
+}
+
+// WriteState durably appends the engine state
+func (j *Journal) WriteState(state State) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.write([]*record{{State: &state}})
+}
+
+// Checkpoint returns the latest checkpoint of the book of name for symbol, which
+// must not be modified
+func (j *Journal) Checkpoint(name exchange.ExchangeName, symbol string) (*exchange.Snapshot, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	cp, ok := j.checkpoints[checkpointKey{exchange: name, symbol: symbol}]
+	return cp, ok
+}
+
+// State returns the latest engine state
+func (j *Journal) State() (State, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.state == nil {
+		return State{}, false
+	}
+	return *j.state, true
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
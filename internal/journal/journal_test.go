@@ -0,0 +1,106 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+func checkpoint(name exchange.ExchangeName, id int64, price string) *exchange.Snapshot {
+	return &exchange.Snapshot{
+		Exchange:     name,
+		Symbol:       "BTCUSDT",
+		LastUpdateID: id,
+		Bids:         []exchange.PriceLevel{{Price: price, Quantity: "1.5"}},
+		Asks:         []exchange.PriceLevel{{Price: "50001", Quantity: "2"}},
+		Timestamp:    time.Unix(1700000000, 0).UTC(),
+	}
+}
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orderbook.wal")
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, ok := j.State(); ok {
+		t.Errorf("Expected no state in a new journal")
+	}
+	if err := j.WriteState(State{Symbol: "ETHUSDT"}); err != nil {
+		t.Fatalf("WriteState failed: %v", err)
+	}
+	for i := int64(1); i <= 3; i++ {
+		if err := j.WriteCheckpoints([]*exchange.Snapshot{checkpoint(exchange.Binance, i, "50000"), checkpoint(exchange.OKX, 10*i, "49999")}); err != nil {
+			t.Fatalf("WriteCheckpoints failed: %v", err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// A crash mid-write leaves a torn record at the end of the file
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("Failed to open journal file: %v", err)
+	}
+	file.Write([]byte{0, 0, 1, 0, 1, 2, 3, 4, '{', '"'})
+	file.Close()
+
+	j, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open after a torn write failed: %v", err)
+	}
+	defer j.Close()
+
+	if state, ok := j.State(); !ok || state.Symbol != "ETHUSDT" {
+		t.Errorf("Expected state ETHUSDT, got %+v", state)
+	}
+	tests := []struct {
+		name  exchange.ExchangeName
+		id    int64
+		price string
+	}{
+		{exchange.Binance, 3, "50000"},
+		{exchange.OKX, 30, "49999"},
+	}
+	for _, tt := range tests {
+		cp, ok := j.Checkpoint(tt.name, "BTCUSDT")
+		if !ok {
+			t.Errorf("%s: Expected a checkpoint", tt.name)
+			continue
+		}
+		if cp.LastUpdateID != tt.id || cp.Bids[0].Price != tt.price || !cp.Timestamp.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("%s: Expected lastUpdateId %d at %s, got %+v", tt.name, tt.id, tt.price, cp)
+		}
+	}
+	if _, ok := j.Checkpoint(exchange.Bybit, "BTCUSDT"); ok {
+		t.Errorf("Expected no checkpoint for bybit")
+	}
+
+	// Opening compacted the journal to the latest records and dropped the torn tail
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size() != j.compactSize {
+		t.Errorf("Expected a compacted size of %d, got %d", j.compactSize, info.Size())
+	}
+
+	// Records appended after the truncated tail are replayed
+	if err := j.WriteCheckpoints([]*exchange.Snapshot{checkpoint(exchange.Binance, 4, "50002")}); err != nil {
+		t.Fatalf("WriteCheckpoints failed: %v", err)
+	}
+	j.Close()
+	j, err = Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer j.Close()
+	if cp, ok := j.Checkpoint(exchange.Binance, "BTCUSDT"); !ok || cp.LastUpdateID != 4 {
+		t.Errorf("Expected lastUpdateId 4 after reopening, got %+v", cp)
+	}
+}
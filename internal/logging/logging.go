@@ -0,0 +1,113 @@
+// Package logging adds a runtime-adjustable verbosity to the standard logger.
+//
+// Most of the code logs through log.Printf. Those lines are treated as info,
+// or as errors when they report a failure, so the level can be changed without
+// touching every call site; Debugf adds lines that are only written at debug.
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// Level is the minimum severity of the lines written
+type Level int32
+
+const (
+	LevelDebug Level = iota // Also write Debugf lines
+	LevelInfo               // Write every standard log line (default)
+	LevelError              // Only write lines reporting errors or failures
+)
+
+var level atomic.Int32
+
+func init() {
+	level.Store(int32(LevelInfo))
+}
+
+// String returns the name accepted by ParseLevel
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel parses "debug", "info" or "error"
+func ParseLevel(s string) (Level, error) {
+	switch s {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (debug, info, error)", s)
+}
+
+// SetLevel changes the level of every subsequent line
+func SetLevel(l Level) {
+	level.Store(int32(l))
+}
+
+// GetLevel returns the current level
+func GetLevel() Level {
+	return Level(level.Load())
+}
+
+// Debugf logs through the standard logger at debug level
+func Debugf(format string, args ...interface{}) {
+	if GetLevel() <= LevelDebug {
+		log.Printf(format, args...)
+	}
+}
+
+// Install routes the standard logger through a filter that applies the level
+func Install() {
+	log.SetOutput(NewFilter(log.Writer()))
+}
+
+// errorMarkers identify standard log lines reporting a failure
+var errorMarkers = [][]byte{[]byte("error"), []byte("fail"), []byte("fatal"), []byte("panic")}
+
+// filter drops lines below the current level before writing them to w
+type filter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewFilter returns a writer that forwards to w the lines allowed by the current level
+func NewFilter(w io.Writer) io.Writer {
+	return &filter{w: w}
+}
+
+// Write forwards p unless the level is error and p does not report an error.
+// The standard logger writes one line per call.
+func (f *filter) Write(p []byte) (int, error) {
+	if GetLevel() >= LevelError && !isError(p) {
+		return len(p), nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.w.Write(p)
+}
+
+// isError reports whether a line reports an error or a failure
+func isError(line []byte) bool {
+	lower := bytes.ToLower(line)
+	for _, marker := range errorMarkers {
+		if bytes.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
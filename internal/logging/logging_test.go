@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	defer SetLevel(LevelInfo)
+
+	var buf bytes.Buffer
+	logger := log.New(NewFilter(&buf), "", 0)
+	tests := []struct {
+		level Level
+		want  string
+	}{
+		{LevelInfo, "Orderbook initialized\n[binance] Failed to connect\n"},
+		{LevelError, "[binance] Failed to connect\n"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		SetLevel(tt.level)
+		logger.Print("Orderbook initialized")
+		logger.Print("[binance] Failed to connect")
+		if buf.String() != tt.want {
+			t.Errorf("Expected %q at %s, got %q", tt.want, tt.level, buf.String())
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, name := range []string{"debug", "info", "error"} {
+		level, err := ParseLevel(name)
+		if err != nil || level.String() != name {
+			t.Errorf("Expected %s, got %s (%v)", name, level, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("Expected an error for an unknown level")
+	}
+}
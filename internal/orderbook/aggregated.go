@@ -0,0 +1,52 @@
+package orderbook
+
+import (
+	"orderbook/internal/aggregation"
+	"orderbook/internal/types"
+)
+
+// EnableIncrementalAggregation maintains the book aggregated at tick, updating the
+// affected buckets on every depth update instead of rebuilding them on each read
+func (ob *OrderBook) EnableIncrementalAggregation(tick types.TickLevel) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.aggregated = aggregation.NewIncremental(tick)
+	ob.resetAggregated()
+}
+
+// GetAggregatedLevels returns the incrementally aggregated book, sorted best first.
+// ok is false when incremental aggregation is not enabled at tick.
+func (ob *OrderBook) GetAggregatedLevels(tick types.TickLevel) (bids, asks []types.PriceLevel, ok bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	if ob.aggregated == nil || ob.aggregated.GetTickLevel() != tick {
+		return nil, nil, false
+	}
+	return ob.aggregated.Bids(), ob.aggregated.Asks(), true
+}
+
+// resetAggregated rebuilds the aggregated buckets from the full book (must be called with mutex locked)
+func (ob *OrderBook) resetAggregated() {
+	ob.observeFixedChanges()
+	if ob.aggregated == nil {
+		return
+	}
+
+	if ob.fixed != nil {
+		ob.aggregated.Reset(levelSlice(ob.fixed.levels(true)), levelSlice(ob.fixed.levels(false)))
+		return
+	}
+
+	ob.aggregated.Reset(levelSlice(ob.bids), levelSlice(ob.asks))
+}
+
+// levelSlice converts a side of the book to a slice
+func levelSlice(levels map[string]types.PriceLevel) []types.PriceLevel {
+	result := make([]types.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		result = append(result, level)
+	}
+	return result
+}
@@ -0,0 +1,197 @@
+package orderbook
+
+import (
+	"fmt"
+	"time"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultAverageWindows are the windows of the time-weighted averages used when none are configured
+var DefaultAverageWindows = []time.Duration{time.Minute, 5 * time.Minute, time.Hour}
+
+// averageBuckets is the number of buckets each window is split into; averages
+// cover the window to within one bucket
+const averageBuckets = 60
+
+// averageSample holds the averaged stats: the spread in bps of mid, then the bid
+// and ask liquidity within 0.5%, 2% and 10% of mid
+type averageSample [7]float64
+
+// averageBucket accumulates the samples of one bucket weighted by the time they held
+type averageBucket struct {
+	index  int64         // Bucket number since the Unix epoch
+	sums   averageSample // Values multiplied by the seconds they held
+	weight time.Duration // Time covered by samples
+}
+
+// averageWindow is a ring of buckets covering one trailing window
+type averageWindow struct {
+	window  time.Duration
+	bucket  time.Duration
+	buckets []averageBucket
+}
+
+// add accumulates values held from from to to, ignoring time before the window
+func (w *averageWindow) add(from, to time.Time, values averageSample) {
+	if earliest := to.Add(-w.window); from.Before(earliest) {
+		from = earliest
+	}
+	for from.Before(to) {
+		index := from.UnixNano() / int64(w.bucket)
+		end := time.Unix(0, (index+1)*int64(w.bucket))
+		if end.After(to) {
+			end = to
+		}
+		b := &w.buckets[index%int64(len(w.buckets))]
+		if b.index != index {
+			*b = averageBucket{index: index}
+		}
+		held := end.Sub(from)
+		for i, value := range values {
+			b.sums[i] += value * held.Seconds()
+		}
+		b.weight += held
+		from = end
+	}
+}
+
+// average returns the time-weighted average of the window at now, counting the
+// open segment started at since, and the time covered by samples
+func (w *averageWindow) average(now, since time.Time, open averageSample) (averageSample, time.Duration) {
+	var sums averageSample
+	var weight time.Duration
+	first := now.UnixNano()/int64(w.bucket) - averageBuckets
+	for _, b := range w.buckets {
+		if b.weight == 0 || b.index < first {
+			continue
+		}
+		for i := range sums {
+			sums[i] += b.sums[i]
+		}
+		weight += b.weight
+	}
+	if !since.IsZero() && now.After(since) {
+		if earliest := now.Add(-w.window); since.Before(earliest) {
+			since = earliest
+		}
+		held := now.Sub(since)
+		for i, value := range open {
+			sums[i] += value * held.Seconds()
+		}
+		weight += held
+	}
+
+	if weight > 0 {
+		for i := range sums {
+			sums[i] /= weight.Seconds()
+		}
+	}
+	return sums, weight
+}
+
+// twaTracker maintains time-weighted averages of the spread and band liquidity
+// over trailing windows. Each observed value holds until the next observation;
+// time without a two-sided book is left out of the averages.
+type twaTracker struct {
+	windows []averageWindow
+	since   time.Time     // Start of the open segment, zero while paused
+	current averageSample // Values of the open segment
+}
+
+// newTWATracker creates a tracker for the given windows (must be sorted ascending)
+func newTWATracker(windows []time.Duration) *twaTracker {
+	t := &twaTracker{windows: make([]averageWindow, len(windows))}
+	for i, window := range windows {
+		t.windows[i] = averageWindow{
+			window:  window,
+			bucket:  max(window/averageBuckets, time.Nanosecond),
+			buckets: make([]averageBucket, averageBuckets+1),
+		}
+	}
+	return t
+}
+
+// observe closes the open segment at now and starts a new one with sample, or
+// pauses the averages while the book is not valid
+func (t *twaTracker) observe(now time.Time, sample averageSample, valid bool) {
+	if !t.since.IsZero() && now.After(t.since) {
+		for i := range t.windows {
+			t.windows[i].add(t.since, now, t.current)
+		}
+		t.since = now
+	}
+	if !valid {
+		t.since = time.Time{}
+		return
+	}
+	if t.since.IsZero() {
+		t.since = now
+	}
+	t.current = sample
+}
+
+// averages returns the time-weighted averages of every window at now
+func (t *twaTracker) averages(now time.Time) []types.WindowAverage {
+	result := make([]types.WindowAverage, len(t.windows))
+	for i := range t.windows {
+		values, covered := t.windows[i].average(now, t.since, t.current)
+		result[i] = types.WindowAverage{
+			Window:            t.windows[i].window,
+			Covered:           covered,
+			SpreadBps:         averageDecimal(values[0]),
+			BidLiquidity05Pct: averageDecimal(values[1]),
+			AskLiquidity05Pct: averageDecimal(values[2]),
+			BidLiquidity2Pct:  averageDecimal(values[3]),
+			AskLiquidity2Pct:  averageDecimal(values[4]),
+			BidLiquidity10Pct: averageDecimal(values[5]),
+			AskLiquidity10Pct: averageDecimal(values[6]),
+		}
+	}
+	return result
+}
+
+// averageDecimal converts an average to a decimal, dropping float noise
+func averageDecimal(value float64) decimal.Decimal {
+	return decimal.NewFromFloat(value).Round(8)
+}
+
+// SetAverageWindows configures the windows of the time-weighted spread and
+// liquidity averages, discarding the averages accumulated so far
+func (ob *OrderBook) SetAverageWindows(windows []time.Duration) error {
+	for i, window := range windows {
+		if window <= 0 {
+			return fmt.Errorf("invalid average window %v", window)
+		}
+		if i > 0 && window <= windows[i-1] {
+			return fmt.Errorf("average windows must be strictly ascending: %v", windows)
+		}
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.averages = newTWATracker(windows)
+	return nil
+}
+
+// observeAverages feeds the current spread and liquidity stats into the
+// time-weighted averages (must be called with mutex locked)
+func (ob *OrderBook) observeAverages() {
+	mid := ob.midPrice()
+	valid := ob.initialized && ob.stats.Spread.IsPositive() && mid.IsPositive()
+	var sample averageSample
+	if valid {
+		sample = averageSample{
+			ob.stats.Spread.InexactFloat64() / mid.InexactFloat64() * 10000,
+			ob.stats.BidLiquidity05Pct.InexactFloat64(),
+			ob.stats.AskLiquidity05Pct.InexactFloat64(),
+			ob.stats.BidLiquidity2Pct.InexactFloat64(),
+			ob.stats.AskLiquidity2Pct.InexactFloat64(),
+			ob.stats.BidLiquidity10Pct.InexactFloat64(),
+			ob.stats.AskLiquidity10Pct.InexactFloat64(),
+		}
+	}
+	ob.averages.observe(ob.clock.Now(), sample, valid)
+}
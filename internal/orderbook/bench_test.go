@@ -0,0 +1,254 @@
+package orderbook
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/exchange/baseexchange"
+	"orderbook/internal/exchange/binancecompat"
+)
+
+// Benchmarks of update application and stats computation. Results are tracked in
+// testdata/bench.txt; compare a change against it with
+//
+//	go test -run '^$' -bench . -count 10 ./internal/orderbook > new.txt
+//	benchstat internal/orderbook/testdata/bench.txt new.txt
+//
+// The update stream is synthetic unless ORDERBOOK_BENCH_STREAM names a recording of
+// Binance depth events in the JSON lines format read by cmd/backfill, starting
+// with a REST snapshot. Recorded streams are replayed on the fixed-point engine
+// at 2 price and 3 quantity decimals (BTCUSDT); other precisions count as malformed.
+
+// envBenchStream names a recorded stream replayed by the benchmarks
+const envBenchStream = "ORDERBOOK_BENCH_STREAM"
+
+// benchStream is a snapshot and the updates following it
+type benchStream struct {
+	snapshot *exchange.Snapshot
+	updates  []*exchange.DepthUpdate
+}
+
+// levels returns the number of level changes in the stream
+func (s benchStream) levels() int {
+	levels := 0
+	for _, update := range s.updates {
+		levels += len(update.Bids) + len(update.Asks)
+	}
+	return levels
+}
+
+// loadBenchStream returns the recorded stream named by ORDERBOOK_BENCH_STREAM, or a synthetic one
+func loadBenchStream(b *testing.B) benchStream {
+	b.Helper()
+	path := os.Getenv(envBenchStream)
+	if path == "" {
+		return makeBurstyStream(5000, 5000)
+	}
+
+	stream, err := readBinanceStream(path)
+	if err != nil {
+		b.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return stream
+}
+
+// makeBurstyStream builds a snapshot of n levels per side and updates shaped like
+// a venue feed: mostly a few changes near the touch, with periodic bursts of
+// hundreds of changes spreading deeper into the book, a quarter of them deletes
+func makeBurstyStream(levels, n int) benchStream {
+	rng := rand.New(rand.NewSource(7))
+	stream := benchStream{snapshot: makeSnapshot(levels), updates: make([]*exchange.DepthUpdate, n)}
+
+	for i := range stream.updates {
+		update := &exchange.DepthUpdate{
+			Exchange:      exchange.Binancef,
+			Symbol:        "BTCUSDT",
+			EventTime:     time.UnixMilli(int64(1700000000000 + i*10)),
+			FirstUpdateID: int64(i + 2),
+			FinalUpdateID: int64(i + 2),
+			PrevUpdateID:  int64(i + 1),
+		}
+		changes, scale := 1+rng.Intn(8), 20.0
+		if rng.Intn(10) == 0 {
+			changes, scale = 50+rng.Intn(250), 200.0
+		}
+		for j := 0; j < changes; j++ {
+			// Distances from the touch are exponential, so most changes hit the top levels
+			offset := min(int(rng.ExpFloat64()*scale), levels+levels/10)
+			qty := "0"
+			if rng.Intn(4) != 0 {
+				qty = fmt.Sprintf("%.3f", 0.001+rng.ExpFloat64())
+			}
+			if rng.Intn(2) == 0 {
+				update.Bids = append(update.Bids, exchange.PriceLevel{Price: fmt.Sprintf("%.2f", 50000.0-float64(offset)*0.1), Quantity: qty})
+			} else {
+				update.Asks = append(update.Asks, exchange.PriceLevel{Price: fmt.Sprintf("%.2f", 50000.1+float64(offset)*0.1), Quantity: qty})
+			}
+		}
+		stream.updates[i] = update
+	}
+	return stream
+}
+
+// readBinanceStream reads a REST snapshot followed by the depth events continuing it
+func readBinanceStream(path string) (benchStream, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return benchStream{}, err
+	}
+	defer f.Close()
+
+	var stream benchStream
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record struct {
+			binancecompat.DepthUpdate
+			Data         json.RawMessage `json:"data"`
+			LastUpdateID *int64          `json:"lastUpdateId"`
+			SnapBids     [][]string      `json:"bids"`
+			SnapAsks     [][]string      `json:"asks"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return benchStream{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		if record.LastUpdateID != nil {
+			if stream.snapshot == nil {
+				stream.snapshot = &exchange.Snapshot{
+					Exchange:     exchange.Binancef,
+					LastUpdateID: *record.LastUpdateID,
+					Bids:         baseexchange.ConvertLevels(record.SnapBids),
+					Asks:         baseexchange.ConvertLevels(record.SnapAsks),
+					Timestamp:    time.Now(),
+				}
+			}
+			continue
+		}
+
+		event := record.DepthUpdate
+		if len(record.Data) > 0 {
+			if err := json.Unmarshal(record.Data, &event); err != nil {
+				return benchStream{}, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if event.EventType != "depthUpdate" || stream.snapshot == nil || event.FinalUpdateID <= stream.snapshot.LastUpdateID {
+			continue
+		}
+		stream.updates = append(stream.updates, &exchange.DepthUpdate{
+			Exchange:      exchange.Binancef,
+			Symbol:        event.Symbol,
+			EventTime:     time.UnixMilli(event.EventTime),
+			FirstUpdateID: event.FirstUpdateID,
+			FinalUpdateID: event.FinalUpdateID,
+			PrevUpdateID:  event.PrevUpdateID,
+			Bids:          baseexchange.ConvertLevels(event.Bids),
+			Asks:          baseexchange.ConvertLevels(event.Asks),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return benchStream{}, err
+	}
+	if stream.snapshot == nil || len(stream.updates) == 0 {
+		return benchStream{}, fmt.Errorf("no snapshot followed by depth events")
+	}
+	return stream, nil
+}
+
+// quietLog discards log output for the rest of the benchmark, so book setup
+// messages do not break the benchmark lines read by benchstat
+func quietLog(b *testing.B) {
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// benchmarkStream replays the stream on a loaded book, renumbering the updates so
+// it can be cycled. statsInterval > 0 leaves the liquidity bands to RefreshStats.
+func benchmarkStream(b *testing.B, fixed bool, statsInterval time.Duration) {
+	quietLog(b)
+	stream := loadBenchStream(b)
+	ob := newLoadedBook(b, fixed, stream.snapshot)
+	ob.SetStatsInterval(statsInterval)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		update := *stream.updates[i%len(stream.updates)]
+		update.PrevUpdateID = ob.lastUpdateID
+		update.FirstUpdateID = ob.lastUpdateID + 1
+		update.FinalUpdateID = ob.lastUpdateID + 1
+		ob.HandleDepthUpdate(&update)
+	}
+	b.ReportMetric(float64(stream.levels())/float64(len(stream.updates)), "levels/update")
+}
+
+func BenchmarkStream(b *testing.B) {
+	engines := []struct {
+		name  string
+		fixed bool
+	}{
+		{"decimal", false},
+		{"fixed", true},
+	}
+	for _, engine := range engines {
+		b.Run(engine.name+"/apply", func(b *testing.B) {
+			benchmarkStream(b, engine.fixed, time.Second)
+		})
+		b.Run(engine.name+"/apply+stats", func(b *testing.B) {
+			benchmarkStream(b, engine.fixed, 0)
+		})
+	}
+}
+
+func BenchmarkUpdateStats(b *testing.B) {
+	for _, levels := range []int{1000, 5000, 20000} {
+		for _, fixed := range []bool{false, true} {
+			engine := "decimal"
+			if fixed {
+				engine = "fixed"
+			}
+			b.Run(fmt.Sprintf("%s/%d", engine, levels), func(b *testing.B) {
+				quietLog(b)
+				ob := newLoadedBook(b, fixed, makeSnapshot(levels))
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					ob.mu.Lock()
+					ob.updateStats()
+					ob.mu.Unlock()
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkLoadSnapshot(b *testing.B) {
+	snapshot := makeSnapshot(5000)
+	for _, fixed := range []bool{false, true} {
+		engine := "decimal"
+		if fixed {
+			engine = "fixed"
+		}
+		b.Run(engine, func(b *testing.B) {
+			quietLog(b)
+			ob := newLoadedBook(b, fixed, snapshot)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if err := ob.LoadSnapshot(snapshot); err != nil {
+					b.Fatalf("LoadSnapshot() failed: %v", err)
+				}
+			}
+		})
+	}
+}
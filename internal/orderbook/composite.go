@@ -0,0 +1,90 @@
+package orderbook
+
+import (
+	"orderbook/internal/exchange"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// compositePriceDecimals bounds the precision of converted composite prices
+const compositePriceDecimals = 8
+
+// CompositeLeg is a book merged into a composite and the factor converting its
+// prices to the composite's quote currency
+type CompositeLeg struct {
+	Book   *OrderBook
+	Factor decimal.Decimal // Zero or one leaves prices unchanged
+}
+
+// LoadComposite replaces the book with the union of the ready legs at
+// converted prices, summing the quantities of levels that convert to the same
+// price. Converted bids are rounded down and asks up. The first load initializes
+// the book; it returns false, leaving the book unchanged, while no leg is ready.
+func (ob *OrderBook) LoadComposite(legs []CompositeLeg) bool {
+	bids := make(map[string]decimal.Decimal)
+	asks := make(map[string]decimal.Decimal)
+	loaded := false
+	for _, leg := range legs {
+		if !leg.Book.IsReady() {
+			continue
+		}
+		legBids, legAsks := leg.Book.levels()
+		mergeLevels(bids, legBids, leg.Factor, true)
+		mergeLevels(asks, legAsks, leg.Factor, false)
+		loaded = true
+	}
+	if !loaded {
+		return false
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	snapshot := &exchange.Snapshot{
+		LastUpdateID: ob.lastUpdateID + 1,
+		Bids:         mergedLevels(bids),
+		Asks:         mergedLevels(asks),
+		Timestamp:    ob.clock.Now(),
+	}
+	if err := ob.loadSnapshot(snapshot); err != nil {
+		return false
+	}
+	ob.markInitialized()
+	ob.publishView()
+	return true
+}
+
+// levels returns both sides of the book, from the published view when there is one
+func (ob *OrderBook) levels() ([]types.PriceLevel, []types.PriceLevel) {
+	if view := ob.View(); view != nil {
+		return view.Bids, view.Asks
+	}
+	return levelSlice(ob.GetBids()), levelSlice(ob.GetAsks())
+}
+
+// mergeLevels adds levels converted by factor to merged, keyed by converted price
+func mergeLevels(merged map[string]decimal.Decimal, levels []types.PriceLevel, factor decimal.Decimal, isBid bool) {
+	convert := !factor.IsZero() && !factor.Equal(decimal.NewFromInt(1))
+	for _, level := range levels {
+		price := level.Price
+		if convert {
+			price = price.Mul(factor)
+			if isBid {
+				price = price.RoundFloor(compositePriceDecimals)
+			} else {
+				price = price.RoundCeil(compositePriceDecimals)
+			}
+		}
+		key := price.String()
+		merged[key] = merged[key].Add(level.Quantity)
+	}
+}
+
+// mergedLevels converts merged levels to snapshot levels
+func mergedLevels(merged map[string]decimal.Decimal) []exchange.PriceLevel {
+	levels := make([]exchange.PriceLevel, 0, len(merged))
+	for price, quantity := range merged {
+		levels = append(levels, exchange.PriceLevel{Price: price, Quantity: quantity.String()})
+	}
+	return levels
+}
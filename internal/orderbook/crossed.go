@@ -0,0 +1,124 @@
+package orderbook
+
+import (
+	"fmt"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// CrossedBook describes a book that became crossed
+type CrossedBook struct {
+	BestBid decimal.Decimal     // Best bid when the crossing was detected
+	BestAsk decimal.Decimal     // Best ask when the crossing was detected
+	Policy  types.CrossedPolicy // Action taken
+	Removed int                 // Levels removed by CrossedClean
+}
+
+// SetCrossedPolicy selects how crossed books are healed (default types.CrossedClean)
+func (ob *OrderBook) SetCrossedPolicy(policy types.CrossedPolicy) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.crossedPolicy = policy
+}
+
+// SetCrossedHandler registers fn to be called, on its own goroutine, every time
+// the book becomes crossed
+func (ob *OrderBook) SetCrossedHandler(fn func(CrossedBook)) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.onCrossed = fn
+}
+
+// isCrossed reports whether the best bid reaches the best ask (must be called with mutex locked)
+func (ob *OrderBook) isCrossed() bool {
+	return !ob.bestBid.IsZero() && !ob.bestAsk.IsZero() && ob.bestBid.GreaterThanOrEqual(ob.bestAsk)
+}
+
+// checkCrossed detects a book crossed by update and heals it according to the
+// policy (must be called with mutex locked)
+func (ob *OrderBook) checkCrossed(update *exchange.DepthUpdate) {
+	// A book left crossed by the resync or ignore policies was already reported
+	if !ob.isCrossed() || ob.stats.CrossedBook {
+		return
+	}
+
+	event := CrossedBook{BestBid: ob.bestBid, BestAsk: ob.bestAsk, Policy: ob.crossedPolicy}
+	ob.stats.CrossedBooks++
+	ob.recordEvent(FeedEventCrossed, fmt.Sprintf("bid %s >= ask %s, %s", ob.bestBid, ob.bestAsk, ob.crossedPolicy))
+
+	switch ob.crossedPolicy {
+	case types.CrossedResync:
+		ob.resyncRequested = true
+		ob.resyncReason = "crossed book"
+	case types.CrossedIgnore:
+	default:
+		event.Removed = ob.removeCrossingLevels(update)
+	}
+
+	if ob.onCrossed != nil {
+		go ob.onCrossed(event)
+	}
+}
+
+// removeCrossingLevels trusts the levels of the latest update and removes the
+// levels of the opposite side that cross them. It returns the number of levels
+// removed (must be called with mutex locked).
+func (ob *OrderBook) removeCrossingLevels(update *exchange.DepthUpdate) int {
+	// The update crossed the book with a bid when it set a bid at or above the best ask
+	bidCrossed := false
+	for _, bid := range update.Bids {
+		price, err := decimal.NewFromString(bid.Price)
+		qty, qtyErr := decimal.NewFromString(bid.Quantity)
+		if err == nil && qtyErr == nil && !qty.IsZero() && price.GreaterThanOrEqual(ob.bestAsk) {
+			bidCrossed = true
+			break
+		}
+	}
+
+	removal := &exchange.DepthUpdate{}
+	if bidCrossed {
+		removal.Asks = ob.levelsThrough(false, ob.bestBid)
+	} else {
+		removal.Bids = ob.levelsThrough(true, ob.bestAsk)
+	}
+
+	if ob.fixed != nil {
+		ob.fixed.apply(removal)
+		ob.bestBid, ob.bestAsk = ob.fixed.bestPrices()
+	} else {
+		ob.applyDecimalUpdate(removal)
+	}
+	return len(removal.Bids) + len(removal.Asks)
+}
+
+// levelsThrough returns zero-quantity levels deleting every level of a side priced
+// at or beyond limit: bids at or above it, asks at or below it (must be called with mutex locked)
+func (ob *OrderBook) levelsThrough(isBid bool, limit decimal.Decimal) []exchange.PriceLevel {
+	var levels []exchange.PriceLevel
+	if ob.fixed != nil {
+		side, bound := ob.fixed.asks, ob.fixed.priceScale.FromDecimalFloor(limit)
+		if isBid {
+			side, bound = ob.fixed.bids, ob.fixed.priceScale.FromDecimalCeil(limit)
+		}
+		for price := range side {
+			if (isBid && price >= bound) || (!isBid && price <= bound) {
+				levels = append(levels, exchange.PriceLevel{Price: ob.fixed.priceScale.ToDecimal(price).String(), Quantity: "0"})
+			}
+		}
+		return levels
+	}
+
+	side := ob.asks
+	if isBid {
+		side = ob.bids
+	}
+	for key, level := range side {
+		if (isBid && level.Price.GreaterThanOrEqual(limit)) || (!isBid && level.Price.LessThanOrEqual(limit)) {
+			levels = append(levels, exchange.PriceLevel{Price: key, Quantity: "0"})
+		}
+	}
+	return levels
+}
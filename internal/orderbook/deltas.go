@@ -0,0 +1,152 @@
+package orderbook
+
+import (
+	"sort"
+	"time"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// LevelChange is one price level changed in the book
+type LevelChange struct {
+	IsBid       bool
+	Price       decimal.Decimal
+	OldQuantity decimal.Decimal // Zero for a new level
+	NewQuantity decimal.Decimal // Zero for a removed level
+}
+
+// BookDelta holds the level changes of one applied update, snapshot or pruning pass
+type BookDelta struct {
+	Seq      int64     // Incremented by one per delta, so dropped deltas can be detected
+	Time     time.Time // Venue time of the update, local time when unknown
+	Snapshot bool      // Changes diff the previous book against a snapshot
+	Changes  []LevelChange
+	BestBid  decimal.Decimal // Best bid after the changes, zero when the side is empty
+	BestAsk  decimal.Decimal // Best ask after the changes, zero when the side is empty
+}
+
+// SetDeltaHandler registers fn to receive every change applied to the book, so
+// the book can be replayed level by level. fn is called in order with the mutex
+// held and must neither block nor call back into the book.
+func (ob *OrderBook) SetDeltaHandler(fn func(BookDelta)) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.onDelta = fn
+	ob.observeFixedChanges()
+}
+
+// recordChange queues a level change for the next delta (must be called with mutex locked)
+func (ob *OrderBook) recordChange(isBid bool, price, oldQty, newQty decimal.Decimal) {
+	if ob.onDelta == nil || oldQty.Equal(newQty) {
+		return
+	}
+	ob.pendingChanges = append(ob.pendingChanges, LevelChange{
+		IsBid:       isBid,
+		Price:       price,
+		OldQuantity: oldQty,
+		NewQuantity: newQty,
+	})
+}
+
+// flushDelta passes the queued changes to the delta handler (must be called with mutex locked)
+func (ob *OrderBook) flushDelta(at time.Time, snapshot bool) {
+	if ob.onDelta == nil || len(ob.pendingChanges) == 0 {
+		return
+	}
+	if at.IsZero() {
+		at = ob.clock.Now()
+	}
+	ob.deltaSeq++
+	changes := ob.pendingChanges
+	// The handler may keep the changes
+	ob.pendingChanges = nil
+	ob.onDelta(BookDelta{Seq: ob.deltaSeq, Time: at, Snapshot: snapshot, Changes: changes, BestBid: ob.bestBid, BestAsk: ob.bestAsk})
+}
+
+// bookState is a copy of both sides of the book keyed by normalized price
+type bookState struct {
+	bids map[string]types.PriceLevel
+	asks map[string]types.PriceLevel
+}
+
+// captureState copies the book before a bulk change, or returns nil when no delta
+// handler is registered (must be called with mutex locked)
+func (ob *OrderBook) captureState() *bookState {
+	if ob.onDelta == nil {
+		return nil
+	}
+	return &bookState{bids: ob.normalizedLevels(true), asks: ob.normalizedLevels(false)}
+}
+
+// emitDiff records the changes between before and the current book as one delta
+// (must be called with mutex locked)
+func (ob *OrderBook) emitDiff(before *bookState, at time.Time, snapshot bool) {
+	if before == nil {
+		return
+	}
+	ob.diffSide(true, before.bids, ob.normalizedLevels(true))
+	ob.diffSide(false, before.asks, ob.normalizedLevels(false))
+	ob.flushDelta(at, snapshot)
+}
+
+// diffSide records the changes of one side, in price order (must be called with mutex locked)
+func (ob *OrderBook) diffSide(isBid bool, before, after map[string]types.PriceLevel) {
+	var changes []LevelChange
+	for key, level := range after {
+		if old := before[key]; !old.Quantity.Equal(level.Quantity) {
+			changes = append(changes, LevelChange{IsBid: isBid, Price: level.Price, OldQuantity: old.Quantity, NewQuantity: level.Quantity})
+		}
+	}
+	for key, level := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, LevelChange{IsBid: isBid, Price: level.Price, OldQuantity: level.Quantity, NewQuantity: decimal.Zero})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Price.LessThan(changes[j].Price) })
+	for _, change := range changes {
+		ob.recordChange(change.IsBid, change.Price, change.OldQuantity, change.NewQuantity)
+	}
+}
+
+// normalizedLevels returns a side of the book keyed by normalized price (must be called with mutex locked)
+func (ob *OrderBook) normalizedLevels(isBid bool) map[string]types.PriceLevel {
+	if ob.fixed != nil {
+		return ob.fixed.levels(isBid)
+	}
+	side := ob.asks
+	if isBid {
+		side = ob.bids
+	}
+	levels := make(map[string]types.PriceLevel, len(side))
+	for _, level := range side {
+		levels[level.Price.String()] = level
+	}
+	return levels
+}
+
+// observeFixedChanges routes the level changes of the fixed-point engine to the
+// aggregated buckets and the delta handler (must be called with mutex locked)
+func (ob *OrderBook) observeFixedChanges() {
+	fb := ob.fixed
+	if fb == nil {
+		return
+	}
+	if ob.aggregated == nil && ob.onDelta == nil {
+		fb.onChange = nil
+		return
+	}
+	fb.onChange = func(isBid bool, price, oldQty, newQty int64) {
+		priceDecimal := fb.priceScale.ToDecimal(price)
+		oldDecimal, newDecimal := fb.qtyScale.ToDecimal(oldQty), fb.qtyScale.ToDecimal(newQty)
+		if ob.aggregated != nil {
+			if isBid {
+				ob.aggregated.UpdateBid(priceDecimal, oldDecimal, newDecimal)
+			} else {
+				ob.aggregated.UpdateAsk(priceDecimal, oldDecimal, newDecimal)
+			}
+		}
+		ob.recordChange(isBid, priceDecimal, oldDecimal, newDecimal)
+	}
+}
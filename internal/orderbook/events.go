@@ -0,0 +1,65 @@
+package orderbook
+
+import "time"
+
+// FeedEventKind classifies the events that reveal how reliable a feed is
+type FeedEventKind string
+
+const (
+	FeedEventGap            FeedEventKind = "gap"             // Sequence gap detected after initialization
+	FeedEventBufferOverflow FeedEventKind = "buffer_overflow" // Buffered updates exceeded the reinitialization threshold
+	FeedEventResync         FeedEventKind = "resync"          // Book reloaded from a fresh snapshot
+	FeedEventResyncFailed   FeedEventKind = "resync_failed"   // Snapshot reload failed, the book stays uninitialized
+	FeedEventReset          FeedEventKind = "reset"           // Book replaced by a snapshot sent on the stream
+	FeedEventCrossed        FeedEventKind = "crossed"         // Best bid reached the best ask
+	FeedEventExpired        FeedEventKind = "expired"         // Book cleared after exceeding its max age without data
+)
+
+// FeedEvent is one entry of the feed event log
+type FeedEvent struct {
+	Time   time.Time
+	Kind   FeedEventKind
+	Detail string
+}
+
+// DefaultEventLogSize is the number of feed events kept per book
+const DefaultEventLogSize = 100
+
+// eventLog is a fixed-size ring of the latest feed events
+type eventLog struct {
+	events []FeedEvent
+	next   int // Index overwritten by the next event once the ring is full
+}
+
+// add records an event, overwriting the oldest one when the ring is full
+func (l *eventLog) add(event FeedEvent) {
+	if len(l.events) < DefaultEventLogSize {
+		l.events = append(l.events, event)
+		return
+	}
+	l.events[l.next] = event
+	l.next = (l.next + 1) % len(l.events)
+}
+
+// list returns a copy of the events, oldest first
+func (l *eventLog) list() []FeedEvent {
+	events := make([]FeedEvent, 0, len(l.events))
+	events = append(events, l.events[l.next:]...)
+	return append(events, l.events[:l.next]...)
+}
+
+// recordEvent adds an event to the log (must be called with mutex locked)
+func (ob *OrderBook) recordEvent(kind FeedEventKind, detail string) {
+	if kind != FeedEventCrossed {
+		// The book cannot be trusted again until it was stable for a full warm-up
+		ob.restartWarmup()
+	}
+	ob.events.add(FeedEvent{Time: ob.clock.Now(), Kind: kind, Detail: detail})
+}
+
+// FeedEvents returns the latest gaps, buffer overflows and resyncs, oldest first
+func (ob *OrderBook) FeedEvents() []FeedEvent {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.events.list()
+}
@@ -0,0 +1,42 @@
+package orderbook
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+// SetMaxAge clears the book and marks it uninitialized once no snapshot or update
+// has been applied for longer than maxAge, as checked by CheckAndReinitialize.
+// Full-depth feeds (e.g., REST polling) recover with the next full book, other
+// feeds are reloaded from a snapshot. 0 disables expiry.
+func (ob *OrderBook) SetMaxAge(maxAge time.Duration) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.maxAge = maxAge
+}
+
+// expire clears the book when it is older than the max age and reports whether
+// it did (must be called with mutex locked)
+func (ob *OrderBook) expire(now time.Time) bool {
+	if ob.maxAge <= 0 || !ob.initialized || ob.lastApplied.IsZero() {
+		return false
+	}
+	age := now.Sub(ob.lastApplied)
+	if age <= ob.maxAge {
+		return false
+	}
+
+	// An empty snapshot clears every level
+	ob.loadSnapshot(&exchange.Snapshot{})
+	ob.dropBufferedEvents(len(ob.eventBuffer))
+	ob.initialized = false
+	ob.expired = true
+	ob.stats.Expiries++
+	ob.recordEvent(FeedEventExpired, fmt.Sprintf("no data for %s", age.Round(time.Millisecond)))
+	ob.publishView()
+	log.Printf("Orderbook expired after %s without data, cleared", age.Round(time.Millisecond))
+	return true
+}
@@ -0,0 +1,162 @@
+package orderbook
+
+import (
+	"orderbook/internal/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+// LevelFilter decides whether an incoming price level is ingested. mid is the
+// current mid price, zero while either side of the book is empty.
+type LevelFilter interface {
+	Keep(isBid bool, price, qty, mid decimal.Decimal) bool
+}
+
+// MaxDistanceFilter rejects levels further than MaxDistancePct from mid (e.g., 0.5 = 50%)
+type MaxDistanceFilter struct {
+	MaxDistancePct decimal.Decimal
+}
+
+// Keep implements LevelFilter
+func (f MaxDistanceFilter) Keep(isBid bool, price, qty, mid decimal.Decimal) bool {
+	if mid.IsZero() {
+		return true
+	}
+	maxDistance := mid.Mul(f.MaxDistancePct)
+	if isBid {
+		return mid.Sub(price).LessThanOrEqual(maxDistance)
+	}
+	return price.Sub(mid).LessThanOrEqual(maxDistance)
+}
+
+// MinQuantityFilter rejects levels smaller than MinQuantity (dust)
+type MinQuantityFilter struct {
+	MinQuantity decimal.Decimal
+}
+
+// Keep implements LevelFilter
+func (f MinQuantityFilter) Keep(isBid bool, price, qty, mid decimal.Decimal) bool {
+	return qty.GreaterThanOrEqual(f.MinQuantity)
+}
+
+// FilterConfig configures the standard ingestion filters
+type FilterConfig struct {
+	MaxDistancePct float64 // Reject levels further than this fraction from mid, 0 disables
+	MinQuantity    float64 // Reject levels smaller than this quantity, 0 disables
+}
+
+// NewFilters builds the filter chain described by config
+func NewFilters(config FilterConfig) []LevelFilter {
+	var filters []LevelFilter
+	if config.MaxDistancePct > 0 {
+		filters = append(filters, MaxDistanceFilter{MaxDistancePct: decimal.NewFromFloat(config.MaxDistancePct)})
+	}
+	if config.MinQuantity > 0 {
+		filters = append(filters, MinQuantityFilter{MinQuantity: decimal.NewFromFloat(config.MinQuantity)})
+	}
+	return filters
+}
+
+// SetFilters replaces the ingestion filter chain applied to snapshots and updates.
+// It should be called before LoadSnapshot.
+func (ob *OrderBook) SetFilters(filters ...LevelFilter) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.filters = filters
+}
+
+// keepLevel runs the filter chain on a level (must be called with mutex locked)
+func (ob *OrderBook) keepLevel(isBid bool, level exchange.PriceLevel, mid decimal.Decimal) bool {
+	price, err := decimal.NewFromString(level.Price)
+	if err != nil {
+		return true // Left to the engine's own validation
+	}
+	qty, err := decimal.NewFromString(level.Quantity)
+	if err != nil {
+		return true
+	}
+
+	for _, filter := range ob.filters {
+		if !filter.Keep(isBid, price, qty, mid) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterSnapshot returns snapshot without the levels rejected by the filter
+// chain, measuring distance from the snapshot's own mid (must be called with mutex locked)
+func (ob *OrderBook) filterSnapshot(snapshot *exchange.Snapshot) *exchange.Snapshot {
+	mid := snapshotMid(snapshot)
+
+	filtered := *snapshot
+	filtered.Bids = make([]exchange.PriceLevel, 0, len(snapshot.Bids))
+	for _, bid := range snapshot.Bids {
+		if ob.keepLevel(true, bid, mid) {
+			filtered.Bids = append(filtered.Bids, bid)
+		}
+	}
+	filtered.Asks = make([]exchange.PriceLevel, 0, len(snapshot.Asks))
+	for _, ask := range snapshot.Asks {
+		if ob.keepLevel(false, ask, mid) {
+			filtered.Asks = append(filtered.Asks, ask)
+		}
+	}
+	return &filtered
+}
+
+// filterUpdate returns update with rejected levels turned into deletions, so a
+// level ingested earlier does not linger once it stops passing the filters
+// (must be called with mutex locked)
+func (ob *OrderBook) filterUpdate(update *exchange.DepthUpdate) *exchange.DepthUpdate {
+	mid := ob.midPrice()
+
+	filtered := *update
+	filtered.Bids = ob.filterUpdateLevels(true, update.Bids, mid)
+	filtered.Asks = ob.filterUpdateLevels(false, update.Asks, mid)
+	return &filtered
+}
+
+func (ob *OrderBook) filterUpdateLevels(isBid bool, levels []exchange.PriceLevel, mid decimal.Decimal) []exchange.PriceLevel {
+	result := make([]exchange.PriceLevel, len(levels))
+	for i, level := range levels {
+		result[i] = level
+		if isZeroQuantity(level.Quantity) || ob.keepLevel(isBid, level, mid) {
+			continue
+		}
+		result[i].Quantity = "0"
+	}
+	return result
+}
+
+// snapshotMid returns the mid price of a snapshot, zero if a side is empty
+func snapshotMid(snapshot *exchange.Snapshot) decimal.Decimal {
+	var bestBid, bestAsk decimal.Decimal
+	for _, bid := range snapshot.Bids {
+		price, err := decimal.NewFromString(bid.Price)
+		if err == nil && price.GreaterThan(bestBid) {
+			bestBid = price
+		}
+	}
+	for _, ask := range snapshot.Asks {
+		price, err := decimal.NewFromString(ask.Price)
+		if err == nil && (bestAsk.IsZero() || price.LessThan(bestAsk)) {
+			bestAsk = price
+		}
+	}
+
+	if bestBid.IsZero() || bestAsk.IsZero() {
+		return decimal.Zero
+	}
+	return bestBid.Add(bestAsk).Div(decimal.NewFromInt(2))
+}
+
+// isZeroQuantity reports whether a quantity string is a deletion (e.g., "0", "0.000")
+func isZeroQuantity(qty string) bool {
+	for _, c := range qty {
+		if c != '0' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,322 @@
+package orderbook
+
+import (
+	"fmt"
+	"sort"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/fixedpoint"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// fixedBook stores levels as fixed-point int64 values so the hot path
+// (update application and depth statistics) avoids decimal arithmetic
+type fixedBook struct {
+	priceScale fixedpoint.Scale
+	qtyScale   fixedpoint.Scale
+	bids       map[int64]int64 // price -> quantity
+	asks       map[int64]int64
+	bestBid    int64 // 0 when empty
+	bestAsk    int64 // 0 when empty
+	// Optional observer of level changes (quantities are 0 when absent)
+	onChange func(isBid bool, price, oldQty, newQty int64)
+}
+
+// newFixedBook creates a fixed-point book for the given instrument precision
+func newFixedBook(priceDecimals, qtyDecimals int) (*fixedBook, error) {
+	priceScale, err := fixedpoint.NewScale(priceDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price precision: %w", err)
+	}
+	qtyScale, err := fixedpoint.NewScale(qtyDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quantity precision: %w", err)
+	}
+	return &fixedBook{
+		priceScale: priceScale,
+		qtyScale:   qtyScale,
+		bids:       make(map[int64]int64),
+		asks:       make(map[int64]int64),
+	}, nil
+}
+
+// load replaces the book contents with a snapshot, skipping malformed levels.
+// It returns the number of levels skipped and the last error.
+func (fb *fixedBook) load(snapshot *exchange.Snapshot) (int, error) {
+	fb.bids = make(map[int64]int64, len(snapshot.Bids))
+	fb.asks = make(map[int64]int64, len(snapshot.Asks))
+
+	rejected := 0
+	var lastErr error
+	for _, bid := range snapshot.Bids {
+		price, qty, err := fb.parseLevel(bid)
+		if err != nil {
+			rejected, lastErr = rejected+1, fmt.Errorf("bid: %w", err)
+			continue
+		}
+		if qty != 0 {
+			fb.bids[price] = qty
+		}
+	}
+	for _, ask := range snapshot.Asks {
+		price, qty, err := fb.parseLevel(ask)
+		if err != nil {
+			rejected, lastErr = rejected+1, fmt.Errorf("ask: %w", err)
+			continue
+		}
+		if qty != 0 {
+			fb.asks[price] = qty
+		}
+	}
+
+	fb.recalculateBestBid()
+	fb.recalculateBestAsk()
+	return rejected, lastErr
+}
+
+// apply applies a depth update, skipping malformed levels. It returns the
+// number of levels skipped and the last error.
+func (fb *fixedBook) apply(update *exchange.DepthUpdate) (int, error) {
+	bestBidRemoved := false
+	bestAskRemoved := false
+	rejected := 0
+	var lastErr error
+
+	for _, bid := range update.Bids {
+		price, qty, err := fb.parseLevel(bid)
+		if err != nil {
+			rejected, lastErr = rejected+1, fmt.Errorf("bid: %w", err)
+			continue
+		}
+		if fb.onChange != nil {
+			fb.onChange(true, price, fb.bids[price], qty)
+		}
+		if qty == 0 {
+			delete(fb.bids, price)
+			if price == fb.bestBid {
+				bestBidRemoved = true
+			}
+		} else {
+			fb.bids[price] = qty
+			if price > fb.bestBid {
+				fb.bestBid = price
+			}
+		}
+	}
+
+	for _, ask := range update.Asks {
+		price, qty, err := fb.parseLevel(ask)
+		if err != nil {
+			rejected, lastErr = rejected+1, fmt.Errorf("ask: %w", err)
+			continue
+		}
+		if fb.onChange != nil {
+			fb.onChange(false, price, fb.asks[price], qty)
+		}
+		if qty == 0 {
+			delete(fb.asks, price)
+			if price == fb.bestAsk {
+				bestAskRemoved = true
+			}
+		} else {
+			fb.asks[price] = qty
+			if fb.bestAsk == 0 || price < fb.bestAsk {
+				fb.bestAsk = price
+			}
+		}
+	}
+
+	if bestBidRemoved {
+		fb.recalculateBestBid()
+	}
+	if bestAskRemoved {
+		fb.recalculateBestAsk()
+	}
+	return rejected, lastErr
+}
+
+// bestPrices returns the best bid and ask as decimals (zero when a side is empty)
+func (fb *fixedBook) bestPrices() (decimal.Decimal, decimal.Decimal) {
+	return fb.priceScale.ToDecimal(fb.bestBid), fb.priceScale.ToDecimal(fb.bestAsk)
+}
+
+// levels returns a side of the book in the decimal representation used by the OrderBook API
+func (fb *fixedBook) levels(isBid bool) map[string]types.PriceLevel {
+	side := fb.asks
+	if isBid {
+		side = fb.bids
+	}
+
+	result := make(map[string]types.PriceLevel, len(side))
+	for price, qty := range side {
+		priceDecimal := fb.priceScale.ToDecimal(price)
+		result[priceDecimal.String()] = types.PriceLevel{
+			Price:    priceDecimal,
+			Quantity: fb.qtyScale.ToDecimal(qty),
+		}
+	}
+	return result
+}
+
+// calculateLiquidityDepth fills the depth metrics of stats using integer arithmetic
+func (fb *fixedBook) calculateLiquidityDepth(stats *types.Stats, mid decimal.Decimal) {
+	// Thresholds are computed once in decimal and converted so comparisons stay exact
+	minBid05 := fb.priceScale.FromDecimalCeil(mid.Sub(mid.Mul(depth05Pct)))
+	minBid2 := fb.priceScale.FromDecimalCeil(mid.Sub(mid.Mul(depth2Pct)))
+	minBid10 := fb.priceScale.FromDecimalCeil(mid.Sub(mid.Mul(depth10Pct)))
+	maxAsk05 := fb.priceScale.FromDecimalFloor(mid.Add(mid.Mul(depth05Pct)))
+	maxAsk2 := fb.priceScale.FromDecimalFloor(mid.Add(mid.Mul(depth2Pct)))
+	maxAsk10 := fb.priceScale.FromDecimalFloor(mid.Add(mid.Mul(depth10Pct)))
+
+	var bidLiq05, bidLiq2, bidLiq10, totalBids int64
+	var bidSquares2 float64
+	for price, qty := range fb.bids {
+		totalBids += qty
+		if price >= minBid05 {
+			bidLiq05 += qty
+		}
+		if price >= minBid2 {
+			bidLiq2 += qty
+			bidSquares2 += float64(qty) * float64(qty)
+		}
+		if price >= minBid10 {
+			bidLiq10 += qty
+		}
+	}
+
+	var askLiq05, askLiq2, askLiq10, totalAsks int64
+	var askSquares2 float64
+	for price, qty := range fb.asks {
+		totalAsks += qty
+		if price <= maxAsk05 {
+			askLiq05 += qty
+		}
+		if price <= maxAsk2 {
+			askLiq2 += qty
+			askSquares2 += float64(qty) * float64(qty)
+		}
+		if price <= maxAsk10 {
+			askLiq10 += qty
+		}
+	}
+
+	q := fb.qtyScale
+	stats.BidLiquidity05Pct = q.ToDecimal(bidLiq05)
+	stats.AskLiquidity05Pct = q.ToDecimal(askLiq05)
+	stats.BidLiquidity2Pct = q.ToDecimal(bidLiq2)
+	stats.AskLiquidity2Pct = q.ToDecimal(askLiq2)
+	stats.BidLiquidity10Pct = q.ToDecimal(bidLiq10)
+	stats.AskLiquidity10Pct = q.ToDecimal(askLiq10)
+	stats.TotalBidsQty = q.ToDecimal(totalBids)
+	stats.TotalAsksQty = q.ToDecimal(totalAsks)
+	stats.DeltaLiquidity05Pct = q.ToDecimal(bidLiq05 - askLiq05)
+	stats.DeltaLiquidity2Pct = q.ToDecimal(bidLiq2 - askLiq2)
+	stats.DeltaLiquidity10Pct = q.ToDecimal(bidLiq10 - askLiq10)
+	stats.TotalDelta = q.ToDecimal(totalBids - totalAsks)
+	// Shares are scale-free, so the index is computed on the scaled quantities
+	stats.BidConcentration2Pct = concentration(bidSquares2, float64(bidLiq2))
+	stats.AskConcentration2Pct = concentration(askSquares2, float64(askLiq2))
+}
+
+// prune applies the memory bounds and returns the number of levels removed
+func (fb *fixedBook) prune(config PruneConfig, mid decimal.Decimal) int {
+	pruned := 0
+
+	if config.MaxDistancePct > 0 && !mid.IsZero() {
+		maxDistance := mid.Mul(decimal.NewFromFloat(config.MaxDistancePct))
+		minBid := fb.priceScale.FromDecimalCeil(mid.Sub(maxDistance))
+		maxAsk := fb.priceScale.FromDecimalFloor(mid.Add(maxDistance))
+
+		for price := range fb.bids {
+			if price < minBid {
+				delete(fb.bids, price)
+				pruned++
+			}
+		}
+		for price := range fb.asks {
+			if price > maxAsk {
+				delete(fb.asks, price)
+				pruned++
+			}
+		}
+	}
+
+	if config.MaxLevels > 0 {
+		pruned += capFixedLevels(fb.bids, config.MaxLevels, true)
+		pruned += capFixedLevels(fb.asks, config.MaxLevels, false)
+	}
+
+	if pruned > 0 {
+		fb.recalculateBestBid()
+		fb.recalculateBestAsk()
+	}
+	return pruned
+}
+
+// parseLevel converts a canonical price level to fixed-point values, rejecting
+// non-positive prices and negative quantities like parseDecimalLevel
+func (fb *fixedBook) parseLevel(level exchange.PriceLevel) (int64, int64, error) {
+	price, err := fb.priceScale.Parse(level.Price)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: price %q: %v", errMalformedLevel, level.Price, err)
+	}
+	if price <= 0 {
+		return 0, 0, fmt.Errorf("%w: price %q", errMalformedLevel, level.Price)
+	}
+	qty, err := fb.qtyScale.Parse(level.Quantity)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: quantity %q: %v", errMalformedLevel, level.Quantity, err)
+	}
+	if qty < 0 {
+		return 0, 0, fmt.Errorf("%w: quantity %q", errMalformedLevel, level.Quantity)
+	}
+	return price, qty, nil
+}
+
+// recalculateBestBid rescans the bids for the highest price
+func (fb *fixedBook) recalculateBestBid() {
+	fb.bestBid = 0
+	for price := range fb.bids {
+		if price > fb.bestBid {
+			fb.bestBid = price
+		}
+	}
+}
+
+// recalculateBestAsk rescans the asks for the lowest price
+func (fb *fixedBook) recalculateBestAsk() {
+	fb.bestAsk = 0
+	for price := range fb.asks {
+		if fb.bestAsk == 0 || price < fb.bestAsk {
+			fb.bestAsk = price
+		}
+	}
+}
+
+// capFixedLevels keeps only the maxLevels best levels of a side and returns the number removed
+func capFixedLevels(levels map[int64]int64, maxLevels int, isBid bool) int {
+	excess := len(levels) - maxLevels
+	if excess <= 0 {
+		return 0
+	}
+
+	prices := make([]int64, 0, len(levels))
+	for price := range levels {
+		prices = append(prices, price)
+	}
+
+	// Sort worst-first so the excess levels are at the front
+	sort.Slice(prices, func(i, j int) bool {
+		if isBid {
+			return prices[i] < prices[j]
+		}
+		return prices[i] > prices[j]
+	})
+
+	for _, price := range prices[:excess] {
+		delete(levels, price)
+	}
+	return excess
+}
@@ -0,0 +1,40 @@
+package orderbook
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetGapResync requests a resync as soon as a definitive sequence gap is detected,
+// instead of waiting for the event buffer to overflow. A gap is definitive when an
+// update continues from an ID past the last applied one without overlapping it.
+// Gap resyncs are requested at most once per cooldown; gaps inside the cooldown
+// are buffered as before. fn, when set, is called on its own goroutine after each
+// request so the caller can serve it right away through CheckAndReinitialize.
+// A cooldown of 0 disables gap resyncs.
+func (ob *OrderBook) SetGapResync(cooldown time.Duration, fn func()) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.gapResyncCooldown = cooldown
+	ob.onGapResync = fn
+}
+
+// requestGapResync flags the book for a resync after a definitive gap unless
+// the cooldown since the last gap resync has not elapsed (must be called with
+// mutex locked)
+func (ob *OrderBook) requestGapResync(expectedPrevID, prevID int64) {
+	if ob.gapResyncCooldown <= 0 || ob.resyncRequested {
+		return
+	}
+	now := ob.clock.Now()
+	if !ob.lastGapResync.IsZero() && now.Sub(ob.lastGapResync) < ob.gapResyncCooldown {
+		return
+	}
+
+	ob.lastGapResync = now
+	ob.resyncRequested = true
+	ob.resyncReason = fmt.Sprintf("sequence gap (expected pu=%d, got pu=%d)", expectedPrevID, prevID)
+	if fn := ob.onGapResync; fn != nil {
+		go fn()
+	}
+}
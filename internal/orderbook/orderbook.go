@@ -5,14 +5,25 @@ import (
 	"log"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"orderbook/internal/aggregation"
+	"orderbook/internal/clock"
 	"orderbook/internal/exchange"
+	"orderbook/internal/logging"
 	"orderbook/internal/types"
 
 	"github.com/shopspring/decimal"
 )
 
+// Depth bands used for liquidity metrics (fraction of mid price)
+var (
+	depth05Pct = decimal.NewFromFloat(0.005)
+	depth2Pct  = decimal.NewFromFloat(0.02)
+	depth10Pct = decimal.NewFromFloat(0.10)
+)
+
 // OrderBook manages the real-time order book state
 type OrderBook struct {
 	mu           sync.RWMutex
@@ -28,42 +39,153 @@ type OrderBook struct {
 	bestAsk   decimal.Decimal
 	bidLevels int
 	askLevels int
+	// Execution quality estimation from trades
+	spreads *spreadEstimator
+	// Time-weighted spread and liquidity averages
+	averages *twaTracker
+	// Memory bounds applied by Prune
+	pruneConfig PruneConfig
+	// Optional fixed-point engine; when set it holds the levels instead of bids/asks
+	fixed *fixedBook
+	// Optional aggregated buckets maintained from deltas
+	aggregated *aggregation.Incremental
+	// Update rate and apply cost
+	throughput throughputMeter
+	// Last published copy for lock-free readers
+	view atomic.Pointer[View]
+	// Cadence of liquidity band recomputation; 0 recomputes on every update
+	statsInterval time.Duration
+	depthStale    bool
+	// Ingestion filters applied to snapshots and updates
+	filters []LevelFilter
+	// Feed behavior of the exchange, e.g. full books or unsequenced deltas
+	capabilities exchange.Capabilities
+	// Latest gaps, buffer overflows and resyncs
+	events eventLog
+	// Healing of crossed books and the alert hook
+	crossedPolicy   types.CrossedPolicy
+	onCrossed       func(CrossedBook)
+	resyncRequested bool   // Set by CrossedResync or a gap resync, served by CheckAndReinitialize
+	resyncReason    string // Cause of the requested resync, logged when served
+	// Buffer threshold and cadence of the reinitialization checks
+	reinit ReinitConfig
+	// Immediate resyncs on definitive sequence gaps
+	gapResyncCooldown time.Duration
+	onGapResync       func()
+	lastGapResync     time.Time
+	// Time-based expiry of books that stopped receiving data
+	maxAge      time.Duration
+	lastApplied time.Time // Local time of the last snapshot or update applied
+	expired     bool      // Cleared by expiry, waiting for fresh data
+	// Level changes passed to the delta handler
+	onDelta        func(BookDelta)
+	pendingChanges []LevelChange
+	deltaSeq       int64
+	// Stability required before the book is reported ready
+	warmup     WarmupConfig
+	warmStart  time.Time // Start of the current warm-up
+	warmEvents int       // Updates applied since warmStart
+	// Time source of expiry, latency and event stamps
+	clock clock.Clock
+}
+
+// defaultCapabilities is assumed until SetCapabilities is called: sequenced deltas on a REST snapshot
+var defaultCapabilities = exchange.Capabilities{
+	SequenceIDs:    true,
+	SnapshotSource: exchange.SnapshotREST,
 }
 
 // New creates a new OrderBook instance
 func New() *OrderBook {
 	return &OrderBook{
-		bids:        make(map[string]types.PriceLevel),
-		asks:        make(map[string]types.PriceLevel),
-		eventBuffer: make([]*exchange.DepthUpdate, 0),
-		currentTick: types.Tick1, // Default to 1.0 tick size
-		bestBid:     decimal.Zero,
-		bestAsk:     decimal.Zero,
+		bids:          make(map[string]types.PriceLevel),
+		asks:          make(map[string]types.PriceLevel),
+		eventBuffer:   make([]*exchange.DepthUpdate, 0),
+		currentTick:   types.Tick1, // Default to 1.0 tick size
+		bestBid:       decimal.Zero,
+		bestAsk:       decimal.Zero,
+		spreads:       newSpreadEstimator(DefaultSpreadHorizons, DefaultSpreadWindow),
+		averages:      newTWATracker(DefaultAverageWindows),
+		capabilities:  defaultCapabilities,
+		crossedPolicy: types.CrossedClean,
+		reinit:        ReinitConfig{BufferSize: DefaultReinitBufferSize},
+		clock:         clock.Real,
 		stats: types.Stats{
 			ConnectionTime: time.Now(),
 		},
 	}
 }
 
+// SetClock replaces the time source, e.g. with a fake clock in tests. It must be
+// called before LoadSnapshot.
+func (ob *OrderBook) SetClock(c clock.Clock) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.clock = c
+	ob.stats.ConnectionTime = c.Now()
+}
+
+// SetCapabilities adapts update handling to the feed: full-depth updates replace the
+// book and updates without sequence IDs are applied without continuity checks.
+// Top-of-book feeds run in a minimal mode that skips liquidity bands and pruning.
+func (ob *OrderBook) SetCapabilities(capabilities exchange.Capabilities) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.capabilities = capabilities
+}
+
 // LoadSnapshot initializes the orderbook with a snapshot from the exchange
 func (ob *OrderBook) LoadSnapshot(snapshot *exchange.Snapshot) error {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
+	return ob.loadSnapshot(snapshot)
+}
+
+// Restore initializes the orderbook with a checkpoint saved by a previous run, so
+// the book can be served while a fresh snapshot is fetched
+func (ob *OrderBook) Restore(checkpoint *exchange.Snapshot) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if err := ob.loadSnapshot(checkpoint); err != nil {
+		return err
+	}
+	ob.markInitialized()
+	ob.publishView()
+	return nil
+}
+
+// loadSnapshot replaces the book with snapshot. The caller must hold ob.mu.
+func (ob *OrderBook) loadSnapshot(snapshot *exchange.Snapshot) error {
+	if len(ob.filters) > 0 {
+		snapshot = ob.filterSnapshot(snapshot)
+	}
+	if before := ob.captureState(); before != nil {
+		defer ob.emitDiff(before, snapshot.Timestamp, true)
+	}
 
 	ob.lastUpdateID = snapshot.LastUpdateID
+	ob.lastApplied = ob.clock.Now()
+	ob.expired = false
 	ob.bids = make(map[string]types.PriceLevel)
 	ob.asks = make(map[string]types.PriceLevel)
+
+	if ob.fixed != nil {
+		ob.countMalformed(ob.fixed.load(snapshot))
+		ob.updateStats()
+		return nil
+	}
+
 	ob.bestBid = decimal.Zero
 	ob.bestAsk = decimal.NewFromFloat(999999999)
 
+	// Malformed levels are skipped rather than failing the whole snapshot
+	rejected := 0
+	var lastErr error
 	for _, bid := range snapshot.Bids {
-		price, err := decimal.NewFromString(bid.Price)
-		if err != nil {
-			return fmt.Errorf("invalid bid price %s: %w", bid.Price, err)
-		}
-		qty, err := decimal.NewFromString(bid.Quantity)
+		price, qty, err := parseDecimalLevel(bid)
 		if err != nil {
-			return fmt.Errorf("invalid bid quantity %s: %w", bid.Quantity, err)
+			rejected, lastErr = rejected+1, fmt.Errorf("bid: %w", err)
+			continue
 		}
 		if !qty.IsZero() {
 			ob.bids[bid.Price] = types.PriceLevel{Price: price, Quantity: qty}
@@ -75,13 +197,10 @@ func (ob *OrderBook) LoadSnapshot(snapshot *exchange.Snapshot) error {
 	}
 
 	for _, ask := range snapshot.Asks {
-		price, err := decimal.NewFromString(ask.Price)
-		if err != nil {
-			return fmt.Errorf("invalid ask price %s: %w", ask.Price, err)
-		}
-		qty, err := decimal.NewFromString(ask.Quantity)
+		price, qty, err := parseDecimalLevel(ask)
 		if err != nil {
-			return fmt.Errorf("invalid ask quantity %s: %w", ask.Quantity, err)
+			rejected, lastErr = rejected+1, fmt.Errorf("ask: %w", err)
+			continue
 		}
 		if !qty.IsZero() {
 			ob.asks[ask.Price] = types.PriceLevel{Price: price, Quantity: qty}
@@ -92,6 +211,7 @@ func (ob *OrderBook) LoadSnapshot(snapshot *exchange.Snapshot) error {
 		}
 	}
 
+	ob.countMalformed(rejected, lastErr)
 	ob.updateStats()
 	return nil
 }
@@ -102,101 +222,221 @@ func (ob *OrderBook) HandleDepthUpdate(update *exchange.DepthUpdate) {
 	defer ob.mu.Unlock()
 
 	if !ob.initialized {
-		ob.eventBuffer = append(ob.eventBuffer, update)
+		if ob.expired && (update.Snapshot || ob.capabilities.FullDepth) {
+			// A full book replaces the expired one without waiting for a snapshot
+			ob.markInitialized()
+			ob.resetFromUpdate(update)
+			return
+		}
+		ob.bufferEvent(update)
+		return
+	}
+
+	if update.Snapshot || ob.capabilities.FullDepth {
+		ob.resetFromUpdate(update)
+		return
+	}
+
+	if !ob.capabilities.SequenceIDs {
+		ob.applyUpdate(update)
 		return
 	}
 
 	expectedPrevID := ob.lastUpdateID
 	if update.PrevUpdateID != expectedPrevID {
 		if update.FirstUpdateID <= expectedPrevID+1 && update.FinalUpdateID > expectedPrevID {
-			//log.Printf("Accepting overlapping event: U=%d, u=%d, expected_pu=%d, got_pu=%d", update.FirstUpdateID, update.FinalUpdateID, expectedPrevID, update.PrevUpdateID)
+			logging.Debugf("Accepting overlapping event: U=%d, u=%d, expected_pu=%d, got_pu=%d", update.FirstUpdateID, update.FinalUpdateID, expectedPrevID, update.PrevUpdateID)
 			ob.applyUpdate(update)
 			return
 		}
 
-		//log.Printf("Sequence gap: expected pu=%d, got pu=%d. Buffering event...", expectedPrevID, update.PrevUpdateID)
-		ob.eventBuffer = append(ob.eventBuffer, update)
+		logging.Debugf("Sequence gap: expected pu=%d, got pu=%d. Buffering event...", expectedPrevID, update.PrevUpdateID)
+		if len(ob.eventBuffer) == 0 {
+			ob.stats.Gaps++
+			ob.recordEvent(FeedEventGap, fmt.Sprintf("expected pu=%d, got pu=%d", expectedPrevID, update.PrevUpdateID))
+		}
+		if update.PrevUpdateID > expectedPrevID {
+			// Updates were missed, buffering cannot close the gap
+			ob.requestGapResync(expectedPrevID, update.PrevUpdateID)
+		}
+		ob.bufferEvent(update)
 		return
 	}
 
 	ob.applyUpdate(update)
 }
 
-// ProcessBufferedEvents processes any buffered events after snapshot load
+// resetFromUpdate replaces the book with a snapshot received mid-stream,
+// discarding buffered events that predate it. The caller must hold ob.mu.
+func (ob *OrderBook) resetFromUpdate(update *exchange.DepthUpdate) {
+	start := ob.clock.Now()
+	err := ob.loadSnapshot(&exchange.Snapshot{
+		Exchange:     update.Exchange,
+		Symbol:       update.Symbol,
+		LastUpdateID: update.FinalUpdateID,
+		Bids:         update.Bids,
+		Asks:         update.Asks,
+		Timestamp:    update.EventTime,
+	})
+	if err != nil {
+		log.Printf("Failed to reset from stream snapshot: %v", err)
+		ob.initialized = false
+		ob.publishView()
+		return
+	}
+
+	ob.throughput.observe(start, ob.clock.Since(start))
+	ob.stats.EventsProcessed++
+	ob.stats.LastEventTime = update.EventTime
+	ob.warmEvents++
+
+	ob.dropBufferedEvents(len(ob.eventBuffer))
+	ob.publishView()
+	if update.Snapshot {
+		ob.recordEvent(FeedEventReset, fmt.Sprintf("lastUpdateId=%d", update.FinalUpdateID))
+		log.Printf("Orderbook reset from stream snapshot: lastUpdateId=%d", update.FinalUpdateID)
+	}
+}
+
+// ProcessBufferedEvents applies the buffered events that continue the loaded
+// snapshot, in order of their ID ranges. Events whose whole range was already
+// applied (older than the snapshot, or duplicates replayed after a reconnect)
+// are discarded, so every ID range is applied once: an event straddling the last
+// applied ID is applied and advances it past the events it contains. Events after
+// a break in the sequence are dropped.
 func (ob *OrderBook) ProcessBufferedEvents() {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
+	ob.processBufferedEvents()
+}
+
+// sortBufferedEvents orders the buffered events by ID range (must be called with mutex locked)
+func (ob *OrderBook) sortBufferedEvents() {
+	events := ob.eventBuffer
+	// On equal first IDs the longest range goes first, leaving the shorter ones covered
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].FirstUpdateID != events[j].FirstUpdateID {
+			return events[i].FirstUpdateID < events[j].FirstUpdateID
+		}
+		return events[i].FinalUpdateID > events[j].FinalUpdateID
+	})
+}
 
-	validEvents := make([]*exchange.DepthUpdate, 0)
+// processBufferedEvents implements ProcessBufferedEvents (must be called with mutex locked)
+func (ob *OrderBook) processBufferedEvents() {
+	ob.sortBufferedEvents()
+	events := ob.eventBuffer
 
-	for _, event := range ob.eventBuffer {
+	applied, stale := 0, 0
+	for i, event := range events {
 		if event.FinalUpdateID <= ob.lastUpdateID {
-			log.Printf("Discarding old buffered event: u=%d <= lastUpdateId=%d",
-				event.FinalUpdateID, ob.lastUpdateID)
+			stale++
 			continue
 		}
-
-		if event.FirstUpdateID <= ob.lastUpdateID+1 && event.FinalUpdateID > ob.lastUpdateID {
-			validEvents = append(validEvents, event)
-			log.Printf("Found valid buffered event: U=%d, u=%d, lastUpdateId=%d",
-				event.FirstUpdateID, event.FinalUpdateID, ob.lastUpdateID)
+		if !ob.continuesSequence(event) {
+			log.Printf("Sequence break in buffered events: U=%d, pu=%d after lastUpdateId=%d, dropping %d events",
+				event.FirstUpdateID, event.PrevUpdateID, ob.lastUpdateID, len(events)-i)
+			break
 		}
+		ob.applyUpdate(event)
+		applied++
+	}
+	if stale > 0 {
+		log.Printf("Discarded %d buffered events already covered by lastUpdateId", stale)
 	}
 
-	if len(validEvents) == 0 {
-		log.Printf("No valid events found in buffer, dropping all and starting fresh")
-		ob.eventBuffer = nil
-		ob.initialized = true
+	ob.dropBufferedEvents(len(events) - applied)
+	ob.markInitialized()
+	ob.publishView()
+	if applied == 0 {
+		log.Printf("No valid events found in buffer, starting fresh")
 		return
 	}
+	log.Printf("Orderbook initialized with %d valid events", applied)
+}
 
-	sort.Slice(validEvents, func(i, j int) bool {
-		return validEvents[i].FirstUpdateID < validEvents[j].FirstUpdateID
-	})
-
-	ob.eventBuffer = nil
+// continuesSequence reports whether an event not yet covered by the last applied
+// ID follows it: its range starts at most one past it, or it names it as the
+// previous ID (must be called with mutex locked)
+func (ob *OrderBook) continuesSequence(event *exchange.DepthUpdate) bool {
+	return event.FirstUpdateID <= ob.lastUpdateID+1 || event.PrevUpdateID == ob.lastUpdateID
+}
 
-	for _, event := range validEvents {
-		if event.FirstUpdateID <= ob.lastUpdateID+1 {
-			ob.applyUpdate(event)
+// CheckAndReinitialize checks if the orderbook needs reinitialization and expires
+// it when it exceeded its max age
+func (ob *OrderBook) CheckAndReinitialize(getSnapshot func() (*exchange.Snapshot, error)) {
+	ob.mu.Lock()
+	expired := ob.expire(ob.clock.Now())
+	fullDepth := ob.capabilities.FullDepth
+	ob.mu.Unlock()
+	if expired {
+		// Full-depth feeds replace the book with their next update
+		if !fullDepth {
+			ob.Reinitialize(getSnapshot)
 		}
+		return
 	}
 
-	ob.initialized = true
-	log.Printf("Orderbook initialized with %d valid events", len(validEvents))
-}
-
-// CheckAndReinitialize checks if the orderbook needs reinitialization
-func (ob *OrderBook) CheckAndReinitialize(getSnapshot func() (*exchange.Snapshot, error)) {
 	ob.mu.RLock()
-	shouldReinit := len(ob.eventBuffer) > 100
+	shouldReinit := len(ob.eventBuffer) > ob.reinit.BufferSize
 	bufferLen := len(ob.eventBuffer)
 	initialized := ob.initialized
+	resyncRequested := ob.resyncRequested
+	reason := ob.resyncReason
 	ob.mu.RUnlock()
 
-	if shouldReinit {
+	if resyncRequested {
+		log.Printf("Reinitializing after %s", reason)
+		ob.Reinitialize(getSnapshot)
+	} else if shouldReinit {
 		log.Printf("Reinitializing due to buffer accumulation: %d events", bufferLen)
 		ob.mu.Lock()
-		ob.initialized = false
+		ob.stats.BufferOverflows++
+		ob.recordEvent(FeedEventBufferOverflow, fmt.Sprintf("%d events buffered", bufferLen))
 		ob.mu.Unlock()
-
-		snapshot, err := getSnapshot()
-		if err != nil {
-			log.Printf("Failed to reinitialize: %v", err)
-			return
-		}
-
-		if err := ob.LoadSnapshot(snapshot); err != nil {
-			log.Printf("Failed to load snapshot during reinitialize: %v", err)
-			return
-		}
-
-		ob.ProcessBufferedEvents()
+		ob.Reinitialize(getSnapshot)
 	} else if initialized && bufferLen > 0 && bufferLen%10 == 0 {
 		log.Printf("Buffer status: %d events pending", bufferLen)
 	}
 }
 
+// Reinitialize reloads the orderbook from a fresh snapshot, buffering updates
+// received in the meantime (e.g., after the exchange connection was replaced)
+func (ob *OrderBook) Reinitialize(getSnapshot func() (*exchange.Snapshot, error)) {
+	ob.mu.Lock()
+	ob.initialized = false
+	ob.resyncRequested = false
+	ob.resyncReason = ""
+	ob.stats.Resyncs++
+	ob.observeAverages()
+	ob.publishView()
+	ob.mu.Unlock()
+
+	snapshot, err := getSnapshot()
+	if err != nil {
+		log.Printf("Failed to reinitialize: %v", err)
+		ob.mu.Lock()
+		ob.recordEvent(FeedEventResyncFailed, err.Error())
+		ob.mu.Unlock()
+		return
+	}
+
+	ob.mu.Lock()
+	err = ob.loadSnapshot(snapshot)
+	if err != nil {
+		ob.recordEvent(FeedEventResyncFailed, err.Error())
+	} else {
+		ob.recordEvent(FeedEventResync, fmt.Sprintf("lastUpdateId=%d", snapshot.LastUpdateID))
+	}
+	ob.mu.Unlock()
+	if err != nil {
+		log.Printf("Failed to load snapshot during reinitialize: %v", err)
+		return
+	}
+
+	ob.ProcessBufferedEvents()
+}
+
 // SetTickLevel changes the current tick level for price aggregation
 func (ob *OrderBook) SetTickLevel(tick types.TickLevel) {
 	ob.mu.Lock()
@@ -216,6 +456,10 @@ func (ob *OrderBook) GetBids() map[string]types.PriceLevel {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
+	if ob.fixed != nil {
+		return ob.fixed.levels(true)
+	}
+
 	bids := make(map[string]types.PriceLevel)
 	for k, v := range ob.bids {
 		bids[k] = v
@@ -228,6 +472,10 @@ func (ob *OrderBook) GetAsks() map[string]types.PriceLevel {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
+	if ob.fixed != nil {
+		return ob.fixed.levels(false)
+	}
+
 	asks := make(map[string]types.PriceLevel)
 	for k, v := range ob.asks {
 		asks[k] = v
@@ -239,7 +487,88 @@ func (ob *OrderBook) GetAsks() map[string]types.PriceLevel {
 func (ob *OrderBook) GetStats() types.Stats {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
-	return ob.stats
+
+	stats := ob.stats
+	stats.RealizedSpreadBps = append([]types.HorizonSpread(nil), ob.stats.RealizedSpreadBps...)
+	stats.EventsPerSecond = ob.throughput.eventsPerSecond(ob.clock.Now())
+	stats.ApplyTime = ob.throughput.applyTime
+	stats.Averages = ob.averages.averages(ob.clock.Now())
+	return stats
+}
+
+// SetFairValueDeviation records the venue's deviation from the cross-venue fair value
+func (ob *OrderBook) SetFairValueDeviation(fairValue, deviationBps decimal.Decimal, alert bool) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.stats.FairValue = fairValue
+	ob.stats.FairValueDeviationBps = deviationBps
+	ob.stats.FairValueAlert = alert
+}
+
+// SetResilience records how quickly the venue's book replenished after its latest sweeps
+func (ob *OrderBook) SetResilience(sweeps int, recovered float64, median time.Duration) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.stats.ResilienceSweeps = sweeps
+	ob.stats.ResilienceRecovered = recovered
+	ob.stats.ResilienceMedian = median
+}
+
+// SetOpenInterest records the venue's open interest and its change over the configured window
+func (ob *OrderBook) SetOpenInterest(openInterest, change decimal.Decimal) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.stats.OpenInterest = openInterest
+	ob.stats.OpenInterestChange = change
+}
+
+// EnableFixedPoint switches the orderbook to the fixed-point engine using the
+// instrument's price and quantity precision. It must be called before LoadSnapshot.
+func (ob *OrderBook) EnableFixedPoint(priceDecimals, qtyDecimals int) error {
+	fixed, err := newFixedBook(priceDecimals, qtyDecimals)
+	if err != nil {
+		return err
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.fixed = fixed
+	ob.bids = make(map[string]types.PriceLevel)
+	ob.asks = make(map[string]types.PriceLevel)
+	ob.updateStats()
+	return nil
+}
+
+// SetStatsInterval defers the liquidity band and total quantity stats of
+// updates to RefreshStats, expected to be called every interval. 0 restores
+// recomputation on every update.
+func (ob *OrderBook) SetStatsInterval(interval time.Duration) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.statsInterval = interval
+	if interval == 0 && ob.depthStale {
+		ob.calculateLiquidityDepth()
+		ob.depthStale = false
+	}
+}
+
+// RefreshStats recomputes the liquidity band and total quantity stats if
+// updates applied since the last refresh left them stale
+func (ob *OrderBook) RefreshStats() {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if ob.depthStale {
+		ob.calculateLiquidityDepth()
+		ob.depthStale = false
+		ob.observeAverages()
+	}
+}
+
+// IsFixedPoint returns whether the orderbook uses the fixed-point engine
+func (ob *OrderBook) IsFixedPoint() bool {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.fixed != nil
 }
 
 // IsInitialized returns whether the orderbook is initialized
@@ -256,15 +585,88 @@ func (ob *OrderBook) GetBufferLength() int {
 	return len(ob.eventBuffer)
 }
 
+// GetLastUpdateID returns the ID of the last applied update
+func (ob *OrderBook) GetLastUpdateID() int64 {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.lastUpdateID
+}
+
 // applyUpdate applies a depth update to the orderbook (must be called with mutex locked)
 func (ob *OrderBook) applyUpdate(update *exchange.DepthUpdate) {
+	start := ob.clock.Now()
+	if len(ob.filters) > 0 {
+		update = ob.filterUpdate(update)
+	}
+	if ob.fixed != nil {
+		ob.countMalformed(ob.fixed.apply(update))
+		ob.bestBid, ob.bestAsk = ob.fixed.bestPrices()
+	} else {
+		ob.countMalformed(ob.applyDecimalUpdate(update))
+	}
+	ob.checkCrossed(update)
+	ob.flushDelta(update.EventTime, false)
+	ob.throughput.observe(start, ob.clock.Since(start))
+
+	ob.lastUpdateID = update.FinalUpdateID
+	ob.lastApplied = ob.clock.Now()
+	ob.stats.EventsProcessed++
+	ob.stats.LastEventTime = update.EventTime
+	ob.warmEvents++
+	if !update.LocalEventTime.IsZero() {
+		ob.stats.EventLatency = ob.clock.Since(update.LocalEventTime)
+	}
+	if ob.statsInterval > 0 {
+		// Liquidity bands scan every level; RefreshStats recomputes them on its own cadence
+		ob.updateTopOfBookStats()
+		ob.depthStale = true
+	} else {
+		ob.updateCachedStats()
+	}
+	ob.observeAverages()
+
+	now := update.EventTime
+	if now.IsZero() {
+		now = ob.clock.Now()
+	}
+	ob.spreads.observeMid(ob.midPrice(), now)
+	ob.updateSpreadStats()
+}
+
+// bufferEvent queues an update until it can be applied (must be called with mutex locked)
+func (ob *OrderBook) bufferEvent(update *exchange.DepthUpdate) {
+	ob.eventBuffer = append(ob.eventBuffer, update)
+	ob.stats.EventsBuffered++
+}
+
+// dropBufferedEvents clears the event buffer, counting dropped events that were
+// not applied (must be called with mutex locked)
+func (ob *OrderBook) dropBufferedEvents(dropped int) {
+	ob.eventBuffer = nil
+	ob.stats.EventsDropped += int64(dropped)
+}
+
+// applyDecimalUpdate applies a depth update to the decimal level maps, skipping
+// malformed levels. It returns the number of levels skipped and the last error
+// (must be called with mutex locked).
+func (ob *OrderBook) applyDecimalUpdate(update *exchange.DepthUpdate) (int, error) {
 	bestBidChanged := false
 	bestAskChanged := false
+	rejected := 0
+	var lastErr error
 
 	for _, bid := range update.Bids {
 		price := bid.Price
-		qty, _ := decimal.NewFromString(bid.Quantity)
-		priceDecimal, _ := decimal.NewFromString(price)
+		priceDecimal, qty, err := parseDecimalLevel(bid)
+		if err != nil {
+			rejected, lastErr = rejected+1, fmt.Errorf("bid: %w", err)
+			continue
+		}
+
+		if ob.aggregated != nil {
+			ob.aggregated.UpdateBid(priceDecimal, ob.bids[price].Quantity, qty)
+		}
+		ob.recordChange(true, priceDecimal, ob.bids[price].Quantity, qty)
 
 		if qty.IsZero() {
 			// Remove bid level
@@ -287,8 +689,16 @@ func (ob *OrderBook) applyUpdate(update *exchange.DepthUpdate) {
 
 	for _, ask := range update.Asks {
 		price := ask.Price
-		qty, _ := decimal.NewFromString(ask.Quantity)
-		priceDecimal, _ := decimal.NewFromString(price)
+		priceDecimal, qty, err := parseDecimalLevel(ask)
+		if err != nil {
+			rejected, lastErr = rejected+1, fmt.Errorf("ask: %w", err)
+			continue
+		}
+
+		if ob.aggregated != nil {
+			ob.aggregated.UpdateAsk(priceDecimal, ob.asks[price].Quantity, qty)
+		}
+		ob.recordChange(false, priceDecimal, ob.asks[price].Quantity, qty)
 
 		if qty.IsZero() {
 			// Remove ask level
@@ -316,15 +726,23 @@ func (ob *OrderBook) applyUpdate(update *exchange.DepthUpdate) {
 	if bestAskChanged {
 		ob.recalculateBestAsk()
 	}
-
-	ob.lastUpdateID = update.FinalUpdateID
-	ob.stats.EventsProcessed++
-	ob.stats.LastEventTime = update.EventTime
-	ob.updateCachedStats()
+	return rejected, lastErr
 }
 
 // updateStats recalculates orderbook statistics (must be called with mutex locked)
 func (ob *OrderBook) updateStats() {
+	// Full recalculations follow bulk changes, which also invalidate aggregated buckets
+	ob.resetAggregated()
+
+	if ob.fixed != nil {
+		ob.bidLevels = len(ob.fixed.bids)
+		ob.askLevels = len(ob.fixed.asks)
+		ob.bestBid, ob.bestAsk = ob.fixed.bestPrices()
+		ob.updateCachedStats()
+		ob.observeAverages()
+		return
+	}
+
 	ob.bidLevels = len(ob.bids)
 	ob.askLevels = len(ob.asks)
 
@@ -353,29 +771,38 @@ func (ob *OrderBook) updateStats() {
 	}
 
 	ob.updateCachedStats()
+	ob.observeAverages()
 }
 
 // updateCachedStats updates the stats structure with cached values (must be called with mutex locked)
 func (ob *OrderBook) updateCachedStats() {
+	ob.updateTopOfBookStats()
+
+	// Calculate liquidity depth metrics
+	ob.calculateLiquidityDepth()
+	ob.depthStale = false
+}
+
+// updateTopOfBookStats updates the stats that do not scan the book (must be called with mutex locked)
+func (ob *OrderBook) updateTopOfBookStats() {
 	ob.stats.BidLevels = ob.bidLevels
 	ob.stats.AskLevels = ob.askLevels
 	ob.stats.BufferedEvents = len(ob.eventBuffer)
 	ob.stats.BestBid = ob.bestBid
 	ob.stats.BestAsk = ob.bestAsk
+	ob.stats.CrossedBook = ob.isCrossed()
 
 	if !ob.bestBid.IsZero() && !ob.bestAsk.IsZero() && ob.bestAsk.GreaterThan(ob.bestBid) {
 		ob.stats.Spread = ob.bestAsk.Sub(ob.bestBid)
 	} else {
 		ob.stats.Spread = decimal.Zero
 	}
-
-	// Calculate liquidity depth metrics
-	ob.calculateLiquidityDepth()
 }
 
 // calculateLiquidityDepth calculates liquidity at various depth percentages (must be called with mutex locked)
 func (ob *OrderBook) calculateLiquidityDepth() {
-	if ob.bestBid.IsZero() || ob.bestAsk.IsZero() {
+	// Top-of-book feeds have no depth to measure
+	if ob.capabilities.TopOfBook || ob.bestBid.IsZero() || ob.bestAsk.IsZero() {
 		ob.stats.BidLiquidity05Pct = decimal.Zero
 		ob.stats.AskLiquidity05Pct = decimal.Zero
 		ob.stats.BidLiquidity2Pct = decimal.Zero
@@ -385,6 +812,8 @@ func (ob *OrderBook) calculateLiquidityDepth() {
 		ob.stats.DeltaLiquidity05Pct = decimal.Zero
 		ob.stats.DeltaLiquidity2Pct = decimal.Zero
 		ob.stats.DeltaLiquidity10Pct = decimal.Zero
+		ob.stats.BidConcentration2Pct = 0
+		ob.stats.AskConcentration2Pct = 0
 		ob.stats.TotalBidsQty = decimal.Zero
 		ob.stats.TotalAsksQty = decimal.Zero
 		return
@@ -393,15 +822,21 @@ func (ob *OrderBook) calculateLiquidityDepth() {
 	// Calculate mid price
 	midPrice := ob.bestBid.Add(ob.bestAsk).Div(decimal.NewFromInt(2))
 
+	if ob.fixed != nil {
+		ob.fixed.calculateLiquidityDepth(&ob.stats, midPrice)
+		return
+	}
+
 	// Calculate price thresholds
-	threshold05Pct := midPrice.Mul(decimal.NewFromFloat(0.005))
-	threshold2Pct := midPrice.Mul(decimal.NewFromFloat(0.02))
-	threshold10Pct := midPrice.Mul(decimal.NewFromFloat(0.10))
+	threshold05Pct := midPrice.Mul(depth05Pct)
+	threshold2Pct := midPrice.Mul(depth2Pct)
+	threshold10Pct := midPrice.Mul(depth10Pct)
 
 	// Calculate bid side liquidity
 	bidLiq05 := decimal.Zero
 	bidLiq2 := decimal.Zero
 	bidLiq10 := decimal.Zero
+	bidSquares2 := 0.0
 	totalBidsQty := decimal.Zero
 	minBid05Pct := midPrice.Sub(threshold05Pct)
 	minBid2Pct := midPrice.Sub(threshold2Pct)
@@ -414,6 +849,8 @@ func (ob *OrderBook) calculateLiquidityDepth() {
 		}
 		if level.Price.GreaterThanOrEqual(minBid2Pct) {
 			bidLiq2 = bidLiq2.Add(level.Quantity)
+			qty := level.Quantity.InexactFloat64()
+			bidSquares2 += qty * qty
 		}
 		if level.Price.GreaterThanOrEqual(minBid10Pct) {
 			bidLiq10 = bidLiq10.Add(level.Quantity)
@@ -424,6 +861,7 @@ func (ob *OrderBook) calculateLiquidityDepth() {
 	askLiq05 := decimal.Zero
 	askLiq2 := decimal.Zero
 	askLiq10 := decimal.Zero
+	askSquares2 := 0.0
 	totalAsksQty := decimal.Zero
 	maxAsk05Pct := midPrice.Add(threshold05Pct)
 	maxAsk2Pct := midPrice.Add(threshold2Pct)
@@ -436,6 +874,8 @@ func (ob *OrderBook) calculateLiquidityDepth() {
 		}
 		if level.Price.LessThanOrEqual(maxAsk2Pct) {
 			askLiq2 = askLiq2.Add(level.Quantity)
+			qty := level.Quantity.InexactFloat64()
+			askSquares2 += qty * qty
 		}
 		if level.Price.LessThanOrEqual(maxAsk10Pct) {
 			askLiq10 = askLiq10.Add(level.Quantity)
@@ -457,6 +897,17 @@ func (ob *OrderBook) calculateLiquidityDepth() {
 	ob.stats.DeltaLiquidity2Pct = bidLiq2.Sub(askLiq2)
 	ob.stats.DeltaLiquidity10Pct = bidLiq10.Sub(askLiq10)
 	ob.stats.TotalDelta = totalBidsQty.Sub(totalAsksQty)
+	ob.stats.BidConcentration2Pct = concentration(bidSquares2, bidLiq2.InexactFloat64())
+	ob.stats.AskConcentration2Pct = concentration(askSquares2, askLiq2.InexactFloat64())
+}
+
+// concentration returns the Herfindahl index of levels from the sum of their
+// squared quantities and their total quantity, 0 without liquidity
+func concentration(sumSquares, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return sumSquares / (total * total)
 }
 
 // recalculateBestBid recalculates the best bid when the current best is removed
@@ -0,0 +1,1111 @@
+package orderbook
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"orderbook/internal/aggregation"
+	"orderbook/internal/clock"
+	"orderbook/internal/exchange"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// makeSnapshot builds a snapshot with n levels per side around 50000 at a 0.1 tick
+func makeSnapshot(n int) *exchange.Snapshot {
+	snapshot := &exchange.Snapshot{
+		Exchange:     exchange.Binancef,
+		Symbol:       "BTCUSDT",
+		LastUpdateID: 1,
+		Timestamp:    time.Now(),
+	}
+	for i := 0; i < n; i++ {
+		snapshot.Bids = append(snapshot.Bids, exchange.PriceLevel{
+			Price:    fmt.Sprintf("%.2f", 50000.0-float64(i)*0.1),
+			Quantity: fmt.Sprintf("%.3f", 0.001*float64(i%50+1)),
+		})
+		snapshot.Asks = append(snapshot.Asks, exchange.PriceLevel{
+			Price:    fmt.Sprintf("%.2f", 50000.1+float64(i)*0.1),
+			Quantity: fmt.Sprintf("%.3f", 0.001*float64(i%40+1)),
+		})
+	}
+	return snapshot
+}
+
+// makeUpdates builds a sequence of contiguous depth updates touching levels near the touch
+func makeUpdates(n, levelsPerUpdate int) []*exchange.DepthUpdate {
+	rng := rand.New(rand.NewSource(42))
+	updates := make([]*exchange.DepthUpdate, n)
+	for i := range updates {
+		update := &exchange.DepthUpdate{
+			Exchange:      exchange.Binancef,
+			Symbol:        "BTCUSDT",
+			EventTime:     time.UnixMilli(int64(1700000000000 + i)),
+			FirstUpdateID: int64(i + 2),
+			FinalUpdateID: int64(i + 2),
+			PrevUpdateID:  int64(i + 1),
+		}
+		for j := 0; j < levelsPerUpdate; j++ {
+			qty := "0"
+			if rng.Intn(4) != 0 {
+				qty = fmt.Sprintf("%.3f", rng.Float64()*2)
+			}
+			offset := float64(rng.Intn(200)) * 0.1
+			update.Bids = append(update.Bids, exchange.PriceLevel{Price: fmt.Sprintf("%.2f", 50000.0-offset), Quantity: qty})
+			update.Asks = append(update.Asks, exchange.PriceLevel{Price: fmt.Sprintf("%.2f", 50000.1+offset), Quantity: qty})
+		}
+		updates[i] = update
+	}
+	return updates
+}
+
+func newLoadedBook(tb testing.TB, fixed bool, snapshot *exchange.Snapshot) *OrderBook {
+	tb.Helper()
+	ob := New()
+	if fixed {
+		if err := ob.EnableFixedPoint(2, 3); err != nil {
+			tb.Fatalf("EnableFixedPoint() failed: %v", err)
+		}
+	}
+	if err := ob.LoadSnapshot(snapshot); err != nil {
+		tb.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	ob.ProcessBufferedEvents()
+	return ob
+}
+
+func TestFixedPointMatchesDecimal(t *testing.T) {
+	snapshot := makeSnapshot(500)
+	updates := makeUpdates(200, 10)
+
+	decimalBook := newLoadedBook(t, false, snapshot)
+	fixedBook := newLoadedBook(t, true, snapshot)
+
+	for _, update := range updates {
+		decimalBook.HandleDepthUpdate(update)
+		fixedBook.HandleDepthUpdate(update)
+	}
+
+	d, f := decimalBook.GetStats(), fixedBook.GetStats()
+	checks := []struct {
+		name     string
+		dec, fix string
+	}{
+		{"BestBid", d.BestBid.String(), f.BestBid.String()},
+		{"BestAsk", d.BestAsk.String(), f.BestAsk.String()},
+		{"Spread", d.Spread.String(), f.Spread.String()},
+		{"BidLiquidity05Pct", d.BidLiquidity05Pct.String(), f.BidLiquidity05Pct.String()},
+		{"AskLiquidity2Pct", d.AskLiquidity2Pct.String(), f.AskLiquidity2Pct.String()},
+		{"DeltaLiquidity10Pct", d.DeltaLiquidity10Pct.String(), f.DeltaLiquidity10Pct.String()},
+		{"TotalBidsQty", d.TotalBidsQty.String(), f.TotalBidsQty.String()},
+		{"TotalAsksQty", d.TotalAsksQty.String(), f.TotalAsksQty.String()},
+		{"BidConcentration2Pct", fmt.Sprintf("%.6f", d.BidConcentration2Pct), fmt.Sprintf("%.6f", f.BidConcentration2Pct)},
+	}
+	for _, c := range checks {
+		if c.dec != c.fix {
+			t.Errorf("%s mismatch: decimal=%s fixed=%s", c.name, c.dec, c.fix)
+		}
+	}
+
+	if len(decimalBook.GetBids()) != len(fixedBook.GetBids()) || len(decimalBook.GetAsks()) != len(fixedBook.GetAsks()) {
+		t.Errorf("Level count mismatch: decimal=%d/%d fixed=%d/%d",
+			len(decimalBook.GetBids()), len(decimalBook.GetAsks()),
+			len(fixedBook.GetBids()), len(fixedBook.GetAsks()))
+	}
+}
+
+func TestConcentration(t *testing.T) {
+	snapshot := &exchange.Snapshot{
+		Exchange:     exchange.Binancef,
+		Symbol:       "BTCUSDT",
+		LastUpdateID: 1,
+		Bids: []exchange.PriceLevel{
+			{Price: "100", Quantity: "1"},
+			{Price: "99", Quantity: "1"},
+			{Price: "98", Quantity: "2"}, // Beyond 2% of mid 100.5
+		},
+		Asks: []exchange.PriceLevel{
+			{Price: "101", Quantity: "4"},
+			{Price: "150", Quantity: "10"},
+		},
+		Timestamp: time.Now(),
+	}
+
+	for _, fixed := range []bool{false, true} {
+		stats := newLoadedBook(t, fixed, snapshot).GetStats()
+		if stats.BidConcentration2Pct != 0.5 {
+			t.Errorf("fixed=%v: Expected bid concentration 0.5 for two equal levels, got %v", fixed, stats.BidConcentration2Pct)
+		}
+		if stats.AskConcentration2Pct != 1 {
+			t.Errorf("fixed=%v: Expected ask concentration 1 for a single level, got %v", fixed, stats.AskConcentration2Pct)
+		}
+	}
+}
+
+func TestStreamSnapshotResetsBook(t *testing.T) {
+	for _, fixed := range []bool{false, true} {
+		ob := newLoadedBook(t, fixed, makeSnapshot(100))
+
+		// A snapshot resent mid-stream (e.g., after reconnect) replaces every level
+		ob.HandleDepthUpdate(&exchange.DepthUpdate{
+			Exchange:      exchange.Bybitf,
+			Symbol:        "BTCUSDT",
+			FirstUpdateID: 500,
+			FinalUpdateID: 500,
+			PrevUpdateID:  42,
+			Bids:          []exchange.PriceLevel{{Price: "49000.00", Quantity: "1.000"}},
+			Asks:          []exchange.PriceLevel{{Price: "49000.50", Quantity: "2.000"}},
+			Snapshot:      true,
+		})
+
+		if len(ob.GetBids()) != 1 || len(ob.GetAsks()) != 1 {
+			t.Errorf("fixed=%v: Expected 1 level per side, got %d/%d", fixed, len(ob.GetBids()), len(ob.GetAsks()))
+		}
+		if stats := ob.GetStats(); stats.BestBid.String() != "49000" || stats.BestAsk.String() != "49000.5" {
+			t.Errorf("fixed=%v: Expected 49000/49000.5, got %s/%s", fixed, stats.BestBid, stats.BestAsk)
+		}
+
+		// Deltas continue from the snapshot sequence
+		ob.HandleDepthUpdate(&exchange.DepthUpdate{
+			FirstUpdateID: 501,
+			FinalUpdateID: 501,
+			PrevUpdateID:  500,
+			Bids:          []exchange.PriceLevel{{Price: "49000.10", Quantity: "1.000"}},
+		})
+		if ob.GetBufferLength() != 0 || len(ob.GetBids()) != 2 {
+			t.Errorf("fixed=%v: Expected delta applied after reset, got %d bids and %d buffered", fixed, len(ob.GetBids()), ob.GetBufferLength())
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	// Full-depth feeds replace the book with every update
+	ob := newLoadedBook(t, false, makeSnapshot(100))
+	ob.SetCapabilities(exchange.Capabilities{FullDepth: true})
+	ob.HandleDepthUpdate(&exchange.DepthUpdate{
+		FirstUpdateID: 7,
+		FinalUpdateID: 7,
+		Bids:          []exchange.PriceLevel{{Price: "49000", Quantity: "1"}},
+		Asks:          []exchange.PriceLevel{{Price: "49001", Quantity: "1"}},
+	})
+	if len(ob.GetBids()) != 1 || len(ob.GetAsks()) != 1 || ob.GetStats().EventsProcessed != 1 {
+		t.Errorf("Expected the full-depth update to replace the book, got %d/%d levels", len(ob.GetBids()), len(ob.GetAsks()))
+	}
+
+	// Unsequenced feeds apply every delta without gap detection
+	ob = newLoadedBook(t, false, makeSnapshot(100))
+	ob.SetCapabilities(exchange.Capabilities{})
+	ob.HandleDepthUpdate(&exchange.DepthUpdate{
+		Bids: []exchange.PriceLevel{{Price: "50000.05", Quantity: "1"}},
+	})
+	if ob.GetBufferLength() != 0 || len(ob.GetBids()) != 101 || ob.GetStats().Gaps != 0 {
+		t.Errorf("Expected the unsequenced update applied, got %d bids and %d buffered", len(ob.GetBids()), ob.GetBufferLength())
+	}
+
+	// Top-of-book feeds keep the spread but skip liquidity bands and pruning
+	ob = newLoadedBook(t, false, makeSnapshot(100))
+	ob.SetCapabilities(exchange.Capabilities{FullDepth: true, TopOfBook: true})
+	ob.SetPruneConfig(PruneConfig{MaxLevels: 1})
+	ob.HandleDepthUpdate(&exchange.DepthUpdate{
+		FirstUpdateID: 7,
+		FinalUpdateID: 7,
+		Bids:          []exchange.PriceLevel{{Price: "49000", Quantity: "1"}},
+		Asks:          []exchange.PriceLevel{{Price: "49002", Quantity: "2"}},
+	})
+	stats := ob.GetStats()
+	if stats.Spread.String() != "2" || !stats.BidLiquidity2Pct.IsZero() || !stats.TotalAsksQty.IsZero() {
+		t.Errorf("Expected spread 2 without liquidity bands, got %s and %s", stats.Spread, stats.BidLiquidity2Pct)
+	}
+	if pruned := ob.Prune(); pruned != 0 {
+		t.Errorf("Expected no pruning on a top-of-book feed, got %d levels", pruned)
+	}
+}
+
+func TestThroughputCounters(t *testing.T) {
+	ob := New()
+	updates := makeUpdates(20, 5)
+
+	// Updates received before the snapshot are buffered; the stale ones are dropped on load
+	for _, update := range updates[:10] {
+		ob.HandleDepthUpdate(update)
+	}
+	snapshot := makeSnapshot(100)
+	snapshot.LastUpdateID = 10
+	if err := ob.LoadSnapshot(snapshot); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	ob.ProcessBufferedEvents()
+
+	for _, update := range updates[10:] {
+		ob.HandleDepthUpdate(update)
+	}
+
+	stats := ob.GetStats()
+	if stats.EventsBuffered != 10 {
+		t.Errorf("Expected 10 buffered events, got %d", stats.EventsBuffered)
+	}
+	if stats.EventsDropped != 9 {
+		t.Errorf("Expected 9 dropped events, got %d", stats.EventsDropped)
+	}
+	if stats.EventsProcessed != 11 {
+		t.Errorf("Expected 11 applied events, got %d", stats.EventsProcessed)
+	}
+	if stats.ApplyTime <= 0 {
+		t.Errorf("Expected a positive apply time, got %v", stats.ApplyTime)
+	}
+}
+
+func TestProcessBufferedEventsOverlaps(t *testing.T) {
+	// Update IDs follow Binance USDⓈ-M reconnects: U and u of one event span several
+	// IDs, pu is the u of the previous event and IDs are not contiguous across events.
+	// Each event sets the 50000 bid to its u, so the quantity shows the last one applied.
+	event := func(first, final, prev int64) *exchange.DepthUpdate {
+		return &exchange.DepthUpdate{
+			Exchange:      exchange.Binancef,
+			Symbol:        "BTCUSDT",
+			FirstUpdateID: first,
+			FinalUpdateID: final,
+			PrevUpdateID:  prev,
+			Bids:          []exchange.PriceLevel{{Price: "50000.00", Quantity: strconv.FormatInt(final, 10)}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		snapshot int64
+		events   []*exchange.DepthUpdate
+		applied  int64 // Events applied
+		last     int64 // lastUpdateId afterwards, also the 50000 bid quantity
+	}{
+		{
+			name:     "event straddling the snapshot then its successor",
+			snapshot: 7359321045,
+			events: []*exchange.DepthUpdate{
+				event(7359320988, 7359321012, 7359320981),
+				event(7359321013, 7359321051, 7359321012),
+				event(7359321058, 7359321077, 7359321051),
+			},
+			applied: 2,
+			last:    7359321077,
+		},
+		{
+			name:     "events replayed by the new connection",
+			snapshot: 7359321045,
+			events: []*exchange.DepthUpdate{
+				event(7359321013, 7359321051, 7359321012),
+				event(7359321058, 7359321077, 7359321051),
+				event(7359321013, 7359321051, 7359321012),
+				event(7359321058, 7359321077, 7359321051),
+				event(7359321080, 7359321094, 7359321077),
+			},
+			applied: 3,
+			last:    7359321094,
+		},
+		{
+			name:     "range nested in a longer one",
+			snapshot: 7359321045,
+			events: []*exchange.DepthUpdate{
+				event(7359321013, 7359321049, 7359321012),
+				event(7359321013, 7359321051, 7359321012),
+				event(7359321050, 7359321051, 7359321049),
+			},
+			applied: 1,
+			last:    7359321051,
+		},
+		{
+			name:     "events buffered out of order",
+			snapshot: 7359321045,
+			events: []*exchange.DepthUpdate{
+				event(7359321080, 7359321094, 7359321077),
+				event(7359321013, 7359321051, 7359321012),
+				event(7359321058, 7359321077, 7359321051),
+			},
+			applied: 3,
+			last:    7359321094,
+		},
+		{
+			name:     "every event older than the snapshot",
+			snapshot: 7359321100,
+			events: []*exchange.DepthUpdate{
+				event(7359321013, 7359321051, 7359321012),
+				event(7359321058, 7359321077, 7359321051),
+			},
+			applied: 0,
+			last:    7359321100,
+		},
+		{
+			name:     "break after the straddling event",
+			snapshot: 7359321045,
+			events: []*exchange.DepthUpdate{
+				event(7359321013, 7359321051, 7359321012),
+				event(7359321080, 7359321094, 7359321077),
+				event(7359321095, 7359321102, 7359321094),
+			},
+			applied: 1,
+			last:    7359321051,
+		},
+	}
+
+	for _, tt := range tests {
+		ob := New()
+		for _, e := range tt.events {
+			ob.HandleDepthUpdate(e)
+		}
+		snapshot := makeSnapshot(10)
+		snapshot.LastUpdateID = tt.snapshot
+		snapshot.Bids[0].Quantity = strconv.FormatInt(tt.snapshot, 10)
+		if err := ob.LoadSnapshot(snapshot); err != nil {
+			t.Fatalf("%s: LoadSnapshot() failed: %v", tt.name, err)
+		}
+		ob.ProcessBufferedEvents()
+
+		stats := ob.GetStats()
+		if stats.EventsProcessed != tt.applied || stats.EventsDropped != int64(len(tt.events))-tt.applied {
+			t.Errorf("%s: Expected %d events applied and %d dropped, got %d and %d",
+				tt.name, tt.applied, int64(len(tt.events))-tt.applied, stats.EventsProcessed, stats.EventsDropped)
+		}
+		if last := ob.GetLastUpdateID(); last != tt.last {
+			t.Errorf("%s: Expected lastUpdateId %d, got %d", tt.name, tt.last, last)
+		}
+		if qty := ob.GetBids()["50000.00"].Quantity.IntPart(); qty != tt.last {
+			t.Errorf("%s: Expected the 50000 bid set by update %d, got %d", tt.name, tt.last, qty)
+		}
+		if !ob.IsInitialized() || ob.GetBufferLength() != 0 {
+			t.Errorf("%s: Expected an initialized book with an empty buffer, got %d buffered", tt.name, ob.GetBufferLength())
+		}
+	}
+}
+
+func TestGapAndResyncCounters(t *testing.T) {
+	ob := New()
+	snapshot := makeSnapshot(100)
+	snapshot.LastUpdateID = 1
+	if err := ob.LoadSnapshot(snapshot); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	ob.ProcessBufferedEvents()
+
+	// Skipping updates 1 and 2 opens one gap, however many events queue behind it
+	updates := makeUpdates(6, 5)
+	ob.HandleDepthUpdate(updates[0])
+	for _, update := range updates[3:] {
+		ob.HandleDepthUpdate(update)
+	}
+	if gaps := ob.GetStats().Gaps; gaps != 1 {
+		t.Errorf("Expected 1 gap, got %d", gaps)
+	}
+
+	ob.Reinitialize(func() (*exchange.Snapshot, error) {
+		snapshot := makeSnapshot(100)
+		snapshot.LastUpdateID = 7
+		return snapshot, nil
+	})
+	stats := ob.GetStats()
+	if stats.Resyncs != 1 || !ob.IsInitialized() {
+		t.Errorf("Expected 1 resync and an initialized book, got %d resyncs", stats.Resyncs)
+	}
+
+	events := ob.FeedEvents()
+	if len(events) != 2 || events[0].Kind != FeedEventGap || events[1].Kind != FeedEventResync || events[1].Detail != "lastUpdateId=7" {
+		t.Errorf("Expected a gap then a resync in the event log, got %+v", events)
+	}
+}
+
+func TestReinitBufferSize(t *testing.T) {
+	ob := New()
+	ob.SetReinitConfig(ReinitConfig{BufferSize: 3})
+	snapshot := makeSnapshot(100)
+	snapshot.LastUpdateID = 1
+	if err := ob.LoadSnapshot(snapshot); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	ob.ProcessBufferedEvents()
+
+	getSnapshot := func() (*exchange.Snapshot, error) {
+		snapshot := makeSnapshot(100)
+		snapshot.LastUpdateID = 10
+		return snapshot, nil
+	}
+	// Skipping update 2 buffers the following ones
+	updates := makeUpdates(10, 5)
+	for _, update := range updates[1:4] {
+		ob.HandleDepthUpdate(update)
+	}
+	ob.CheckAndReinitialize(getSnapshot)
+	if stats := ob.GetStats(); stats.Resyncs != 0 {
+		t.Errorf("Expected no resync with 3 buffered events, got %d", stats.Resyncs)
+	}
+
+	ob.HandleDepthUpdate(updates[4])
+	ob.CheckAndReinitialize(getSnapshot)
+	if stats := ob.GetStats(); stats.Resyncs != 1 || stats.BufferOverflows != 1 {
+		t.Errorf("Expected a buffer overflow resync past 3 events, got %d resyncs and %d overflows", stats.Resyncs, stats.BufferOverflows)
+	}
+
+	ob.SetReinitConfig(ReinitConfig{})
+	if ob.ReinitConfig().BufferSize != DefaultReinitBufferSize {
+		t.Errorf("Expected the default buffer size, got %d", ob.ReinitConfig().BufferSize)
+	}
+}
+
+func TestGapResync(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	ob := New()
+	ob.SetClock(fake)
+	requests := make(chan struct{}, 4)
+	ob.SetGapResync(time.Minute, func() { requests <- struct{}{} })
+	snapshot := makeSnapshot(100)
+	snapshot.LastUpdateID = 1
+	if err := ob.LoadSnapshot(snapshot); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	ob.ProcessBufferedEvents()
+
+	getSnapshot := func(lastUpdateID int64) func() (*exchange.Snapshot, error) {
+		return func() (*exchange.Snapshot, error) {
+			snapshot := makeSnapshot(100)
+			snapshot.LastUpdateID = lastUpdateID
+			return snapshot, nil
+		}
+	}
+
+	// An update continuing from an older ID is not a definitive gap
+	updates := makeUpdates(10, 10)
+	stale := *updates[0]
+	stale.FirstUpdateID, stale.FinalUpdateID, stale.PrevUpdateID = 1, 1, 0
+	ob.HandleDepthUpdate(&stale)
+	select {
+	case <-requests:
+		t.Fatal("Expected no resync request for a stale update")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Skipping update 2 requests a resync on the first event past the gap
+	ob.HandleDepthUpdate(updates[1])
+	select {
+	case <-requests:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a resync request after a definitive gap")
+	}
+	ob.CheckAndReinitialize(getSnapshot(3))
+	if resyncs := ob.GetStats().Resyncs; resyncs != 1 || !ob.IsInitialized() {
+		t.Errorf("Expected the gap served by a resync, got %d resyncs", resyncs)
+	}
+
+	// Another gap inside the cooldown waits for the buffer instead
+	ob.HandleDepthUpdate(updates[4])
+	ob.CheckAndReinitialize(getSnapshot(5))
+	if resyncs := ob.GetStats().Resyncs; resyncs != 1 {
+		t.Errorf("Expected no resync inside the cooldown, got %d resyncs", resyncs)
+	}
+
+	fake.Advance(time.Minute)
+	ob.HandleDepthUpdate(updates[6])
+	ob.CheckAndReinitialize(getSnapshot(7))
+	if resyncs := ob.GetStats().Resyncs; resyncs != 2 {
+		t.Errorf("Expected a resync after the cooldown, got %d resyncs", resyncs)
+	}
+	select {
+	case <-requests:
+	case <-time.After(time.Second):
+		t.Error("Expected a resync request after the cooldown")
+	}
+}
+
+func TestCrossedBook(t *testing.T) {
+	// A bid at 50000.30 crosses the asks from 50000.10 to 50000.30
+	crossing := &exchange.DepthUpdate{
+		FirstUpdateID: 2,
+		FinalUpdateID: 2,
+		PrevUpdateID:  1,
+		Bids:          []exchange.PriceLevel{{Price: "50000.30", Quantity: "1"}},
+	}
+
+	for _, fixed := range []bool{false, true} {
+		ob := newLoadedBook(t, fixed, makeSnapshot(10))
+		alerts := make(chan CrossedBook, 1)
+		ob.SetCrossedHandler(func(event CrossedBook) { alerts <- event })
+		ob.HandleDepthUpdate(crossing)
+
+		stats := ob.GetStats()
+		if stats.CrossedBook || stats.CrossedBooks != 1 || stats.BestBid.String() != "50000.3" || stats.BestAsk.String() != "50000.4" {
+			t.Errorf("fixed=%v: Expected the crossing asks removed, got bid %s ask %s crossed=%v (%d)", fixed, stats.BestBid, stats.BestAsk, stats.CrossedBook, stats.CrossedBooks)
+		}
+		select {
+		case event := <-alerts:
+			if event.Policy != types.CrossedClean || event.Removed != 3 {
+				t.Errorf("fixed=%v: Expected 3 levels cleaned, got %+v", fixed, event)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("fixed=%v: Expected a crossed book alert", fixed)
+		}
+	}
+
+	// The resync policy leaves the book crossed until the next reinitialization check
+	ob := newLoadedBook(t, false, makeSnapshot(10))
+	ob.SetCrossedPolicy(types.CrossedResync)
+	ob.HandleDepthUpdate(crossing)
+	if !ob.GetStats().CrossedBook {
+		t.Errorf("Expected the book flagged as crossed")
+	}
+	ob.CheckAndReinitialize(func() (*exchange.Snapshot, error) {
+		snapshot := makeSnapshot(10)
+		snapshot.LastUpdateID = 2
+		return snapshot, nil
+	})
+	if stats := ob.GetStats(); stats.CrossedBook || stats.Resyncs != 1 {
+		t.Errorf("Expected the crossed book resynced, got crossed=%v after %d resyncs", stats.CrossedBook, stats.Resyncs)
+	}
+}
+
+func TestMalformedLevelsRejected(t *testing.T) {
+	update := &exchange.DepthUpdate{
+		FirstUpdateID: 2,
+		FinalUpdateID: 2,
+		PrevUpdateID:  1,
+		Bids: []exchange.PriceLevel{
+			{Price: "abc", Quantity: "1"},
+			{Price: "0", Quantity: "1"},
+			{Price: "49999.00", Quantity: "-1"},
+			{Price: "49999.10", Quantity: ""},
+			{Price: "49998.00", Quantity: "2"},
+		},
+		Asks: []exchange.PriceLevel{{Price: "-50001.00", Quantity: "1"}},
+	}
+
+	for _, fixed := range []bool{false, true} {
+		snapshot := makeSnapshot(10)
+		snapshot.Asks = append(snapshot.Asks, exchange.PriceLevel{Price: "50002.00", Quantity: "x"})
+		ob := newLoadedBook(t, fixed, snapshot)
+		ob.HandleDepthUpdate(update)
+
+		if bids, asks := len(ob.GetBids()), len(ob.GetAsks()); bids != 11 || asks != 10 {
+			t.Errorf("fixed=%v: Expected 11 bids and 10 asks, got %d and %d", fixed, bids, asks)
+		}
+		stats := ob.GetStats()
+		if stats.MalformedLevels != 6 || stats.MalformedMessages != 2 {
+			t.Errorf("fixed=%v: Expected 6 malformed levels in 2 messages, got %d in %d", fixed, stats.MalformedLevels, stats.MalformedMessages)
+		}
+		if stats.BestBid.String() != "50000" {
+			t.Errorf("fixed=%v: Expected best bid 50000, got %s", fixed, stats.BestBid)
+		}
+	}
+}
+
+func TestBookExpiry(t *testing.T) {
+	// Full-depth feeds recover with the next full book
+	ob := newLoadedBook(t, false, makeSnapshot(10))
+	ob.SetCapabilities(exchange.Capabilities{FullDepth: true})
+	ob.SetMaxAge(time.Minute)
+
+	ob.mu.Lock()
+	early := ob.expire(time.Now())
+	expired := ob.expire(time.Now().Add(2 * time.Minute))
+	ob.mu.Unlock()
+	if early || !expired {
+		t.Errorf("Expected the book to expire only after a minute, got early=%v expired=%v", early, expired)
+	}
+	if ob.IsInitialized() || len(ob.GetBids()) != 0 || ob.GetStats().Expiries != 1 {
+		t.Errorf("Expected an uninitialized empty book, got initialized=%v with %d bids", ob.IsInitialized(), len(ob.GetBids()))
+	}
+
+	ob.HandleDepthUpdate(&exchange.DepthUpdate{
+		Bids: []exchange.PriceLevel{{Price: "50000", Quantity: "1"}},
+		Asks: []exchange.PriceLevel{{Price: "50001", Quantity: "1"}},
+	})
+	if !ob.IsInitialized() || len(ob.GetBids()) != 1 {
+		t.Errorf("Expected the book restored from the next full book, got initialized=%v with %d bids", ob.IsInitialized(), len(ob.GetBids()))
+	}
+
+	// Sequenced feeds are reloaded from a snapshot
+	fake := clock.NewFake(time.Now())
+	ob = New()
+	ob.SetClock(fake)
+	ob.SetMaxAge(time.Minute)
+	if err := ob.LoadSnapshot(makeSnapshot(10)); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	ob.ProcessBufferedEvents()
+	getSnapshot := func() (*exchange.Snapshot, error) { return makeSnapshot(5), nil }
+
+	fake.Advance(time.Minute)
+	ob.CheckAndReinitialize(getSnapshot)
+	if ob.GetStats().Expiries != 0 {
+		t.Errorf("Expected no expiry at exactly the max age")
+	}
+	fake.Advance(time.Millisecond)
+	ob.CheckAndReinitialize(getSnapshot)
+	if stats := ob.GetStats(); !ob.IsInitialized() || stats.Expiries != 1 || stats.Resyncs != 1 || len(ob.GetBids()) != 5 {
+		t.Errorf("Expected the expired book resynced, got %d expiries, %d resyncs and %d bids", stats.Expiries, stats.Resyncs, len(ob.GetBids()))
+	}
+}
+
+func TestBookDeltas(t *testing.T) {
+	for _, fixed := range []bool{false, true} {
+		ob := New()
+		if fixed {
+			if err := ob.EnableFixedPoint(2, 3); err != nil {
+				t.Fatalf("EnableFixedPoint() failed: %v", err)
+			}
+		}
+		var deltas []BookDelta
+		ob.SetDeltaHandler(func(delta BookDelta) { deltas = append(deltas, delta) })
+
+		snapshot := makeSnapshot(2)
+		if err := ob.LoadSnapshot(snapshot); err != nil {
+			t.Fatalf("LoadSnapshot() failed: %v", err)
+		}
+		ob.ProcessBufferedEvents()
+		ob.HandleDepthUpdate(&exchange.DepthUpdate{
+			FirstUpdateID: 2,
+			FinalUpdateID: 2,
+			PrevUpdateID:  1,
+			Bids: []exchange.PriceLevel{
+				{Price: "50000.00", Quantity: "0.005"}, // Changed
+				{Price: "49999.00", Quantity: "0"},     // Absent, no change
+			},
+			Asks: []exchange.PriceLevel{{Price: "50000.10", Quantity: "0"}}, // Removed
+		})
+
+		if len(deltas) != 2 || !deltas[0].Snapshot || len(deltas[0].Changes) != 4 || deltas[1].Snapshot || deltas[1].Seq != deltas[0].Seq+1 {
+			t.Fatalf("fixed=%v: Expected a snapshot delta of 4 levels then an update delta, got %+v", fixed, deltas)
+		}
+		changes := deltas[1].Changes
+		if len(changes) != 2 ||
+			!changes[0].IsBid || changes[0].OldQuantity.String() != "0.001" || changes[0].NewQuantity.String() != "0.005" ||
+			changes[1].IsBid || changes[1].Price.String() != "50000.1" || changes[1].OldQuantity.String() != "0.001" || !changes[1].NewQuantity.IsZero() {
+			t.Errorf("fixed=%v: Unexpected update changes %+v", fixed, changes)
+		}
+		if deltas[1].BestBid.String() != "50000" || deltas[1].BestAsk.String() != "50000.2" {
+			t.Errorf("fixed=%v: Expected touch 50000/50000.2 after the update, got %s/%s", fixed, deltas[1].BestBid, deltas[1].BestAsk)
+		}
+	}
+}
+
+func TestEventLogKeepsLatest(t *testing.T) {
+	var l eventLog
+	for i := 0; i < DefaultEventLogSize+5; i++ {
+		l.add(FeedEvent{Detail: strconv.Itoa(i)})
+	}
+
+	events := l.list()
+	if len(events) != DefaultEventLogSize || events[0].Detail != "5" || events[len(events)-1].Detail != strconv.Itoa(DefaultEventLogSize+4) {
+		t.Errorf("Expected events 5 to %d, got %d events from %s", DefaultEventLogSize+4, len(events), events[0].Detail)
+	}
+}
+
+func TestThroughputMeterRate(t *testing.T) {
+	var m throughputMeter
+	start := time.Unix(1700000000, 0)
+	for i := 0; i <= 500; i++ {
+		m.observe(start.Add(time.Duration(i)*2*time.Millisecond), time.Microsecond)
+	}
+
+	if rate := m.eventsPerSecond(start.Add(time.Second)); rate < 499 || rate > 502 {
+		t.Errorf("Expected ~500 events/s, got %.1f", rate)
+	}
+	if rate := m.eventsPerSecond(start.Add(5 * time.Second)); rate != 0 {
+		t.Errorf("Expected 0 events/s after the stream went quiet, got %.1f", rate)
+	}
+}
+
+func TestRange(t *testing.T) {
+	for _, fixed := range []bool{false, true} {
+		ob := newLoadedBook(t, fixed, makeSnapshot(100))
+
+		var bids []string
+		ob.Range(Bids, decimal.RequireFromString("49999.5"), decimal.RequireFromString("49999.8"), func(level types.PriceLevel) bool {
+			bids = append(bids, level.Price.String())
+			return true
+		})
+		expected := []string{"49999.8", "49999.7", "49999.6", "49999.5"}
+		if fmt.Sprint(bids) != fmt.Sprint(expected) {
+			t.Errorf("fixed=%v: Expected bids %v, got %v", fixed, expected, bids)
+		}
+
+		// Unbounded above, stopped by fn after three levels
+		var asks []string
+		ob.Range(Asks, decimal.RequireFromString("50009.15"), decimal.Zero, func(level types.PriceLevel) bool {
+			asks = append(asks, level.Price.String())
+			return len(asks) < 3
+		})
+		expected = []string{"50009.2", "50009.3", "50009.4"}
+		if fmt.Sprint(asks) != fmt.Sprint(expected) {
+			t.Errorf("fixed=%v: Expected asks %v, got %v", fixed, expected, asks)
+		}
+	}
+}
+
+func TestFilteredLevels(t *testing.T) {
+	for _, fixed := range []bool{false, true} {
+		ob := newLoadedBook(t, fixed, makeSnapshot(100))
+
+		// Within 0.001% of mid 50000.05 the five levels nearest the touch, of which
+		// the first two are below the minimum quantity
+		filter := FilterConfig{MaxDistancePct: 0.00001, MinQuantity: 0.003}
+		var bids, asks []string
+		for _, level := range ob.GetBidsFiltered(filter) {
+			bids = append(bids, level.Price.String())
+		}
+		for _, level := range ob.GetAsksFiltered(filter) {
+			asks = append(asks, level.Price.String())
+		}
+		expected := []string{"49999.8", "49999.7", "49999.6"}
+		if fmt.Sprint(bids) != fmt.Sprint(expected) {
+			t.Errorf("fixed=%v: Expected bids %v, got %v", fixed, expected, bids)
+		}
+		expected = []string{"50000.3", "50000.4", "50000.5"}
+		if fmt.Sprint(asks) != fmt.Sprint(expected) {
+			t.Errorf("fixed=%v: Expected asks %v, got %v", fixed, expected, asks)
+		}
+
+		if n := len(ob.GetBidsFiltered(FilterConfig{})); n != 100 {
+			t.Errorf("fixed=%v: Expected all 100 bids without a filter, got %d", fixed, n)
+		}
+	}
+}
+
+func TestPublishView(t *testing.T) {
+	for _, fixed := range []bool{false, true} {
+		ob := newLoadedBook(t, fixed, makeSnapshot(100))
+
+		view := ob.View()
+		if view == nil {
+			t.Fatalf("fixed=%v: Expected a view after initialization", fixed)
+		}
+		if len(view.Bids) != 100 || view.Bids[0].Price.String() != "50000" || view.Asks[0].Price.String() != "50000.1" {
+			t.Errorf("fixed=%v: Expected 100 bids best first, got %d starting at %s/%s", fixed, len(view.Bids), view.Bids[0].Price, view.Asks[0].Price)
+		}
+
+		for _, update := range makeUpdates(50, 5) {
+			ob.HandleDepthUpdate(update)
+		}
+		if ob.View() != view || len(view.Bids) != 100 {
+			t.Errorf("fixed=%v: Expected the published view to stay unchanged until the next publish", fixed)
+		}
+
+		ob.PublishView()
+		published := ob.View()
+		if published.LastUpdateID != 51 {
+			t.Errorf("fixed=%v: Expected view at update 51, got %d", fixed, published.LastUpdateID)
+		}
+		if len(published.Bids) != len(ob.GetBids()) || !published.Stats.BestBid.Equal(published.Bids[0].Price) {
+			t.Errorf("fixed=%v: Expected view to match the book, got %d bids with best %s", fixed, len(published.Bids), published.Bids[0].Price)
+		}
+	}
+}
+
+func TestViewSnapshot(t *testing.T) {
+	ob := newLoadedBook(t, true, makeSnapshot(100))
+	for _, update := range makeUpdates(50, 5) {
+		ob.HandleDepthUpdate(update)
+	}
+	ob.PublishView()
+
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := ob.View().Snapshot(exchange.Binancef, "BTCUSDT", at)
+	if !snapshot.Timestamp.Equal(at) || snapshot.LastUpdateID != 51 {
+		t.Errorf("Expected snapshot at %v for update 51, got %v for update %d", at, snapshot.Timestamp, snapshot.LastUpdateID)
+	}
+
+	// Loading the snapshot reproduces the book
+	copied := newLoadedBook(t, false, snapshot)
+	if len(copied.GetBids()) != len(ob.GetBids()) || len(copied.GetAsks()) != len(ob.GetAsks()) {
+		t.Errorf("Expected %d/%d levels, got %d/%d", len(ob.GetBids()), len(ob.GetAsks()), len(copied.GetBids()), len(copied.GetAsks()))
+	}
+	if !copied.GetStats().BestBid.Equal(ob.GetStats().BestBid) {
+		t.Errorf("Expected best bid %s, got %s", ob.GetStats().BestBid, copied.GetStats().BestBid)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	ob := newLoadedBook(t, false, makeSnapshot(100))
+	checkpoint := ob.View().Snapshot(exchange.Binancef, "BTCUSDT", time.Now())
+
+	restored := New()
+	if err := restored.Restore(checkpoint); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if !restored.IsReady() {
+		t.Errorf("Expected a restored book to be ready")
+	}
+	view := restored.View()
+	if view == nil || view.LastUpdateID != checkpoint.LastUpdateID || len(view.Bids) != 100 || len(view.Asks) != 100 {
+		t.Fatalf("Expected the checkpoint to be published, got %+v", view)
+	}
+	if !restored.GetStats().BestBid.Equal(ob.GetStats().BestBid) {
+		t.Errorf("Expected best bid %s, got %s", ob.GetStats().BestBid, restored.GetStats().BestBid)
+	}
+}
+
+func TestStatsInterval(t *testing.T) {
+	ob := newLoadedBook(t, false, makeSnapshot(100))
+	ob.SetStatsInterval(100 * time.Millisecond)
+	before := ob.GetStats().TotalBidsQty
+
+	ob.HandleDepthUpdate(&exchange.DepthUpdate{
+		FirstUpdateID: 2,
+		FinalUpdateID: 2,
+		PrevUpdateID:  1,
+		Bids:          []exchange.PriceLevel{{Price: "50000.05", Quantity: "5"}},
+	})
+
+	stats := ob.GetStats()
+	if stats.BestBid.String() != "50000.05" {
+		t.Errorf("Expected best bid to update immediately, got %s", stats.BestBid)
+	}
+	if !stats.TotalBidsQty.Equal(before) {
+		t.Errorf("Expected totals deferred until refresh, got %s (was %s)", stats.TotalBidsQty, before)
+	}
+
+	ob.RefreshStats()
+	if total := ob.GetStats().TotalBidsQty; !total.Equal(before.Add(decimal.NewFromInt(5))) {
+		t.Errorf("Expected totals %s after refresh, got %s", before.Add(decimal.NewFromInt(5)), total)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	for _, fixed := range []bool{false, true} {
+		ob := New()
+		if fixed {
+			if err := ob.EnableFixedPoint(2, 3); err != nil {
+				t.Fatalf("EnableFixedPoint() failed: %v", err)
+			}
+		}
+		ob.SetFilters(NewFilters(FilterConfig{MaxDistancePct: 0.5, MinQuantity: 0.01})...)
+
+		err := ob.LoadSnapshot(&exchange.Snapshot{
+			LastUpdateID: 1,
+			Bids: []exchange.PriceLevel{
+				{Price: "50000.00", Quantity: "1.000"},
+				{Price: "49990.00", Quantity: "0.001"}, // Dust
+				{Price: "0.01", Quantity: "100.000"},   // Absurdly far
+			},
+			Asks: []exchange.PriceLevel{
+				{Price: "50000.10", Quantity: "1.000"},
+				{Price: "999999.00", Quantity: "1.000"}, // Absurdly far
+			},
+		})
+		if err != nil {
+			t.Fatalf("LoadSnapshot() failed: %v", err)
+		}
+		ob.ProcessBufferedEvents()
+
+		if len(ob.GetBids()) != 1 || len(ob.GetAsks()) != 1 {
+			t.Errorf("fixed=%v: Expected 1 level per side after filtering, got %d/%d", fixed, len(ob.GetBids()), len(ob.GetAsks()))
+		}
+
+		// A level shrinking to dust is removed rather than left at its old size
+		ob.HandleDepthUpdate(&exchange.DepthUpdate{
+			FirstUpdateID: 2,
+			FinalUpdateID: 2,
+			PrevUpdateID:  1,
+			Bids: []exchange.PriceLevel{
+				{Price: "50000.00", Quantity: "0.005"},
+				{Price: "49999.00", Quantity: "2.000"},
+			},
+		})
+		bids := ob.GetBids()
+		if len(bids) != 1 {
+			t.Errorf("fixed=%v: Expected the dust update to delete its level, got %d bids", fixed, len(bids))
+		}
+		if best := ob.GetStats().BestBid.String(); best != "49999" {
+			t.Errorf("fixed=%v: Expected best bid 49999, got %s", fixed, best)
+		}
+	}
+}
+
+func TestIncrementalAggregationMatchesRebuild(t *testing.T) {
+	for _, fixed := range []bool{false, true} {
+		ob := newLoadedBook(t, fixed, makeSnapshot(500))
+		ob.EnableIncrementalAggregation(types.Tick1)
+
+		for _, update := range makeUpdates(200, 10) {
+			ob.HandleDepthUpdate(update)
+		}
+
+		bids, asks, ok := ob.GetAggregatedLevels(types.Tick1)
+		if !ok {
+			t.Fatalf("fixed=%v: Expected aggregated levels at tick 1", fixed)
+		}
+		if _, _, ok := ob.GetAggregatedLevels(types.Tick10); ok {
+			t.Errorf("fixed=%v: Expected no aggregated levels at tick 10", fixed)
+		}
+
+		agg := aggregation.New(types.Tick1)
+		checks := []struct {
+			side     string
+			got      []types.PriceLevel
+			expected []types.PriceLevel
+		}{
+			{"bids", bids, agg.AggregateBids(levelSlice(ob.GetBids()))},
+			{"asks", asks, agg.AggregateAsks(levelSlice(ob.GetAsks()))},
+		}
+		for _, c := range checks {
+			if len(c.got) != len(c.expected) {
+				t.Errorf("fixed=%v %s: Expected %d buckets, got %d", fixed, c.side, len(c.expected), len(c.got))
+				continue
+			}
+			for i := range c.expected {
+				if !c.got[i].Price.Equal(c.expected[i].Price) || !c.got[i].Quantity.Equal(c.expected[i].Quantity) {
+					t.Errorf("fixed=%v %s bucket %d: Expected %s@%s, got %s@%s", fixed, c.side, i,
+						c.expected[i].Price, c.expected[i].Quantity, c.got[i].Price, c.got[i].Quantity)
+					break
+				}
+			}
+		}
+	}
+}
+
+// Benchmarks
+
+func benchmarkHandleDepthUpdate(b *testing.B, fixed bool) {
+	snapshot := makeSnapshot(5000)
+	updates := makeUpdates(1000, 20)
+	quietLog(b)
+	ob := newLoadedBook(b, fixed, snapshot)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		update := *updates[i%len(updates)]
+		update.PrevUpdateID = ob.lastUpdateID
+		update.FirstUpdateID = ob.lastUpdateID + 1
+		update.FinalUpdateID = ob.lastUpdateID + 1
+		ob.HandleDepthUpdate(&update)
+	}
+}
+
+func BenchmarkHandleDepthUpdateDecimal(b *testing.B) {
+	benchmarkHandleDepthUpdate(b, false)
+}
+
+func BenchmarkHandleDepthUpdateFixedPoint(b *testing.B) {
+	benchmarkHandleDepthUpdate(b, true)
+}
+
+func TestLoadComposite(t *testing.T) {
+	level := func(price, qty string) exchange.PriceLevel { return exchange.PriceLevel{Price: price, Quantity: qty} }
+	usdt := newLoadedBook(t, false, &exchange.Snapshot{
+		LastUpdateID: 1,
+		Bids:         []exchange.PriceLevel{level("99.9", "2"), level("99.8", "1")},
+		Asks:         []exchange.PriceLevel{level("100.1", "3")},
+	})
+	usdc := New()
+	composite := New()
+	legs := []CompositeLeg{{Book: usdc, Factor: decimal.RequireFromString("0.999")}}
+	if composite.LoadComposite(legs) || composite.IsInitialized() {
+		t.Fatalf("Expected no composite before any leg is initialized")
+	}
+	legs = append(legs, CompositeLeg{Book: usdt})
+	if !composite.LoadComposite(legs) || composite.GetStats().BestBid.String() != "99.9" {
+		t.Fatalf("Expected the initialized leg alone, got %+v", composite.GetStats())
+	}
+
+	// USDC prices convert to 99.9, 99.8001 and 100.0998, levels at the same price are summed
+	if err := usdc.LoadSnapshot(&exchange.Snapshot{
+		LastUpdateID: 1,
+		Bids:         []exchange.PriceLevel{level("100", "5"), level("99.9", "4")},
+		Asks:         []exchange.PriceLevel{level("100.2", "1")},
+	}); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	usdc.ProcessBufferedEvents()
+	if !composite.LoadComposite(legs) {
+		t.Fatalf("Expected the composite to load")
+	}
+	bids, asks := composite.levels()
+	sortLevels := func(levels []types.PriceLevel) string {
+		result := make([]string, len(levels))
+		for i, l := range levels {
+			result[i] = l.Price.String() + "x" + l.Quantity.String()
+		}
+		sort.Strings(result)
+		return fmt.Sprint(result)
+	}
+	if got, expected := sortLevels(bids), "[99.8001x4 99.8x1 99.9x7]"; got != expected {
+		t.Errorf("Expected bids %s, got %s", expected, got)
+	}
+	if got, expected := sortLevels(asks), "[100.0998x1 100.1x3]"; got != expected {
+		t.Errorf("Expected asks %s, got %s", expected, got)
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	ob := New()
+	ob.SetClock(fake)
+	ob.SetWarmup(WarmupConfig{Duration: 5 * time.Second, Events: 2})
+	if err := ob.LoadSnapshot(makeSnapshot(10)); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	ob.ProcessBufferedEvents()
+
+	updates := makeUpdates(4, 2)
+	ob.HandleDepthUpdate(updates[0])
+	ob.HandleDepthUpdate(updates[1])
+	if !ob.IsInitialized() || ob.IsReady() {
+		t.Errorf("Expected an initialized book still warming up before the duration elapsed")
+	}
+	fake.Advance(5 * time.Second)
+	if !ob.IsReady() {
+		t.Fatalf("Expected the book ready after 5s and 2 updates")
+	}
+
+	// A gap starts the warm-up over
+	ob.HandleDepthUpdate(updates[3])
+	fake.Advance(5 * time.Second)
+	if ob.IsReady() {
+		t.Errorf("Expected the book held back after a gap")
+	}
+
+	// Without a warm-up a book is ready once initialized
+	ob = newLoadedBook(t, false, makeSnapshot(10))
+	if !ob.IsReady() {
+		t.Errorf("Expected a book without warm-up to be ready")
+	}
+}
+
+func TestTimeWeightedAverages(t *testing.T) {
+	start := time.Unix(1700000040, 0)
+	tracker := newTWATracker([]time.Duration{time.Minute, time.Hour})
+	tracker.observe(start, averageSample{2, 10}, true)
+	tracker.observe(start.Add(30*time.Second), averageSample{4, 20}, true)
+	tracker.observe(start.Add(time.Minute), averageSample{}, false)
+
+	tests := []struct {
+		at        time.Duration
+		window    int
+		spreadBps string
+		bidLiq    string
+		covered   time.Duration
+	}{
+		{time.Minute, 0, "3", "15", time.Minute},
+		{90 * time.Second, 0, "4", "20", 30 * time.Second}, // The first half left the window, the pause is not counted
+		{90 * time.Second, 1, "3", "15", time.Minute},
+		{3 * time.Minute, 0, "0", "0", 0},
+	}
+
+	for _, tt := range tests {
+		averages := tracker.averages(start.Add(tt.at))
+		avg := averages[tt.window]
+		if avg.SpreadBps.String() != tt.spreadBps || avg.BidLiquidity05Pct.String() != tt.bidLiq || avg.Covered != tt.covered {
+			t.Errorf("%v window at %v: Expected spread %s, liquidity %s over %v, got %s, %s over %v",
+				avg.Window, tt.at, tt.spreadBps, tt.bidLiq, tt.covered, avg.SpreadBps, avg.BidLiquidity05Pct, avg.Covered)
+		}
+	}
+
+	ob := New()
+	if err := ob.SetAverageWindows([]time.Duration{time.Hour, time.Minute}); err == nil {
+		t.Errorf("Expected an error for descending windows")
+	}
+	if err := ob.SetAverageWindows([]time.Duration{time.Minute}); err != nil || len(ob.GetStats().Averages) != 1 {
+		t.Errorf("Expected one average window, got %v (%v)", ob.GetStats().Averages, err)
+	}
+}
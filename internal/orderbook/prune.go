@@ -0,0 +1,111 @@
+package orderbook
+
+import (
+	"sort"
+	"time"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// PruneConfig bounds the memory used by an orderbook
+type PruneConfig struct {
+	MaxDistancePct float64 // Drop levels further than this fraction from mid (e.g., 0.5 = 50%), 0 disables
+	MaxLevels      int     // Keep at most this many levels per side (closest to mid), 0 disables
+}
+
+// SetPruneConfig updates the pruning limits applied by Prune
+func (ob *OrderBook) SetPruneConfig(config PruneConfig) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.pruneConfig = config
+}
+
+// Prune removes levels beyond the configured distance from mid and caps the
+// number of levels per side. It returns the number of levels removed.
+func (ob *OrderBook) Prune() int {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	// A top-of-book feed holds a single level per side
+	if !ob.initialized || ob.capabilities.TopOfBook {
+		return 0
+	}
+	before := ob.captureState()
+
+	if ob.fixed != nil {
+		pruned := ob.fixed.prune(ob.pruneConfig, ob.midPrice())
+		if pruned > 0 {
+			ob.stats.PrunedLevels += int64(pruned)
+			ob.updateStats()
+			ob.emitDiff(before, time.Time{}, false)
+			ob.publishView()
+		}
+		return pruned
+	}
+
+	pruned := 0
+
+	if ob.pruneConfig.MaxDistancePct > 0 {
+		mid := ob.midPrice()
+		if !mid.IsZero() {
+			maxDistance := mid.Mul(decimal.NewFromFloat(ob.pruneConfig.MaxDistancePct))
+			minBid := mid.Sub(maxDistance)
+			maxAsk := mid.Add(maxDistance)
+
+			for key, level := range ob.bids {
+				if level.Price.LessThan(minBid) {
+					delete(ob.bids, key)
+					pruned++
+				}
+			}
+			for key, level := range ob.asks {
+				if level.Price.GreaterThan(maxAsk) {
+					delete(ob.asks, key)
+					pruned++
+				}
+			}
+		}
+	}
+
+	if ob.pruneConfig.MaxLevels > 0 {
+		pruned += capLevels(ob.bids, ob.pruneConfig.MaxLevels, true)
+		pruned += capLevels(ob.asks, ob.pruneConfig.MaxLevels, false)
+	}
+
+	if pruned > 0 {
+		ob.stats.PrunedLevels += int64(pruned)
+		ob.updateStats()
+		ob.emitDiff(before, time.Time{}, false)
+		ob.publishView()
+	}
+
+	return pruned
+}
+
+// capLevels keeps only the maxLevels best levels of a side and returns the number removed
+func capLevels(levels map[string]types.PriceLevel, maxLevels int, isBid bool) int {
+	excess := len(levels) - maxLevels
+	if excess <= 0 {
+		return 0
+	}
+
+	keys := make([]string, 0, len(levels))
+	for key := range levels {
+		keys = append(keys, key)
+	}
+
+	// Sort worst-first so the excess levels are at the front
+	sort.Slice(keys, func(i, j int) bool {
+		if isBid {
+			return levels[keys[i]].Price.LessThan(levels[keys[j]].Price)
+		}
+		return levels[keys[i]].Price.GreaterThan(levels[keys[j]].Price)
+	})
+
+	for _, key := range keys[:excess] {
+		delete(levels, key)
+	}
+	return excess
+}
@@ -0,0 +1,73 @@
+package orderbook
+
+import "testing"
+
+func TestPruneCapsLevels(t *testing.T) {
+	for _, fixed := range []bool{false, true} {
+		// 20 bids and 5 asks: the cap only trims the bids
+		snapshot := makeSnapshot(20)
+		snapshot.Asks = snapshot.Asks[:5]
+		ob := newLoadedBook(t, fixed, snapshot)
+		ob.SetPruneConfig(PruneConfig{MaxLevels: 10})
+
+		if pruned := ob.Prune(); pruned != 10 {
+			t.Errorf("fixed=%v: Expected 10 bids pruned, got %d", fixed, pruned)
+		}
+		view := ob.View()
+		if len(ob.GetBids()) != 10 || len(ob.GetAsks()) != 5 || len(view.Bids) != 10 || len(view.Asks) != 5 {
+			t.Fatalf("fixed=%v: Expected 10 bids and 5 asks in the book and its view, got %d/%d and %d/%d",
+				fixed, len(ob.GetBids()), len(ob.GetAsks()), len(view.Bids), len(view.Asks))
+		}
+		// The best levels are kept, the furthest are dropped
+		if got := view.Bids[0].Price.String(); got != "50000" {
+			t.Errorf("fixed=%v: Expected best bid 50000, got %s", fixed, got)
+		}
+		if got := view.Bids[9].Price.String(); got != "49999.1" {
+			t.Errorf("fixed=%v: Expected worst kept bid 49999.1, got %s", fixed, got)
+		}
+		if got := view.Asks[0].Price.String(); got != "50000.1" {
+			t.Errorf("fixed=%v: Expected best ask 50000.1, got %s", fixed, got)
+		}
+		stats := ob.GetStats()
+		if stats.BidLevels != 10 || stats.AskLevels != 5 || stats.PrunedLevels != 10 || stats.BestBid.String() != "50000" {
+			t.Errorf("fixed=%v: Expected stats of the pruned book, got %d/%d levels, %d pruned, best bid %s",
+				fixed, stats.BidLevels, stats.AskLevels, stats.PrunedLevels, stats.BestBid)
+		}
+
+		// A book within the cap is left alone
+		if pruned := ob.Prune(); pruned != 0 {
+			t.Errorf("fixed=%v: Expected nothing left to prune, got %d", fixed, pruned)
+		}
+	}
+}
+
+func TestPruneByDistance(t *testing.T) {
+	decimalBook := newLoadedBook(t, false, makeSnapshot(100))
+	fixedBook := newLoadedBook(t, true, makeSnapshot(100))
+
+	// Mid 50000.05, levels within 0.5 of it are kept on each side
+	config := PruneConfig{MaxDistancePct: 0.00001}
+	decimalBook.SetPruneConfig(config)
+	fixedBook.SetPruneConfig(config)
+	if pruned := decimalBook.Prune(); pruned != 190 {
+		t.Errorf("Expected 190 levels pruned, got %d", pruned)
+	}
+	if pruned := fixedBook.Prune(); pruned != 190 {
+		t.Errorf("fixed: Expected 190 levels pruned, got %d", pruned)
+	}
+
+	// Both engines publish the same pruned view
+	dv, fv := decimalBook.View(), fixedBook.View()
+	if len(dv.Bids) != 5 || len(dv.Asks) != 5 || len(fv.Bids) != 5 || len(fv.Asks) != 5 {
+		t.Fatalf("Expected 5 levels per side, got %d/%d and fixed %d/%d", len(dv.Bids), len(dv.Asks), len(fv.Bids), len(fv.Asks))
+	}
+	for i := range dv.Bids {
+		if !dv.Bids[i].Price.Equal(fv.Bids[i].Price) || !dv.Bids[i].Quantity.Equal(fv.Bids[i].Quantity) ||
+			!dv.Asks[i].Price.Equal(fv.Asks[i].Price) || !dv.Asks[i].Quantity.Equal(fv.Asks[i].Quantity) {
+			t.Errorf("Level %d differs: bids %v vs %v, asks %v vs %v", i, dv.Bids[i], fv.Bids[i], dv.Asks[i], fv.Asks[i])
+		}
+	}
+	if got := dv.Bids[4].Price.String(); got != "49999.6" {
+		t.Errorf("Expected the furthest kept bid at 49999.6, got %s", got)
+	}
+}
@@ -0,0 +1,135 @@
+package orderbook
+
+import (
+	"sort"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// Side selects one side of the book
+type Side int
+
+const (
+	Bids Side = iota
+	Asks
+)
+
+// Range calls fn for every level of side priced within [from, to], best price first
+// (bids descending, asks ascending), until fn returns false. A zero to leaves the
+// range unbounded above. fn runs under the book's read lock and must not call
+// other OrderBook methods.
+func (ob *OrderBook) Range(side Side, from, to decimal.Decimal, fn func(level types.PriceLevel) bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	if ob.fixed != nil {
+		ob.fixed.rangeLevels(side == Bids, from, to, fn)
+		return
+	}
+
+	levels := ob.asks
+	if side == Bids {
+		levels = ob.bids
+	}
+
+	matched := make([]types.PriceLevel, 0, 64)
+	for _, level := range levels {
+		if level.Price.LessThan(from) || (!to.IsZero() && level.Price.GreaterThan(to)) {
+			continue
+		}
+		matched = append(matched, level)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		if side == Bids {
+			return matched[i].Price.GreaterThan(matched[j].Price)
+		}
+		return matched[i].Price.LessThan(matched[j].Price)
+	})
+
+	for _, level := range matched {
+		if !fn(level) {
+			return
+		}
+	}
+}
+
+// GetBidsFiltered returns the bids kept by filter, best first: within
+// MaxDistancePct of mid and at least MinQuantity. Readers that only need the
+// book near the touch skip the long tail of dust levels of full-depth feeds.
+// The distance is not filtered while the book is one-sided.
+func (ob *OrderBook) GetBidsFiltered(filter FilterConfig) []types.PriceLevel {
+	return ob.filteredLevels(Bids, filter)
+}
+
+// GetAsksFiltered returns the asks kept by filter, best first, as GetBidsFiltered
+func (ob *OrderBook) GetAsksFiltered(filter FilterConfig) []types.PriceLevel {
+	return ob.filteredLevels(Asks, filter)
+}
+
+// filteredLevels collects the levels of side kept by filter, bounding the price
+// range by the distance from mid so levels beyond it are not visited
+func (ob *OrderBook) filteredLevels(side Side, filter FilterConfig) []types.PriceLevel {
+	mid := ob.MidPrice()
+
+	from, to := decimal.Zero, decimal.Zero
+	if filter.MaxDistancePct > 0 && !mid.IsZero() {
+		distance := mid.Mul(decimal.NewFromFloat(filter.MaxDistancePct))
+		if side == Bids {
+			from = mid.Sub(distance)
+		} else {
+			to = mid.Add(distance)
+		}
+	}
+	minQuantity := decimal.NewFromFloat(filter.MinQuantity)
+
+	var levels []types.PriceLevel
+	ob.Range(side, from, to, func(level types.PriceLevel) bool {
+		if level.Quantity.GreaterThanOrEqual(minQuantity) {
+			levels = append(levels, level)
+		}
+		return true
+	})
+	return levels
+}
+
+// rangeLevels implements Range for the fixed-point engine, comparing integer prices
+func (fb *fixedBook) rangeLevels(isBid bool, from, to decimal.Decimal, fn func(level types.PriceLevel) bool) {
+	levels := fb.asks
+	if isBid {
+		levels = fb.bids
+	}
+
+	low := fb.priceScale.FromDecimalCeil(from)
+	high := int64(-1)
+	if !to.IsZero() {
+		high = fb.priceScale.FromDecimalFloor(to)
+	}
+
+	prices := make([]int64, 0, 64)
+	for price := range levels {
+		if price < low || (high >= 0 && price > high) {
+			continue
+		}
+		prices = append(prices, price)
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		if isBid {
+			return prices[i] > prices[j]
+		}
+		return prices[i] < prices[j]
+	})
+
+	for _, price := range prices {
+		level := types.PriceLevel{
+			Price:    fb.priceScale.ToDecimal(price),
+			Quantity: fb.qtyScale.ToDecimal(levels[price]),
+		}
+		if !fn(level) {
+			return
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package orderbook
+
+import (
+	"sort"
+	"sync"
+)
+
+// BookKey identifies a book by exchange and symbol
+type BookKey struct {
+	Exchange string
+	Symbol   string
+}
+
+func (k BookKey) String() string {
+	return k.Exchange + ":" + k.Symbol
+}
+
+// BookChangeKind tells whether a book was added to or removed from a registry
+type BookChangeKind string
+
+const (
+	BookAdded   BookChangeKind = "added"
+	BookRemoved BookChangeKind = "removed"
+)
+
+// BookChange is sent to registry subscribers when a book is set or deleted
+type BookChange struct {
+	Kind BookChangeKind
+	Key  BookKey
+	Book *OrderBook // The book added, or the one removed
+}
+
+// BookEntry is a registered book and its key
+type BookEntry struct {
+	Key  BookKey
+	Book *OrderBook
+}
+
+// BookRegistry holds the running books keyed by exchange and symbol. It is safe
+// for concurrent use and notifies subscribers of every change.
+type BookRegistry struct {
+	mu          sync.RWMutex
+	books       map[BookKey]*OrderBook
+	subscribers map[chan BookChange]struct{}
+}
+
+// NewBookRegistry creates an empty registry
+func NewBookRegistry() *BookRegistry {
+	return &BookRegistry{
+		books:       make(map[BookKey]*OrderBook),
+		subscribers: make(map[chan BookChange]struct{}),
+	}
+}
+
+// Set registers ob under key, replacing the book registered before
+func (r *BookRegistry) Set(key BookKey, ob *OrderBook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if old, ok := r.books[key]; ok && old != ob {
+		r.notify(BookChange{Kind: BookRemoved, Key: key, Book: old})
+	}
+	r.books[key] = ob
+	r.notify(BookChange{Kind: BookAdded, Key: key, Book: ob})
+}
+
+// Get returns the book registered under key
+func (r *BookRegistry) Get(key BookKey) (*OrderBook, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ob, ok := r.books[key]
+	return ob, ok
+}
+
+// Find returns the book of exchange for symbol. An empty symbol matches the first
+// symbol of the exchange in key order.
+func (r *BookRegistry) Find(exchange, symbol string) (*OrderBook, bool) {
+	if symbol != "" {
+		return r.Get(BookKey{Exchange: exchange, Symbol: symbol})
+	}
+	for _, entry := range r.List() {
+		if entry.Key.Exchange == exchange {
+			return entry.Book, true
+		}
+	}
+	return nil, false
+}
+
+// Delete removes the book registered under key and reports whether there was one
+func (r *BookRegistry) Delete(key BookKey) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ob, ok := r.books[key]
+	if !ok {
+		return false
+	}
+	delete(r.books, key)
+	r.notify(BookChange{Kind: BookRemoved, Key: key, Book: ob})
+	return true
+}
+
+// Clear removes every book
+func (r *BookRegistry) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, ob := range r.books {
+		delete(r.books, key)
+		r.notify(BookChange{Kind: BookRemoved, Key: key, Book: ob})
+	}
+}
+
+// Len returns the number of registered books
+func (r *BookRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.books)
+}
+
+// List returns the registered books sorted by exchange and symbol
+func (r *BookRegistry) List() []BookEntry {
+	r.mu.RLock()
+	entries := make([]BookEntry, 0, len(r.books))
+	for key, ob := range r.books {
+		entries = append(entries, BookEntry{Key: key, Book: ob})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key.Exchange != entries[j].Key.Exchange {
+			return entries[i].Key.Exchange < entries[j].Key.Exchange
+		}
+		return entries[i].Key.Symbol < entries[j].Key.Symbol
+	})
+	return entries
+}
+
+// Subscribe returns a channel receiving every change, buffered for buffer changes.
+// Changes are dropped while the channel is full. The returned function unsubscribes
+// and closes the channel.
+func (r *BookRegistry) Subscribe(buffer int) (<-chan BookChange, func()) {
+	ch := make(chan BookChange, buffer)
+	r.mu.Lock()
+	r.subscribers[ch] = struct{}{}
+	r.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.subscribers, ch)
+			r.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// notify sends change to every subscriber without blocking (must be called with mutex locked)
+func (r *BookRegistry) notify(change BookChange) {
+	for ch := range r.subscribers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
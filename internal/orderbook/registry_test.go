@@ -0,0 +1,63 @@
+package orderbook
+
+import "testing"
+
+func TestBookRegistry(t *testing.T) {
+	r := NewBookRegistry()
+	changes, unsubscribe := r.Subscribe(8)
+
+	okx := BookKey{Exchange: "okx", Symbol: "BTCUSDT"}
+	binance := BookKey{Exchange: "binance", Symbol: "BTCUSDT"}
+	binanceEth := BookKey{Exchange: "binance", Symbol: "ETHUSDT"}
+	first, replacement := New(), New()
+	r.Set(okx, New())
+	r.Set(binanceEth, New())
+	r.Set(binance, first)
+	r.Set(binance, replacement)
+
+	entries := r.List()
+	expected := []BookKey{binance, binanceEth, okx}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d books, got %d", len(expected), len(entries))
+	}
+	for i, key := range expected {
+		if entries[i].Key != key {
+			t.Errorf("Entry %d: Expected %s, got %s", i, key, entries[i].Key)
+		}
+	}
+	if ob, ok := r.Get(binance); !ok || ob != replacement {
+		t.Errorf("Expected the replacement book for %s", binance)
+	}
+	if ob, ok := r.Find("binance", ""); !ok || ob != replacement {
+		t.Errorf("Expected Find without a symbol to return the first symbol of the exchange")
+	}
+	if _, ok := r.Find("kraken", ""); ok {
+		t.Errorf("Expected no book for kraken")
+	}
+
+	if !r.Delete(okx) || r.Delete(okx) {
+		t.Errorf("Expected Delete to report only the first removal")
+	}
+	r.Clear()
+	if r.Len() != 0 {
+		t.Errorf("Expected an empty registry after Clear, got %d books", r.Len())
+	}
+
+	// okx, binanceEth, binance added; first replaced; okx deleted; two cleared
+	unsubscribe()
+	var added, removed int
+	for change := range changes {
+		switch change.Kind {
+		case BookAdded:
+			added++
+		case BookRemoved:
+			removed++
+			if change.Key == binance && change.Book != first && change.Book != replacement {
+				t.Errorf("Expected removed binance changes to carry the removed book")
+			}
+		}
+	}
+	if added != 4 || removed != 4 {
+		t.Errorf("Expected 4 added and 4 removed changes, got %d and %d", added, removed)
+	}
+}
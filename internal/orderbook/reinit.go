@@ -0,0 +1,31 @@
+package orderbook
+
+import "time"
+
+// DefaultReinitBufferSize is the number of buffered events past which
+// CheckAndReinitialize reloads the book, unless SetReinitConfig changes it
+const DefaultReinitBufferSize = 100
+
+// ReinitConfig tunes the reinitialization checks of a book
+type ReinitConfig struct {
+	CheckInterval time.Duration // Interval between CheckAndReinitialize calls, read by the caller's check loop; 0 keeps the caller's default
+	BufferSize    int           // Reload once more than this many events are buffered, 0 uses DefaultReinitBufferSize
+}
+
+// SetReinitConfig changes the reinitialization checks. It may be called at any
+// time; the new buffer size applies from the next check.
+func (ob *OrderBook) SetReinitConfig(config ReinitConfig) {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultReinitBufferSize
+	}
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.reinit = config
+}
+
+// ReinitConfig returns the reinitialization checks of the book
+func (ob *OrderBook) ReinitConfig() ReinitConfig {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.reinit
+}
@@ -0,0 +1,110 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"orderbook/internal/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrResumeGap is returned by Resume when the stream does not continue the
+// checkpoint and no shallow snapshot can bridge the gap
+var ErrResumeGap = errors.New("buffered events do not continue the checkpoint")
+
+// Resume initializes the orderbook from a checkpoint saved by a previous run and
+// the events buffered since the stream started, instead of a full snapshot. When
+// the events continue the checkpoint's lastUpdateID they are applied on top of
+// it. After a gap, getShallow (nil if the venue has no depth-limited snapshots)
+// fetches the depth levels nearest the touch: they replace the checkpoint's
+// levels within their price range, the deeper checkpoint levels are kept, and
+// the events continuing the shallow snapshot are applied. It fails when the gap
+// cannot be bridged, leaving the book to be loaded from a full snapshot.
+func (ob *OrderBook) Resume(checkpoint *exchange.Snapshot, depth int, getShallow func(depth int) (*exchange.Snapshot, error)) error {
+	ob.mu.Lock()
+	if ob.bufferContinues(checkpoint.LastUpdateID) {
+		defer ob.mu.Unlock()
+		if err := ob.loadSnapshot(checkpoint); err != nil {
+			return err
+		}
+		log.Printf("Resuming from checkpoint: lastUpdateId=%d continued by the stream", checkpoint.LastUpdateID)
+		ob.processBufferedEvents()
+		return nil
+	}
+	ob.mu.Unlock()
+
+	if getShallow == nil {
+		return ErrResumeGap
+	}
+	shallow, err := getShallow(depth)
+	if err != nil {
+		return fmt.Errorf("failed to get catch-up snapshot: %w", err)
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if err := ob.loadSnapshot(mergeShallow(checkpoint, shallow, depth)); err != nil {
+		return err
+	}
+	log.Printf("Resuming from checkpoint: caught up with a %d-level snapshot (lastUpdateId=%d)", depth, shallow.LastUpdateID)
+	ob.processBufferedEvents()
+	return nil
+}
+
+// bufferContinues reports whether the first buffered event not covered by
+// lastUpdateID follows it (must be called with mutex locked)
+func (ob *OrderBook) bufferContinues(lastUpdateID int64) bool {
+	ob.sortBufferedEvents()
+	for _, event := range ob.eventBuffer {
+		if event.FinalUpdateID <= lastUpdateID {
+			continue
+		}
+		return event.FirstUpdateID <= lastUpdateID+1 || event.PrevUpdateID == lastUpdateID
+	}
+	return false
+}
+
+// mergeShallow returns the shallow snapshot completed with the checkpoint levels
+// beyond its deepest price on each side. A side with fewer than depth levels is
+// the whole side and is kept as is.
+func mergeShallow(checkpoint, shallow *exchange.Snapshot, depth int) *exchange.Snapshot {
+	merged := *shallow
+	merged.Bids = mergeShallowSide(shallow.Bids, checkpoint.Bids, depth, true)
+	merged.Asks = mergeShallowSide(shallow.Asks, checkpoint.Asks, depth, false)
+	return &merged
+}
+
+// mergeShallowSide appends the checkpoint levels deeper than the deepest shallow level
+func mergeShallowSide(shallow, checkpoint []exchange.PriceLevel, depth int, isBid bool) []exchange.PriceLevel {
+	if len(shallow) == 0 || len(shallow) < depth {
+		return shallow
+	}
+	var deepest decimal.Decimal
+	found := false
+	for _, level := range shallow {
+		price, err := decimal.NewFromString(level.Price)
+		if err != nil {
+			continue
+		}
+		if !found || (isBid && price.LessThan(deepest)) || (!isBid && price.GreaterThan(deepest)) {
+			deepest, found = price, true
+		}
+	}
+	if !found {
+		return shallow
+	}
+
+	merged := append([]exchange.PriceLevel(nil), shallow...)
+	for _, level := range checkpoint {
+		price, err := decimal.NewFromString(level.Price)
+		if err != nil {
+			continue
+		}
+		if (isBid && price.LessThan(deepest)) || (!isBid && price.GreaterThan(deepest)) {
+			merged = append(merged, level)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,110 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+
+	"orderbook/internal/exchange"
+)
+
+func TestResume(t *testing.T) {
+	update := func(id int64, bids ...exchange.PriceLevel) *exchange.DepthUpdate {
+		return &exchange.DepthUpdate{
+			Exchange:      exchange.Binancef,
+			Symbol:        "BTCUSDT",
+			FirstUpdateID: id,
+			FinalUpdateID: id,
+			PrevUpdateID:  id - 1,
+			Bids:          bids,
+		}
+	}
+	checkpoint := makeSnapshot(10)
+	checkpoint.LastUpdateID = 10
+
+	t.Run("buffered events continue the checkpoint", func(t *testing.T) {
+		ob := New()
+		for _, id := range []int64{12, 9, 11} {
+			ob.HandleDepthUpdate(update(id, exchange.PriceLevel{Price: "50000.00", Quantity: "5"}))
+		}
+		err := ob.Resume(checkpoint, 3, func(int) (*exchange.Snapshot, error) {
+			t.Errorf("Expected no catch-up snapshot when the stream continues the checkpoint")
+			return nil, errors.New("unexpected")
+		})
+		if err != nil {
+			t.Fatalf("Resume() failed: %v", err)
+		}
+		if !ob.IsInitialized() {
+			t.Errorf("Expected the book to be initialized")
+		}
+		if got := ob.GetLastUpdateID(); got != 12 {
+			t.Errorf("Expected lastUpdateID 12, got %d", got)
+		}
+		if got := ob.GetBufferLength(); got != 0 {
+			t.Errorf("Expected an empty buffer, got %d events", got)
+		}
+		if got := len(ob.GetBids()); got != 10 {
+			t.Errorf("Expected the 10 checkpoint bids, got %d", got)
+		}
+	})
+
+	t.Run("gap bridged by a shallow snapshot", func(t *testing.T) {
+		ob := New()
+		ob.HandleDepthUpdate(update(20, exchange.PriceLevel{Price: "49999.90", Quantity: "7"}))
+
+		shallow := &exchange.Snapshot{
+			Exchange:     exchange.Binancef,
+			Symbol:       "BTCUSDT",
+			LastUpdateID: 19,
+			Bids: []exchange.PriceLevel{
+				{Price: "50000.00", Quantity: "2"},
+				{Price: "49999.90", Quantity: "0.5"},
+				{Price: "49999.80", Quantity: "1"},
+			},
+			Asks: checkpoint.Asks[:3],
+		}
+		requested := 0
+		err := ob.Resume(checkpoint, 3, func(depth int) (*exchange.Snapshot, error) {
+			requested = depth
+			return shallow, nil
+		})
+		if err != nil {
+			t.Fatalf("Resume() failed: %v", err)
+		}
+		if requested != 3 {
+			t.Errorf("Expected a 3-level catch-up snapshot, got %d", requested)
+		}
+		if got := ob.GetLastUpdateID(); got != 20 {
+			t.Errorf("Expected lastUpdateID 20, got %d", got)
+		}
+
+		// The shallow levels replace the checkpoint near the touch, the deeper
+		// checkpoint levels are kept and the buffered event is applied
+		bids := ob.GetBids()
+		if len(bids) != 10 {
+			t.Errorf("Expected 3 shallow and 7 checkpoint bids, got %d", len(bids))
+		}
+		if qty := bids["50000.00"].Quantity.String(); qty != "2" {
+			t.Errorf("Expected the shallow quantity 2 at 50000.00, got %s", qty)
+		}
+		if qty := bids["49999.90"].Quantity.String(); qty != "7" {
+			t.Errorf("Expected the buffered quantity 7 at 49999.90, got %s", qty)
+		}
+		if got := len(ob.GetAsks()); got != 10 {
+			t.Errorf("Expected 3 shallow and 7 checkpoint asks, got %d", got)
+		}
+	})
+
+	t.Run("gap without catch-up snapshots", func(t *testing.T) {
+		ob := New()
+		ob.HandleDepthUpdate(update(20))
+		if err := ob.Resume(checkpoint, 3, nil); !errors.Is(err, ErrResumeGap) {
+			t.Errorf("Expected ErrResumeGap, got %v", err)
+		}
+		if ob.IsInitialized() {
+			t.Errorf("Expected the book to stay uninitialized for a full snapshot")
+		}
+		if got := ob.GetBufferLength(); got != 1 {
+			t.Errorf("Expected the buffered event to be kept, got %d", got)
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package orderbook
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultSpreadHorizons are the realized spread horizons used when none are configured
+var DefaultSpreadHorizons = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}
+
+// DefaultSpreadWindow is the number of trades kept in each rolling spread average
+const DefaultSpreadWindow = 500
+
+var bpsMultiplier = decimal.NewFromInt(20000) // 2 * 10000, spreads are quoted round-trip in bps
+
+// pendingTrade is a trade waiting for its realized spread horizons to elapse
+type pendingTrade struct {
+	price     decimal.Decimal
+	sign      decimal.Decimal // +1 for buys, -1 for sells
+	mid       decimal.Decimal // prevailing mid at trade time
+	tradeTime time.Time
+	resolved  int // number of horizons already resolved (horizons are sorted ascending)
+}
+
+// midSample is the mid price from a point in time until the next sample
+type midSample struct {
+	mid decimal.Decimal
+	at  time.Time
+}
+
+// spreadEstimator computes rolling effective and realized spreads from trades
+type spreadEstimator struct {
+	horizons  []time.Duration
+	window    int
+	effective []decimal.Decimal
+	realized  [][]decimal.Decimal // one rolling window per horizon
+	pending   []*pendingTrade
+	// Mid history covering the longest horizon, oldest first. Trades arrive on
+	// their own stream, so they are matched with the mid prevailing at their
+	// trade time and at each horizon rather than with the latest one.
+	mids []midSample
+}
+
+// newSpreadEstimator creates an estimator for the given horizons (must be sorted ascending)
+func newSpreadEstimator(horizons []time.Duration, window int) *spreadEstimator {
+	if window <= 0 {
+		window = DefaultSpreadWindow
+	}
+	return &spreadEstimator{
+		horizons: horizons,
+		window:   window,
+		realized: make([][]decimal.Decimal, len(horizons)),
+	}
+}
+
+// recordTrade registers a trade against the mid prevailing before its trade time,
+// or against mid when the history does not reach back to it
+func (s *spreadEstimator) recordTrade(price decimal.Decimal, side exchange.TradeSide, mid decimal.Decimal, tradeTime time.Time) {
+	if prevailing, ok := s.midBefore(tradeTime); ok {
+		mid = prevailing
+	}
+	if mid.IsZero() {
+		return
+	}
+
+	sign := decimal.NewFromInt(1)
+	if side == exchange.TradeSideSell {
+		sign = decimal.NewFromInt(-1)
+	}
+
+	// Effective spread: 2 * side * (price - mid) / mid
+	effective := sign.Mul(price.Sub(mid)).Div(mid).Mul(bpsMultiplier)
+	s.effective = appendRolling(s.effective, effective, s.window)
+
+	if len(s.horizons) > 0 {
+		s.pending = append(s.pending, &pendingTrade{
+			price:     price,
+			sign:      sign,
+			mid:       mid,
+			tradeTime: tradeTime,
+		})
+	}
+}
+
+// observeMid adds the current mid price to the history and resolves pending
+// trades whose horizons have elapsed with the mid prevailing at each horizon
+func (s *spreadEstimator) observeMid(mid decimal.Decimal, now time.Time) {
+	if mid.IsZero() {
+		return
+	}
+	s.addMid(mid, now)
+
+	remaining := s.pending[:0]
+	for _, trade := range s.pending {
+		for trade.resolved < len(s.horizons) && !now.Before(trade.tradeTime.Add(s.horizons[trade.resolved])) {
+			after, ok := s.midAt(trade.tradeTime.Add(s.horizons[trade.resolved]))
+			if !ok {
+				after = mid
+			}
+			// Realized spread: 2 * side * (price - mid_after) / mid_at_trade
+			realized := trade.sign.Mul(trade.price.Sub(after)).Div(trade.mid).Mul(bpsMultiplier)
+			s.realized[trade.resolved] = appendRolling(s.realized[trade.resolved], realized, s.window)
+			trade.resolved++
+		}
+		if trade.resolved < len(s.horizons) {
+			remaining = append(remaining, trade)
+		}
+	}
+	s.pending = remaining
+}
+
+// addMid appends a mid to the history and expires the samples no horizon can
+// reach anymore, keeping the one prevailing at the start of the longest horizon
+func (s *spreadEstimator) addMid(mid decimal.Decimal, now time.Time) {
+	if n := len(s.mids); n > 0 && now.Before(s.mids[n-1].at) {
+		// Out of order; the history stays sorted
+		return
+	}
+	s.mids = append(s.mids, midSample{mid: mid, at: now})
+
+	var longest time.Duration
+	if len(s.horizons) > 0 {
+		longest = s.horizons[len(s.horizons)-1]
+	}
+	cutoff := now.Add(-longest)
+	expired := 0
+	for expired+1 < len(s.mids) && !s.mids[expired+1].at.After(cutoff) {
+		expired++
+	}
+	if expired > 0 {
+		s.mids = append(s.mids[:0], s.mids[expired:]...)
+	}
+}
+
+// midAt returns the mid prevailing at t: the latest sample at or before it.
+// It reports false when the history starts after t.
+func (s *spreadEstimator) midAt(t time.Time) (decimal.Decimal, bool) {
+	return s.latestMid(sort.Search(len(s.mids), func(i int) bool { return s.mids[i].at.After(t) }))
+}
+
+// midBefore returns the latest mid sampled strictly before t, which excludes
+// updates stamped with the time of a trade that caused them
+func (s *spreadEstimator) midBefore(t time.Time) (decimal.Decimal, bool) {
+	return s.latestMid(sort.Search(len(s.mids), func(i int) bool { return !s.mids[i].at.Before(t) }))
+}
+
+// latestMid returns the sample preceding index end, false if there is none
+func (s *spreadEstimator) latestMid(end int) (decimal.Decimal, bool) {
+	if end == 0 {
+		return decimal.Zero, false
+	}
+	return s.mids[end-1].mid, true
+}
+
+// effectiveBps returns the rolling average effective spread in basis points
+func (s *spreadEstimator) effectiveBps() decimal.Decimal {
+	return average(s.effective)
+}
+
+// realizedBps returns the rolling average realized spread per horizon in basis points
+func (s *spreadEstimator) realizedBps() []types.HorizonSpread {
+	result := make([]types.HorizonSpread, len(s.horizons))
+	for i, horizon := range s.horizons {
+		result[i] = types.HorizonSpread{
+			Horizon: horizon,
+			Bps:     average(s.realized[i]),
+		}
+	}
+	return result
+}
+
+// appendRolling appends a sample and trims the slice to the window size
+func appendRolling(samples []decimal.Decimal, sample decimal.Decimal, window int) []decimal.Decimal {
+	samples = append(samples, sample)
+	if len(samples) > window {
+		samples = samples[len(samples)-window:]
+	}
+	return samples
+}
+
+// average returns the arithmetic mean of the samples, or zero if empty
+func average(samples []decimal.Decimal) decimal.Decimal {
+	if len(samples) == 0 {
+		return decimal.Zero
+	}
+	sum := decimal.Zero
+	for _, sample := range samples {
+		sum = sum.Add(sample)
+	}
+	return sum.Div(decimal.NewFromInt(int64(len(samples))))
+}
+
+// SetSpreadHorizons configures the realized spread horizons and rolling window size
+func (ob *OrderBook) SetSpreadHorizons(horizons []time.Duration, window int) error {
+	for i, horizon := range horizons {
+		if horizon <= 0 {
+			return fmt.Errorf("invalid spread horizon %v", horizon)
+		}
+		if i > 0 && horizon <= horizons[i-1] {
+			return fmt.Errorf("spread horizons must be strictly ascending: %v", horizons)
+		}
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.spreads = newSpreadEstimator(horizons, window)
+	ob.updateSpreadStats()
+	return nil
+}
+
+// RecordTrade feeds a trade into the effective/realized spread estimator
+func (ob *OrderBook) RecordTrade(trade *exchange.Trade) error {
+	price, err := decimal.NewFromString(trade.Price)
+	if err != nil {
+		return fmt.Errorf("invalid trade price %s: %w", trade.Price, err)
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if !ob.initialized {
+		return nil
+	}
+
+	tradeTime := trade.TradeTime
+	if tradeTime.IsZero() {
+		tradeTime = ob.clock.Now()
+	}
+
+	ob.spreads.recordTrade(price, trade.Side, ob.midPrice(), tradeTime)
+	ob.updateSpreadStats()
+	return nil
+}
+
+// MidPrice returns the mid of the best bid and ask, zero while the book is one-sided
+func (ob *OrderBook) MidPrice() decimal.Decimal {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.midPrice()
+}
+
+// midPrice returns the current mid price or zero if the book is one-sided (must be called with mutex locked)
+func (ob *OrderBook) midPrice() decimal.Decimal {
+	if ob.bestBid.IsZero() || ob.bestAsk.IsZero() {
+		return decimal.Zero
+	}
+	return ob.bestBid.Add(ob.bestAsk).Div(decimal.NewFromInt(2))
+}
+
+// updateSpreadStats copies the estimator output into stats (must be called with mutex locked)
+func (ob *OrderBook) updateSpreadStats() {
+	ob.stats.EffectiveSpreadBps = ob.spreads.effectiveBps()
+	ob.stats.RealizedSpreadBps = ob.spreads.realizedBps()
+}
@@ -0,0 +1,133 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSpreadEstimator(t *testing.T) {
+	d := decimal.RequireFromString
+	t0 := time.UnixMilli(1700000000000)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+
+	s := newSpreadEstimator([]time.Duration{time.Second, 5 * time.Second}, 10)
+	s.observeMid(d("100"), at(0))
+
+	// Effective spread against the mid before each trade: 2 * 0.05 / 100 is
+	// 10 bps for the buy and 2 * 0.1 / 100 is 20 bps for the sell
+	s.recordTrade(d("100.05"), exchange.TradeSideBuy, d("100"), at(100))
+	s.recordTrade(d("99.9"), exchange.TradeSideSell, d("100"), at(200))
+	if got := s.effectiveBps(); !got.Equal(d("15")) {
+		t.Errorf("Expected effective spread 15 bps, got %s", got)
+	}
+
+	// Realized spreads use the mid prevailing at each horizon, not the latest:
+	// the buy's 1s horizon (1.1s) is at mid 100.1, the sell's (1.2s) at 100.2
+	s.observeMid(d("100.1"), at(500))
+	s.observeMid(d("100.2"), at(1150))
+	s.observeMid(d("100.2"), at(3000))
+	// Buy: 2 * (100.05 - 100.1) / 100 is -10 bps; sell: -2 * (99.9 - 100.2) / 100 is 60 bps
+	if got := s.realizedBps()[0].Bps; !got.Equal(d("25")) {
+		t.Errorf("Expected 1s realized spread 25 bps, got %s", got)
+	}
+	if got := s.realizedBps()[1].Bps; !got.IsZero() {
+		t.Errorf("Expected no 5s realized spread yet, got %s", got)
+	}
+
+	// The 5s horizons (5.1s and 5.2s) resolve at mid 100.2, before the move to 99.9:
+	// -30 bps for the buy and 60 bps for the sell
+	s.observeMid(d("99.9"), at(6000))
+	if got := s.realizedBps()[1].Bps; !got.Equal(d("15")) {
+		t.Errorf("Expected 5s realized spread 15 bps, got %s", got)
+	}
+	if len(s.pending) != 0 {
+		t.Errorf("Expected resolved trades to be dropped, %d pending", len(s.pending))
+	}
+
+	// Mids older than the longest horizon expire, except the one prevailing at its start
+	if len(s.mids) != 4 {
+		t.Errorf("Expected 4 mids within 5s of 6s, got %d", len(s.mids))
+	}
+	s.observeMid(d("99.8"), at(20000))
+	if len(s.mids) != 2 {
+		t.Fatalf("Expected the mids of 6s and 20s, got %d", len(s.mids))
+	}
+	if mid, ok := s.midAt(at(15000)); !ok || !mid.Equal(d("99.9")) {
+		t.Errorf("Expected mid 99.9 at 15s, got %s (%v)", mid, ok)
+	}
+	if _, ok := s.midAt(at(1000)); ok {
+		t.Errorf("Expected no mid at 1s after expiry")
+	}
+}
+
+func TestSpreadEstimatorRollingWindow(t *testing.T) {
+	d := decimal.RequireFromString
+	s := newSpreadEstimator(nil, 2)
+
+	// Without history the trades are measured against the given mid
+	s.recordTrade(d("101"), exchange.TradeSideBuy, d("100"), time.Time{})   // 200 bps
+	s.recordTrade(d("100.5"), exchange.TradeSideBuy, d("100"), time.Time{}) // 100 bps
+	s.recordTrade(d("99.5"), exchange.TradeSideSell, d("100"), time.Time{}) // 100 bps
+	if got := s.effectiveBps(); !got.Equal(d("100")) {
+		t.Errorf("Expected the oldest trade to expire from the window, got %s bps", got)
+	}
+	if len(s.pending) != 0 {
+		t.Errorf("Expected no pending trades without horizons, got %d", len(s.pending))
+	}
+}
+
+func TestRecordTrade(t *testing.T) {
+	t0 := time.UnixMilli(1700000000000)
+	update := func(id int64, ms int, asks ...exchange.PriceLevel) *exchange.DepthUpdate {
+		return &exchange.DepthUpdate{
+			Exchange:      exchange.Binancef,
+			Symbol:        "BTCUSDT",
+			EventTime:     t0.Add(time.Duration(ms) * time.Millisecond),
+			FirstUpdateID: id,
+			FinalUpdateID: id,
+			PrevUpdateID:  id - 1,
+			Asks:          asks,
+		}
+	}
+
+	// Trades before the book is loaded are ignored
+	empty := New()
+	if err := empty.RecordTrade(&exchange.Trade{Price: "50000", Side: exchange.TradeSideBuy}); err != nil {
+		t.Errorf("RecordTrade() failed: %v", err)
+	}
+	if got := empty.GetStats().EffectiveSpreadBps; !got.IsZero() {
+		t.Errorf("Expected no effective spread before the book is loaded, got %s", got)
+	}
+
+	// Mid 50000.05
+	ob := newLoadedBook(t, false, makeSnapshot(10))
+	if err := ob.SetSpreadHorizons([]time.Duration{time.Second}, 10); err != nil {
+		t.Fatalf("SetSpreadHorizons() failed: %v", err)
+	}
+	ob.HandleDepthUpdate(update(2, 0))
+
+	if err := ob.RecordTrade(&exchange.Trade{Price: "bad"}); err == nil {
+		t.Errorf("Expected an error for an invalid trade price")
+	}
+	err := ob.RecordTrade(&exchange.Trade{Price: "50000.15", Side: exchange.TradeSideBuy, TradeTime: t0.Add(100 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("RecordTrade() failed: %v", err)
+	}
+	// 2 * 0.1 / 50000.05
+	if got := ob.GetStats().EffectiveSpreadBps.StringFixed(4); got != "0.0400" {
+		t.Errorf("Expected effective spread 0.0400 bps, got %s", got)
+	}
+
+	// The best ask is lifted (mid 50000.1), then an update past the horizon resolves the trade
+	ob.HandleDepthUpdate(update(3, 500, exchange.PriceLevel{Price: "50000.10", Quantity: "0"}))
+	ob.HandleDepthUpdate(update(4, 2000))
+	realized := ob.GetStats().RealizedSpreadBps
+	// 2 * (50000.15 - 50000.1) / 50000.05
+	if len(realized) != 1 || realized[0].Horizon != time.Second || realized[0].Bps.StringFixed(4) != "0.0200" {
+		t.Errorf("Expected 1s realized spread 0.0200 bps, got %+v", realized)
+	}
+}
@@ -0,0 +1,44 @@
+package orderbook
+
+import "time"
+
+// Throughput measurement parameters
+const (
+	rateWindow    = time.Second // Window over which EventsPerSecond is measured
+	applyTimeEWMA = 0.05        // Weight of the newest sample in the apply time average
+)
+
+// throughputMeter measures the update rate and apply cost of an orderbook
+type throughputMeter struct {
+	windowStart  time.Time
+	windowEvents int64
+	rate         float64       // Events per second over the last complete window
+	applyTime    time.Duration // Exponentially weighted average apply time
+}
+
+// observe records one applied update that took elapsed to apply
+func (m *throughputMeter) observe(now time.Time, elapsed time.Duration) {
+	if m.applyTime == 0 {
+		m.applyTime = elapsed
+	} else {
+		m.applyTime += time.Duration(applyTimeEWMA * float64(elapsed-m.applyTime))
+	}
+
+	if m.windowStart.IsZero() {
+		m.windowStart = now
+	}
+	m.windowEvents++
+	if span := now.Sub(m.windowStart); span >= rateWindow {
+		m.rate = float64(m.windowEvents) / span.Seconds()
+		m.windowStart = now
+		m.windowEvents = 0
+	}
+}
+
+// eventsPerSecond returns the last measured rate, or zero once the stream has gone quiet
+func (m *throughputMeter) eventsPerSecond(now time.Time) float64 {
+	if m.windowStart.IsZero() || now.Sub(m.windowStart) > 2*rateWindow {
+		return 0
+	}
+	return m.rate
+}
@@ -0,0 +1,39 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/logging"
+
+	"github.com/shopspring/decimal"
+)
+
+// errMalformedLevel is wrapped by the errors of levels rejected at ingestion
+var errMalformedLevel = errors.New("malformed level")
+
+// parseDecimalLevel parses a level, rejecting unparseable values, non-positive
+// prices and negative quantities. A zero quantity is a deletion.
+func parseDecimalLevel(level exchange.PriceLevel) (decimal.Decimal, decimal.Decimal, error) {
+	price, err := decimal.NewFromString(level.Price)
+	if err != nil || !price.IsPositive() {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("%w: price %q", errMalformedLevel, level.Price)
+	}
+	qty, err := decimal.NewFromString(level.Quantity)
+	if err != nil || qty.IsNegative() {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("%w: quantity %q", errMalformedLevel, level.Quantity)
+	}
+	return price, qty, nil
+}
+
+// countMalformed records the levels of one snapshot or update rejected at
+// ingestion (must be called with mutex locked)
+func (ob *OrderBook) countMalformed(rejected int, err error) {
+	if rejected == 0 {
+		return
+	}
+	ob.stats.MalformedLevels += int64(rejected)
+	ob.stats.MalformedMessages++
+	logging.Debugf("Rejected %d malformed levels, last: %v", rejected, err)
+}
@@ -0,0 +1,126 @@
+package orderbook
+
+import (
+	"sort"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/types"
+)
+
+// View is an immutable copy of the book published for lock-free readers.
+// Its slices are shared between readers and must not be modified.
+type View struct {
+	Bids         []types.PriceLevel // Sorted best first (descending)
+	Asks         []types.PriceLevel // Sorted best first (ascending)
+	Stats        types.Stats
+	LastUpdateID int64
+	PublishedAt  time.Time
+}
+
+// View returns the last published copy of the book without locking, or nil if
+// none has been published since the book was initialized
+func (ob *OrderBook) View() *View {
+	return ob.view.Load()
+}
+
+// PublishView publishes a copy of the current book for View readers. Writers call
+// it after each batch of updates (e.g., once the update channel is drained) rather
+// than after every update.
+func (ob *OrderBook) PublishView() {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	ob.publishView()
+}
+
+// publishView builds and swaps in a new view (must be called with mutex locked)
+func (ob *OrderBook) publishView() {
+	if !ob.initialized {
+		ob.view.Store(nil)
+		return
+	}
+
+	var bids, asks []types.PriceLevel
+	if ob.fixed != nil {
+		bids = ob.fixed.sortedLevels(true)
+		asks = ob.fixed.sortedLevels(false)
+	} else {
+		bids = sortedLevels(ob.bids, true)
+		asks = sortedLevels(ob.asks, false)
+	}
+
+	stats := ob.stats
+	stats.RealizedSpreadBps = append([]types.HorizonSpread(nil), ob.stats.RealizedSpreadBps...)
+
+	ob.view.Store(&View{
+		Bids:         bids,
+		Asks:         asks,
+		Stats:        stats,
+		LastUpdateID: ob.lastUpdateID,
+		PublishedAt:  ob.clock.Now(),
+	})
+}
+
+// Snapshot converts the view to a canonical snapshot of the full book stamped with at
+func (v *View) Snapshot(name exchange.ExchangeName, symbol string, at time.Time) *exchange.Snapshot {
+	return &exchange.Snapshot{
+		Exchange:     name,
+		Symbol:       symbol,
+		LastUpdateID: v.LastUpdateID,
+		Bids:         snapshotLevels(v.Bids),
+		Asks:         snapshotLevels(v.Asks),
+		Timestamp:    at,
+	}
+}
+
+// snapshotLevels converts levels to their canonical string form
+func snapshotLevels(levels []types.PriceLevel) []exchange.PriceLevel {
+	result := make([]exchange.PriceLevel, len(levels))
+	for i, level := range levels {
+		result[i] = exchange.PriceLevel{
+			Price:    level.Price.String(),
+			Quantity: level.Quantity.String(),
+		}
+	}
+	return result
+}
+
+// sortedLevels returns a side of the decimal book sorted best first
+func sortedLevels(levels map[string]types.PriceLevel, isBid bool) []types.PriceLevel {
+	result := levelSlice(levels)
+	sort.Slice(result, func(i, j int) bool {
+		if isBid {
+			return result[i].Price.GreaterThan(result[j].Price)
+		}
+		return result[i].Price.LessThan(result[j].Price)
+	})
+	return result
+}
+
+// sortedLevels returns a side of the fixed-point book sorted best first
+func (fb *fixedBook) sortedLevels(isBid bool) []types.PriceLevel {
+	side := fb.asks
+	if isBid {
+		side = fb.bids
+	}
+
+	prices := make([]int64, 0, len(side))
+	for price := range side {
+		prices = append(prices, price)
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if isBid {
+			return prices[i] > prices[j]
+		}
+		return prices[i] < prices[j]
+	})
+
+	result := make([]types.PriceLevel, len(prices))
+	for i, price := range prices {
+		result[i] = types.PriceLevel{
+			Price:    fb.priceScale.ToDecimal(price),
+			Quantity: fb.qtyScale.ToDecimal(side[price]),
+		}
+	}
+	return result
+}
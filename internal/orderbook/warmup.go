@@ -0,0 +1,43 @@
+package orderbook
+
+import "time"
+
+// WarmupConfig is the stability a book must show after (re)initialization before
+// it is published. Both conditions must hold; zero values disable them.
+type WarmupConfig struct {
+	Duration time.Duration // Time initialized without gaps, resyncs or resets
+	Events   int           // Updates applied without gaps, resyncs or resets
+}
+
+// SetWarmup holds back a freshly initialized or resynced book until it met
+// config, as reported by IsReady
+func (ob *OrderBook) SetWarmup(config WarmupConfig) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.warmup = config
+}
+
+// IsReady reports whether the book is initialized and warmed up, i.e. fit to be
+// broadcast and displayed. Without a warm-up it equals IsInitialized.
+func (ob *OrderBook) IsReady() bool {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.initialized &&
+		ob.warmEvents >= ob.warmup.Events &&
+		ob.clock.Since(ob.warmStart) >= ob.warmup.Duration
+}
+
+// markInitialized initializes the book, starting its warm-up unless it was
+// already initialized (must be called with mutex locked)
+func (ob *OrderBook) markInitialized() {
+	if !ob.initialized {
+		ob.restartWarmup()
+	}
+	ob.initialized = true
+}
+
+// restartWarmup starts the warm-up over, e.g. after a gap (must be called with mutex locked)
+func (ob *OrderBook) restartWarmup() {
+	ob.warmStart = ob.clock.Now()
+	ob.warmEvents = 0
+}
@@ -0,0 +1,221 @@
+package pipeline
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"orderbook/internal/orderbook"
+	"orderbook/internal/types"
+)
+
+// DefaultQueueSize is the number of events queued per processor before new ones are dropped
+const DefaultQueueSize = 1024
+
+// Processor is an analytics module fed with the books of every exchange. Each
+// registered processor runs on its own goroutine, so its methods are never called
+// concurrently and may block without holding back the books or other processors.
+type Processor interface {
+	// Name identifies the processor in logs and drop counters
+	Name() string
+
+	// OnSnapshot is called when a venue's book is loaded or reloaded, with the
+	// changes against the previous book
+	OnSnapshot(venue string, delta orderbook.BookDelta)
+
+	// OnUpdate is called with the level changes of every update applied to a venue's book
+	OnUpdate(venue string, delta orderbook.BookDelta)
+
+	// OnStats is called periodically with the statistics of a venue's book
+	OnStats(venue string, stats types.Stats)
+
+	// Reset discards all state, e.g. after a symbol change
+	Reset()
+}
+
+// Emitter is implemented by processors producing output. The pipeline forwards
+// every value received from Output to its sink until the channel is closed.
+type Emitter interface {
+	Output() <-chan interface{}
+}
+
+// eventKind tells which Processor method handles an event
+type eventKind int
+
+const (
+	eventSnapshot eventKind = iota
+	eventUpdate
+	eventStats
+	eventReset
+)
+
+// event is queued to a processor
+type event struct {
+	kind  eventKind
+	venue string
+	delta orderbook.BookDelta
+	stats types.Stats
+}
+
+// registration is a registered processor and its queue
+type registration struct {
+	processor Processor
+	events    chan event
+	dropped   atomic.Int64
+}
+
+// Pipeline fans book events out to registered processors. Events are queued per
+// processor without blocking: a processor that falls behind loses new events
+// instead of slowing down the books.
+type Pipeline struct {
+	mu            sync.RWMutex
+	registrations []*registration
+	queueSize     int
+	sink          func(interface{})
+	started       bool
+	wg            sync.WaitGroup
+}
+
+// New creates a pipeline queueing queueSize events per processor (DefaultQueueSize
+// when not positive) and passing processor output to sink
+func New(queueSize int, sink func(interface{})) *Pipeline {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	return &Pipeline{queueSize: queueSize, sink: sink}
+}
+
+// Register adds a processor. It must be called before Start; names must be unique.
+func (p *Pipeline) Register(processor Processor) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return fmt.Errorf("processor %s registered after start", processor.Name())
+	}
+	for _, r := range p.registrations {
+		if r.processor.Name() == processor.Name() {
+			return fmt.Errorf("processor %s already registered", processor.Name())
+		}
+	}
+	p.registrations = append(p.registrations, &registration{
+		processor: processor,
+		events:    make(chan event, p.queueSize),
+	})
+	return nil
+}
+
+// Start runs every registered processor, and forwards the output of emitters, on its own goroutines
+func (p *Pipeline) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+
+	for _, r := range p.registrations {
+		p.wg.Add(1)
+		go p.run(r)
+		if emitter, ok := r.processor.(Emitter); ok {
+			go p.forward(emitter.Output())
+		}
+		log.Printf("Analytics processor %s started", r.processor.Name())
+	}
+}
+
+// Stop closes the queues and waits for every processor to drain its queue
+func (p *Pipeline) Stop() {
+	p.mu.Lock()
+	if !p.started {
+		p.mu.Unlock()
+		return
+	}
+	p.started = false
+	for _, r := range p.registrations {
+		close(r.events)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// run passes queued events to a processor until its queue is closed
+func (p *Pipeline) run(r *registration) {
+	defer p.wg.Done()
+	for ev := range r.events {
+		switch ev.kind {
+		case eventSnapshot:
+			r.processor.OnSnapshot(ev.venue, ev.delta)
+		case eventUpdate:
+			r.processor.OnUpdate(ev.venue, ev.delta)
+		case eventStats:
+			r.processor.OnStats(ev.venue, ev.stats)
+		case eventReset:
+			r.processor.Reset()
+		}
+	}
+}
+
+// forward passes the output of an emitter to the sink
+func (p *Pipeline) forward(output <-chan interface{}) {
+	for value := range output {
+		if p.sink != nil {
+			p.sink(value)
+		}
+	}
+}
+
+// submit queues an event to every processor without blocking
+func (p *Pipeline) submit(ev event) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if !p.started {
+		return
+	}
+	for _, r := range p.registrations {
+		select {
+		case r.events <- ev:
+		default:
+			r.dropped.Add(1)
+		}
+	}
+}
+
+// Delta queues a book delta of venue, as a snapshot or an update. It never blocks,
+// so it may be called from a book's delta handler.
+func (p *Pipeline) Delta(venue string, delta orderbook.BookDelta) {
+	kind := eventUpdate
+	if delta.Snapshot {
+		kind = eventSnapshot
+	}
+	p.submit(event{kind: kind, venue: venue, delta: delta})
+}
+
+// Stats queues the statistics of venue's book
+func (p *Pipeline) Stats(venue string, stats types.Stats) {
+	p.submit(event{kind: eventStats, venue: venue, stats: stats})
+}
+
+// Reset queues a reset behind the pending events of every processor, waiting for
+// room in full queues so no reset is lost
+func (p *Pipeline) Reset() {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if !p.started {
+		return
+	}
+	for _, r := range p.registrations {
+		r.events <- event{kind: eventReset}
+	}
+}
+
+// Dropped returns the events dropped by each processor because its queue was full
+func (p *Pipeline) Dropped() map[string]int64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	dropped := make(map[string]int64, len(p.registrations))
+	for _, r := range p.registrations {
+		dropped[r.processor.Name()] = r.dropped.Load()
+	}
+	return dropped
+}
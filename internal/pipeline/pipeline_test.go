@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"orderbook/internal/orderbook"
+	"orderbook/internal/types"
+)
+
+// recordingProcessor records the events it receives and emits one value per update
+type recordingProcessor struct {
+	name    string
+	mu      sync.Mutex
+	events  []string
+	busy    chan struct{} // Signaled when an event starts being processed, when set
+	block   chan struct{} // Holds back every event until closed, when set
+	outputs chan interface{}
+}
+
+func (p *recordingProcessor) Name() string { return p.name }
+
+func (p *recordingProcessor) Output() <-chan interface{} { return p.outputs }
+
+func (p *recordingProcessor) record(event string) {
+	if p.busy != nil {
+		p.busy <- struct{}{}
+	}
+	if p.block != nil {
+		<-p.block
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+}
+
+func (p *recordingProcessor) OnSnapshot(venue string, delta orderbook.BookDelta) {
+	p.record("snapshot:" + venue)
+}
+
+func (p *recordingProcessor) OnUpdate(venue string, delta orderbook.BookDelta) {
+	p.record(fmt.Sprintf("update:%s:%d", venue, delta.Seq))
+	p.outputs <- delta.Seq
+}
+
+func (p *recordingProcessor) OnStats(venue string, stats types.Stats) {
+	p.record(fmt.Sprintf("stats:%s:%d", venue, stats.Gaps))
+}
+
+func (p *recordingProcessor) Reset() { p.record("reset") }
+
+func (p *recordingProcessor) recorded() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return fmt.Sprint(p.events)
+}
+
+func TestPipeline(t *testing.T) {
+	var sinkMu sync.Mutex
+	var sunk []interface{}
+	p := New(64, func(output interface{}) {
+		sinkMu.Lock()
+		defer sinkMu.Unlock()
+		sunk = append(sunk, output)
+	})
+
+	processor := &recordingProcessor{name: "recorder", outputs: make(chan interface{}, 8)}
+	if err := p.Register(processor); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	if err := p.Register(&recordingProcessor{name: "recorder"}); err == nil {
+		t.Errorf("Expected an error registering a duplicate name")
+	}
+
+	// Events submitted before Start are ignored
+	p.Delta("okx", orderbook.BookDelta{Seq: 1})
+	p.Start()
+	if err := p.Register(&recordingProcessor{name: "late"}); err == nil {
+		t.Errorf("Expected an error registering after Start")
+	}
+
+	p.Delta("okx", orderbook.BookDelta{Seq: 1, Snapshot: true})
+	p.Delta("okx", orderbook.BookDelta{Seq: 2})
+	p.Stats("okx", types.Stats{Gaps: 3})
+	p.Delta("binance", orderbook.BookDelta{Seq: 4})
+	p.Reset()
+	p.Stop()
+
+	expected := "[snapshot:okx update:okx:2 stats:okx:3 update:binance:4 reset]"
+	if got := processor.recorded(); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		sinkMu.Lock()
+		got := fmt.Sprint(sunk)
+		sinkMu.Unlock()
+		if got == "[2 4]" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the emitted updates [2 4] in the sink, got %s", got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPipelineDropsWhenFull(t *testing.T) {
+	p := New(2, nil)
+	slow := &recordingProcessor{
+		name:    "slow",
+		outputs: make(chan interface{}, 8),
+		busy:    make(chan struct{}, 8),
+		block:   make(chan struct{}),
+	}
+	if err := p.Register(slow); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	p.Start()
+
+	// The first event is in progress, two more fit in the queue
+	p.Delta("okx", orderbook.BookDelta{Seq: 1})
+	<-slow.busy
+	for seq := int64(2); seq <= 5; seq++ {
+		p.Delta("okx", orderbook.BookDelta{Seq: seq})
+	}
+	if dropped := p.Dropped()["slow"]; dropped != 2 {
+		t.Errorf("Expected 2 dropped events, got %d", dropped)
+	}
+
+	close(slow.block)
+	p.Stop()
+	if got := slow.recorded(); got != "[update:okx:1 update:okx:2 update:okx:3]" {
+		t.Errorf("Expected the first three updates, got %s", got)
+	}
+}
@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"time"
+
+	"orderbook/internal/analytics"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// outputQueueSize is the number of values an emitter buffers for the sink
+const outputQueueSize = 64
+
+// levelChanges converts the changes of a book delta for the analytics detectors
+func levelChanges(delta orderbook.BookDelta) []analytics.LevelChange {
+	changes := make([]analytics.LevelChange, len(delta.Changes))
+	for i, change := range delta.Changes {
+		changes[i] = analytics.LevelChange{
+			IsBid:       change.IsBid,
+			Price:       change.Price,
+			OldQuantity: change.OldQuantity,
+			NewQuantity: change.NewQuantity,
+		}
+	}
+	return changes
+}
+
+// IcebergProcessor runs an analytics.IcebergDetector and emits its signals
+type IcebergProcessor struct {
+	detector *analytics.IcebergDetector
+	output   chan interface{}
+}
+
+// NewIcebergProcessor creates a processor flagging levels refilled at the touch
+func NewIcebergProcessor(detector *analytics.IcebergDetector) *IcebergProcessor {
+	return &IcebergProcessor{detector: detector, output: make(chan interface{}, outputQueueSize)}
+}
+
+// Name implements Processor
+func (p *IcebergProcessor) Name() string { return "iceberg" }
+
+// Output implements Emitter, sending an analytics.IcebergSignal per flagged level
+func (p *IcebergProcessor) Output() <-chan interface{} { return p.output }
+
+// OnSnapshot implements Processor: levels of a reloaded book are not refills
+func (p *IcebergProcessor) OnSnapshot(venue string, delta orderbook.BookDelta) {
+	p.detector.ResetVenue(venue)
+}
+
+// OnUpdate implements Processor
+func (p *IcebergProcessor) OnUpdate(venue string, delta orderbook.BookDelta) {
+	for _, signal := range p.detector.Observe(venue, delta.Time, delta.BestBid, delta.BestAsk, levelChanges(delta)) {
+		p.output <- signal
+	}
+}
+
+// OnStats implements Processor
+func (p *IcebergProcessor) OnStats(venue string, stats types.Stats) {}
+
+// Reset implements Processor
+func (p *IcebergProcessor) Reset() { p.detector.Reset() }
+
+// ResilienceProcessor feeds an analytics.ResilienceTracker, whose reports are read separately
+type ResilienceProcessor struct {
+	tracker *analytics.ResilienceTracker
+}
+
+// NewResilienceProcessor creates a processor measuring the replenishment of books after sweeps
+func NewResilienceProcessor(tracker *analytics.ResilienceTracker) *ResilienceProcessor {
+	return &ResilienceProcessor{tracker: tracker}
+}
+
+// Name implements Processor
+func (p *ResilienceProcessor) Name() string { return "resilience" }
+
+// OnSnapshot implements Processor: levels of a reloaded book are not sweeps
+func (p *ResilienceProcessor) OnSnapshot(venue string, delta orderbook.BookDelta) {
+	p.tracker.ResetVenue(venue)
+}
+
+// OnUpdate implements Processor. Sweeps are timed on the local clock, which the
+// periodic reports also read; queueing delays sweeps and refills alike.
+func (p *ResilienceProcessor) OnUpdate(venue string, delta orderbook.BookDelta) {
+	p.tracker.Observe(venue, time.Now(), delta.BestBid, delta.BestAsk, levelChanges(delta))
+}
+
+// OnStats implements Processor
+func (p *ResilienceProcessor) OnStats(venue string, stats types.Stats) {}
+
+// Reset implements Processor
+func (p *ResilienceProcessor) Reset() { p.tracker.Reset() }
+
+// AnomalyProcessor runs an analytics.AnomalyDetector on the stats of every venue and emits its signals
+type AnomalyProcessor struct {
+	detector *analytics.AnomalyDetector
+	output   chan interface{}
+}
+
+// NewAnomalyProcessor creates a processor flagging spread and depth spikes
+func NewAnomalyProcessor(detector *analytics.AnomalyDetector) *AnomalyProcessor {
+	return &AnomalyProcessor{detector: detector, output: make(chan interface{}, outputQueueSize)}
+}
+
+// Name implements Processor
+func (p *AnomalyProcessor) Name() string { return "anomaly" }
+
+// Output implements Emitter, sending an analytics.AnomalySignal per anomalous metric
+func (p *AnomalyProcessor) Output() <-chan interface{} { return p.output }
+
+// OnSnapshot implements Processor
+func (p *AnomalyProcessor) OnSnapshot(venue string, delta orderbook.BookDelta) {}
+
+// OnUpdate implements Processor
+func (p *AnomalyProcessor) OnUpdate(venue string, delta orderbook.BookDelta) {}
+
+// OnStats implements Processor. One-sided books are skipped.
+func (p *AnomalyProcessor) OnStats(venue string, stats types.Stats) {
+	mid := stats.BestBid.Add(stats.BestAsk).Div(decimal.NewFromInt(2))
+	if !stats.Spread.IsPositive() || !mid.IsPositive() {
+		return
+	}
+	spreadBps := stats.Spread.Div(mid).InexactFloat64() * 10000
+	depth := stats.BidLiquidity05Pct.Add(stats.AskLiquidity05Pct).InexactFloat64()
+	for _, signal := range p.detector.Observe(venue, time.Now(), spreadBps, depth) {
+		p.output <- signal
+	}
+}
+
+// Reset implements Processor
+func (p *AnomalyProcessor) Reset() { p.detector.Reset() }
@@ -0,0 +1,295 @@
+// Package reporting builds daily service level reports of the exchange feeds
+package reporting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DateLayout is the format of report dates, one report per UTC day
+const DateLayout = "2006-01-02"
+
+// Sample is a point-in-time reading of one exchange feed
+type Sample struct {
+	Exchange  string
+	Symbol    string
+	Time      time.Time
+	Started   time.Time     // Creation time of the feed; Resyncs restarts with a new feed
+	Up        bool          // Connected with a ready book
+	Staleness time.Duration // Time since the last venue event, 0 before the first one
+	Resyncs   int64         // Resyncs of the feed since Started
+	Spread    decimal.Decimal
+	Depth2Pct decimal.Decimal // Bid plus ask size within 2% of mid
+}
+
+// VenueReport holds the service level of one exchange and symbol over a day
+type VenueReport struct {
+	Exchange        string          `json:"exchange"`
+	Symbol          string          `json:"symbol"`
+	ObservedSeconds float64         `json:"observedSeconds"` // Time covered by samples
+	UptimePct       float64         `json:"uptimePct"`       // Share of the observed time the feed was up, in percent
+	MeanStalenessMs float64         `json:"meanStalenessMs"`
+	P95StalenessMs  float64         `json:"p95StalenessMs"`
+	Resyncs         int64           `json:"resyncs"`
+	AvgSpread       decimal.Decimal `json:"avgSpread"`
+	AvgDepth2Pct    decimal.Decimal `json:"avgDepth2Pct"`
+	Samples         int             `json:"samples"`
+}
+
+// Report is the service level of every venue sampled during one UTC day
+type Report struct {
+	Date   string        `json:"date"`
+	Start  time.Time     `json:"start"` // First sample of the day
+	End    time.Time     `json:"end"`   // Last sample of the day
+	Final  bool          `json:"final"` // False while the day is in progress
+	Venues []VenueReport `json:"venues"`
+}
+
+// venueDay accumulates the samples of one exchange and symbol over a day
+type venueDay struct {
+	upSeconds   float64
+	observed    float64
+	staleness   []float64 // Milliseconds, one per sample with a known last event
+	resyncs     int64
+	spreadSum   decimal.Decimal
+	spreadCount int
+	depthSum    decimal.Decimal
+	samples     int
+}
+
+// Tracker accumulates samples into daily reports. The report of a day is closed
+// by the first sample of the next day; the latest closed reports are kept.
+type Tracker struct {
+	mu      sync.Mutex
+	keep    int
+	date    string
+	start   time.Time
+	end     time.Time
+	venues  map[[2]string]*venueDay // Keyed by exchange and symbol
+	last    map[[2]string]Sample    // Latest sample of each venue, kept across days
+	reports []Report                // Closed reports, oldest first
+}
+
+// NewTracker creates a Tracker keeping the reports of the last keep days
+func NewTracker(keep int) *Tracker {
+	return &Tracker{
+		keep:   keep,
+		venues: make(map[[2]string]*venueDay),
+		last:   make(map[[2]string]Sample),
+	}
+}
+
+// Sample records a reading of an exchange feed. When it starts a new day, the
+// report of the previous day is closed and returned.
+func (t *Tracker) Sample(s Sample) *Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var closed *Report
+	date := s.Time.UTC().Format(DateLayout)
+	if t.date != date {
+		if t.date != "" {
+			report := t.report(true)
+			t.reports = append(t.reports, report)
+			if len(t.reports) > t.keep {
+				t.reports = t.reports[len(t.reports)-t.keep:]
+			}
+			closed = &report
+		}
+		t.date = date
+		t.start = s.Time
+		t.venues = make(map[[2]string]*venueDay)
+	}
+	t.end = s.Time
+
+	key := [2]string{s.Exchange, s.Symbol}
+	day, ok := t.venues[key]
+	if !ok {
+		day = &venueDay{}
+		t.venues[key] = day
+	}
+	if last, ok := t.last[key]; ok {
+		// The time since the previous sample is attributed to its state
+		if elapsed := s.Time.Sub(last.Time).Seconds(); elapsed > 0 {
+			day.observed += elapsed
+			if last.Up {
+				day.upSeconds += elapsed
+			}
+		}
+		// Counters restart with a new feed
+		if last.Started.Equal(s.Started) {
+			day.resyncs += max(s.Resyncs-last.Resyncs, 0)
+		} else {
+			day.resyncs += s.Resyncs
+		}
+	}
+	t.last[key] = s
+
+	day.samples++
+	if s.Staleness > 0 {
+		day.staleness = append(day.staleness, float64(s.Staleness)/float64(time.Millisecond))
+	}
+	if s.Spread.IsPositive() {
+		day.spreadSum = day.spreadSum.Add(s.Spread)
+		day.spreadCount++
+	}
+	day.depthSum = day.depthSum.Add(s.Depth2Pct)
+	return closed
+}
+
+// Current returns the report of the day in progress, false before the first sample
+func (t *Tracker) Current() (Report, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.date == "" {
+		return Report{}, false
+	}
+	return t.report(false), true
+}
+
+// Report returns the report of date, closed or in progress
+func (t *Tracker) Report(date string) (Report, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if date == t.date && t.date != "" {
+		return t.report(false), true
+	}
+	for _, report := range t.reports {
+		if report.Date == date {
+			return report, true
+		}
+	}
+	return Report{}, false
+}
+
+// Dates returns the dates of the kept reports, oldest first, including the day in progress
+func (t *Tracker) Dates() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	dates := make([]string, 0, len(t.reports)+1)
+	for _, report := range t.reports {
+		dates = append(dates, report.Date)
+	}
+	if t.date != "" {
+		dates = append(dates, t.date)
+	}
+	return dates
+}
+
+// report builds the report of the current day, sorted by exchange and symbol (must be called with mutex locked)
+func (t *Tracker) report(final bool) Report {
+	report := Report{
+		Date:   t.date,
+		Start:  t.start,
+		End:    t.end,
+		Final:  final,
+		Venues: make([]VenueReport, 0, len(t.venues)),
+	}
+	for key, day := range t.venues {
+		venue := VenueReport{
+			Exchange:        key[0],
+			Symbol:          key[1],
+			ObservedSeconds: day.observed,
+			Resyncs:         day.resyncs,
+			Samples:         day.samples,
+		}
+		if day.observed > 0 {
+			venue.UptimePct = 100 * day.upSeconds / day.observed
+		}
+		if len(day.staleness) > 0 {
+			venue.MeanStalenessMs, venue.P95StalenessMs = meanAndPercentile(day.staleness, 0.95)
+		}
+		if day.spreadCount > 0 {
+			venue.AvgSpread = day.spreadSum.Div(decimal.NewFromInt(int64(day.spreadCount)))
+		}
+		if day.samples > 0 {
+			venue.AvgDepth2Pct = day.depthSum.Div(decimal.NewFromInt(int64(day.samples)))
+		}
+		report.Venues = append(report.Venues, venue)
+	}
+
+	sort.Slice(report.Venues, func(i, j int) bool {
+		a, b := report.Venues[i], report.Venues[j]
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		return a.Symbol < b.Symbol
+	})
+	return report
+}
+
+// meanAndPercentile returns the mean and the nearest-rank percentile p of values
+func meanAndPercentile(values []float64, p float64) (float64, float64) {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sum / float64(len(sorted)), sorted[max(rank, 0)]
+}
+
+// WriteMarkdown renders report as a Markdown table, one row per venue
+func WriteMarkdown(w io.Writer, report Report) error {
+	status := "final"
+	if !report.Final {
+		status = "in progress"
+	}
+	if _, err := fmt.Fprintf(w, "# Feed SLA report %s (%s)\n\n", report.Date, status); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Sampled from %s to %s UTC.\n\n", report.Start.UTC().Format(time.TimeOnly), report.End.UTC().Format(time.TimeOnly)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "| Exchange | Symbol | Uptime | Mean staleness | p95 staleness | Resyncs | Avg spread | Avg depth 2% |"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "|---|---|---:|---:|---:|---:|---:|---:|"); err != nil {
+		return err
+	}
+	for _, v := range report.Venues {
+		if _, err := fmt.Fprintf(w, "| %s | %s | %.2f%% | %.0f ms | %.0f ms | %d | %s | %s |\n",
+			v.Exchange, v.Symbol, v.UptimePct, v.MeanStalenessMs, v.P95StalenessMs, v.Resyncs,
+			v.AvgSpread.StringFixed(2), v.AvgDepth2Pct.StringFixed(4)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFiles writes report to dir as sla-<date>.json and sla-<date>.md,
+// replacing the files of a previous write of the same day
+func WriteFiles(dir string, report Report) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	base := filepath.Join(dir, "sla-"+report.Date)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(base+".json", append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	f, err := os.Create(base + ".md")
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if err := WriteMarkdown(f, report); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,93 @@
+package reporting
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker(7)
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	samples := []Sample{
+		{Time: day, Started: started, Up: true, Staleness: 100 * time.Millisecond, Resyncs: 3, Spread: decimal.NewFromInt(2), Depth2Pct: decimal.NewFromInt(10)},
+		{Time: day.Add(time.Minute), Started: started, Up: false, Staleness: 200 * time.Millisecond, Resyncs: 4, Spread: decimal.NewFromInt(4), Depth2Pct: decimal.NewFromInt(20)},
+		{Time: day.Add(2 * time.Minute), Started: started, Up: true, Staleness: 300 * time.Millisecond, Resyncs: 4},
+		// A new feed restarts its counters
+		{Time: day.Add(4 * time.Minute), Started: day.Add(3 * time.Minute), Up: true, Staleness: 1000 * time.Millisecond, Resyncs: 1},
+	}
+	for _, s := range samples {
+		s.Exchange, s.Symbol = "binance", "BTCUSDT"
+		if closed := tracker.Sample(s); closed != nil {
+			t.Fatalf("Expected no closed report within the day, got %+v", closed)
+		}
+	}
+
+	report, ok := tracker.Report("2024-01-01")
+	if !ok || report.Final || len(report.Venues) != 1 {
+		t.Fatalf("Expected the report in progress with one venue, got %+v", report)
+	}
+	venue := report.Venues[0]
+	// Up for the first minute and the last two, down for the second
+	if venue.ObservedSeconds != 240 || venue.UptimePct != 75 {
+		t.Errorf("Expected 75%% of 240s up, got %v%% of %vs", venue.UptimePct, venue.ObservedSeconds)
+	}
+	if venue.MeanStalenessMs != 400 || venue.P95StalenessMs != 1000 {
+		t.Errorf("Expected staleness mean 400ms and p95 1000ms, got %v and %v", venue.MeanStalenessMs, venue.P95StalenessMs)
+	}
+	if venue.Resyncs != 2 {
+		t.Errorf("Expected 2 resyncs during the day, got %d", venue.Resyncs)
+	}
+	if venue.AvgSpread.String() != "3" || venue.AvgDepth2Pct.String() != "7.5" {
+		t.Errorf("Expected avg spread 3 and depth 7.5, got %s and %s", venue.AvgSpread, venue.AvgDepth2Pct)
+	}
+
+	// The first sample of the next day closes the report
+	closed := tracker.Sample(Sample{Exchange: "binance", Symbol: "BTCUSDT", Time: day.Add(12 * time.Hour), Started: day.Add(3 * time.Minute), Up: true, Resyncs: 1})
+	if closed == nil || closed.Date != "2024-01-01" || !closed.Final {
+		t.Fatalf("Expected the final report of 2024-01-01, got %+v", closed)
+	}
+	if dates := tracker.Dates(); strings.Join(dates, ",") != "2024-01-01,2024-01-02" {
+		t.Errorf("Expected both days, got %v", dates)
+	}
+	next, _ := tracker.Report("2024-01-02")
+	if next.Venues[0].Resyncs != 0 || next.Venues[0].UptimePct != 100 {
+		t.Errorf("Expected the new day to start from the previous sample, got %+v", next.Venues[0])
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	report := Report{
+		Date:  "2024-01-01",
+		Final: true,
+		Venues: []VenueReport{
+			{Exchange: "okx", Symbol: "BTCUSDT", UptimePct: 99.5, MeanStalenessMs: 120, P95StalenessMs: 480, Resyncs: 2, AvgSpread: decimal.NewFromFloat(0.1), AvgDepth2Pct: decimal.NewFromInt(42)},
+		},
+	}
+	if err := WriteFiles(dir, report); err != nil {
+		t.Fatalf("WriteFiles() failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "sla-2024-01-01.json")); err != nil {
+		t.Errorf("Expected the JSON report: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "sla-2024-01-01.md"))
+	if err != nil {
+		t.Fatalf("Expected the Markdown report: %v", err)
+	}
+	var expected bytes.Buffer
+	if err := WriteMarkdown(&expected, report); err != nil {
+		t.Fatalf("WriteMarkdown() failed: %v", err)
+	}
+	if string(data) != expected.String() || !strings.Contains(string(data), "| okx | BTCUSDT | 99.50% | 120 ms | 480 ms | 2 | 0.10 | 42.0000 |") {
+		t.Errorf("Unexpected Markdown report:\n%s", data)
+	}
+}
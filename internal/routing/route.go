@@ -0,0 +1,195 @@
+package routing
+
+import (
+	"fmt"
+	"sort"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// Side is the direction of the order to route
+type Side string
+
+const (
+	Buy  Side = "buy"  // Takes asks
+	Sell Side = "sell" // Takes bids
+)
+
+// Venue is the book side an order would take on one exchange
+type Venue struct {
+	Name   string
+	Levels []types.PriceLevel // Sorted best first: asks for buys, bids for sells
+	FeeBps float64            // Taker fee in basis points of notional
+}
+
+// Fill is the part of an order executed on one venue
+type Fill struct {
+	Venue    string          `json:"exchange"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Notional decimal.Decimal `json:"notional"` // Sum of price * quantity
+	Fee      decimal.Decimal `json:"fee"`
+	AvgPrice decimal.Decimal `json:"avgPrice"` // Notional / quantity, before fees
+}
+
+// Plan is the venue split with the lowest expected cost for an order
+type Plan struct {
+	Side      Side            `json:"side"`
+	Requested decimal.Decimal `json:"requested"`
+	Filled    decimal.Decimal `json:"filled"` // Less than Requested when the books are too thin
+	Notional  decimal.Decimal `json:"notional"`
+	Fees      decimal.Decimal `json:"fees"`
+	Cost      decimal.Decimal `json:"cost"`     // Notional plus fees for buys, minus fees (net proceeds) for sells
+	AvgPrice  decimal.Decimal `json:"avgPrice"` // Cost / filled, i.e. the fee-inclusive price
+	Fills     []Fill          `json:"fills"`    // Largest first
+	// BestSingle is the cheapest venue able to fill the whole order alone, for
+	// comparison with the split; nil when no venue can
+	BestSingle *Fill `json:"bestSingle,omitempty"`
+}
+
+// level is a price level of one venue with its fee-adjusted price
+type level struct {
+	venue     int
+	price     decimal.Decimal
+	quantity  decimal.Decimal
+	effective decimal.Decimal
+}
+
+// Route splits quantity across venues by walking the consolidated book in order of
+// fee-adjusted price, which minimizes the cost of a buy and maximizes the proceeds
+// of a sell given the visible liquidity
+func Route(side Side, quantity decimal.Decimal, venues []Venue) (Plan, error) {
+	if side != Buy && side != Sell {
+		return Plan{}, fmt.Errorf("invalid side: %s", side)
+	}
+	if !quantity.IsPositive() {
+		return Plan{}, fmt.Errorf("quantity must be positive: %s", quantity)
+	}
+
+	// Each venue contributes at most quantity, so deeper levels are never needed
+	var levels []level
+	for i, venue := range venues {
+		feeRate := feeRate(venue.FeeBps)
+		remaining := quantity
+		for _, l := range venue.Levels {
+			if !remaining.IsPositive() {
+				break
+			}
+			if !l.Quantity.IsPositive() {
+				continue
+			}
+			levels = append(levels, level{
+				venue:     i,
+				price:     l.Price,
+				quantity:  l.Quantity,
+				effective: effectivePrice(side, l.Price, feeRate),
+			})
+			remaining = remaining.Sub(l.Quantity)
+		}
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		if side == Buy {
+			return levels[i].effective.LessThan(levels[j].effective)
+		}
+		return levels[i].effective.GreaterThan(levels[j].effective)
+	})
+
+	plan := Plan{Side: side, Requested: quantity}
+	fills := make([]Fill, len(venues))
+	remaining := quantity
+	for _, l := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+		take := decimal.Min(remaining, l.quantity)
+		fill := &fills[l.venue]
+		fill.Quantity = fill.Quantity.Add(take)
+		fill.Notional = fill.Notional.Add(take.Mul(l.price))
+		remaining = remaining.Sub(take)
+	}
+
+	for i, fill := range fills {
+		if !fill.Quantity.IsPositive() {
+			continue
+		}
+		fill = finishFill(venues[i], fill)
+		plan.Filled = plan.Filled.Add(fill.Quantity)
+		plan.Notional = plan.Notional.Add(fill.Notional)
+		plan.Fees = plan.Fees.Add(fill.Fee)
+		plan.Fills = append(plan.Fills, fill)
+	}
+	sort.SliceStable(plan.Fills, func(i, j int) bool {
+		return plan.Fills[i].Quantity.GreaterThan(plan.Fills[j].Quantity)
+	})
+	plan.Cost = netCost(side, plan.Notional, plan.Fees)
+	if plan.Filled.IsPositive() {
+		plan.AvgPrice = plan.Cost.Div(plan.Filled)
+	}
+
+	plan.BestSingle = bestSingle(side, quantity, venues)
+	return plan, nil
+}
+
+// bestSingle returns the fill of the venue with the best fee-inclusive cost among
+// those able to fill quantity alone
+func bestSingle(side Side, quantity decimal.Decimal, venues []Venue) *Fill {
+	var best *Fill
+	var bestCost decimal.Decimal
+	for _, venue := range venues {
+		fill := Fill{Venue: venue.Name}
+		remaining := quantity
+		for _, l := range venue.Levels {
+			if !remaining.IsPositive() {
+				break
+			}
+			take := decimal.Min(remaining, l.Quantity)
+			if !take.IsPositive() {
+				continue
+			}
+			fill.Quantity = fill.Quantity.Add(take)
+			fill.Notional = fill.Notional.Add(take.Mul(l.Price))
+			remaining = remaining.Sub(take)
+		}
+		if remaining.IsPositive() {
+			continue
+		}
+
+		fill = finishFill(venue, fill)
+		cost := netCost(side, fill.Notional, fill.Fee)
+		if best == nil || (side == Buy && cost.LessThan(bestCost)) || (side == Sell && cost.GreaterThan(bestCost)) {
+			best = &fill
+			bestCost = cost
+		}
+	}
+	return best
+}
+
+// finishFill fills in the fee and average price of a venue's fill
+func finishFill(venue Venue, fill Fill) Fill {
+	fill.Venue = venue.Name
+	fill.Fee = fill.Notional.Mul(feeRate(venue.FeeBps))
+	fill.AvgPrice = fill.Notional.Div(fill.Quantity)
+	return fill
+}
+
+// feeRate converts a fee in basis points to a fraction of notional
+func feeRate(bps float64) decimal.Decimal {
+	return decimal.NewFromFloat(bps).Div(decimal.NewFromInt(10000))
+}
+
+// effectivePrice is the price of a level including the taker fee
+func effectivePrice(side Side, price, feeRate decimal.Decimal) decimal.Decimal {
+	if side == Buy {
+		return price.Mul(decimal.NewFromInt(1).Add(feeRate))
+	}
+	return price.Mul(decimal.NewFromInt(1).Sub(feeRate))
+}
+
+// netCost is what a buy pays or a sell receives after fees
+func netCost(side Side, notional, fees decimal.Decimal) decimal.Decimal {
+	if side == Buy {
+		return notional.Add(fees)
+	}
+	return notional.Sub(fees)
+}
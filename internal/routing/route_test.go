@@ -0,0 +1,101 @@
+package routing
+
+import (
+	"testing"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+func levels(pairs ...string) []types.PriceLevel {
+	result := make([]types.PriceLevel, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		result = append(result, types.PriceLevel{
+			Price:    decimal.RequireFromString(pairs[i]),
+			Quantity: decimal.RequireFromString(pairs[i+1]),
+		})
+	}
+	return result
+}
+
+func TestRouteBuy(t *testing.T) {
+	venues := []Venue{
+		// Cheapest quote, but its fee puts it behind okx
+		{Name: "kraken", Levels: levels("99.5", "10"), FeeBps: 150},
+		{Name: "binance", Levels: levels("100", "1", "101", "5"), FeeBps: 10},
+		{Name: "okx", Levels: levels("100.5", "2"), FeeBps: 0},
+	}
+
+	plan, err := Route(Buy, decimal.NewFromInt(4), venues)
+	if err != nil {
+		t.Fatalf("Route() failed: %v", err)
+	}
+
+	// binance 100 (100.1 with fees), okx 100.5, kraken 99.5 (100.9925 with fees), binance 101 (101.101)
+	expected := map[string]string{"binance": "1", "okx": "2", "kraken": "1"}
+	if len(plan.Fills) != len(expected) {
+		t.Fatalf("Expected %d fills, got %+v", len(expected), plan.Fills)
+	}
+	for _, fill := range plan.Fills {
+		if fill.Quantity.String() != expected[fill.Venue] {
+			t.Errorf("%s: Expected quantity %s, got %s", fill.Venue, expected[fill.Venue], fill.Quantity)
+		}
+	}
+	if plan.Fills[0].Venue != "okx" {
+		t.Errorf("Expected largest fill first, got %s", plan.Fills[0].Venue)
+	}
+	if !plan.Filled.Equal(decimal.NewFromInt(4)) {
+		t.Errorf("Expected filled 4, got %s", plan.Filled)
+	}
+
+	// 100 + 201 + 99.5 notional, 0.1 + 0 + 1.4925 fees
+	if plan.Cost.String() != "402.0925" {
+		t.Errorf("Expected cost 402.0925, got %s", plan.Cost)
+	}
+
+	// binance alone: 403 + 0.403 beats kraken alone: 398 + 5.97
+	if plan.BestSingle == nil || plan.BestSingle.Venue != "binance" {
+		t.Errorf("Expected binance as best single venue, got %+v", plan.BestSingle)
+	}
+}
+
+func TestRouteSell(t *testing.T) {
+	venues := []Venue{
+		{Name: "binance", Levels: levels("100", "1", "99", "1"), FeeBps: 10},
+		{Name: "okx", Levels: levels("99.95", "1"), FeeBps: 0},
+	}
+
+	plan, err := Route(Sell, decimal.NewFromInt(5), venues)
+	if err != nil {
+		t.Fatalf("Route() failed: %v", err)
+	}
+	if !plan.Filled.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("Expected partial fill of 3, got %s", plan.Filled)
+	}
+	// 199 notional less 0.199 fees on binance plus 99.95 on okx
+	if plan.Cost.String() != "298.751" {
+		t.Errorf("Expected proceeds 298.751, got %s", plan.Cost)
+	}
+	if plan.BestSingle != nil {
+		t.Errorf("Expected no single venue to fill 5, got %+v", plan.BestSingle)
+	}
+}
+
+func TestRouteErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		side     Side
+		quantity decimal.Decimal
+	}{
+		{"invalid side", "short", decimal.NewFromInt(1)},
+		{"zero quantity", Buy, decimal.Zero},
+		{"negative quantity", Sell, decimal.NewFromInt(-1)},
+	}
+
+	for _, tt := range tests {
+		if _, err := Route(tt.side, tt.quantity, nil); err == nil {
+			t.Errorf("%s: Expected error, got nil", tt.name)
+		}
+	}
+}
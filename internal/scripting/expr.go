@@ -0,0 +1,283 @@
+package scripting
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a compiled arithmetic expression over numeric variables. Comparisons and
+// logical operators yield 1 for true and 0 for false; any non-zero value is true.
+type Expr struct {
+	source string
+	eval   func(vars []float64) float64
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression with the values of the variables it was compiled
+// against, in the same order
+func (e *Expr) Eval(vars []float64) float64 {
+	return e.eval(vars)
+}
+
+// functions are the functions callable from expressions, by name and arity
+var functions = map[string]struct {
+	arity int
+	fn    func(args []float64) float64
+}{
+	"abs":  {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt": {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"log":  {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"min":  {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":  {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+}
+
+// binaryPrecedence orders the binary operators, higher binds tighter
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6,
+}
+
+// Compile parses source into an expression over vars. Variables not in vars and
+// unknown functions are rejected.
+func Compile(source string, vars []string) (*Expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, vars: make(map[string]int, len(vars))}
+	for i, name := range vars {
+		p.vars[name] = i
+	}
+	eval, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+	return &Expr{source: source, eval: eval}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize splits source into numbers, identifiers and operators
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(source) && (unicode.IsDigit(rune(source[i])) || source[i] == '.' || source[i] == 'e' ||
+				((source[i] == '-' || source[i] == '+') && source[i-1] == 'e')) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[start:i], pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(source) && (unicode.IsLetter(rune(source[i])) || unicode.IsDigit(rune(source[i])) || source[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "<", ">", "!", "(", ")", ","} {
+				if strings.HasPrefix(source[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, text: "end of expression", pos: len(source)}), nil
+}
+
+// parser is a precedence climbing parser compiling tokens to closures
+type parser struct {
+	tokens []token
+	next   int
+	vars   map[string]int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *parser) expect(op string) error {
+	if tok := p.take(); tok.kind != tokenOp || tok.text != op {
+		return fmt.Errorf("expected %q at offset %d, got %q", op, tok.pos, tok.text)
+	}
+	return nil
+}
+
+// parseExpr parses binary operations binding tighter than minPrecedence
+func (p *parser) parseExpr(minPrecedence int) (func([]float64) float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		precedence, ok := binaryPrecedence[tok.text]
+		if tok.kind != tokenOp || !ok || precedence <= minPrecedence {
+			return left, nil
+		}
+		p.take()
+		right, err := p.parseExpr(precedence)
+		if err != nil {
+			return nil, err
+		}
+		left = binary(tok.text, left, right)
+	}
+}
+
+// parseUnary parses negations and operands
+func (p *parser) parseUnary() (func([]float64) float64, error) {
+	tok := p.take()
+	switch {
+	case tok.kind == tokenOp && tok.text == "-":
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v []float64) float64 { return -operand(v) }, nil
+	case tok.kind == tokenOp && tok.text == "!":
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v []float64) float64 { return truth(operand(v) == 0) }, nil
+	case tok.kind == tokenOp && tok.text == "(":
+		inner, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case tok.kind == tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", tok.text, tok.pos)
+		}
+		return func([]float64) float64 { return value }, nil
+	case tok.kind == tokenIdent:
+		if p.peek().text == "(" {
+			return p.parseCall(tok)
+		}
+		index, ok := p.vars[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %q at offset %d", tok.text, tok.pos)
+		}
+		return func(v []float64) float64 { return v[index] }, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+}
+
+// parseCall parses the arguments of a function call
+func (p *parser) parseCall(name token) (func([]float64) float64, error) {
+	function, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at offset %d", name.text, name.pos)
+	}
+	p.take() // (
+	args := make([]func([]float64) float64, 0, function.arity)
+	for p.peek().text != ")" {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.take() // )
+	if len(args) != function.arity {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", name.text, function.arity, len(args))
+	}
+	return func(v []float64) float64 {
+		values := make([]float64, len(args))
+		for i, arg := range args {
+			values[i] = arg(v)
+		}
+		return function.fn(values)
+	}, nil
+}
+
+// binary returns the closure applying op to two operands
+func binary(op string, left, right func([]float64) float64) func([]float64) float64 {
+	switch op {
+	case "+":
+		return func(v []float64) float64 { return left(v) + right(v) }
+	case "-":
+		return func(v []float64) float64 { return left(v) - right(v) }
+	case "*":
+		return func(v []float64) float64 { return left(v) * right(v) }
+	case "/":
+		return func(v []float64) float64 { return left(v) / right(v) }
+	case "<":
+		return func(v []float64) float64 { return truth(left(v) < right(v)) }
+	case "<=":
+		return func(v []float64) float64 { return truth(left(v) <= right(v)) }
+	case ">":
+		return func(v []float64) float64 { return truth(left(v) > right(v)) }
+	case ">=":
+		return func(v []float64) float64 { return truth(left(v) >= right(v)) }
+	case "==":
+		return func(v []float64) float64 { return truth(left(v) == right(v)) }
+	case "!=":
+		return func(v []float64) float64 { return truth(left(v) != right(v)) }
+	case "&&":
+		return func(v []float64) float64 { return truth(left(v) != 0 && right(v) != 0) }
+	default: // "||"
+		return func(v []float64) float64 { return truth(left(v) != 0 || right(v) != 0) }
+	}
+}
+
+// truth converts a condition to 1 or 0
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
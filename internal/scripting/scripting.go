@@ -0,0 +1,211 @@
+// Package scripting evaluates user scripts on book events, so custom signals can
+// be computed and published without rebuilding the binary. Scripts are
+// expressions over the values of a book, see Compile for the syntax.
+package scripting
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"orderbook/internal/orderbook"
+	"orderbook/internal/types"
+)
+
+// Trigger is the book event a script is evaluated on
+type Trigger string
+
+const (
+	OnStats  Trigger = "stats"  // Periodic statistics of a venue's book
+	OnUpdate Trigger = "update" // Every update applied to a venue's book
+)
+
+// commonVars are the variables of every trigger. last is the previous value of
+// the script on the same venue, zero before the first evaluation.
+var commonVars = []string{"bestBid", "bestAsk", "mid", "spread", "last"}
+
+// Variables are the variables available to the scripts of each trigger
+var Variables = map[Trigger][]string{
+	OnStats: append(commonVars[:len(commonVars):len(commonVars)],
+		"bidLiquidity05", "askLiquidity05", "bidLiquidity2", "askLiquidity2",
+		"bidLiquidity10", "askLiquidity10", "totalBids", "totalAsks",
+		"bidLevels", "askLevels", "eventsPerSecond",
+	),
+	OnUpdate: append(commonVars[:len(commonVars):len(commonVars)],
+		"changes", "bidAdded", "askAdded", // Net quantity added to each side by the update
+	),
+}
+
+// Definition is a script as written in a scripts file
+type Definition struct {
+	Name string  `json:"name"`
+	On   Trigger `json:"on"`
+	Expr string  `json:"expr"`           // Value of the signal
+	When string  `json:"when,omitempty"` // Emits the signal when non-zero, or when the value changed if empty. It may read the new value as value.
+}
+
+// Script is a compiled script
+type Script struct {
+	Name string
+	On   Trigger
+	expr *Expr
+	when *Expr
+}
+
+// Signal is a value emitted by a script
+type Signal struct {
+	Script string
+	Venue  string
+	Time   time.Time
+	Value  float64
+}
+
+// Compile compiles a script definition
+func (d Definition) Compile() (*Script, error) {
+	if d.Name == "" {
+		return nil, fmt.Errorf("script has no name")
+	}
+	vars, ok := Variables[d.On]
+	if !ok {
+		return nil, fmt.Errorf("script %s: unknown trigger %q (expected %s or %s)", d.Name, d.On, OnStats, OnUpdate)
+	}
+	expr, err := Compile(d.Expr, vars)
+	if err != nil {
+		return nil, fmt.Errorf("script %s: expr: %w", d.Name, err)
+	}
+	script := &Script{Name: d.Name, On: d.On, expr: expr}
+	if d.When != "" {
+		if script.when, err = Compile(d.When, append(vars[:len(vars):len(vars)], "value")); err != nil {
+			return nil, fmt.Errorf("script %s: when: %w", d.Name, err)
+		}
+	}
+	return script, nil
+}
+
+// Load reads and compiles the scripts of a JSON file holding a list of definitions
+func Load(path string) ([]*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scripts: %w", err)
+	}
+	var definitions []Definition
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		return nil, fmt.Errorf("failed to parse scripts: %w", err)
+	}
+	names := make(map[string]bool, len(definitions))
+	scripts := make([]*Script, 0, len(definitions))
+	for _, definition := range definitions {
+		if names[definition.Name] {
+			return nil, fmt.Errorf("duplicate script %s", definition.Name)
+		}
+		names[definition.Name] = true
+		script, err := definition.Compile()
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts, nil
+}
+
+// outputQueueSize is the number of signals buffered for the pipeline sink
+const outputQueueSize = 64
+
+// Processor evaluates scripts on book events. It implements pipeline.Processor
+// and pipeline.Emitter, sending a Signal per emitted value.
+type Processor struct {
+	scripts []*Script
+	last    []map[string]float64 // Previous value per script, by venue
+	output  chan interface{}
+}
+
+// NewProcessor creates a processor evaluating scripts
+func NewProcessor(scripts []*Script) *Processor {
+	p := &Processor{scripts: scripts, output: make(chan interface{}, outputQueueSize)}
+	p.Reset()
+	return p
+}
+
+// Name implements pipeline.Processor
+func (p *Processor) Name() string { return "scripts" }
+
+// Output implements pipeline.Emitter
+func (p *Processor) Output() <-chan interface{} { return p.output }
+
+// OnSnapshot implements pipeline.Processor: a reloaded book is not an update
+func (p *Processor) OnSnapshot(venue string, delta orderbook.BookDelta) {}
+
+// OnUpdate implements pipeline.Processor
+func (p *Processor) OnUpdate(venue string, delta orderbook.BookDelta) {
+	bidAdded, askAdded := 0.0, 0.0
+	for _, change := range delta.Changes {
+		added := change.NewQuantity.Sub(change.OldQuantity).InexactFloat64()
+		if change.IsBid {
+			bidAdded += added
+		} else {
+			askAdded += added
+		}
+	}
+	vars := append(bookVars(delta.BestBid.InexactFloat64(), delta.BestAsk.InexactFloat64()),
+		float64(len(delta.Changes)), bidAdded, askAdded)
+	p.run(OnUpdate, venue, delta.Time, vars)
+}
+
+// OnStats implements pipeline.Processor
+func (p *Processor) OnStats(venue string, stats types.Stats) {
+	vars := append(bookVars(stats.BestBid.InexactFloat64(), stats.BestAsk.InexactFloat64()),
+		stats.BidLiquidity05Pct.InexactFloat64(), stats.AskLiquidity05Pct.InexactFloat64(),
+		stats.BidLiquidity2Pct.InexactFloat64(), stats.AskLiquidity2Pct.InexactFloat64(),
+		stats.BidLiquidity10Pct.InexactFloat64(), stats.AskLiquidity10Pct.InexactFloat64(),
+		stats.TotalBidsQty.InexactFloat64(), stats.TotalAsksQty.InexactFloat64(),
+		float64(stats.BidLevels), float64(stats.AskLevels), stats.EventsPerSecond,
+	)
+	p.run(OnStats, venue, time.Now(), vars)
+}
+
+// Reset implements pipeline.Processor
+func (p *Processor) Reset() {
+	p.last = make([]map[string]float64, len(p.scripts))
+	for i := range p.last {
+		p.last[i] = make(map[string]float64)
+	}
+}
+
+// lastIndex is the position of the last variable in commonVars
+const lastIndex = 4
+
+// bookVars returns commonVars for a book, with last unset
+func bookVars(bestBid, bestAsk float64) []float64 {
+	mid, spread := 0.0, 0.0
+	if bestBid > 0 && bestAsk > 0 {
+		mid = (bestBid + bestAsk) / 2
+		spread = bestAsk - bestBid
+	}
+	return []float64{bestBid, bestAsk, mid, spread, 0}
+}
+
+// run evaluates the scripts of trigger and emits their signals. Values that are
+// not finite, e.g. from a division by zero, are discarded.
+func (p *Processor) run(trigger Trigger, venue string, at time.Time, vars []float64) {
+	for i, script := range p.scripts {
+		if script.On != trigger {
+			continue
+		}
+		last, seen := p.last[i][venue]
+		vars[lastIndex] = last
+		value := script.expr.Eval(vars)
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		emit := !seen || value != last
+		if script.when != nil {
+			emit = script.when.Eval(append(vars, value)) != 0
+		}
+		p.last[i][venue] = value
+		if emit {
+			p.output <- Signal{Script: script.Name, Venue: venue, Time: at, Value: value}
+		}
+	}
+}
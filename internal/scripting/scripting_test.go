@@ -0,0 +1,101 @@
+package scripting
+
+import (
+	"testing"
+	"time"
+
+	"orderbook/internal/orderbook"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCompile(t *testing.T) {
+	vars := []string{"a", "b"}
+	values := []float64{3, 4}
+	tests := []struct {
+		source   string
+		expected float64
+	}{
+		{"a + b * 2", 11},
+		{"(a + b) * 2", 14},
+		{"-a - -b", 1},
+		{"a / b", 0.75},
+		{"a < b && !(a == b)", 1},
+		{"a > b || b >= 5", 0},
+		{"sqrt(a*a + b*b)", 5},
+		{"max(a, min(b, 1)) - abs(-2)", 1},
+		{"1.5e1 - b", 11},
+	}
+	for _, tt := range tests {
+		expr, err := Compile(tt.source, vars)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", tt.source, err)
+			continue
+		}
+		if got := expr.Eval(values); got != tt.expected {
+			t.Errorf("Expected %q = %v, got %v", tt.source, tt.expected, got)
+		}
+	}
+
+	for _, source := range []string{"", "a +", "c", "foo(a)", "min(a)", "(a", "a b", "a # b"} {
+		if _, err := Compile(source, vars); err == nil {
+			t.Errorf("Expected an error compiling %q", source)
+		}
+	}
+}
+
+func TestProcessor(t *testing.T) {
+	var scripts []*Script
+	for _, definition := range []Definition{
+		{Name: "imbalance", On: OnStats, Expr: "(bidLiquidity2 - askLiquidity2) / (bidLiquidity2 + askLiquidity2)"},
+		{Name: "bid-pull", On: OnUpdate, Expr: "-bidAdded", When: "value > 5"},
+		{Name: "updates", On: OnUpdate, Expr: "last + 1", When: "1"},
+	} {
+		script, err := definition.Compile()
+		if err != nil {
+			t.Fatalf("Compile() failed: %v", err)
+		}
+		scripts = append(scripts, script)
+	}
+	if _, err := (Definition{Name: "bad", On: "trade", Expr: "1"}).Compile(); err == nil {
+		t.Errorf("Expected an error for an unknown trigger")
+	}
+
+	p := NewProcessor(scripts)
+	stats := types.Stats{BidLiquidity2Pct: decimal.NewFromInt(3), AskLiquidity2Pct: decimal.NewFromInt(1)}
+	p.OnStats("okx", stats)
+	p.OnStats("okx", stats) // Unchanged, not emitted again
+	p.OnStats("okx", types.Stats{})
+
+	pull := orderbook.BookDelta{Time: time.Unix(1, 0), Changes: []orderbook.LevelChange{
+		{IsBid: true, Price: decimal.NewFromInt(100), OldQuantity: decimal.NewFromInt(8)},
+		{IsBid: false, Price: decimal.NewFromInt(101), NewQuantity: decimal.NewFromInt(2)},
+	}}
+	p.OnUpdate("okx", pull)
+	p.OnUpdate("binance", orderbook.BookDelta{})
+
+	expected := []Signal{
+		{Script: "imbalance", Venue: "okx", Value: 0.5},
+		{Script: "bid-pull", Venue: "okx", Value: 8},
+		{Script: "updates", Venue: "okx", Value: 1},
+		{Script: "updates", Venue: "binance", Value: 1},
+	}
+	for _, want := range expected {
+		select {
+		case output := <-p.Output():
+			got := output.(Signal)
+			if got.Script != want.Script || got.Venue != want.Venue || got.Value != want.Value {
+				t.Errorf("Expected %s on %s = %v, got %s on %s = %v",
+					want.Script, want.Venue, want.Value, got.Script, got.Venue, got.Value)
+			}
+		default:
+			t.Fatalf("Expected signal %s on %s, got none", want.Script, want.Venue)
+		}
+	}
+	select {
+	case output := <-p.Output():
+		t.Errorf("Expected no more signals, got %+v", output)
+	default:
+	}
+}
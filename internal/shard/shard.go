@@ -0,0 +1,219 @@
+// Package shard runs the update pipelines of every (exchange, symbol) pair on a
+// fixed pool of workers instead of one goroutine each. A pipeline is pinned to
+// one shard, so its updates are applied in order, and each shard has a bounded
+// queue so a burst on one venue delays only the venues sharing its shard.
+package shard
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+// DefaultQueueSize is the number of updates queued per shard before producers block
+const DefaultQueueSize = 1024
+
+// queueWaitEWMA is the weight of the newest sample in the queue wait average
+const queueWaitEWMA = 0.05
+
+// Config holds the worker pool configuration
+type Config struct {
+	Shards      int  // Number of workers, 0 uses GOMAXPROCS
+	QueueSize   int  // Updates queued per shard, 0 uses DefaultQueueSize
+	LockThreads bool // Lock each worker to its own OS thread, e.g. to pin it with taskset
+}
+
+// Handler applies one update of a pipeline. last reports that the exchange had no
+// further update pending when this one was queued, i.e. the end of a burst.
+type Handler func(update *exchange.DepthUpdate, last bool)
+
+// Stats are the runtime statistics of one shard
+type Stats struct {
+	Shard         int
+	Pipelines     []string      // Keys of the pipelines pinned to the shard
+	QueueLength   int           // Updates waiting
+	QueueCapacity int           // Bound of the queue
+	Processed     int64         // Updates applied
+	Blocked       int64         // Submissions that found the queue full and waited
+	BusyTime      time.Duration // Total time spent applying updates
+	QueueWait     time.Duration // Exponentially weighted average wait in the queue
+	MaxQueueWait  time.Duration // Longest wait in the queue
+}
+
+// job is one queued update
+type job struct {
+	lane   *Lane
+	update *exchange.DepthUpdate
+	last   bool
+	queued time.Time
+}
+
+// shard is one worker and its queue
+type shard struct {
+	index int
+	queue chan job
+
+	mu        sync.Mutex // Guards the fields below
+	pipelines map[string]bool
+	processed int64
+	blocked   int64
+	busy      time.Duration
+	wait      time.Duration
+	maxWait   time.Duration
+}
+
+// Lane submits the updates of one pipeline to its shard
+type Lane struct {
+	key     string
+	shard   *shard
+	handler Handler
+}
+
+// Pool is a fixed set of shards
+type Pool struct {
+	config Config
+	shards []*shard
+	mu     sync.Mutex // Guards the pipeline assignments
+	wg     sync.WaitGroup
+}
+
+// New creates a pool; Start launches its workers
+func New(config Config) *Pool {
+	if config.Shards <= 0 {
+		config.Shards = runtime.GOMAXPROCS(0)
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	p := &Pool{config: config, shards: make([]*shard, config.Shards)}
+	for i := range p.shards {
+		p.shards[i] = &shard{
+			index:     i,
+			queue:     make(chan job, config.QueueSize),
+			pipelines: make(map[string]bool),
+		}
+	}
+	return p
+}
+
+// Start launches one worker per shard
+func (p *Pool) Start() {
+	for _, s := range p.shards {
+		p.wg.Add(1)
+		go p.run(s)
+	}
+}
+
+// Stop drains the queues and waits for the workers to exit. Lanes must not be used afterwards.
+func (p *Pool) Stop() {
+	for _, s := range p.shards {
+		close(s.queue)
+	}
+	p.wg.Wait()
+}
+
+// run applies the queued updates of a shard in order
+func (p *Pool) run(s *shard) {
+	defer p.wg.Done()
+	if p.config.LockThreads {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	for j := range s.queue {
+		start := time.Now()
+		j.lane.handler(j.update, j.last)
+		busy := time.Since(start)
+		wait := start.Sub(j.queued)
+
+		s.mu.Lock()
+		s.processed++
+		s.busy += busy
+		if s.wait == 0 {
+			s.wait = wait
+		} else {
+			s.wait += time.Duration(queueWaitEWMA * float64(wait-s.wait))
+		}
+		s.maxWait = max(s.maxWait, wait)
+		s.mu.Unlock()
+	}
+}
+
+// Assign pins a pipeline to the shard running the fewest pipelines and returns its lane
+func (p *Pool) Assign(key string, handler Handler) *Lane {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	best := p.shards[0]
+	bestCount := -1
+	for _, s := range p.shards {
+		s.mu.Lock()
+		count := len(s.pipelines)
+		s.mu.Unlock()
+		if bestCount < 0 || count < bestCount {
+			best, bestCount = s, count
+		}
+	}
+	best.mu.Lock()
+	best.pipelines[key] = true
+	best.mu.Unlock()
+	return &Lane{key: key, shard: best, handler: handler}
+}
+
+// Release unpins the pipeline of a lane once no more updates are submitted to it
+func (p *Pool) Release(lane *Lane) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	lane.shard.mu.Lock()
+	delete(lane.shard.pipelines, lane.key)
+	lane.shard.mu.Unlock()
+}
+
+// Submit queues an update, blocking while the shard's queue is full
+func (l *Lane) Submit(update *exchange.DepthUpdate, last bool) {
+	j := job{lane: l, update: update, last: last, queued: time.Now()}
+	select {
+	case l.shard.queue <- j:
+		return
+	default:
+	}
+
+	l.shard.mu.Lock()
+	l.shard.blocked++
+	l.shard.mu.Unlock()
+	l.shard.queue <- j
+}
+
+// Shard returns the index of the shard the lane is pinned to
+func (l *Lane) Shard() int {
+	return l.shard.index
+}
+
+// Stats returns the statistics of every shard, in shard order
+func (p *Pool) Stats() []Stats {
+	stats := make([]Stats, len(p.shards))
+	for i, s := range p.shards {
+		s.mu.Lock()
+		pipelines := make([]string, 0, len(s.pipelines))
+		for key := range s.pipelines {
+			pipelines = append(pipelines, key)
+		}
+		stats[i] = Stats{
+			Shard:         i,
+			Pipelines:     pipelines,
+			QueueLength:   len(s.queue),
+			QueueCapacity: cap(s.queue),
+			Processed:     s.processed,
+			Blocked:       s.blocked,
+			BusyTime:      s.busy,
+			QueueWait:     s.wait,
+			MaxQueueWait:  s.maxWait,
+		}
+		s.mu.Unlock()
+		sort.Strings(stats[i].Pipelines)
+	}
+	return stats
+}
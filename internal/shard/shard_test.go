@@ -0,0 +1,94 @@
+package shard
+
+import (
+	"sync"
+	"testing"
+
+	"orderbook/internal/exchange"
+)
+
+func TestAssignBalancesPipelines(t *testing.T) {
+	pool := New(Config{Shards: 3, QueueSize: 4})
+	noop := func(*exchange.DepthUpdate, bool) {}
+
+	var lanes []*Lane
+	for _, key := range []string{"binance:BTCUSDT", "bybit:BTCUSDT", "okx:BTC-USDT", "kraken:XBT/USD"} {
+		lanes = append(lanes, pool.Assign(key, noop))
+	}
+
+	expected := []int{0, 1, 2, 0}
+	for i, lane := range lanes {
+		if lane.Shard() != expected[i] {
+			t.Errorf("Expected pipeline %d on shard %d, got %d", i, expected[i], lane.Shard())
+		}
+	}
+
+	pool.Release(lanes[1])
+	if lane := pool.Assign("coinbase:BTC-USD", noop); lane.Shard() != 1 {
+		t.Errorf("Expected the released shard to be reused, got shard %d", lane.Shard())
+	}
+
+	stats := pool.Stats()
+	if len(stats[0].Pipelines) != 2 || stats[0].Pipelines[0] != "binance:BTCUSDT" || stats[0].QueueCapacity != 4 {
+		t.Errorf("Expected shard 0 to run binance and kraken with a queue of 4, got %+v", stats[0])
+	}
+}
+
+func TestLaneOrderAndStats(t *testing.T) {
+	pool := New(Config{Shards: 2, QueueSize: 2})
+
+	var mu sync.Mutex
+	applied := make(map[string][]int64)
+	var lasts int
+	handler := func(key string) Handler {
+		return func(update *exchange.DepthUpdate, last bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			applied[key] = append(applied[key], update.FinalUpdateID)
+			if last {
+				lasts++
+			}
+		}
+	}
+	binance := pool.Assign("binance:BTCUSDT", handler("binance"))
+	bybit := pool.Assign("bybit:BTCUSDT", handler("bybit"))
+
+	pool.Start()
+	const updates = 100
+	var wg sync.WaitGroup
+	for _, lane := range []*Lane{binance, bybit} {
+		wg.Add(1)
+		go func(lane *Lane) {
+			defer wg.Done()
+			for id := int64(1); id <= updates; id++ {
+				lane.Submit(&exchange.DepthUpdate{FinalUpdateID: id}, id == updates)
+			}
+		}(lane)
+	}
+	wg.Wait()
+	pool.Stop()
+
+	for _, key := range []string{"binance", "bybit"} {
+		ids := applied[key]
+		if len(ids) != updates {
+			t.Fatalf("Expected %d updates applied for %s, got %d", updates, key, len(ids))
+		}
+		for i, id := range ids {
+			if id != int64(i+1) {
+				t.Fatalf("Expected %s updates in order, got %d at position %d", key, id, i)
+			}
+		}
+	}
+	if lasts != 2 {
+		t.Errorf("Expected 2 bursts ended, got %d", lasts)
+	}
+
+	for _, stats := range pool.Stats() {
+		if stats.Processed != updates {
+			t.Errorf("Expected %d updates processed on shard %d, got %d", updates, stats.Shard, stats.Processed)
+		}
+		if stats.QueueLength != 0 {
+			t.Errorf("Expected shard %d drained, got %d queued", stats.Shard, stats.QueueLength)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+var clickHouseSchema = []string{
+	`CREATE TABLE IF NOT EXISTS snapshots (
+		exchange       LowCardinality(String),
+		symbol         LowCardinality(String),
+		ts             Int64,
+		last_update_id Int64,
+		bids           String,
+		asks           String
+	) ENGINE = MergeTree ORDER BY (exchange, symbol, ts)`,
+	`CREATE TABLE IF NOT EXISTS updates (
+		exchange        LowCardinality(String),
+		symbol          LowCardinality(String),
+		ts              Int64,
+		first_update_id Int64,
+		final_update_id Int64,
+		prev_update_id  Int64,
+		bids            String,
+		asks            String
+	) ENGINE = MergeTree ORDER BY (exchange, symbol, ts)`,
+	`CREATE TABLE IF NOT EXISTS stats (
+		exchange LowCardinality(String),
+		symbol   LowCardinality(String),
+		ts       Int64,
+		data     String
+	) ENGINE = MergeTree ORDER BY (exchange, symbol, ts)`,
+}
+
+// clickHouseFilter is the WHERE clause shared by all ClickHouse queries (uses server-side parameters)
+const clickHouseFilter = ` WHERE ({exchange:String} = '' OR exchange = {exchange:String})` +
+	` AND ({symbol:String} = '' OR symbol = {symbol:String})` +
+	` AND ts BETWEEN {from:Int64} AND {to:Int64}` +
+	` ORDER BY ts LIMIT {limit:UInt64} FORMAT JSONEachRow`
+
+// ClickHouseStorage implements the Storage interface using the ClickHouse HTTP interface
+type ClickHouseStorage struct {
+	baseURL *url.URL
+	client  *http.Client
+}
+
+type clickHouseSnapshotRow struct {
+	Exchange     string `json:"exchange"`
+	Symbol       string `json:"symbol"`
+	Ts           int64  `json:"ts"`
+	LastUpdateID int64  `json:"last_update_id"`
+	Bids         string `json:"bids"`
+	Asks         string `json:"asks"`
+}
+
+type clickHouseUpdateRow struct {
+	Exchange      string `json:"exchange"`
+	Symbol        string `json:"symbol"`
+	Ts            int64  `json:"ts"`
+	FirstUpdateID int64  `json:"first_update_id"`
+	FinalUpdateID int64  `json:"final_update_id"`
+	PrevUpdateID  int64  `json:"prev_update_id"`
+	Bids          string `json:"bids"`
+	Asks          string `json:"asks"`
+}
+
+type clickHouseStatsRow struct {
+	Exchange string `json:"exchange"`
+	Symbol   string `json:"symbol"`
+	Ts       int64  `json:"ts"`
+	Data     string `json:"data"`
+}
+
+// NewClickHouse connects to a ClickHouse server over HTTP and creates the schema
+func NewClickHouse(dsn string) (*ClickHouseStorage, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("clickhouse storage requires an HTTP URL")
+	}
+
+	baseURL, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clickhouse URL: %w", err)
+	}
+
+	s := &ClickHouseStorage{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, statement := range clickHouseSchema {
+		if _, err := s.do(ctx, statement, nil, nil); err != nil {
+			return nil, fmt.Errorf("failed to create clickhouse schema: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// WriteSnapshot persists a full orderbook snapshot
+func (s *ClickHouseStorage) WriteSnapshot(ctx context.Context, snapshot *exchange.Snapshot) error {
+	bids, asks, err := marshalLevels(snapshot.Bids, snapshot.Asks)
+	if err != nil {
+		return err
+	}
+
+	return s.insert(ctx, "snapshots", clickHouseSnapshotRow{
+		Exchange:     string(snapshot.Exchange),
+		Symbol:       snapshot.Symbol,
+		Ts:           snapshot.Timestamp.UnixMilli(),
+		LastUpdateID: snapshot.LastUpdateID,
+		Bids:         bids,
+		Asks:         asks,
+	})
+}
+
+// WriteUpdate persists a single depth update
+func (s *ClickHouseStorage) WriteUpdate(ctx context.Context, update *exchange.DepthUpdate) error {
+	bids, asks, err := marshalLevels(update.Bids, update.Asks)
+	if err != nil {
+		return err
+	}
+
+	return s.insert(ctx, "updates", clickHouseUpdateRow{
+		Exchange:      string(update.Exchange),
+		Symbol:        update.Symbol,
+		Ts:            update.EventTime.UnixMilli(),
+		FirstUpdateID: update.FirstUpdateID,
+		FinalUpdateID: update.FinalUpdateID,
+		PrevUpdateID:  update.PrevUpdateID,
+		Bids:          bids,
+		Asks:          asks,
+	})
+}
+
+// WriteStats persists a stats sample for an exchange
+func (s *ClickHouseStorage) WriteStats(ctx context.Context, record StatsRecord) error {
+	data, err := json.Marshal(record.Stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats: %w", err)
+	}
+
+	return s.insert(ctx, "stats", clickHouseStatsRow{
+		Exchange: string(record.Exchange),
+		Symbol:   record.Symbol,
+		Ts:       record.Timestamp.UnixMilli(),
+		Data:     string(data),
+	})
+}
+
+// QuerySnapshots returns snapshots matching the query, ordered by time
+func (s *ClickHouseStorage) QuerySnapshots(ctx context.Context, query Query) ([]*exchange.Snapshot, error) {
+	body, err := s.do(ctx, `SELECT exchange, symbol, ts, last_update_id, bids, asks FROM snapshots`+clickHouseFilter, s.filterParams(query), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snapshots: %w", err)
+	}
+
+	var snapshots []*exchange.Snapshot
+	err = decodeRows(body, func(decoder *json.Decoder) error {
+		var row clickHouseSnapshotRow
+		if err := decoder.Decode(&row); err != nil {
+			return err
+		}
+		bids, asks, err := unmarshalLevels(row.Bids, row.Asks)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, &exchange.Snapshot{
+			Exchange:     exchange.ExchangeName(row.Exchange),
+			Symbol:       row.Symbol,
+			LastUpdateID: row.LastUpdateID,
+			Bids:         bids,
+			Asks:         asks,
+			Timestamp:    time.UnixMilli(row.Ts),
+		})
+		return nil
+	})
+	return snapshots, err
+}
+
+// QueryUpdates returns depth updates matching the query, ordered by time
+func (s *ClickHouseStorage) QueryUpdates(ctx context.Context, query Query) ([]*exchange.DepthUpdate, error) {
+	body, err := s.do(ctx, `SELECT exchange, symbol, ts, first_update_id, final_update_id, prev_update_id, bids, asks FROM updates`+clickHouseFilter, s.filterParams(query), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query updates: %w", err)
+	}
+
+	var updates []*exchange.DepthUpdate
+	err = decodeRows(body, func(decoder *json.Decoder) error {
+		var row clickHouseUpdateRow
+		if err := decoder.Decode(&row); err != nil {
+			return err
+		}
+		bids, asks, err := unmarshalLevels(row.Bids, row.Asks)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, &exchange.DepthUpdate{
+			Exchange:      exchange.ExchangeName(row.Exchange),
+			Symbol:        row.Symbol,
+			EventTime:     time.UnixMilli(row.Ts),
+			FirstUpdateID: row.FirstUpdateID,
+			FinalUpdateID: row.FinalUpdateID,
+			PrevUpdateID:  row.PrevUpdateID,
+			Bids:          bids,
+			Asks:          asks,
+		})
+		return nil
+	})
+	return updates, err
+}
+
+// QueryStats returns stats samples matching the query, ordered by time
+func (s *ClickHouseStorage) QueryStats(ctx context.Context, query Query) ([]StatsRecord, error) {
+	body, err := s.do(ctx, `SELECT exchange, symbol, ts, data FROM stats`+clickHouseFilter, s.filterParams(query), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats: %w", err)
+	}
+
+	var records []StatsRecord
+	err = decodeRows(body, func(decoder *json.Decoder) error {
+		var row clickHouseStatsRow
+		if err := decoder.Decode(&row); err != nil {
+			return err
+		}
+		record := StatsRecord{
+			Exchange:  exchange.ExchangeName(row.Exchange),
+			Symbol:    row.Symbol,
+			Timestamp: time.UnixMilli(row.Ts),
+		}
+		if err := json.Unmarshal([]byte(row.Data), &record.Stats); err != nil {
+			return fmt.Errorf("failed to unmarshal stats: %w", err)
+		}
+		records = append(records, record)
+		return nil
+	})
+	return records, err
+}
+
+// Close releases idle HTTP connections
+func (s *ClickHouseStorage) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// insert writes a single row to a table using the JSONEachRow format
+func (s *ClickHouseStorage) insert(ctx context.Context, table string, row interface{}) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s row: %w", table, err)
+	}
+
+	if _, err := s.do(ctx, fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", table), nil, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", table, err)
+	}
+	return nil
+}
+
+// do executes a query over HTTP. The query is sent in the URL when a body is
+// provided (inserts) and as the request body otherwise.
+func (s *ClickHouseStorage) do(ctx context.Context, query string, params map[string]string, body io.Reader) ([]byte, error) {
+	reqURL := *s.baseURL
+	values := reqURL.Query()
+	values.Set("output_format_json_quote_64bit_integers", "0")
+	for name, value := range params {
+		values.Set("param_"+name, value)
+	}
+
+	if body != nil {
+		values.Set("query", query)
+	} else {
+		body = bytes.NewBufferString(query)
+	}
+	reqURL.RawQuery = values.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("clickhouse request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clickhouse response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("clickhouse error: status=%d, body=%s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	return respBody, nil
+}
+
+// filterParams builds the server-side parameters for clickHouseFilter
+func (s *ClickHouseStorage) filterParams(query Query) map[string]string {
+	from, to := query.timeBounds()
+	limit := uint64(1<<63 - 1)
+	if query.Limit > 0 {
+		limit = uint64(query.Limit)
+	}
+	return map[string]string{
+		"exchange": string(query.Exchange),
+		"symbol":   query.Symbol,
+		"from":     strconv.FormatInt(from, 10),
+		"to":       strconv.FormatInt(to, 10),
+		"limit":    strconv.FormatUint(limit, 10),
+	}
+}
+
+// decodeRows calls decode for each JSONEachRow line in the body
+func decodeRows(body []byte, decode func(decoder *json.Decoder) error) error {
+	decoder := json.NewDecoder(bufio.NewReader(bytes.NewReader(body)))
+	for decoder.More() {
+		if err := decode(decoder); err != nil {
+			return fmt.Errorf("failed to decode row: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// clickHouseRequest is a request received by fakeClickHouse
+type clickHouseRequest struct {
+	query  string
+	params url.Values
+	body   string // Inserted rows; empty for queries sent as the body
+}
+
+// fakeClickHouse records the requests of a ClickHouseStorage and answers them
+// with a canned JSONEachRow response
+type fakeClickHouse struct {
+	mu       sync.Mutex
+	requests []clickHouseRequest
+	response string
+	status   int
+}
+
+func (f *fakeClickHouse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, _ := io.ReadAll(r.Body)
+	req := clickHouseRequest{query: r.URL.Query().Get("query"), params: r.URL.Query()}
+	if req.query != "" {
+		req.body = string(data)
+	} else {
+		req.query = string(data)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+	}
+	io.WriteString(w, f.response)
+}
+
+// last returns the last request received
+func (f *fakeClickHouse) last(t *testing.T) clickHouseRequest {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.requests) == 0 {
+		t.Fatalf("Expected a clickhouse request")
+	}
+	return f.requests[len(f.requests)-1]
+}
+
+// respond sets the body of the following responses
+func (f *fakeClickHouse) respond(body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.response = body
+}
+
+func newTestClickHouse(t *testing.T) (*ClickHouseStorage, *fakeClickHouse) {
+	t.Helper()
+	fake := &fakeClickHouse{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	store, err := NewClickHouse(server.URL + "/?database=orderbook")
+	if err != nil {
+		t.Fatalf("NewClickHouse() failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, fake
+}
+
+func TestClickHouseCreatesSchema(t *testing.T) {
+	_, fake := newTestClickHouse(t)
+
+	if len(fake.requests) != len(clickHouseSchema) {
+		t.Fatalf("Expected %d schema statements, got %d", len(clickHouseSchema), len(fake.requests))
+	}
+	for i, table := range []string{"snapshots", "updates", "stats"} {
+		req := fake.requests[i]
+		if !strings.HasPrefix(req.query, "CREATE TABLE IF NOT EXISTS "+table+" ") {
+			t.Errorf("Expected the %s table to be created, got %q", table, req.query)
+		}
+		if req.params.Get("database") != "orderbook" {
+			t.Errorf("Expected the DSN parameters to be kept, got %v", req.params)
+		}
+	}
+}
+
+func TestClickHouseWriteSnapshot(t *testing.T) {
+	store, fake := newTestClickHouse(t)
+
+	err := store.WriteSnapshot(context.Background(), &exchange.Snapshot{
+		Exchange:     exchange.Binance,
+		Symbol:       "BTCUSDT",
+		LastUpdateID: 42,
+		Bids:         []exchange.PriceLevel{{Price: "50000.1", Quantity: "1.5"}},
+		Asks:         []exchange.PriceLevel{{Price: "50000.2", Quantity: "2"}},
+		Timestamp:    time.UnixMilli(1700000000000),
+	})
+	if err != nil {
+		t.Fatalf("WriteSnapshot() failed: %v", err)
+	}
+
+	req := fake.last(t)
+	if req.query != "INSERT INTO snapshots FORMAT JSONEachRow" {
+		t.Errorf("Expected an insert into snapshots, got %q", req.query)
+	}
+	if req.params.Get("output_format_json_quote_64bit_integers") != "0" {
+		t.Errorf("Expected 64-bit integers unquoted, got %v", req.params)
+	}
+	var row clickHouseSnapshotRow
+	if err := json.Unmarshal([]byte(req.body), &row); err != nil {
+		t.Fatalf("Failed to decode the inserted row %q: %v", req.body, err)
+	}
+	expected := clickHouseSnapshotRow{
+		Exchange:     "binance",
+		Symbol:       "BTCUSDT",
+		Ts:           1700000000000,
+		LastUpdateID: 42,
+		Bids:         `[{"Price":"50000.1","Quantity":"1.5"}]`,
+		Asks:         `[{"Price":"50000.2","Quantity":"2"}]`,
+	}
+	if row != expected {
+		t.Errorf("Expected row %+v, got %+v", expected, row)
+	}
+}
+
+func TestClickHouseWriteStats(t *testing.T) {
+	store, fake := newTestClickHouse(t)
+
+	err := store.WriteStats(context.Background(), StatsRecord{
+		Exchange:  exchange.Kraken,
+		Symbol:    "BTC/USD",
+		Timestamp: time.UnixMilli(1700000000000),
+		Stats:     types.Stats{BestBid: decimal.RequireFromString("50000.5")},
+	})
+	if err != nil {
+		t.Fatalf("WriteStats() failed: %v", err)
+	}
+
+	req := fake.last(t)
+	if req.query != "INSERT INTO stats FORMAT JSONEachRow" {
+		t.Errorf("Expected an insert into stats, got %q", req.query)
+	}
+	var row clickHouseStatsRow
+	if err := json.Unmarshal([]byte(req.body), &row); err != nil {
+		t.Fatalf("Failed to decode the inserted row %q: %v", req.body, err)
+	}
+	if row.Exchange != "kraken" || row.Symbol != "BTC/USD" || row.Ts != 1700000000000 {
+		t.Errorf("Expected kraken BTC/USD at 1700000000000, got %+v", row)
+	}
+	var stats types.Stats
+	if err := json.Unmarshal([]byte(row.Data), &stats); err != nil {
+		t.Fatalf("Failed to decode the stats %q: %v", row.Data, err)
+	}
+	if !stats.BestBid.Equal(decimal.RequireFromString("50000.5")) {
+		t.Errorf("Expected best bid 50000.5, got %s", stats.BestBid)
+	}
+}
+
+// checkFilter checks that req selects columns from table with the parameters of
+// clickHouseFilter
+func checkFilter(t *testing.T, req clickHouseRequest, columns, table string, params map[string]string) {
+	t.Helper()
+	if expected := "SELECT " + columns + " FROM " + table + clickHouseFilter; req.query != expected {
+		t.Errorf("Expected query %q, got %q", expected, req.query)
+	}
+	for name, value := range params {
+		if got := req.params.Get("param_" + name); got != value {
+			t.Errorf("Expected param_%s=%q, got %q", name, value, got)
+		}
+	}
+}
+
+func TestClickHouseQuerySnapshots(t *testing.T) {
+	store, fake := newTestClickHouse(t)
+	fake.respond(`{"exchange":"binance","symbol":"BTCUSDT","ts":1700000000000,"last_update_id":1,"bids":"[{\"Price\":\"50000.1\",\"Quantity\":\"1.5\"}]","asks":"[]"}
+{"exchange":"binance","symbol":"BTCUSDT","ts":1700000001000,"last_update_id":3,"bids":"[]","asks":"[{\"Price\":\"50000.2\",\"Quantity\":\"2\"}]"}
+`)
+
+	snapshots, err := store.QuerySnapshots(context.Background(), Query{
+		Exchange: exchange.Binance,
+		Symbol:   "BTCUSDT",
+		From:     time.UnixMilli(1700000000000),
+		To:       time.UnixMilli(1700000060000),
+		Limit:    10,
+	})
+	if err != nil {
+		t.Fatalf("QuerySnapshots() failed: %v", err)
+	}
+	checkFilter(t, fake.last(t), "exchange, symbol, ts, last_update_id, bids, asks", "snapshots", map[string]string{
+		"exchange": "binance",
+		"symbol":   "BTCUSDT",
+		"from":     "1700000000000",
+		"to":       "1700000060000",
+		"limit":    "10",
+	})
+
+	if len(snapshots) != 2 {
+		t.Fatalf("Expected 2 snapshots, got %d", len(snapshots))
+	}
+	if snapshots[0].Exchange != exchange.Binance || snapshots[0].LastUpdateID != 1 || snapshots[1].LastUpdateID != 3 {
+		t.Errorf("Expected binance snapshots 1 and 3, got %+v and %+v", snapshots[0], snapshots[1])
+	}
+	if !snapshots[1].Timestamp.Equal(time.UnixMilli(1700000001000)) {
+		t.Errorf("Expected the second snapshot at 1700000001000, got %d", snapshots[1].Timestamp.UnixMilli())
+	}
+	if snapshots[0].Bids[0].Price != "50000.1" || snapshots[1].Asks[0].Quantity != "2" {
+		t.Errorf("Levels not preserved: %+v, %+v", snapshots[0], snapshots[1])
+	}
+}
+
+func TestClickHouseQueryUpdates(t *testing.T) {
+	store, fake := newTestClickHouse(t)
+	fake.respond(`{"exchange":"okx","symbol":"BTC-USDT","ts":1700000000000,"first_update_id":11,"final_update_id":12,"prev_update_id":10,"bids":"[{\"Price\":\"50000\",\"Quantity\":\"0\"}]","asks":"[]"}` + "\n")
+
+	// Unset bounds and limit match everything
+	updates, err := store.QueryUpdates(context.Background(), Query{})
+	if err != nil {
+		t.Fatalf("QueryUpdates() failed: %v", err)
+	}
+	checkFilter(t, fake.last(t), "exchange, symbol, ts, first_update_id, final_update_id, prev_update_id, bids, asks", "updates", map[string]string{
+		"exchange": "",
+		"symbol":   "",
+		"from":     "0",
+		"to":       "9223372036854775807",
+		"limit":    "9223372036854775807",
+	})
+
+	if len(updates) != 1 {
+		t.Fatalf("Expected 1 update, got %d", len(updates))
+	}
+	update := updates[0]
+	if update.Exchange != exchange.OKX || update.Symbol != "BTC-USDT" || update.FirstUpdateID != 11 || update.FinalUpdateID != 12 || update.PrevUpdateID != 10 {
+		t.Errorf("Expected okx BTC-USDT update 11-12 after 10, got %+v", update)
+	}
+	if !update.EventTime.Equal(time.UnixMilli(1700000000000)) || update.Bids[0].Quantity != "0" {
+		t.Errorf("Expected a bid removal at 1700000000000, got %+v", update)
+	}
+}
+
+func TestClickHouseQueryStats(t *testing.T) {
+	store, fake := newTestClickHouse(t)
+	data, err := json.Marshal(types.Stats{BestAsk: decimal.RequireFromString("50001")})
+	if err != nil {
+		t.Fatalf("Failed to marshal stats: %v", err)
+	}
+	row, err := json.Marshal(clickHouseStatsRow{Exchange: "kraken", Symbol: "BTC/USD", Ts: 1700000000000, Data: string(data)})
+	if err != nil {
+		t.Fatalf("Failed to marshal row: %v", err)
+	}
+	fake.respond(string(row) + "\n")
+
+	records, err := store.QueryStats(context.Background(), Query{Symbol: "BTC/USD"})
+	if err != nil {
+		t.Fatalf("QueryStats() failed: %v", err)
+	}
+	checkFilter(t, fake.last(t), "exchange, symbol, ts, data", "stats", map[string]string{
+		"exchange": "",
+		"symbol":   "BTC/USD",
+	})
+
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 stats record, got %d", len(records))
+	}
+	if records[0].Exchange != exchange.Kraken || !records[0].Timestamp.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("Expected kraken stats at 1700000000000, got %+v", records[0])
+	}
+	if !records[0].Stats.BestAsk.Equal(decimal.RequireFromString("50001")) {
+		t.Errorf("Expected best ask 50001, got %s", records[0].Stats.BestAsk)
+	}
+
+	// Rows that do not decode fail the query
+	fake.respond(`{"exchange":"kraken","symbol":"BTC/USD","ts":1700000000000,"data":"not json"}` + "\n")
+	if _, err := store.QueryStats(context.Background(), Query{}); err == nil {
+		t.Errorf("Expected malformed stats to fail the query")
+	}
+}
+
+func TestClickHouseError(t *testing.T) {
+	store, fake := newTestClickHouse(t)
+	fake.mu.Lock()
+	fake.status = http.StatusInternalServerError
+	fake.response = "Code: 60. DB::Exception: Table orderbook.snapshots does not exist\n"
+	fake.mu.Unlock()
+
+	_, err := store.QuerySnapshots(context.Background(), Query{})
+	if err == nil || !strings.Contains(err.Error(), "status=500") || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected the clickhouse error to be returned, got %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"orderbook/internal/exchange"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS snapshots (
+	exchange       TEXT    NOT NULL,
+	symbol         TEXT    NOT NULL,
+	ts             INTEGER NOT NULL,
+	last_update_id INTEGER NOT NULL,
+	bids           TEXT    NOT NULL,
+	asks           TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_snapshots ON snapshots (exchange, symbol, ts);
+
+CREATE TABLE IF NOT EXISTS updates (
+	exchange        TEXT    NOT NULL,
+	symbol          TEXT    NOT NULL,
+	ts              INTEGER NOT NULL,
+	first_update_id INTEGER NOT NULL,
+	final_update_id INTEGER NOT NULL,
+	prev_update_id  INTEGER NOT NULL,
+	bids            TEXT    NOT NULL,
+	asks            TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_updates ON updates (exchange, symbol, ts);
+
+CREATE TABLE IF NOT EXISTS stats (
+	exchange TEXT    NOT NULL,
+	symbol   TEXT    NOT NULL,
+	ts       INTEGER NOT NULL,
+	data     TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_stats ON stats (exchange, symbol, ts);
+`
+
+// filter is the WHERE clause shared by all SQLite queries
+const sqliteFilter = `WHERE (? = '' OR exchange = ?) AND (? = '' OR symbol = ?) AND ts BETWEEN ? AND ? ORDER BY ts LIMIT ?`
+
+// SQLiteStorage implements the Storage interface on a single SQLite file
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLite opens (or creates) an SQLite database at the given path
+func NewSQLite(path string) (*SQLiteStorage, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite storage requires a file path")
+	}
+
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	// SQLite allows a single writer at a time
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+// WriteSnapshot persists a full orderbook snapshot
+func (s *SQLiteStorage) WriteSnapshot(ctx context.Context, snapshot *exchange.Snapshot) error {
+	bids, asks, err := marshalLevels(snapshot.Bids, snapshot.Asks)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO snapshots (exchange, symbol, ts, last_update_id, bids, asks) VALUES (?, ?, ?, ?, ?, ?)`,
+		string(snapshot.Exchange), snapshot.Symbol, snapshot.Timestamp.UnixMilli(), snapshot.LastUpdateID, bids, asks)
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// WriteUpdate persists a single depth update
+func (s *SQLiteStorage) WriteUpdate(ctx context.Context, update *exchange.DepthUpdate) error {
+	bids, asks, err := marshalLevels(update.Bids, update.Asks)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO updates (exchange, symbol, ts, first_update_id, final_update_id, prev_update_id, bids, asks) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		string(update.Exchange), update.Symbol, update.EventTime.UnixMilli(),
+		update.FirstUpdateID, update.FinalUpdateID, update.PrevUpdateID, bids, asks)
+	if err != nil {
+		return fmt.Errorf("failed to write update: %w", err)
+	}
+	return nil
+}
+
+// WriteStats persists a stats sample for an exchange
+func (s *SQLiteStorage) WriteStats(ctx context.Context, record StatsRecord) error {
+	data, err := json.Marshal(record.Stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO stats (exchange, symbol, ts, data) VALUES (?, ?, ?, ?)`,
+		string(record.Exchange), record.Symbol, record.Timestamp.UnixMilli(), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	return nil
+}
+
+// QuerySnapshots returns snapshots matching the query, ordered by time
+func (s *SQLiteStorage) QuerySnapshots(ctx context.Context, query Query) ([]*exchange.Snapshot, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT exchange, symbol, ts, last_update_id, bids, asks FROM snapshots `+sqliteFilter,
+		s.filterArgs(query)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*exchange.Snapshot
+	for rows.Next() {
+		var (
+			name       string
+			ts         int64
+			bids, asks string
+			snapshot   exchange.Snapshot
+		)
+		if err := rows.Scan(&name, &snapshot.Symbol, &ts, &snapshot.LastUpdateID, &bids, &asks); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshot.Exchange = exchange.ExchangeName(name)
+		snapshot.Timestamp = time.UnixMilli(ts)
+		if snapshot.Bids, snapshot.Asks, err = unmarshalLevels(bids, asks); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, &snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// QueryUpdates returns depth updates matching the query, ordered by time
+func (s *SQLiteStorage) QueryUpdates(ctx context.Context, query Query) ([]*exchange.DepthUpdate, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT exchange, symbol, ts, first_update_id, final_update_id, prev_update_id, bids, asks FROM updates `+sqliteFilter,
+		s.filterArgs(query)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query updates: %w", err)
+	}
+	defer rows.Close()
+
+	var updates []*exchange.DepthUpdate
+	for rows.Next() {
+		var (
+			name       string
+			ts         int64
+			bids, asks string
+			update     exchange.DepthUpdate
+		)
+		if err := rows.Scan(&name, &update.Symbol, &ts, &update.FirstUpdateID, &update.FinalUpdateID, &update.PrevUpdateID, &bids, &asks); err != nil {
+			return nil, fmt.Errorf("failed to scan update: %w", err)
+		}
+		update.Exchange = exchange.ExchangeName(name)
+		update.EventTime = time.UnixMilli(ts)
+		if update.Bids, update.Asks, err = unmarshalLevels(bids, asks); err != nil {
+			return nil, err
+		}
+		updates = append(updates, &update)
+	}
+	return updates, rows.Err()
+}
+
+// QueryStats returns stats samples matching the query, ordered by time
+func (s *SQLiteStorage) QueryStats(ctx context.Context, query Query) ([]StatsRecord, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT exchange, symbol, ts, data FROM stats `+sqliteFilter,
+		s.filterArgs(query)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats: %w", err)
+	}
+	defer rows.Close()
+
+	var records []StatsRecord
+	for rows.Next() {
+		var (
+			name   string
+			ts     int64
+			data   string
+			record StatsRecord
+		)
+		if err := rows.Scan(&name, &record.Symbol, &ts, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan stats: %w", err)
+		}
+		record.Exchange = exchange.ExchangeName(name)
+		record.Timestamp = time.UnixMilli(ts)
+		if err := json.Unmarshal([]byte(data), &record.Stats); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stats: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// Close closes the database
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// filterArgs builds the arguments for sqliteFilter
+func (s *SQLiteStorage) filterArgs(query Query) []interface{} {
+	from, to := query.timeBounds()
+	limit := -1 // SQLite treats a negative limit as unbounded
+	if query.Limit > 0 {
+		limit = query.Limit
+	}
+	return []interface{}{
+		string(query.Exchange), string(query.Exchange),
+		query.Symbol, query.Symbol,
+		from, to,
+		limit,
+	}
+}
+
+// marshalLevels encodes bid and ask levels as JSON strings
+func marshalLevels(bids, asks []exchange.PriceLevel) (string, string, error) {
+	bidsJSON, err := json.Marshal(bids)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal bids: %w", err)
+	}
+	asksJSON, err := json.Marshal(asks)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal asks: %w", err)
+	}
+	return string(bidsJSON), string(asksJSON), nil
+}
+
+// unmarshalLevels decodes bid and ask levels from JSON strings
+func unmarshalLevels(bidsJSON, asksJSON string) ([]exchange.PriceLevel, []exchange.PriceLevel, error) {
+	var bids, asks []exchange.PriceLevel
+	if err := json.Unmarshal([]byte(bidsJSON), &bids); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal bids: %w", err)
+	}
+	if err := json.Unmarshal([]byte(asksJSON), &asks); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal asks: %w", err)
+	}
+	return bids, asks, nil
+}
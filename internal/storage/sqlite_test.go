@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestSQLite(t *testing.T) *SQLiteStorage {
+	t.Helper()
+	store, err := NewSQLite(filepath.Join(t.TempDir(), "orderbook.db"))
+	if err != nil {
+		t.Fatalf("NewSQLite() failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteSnapshotRoundTrip(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	base := time.UnixMilli(1700000000000)
+
+	for i, name := range []exchange.ExchangeName{exchange.Binance, exchange.Bybit, exchange.Binance} {
+		err := store.WriteSnapshot(ctx, &exchange.Snapshot{
+			Exchange:     name,
+			Symbol:       "BTCUSDT",
+			LastUpdateID: int64(i + 1),
+			Bids:         []exchange.PriceLevel{{Price: "50000.1", Quantity: "1.5"}},
+			Asks:         []exchange.PriceLevel{{Price: "50000.2", Quantity: "2"}},
+			Timestamp:    base.Add(time.Duration(i) * time.Second),
+		})
+		if err != nil {
+			t.Fatalf("WriteSnapshot() failed: %v", err)
+		}
+	}
+
+	snapshots, err := store.QuerySnapshots(ctx, Query{Exchange: exchange.Binance})
+	if err != nil {
+		t.Fatalf("QuerySnapshots() failed: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("Expected 2 binance snapshots, got %d", len(snapshots))
+	}
+	if snapshots[0].LastUpdateID != 1 || snapshots[1].LastUpdateID != 3 {
+		t.Errorf("Expected snapshots ordered by time, got IDs %d, %d", snapshots[0].LastUpdateID, snapshots[1].LastUpdateID)
+	}
+	if snapshots[0].Bids[0].Price != "50000.1" || snapshots[0].Asks[0].Quantity != "2" {
+		t.Errorf("Levels not preserved: %+v", snapshots[0])
+	}
+
+	limited, err := store.QuerySnapshots(ctx, Query{From: base.Add(time.Second), Limit: 1})
+	if err != nil {
+		t.Fatalf("QuerySnapshots() failed: %v", err)
+	}
+	if len(limited) != 1 || limited[0].Exchange != exchange.Bybit {
+		t.Errorf("Expected only the bybit snapshot, got %+v", limited)
+	}
+}
+
+func TestSQLiteStatsRoundTrip(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	err := store.WriteStats(ctx, StatsRecord{
+		Exchange:  exchange.Kraken,
+		Symbol:    "BTC/USD",
+		Timestamp: time.Now(),
+		Stats: types.Stats{
+			BestBid:            decimal.RequireFromString("50000.5"),
+			BestAsk:            decimal.RequireFromString("50001"),
+			EffectiveSpreadBps: decimal.RequireFromString("0.42"),
+			RealizedSpreadBps:  []types.HorizonSpread{{Horizon: 5 * time.Second, Bps: decimal.RequireFromString("0.2")}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteStats() failed: %v", err)
+	}
+
+	records, err := store.QueryStats(ctx, Query{Symbol: "BTC/USD"})
+	if err != nil {
+		t.Fatalf("QueryStats() failed: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 stats record, got %d", len(records))
+	}
+	if !records[0].Stats.BestBid.Equal(decimal.RequireFromString("50000.5")) {
+		t.Errorf("Expected best bid 50000.5, got %s", records[0].Stats.BestBid)
+	}
+	// Execution quality is part of the stats history
+	stats := records[0].Stats
+	if !stats.EffectiveSpreadBps.Equal(decimal.RequireFromString("0.42")) || len(stats.RealizedSpreadBps) != 1 ||
+		stats.RealizedSpreadBps[0].Horizon != 5*time.Second || !stats.RealizedSpreadBps[0].Bps.Equal(decimal.RequireFromString("0.2")) {
+		t.Errorf("Expected the effective and realized spreads, got %s and %+v", stats.EffectiveSpreadBps, stats.RealizedSpreadBps)
+	}
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/types"
+)
+
+// Driver represents supported storage backend identifiers
+type Driver string
+
+const (
+	DriverNone       Driver = ""
+	DriverSQLite     Driver = "sqlite"
+	DriverClickHouse Driver = "clickhouse"
+)
+
+// Config holds configuration for creating a storage backend
+type Config struct {
+	Driver Driver
+	DSN    string // File path for SQLite, HTTP URL for ClickHouse (e.g., http://localhost:8123/?database=orderbook)
+}
+
+// Storage defines the interface that all storage backends must implement.
+// Recording, history and replay all read and write through this interface.
+type Storage interface {
+	// WriteSnapshot persists a full orderbook snapshot
+	WriteSnapshot(ctx context.Context, snapshot *exchange.Snapshot) error
+
+	// WriteUpdate persists a single depth update
+	WriteUpdate(ctx context.Context, update *exchange.DepthUpdate) error
+
+	// WriteStats persists a stats sample for an exchange
+	WriteStats(ctx context.Context, record StatsRecord) error
+
+	// QuerySnapshots returns snapshots matching the query, ordered by time
+	QuerySnapshots(ctx context.Context, query Query) ([]*exchange.Snapshot, error)
+
+	// QueryUpdates returns depth updates matching the query, ordered by time
+	QueryUpdates(ctx context.Context, query Query) ([]*exchange.DepthUpdate, error)
+
+	// QueryStats returns stats samples matching the query, ordered by time
+	QueryStats(ctx context.Context, query Query) ([]StatsRecord, error)
+
+	// Close releases the underlying resources
+	Close() error
+}
+
+// StatsRecord is a timestamped stats sample for an exchange
+type StatsRecord struct {
+	Exchange  exchange.ExchangeName
+	Symbol    string
+	Timestamp time.Time
+	Stats     types.Stats
+}
+
+// Query filters records by exchange, symbol and time range
+type Query struct {
+	Exchange exchange.ExchangeName // Empty matches all exchanges
+	Symbol   string                // Empty matches all symbols
+	From     time.Time             // Zero means unbounded
+	To       time.Time             // Zero means unbounded
+	Limit    int                   // Zero means no limit
+}
+
+// New creates a storage backend based on the configuration.
+// It returns nil without error when storage is disabled.
+func New(config Config) (Storage, error) {
+	switch config.Driver {
+	case DriverNone:
+		return nil, nil
+
+	case DriverSQLite:
+		return NewSQLite(config.DSN)
+
+	case DriverClickHouse:
+		return NewClickHouse(config.DSN)
+
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", config.Driver)
+	}
+}
+
+// timeBounds returns the query time range in unix milliseconds
+func (q Query) timeBounds() (int64, int64) {
+	from := int64(0)
+	if !q.From.IsZero() {
+		from = q.From.UnixMilli()
+	}
+	to := int64(1<<63 - 1)
+	if !q.To.IsZero() {
+		to = q.To.UnixMilli()
+	}
+	return from, to
+}
@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Exporter names accepted by NewExporter
+const (
+	ExporterNone   = "none"   // Tracing disabled
+	ExporterStderr = "stderr" // One JSON span per line, for local investigations
+	ExporterOTLP   = "otlp"   // OTLP over HTTP with JSON encoding, e.g. to an OpenTelemetry Collector
+)
+
+// DefaultOTLPEndpoint is the OTLP/HTTP endpoint of a local collector
+const DefaultOTLPEndpoint = "http://localhost:4318"
+
+// ValidateExporter checks that name is ExporterNone, ExporterStderr or ExporterOTLP
+func ValidateExporter(name string) error {
+	switch name {
+	case ExporterNone, ExporterStderr, ExporterOTLP:
+		return nil
+	}
+	return fmt.Errorf("unknown tracing exporter %q (expected none, stderr or otlp)", name)
+}
+
+// NewExporter creates the exporter called name, nil for ExporterNone. Spans are
+// attributed to service; endpoint is the OTLP/HTTP base URL.
+func NewExporter(name, service, endpoint string, stderr io.Writer) (Exporter, error) {
+	if err := ValidateExporter(name); err != nil {
+		return nil, err
+	}
+	switch name {
+	case ExporterStderr:
+		return NewWriterExporter(stderr), nil
+	case ExporterOTLP:
+		if endpoint == "" {
+			endpoint = DefaultOTLPEndpoint
+		}
+		return NewOTLPExporter(endpoint, service), nil
+	}
+	return nil, nil
+}
+
+// jsonSpan is a span as written by WriterExporter
+type jsonSpan struct {
+	TraceID    string            `json:"traceId"`
+	SpanID     string            `json:"spanId"`
+	ParentID   string            `json:"parentSpanId,omitempty"`
+	Name       string            `json:"name"`
+	Start      int64             `json:"startUnixNano"`
+	DurationUs float64           `json:"durationMicros"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// WriterExporter writes spans as JSON lines
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter creates an exporter writing one JSON object per span to w
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// Export implements Exporter
+func (e *WriterExporter) Export(ctx context.Context, spans []*Span) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range spans {
+		start, end := s.Times()
+		line := jsonSpan{
+			TraceID:    s.TraceID(),
+			SpanID:     s.SpanID(),
+			ParentID:   s.ParentID(),
+			Name:       s.Name(),
+			Start:      start.UnixNano(),
+			DurationUs: float64(end.Sub(start).Nanoseconds()) / 1e3,
+		}
+		if attrs := s.Attributes(); len(attrs) > 0 {
+			line.Attributes = make(map[string]string, len(attrs))
+			for _, attr := range attrs {
+				line.Attributes[attr.Key] = attr.Value
+			}
+		}
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("failed to encode span: %w", err)
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+// OTLP/HTTP JSON request body (opentelemetry-proto trace/v1, JSON mapping)
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+)
+
+// otlpSpanKindInternal is SPAN_KIND_INTERNAL
+const otlpSpanKindInternal = 1
+
+// OTLPExporter posts spans to an OTLP/HTTP receiver with the JSON encoding
+type OTLPExporter struct {
+	url     string
+	service string
+	client  *http.Client
+}
+
+// NewOTLPExporter creates an exporter posting to endpoint's /v1/traces (e.g.
+// "http://localhost:4318"), attributing spans to service
+func NewOTLPExporter(endpoint, service string) *OTLPExporter {
+	return &OTLPExporter{
+		url:     strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		service: service,
+		client:  &http.Client{},
+	}
+}
+
+// Export implements Exporter
+func (e *OTLPExporter) Export(ctx context.Context, spans []*Span) error {
+	converted := make([]otlpSpan, len(spans))
+	for i, s := range spans {
+		start, end := s.Times()
+		converted[i] = otlpSpan{
+			TraceID:           s.TraceID(),
+			SpanID:            s.SpanID(),
+			ParentSpanID:      s.ParentID(),
+			Name:              s.Name(),
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		}
+		for _, attr := range s.Attributes() {
+			converted[i].Attributes = append(converted[i].Attributes, otlpAttribute{Key: attr.Key, Value: otlpValue{attr.Value}})
+		}
+	}
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{e.service}},
+		}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "orderbook"}, Spans: converted}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post spans: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
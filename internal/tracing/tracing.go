@@ -0,0 +1,254 @@
+// Package tracing records sampled spans of the update path (receipt, parse,
+// queueing, apply, publish and broadcast) and exports them in the OpenTelemetry
+// data model, so latency contributors can be found in production. Spans are
+// nil when a trace is not sampled and every method accepts a nil *Span, so
+// instrumented code needs no checks.
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Attribute is a key/value annotation of a span
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Span is a timed operation of a trace
+type Span struct {
+	tracer  *Tracer
+	parent  *Span
+	traceID [16]byte
+	spanID  [8]byte
+	name    string
+	start   time.Time
+	end     time.Time
+	attrs   []Attribute
+	ended   atomic.Bool
+}
+
+// TraceID returns the hex trace ID, empty for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// SpanID returns the hex span ID, empty for a nil span
+func (s *Span) SpanID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.spanID[:])
+}
+
+// ParentID returns the hex ID of the parent span, empty for a root span
+func (s *Span) ParentID() string {
+	if s == nil || s.parent == nil {
+		return ""
+	}
+	return s.parent.SpanID()
+}
+
+// Name returns the name of the span
+func (s *Span) Name() string {
+	if s == nil {
+		return ""
+	}
+	return s.name
+}
+
+// Times returns the start and end of the span, zero end while it is running
+func (s *Span) Times() (start, end time.Time) {
+	if s == nil {
+		return time.Time{}, time.Time{}
+	}
+	return s.start, s.end
+}
+
+// Attributes returns the annotations of the span
+func (s *Span) Attributes() []Attribute {
+	if s == nil {
+		return nil
+	}
+	return s.attrs
+}
+
+// Parent returns the span this span was started from, nil for a root span
+func (s *Span) Parent() *Span {
+	if s == nil {
+		return nil
+	}
+	return s.parent
+}
+
+// Child starts a span of the same trace, nil when s is nil
+func (s *Span) Child(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	return s.tracer.newSpan(name, s)
+}
+
+// SetAttribute annotates the span. It must not be called once the span ended.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, Attribute{Key: key, Value: value})
+}
+
+// End stops the span and queues it for export. Only the first call has an effect,
+// so a span shared by several updates of one message may be ended by each.
+func (s *Span) End() {
+	if s == nil || !s.ended.CompareAndSwap(false, true) {
+		return
+	}
+	s.end = time.Now()
+	s.tracer.queue(s)
+}
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+}
+
+// Batching of finished spans
+const (
+	queueSize     = 4096
+	batchSize     = 512
+	flushInterval = time.Second
+	exportTimeout = 10 * time.Second
+)
+
+// Tracer samples traces and exports their spans in batches from a background goroutine
+type Tracer struct {
+	sampleRatio float64
+	exporter    Exporter
+	spans       chan *Span
+	dropped     atomic.Int64 // Spans discarded because the export queue was full
+	stop        chan struct{}
+	done        chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewTracer creates a tracer sampling sampleRatio of the traces (0 to 1) and
+// starts exporting their spans through exporter
+func NewTracer(sampleRatio float64, exporter Exporter) *Tracer {
+	t := &Tracer{
+		sampleRatio: sampleRatio,
+		exporter:    exporter,
+		spans:       make(chan *Span, queueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Start starts the root span of a new trace, or returns nil when the trace is not
+// sampled. A nil tracer samples nothing.
+func (t *Tracer) Start(name string) *Span {
+	if t == nil || t.sampleRatio <= 0 || (t.sampleRatio < 1 && rand.Float64() >= t.sampleRatio) {
+		return nil
+	}
+	return t.newSpan(name, nil)
+}
+
+// Dropped returns the number of spans discarded because the export queue was full
+func (t *Tracer) Dropped() int64 {
+	return t.dropped.Load()
+}
+
+// Stop exports the queued spans and stops the tracer
+func (t *Tracer) Stop() {
+	t.stopOnce.Do(func() { close(t.stop) })
+	<-t.done
+}
+
+// newSpan starts a span under parent, or a new trace when parent is nil
+func (t *Tracer) newSpan(name string, parent *Span) *Span {
+	s := &Span{tracer: t, parent: parent, name: name, start: time.Now()}
+	if parent != nil {
+		s.traceID = parent.traceID
+	} else {
+		binary.LittleEndian.PutUint64(s.traceID[:8], rand.Uint64())
+		binary.LittleEndian.PutUint64(s.traceID[8:], rand.Uint64())
+	}
+	binary.LittleEndian.PutUint64(s.spanID[:], rand.Uint64()|1) // Non-zero, as the zero ID is invalid
+	return s
+}
+
+// queue hands a finished span to the exporter without blocking
+func (t *Tracer) queue(s *Span) {
+	select {
+	case t.spans <- s:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+// run exports spans in batches of batchSize, or every flushInterval
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := t.exporter.Export(ctx, batch); err != nil {
+			log.Printf("Failed to export %d spans: %v", len(batch), err)
+		}
+		batch = make([]*Span, 0, batchSize)
+	}
+
+	for {
+		select {
+		case s := <-t.spans:
+			batch = append(batch, s)
+			if len(batch) == batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case s := <-t.spans:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// global is the tracer used by Start
+var global atomic.Pointer[Tracer]
+
+// SetTracer sets the tracer used by Start, nil disables tracing
+func SetTracer(t *Tracer) {
+	global.Store(t)
+}
+
+// Start starts a root span with the tracer set by SetTracer, nil when tracing is
+// disabled or the trace is not sampled
+func Start(name string) *Span {
+	return global.Load().Start(name)
+}
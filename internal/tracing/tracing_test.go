@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordingExporter keeps the exported spans
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *recordingExporter) Export(ctx context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(1, exporter)
+
+	root := tracer.Start("update")
+	root.SetAttribute("exchange", "okx")
+	child := root.Child("apply")
+	child.End()
+	root.End()
+	root.End() // Ending twice exports once
+	tracer.Stop()
+
+	if len(exporter.spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(exporter.spans))
+	}
+	apply, update := exporter.spans[0], exporter.spans[1]
+	if apply.Name() != "apply" || apply.ParentID() != update.SpanID() || apply.TraceID() != update.TraceID() {
+		t.Errorf("Expected apply to be a child of update in the same trace")
+	}
+	if update.ParentID() != "" || len(update.TraceID()) != 32 || len(update.SpanID()) != 16 {
+		t.Errorf("Expected a root span with hex IDs, got trace %q span %q parent %q", update.TraceID(), update.SpanID(), update.ParentID())
+	}
+	if start, end := update.Times(); end.Before(start) {
+		t.Errorf("Expected the span to end after it started")
+	}
+
+	// Unsampled traces are nil spans whose methods do nothing
+	unsampled := NewTracer(0, exporter)
+	span := unsampled.Start("update")
+	if span != nil {
+		t.Errorf("Expected no span at a zero sample ratio")
+	}
+	span.Child("apply").End()
+	span.SetAttribute("exchange", "okx")
+	span.End()
+	unsampled.Stop()
+	if (*Tracer)(nil).Start("update") != nil || span.Parent() != nil {
+		t.Errorf("Expected a nil tracer to sample nothing")
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body []byte
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON post to /v1/traces, got %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer collector.Close()
+
+	exporter := &recordingExporter{}
+	tracer := NewTracer(1, exporter)
+	span := tracer.Start("broadcast")
+	span.SetAttribute("clients", "3")
+	span.End()
+	tracer.Stop()
+
+	if err := NewOTLPExporter(collector.URL+"/", "orderbook").Export(context.Background(), exporter.spans); err != nil {
+		t.Fatalf("Export() failed: %v", err)
+	}
+	var request otlpRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatalf("Expected an OTLP JSON body, got %v", err)
+	}
+	resource := request.ResourceSpans[0]
+	if resource.Resource.Attributes[0].Value.StringValue != "orderbook" {
+		t.Errorf("Expected service.name orderbook, got %+v", resource.Resource.Attributes)
+	}
+	got := resource.ScopeSpans[0].Spans[0]
+	if got.Name != "broadcast" || got.TraceID != span.TraceID() || got.Attributes[0].Value.StringValue != "3" {
+		t.Errorf("Expected the broadcast span, got %+v", got)
+	}
+
+	if _, err := NewExporter("jaeger", "orderbook", "", io.Discard); err == nil {
+		t.Errorf("Expected an error for an unknown exporter")
+	}
+	if exporter, err := NewExporter(ExporterNone, "orderbook", "", io.Discard); err != nil || exporter != nil {
+		t.Errorf("Expected no exporter for none, got %v, %v", exporter, err)
+	}
+}
@@ -0,0 +1,313 @@
+// Package transport is the network layer shared by the exchange adapters: one
+// dialer with cached DNS answers, dial timeouts, per-host connection limits and
+// optional happy eyeballs (RFC 8305) racing of the address families, and the HTTP
+// clients and WebSocket dialers built on it. Sharing it lets REST requests reuse
+// connections and keeps a reconnect storm from opening unbounded sockets.
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Config holds the settings of a Transport
+type Config struct {
+	DialTimeout     time.Duration // Timeout of each dial, DNS lookup included, 0 disables
+	DNSCacheTTL     time.Duration // Lifetime of cached DNS answers, 0 disables caching
+	MaxConnsPerHost int           // REST and WebSocket connections open to one host, each, 0 is unlimited
+	HappyEyeballs   bool          // Race IPv6 and IPv4 instead of trying the addresses in order
+}
+
+// DefaultConfig returns the settings of the default transport
+func DefaultConfig() Config {
+	return Config{
+		DialTimeout:     10 * time.Second,
+		DNSCacheTTL:     5 * time.Minute,
+		MaxConnsPerHost: 16,
+		HappyEyeballs:   true,
+	}
+}
+
+// fallbackDelay is how long the preferred address family gets before the other
+// one is tried in parallel, as in the net package
+const fallbackDelay = 300 * time.Millisecond
+
+// dnsEntry is a cached DNS answer
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// Transport dials exchange connections
+type Transport struct {
+	config Config
+	lookup func(ctx context.Context, host string) ([]string, error)
+	dial   func(ctx context.Context, network, address string) (net.Conn, error)
+	http   *http.Transport
+
+	mu    sync.Mutex
+	cache map[string]dnsEntry
+	slots map[string]chan struct{} // WebSocket connection slots by host:port
+}
+
+// New creates a transport with config
+func New(config Config) *Transport {
+	dialer := &net.Dialer{KeepAlive: 30 * time.Second}
+	t := &Transport{
+		config: config,
+		lookup: net.DefaultResolver.LookupHost,
+		dial:   dialer.DialContext,
+		cache:  make(map[string]dnsEntry),
+		slots:  make(map[string]chan struct{}),
+	}
+	t.http = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           t.dialContext,
+		ForceAttemptHTTP2:     true,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		MaxIdleConnsPerHost:   4,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return t
+}
+
+// Client returns an HTTP client with a request timeout, sharing the pooled
+// connections of every client of t
+func (t *Transport) Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: t.http, Timeout: timeout}
+}
+
+// Dialer returns a WebSocket dialer with a handshake timeout. At most
+// MaxConnsPerHost of its connections are open to a host; further dials wait for
+// one to close or for their context to end.
+func (t *Transport) Dialer(handshakeTimeout time.Duration) *websocket.Dialer {
+	return &websocket.Dialer{
+		NetDialContext:   t.DialContext,
+		HandshakeTimeout: handshakeTimeout,
+	}
+}
+
+// DialContext connects to address within the per-host connection limit
+func (t *Transport) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	release, err := t.acquire(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := t.dialContext(ctx, network, address)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &limitedConn{Conn: conn, release: release}, nil
+}
+
+// acquire takes a connection slot of address, returning the function giving it back
+func (t *Transport) acquire(ctx context.Context, address string) (func(), error) {
+	if t.config.MaxConnsPerHost <= 0 {
+		return func() {}, nil
+	}
+	t.mu.Lock()
+	slots, ok := t.slots[address]
+	if !ok {
+		slots = make(chan struct{}, t.config.MaxConnsPerHost)
+		t.slots[address] = slots
+	}
+	t.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-slots }) }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for a connection to %s (limit %d): %w", address, t.config.MaxConnsPerHost, ctx.Err())
+	}
+}
+
+// dialContext resolves the host of address through the cache and connects to one
+// of its addresses
+func (t *Transport) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if t.config.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.config.DialTimeout)
+		defer cancel()
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := t.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	primary, fallback := partition(network, addrs)
+	if len(primary) == 0 {
+		return nil, fmt.Errorf("no %s address for %s", network, host)
+	}
+	if !t.config.HappyEyeballs || len(fallback) == 0 {
+		return t.dialSerial(ctx, network, append(primary, fallback...), port)
+	}
+	return t.dialParallel(ctx, network, primary, fallback, port)
+}
+
+// resolve returns the addresses of host, from the cache while the answer is fresh.
+// A stale answer is used when the lookup fails, so a resolver outage does not stop
+// reconnects to known hosts.
+func (t *Transport) resolve(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+	if t.config.DNSCacheTTL <= 0 {
+		return t.lookup(ctx, host)
+	}
+
+	t.mu.Lock()
+	entry, ok := t.cache[host]
+	t.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := t.lookup(ctx, host)
+	if err != nil {
+		if ok {
+			log.Printf("DNS lookup of %s failed, using the cached answer: %v", host, err)
+			return entry.addrs, nil
+		}
+		return nil, err
+	}
+	t.mu.Lock()
+	t.cache[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(t.config.DNSCacheTTL)}
+	t.mu.Unlock()
+	return addrs, nil
+}
+
+// partition splits addrs into those of the family of the first address and the
+// others, keeping only the family of a "tcp4" or "tcp6" network
+func partition(network string, addrs []string) (primary, fallback []string) {
+	isV4 := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		return ip != nil && ip.To4() != nil
+	}
+	for _, addr := range addrs {
+		switch {
+		case network == "tcp4" && !isV4(addr), network == "tcp6" && isV4(addr):
+		case len(primary) == 0 || isV4(addr) == isV4(primary[0]):
+			primary = append(primary, addr)
+		default:
+			fallback = append(fallback, addr)
+		}
+	}
+	return primary, fallback
+}
+
+// dialSerial tries addrs in order, returning the first connection
+func (t *Transport) dialSerial(ctx context.Context, network string, addrs []string, port string) (net.Conn, error) {
+	var errs []error
+	for _, addr := range addrs {
+		conn, err := t.dial(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// dialParallel dials the primary addresses and, after fallbackDelay or once they
+// failed, the fallback addresses too, returning the first connection
+func (t *Transport) dialParallel(ctx context.Context, network string, primary, fallback []string, port string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan result, 2)
+	race := func(addrs []string) {
+		go func() {
+			conn, err := t.dialSerial(ctx, network, addrs, port)
+			results <- result{conn, err}
+		}()
+	}
+
+	race(primary)
+	timer := time.NewTimer(fallbackDelay)
+	defer timer.Stop()
+	pending, fallbackStarted := 1, false
+	var errs []error
+	for {
+		select {
+		case <-timer.C:
+			if !fallbackStarted {
+				race(fallback)
+				pending, fallbackStarted = pending+1, true
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				if pending > 0 {
+					// Close the connection of the losing family should it complete
+					go func() {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}()
+				}
+				return r.conn, nil
+			}
+			errs = append(errs, r.err)
+			if !fallbackStarted {
+				race(fallback)
+				pending, fallbackStarted = pending+1, true
+			} else if pending == 0 {
+				return nil, errors.Join(errs...)
+			}
+		}
+	}
+}
+
+// limitedConn gives its connection slot back when closed
+type limitedConn struct {
+	net.Conn
+	release func()
+}
+
+// Close implements net.Conn
+func (c *limitedConn) Close() error {
+	c.release()
+	return c.Conn.Close()
+}
+
+// shared is the transport returned by Default
+var shared atomic.Pointer[Transport]
+
+func init() {
+	shared.Store(New(DefaultConfig()))
+}
+
+// Default returns the transport shared by the exchange adapters
+func Default() *Transport {
+	return shared.Load()
+}
+
+// SetDefault replaces the shared transport. It must be called before the
+// adapters connect.
+func SetDefault(t *Transport) {
+	shared.Store(t)
+}
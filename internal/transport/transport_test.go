@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNetwork answers lookups from a table and connects to the listed addresses
+type fakeNetwork struct {
+	mu      sync.Mutex
+	hosts   map[string][]string
+	fail    bool            // Fail lookups
+	blocked map[string]bool // Addresses whose dials hang until cancelled
+	lookups int
+	dials   []string
+}
+
+func (f *fakeNetwork) lookup(ctx context.Context, host string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups++
+	if f.fail {
+		return nil, errors.New("resolver unavailable")
+	}
+	return f.hosts[host], nil
+}
+
+func (f *fakeNetwork) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	f.mu.Lock()
+	f.dials = append(f.dials, address)
+	blocked := f.blocked[address]
+	f.mu.Unlock()
+	if blocked {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+func newFakeTransport(config Config, network *fakeNetwork) *Transport {
+	t := New(config)
+	t.lookup = network.lookup
+	t.dial = network.dial
+	return t
+}
+
+func TestDNSCache(t *testing.T) {
+	network := &fakeNetwork{hosts: map[string][]string{"api.example.com": {"10.0.0.1"}}}
+	tr := newFakeTransport(Config{DNSCacheTTL: time.Minute}, network)
+
+	for range 3 {
+		conn, err := tr.DialContext(context.Background(), "tcp", "api.example.com:443")
+		if err != nil {
+			t.Fatalf("DialContext() failed: %v", err)
+		}
+		conn.Close()
+	}
+	if network.lookups != 1 || network.dials[0] != "10.0.0.1:443" {
+		t.Errorf("Expected 1 lookup and dials to 10.0.0.1:443, got %d lookups and %v", network.lookups, network.dials)
+	}
+
+	// An expired answer is still used while the resolver fails
+	tr.cache["api.example.com"] = dnsEntry{addrs: []string{"10.0.0.1"}, expires: time.Now().Add(-time.Second)}
+	network.fail = true
+	if _, err := tr.DialContext(context.Background(), "tcp", "api.example.com:443"); err != nil {
+		t.Errorf("Expected the stale answer to be used, got %v", err)
+	}
+	if _, err := tr.DialContext(context.Background(), "tcp", "other.example.com:443"); err == nil {
+		t.Errorf("Expected an error for an unknown host while the resolver fails")
+	}
+}
+
+func TestConnectionLimit(t *testing.T) {
+	network := &fakeNetwork{}
+	tr := newFakeTransport(Config{MaxConnsPerHost: 1}, network)
+
+	first, err := tr.DialContext(context.Background(), "tcp", "10.0.0.1:443")
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	if other, err := tr.DialContext(context.Background(), "tcp", "10.0.0.2:443"); err != nil {
+		t.Errorf("Expected another host to have its own limit, got %v", err)
+	} else {
+		other.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := tr.DialContext(ctx, "tcp", "10.0.0.1:443"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the second connection to wait for a slot, got %v", err)
+	}
+
+	first.Close()
+	first.Close() // Closing twice gives the slot back once
+	second, err := tr.DialContext(context.Background(), "tcp", "10.0.0.1:443")
+	if err != nil {
+		t.Fatalf("Expected a slot once the first connection closed, got %v", err)
+	}
+	second.Close()
+}
+
+func TestHappyEyeballs(t *testing.T) {
+	network := &fakeNetwork{
+		hosts:   map[string][]string{"ws.example.com": {"2001:db8::1", "10.0.0.1"}},
+		blocked: map[string]bool{"[2001:db8::1]:443": true},
+	}
+
+	tr := newFakeTransport(Config{DialTimeout: 5 * time.Second, HappyEyeballs: true}, network)
+	start := time.Now()
+	conn, err := tr.DialContext(context.Background(), "tcp", "ws.example.com:443")
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	conn.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected IPv4 to connect after the fallback delay, took %v", elapsed)
+	}
+
+	// Without happy eyeballs the hanging IPv6 address uses up the dial timeout
+	tr = newFakeTransport(Config{DialTimeout: 50 * time.Millisecond}, network)
+	if _, err := tr.DialContext(context.Background(), "tcp", "ws.example.com:443"); err == nil {
+		t.Errorf("Expected the serial dial to time out on the IPv6 address")
+	}
+}
+
+func TestPartition(t *testing.T) {
+	tests := []struct {
+		network  string
+		addrs    []string
+		primary  int
+		fallback int
+	}{
+		{"tcp", []string{"2001:db8::1", "10.0.0.1", "2001:db8::2"}, 2, 1},
+		{"tcp", []string{"10.0.0.1", "10.0.0.2"}, 2, 0},
+		{"tcp4", []string{"2001:db8::1", "10.0.0.1"}, 1, 0},
+		{"tcp6", []string{"10.0.0.1"}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		primary, fallback := partition(tt.network, tt.addrs)
+		if len(primary) != tt.primary || len(fallback) != tt.fallback {
+			t.Errorf("%s %v: Expected %d primary and %d fallback addresses, got %v and %v", tt.network, tt.addrs, tt.primary, tt.fallback, primary, fallback)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package types
+
+import "fmt"
+
+// CrossedPolicy selects how a book that became crossed (best bid >= best ask) is healed
+type CrossedPolicy string
+
+const (
+	// CrossedClean removes the stale levels on the side opposite to the update that crossed the book
+	CrossedClean CrossedPolicy = "clean"
+	// CrossedResync reloads the book from a fresh snapshot on the next reinitialization check
+	CrossedResync CrossedPolicy = "resync"
+	// CrossedIgnore only flags the book
+	CrossedIgnore CrossedPolicy = "ignore"
+)
+
+// ParseCrossedPolicy parses "clean", "resync" or "ignore"
+func ParseCrossedPolicy(s string) (CrossedPolicy, error) {
+	switch policy := CrossedPolicy(s); policy {
+	case CrossedClean, CrossedResync, CrossedIgnore:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown crossed book policy %q (clean, resync, ignore)", s)
+}
@@ -0,0 +1,32 @@
+package types
+
+import "github.com/shopspring/decimal"
+
+var bpsDivisor = decimal.NewFromInt(10000)
+
+// FeeSchedule holds the trading fees of an exchange in basis points of notional
+type FeeSchedule struct {
+	MakerBps float64
+	TakerBps float64
+}
+
+// TakerRate returns the taker fee as a fraction of notional
+func (f FeeSchedule) TakerRate() decimal.Decimal {
+	return decimal.NewFromFloat(f.TakerBps).Div(bpsDivisor)
+}
+
+// AdjustBid returns the proceeds per unit of selling into a bid after the taker fee
+func (f FeeSchedule) AdjustBid(price decimal.Decimal) decimal.Decimal {
+	if f.TakerBps == 0 {
+		return price
+	}
+	return price.Mul(decimal.NewFromInt(1).Sub(f.TakerRate()))
+}
+
+// AdjustAsk returns the cost per unit of buying from an ask after the taker fee
+func (f FeeSchedule) AdjustAsk(price decimal.Decimal) decimal.Decimal {
+	if f.TakerBps == 0 {
+		return price
+	}
+	return price.Mul(decimal.NewFromInt(1).Add(f.TakerRate()))
+}
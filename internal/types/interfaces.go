@@ -12,10 +12,10 @@ type PriceAggregator interface {
 	// GetTickLevel returns the current tick level
 	GetTickLevel() TickLevel
 
-	// AggregateBids aggregates bid price levels
+	// AggregateBids aggregates bid price levels, sorted by price descending
 	AggregateBids(levels []PriceLevel) []PriceLevel
 
-	// AggregateAsks aggregates ask price levels
+	// AggregateAsks aggregates ask price levels, sorted by price ascending
 	AggregateAsks(levels []PriceLevel) []PriceLevel
 }
 
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// TickPresets maps base assets to tick levels suited to their price magnitude, finest first
+var TickPresets = map[string][]TickLevel{
+	"BTC":  {1, 10, 50, 100},
+	"ETH":  {0.1, 1, 5, 10},
+	"BNB":  {0.01, 0.1, 0.5, 1},
+	"SOL":  {0.01, 0.1, 0.5, 1},
+	"XRP":  {0.0001, 0.001, 0.005, 0.01},
+	"ADA":  {0.0001, 0.001, 0.005, 0.01},
+	"DOGE": {0.0001, 0.001},
+}
+
+// DefaultTickLevels is used when a symbol has no preset and no instrument metadata
+var DefaultTickLevels = []TickLevel{Tick1, Tick10, Tick50, Tick100}
+
+// DefaultLadderBuckets is the number of buckets at the current tick on each side
+// of the mid bucket of consolidated ladders
+const DefaultLadderBuckets = 20
+
+// tickMultiples are the multiples of an instrument tick size offered when no preset matches
+var tickMultiples = []float64{1, 10, 50, 100}
+
+// quoteAssets are stripped from symbols to find their base asset, longest first
+var quoteAssets = []string{"USDT", "USDC", "FDUSD", "USD", "PERP"}
+
+// BaseAsset returns the base asset of a symbol (e.g., "BTCUSDT", "BTC-USD", "BTC/USD" -> "BTC")
+func BaseAsset(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if i := strings.IndexAny(symbol, "-/_"); i > 0 {
+		return symbol[:i]
+	}
+	for _, quote := range quoteAssets {
+		if base, ok := strings.CutSuffix(symbol, quote); ok && base != "" {
+			return base
+		}
+	}
+	return symbol
+}
+
+// QuoteAsset returns the quote asset of a symbol (e.g., "BTCUSDT" -> "USDT", "BTC/USD" -> "USD"),
+// or an empty string if it has none of the known quote assets
+func QuoteAsset(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if i := strings.IndexAny(symbol, "-/_"); i > 0 {
+		return symbol[i+1:]
+	}
+	for _, quote := range quoteAssets {
+		if base, ok := strings.CutSuffix(symbol, quote); ok && base != "" {
+			return quote
+		}
+	}
+	return ""
+}
+
+// TickLevelsForSymbol returns the preset tick levels of the symbol's base asset
+func TickLevelsForSymbol(symbol string) ([]TickLevel, bool) {
+	levels, ok := TickPresets[BaseAsset(symbol)]
+	return levels, ok
+}
+
+// TickLevelsForTickSize derives tick levels as multiples of an instrument's minimum price increment
+func TickLevelsForTickSize(tickSize float64) []TickLevel {
+	if tickSize <= 0 {
+		return DefaultTickLevels
+	}
+
+	// Round away float noise such as 0.1*3 so levels compare equal to client input
+	decimals := int(math.Max(0, -math.Floor(math.Log10(tickSize)))) + 1
+	scale := math.Pow(10, float64(decimals))
+
+	levels := make([]TickLevel, len(tickMultiples))
+	for i, multiple := range tickMultiples {
+		levels[i] = TickLevel(math.Round(tickSize*multiple*scale) / scale)
+	}
+	return levels
+}
+
+// ContainsTickLevel reports whether tick is one of levels
+func ContainsTickLevel(levels []TickLevel, tick TickLevel) bool {
+	for _, level := range levels {
+		if level == tick {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package types
+
+import "testing"
+
+func TestTickLevelsForSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		first  TickLevel
+		found  bool
+	}{
+		{"BTCUSDT", 1, true},
+		{"ETH-USD", 0.1, true},
+		{"DOGEUSDT", 0.0001, true},
+		{"sol/usdc", 0.01, true},
+		{"PEPEUSDT", 0, false},
+	}
+
+	for _, tt := range tests {
+		levels, ok := TickLevelsForSymbol(tt.symbol)
+		if ok != tt.found {
+			t.Errorf("%s: expected found %v, got %v", tt.symbol, tt.found, ok)
+			continue
+		}
+		if ok && levels[0] != tt.first {
+			t.Errorf("%s: expected first tick %v, got %v", tt.symbol, tt.first, levels[0])
+		}
+	}
+}
+
+func TestQuoteAsset(t *testing.T) {
+	tests := []struct {
+		symbol string
+		quote  string
+	}{
+		{"BTCUSDT", "USDT"},
+		{"btc-usd", "USD"},
+		{"USDT/USD", "USD"},
+		{"ETHFDUSD", "FDUSD"},
+		{"BTC", ""},
+	}
+
+	for _, tt := range tests {
+		if quote := QuoteAsset(tt.symbol); quote != tt.quote {
+			t.Errorf("%s: expected quote %q, got %q", tt.symbol, tt.quote, quote)
+		}
+	}
+}
+
+func TestTickLevelsForTickSize(t *testing.T) {
+	levels := TickLevelsForTickSize(0.00001)
+	expected := []TickLevel{0.00001, 0.0001, 0.0005, 0.001}
+
+	for i, level := range expected {
+		if levels[i] != level {
+			t.Errorf("Expected level %d to be %v, got %v", i, level, levels[i])
+		}
+	}
+}
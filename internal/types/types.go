@@ -17,15 +17,6 @@ const (
 	Tick100 TickLevel = 100.0
 )
 
-// AvailableTickLevels defines the available tick levels in order of precision
-var AvailableTickLevels = []TickLevel{
-	Tick01,
-	Tick1,
-	Tick10,
-	Tick50,
-	Tick100,
-}
-
 // PriceLevel represents a single price level in the order book
 type PriceLevel struct {
 	Price    decimal.Decimal
@@ -34,15 +25,29 @@ type PriceLevel struct {
 
 // Stats holds statistical information about the order book
 type Stats struct {
-	EventsProcessed int64
-	LastEventTime   time.Time
-	ConnectionTime  time.Time
-	BufferedEvents  int
-	BidLevels       int
-	AskLevels       int
-	BestBid         decimal.Decimal
-	BestAsk         decimal.Decimal
-	Spread          decimal.Decimal
+	EventsProcessed   int64 // Updates applied to the book
+	EventsBuffered    int64 // Updates buffered while uninitialized or out of sequence
+	EventsDropped     int64 // Buffered updates discarded as stale or superseded by a snapshot
+	Gaps              int64 // Sequence gaps detected after initialization
+	Resyncs           int64 // Reloads from a fresh snapshot after initialization
+	BufferOverflows   int64 // Reloads triggered by buffered updates exceeding the threshold
+	CrossedBook       bool  // Best bid currently at or above the best ask
+	CrossedBooks      int64 // Times the book became crossed
+	MalformedLevels   int64 // Levels rejected at ingestion (unparseable, non-positive price or negative quantity)
+	MalformedMessages int64 // Snapshots and updates with at least one rejected level
+	Expiries          int64 // Times the book was cleared for exceeding its max age without data
+	EventsPerSecond   float64
+	ApplyTime         time.Duration // Rolling average time spent applying one update
+	LastEventTime     time.Time
+	EventLatency      time.Duration // Delay between the last venue event and its processing, corrected for clock skew
+	ConnectionTime    time.Time
+	BufferedEvents    int
+	BidLevels         int
+	AskLevels         int
+	PrunedLevels      int64 // Levels dropped by memory-bounding pruning since start
+	BestBid           decimal.Decimal
+	BestAsk           decimal.Decimal
+	Spread            decimal.Decimal
 
 	// Liquidity depth metrics (in base asset units)
 	BidLiquidity05Pct decimal.Decimal // Total bid size within 0.5% of mid
@@ -57,38 +62,84 @@ type Stats struct {
 	DeltaLiquidity2Pct  decimal.Decimal // BidLiquidity2Pct - AskLiquidity2Pct
 	DeltaLiquidity10Pct decimal.Decimal // BidLiquidity10Pct - AskLiquidity10Pct
 
+	// Herfindahl concentration of the liquidity within 2% of mid: the sum of the
+	// squared shares of each level, from 1/levels (evenly spread) to 1 (one level)
+	BidConcentration2Pct float64
+	AskConcentration2Pct float64
+
 	// Total quantities across all price levels
 	TotalBidsQty decimal.Decimal // Sum of all bid quantities
 	TotalAsksQty decimal.Decimal // Sum of all ask quantities
 	TotalDelta   decimal.Decimal // TotalBidsQty - TotalAsksQty (positive = more bids)
+
+	// Execution quality metrics (rolling averages over recent trades, in bps of mid)
+	EffectiveSpreadBps decimal.Decimal // 2 * side * (trade price - mid at trade) / mid
+	RealizedSpreadBps  []HorizonSpread // 2 * side * (trade price - mid after horizon) / mid
+
+	// Time-weighted averages of the spread and band liquidity, one per configured window
+	Averages []WindowAverage
+
+	// Deviation from the cross-venue depth-weighted fair value
+	FairValue             decimal.Decimal // Depth-weighted fair value across venues
+	FairValueDeviationBps decimal.Decimal // (mid - fair value) / fair value in bps
+	FairValueAlert        bool            // True when the deviation exceeds the configured threshold
+
+	// Replenishment after sweeps, over the latest sweeps
+	ResilienceSweeps    int           // Sweeps measured
+	ResilienceRecovered float64       // Fraction of the sweeps replenished in time
+	ResilienceMedian    time.Duration // Median time until the swept quantity reappeared
+
+	// Open interest of derivatives venues, polled from the venue (in base asset units)
+	OpenInterest       decimal.Decimal // Latest open interest
+	OpenInterestChange decimal.Decimal // Change of the open interest over the configured window
+}
+
+// HorizonSpread holds a spread estimate measured over a specific horizon
+type HorizonSpread struct {
+	Horizon time.Duration
+	Bps     decimal.Decimal
+}
+
+// WindowAverage holds time-weighted averages of the spread and band liquidity over
+// a trailing window
+type WindowAverage struct {
+	Window            time.Duration
+	Covered           time.Duration   // Time within the window with a two-sided book
+	SpreadBps         decimal.Decimal // Spread in bps of mid
+	BidLiquidity05Pct decimal.Decimal
+	AskLiquidity05Pct decimal.Decimal
+	BidLiquidity2Pct  decimal.Decimal
+	AskLiquidity2Pct  decimal.Decimal
+	BidLiquidity10Pct decimal.Decimal
+	AskLiquidity10Pct decimal.Decimal
 }
 
 // GetNextTickLevel returns the next tick level in the sequence
-func GetNextTickLevel(current TickLevel) TickLevel {
-	for i, tick := range AvailableTickLevels {
+func GetNextTickLevel(levels []TickLevel, current TickLevel) TickLevel {
+	for i, tick := range levels {
 		if tick == current {
 			// Return next tick level, or wrap around to first
-			if i+1 < len(AvailableTickLevels) {
-				return AvailableTickLevels[i+1]
+			if i+1 < len(levels) {
+				return levels[i+1]
 			}
-			return AvailableTickLevels[0]
+			return levels[0]
 		}
 	}
 	// If current not found, return first available
-	return AvailableTickLevels[0]
+	return levels[0]
 }
 
 // GetPreviousTickLevel returns the previous tick level in the sequence
-func GetPreviousTickLevel(current TickLevel) TickLevel {
-	for i, tick := range AvailableTickLevels {
+func GetPreviousTickLevel(levels []TickLevel, current TickLevel) TickLevel {
+	for i, tick := range levels {
 		if tick == current {
 			// Return previous tick level, or wrap around to last
 			if i-1 >= 0 {
-				return AvailableTickLevels[i-1]
+				return levels[i-1]
 			}
-			return AvailableTickLevels[len(AvailableTickLevels)-1]
+			return levels[len(levels)-1]
 		}
 	}
 	// If current not found, return first available
-	return AvailableTickLevels[0]
+	return levels[0]
 }
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// QuantityUnit is the unit quantities are published in. Books hold base units.
+type QuantityUnit string
+
+const (
+	// UnitBase publishes quantities in the base asset, as held by the books
+	UnitBase QuantityUnit = "base"
+	// UnitQuote publishes quantities as notional in the quote currency
+	UnitQuote QuantityUnit = "quote"
+	// UnitContracts publishes quantities in contracts of the exchange's contract size
+	UnitContracts QuantityUnit = "contracts"
+)
+
+// QuantityUnits lists the units in a fixed order
+var QuantityUnits = []QuantityUnit{UnitBase, UnitQuote, UnitContracts}
+
+// ParseQuantityUnit parses "base", "quote" or "contracts"
+func ParseQuantityUnit(s string) (QuantityUnit, error) {
+	switch unit := QuantityUnit(s); unit {
+	case UnitBase, UnitQuote, UnitContracts:
+		return unit, nil
+	}
+	return "", fmt.Errorf("unknown quantity unit %q (base, quote, contracts)", s)
+}
+
+// Convert returns a base quantity at price in the unit. A zero contract size
+// counts one base unit per contract.
+func (u QuantityUnit) Convert(quantity, price, contractSize decimal.Decimal) decimal.Decimal {
+	switch u {
+	case UnitQuote:
+		return quantity.Mul(price)
+	case UnitContracts:
+		if contractSize.IsPositive() {
+			return quantity.Div(contractSize)
+		}
+	}
+	return quantity
+}
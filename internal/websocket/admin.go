@@ -0,0 +1,361 @@
+package websocket
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"orderbook/internal/distributed"
+	"orderbook/internal/exchange"
+	"orderbook/internal/logging"
+	"orderbook/internal/shard"
+)
+
+// ErrUnknownExchange is returned by an ExchangeController for exchanges that are not running
+var ErrUnknownExchange = errors.New("unknown exchange")
+
+// ExchangeController gives the server access to the running exchange adapters
+type ExchangeController interface {
+	// Exchange returns the adapter of a running exchange
+	Exchange(name string) (exchange.Exchange, bool)
+
+	// Resync reloads the book of an exchange from a fresh snapshot
+	Resync(name string) error
+
+	// Drop disconnects an exchange and removes its book until the next symbol change
+	Drop(name string) error
+}
+
+// AdminExchangeState is the internal state of one exchange
+type AdminExchangeState struct {
+	Exchange         string     `json:"exchange"`
+	Symbol           string     `json:"symbol,omitempty"`
+	Connected        bool       `json:"connected"`
+	Initialized      bool       `json:"initialized"`
+	Ready            bool       `json:"ready"` // Initialized and warmed up
+	LastPing         *time.Time `json:"lastPing,omitempty"`
+	MessageCount     int64      `json:"messageCount"`
+	ErrorCount       int64      `json:"errorCount"`
+	Stalls           int64      `json:"stalls"`
+	Reconnects       int64      `json:"reconnects"`
+	Failovers        int64      `json:"failovers"`
+	ClockOffsetMs    int64      `json:"clockOffsetMs"`
+	LastUpdateID     int64      `json:"lastUpdateId"`
+	BufferLength     int        `json:"bufferLength"`
+	BidLevels        int        `json:"bidLevels"`
+	AskLevels        int        `json:"askLevels"`
+	EventsProcessed  int64      `json:"eventsProcessed"`
+	EventsDropped    int64      `json:"eventsDropped"`
+	Gaps             int64      `json:"gaps"`
+	Resyncs          int64      `json:"resyncs"`
+	MalformedLevels  int64      `json:"malformedLevels"`
+	Expiries         int64      `json:"expiries"`
+	ReinitIntervalMs int64      `json:"reinitIntervalMs"`
+	ReinitBuffer     int        `json:"reinitBuffer"`
+}
+
+// AdminMemoryStats is a subset of runtime.MemStats
+type AdminMemoryStats struct {
+	HeapAlloc    uint64 `json:"heapAlloc"`
+	HeapInuse    uint64 `json:"heapInuse"`
+	HeapObjects  uint64 `json:"heapObjects"`
+	TotalAlloc   uint64 `json:"totalAlloc"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"numGC"`
+	PauseTotalNs uint64 `json:"pauseTotalNs"`
+}
+
+// AdminShardState is the runtime state of one update worker
+type AdminShardState struct {
+	Shard          int      `json:"shard"`
+	Pipelines      []string `json:"pipelines"`
+	QueueLength    int      `json:"queueLength"`
+	QueueCapacity  int      `json:"queueCapacity"`
+	Processed      int64    `json:"processed"`
+	Blocked        int64    `json:"blocked"`
+	BusyMs         int64    `json:"busyMs"`
+	QueueWaitUs    int64    `json:"queueWaitUs"`
+	MaxQueueWaitUs int64    `json:"maxQueueWaitUs"`
+}
+
+// AdminState is the body of the admin state endpoint
+type AdminState struct {
+	Exchanges  []AdminExchangeState         `json:"exchanges"`
+	Shards     []AdminShardState            `json:"shards,omitempty"`
+	Collectors []distributed.CollectorState `json:"collectors,omitempty"`
+	Clients    int                          `json:"clients"`
+	Goroutines int                          `json:"goroutines"`
+	Memory     AdminMemoryStats             `json:"memory"`
+	LogLevel   string                       `json:"logLevel"`
+	Timestamp  int64                        `json:"timestamp"`
+}
+
+// LogLevelRequest is the body of the admin log level endpoint
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// ReinitRequest is the body and response of the admin reinit endpoint. Zero
+// fields keep the current value.
+type ReinitRequest struct {
+	IntervalMs int64 `json:"intervalMs"` // Interval between reinitialization checks
+	Buffer     int   `json:"buffer"`     // Buffered events past which the book is reloaded from a snapshot
+}
+
+// SetExchangeControl gives the server access to the running exchanges, used by
+// resync requests and the admin endpoints. It must be called before Start.
+func (s *Server) SetExchangeControl(control ExchangeController) {
+	s.control = control
+}
+
+// SetShardPool reports the workers applying exchange updates in the admin state.
+// It must be called before Start.
+func (s *Server) SetShardPool(pool *shard.Pool) {
+	s.pool = pool
+}
+
+// SetAdmin enables the admin endpoints under /admin/ on every listener, authenticated
+// with "Authorization: Bearer <token>". An empty token leaves them disabled.
+// It must be called before Start.
+func (s *Server) SetAdmin(token string) {
+	s.adminToken = token
+}
+
+// registerAdmin adds the admin routes to mux when a token is configured
+func (s *Server) registerAdmin(mux *http.ServeMux) {
+	if s.adminToken == "" {
+		return
+	}
+	mux.HandleFunc("GET /admin/state", s.requireAdmin(s.handleAdminState))
+	mux.HandleFunc("POST /admin/exchanges/{exchange}/resync", s.requireAdmin(s.handleAdminResync))
+	mux.HandleFunc("DELETE /admin/exchanges/{exchange}", s.requireAdmin(s.handleAdminDrop))
+	mux.HandleFunc("PUT /admin/exchanges/{exchange}/reinit", s.requireAdmin(s.handleAdminReinit))
+	mux.HandleFunc("PUT /admin/log-level", s.requireAdmin(s.handleAdminLogLevel))
+}
+
+// requireAdmin rejects requests without the admin bearer token
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleAdminState reports the internal state of every exchange and of the process
+func (s *Server) handleAdminState(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	s.clientsMux.RLock()
+	clients := len(s.clients)
+	s.clientsMux.RUnlock()
+
+	books := s.books.List()
+	state := AdminState{
+		Exchanges:  make([]AdminExchangeState, 0, len(books)),
+		Clients:    clients,
+		Goroutines: runtime.NumGoroutine(),
+		Memory: AdminMemoryStats{
+			HeapAlloc:    mem.HeapAlloc,
+			HeapInuse:    mem.HeapInuse,
+			HeapObjects:  mem.HeapObjects,
+			TotalAlloc:   mem.TotalAlloc,
+			Sys:          mem.Sys,
+			NumGC:        mem.NumGC,
+			PauseTotalNs: mem.PauseTotalNs,
+		},
+		LogLevel:  logging.GetLevel().String(),
+		Timestamp: s.clock.Now().UnixMilli(),
+	}
+	for _, entry := range books {
+		name, ob := entry.Key.Exchange, entry.Book
+		stats := ob.GetStats()
+		reinit := ob.ReinitConfig()
+		ex := AdminExchangeState{
+			Exchange:         name,
+			Symbol:           entry.Key.Symbol,
+			Initialized:      ob.IsInitialized(),
+			Ready:            ob.IsReady(),
+			LastUpdateID:     ob.GetLastUpdateID(),
+			BufferLength:     ob.GetBufferLength(),
+			BidLevels:        stats.BidLevels,
+			AskLevels:        stats.AskLevels,
+			EventsProcessed:  stats.EventsProcessed,
+			EventsDropped:    stats.EventsDropped,
+			Gaps:             stats.Gaps,
+			Resyncs:          stats.Resyncs,
+			MalformedLevels:  stats.MalformedLevels,
+			Expiries:         stats.Expiries,
+			ReinitIntervalMs: reinit.CheckInterval.Milliseconds(),
+			ReinitBuffer:     reinit.BufferSize,
+		}
+		if s.control != nil {
+			if adapter, ok := s.control.Exchange(name); ok {
+				health := adapter.Health()
+				ex.Symbol = adapter.GetSymbol()
+				ex.Connected = health.Connected
+				ex.MessageCount = health.MessageCount
+				ex.ErrorCount = health.ErrorCount
+				ex.Stalls = health.Stalls
+				ex.Reconnects = health.Reconnects
+				ex.Failovers = health.Failovers
+				ex.ClockOffsetMs = health.ClockOffset.Milliseconds()
+				if !health.LastPing.IsZero() {
+					ex.LastPing = &health.LastPing
+				}
+			}
+		}
+		state.Exchanges = append(state.Exchanges, ex)
+	}
+	if s.pool != nil {
+		for _, stats := range s.pool.Stats() {
+			state.Shards = append(state.Shards, AdminShardState{
+				Shard:          stats.Shard,
+				Pipelines:      stats.Pipelines,
+				QueueLength:    stats.QueueLength,
+				QueueCapacity:  stats.QueueCapacity,
+				Processed:      stats.Processed,
+				Blocked:        stats.Blocked,
+				BusyMs:         stats.BusyTime.Milliseconds(),
+				QueueWaitUs:    stats.QueueWait.Microseconds(),
+				MaxQueueWaitUs: stats.MaxQueueWait.Microseconds(),
+			})
+		}
+	}
+	sort.Slice(state.Exchanges, func(i, j int) bool {
+		return state.Exchanges[i].Exchange < state.Exchanges[j].Exchange
+	})
+	if s.collectors != nil {
+		state.Collectors = s.collectors.CollectorStates()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		log.Printf("Error writing admin state response: %v", err)
+	}
+}
+
+// handleAdminResync reloads the book of an exchange from a fresh snapshot
+func (s *Server) handleAdminResync(w http.ResponseWriter, r *http.Request) {
+	s.writeExchangeAction(w, r.PathValue("exchange"), actionResync)
+}
+
+// handleAdminDrop disconnects an exchange
+func (s *Server) handleAdminDrop(w http.ResponseWriter, r *http.Request) {
+	s.writeExchangeAction(w, r.PathValue("exchange"), actionDrop)
+}
+
+// Actions on a running exchange
+const (
+	actionResync = "resync"
+	actionDrop   = "drop"
+)
+
+// errNoExchangeControl is returned by exchangeAction when SetExchangeControl was not called
+var errNoExchangeControl = errors.New("exchange control not available")
+
+// exchangeAction runs an action on a running exchange
+func (s *Server) exchangeAction(name, action string) error {
+	if s.control == nil {
+		return errNoExchangeControl
+	}
+	var err error
+	switch action {
+	case actionResync:
+		err = s.control.Resync(name)
+	case actionDrop:
+		err = s.control.Drop(name)
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+	if err == nil {
+		log.Printf("[%s] %s requested", name, action)
+	}
+	return err
+}
+
+// writeExchangeAction runs an exchange action for a REST request, answering 202
+// once it is queued and 404 for exchanges that are not running
+func (s *Server) writeExchangeAction(w http.ResponseWriter, name, action string) {
+	if err := s.exchangeAction(name, action); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrUnknownExchange):
+			status = http.StatusNotFound
+		case errors.Is(err, errNoExchangeControl):
+			status = http.StatusNotImplemented
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleAdminReinit changes the reinitialization checks of an exchange's book
+// (the symbol is picked with ?symbol=). The new interval applies from the next check.
+func (s *Server) handleAdminReinit(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("exchange")
+	ob, ok := s.books.Find(name, r.URL.Query().Get("symbol"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("%s: %s", ErrUnknownExchange, name), http.StatusNotFound)
+		return
+	}
+
+	var req ReinitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.IntervalMs < 0 || req.Buffer < 0 {
+		http.Error(w, "intervalMs and buffer must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	config := ob.ReinitConfig()
+	if req.IntervalMs > 0 {
+		config.CheckInterval = time.Duration(req.IntervalMs) * time.Millisecond
+	}
+	if req.Buffer > 0 {
+		config.BufferSize = req.Buffer
+	}
+	ob.SetReinitConfig(config)
+	log.Printf("[%s] Reinit checks changed to every %s past %d buffered events", name, config.CheckInterval, config.BufferSize)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ReinitRequest{IntervalMs: config.CheckInterval.Milliseconds(), Buffer: config.BufferSize}); err != nil {
+		log.Printf("Error writing reinit response: %v", err)
+	}
+}
+
+// handleAdminLogLevel changes the log level
+func (s *Server) handleAdminLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	level, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logging.SetLevel(level)
+	log.Printf("Log level changed to %s", level)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(LogLevelRequest{Level: level.String()}); err != nil {
+		log.Printf("Error writing log level response: %v", err)
+	}
+}
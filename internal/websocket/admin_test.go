@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/logging"
+	"orderbook/internal/orderbook"
+)
+
+// fakeControl records the actions requested through the admin API
+type fakeControl struct {
+	names   map[string]bool
+	actions []string
+}
+
+func (c *fakeControl) Exchange(name string) (exchange.Exchange, bool) {
+	return nil, false
+}
+
+func (c *fakeControl) Resync(name string) error {
+	return c.act("resync", name)
+}
+
+func (c *fakeControl) Drop(name string) error {
+	return c.act("drop", name)
+}
+
+func (c *fakeControl) act(action, name string) error {
+	if !c.names[name] {
+		return fmt.Errorf("%w: %s", ErrUnknownExchange, name)
+	}
+	c.actions = append(c.actions, action+" "+name)
+	return nil
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	defer logging.SetLevel(logging.LevelInfo)
+
+	s := newDepthServer(t)
+	control := &fakeControl{names: map[string]bool{"binance": true}}
+	s.SetExchangeControl(control)
+	s.SetAdmin("secret")
+	handler := s.handler(PermissionReadOnly)
+
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		token  string
+		body   string
+		want   int
+	}{
+		{"GET", "/admin/state", "", "", http.StatusUnauthorized},
+		{"GET", "/admin/state", "wrong", "", http.StatusUnauthorized},
+		{"POST", "/admin/exchanges/okx/resync", "secret", "", http.StatusNotFound},
+		{"POST", "/admin/exchanges/binance/resync", "secret", "", http.StatusAccepted},
+		{"DELETE", "/admin/exchanges/binance", "secret", "", http.StatusAccepted},
+		{"PUT", "/admin/log-level", "secret", `{"level":"verbose"}`, http.StatusBadRequest},
+		{"PUT", "/admin/log-level", "secret", `{"level":"debug"}`, http.StatusOK},
+		{"PUT", "/admin/exchanges/kraken/reinit", "secret", `{"buffer":50}`, http.StatusNotFound},
+		{"PUT", "/admin/exchanges/binance/reinit", "secret", `{"buffer":-1}`, http.StatusBadRequest},
+		{"PUT", "/admin/exchanges/binance/reinit", "secret", `{"intervalMs":1000,"buffer":50}`, http.StatusOK},
+		{"PUT", "/admin/exchanges/binance/reinit", "secret", `{"buffer":40}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := request(tt.method, tt.path, tt.token, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s: Expected status %d, got %d", tt.method, tt.path, tt.want, rec.Code)
+		}
+	}
+	if len(control.actions) != 2 || control.actions[0] != "resync binance" || control.actions[1] != "drop binance" {
+		t.Errorf("Expected resync and drop of binance, got %v", control.actions)
+	}
+
+	rec := request("GET", "/admin/state", "secret", "")
+	var state AdminState
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+	if len(state.Exchanges) != 2 || state.Exchanges[0].Exchange != "binance" || !state.Exchanges[0].Initialized || state.Exchanges[0].BidLevels != 3 || state.Exchanges[1].Initialized {
+		t.Errorf("Expected binance initialized with 3 bid levels and okx uninitialized, got %+v", state.Exchanges)
+	}
+	// Zero fields keep the current value
+	if binance := state.Exchanges[0]; binance.ReinitIntervalMs != 1000 || binance.ReinitBuffer != 40 {
+		t.Errorf("Expected binance reinit checks every 1000ms past 40 events, got %dms and %d", binance.ReinitIntervalMs, binance.ReinitBuffer)
+	}
+	if okx := state.Exchanges[1]; okx.ReinitBuffer != orderbook.DefaultReinitBufferSize {
+		t.Errorf("Expected okx on the default reinit buffer, got %d", okx.ReinitBuffer)
+	}
+	if state.Goroutines == 0 || state.Memory.HeapAlloc == 0 || state.LogLevel != "debug" {
+		t.Errorf("Expected process stats at log level debug, got %+v", state)
+	}
+
+	// Without a token the admin endpoints are not served
+	plain := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	rec = httptest.NewRecorder()
+	plain.handler(PermissionControl).ServeHTTP(rec, httptest.NewRequest("GET", "/admin/state", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without an admin token, got %d", rec.Code)
+	}
+}
+
+func TestResync(t *testing.T) {
+	s := newDepthServer(t)
+	control := &fakeControl{names: map[string]bool{"binance": true}}
+	s.SetExchangeControl(control)
+
+	tests := []struct {
+		permission Permission
+		exchange   string
+		want       int
+	}{
+		{PermissionReadOnly, "binance", http.StatusForbidden},
+		{PermissionControl, "okx", http.StatusNotFound},
+		{PermissionControl, "binance", http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.handler(tt.permission).ServeHTTP(rec, httptest.NewRequest("POST", "/api/resync/"+tt.exchange, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s resync of %s: Expected status %d, got %d", tt.permission, tt.exchange, tt.want, rec.Code)
+		}
+	}
+
+	// The control message is only accepted from control clients
+	s.handleClientMessage(&client{permission: PermissionReadOnly}, ClientMessage{Type: "resync", Exchange: "binance"})
+	s.handleClientMessage(&client{permission: PermissionControl}, ClientMessage{Type: "resync", Exchange: "binance"})
+	if len(control.actions) != 2 || control.actions[1] != "resync binance" {
+		t.Errorf("Expected one REST and one control message resync, got %v", control.actions)
+	}
+}
@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"log"
+	"sync/atomic"
+
+	"orderbook/internal/orderbook"
+)
+
+// ChannelBookDelta is the channel of bookdelta messages, sent only to subscribed clients
+const ChannelBookDelta = "bookdelta"
+
+// BookSide is the side of a changed level
+type BookSide string
+
+const (
+	SideBid BookSide = "bid"
+	SideAsk BookSide = "ask"
+)
+
+// BookDeltaMessage carries the level changes of one update of an exchange's book,
+// at venue prices. It is sent to ProtocolV2 clients subscribed to ChannelBookDelta.
+type BookDeltaMessage struct {
+	Type      MessageType   `json:"type"`
+	Version   int           `json:"v,omitempty"`
+	Exchange  string        `json:"exchange"`
+	Seq       int64         `json:"seq"`                // Per-exchange, incremented by one per delta; a skipped value means changes were lost
+	Snapshot  bool          `json:"snapshot,omitempty"` // Changes diff the previous book against a snapshot
+	Changes   []LevelChange `json:"changes"`
+	Timestamp int64         `json:"timestamp"` // Venue time of the update, Unix milliseconds
+}
+
+// LevelChange is the wire format of one changed price level
+type LevelChange struct {
+	Side        BookSide `json:"side"`
+	Price       string   `json:"price"`
+	OldQuantity string   `json:"oldQuantity"` // "0" for a new level
+	NewQuantity string   `json:"newQuantity"` // "0" for a removed level
+}
+
+// NewBookDeltaMessage converts a book delta to wire format
+func NewBookDeltaMessage(exchange string, delta orderbook.BookDelta) BookDeltaMessage {
+	changes := make([]LevelChange, len(delta.Changes))
+	for i, change := range delta.Changes {
+		side := SideAsk
+		if change.IsBid {
+			side = SideBid
+		}
+		changes[i] = LevelChange{
+			Side:        side,
+			Price:       change.Price.String(),
+			OldQuantity: change.OldQuantity.String(),
+			NewQuantity: change.NewQuantity.String(),
+		}
+	}
+	return BookDeltaMessage{
+		Type:      MessageTypeBookDelta,
+		Exchange:  exchange,
+		Seq:       delta.Seq,
+		Snapshot:  delta.Snapshot,
+		Changes:   changes,
+		Timestamp: delta.Time.UnixMilli(),
+	}
+}
+
+// PublishBookDelta broadcasts the changes of an exchange's book to the clients
+// subscribed to ChannelBookDelta. It returns immediately when there are none,
+// so it can be registered as the delta handler of every book.
+func (s *Server) PublishBookDelta(exchange string, delta orderbook.BookDelta) {
+	if s.deltaSubscribers.Load() == 0 {
+		return
+	}
+	s.Publish(NewBookDeltaMessage(exchange, delta))
+}
+
+// subscribe adds or removes a client from a channel
+func (s *Server) subscribe(c *client, channel string, subscribed bool) {
+	var member *atomic.Bool
+	var subscribers *atomic.Int32
+	switch channel {
+	case ChannelBookDelta:
+		member, subscribers = &c.bookDelta, &s.deltaSubscribers
+	case ChannelLadder:
+		member, subscribers = &c.ladder, &s.ladderSubscribers
+	default:
+		log.Printf("Unknown channel: %s", channel)
+		return
+	}
+	if member.CompareAndSwap(!subscribed, subscribed) {
+		if subscribed {
+			subscribers.Add(1)
+		} else {
+			subscribers.Add(-1)
+		}
+	}
+}
+
+// wants reports whether msg is sent to the client: channel messages only reach subscribers
+func (c *client) wants(msg interface{}) bool {
+	switch msg.(type) {
+	case BookDeltaMessage:
+		return c.bookDelta.Load()
+	case LadderMessage:
+		return c.ladder.Load()
+	}
+	return true
+}
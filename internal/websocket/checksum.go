@@ -0,0 +1,28 @@
+package websocket
+
+import "hash/crc32"
+
+// DefaultChecksumDepth is the number of levels per side covered by OrderbookMessage checksums
+const DefaultChecksumDepth = 10
+
+// Checksum returns the CRC32 (IEEE) of the top depth bid levels followed by the
+// top depth ask levels, each written as "price:quantity" and joined with ":".
+// Consumers recompute it over the received levels to verify a message.
+func Checksum(bids, asks []PriceLevel, depth int) uint32 {
+	buf := make([]byte, 0, 2*depth*24)
+	buf = appendChecksumLevels(buf, bids[:min(depth, len(bids))])
+	buf = appendChecksumLevels(buf, asks[:min(depth, len(asks))])
+	return crc32.ChecksumIEEE(buf)
+}
+
+func appendChecksumLevels(buf []byte, levels []PriceLevel) []byte {
+	for _, level := range levels {
+		if len(buf) > 0 {
+			buf = append(buf, ':')
+		}
+		buf = append(buf, level.Price...)
+		buf = append(buf, ':')
+		buf = append(buf, level.Quantity...)
+	}
+	return buf
+}
@@ -0,0 +1,277 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Chunked recordings are a directory of chunk files, each a sequence of
+// independently compressed blocks of recording lines, and an index file per
+// chunk with one fixed-size entry per block. Replays read chunks through memory
+// maps and use the indexes to skip blocks before the requested start time.
+//
+// Each index starts with a header naming the format version and the codec of
+// the blocks of its chunk, so that the codec can change without breaking
+// existing recordings. Blocks are compressed with DEFLATE (compress/flate),
+// chosen over zstd to keep the recorder free of dependencies outside the
+// standard library. Indexes without a header, written before it was added,
+// are read as version 1 DEFLATE indexes.
+const (
+	chunkExt         = ".rec"
+	indexExt         = ".idx"
+	indexMagic       = "OBRI"     // First bytes of an index header
+	indexHeaderSize  = 8          // Magic, version, codec and two reserved bytes
+	indexVersion     = 1          // Layout of the index entries
+	indexEntrySize   = 40         // Five little-endian int64 fields
+	recordBlockSize  = 256 * 1024 // Uncompressed lines per block
+	DefaultChunkSize = 64 << 20   // Compressed bytes per chunk file
+)
+
+// chunkCodec is the compression of the blocks of a chunk, recorded in its index header
+type chunkCodec byte
+
+const (
+	codecFlate chunkCodec = 1 // DEFLATE, RFC 1951
+)
+
+// blockIndex locates one compressed block of a chunk
+type blockIndex struct {
+	First  int64 // Time of the first message, Unix milliseconds
+	Last   int64 // Time of the last message, Unix milliseconds
+	Offset int64 // Position of the block in the chunk file
+	Length int64 // Compressed length of the block
+	Count  int64 // Messages in the block
+}
+
+// chunkWriter appends compressed blocks to rotating chunk files
+type chunkWriter struct {
+	dir       string
+	chunkSize int64
+	seq       int
+	chunk     *os.File
+	index     *os.File
+	offset    int64 // Size of the current chunk
+	block     bytes.Buffer
+	entry     blockIndex
+	compress  bytes.Buffer
+	flater    *flate.Writer
+}
+
+// newChunkWriter creates dir (which must not contain a recording) for a chunked recording
+func newChunkWriter(dir string, chunkSize int64) (*chunkWriter, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	if existing, _ := chunkFiles(dir); len(existing) > 0 {
+		return nil, fmt.Errorf("recording directory %s is not empty", dir)
+	}
+	flater, err := flate.NewWriter(nil, flate.DefaultCompression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compressor: %w", err)
+	}
+	return &chunkWriter{dir: dir, chunkSize: chunkSize, flater: flater}, nil
+}
+
+// write appends one recording line, flushing the block once it is full
+func (w *chunkWriter) write(t int64, line []byte) error {
+	if w.entry.Count == 0 {
+		w.entry.First = t
+	}
+	w.entry.Last = t
+	w.entry.Count++
+	w.block.Write(line)
+	if w.block.Len() >= recordBlockSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush compresses the pending block into the current chunk and indexes it
+func (w *chunkWriter) flush() error {
+	if w.entry.Count == 0 {
+		return nil
+	}
+	if w.chunk == nil || w.offset >= w.chunkSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	w.compress.Reset()
+	w.flater.Reset(&w.compress)
+	w.flater.Write(w.block.Bytes())
+	if err := w.flater.Close(); err != nil {
+		return fmt.Errorf("failed to compress block: %w", err)
+	}
+	if _, err := w.chunk.Write(w.compress.Bytes()); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+
+	w.entry.Offset = w.offset
+	w.entry.Length = int64(w.compress.Len())
+	var entry [indexEntrySize]byte
+	for i, field := range []int64{w.entry.First, w.entry.Last, w.entry.Offset, w.entry.Length, w.entry.Count} {
+		binary.LittleEndian.PutUint64(entry[i*8:], uint64(field))
+	}
+	if _, err := w.index.Write(entry[:]); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	w.offset += w.entry.Length
+	w.block.Reset()
+	w.entry = blockIndex{}
+	return nil
+}
+
+// rotate closes the current chunk and opens the next one
+func (w *chunkWriter) rotate() error {
+	if err := w.closeChunk(); err != nil {
+		return err
+	}
+	w.seq++
+	base := filepath.Join(w.dir, fmt.Sprintf("chunk-%06d", w.seq))
+	chunk, err := os.Create(base + chunkExt)
+	if err != nil {
+		return fmt.Errorf("failed to create chunk: %w", err)
+	}
+	index, err := os.Create(base + indexExt)
+	if err != nil {
+		chunk.Close()
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	header := [indexHeaderSize]byte{4: indexVersion, 5: byte(codecFlate)}
+	copy(header[:], indexMagic)
+	if _, err := index.Write(header[:]); err != nil {
+		chunk.Close()
+		index.Close()
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	w.chunk, w.index, w.offset = chunk, index, 0
+	return nil
+}
+
+// closeChunk closes the current chunk and index files, if any
+func (w *chunkWriter) closeChunk() error {
+	if w.chunk == nil {
+		return nil
+	}
+	chunkErr, indexErr := w.chunk.Close(), w.index.Close()
+	w.chunk, w.index = nil, nil
+	if chunkErr != nil {
+		return fmt.Errorf("failed to close chunk: %w", chunkErr)
+	}
+	if indexErr != nil {
+		return fmt.Errorf("failed to close index: %w", indexErr)
+	}
+	return nil
+}
+
+// close flushes the pending block and closes the files
+func (w *chunkWriter) close() error {
+	if err := w.flush(); err != nil {
+		w.closeChunk()
+		return err
+	}
+	return w.closeChunk()
+}
+
+// chunkFiles returns the chunk files of a recording directory in recording order
+func chunkFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var chunks []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "chunk-") && strings.HasSuffix(entry.Name(), chunkExt) {
+			chunks = append(chunks, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(chunks)
+	return chunks, nil
+}
+
+// readIndex reads the codec and the block entries of a chunk
+func readIndex(chunk string) (chunkCodec, []blockIndex, error) {
+	data, err := os.ReadFile(strings.TrimSuffix(chunk, chunkExt) + indexExt)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	codec := codecFlate
+	if len(data) >= indexHeaderSize && string(data[:len(indexMagic)]) == indexMagic {
+		if version := data[4]; version != indexVersion {
+			return 0, nil, fmt.Errorf("unsupported index version %d of %s", version, filepath.Base(chunk))
+		}
+		codec = chunkCodec(data[5])
+		data = data[indexHeaderSize:]
+	}
+	entries := make([]blockIndex, len(data)/indexEntrySize)
+	for i := range entries {
+		entry := data[i*indexEntrySize:]
+		field := func(n int) int64 { return int64(binary.LittleEndian.Uint64(entry[n*8:])) }
+		entries[i] = blockIndex{First: field(0), Last: field(1), Offset: field(2), Length: field(3), Count: field(4)}
+	}
+	return codec, entries, nil
+}
+
+// chunkBlocks returns readers of the uncompressed blocks of the chunked recording
+// in dir holding messages at or after from (Unix milliseconds). Blocks ending
+// before from are skipped through the indexes without being read. Each block is
+// passed to fn; the chunk stays mapped until fn returns.
+func chunkBlocks(dir string, from int64, fn func(block io.Reader) error) error {
+	chunks, err := chunkFiles(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list recording chunks: %w", err)
+	}
+	for _, chunk := range chunks {
+		codec, entries, err := readIndex(chunk)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 || entries[len(entries)-1].Last < from {
+			continue
+		}
+		if err := readChunk(chunk, codec, entries, from, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readChunk maps a chunk and passes its blocks ending at or after from to fn
+func readChunk(chunk string, codec chunkCodec, entries []blockIndex, from int64, fn func(block io.Reader) error) error {
+	if codec != codecFlate {
+		return fmt.Errorf("unsupported codec %d of %s", codec, filepath.Base(chunk))
+	}
+	data, unmap, err := mapFile(chunk)
+	if err != nil {
+		return fmt.Errorf("failed to map chunk: %w", err)
+	}
+	defer unmap()
+
+	for _, entry := range entries {
+		if entry.Last < from {
+			continue
+		}
+		if entry.Offset < 0 || entry.Offset+entry.Length > int64(len(data)) {
+			return fmt.Errorf("index of %s points past the end of the chunk", filepath.Base(chunk))
+		}
+		block := flate.NewReader(bytes.NewReader(data[entry.Offset : entry.Offset+entry.Length]))
+		err := fn(block)
+		block.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// jsonAppender is implemented by messages with a hand-rolled JSON encoder
+type jsonAppender interface {
+	AppendJSON(buf []byte) []byte
+}
+
+// bufferPool reuses encoding buffers across broadcasts
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 64*1024)
+		return &buf
+	},
+}
+
+// encodeMessage encodes msg as JSON into a pooled buffer. The caller must return
+// the buffer with releaseBuffer once the encoded bytes are no longer used.
+func encodeMessage(msg interface{}) (*[]byte, error) {
+	bufPtr := bufferPool.Get().(*[]byte)
+	buf := (*bufPtr)[:0]
+
+	if appender, ok := msg.(jsonAppender); ok {
+		buf = appender.AppendJSON(buf)
+	} else {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			releaseBuffer(bufPtr)
+			return nil, err
+		}
+		buf = append(buf, data...)
+	}
+
+	*bufPtr = buf
+	return bufPtr, nil
+}
+
+// encodeMessageAs encodes msg in format into a pooled buffer, as encodeMessage
+func encodeMessageAs(msg interface{}, format Format) (*[]byte, error) {
+	bufPtr, err := encodeMessage(msg)
+	if err != nil || format == FormatJSON {
+		return bufPtr, err
+	}
+	defer releaseBuffer(bufPtr)
+	return transcode(*bufPtr, format)
+}
+
+// transcode converts a JSON encoding to format into a pooled buffer
+func transcode(data []byte, format Format) (*[]byte, error) {
+	bufPtr := bufferPool.Get().(*[]byte)
+	buf := (*bufPtr)[:0]
+	var err error
+	switch format {
+	case FormatMsgpack:
+		buf, err = appendMsgpack(buf, data)
+	case FormatProtobuf:
+		buf, err = appendProtobuf(buf, data)
+	default:
+		buf = append(buf, data...)
+	}
+	if err != nil {
+		releaseBuffer(bufPtr)
+		return nil, err
+	}
+	*bufPtr = buf
+	return bufPtr, nil
+}
+
+// releaseBuffer returns a buffer to the pool, dropping oversized ones
+func releaseBuffer(bufPtr *[]byte) {
+	if cap(*bufPtr) > 4*1024*1024 {
+		return
+	}
+	bufferPool.Put(bufPtr)
+}
+
+// AppendJSON appends the JSON encoding of the message to buf
+func (m OrderbookMessage) AppendJSON(buf []byte) []byte {
+	buf = append(buf, `{"type":`...)
+	buf = appendJSONString(buf, string(m.Type))
+	buf = appendVersion(buf, m.Version)
+	buf = append(buf, `,"exchange":`...)
+	buf = appendJSONString(buf, m.Exchange)
+	if m.Seq != 0 {
+		buf = append(buf, `,"seq":`...)
+		buf = strconv.AppendInt(buf, m.Seq, 10)
+	}
+	if m.Checksum != 0 {
+		buf = append(buf, `,"checksum":`...)
+		buf = strconv.AppendUint(buf, uint64(m.Checksum), 10)
+	}
+	buf = append(buf, `,"bids":`...)
+	buf = appendPriceLevels(buf, m.Bids)
+	buf = append(buf, `,"asks":`...)
+	buf = appendPriceLevels(buf, m.Asks)
+	buf = append(buf, `,"timestamp":`...)
+	buf = strconv.AppendInt(buf, m.Timestamp, 10)
+	return append(buf, '}')
+}
+
+// AppendJSON appends the JSON encoding of the message to buf. Fields added
+// after ProtocolV1 are left out unless the message is versioned.
+func (m StatsMessage) AppendJSON(buf []byte) []byte {
+	buf = append(buf, `{"type":`...)
+	buf = appendJSONString(buf, string(m.Type))
+	buf = appendVersion(buf, m.Version)
+	buf = appendStringField(buf, "exchange", m.Exchange)
+	buf = appendStringField(buf, "bestBid", m.BestBid)
+	buf = appendStringField(buf, "bestAsk", m.BestAsk)
+	buf = appendStringField(buf, "midPrice", m.MidPrice)
+	buf = appendStringField(buf, "spread", m.Spread)
+	buf = appendStringField(buf, "bidLiquidity05Pct", m.BidLiquidity05Pct)
+	buf = appendStringField(buf, "askLiquidity05Pct", m.AskLiquidity05Pct)
+	buf = appendStringField(buf, "deltaLiquidity05Pct", m.DeltaLiquidity05Pct)
+	buf = appendStringField(buf, "bidLiquidity2Pct", m.BidLiquidity2Pct)
+	buf = appendStringField(buf, "askLiquidity2Pct", m.AskLiquidity2Pct)
+	buf = appendStringField(buf, "deltaLiquidity2Pct", m.DeltaLiquidity2Pct)
+	buf = appendStringField(buf, "bidLiquidity10Pct", m.BidLiquidity10Pct)
+	buf = appendStringField(buf, "askLiquidity10Pct", m.AskLiquidity10Pct)
+	buf = appendStringField(buf, "deltaLiquidity10Pct", m.DeltaLiquidity10Pct)
+	buf = appendStringField(buf, "totalBidsQty", m.TotalBidsQty)
+	buf = appendStringField(buf, "totalAsksQty", m.TotalAsksQty)
+	buf = appendStringField(buf, "totalDelta", m.TotalDelta)
+	if m.Version < ProtocolV2 {
+		buf = append(buf, `,"timestamp":`...)
+		buf = strconv.AppendInt(buf, m.Timestamp, 10)
+		return append(buf, '}')
+	}
+	buf = appendStringField(buf, "effectiveSpreadBps", m.EffectiveSpreadBps)
+	buf = append(buf, `,"realizedSpreadBps":`...)
+	buf = appendStringMap(buf, m.RealizedSpreadBps)
+	buf = append(buf, `,"averages":`...)
+	buf = appendWindowAverages(buf, m.Averages)
+	buf = appendStringField(buf, "fairValue", m.FairValue)
+	buf = appendStringField(buf, "fairValueDeviationBps", m.FairValueDeviationBps)
+	buf = append(buf, `,"fairValueAlert":`...)
+	buf = strconv.AppendBool(buf, m.FairValueAlert)
+	buf = append(buf, `,"resilienceSweeps":`...)
+	buf = strconv.AppendInt(buf, int64(m.ResilienceSweeps), 10)
+	buf = appendStringField(buf, "resilienceRecovered", m.ResilienceRecovered)
+	buf = append(buf, `,"resilienceMs":`...)
+	buf = strconv.AppendInt(buf, m.ResilienceMs, 10)
+	buf = appendStringField(buf, "bidConcentration2Pct", m.BidConcentration2Pct)
+	buf = appendStringField(buf, "askConcentration2Pct", m.AskConcentration2Pct)
+	buf = appendStringField(buf, "openInterest", m.OpenInterest)
+	buf = appendStringField(buf, "openInterestChange", m.OpenInterestChange)
+	buf = append(buf, `,"prunedLevels":`...)
+	buf = strconv.AppendInt(buf, m.PrunedLevels, 10)
+	buf = append(buf, `,"eventLatencyMs":`...)
+	buf = strconv.AppendInt(buf, m.EventLatencyMs, 10)
+	buf = appendStringField(buf, "eventsPerSecond", m.EventsPerSecond)
+	buf = append(buf, `,"eventsProcessed":`...)
+	buf = strconv.AppendInt(buf, m.EventsProcessed, 10)
+	buf = append(buf, `,"eventsBuffered":`...)
+	buf = strconv.AppendInt(buf, m.EventsBuffered, 10)
+	buf = append(buf, `,"eventsDropped":`...)
+	buf = strconv.AppendInt(buf, m.EventsDropped, 10)
+	buf = append(buf, `,"gaps":`...)
+	buf = strconv.AppendInt(buf, m.Gaps, 10)
+	buf = append(buf, `,"resyncs":`...)
+	buf = strconv.AppendInt(buf, m.Resyncs, 10)
+	buf = append(buf, `,"bufferOverflows":`...)
+	buf = strconv.AppendInt(buf, m.BufferOverflows, 10)
+	buf = append(buf, `,"crossedBook":`...)
+	buf = strconv.AppendBool(buf, m.CrossedBook)
+	buf = append(buf, `,"crossedBooks":`...)
+	buf = strconv.AppendInt(buf, m.CrossedBooks, 10)
+	buf = append(buf, `,"malformedLevels":`...)
+	buf = strconv.AppendInt(buf, m.MalformedLevels, 10)
+	buf = append(buf, `,"malformedMessages":`...)
+	buf = strconv.AppendInt(buf, m.MalformedMessages, 10)
+	buf = append(buf, `,"applyTimeNs":`...)
+	buf = strconv.AppendInt(buf, m.ApplyTimeNs, 10)
+	buf = append(buf, `,"timestamp":`...)
+	buf = strconv.AppendInt(buf, m.Timestamp, 10)
+	return append(buf, '}')
+}
+
+// appendWindowAverages appends a JSON array of time-weighted averages
+func appendWindowAverages(buf []byte, averages []WindowAverage) []byte {
+	if averages == nil {
+		return append(buf, "null"...)
+	}
+
+	buf = append(buf, '[')
+	for i, avg := range averages {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"window":`...)
+		buf = appendJSONString(buf, avg.Window)
+		buf = append(buf, `,"coveredMs":`...)
+		buf = strconv.AppendInt(buf, avg.CoveredMs, 10)
+		buf = appendStringField(buf, "spreadBps", avg.SpreadBps)
+		buf = appendStringField(buf, "bidLiquidity05Pct", avg.BidLiquidity05Pct)
+		buf = appendStringField(buf, "askLiquidity05Pct", avg.AskLiquidity05Pct)
+		buf = appendStringField(buf, "bidLiquidity2Pct", avg.BidLiquidity2Pct)
+		buf = appendStringField(buf, "askLiquidity2Pct", avg.AskLiquidity2Pct)
+		buf = appendStringField(buf, "bidLiquidity10Pct", avg.BidLiquidity10Pct)
+		buf = appendStringField(buf, "askLiquidity10Pct", avg.AskLiquidity10Pct)
+		buf = append(buf, '}')
+	}
+	return append(buf, ']')
+}
+
+// appendVersion appends the protocol version field, omitted for ProtocolV1 messages
+func appendVersion(buf []byte, version int) []byte {
+	if version == 0 {
+		return buf
+	}
+	buf = append(buf, `,"v":`...)
+	return strconv.AppendInt(buf, int64(version), 10)
+}
+
+// appendPriceLevels appends a JSON array of price levels
+func appendPriceLevels(buf []byte, levels []PriceLevel) []byte {
+	if levels == nil {
+		return append(buf, "null"...)
+	}
+	buf = append(buf, '[')
+	for i, level := range levels {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"price":`...)
+		buf = appendJSONString(buf, level.Price)
+		buf = appendStringField(buf, "quantity", level.Quantity)
+		buf = appendStringField(buf, "cumulative", level.Cumulative)
+		if level.Tail {
+			buf = append(buf, `,"tail":true`...)
+		}
+		buf = append(buf, '}')
+	}
+	return append(buf, ']')
+}
+
+// appendStringField appends `,"name":"value"`
+func appendStringField(buf []byte, name, value string) []byte {
+	buf = append(buf, ',', '"')
+	buf = append(buf, name...)
+	buf = append(buf, '"', ':')
+	return appendJSONString(buf, value)
+}
+
+// appendStringMap appends a JSON object with keys sorted like encoding/json
+func appendStringMap(buf []byte, m map[string]string) []byte {
+	if m == nil {
+		return append(buf, "null"...)
+	}
+
+	var stack [8]string
+	keys := stack[:0]
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf = append(buf, '{')
+	for i, key := range keys {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendJSONString(buf, key)
+		buf = append(buf, ':')
+		buf = appendJSONString(buf, m[key])
+	}
+	return append(buf, '}')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends a quoted, escaped JSON string (compatible with encoding/json)
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
@@ -0,0 +1,259 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"testing"
+)
+
+func makeOrderbookMessage(levels int) OrderbookMessage {
+	msg := OrderbookMessage{
+		Type:      MessageTypeOrderbook,
+		Exchange:  "binancef",
+		Timestamp: 1700000000000,
+	}
+	for i := 0; i < levels; i++ {
+		msg.Bids = append(msg.Bids, PriceLevel{
+			Price:      fmt.Sprintf("%.1f", 50000.0-float64(i)),
+			Quantity:   fmt.Sprintf("%.3f", 0.5+float64(i)*0.01),
+			Cumulative: fmt.Sprintf("%.3f", float64(i+1)*0.5),
+		})
+		msg.Asks = append(msg.Asks, PriceLevel{
+			Price:      fmt.Sprintf("%.1f", 50001.0+float64(i)),
+			Quantity:   fmt.Sprintf("%.3f", 0.4+float64(i)*0.01),
+			Cumulative: fmt.Sprintf("%.3f", float64(i+1)*0.4),
+		})
+	}
+	return msg
+}
+
+func makeStatsMessage() StatsMessage {
+	return StatsMessage{
+		Type:                MessageTypeStats,
+		Version:             ProtocolV2,
+		Exchange:            "bybit",
+		BestBid:             "50000",
+		BestAsk:             "50000.1",
+		MidPrice:            "50000.05",
+		Spread:              "0.1",
+		BidLiquidity05Pct:   "12.5",
+		AskLiquidity05Pct:   "10.25",
+		DeltaLiquidity05Pct: "2.25",
+		TotalDelta:          "-1.5",
+		EffectiveSpreadBps:  "0.42",
+		RealizedSpreadBps:   map[string]string{"30s": "0.1", "1s": "0.3", "5s": "0.2"},
+		Averages: []WindowAverage{
+			{Window: "1m", CoveredMs: 60000, SpreadBps: "0.0200", BidLiquidity05Pct: "12.1", AskLiquidity05Pct: "10.4"},
+			{Window: "1h", CoveredMs: 1250000, SpreadBps: "0.0350", BidLiquidity2Pct: "40.5", AskLiquidity10Pct: "99"},
+		},
+		FairValue:             "50000.02",
+		FairValueDeviationBps: "0.6",
+		FairValueAlert:        true,
+		ResilienceSweeps:      12,
+		ResilienceRecovered:   "0.75",
+		ResilienceMs:          850,
+		BidConcentration2Pct:  "0.0412",
+		AskConcentration2Pct:  "0.125",
+		PrunedLevels:          42,
+		EventLatencyMs:        -3,
+		EventsPerSecond:       "812.5",
+		EventsProcessed:       120000,
+		EventsBuffered:        14,
+		EventsDropped:         3,
+		Gaps:                  2,
+		Resyncs:               1,
+		BufferOverflows:       1,
+		CrossedBook:           true,
+		CrossedBooks:          4,
+		MalformedLevels:       5,
+		MalformedMessages:     2,
+		ApplyTimeNs:           2400,
+		Timestamp:             1700000000000,
+	}
+}
+
+func TestAppendJSONMatchesEncodingJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  interface{}
+	}{
+		{"orderbook", makeOrderbookMessage(50)},
+		{"empty orderbook", OrderbookMessage{Type: MessageTypeOrderbook, Exchange: "okx"}},
+		{"stats", makeStatsMessage()},
+		{"stats without horizons", StatsMessage{Type: MessageTypeStats, Version: ProtocolV2, Exchange: "kraken"}},
+		{"orderbook with tail", OrderbookMessage{Type: MessageTypeOrderbook, Exchange: "okx", Bids: []PriceLevel{{Price: "50000", Quantity: "1", Cumulative: "1"}, {Price: "47500", Quantity: "80", Cumulative: "81", Tail: true}}}},
+		{"versioned orderbook", OrderbookMessage{Type: MessageTypeOrderbook, Version: ProtocolV2, Exchange: "okx", Seq: 7, Checksum: 4294967295}},
+		{"escaped strings", OrderbookMessage{Type: MessageTypeOrderbook, Exchange: "a\"b\\c\n<&> \x01é\u2028\xff"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := json.Marshal(tt.msg)
+			if err != nil {
+				t.Fatalf("json.Marshal() failed: %v", err)
+			}
+
+			bufPtr, err := encodeMessage(tt.msg)
+			if err != nil {
+				t.Fatalf("encodeMessage() failed: %v", err)
+			}
+			got := string(*bufPtr)
+			releaseBuffer(bufPtr)
+
+			if got != string(expected) {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestAppendJSONProtocolV1Stats(t *testing.T) {
+	msg, ok := withVersion(makeStatsMessage(), ProtocolV1)
+	if !ok {
+		t.Fatal("Expected stats to be sent to v1 clients")
+	}
+
+	bufPtr, err := encodeMessage(msg)
+	if err != nil {
+		t.Fatalf("encodeMessage() failed: %v", err)
+	}
+	defer releaseBuffer(bufPtr)
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(*bufPtr, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	for _, field := range []string{"v", "effectiveSpreadBps", "fairValue", "eventLatencyMs"} {
+		if _, exists := decoded[field]; exists {
+			t.Errorf("Expected v1 stats without %s, got %s", field, *bufPtr)
+		}
+	}
+	if decoded["totalDelta"] != "-1.5" || decoded["timestamp"] != float64(1700000000000) {
+		t.Errorf("Expected v1 stats fields to be kept, got %s", *bufPtr)
+	}
+}
+
+func TestWithVersion(t *testing.T) {
+	if _, ok := withVersion(LeadLagMessage{Type: MessageTypeLeadLag}, ProtocolV1); ok {
+		t.Error("Expected leadlag messages to be withheld from v1 clients")
+	}
+
+	msg, ok := withVersion(OrderbookMessage{Type: MessageTypeOrderbook, Seq: 3, Checksum: 42}, ProtocolV2)
+	if !ok || msg.(OrderbookMessage).Version != ProtocolV2 || msg.(OrderbookMessage).Seq != 3 {
+		t.Errorf("Expected orderbook message tagged with v%d, got %+v", ProtocolV2, msg)
+	}
+
+	msg, _ = withVersion(OrderbookMessage{Type: MessageTypeOrderbook, Seq: 3, Checksum: 42}, ProtocolV1)
+	if v1 := msg.(OrderbookMessage); v1.Seq != 0 || v1.Checksum != 0 {
+		t.Errorf("Expected v1 orderbook message without seq and checksum, got %+v", v1)
+	}
+
+	tests := []struct {
+		requested int
+		expected  int
+	}{
+		{0, ProtocolV1},
+		{ProtocolV1, ProtocolV1},
+		{ProtocolV2, ProtocolV2},
+		{ProtocolVersion + 1, ProtocolVersion},
+	}
+	for _, tt := range tests {
+		if got := negotiateVersion(tt.requested); got != tt.expected {
+			t.Errorf("Expected v%d for requested v%d, got v%d", tt.expected, tt.requested, got)
+		}
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	bids := []PriceLevel{{Price: "50000", Quantity: "1.5"}, {Price: "49999", Quantity: "2"}}
+	asks := []PriceLevel{{Price: "50001", Quantity: "0.25"}}
+
+	expected := crc32.ChecksumIEEE([]byte("50000:1.5:49999:2:50001:0.25"))
+	if got := Checksum(bids, asks, 10); got != expected {
+		t.Errorf("Expected %d, got %d", expected, got)
+	}
+
+	expected = crc32.ChecksumIEEE([]byte("50000:1.5:50001:0.25"))
+	if got := Checksum(bids, asks, 1); got != expected {
+		t.Errorf("Expected %d for depth 1, got %d", expected, got)
+	}
+}
+
+func TestEncodeMessageFallback(t *testing.T) {
+	msg := LeadLagMessage{
+		Type:      MessageTypeLeadLag,
+		Pairs:     []LeadLagPair{{Leader: "binancef", Follower: "bybit", LagMs: 200, Correlation: 0.4}},
+		Scores:    map[string]float64{"binancef": 1},
+		Timestamp: 1700000000000,
+	}
+
+	bufPtr, err := encodeMessage(msg)
+	if err != nil {
+		t.Fatalf("encodeMessage() failed: %v", err)
+	}
+	defer releaseBuffer(bufPtr)
+
+	var decoded LeadLagMessage
+	if err := json.Unmarshal(*bufPtr, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, msg) {
+		t.Errorf("Expected %+v, got %+v", msg, decoded)
+	}
+}
+
+// Benchmarks
+
+func BenchmarkOrderbookMessageEncodingJSON(b *testing.B) {
+	msg := makeOrderbookMessage(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOrderbookMessageAppendJSON(b *testing.B) {
+	msg := makeOrderbookMessage(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		bufPtr, err := encodeMessage(msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		releaseBuffer(bufPtr)
+	}
+}
+
+func BenchmarkStatsMessageEncodingJSON(b *testing.B) {
+	msg := makeStatsMessage()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStatsMessageAppendJSON(b *testing.B) {
+	msg := makeStatsMessage()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		bufPtr, err := encodeMessage(msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		releaseBuffer(bufPtr)
+	}
+}
@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"orderbook/internal/types"
+
+	"github.com/gorilla/websocket"
+)
+
+// Format is the encoding of the messages sent to a client, chosen with the
+// ?format= parameter of the WebSocket URL
+type Format int
+
+const (
+	// FormatJSON sends text frames of JSON, the default
+	FormatJSON Format = iota
+	// FormatMsgpack sends binary frames of MessagePack maps keyed like the JSON fields
+	FormatMsgpack
+	// FormatProtobuf sends binary frames of the protobuf Frame message, see Proto
+	FormatProtobuf
+
+	formatCount = iota
+)
+
+// formatNames lists the ?format= value of each format
+var formatNames = [formatCount]string{"json", "msgpack", "pb"}
+
+// String returns the ?format= value of the format
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+// ParseFormat parses a ?format= value, empty selecting JSON
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(value) {
+	case "", "json":
+		return FormatJSON, nil
+	case "msgpack":
+		return FormatMsgpack, nil
+	case "pb", "protobuf":
+		return FormatProtobuf, nil
+	}
+	return 0, fmt.Errorf("unknown format %q (json, msgpack or pb)", value)
+}
+
+// frameType returns the WebSocket frame type carrying the format
+func (f Format) frameType() int {
+	if f == FormatJSON {
+		return websocket.TextMessage
+	}
+	return websocket.BinaryMessage
+}
+
+// parseCompression parses a ?compression= value: deflate enables
+// permessage-deflate for the client when its browser or library offers it
+func parseCompression(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "none":
+		return false, nil
+	case "deflate":
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown compression %q (none or deflate)", value)
+}
+
+// encoding is one encoding of a broadcast message, shared by the clients asking for it
+type encoding struct {
+	buf      *[]byte                    // Pooled encoded bytes
+	prepared *websocket.PreparedMessage // Compressed frames, built for the first client asking for compression
+	skipped  bool                       // The message does not exist in the version or failed to encode
+}
+
+// encodings caches the encodings of a broadcast message by protocol version, quantity unit and format
+type encodings [ProtocolVersion + 1][unitCount][formatCount]encoding
+
+// release returns the buffers of every encoding to the pool
+func (e *encodings) release() {
+	for version := range e {
+		for unit := range e[version] {
+			for format := range e[version][unit] {
+				if bufPtr := e[version][unit][format].buf; bufPtr != nil {
+					releaseBuffer(bufPtr)
+				}
+			}
+		}
+	}
+}
+
+// preparedMessage returns the message as frames compressed once for every client using them
+func (e *encoding) preparedMessage(format Format) (*websocket.PreparedMessage, error) {
+	if e.prepared == nil {
+		pm, err := websocket.NewPreparedMessage(format.frameType(), *e.buf)
+		if err != nil {
+			return nil, err
+		}
+		e.prepared = pm
+	}
+	return e.prepared, nil
+}
+
+// encode returns the encoding of msg for a client, encoding it on first use.
+// Other formats are transcoded from the JSON encoding, itself cached for JSON
+// clients. It returns nil when the message is not sent to the client.
+func (s *Server) encode(encoded *encodings, msg interface{}, version, unit int, format Format) *encoding {
+	e := &encoded[version][unit][format]
+	if e.skipped {
+		return nil
+	}
+	if e.buf != nil {
+		return e
+	}
+
+	if format != FormatJSON {
+		source := s.encode(encoded, msg, version, unit, FormatJSON)
+		if source == nil {
+			e.skipped = true
+			return nil
+		}
+		bufPtr, err := transcode(*source.buf, format)
+		if err != nil {
+			log.Printf("Error encoding message as %s: %v", format, err)
+			e.skipped = true
+			return nil
+		}
+		e.buf = bufPtr
+		return e
+	}
+
+	versioned, ok := withVersion(s.withUnit(msg, types.QuantityUnits[unit]), version)
+	if !ok {
+		e.skipped = true
+		return nil
+	}
+	bufPtr, err := encodeMessage(versioned)
+	if err != nil {
+		log.Printf("Error encoding message: %v", err)
+		e.skipped = true
+		return nil
+	}
+	e.buf = bufPtr
+	return e
+}
@@ -0,0 +1,179 @@
+package websocket
+
+import (
+	"orderbook/internal/aggregation"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// ChannelLadder is the channel of ladder messages, sent only to subscribed clients
+const ChannelLadder = "ladder"
+
+// LadderMessage is the consolidated book of all venues bucketed at the current
+// tick around the consolidated mid, highest price first. Prices are published
+// prices (quote converted, net of fees when enabled) and quantities are in base
+// units. It is sent to ProtocolV2 clients subscribed to ChannelLadder.
+type LadderMessage struct {
+	Type      MessageType    `json:"type"`
+	Version   int            `json:"v,omitempty"`
+	Tick      float64        `json:"tick"`
+	Mid       string         `json:"mid"`    // Mean of the best bid and best ask across venues
+	Venues    []string       `json:"venues"` // Venues merged into the ladder
+	Buckets   []LadderBucket `json:"buckets"`
+	Timestamp int64          `json:"timestamp"`
+
+	rates rateSet // Rate profiles the message is pushed to
+}
+
+// LadderBucket is the consolidated liquidity of prices in [price, price + tick)
+type LadderBucket struct {
+	Price     string `json:"price"`
+	Bids      string `json:"bids"`      // Bid quantity across venues
+	Asks      string `json:"asks"`      // Ask quantity across venues
+	Imbalance string `json:"imbalance"` // (bids - asks) / (bids + asks), 0 for an empty bucket
+}
+
+// ladderSide is the bid and ask quantity of one bucket
+type ladderSide struct {
+	bids, asks decimal.Decimal
+}
+
+// SetLadderBuckets sets the buckets on each side of the mid bucket of ladder
+// messages. It must be called before Start.
+func (s *Server) SetLadderBuckets(buckets int) {
+	s.ladderBuckets = buckets
+}
+
+// buildLadderMessage merges the ready books into 2 * ladderBuckets + 1 buckets
+// centered on the bucket of the consolidated mid. It reports false when no venue
+// has a two-sided book.
+func (s *Server) buildLadderMessage(timestamp int64) (LadderMessage, bool) {
+	s.tickMux.RLock()
+	tick := s.aggregator.GetTickLevel()
+	s.tickMux.RUnlock()
+
+	type venue struct {
+		name       string
+		ob         *orderbook.OrderBook
+		adjustment priceAdjustment
+	}
+	var venues []venue
+	var bestBid, bestAsk decimal.Decimal
+	for _, entry := range s.books.List() {
+		if !entry.Book.IsReady() {
+			continue
+		}
+		stats := entry.Book.GetStats()
+		if stats.BestBid.IsZero() || stats.BestAsk.IsZero() {
+			continue
+		}
+		adjustment := s.priceAdjustment(entry.Key.Exchange)
+		bid, ask := adjustment.bid(stats.BestBid), adjustment.ask(stats.BestAsk)
+		if bestBid.IsZero() || bid.GreaterThan(bestBid) {
+			bestBid = bid
+		}
+		if bestAsk.IsZero() || ask.LessThan(bestAsk) {
+			bestAsk = ask
+		}
+		venues = append(venues, venue{name: entry.Key.Exchange, ob: entry.Book, adjustment: adjustment})
+	}
+	if len(venues) == 0 {
+		return LadderMessage{}, false
+	}
+
+	// Bucket both sides by flooring, so a bucket covers [price, price + tick)
+	aggregator := aggregation.New(tick)
+	mid := bestBid.Add(bestAsk).Div(decimal.NewFromInt(2))
+	center := aggregator.BidBucket(mid)
+	low, high := center-int64(s.ladderBuckets), center+int64(s.ladderBuckets)
+	lowPrice, highPrice := aggregator.BucketPrice(low), aggregator.BucketPrice(high+1)
+
+	buckets := make(map[int64]*ladderSide, 2*s.ladderBuckets+1)
+	add := func(key int64, quantity decimal.Decimal, isBid bool) {
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &ladderSide{}
+			buckets[key] = bucket
+		}
+		if isBid {
+			bucket.bids = bucket.bids.Add(quantity)
+		} else {
+			bucket.asks = bucket.asks.Add(quantity)
+		}
+	}
+
+	names := make([]string, len(venues))
+	for i, v := range venues {
+		names[i] = v.name
+		// Walk each side from the best price until it leaves the ladder
+		visitLadderSide(v.ob, orderbook.Bids, func(level types.PriceLevel) bool {
+			price := v.adjustment.bid(level.Price)
+			if price.LessThan(lowPrice) {
+				return false
+			}
+			if price.LessThan(highPrice) {
+				add(aggregator.BidBucket(price), level.Quantity, true)
+			}
+			return true
+		})
+		visitLadderSide(v.ob, orderbook.Asks, func(level types.PriceLevel) bool {
+			price := v.adjustment.ask(level.Price)
+			if !price.LessThan(highPrice) {
+				return false
+			}
+			if !price.LessThan(lowPrice) {
+				add(aggregator.BidBucket(price), level.Quantity, false)
+			}
+			return true
+		})
+	}
+
+	rows := make([]LadderBucket, 0, high-low+1)
+	for key := high; key >= low; key-- {
+		bucket := buckets[key]
+		if bucket == nil {
+			bucket = &ladderSide{}
+		}
+		imbalance := decimal.Zero
+		if total := bucket.bids.Add(bucket.asks); total.IsPositive() {
+			imbalance = bucket.bids.Sub(bucket.asks).Div(total)
+		}
+		rows = append(rows, LadderBucket{
+			Price:     aggregator.BucketPrice(key).String(),
+			Bids:      bucket.bids.String(),
+			Asks:      bucket.asks.String(),
+			Imbalance: imbalance.StringFixed(4),
+		})
+	}
+
+	return LadderMessage{
+		Type:      MessageTypeLadder,
+		Tick:      float64(tick),
+		Mid:       mid.String(),
+		Venues:    names,
+		Buckets:   rows,
+		Timestamp: timestamp,
+	}, true
+}
+
+// visitLadderSide calls fn for the levels of side, best first, until fn returns
+// false, reading the published view when there is one
+func visitLadderSide(ob *orderbook.OrderBook, side orderbook.Side, fn func(level types.PriceLevel) bool) {
+	view := ob.View()
+	if view == nil {
+		ob.Range(side, decimal.Zero, decimal.Zero, fn)
+		return
+	}
+
+	levels := view.Asks
+	if side == orderbook.Bids {
+		levels = view.Bids
+	}
+	for _, level := range levels {
+		if !fn(level) {
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"orderbook/internal/distributed"
+)
+
+// Permission is the access level granted to clients of a listener
+type Permission int
+
+const (
+	// PermissionReadOnly clients receive broadcasts; tick and symbol changes are rejected
+	PermissionReadOnly Permission = iota
+	// PermissionControl clients may also change the tick level and the symbol
+	PermissionControl
+)
+
+// String returns the name used in listener specs
+func (p Permission) String() string {
+	if p == PermissionControl {
+		return "control"
+	}
+	return "readonly"
+}
+
+// Listener is an address the server accepts WebSocket and REST connections on
+type Listener struct {
+	Network    string // "tcp" or "unix"
+	Address    string // e.g., ":8086", "127.0.0.1:9000" or "/run/orderbook.sock"
+	Permission Permission
+}
+
+// ParseListener parses a listener spec of the form "[unix:]address[=readonly|=control]",
+// e.g., "127.0.0.1:9000=control", ":8087" or "unix:/run/orderbook.sock".
+// Listeners are read-only unless control is requested.
+func ParseListener(spec string) (Listener, error) {
+	listener := Listener{Network: "tcp", Permission: PermissionReadOnly}
+
+	address := spec
+	if i := strings.LastIndex(spec, "="); i >= 0 {
+		address = spec[:i]
+		switch spec[i+1:] {
+		case "readonly":
+			listener.Permission = PermissionReadOnly
+		case "control":
+			listener.Permission = PermissionControl
+		default:
+			return Listener{}, fmt.Errorf("unknown permission %q in listener %q", spec[i+1:], spec)
+		}
+	}
+
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		listener.Network = "unix"
+		address = path
+	}
+	if address == "" {
+		return Listener{}, fmt.Errorf("missing address in listener %q", spec)
+	}
+	listener.Address = address
+	return listener, nil
+}
+
+// String returns the spec of the listener
+func (l Listener) String() string {
+	if l.Network == "unix" {
+		return "unix:" + l.Address + "=" + l.Permission.String()
+	}
+	return l.Address + "=" + l.Permission.String()
+}
+
+// listen opens the listener, replacing a stale Unix socket file left by a previous run
+func (l Listener) listen() (net.Listener, error) {
+	if l.Network == "unix" {
+		if err := removeStaleSocket(l.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen(l.Network, l.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", l, err)
+	}
+	return ln, nil
+}
+
+// removeStaleSocket removes the socket at path if no process accepts connections
+// on it. Anything else at path, or a socket still in use, is left in place.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check socket %s: %w", path, err)
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("failed to listen on %s: not a socket", path)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("failed to listen on %s: socket in use by another process", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	return nil
+}
+
+// SetCollectors serves hub on /collect of control listeners, where the collectors
+// of distributed mode connect, and reports its collectors in the admin state. It
+// must be called before Start.
+func (s *Server) SetCollectors(hub *distributed.Hub) {
+	s.collectors = hub
+}
+
+// handler returns the HTTP routes served on a listener
+func (s *Server) handler(permission Permission) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		s.serveWebSocket(w, r, permission)
+	})
+	mux.HandleFunc("GET /api/books", s.handleBooks)
+	mux.HandleFunc("GET /api/candles/{exchange}", s.handleCandles)
+	mux.HandleFunc("GET /api/depth/{exchange}", s.handleDepth)
+	mux.HandleFunc("GET /api/events/{exchange}", s.handleEvents)
+	mux.HandleFunc("GET /api/liquidity/{exchange}", s.handleLiquidity)
+	mux.HandleFunc("GET /api/ranking", s.handleRanking)
+	mux.HandleFunc("GET /api/reports", s.handleReports)
+	mux.HandleFunc("GET /api/reports/{date}", s.handleReport)
+	mux.HandleFunc("POST /api/route", s.handleRoute)
+	mux.HandleFunc("GET /api/schema", s.handleSchema)
+	mux.HandleFunc("GET /api/schema.proto", s.handleProto)
+	mux.HandleFunc("POST /api/resync/{exchange}", func(w http.ResponseWriter, r *http.Request) {
+		s.handleResync(w, r, permission)
+	})
+	mux.HandleFunc("GET /health", s.handleHealth)
+	if s.collectors != nil && permission == PermissionControl {
+		// Collectors feed the books, which read-only clients must not do
+		mux.Handle("/collect", s.collectors)
+	}
+	s.registerAdmin(mux)
+	return mux
+}
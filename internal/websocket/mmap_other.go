@@ -0,0 +1,14 @@
+//go:build !unix
+
+package websocket
+
+import "os"
+
+// mapFile reads a file into memory where memory maps are unavailable
+func mapFile(path string) ([]byte, func(), error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() {}, nil
+}
@@ -0,0 +1,146 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// appendMsgpack appends the MessagePack encoding of a JSON document to buf.
+// Transcoding the JSON encoding keeps both formats carrying the same fields and
+// values for every protocol version: objects become maps, numbers become
+// integers when they have no fraction and floats otherwise.
+func appendMsgpack(buf []byte, data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return appendMsgpackValue(buf, value)
+}
+
+func appendMsgpackValue(buf []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if v {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return appendMsgpackInt(buf, i), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, 0xcb)
+		return appendUint64(buf, math.Float64bits(f)), nil
+	case string:
+		return appendMsgpackString(buf, v), nil
+	case []interface{}:
+		buf = appendMsgpackHeader(buf, len(v), 0x90, 0xdc)
+		for _, item := range v {
+			var err error
+			if buf, err = appendMsgpackValue(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf = appendMsgpackHeader(buf, len(keys), 0x80, 0xde)
+		for _, key := range keys {
+			buf = appendMsgpackString(buf, key)
+			var err error
+			if buf, err = appendMsgpackValue(buf, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("msgpack: unsupported value %T", value)
+}
+
+// appendMsgpackHeader appends the header of an array or map of n entries,
+// using the fix format below 16 entries
+func appendMsgpackHeader(buf []byte, n int, fix, format16 byte) []byte {
+	switch {
+	case n < 16:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		return append(buf, format16, byte(n>>8), byte(n))
+	default:
+		buf = append(buf, format16+1)
+		return appendUint32(buf, uint32(n))
+	}
+}
+
+func appendMsgpackString(buf []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xda, byte(n>>8), byte(n))
+	default:
+		buf = append(buf, 0xdb)
+		buf = appendUint32(buf, uint32(n))
+	}
+	return append(buf, s...)
+}
+
+func appendMsgpackInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendMsgpackUint(buf, uint64(i))
+	case i >= -32:
+		return append(buf, byte(i))
+	case i >= math.MinInt8:
+		return append(buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return append(buf, 0xd1, byte(i>>8), byte(i))
+	case i >= math.MinInt32:
+		buf = append(buf, 0xd2)
+		return appendUint32(buf, uint32(i))
+	default:
+		buf = append(buf, 0xd3)
+		return appendUint64(buf, uint64(i))
+	}
+}
+
+func appendMsgpackUint(buf []byte, u uint64) []byte {
+	switch {
+	case u < 128:
+		return append(buf, byte(u))
+	case u <= math.MaxUint8:
+		return append(buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return append(buf, 0xcd, byte(u>>8), byte(u))
+	case u <= math.MaxUint32:
+		buf = append(buf, 0xce)
+		return appendUint32(buf, uint32(u))
+	default:
+		buf = append(buf, 0xcf)
+		return appendUint64(buf, u)
+	}
+}
+
+func appendUint32(buf []byte, u uint32) []byte {
+	return append(buf, byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+func appendUint64(buf []byte, u uint64) []byte {
+	return append(buf, byte(u>>56), byte(u>>48), byte(u>>40), byte(u>>32), byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
@@ -0,0 +1,240 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"orderbook/internal/orderbook"
+
+	"github.com/gorilla/websocket"
+)
+
+// decodeMsgpack decodes the subset of MessagePack written by appendMsgpack into
+// the values encoding/json produces, numbers as float64
+func decodeMsgpack(data []byte) (interface{}, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end of data")
+	}
+	b, data := data[0], data[1:]
+	readN := func(n int) (uint64, error) {
+		if len(data) < n {
+			return 0, fmt.Errorf("unexpected end of data")
+		}
+		var u uint64
+		for _, c := range data[:n] {
+			u = u<<8 | uint64(c)
+		}
+		data = data[n:]
+		return u, nil
+	}
+
+	var n uint64
+	var err error
+	switch {
+	case b <= 0x7f:
+		return float64(b), data, nil
+	case b >= 0xe0:
+		return float64(int8(b)), data, nil
+	case b == 0xc0:
+		return nil, data, nil
+	case b == 0xc2, b == 0xc3:
+		return b == 0xc3, data, nil
+	case b >= 0xcc && b <= 0xcf:
+		u, err := readN(1 << (b - 0xcc))
+		return float64(u), data, err
+	case b >= 0xd0 && b <= 0xd3:
+		size := 1 << (b - 0xd0)
+		u, err := readN(size)
+		shift := 64 - 8*size
+		return float64(int64(u<<shift) >> shift), data, err
+	case b == 0xcb:
+		u, err := readN(8)
+		return math.Float64frombits(u), data, err
+	case b&0xe0 == 0xa0, b >= 0xd9 && b <= 0xdb:
+		if b&0xe0 == 0xa0 {
+			n = uint64(b & 0x1f)
+		} else if n, err = readN(1 << (b - 0xd9)); err != nil {
+			return nil, nil, err
+		}
+		if uint64(len(data)) < n {
+			return nil, nil, fmt.Errorf("unexpected end of data")
+		}
+		return string(data[:n]), data[n:], nil
+	case b&0xf0 == 0x90, b == 0xdc, b == 0xdd:
+		if b&0xf0 == 0x90 {
+			n = uint64(b & 0x0f)
+		} else if n, err = readN(2 << (b - 0xdc)); err != nil {
+			return nil, nil, err
+		}
+		items := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			var item interface{}
+			if item, data, err = decodeMsgpack(data); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case b&0xf0 == 0x80, b == 0xde, b == 0xdf:
+		if b&0xf0 == 0x80 {
+			n = uint64(b & 0x0f)
+		} else if n, err = readN(2 << (b - 0xde)); err != nil {
+			return nil, nil, err
+		}
+		fields := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			var key, value interface{}
+			if key, data, err = decodeMsgpack(data); err != nil {
+				return nil, nil, err
+			}
+			if value, data, err = decodeMsgpack(data); err != nil {
+				return nil, nil, err
+			}
+			fields[key.(string)] = value
+		}
+		return fields, data, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported type byte 0x%x", b)
+}
+
+func TestMsgpackMatchesJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  interface{}
+	}{
+		{"orderbook", makeOrderbookMessage(50)},
+		{"stats", makeStatsMessage()},
+		{"versioned orderbook", OrderbookMessage{Type: MessageTypeOrderbook, Version: ProtocolV2, Exchange: "okx", Seq: 7, Checksum: 4294967295}},
+		{"escaped strings", OrderbookMessage{Type: MessageTypeOrderbook, Exchange: "a\"b\\c\n<&> \x01é " + strings.Repeat("x", 300)}},
+		{"numbers", map[string]interface{}{"small": -5, "int8": -100, "int16": -1000, "int32": -100000, "int64": int64(math.MinInt64), "uint64": uint64(math.MaxUint64), "float": 0.25}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.msg)
+			if err != nil {
+				t.Fatalf("json.Marshal() failed: %v", err)
+			}
+			var expected interface{}
+			if err := json.Unmarshal(data, &expected); err != nil {
+				t.Fatalf("json.Unmarshal() failed: %v", err)
+			}
+
+			bufPtr, err := encodeMessageAs(tt.msg, FormatMsgpack)
+			if err != nil {
+				t.Fatalf("encodeMessageAs() failed: %v", err)
+			}
+			got, rest, err := decodeMsgpack(*bufPtr)
+			releaseBuffer(bufPtr)
+			if err != nil || len(rest) != 0 {
+				t.Fatalf("Failed to decode msgpack (%d trailing bytes): %v", len(rest), err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for value, expected := range map[string]Format{"": FormatJSON, "json": FormatJSON, "MsgPack": FormatMsgpack, "pb": FormatProtobuf, "protobuf": FormatProtobuf} {
+		if got, err := ParseFormat(value); err != nil || got != expected {
+			t.Errorf("ParseFormat(%q) = %v, %v; expected %v", value, got, err, expected)
+		}
+	}
+	for _, value := range []string{"proto", "xml"} {
+		if _, err := ParseFormat(value); err == nil {
+			t.Errorf("Expected an error for format %q", value)
+		}
+	}
+
+	for value, expected := range map[string]bool{"": false, "none": false, "deflate": true} {
+		if got, err := parseCompression(value); err != nil || got != expected {
+			t.Errorf("parseCompression(%q) = %v, %v; expected %v", value, got, err, expected)
+		}
+	}
+	if _, err := parseCompression("gzip"); err == nil {
+		t.Error("Expected an error for compression gzip")
+	}
+}
+
+func TestWebSocketFormat(t *testing.T) {
+	s := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	go s.broadcastMessages()
+	ts := httptest.NewServer(s.handler(PermissionReadOnly))
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	for _, query := range []string{"?format=xml", "?compression=gzip", "?rate=realtime"} {
+		_, resp, err := websocket.DefaultDialer.Dial(url+query, nil)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %v (%v)", query, resp, err)
+		}
+	}
+
+	dialer := websocket.Dialer{EnableCompression: true}
+	packed, resp, err := dialer.Dial(url+"?format=msgpack&compression=deflate", nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer packed.Close()
+	if !strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate") {
+		t.Errorf("Expected compression negotiated, got %q", resp.Header.Get("Sec-WebSocket-Extensions"))
+	}
+	plain, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer plain.Close()
+
+	clients := func() int {
+		s.clientsMux.RLock()
+		defer s.clientsMux.RUnlock()
+		return len(s.clients)
+	}
+	for deadline := time.Now().Add(2 * time.Second); clients() != 2; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected two clients, got %d", clients())
+		}
+	}
+	stats := makeStatsMessage()
+	stats.Version = 0
+	s.Publish(stats)
+
+	// Both clients receive the same stats, as MessagePack in binary frames and as JSON in text frames
+	read := func(conn *websocket.Conn, frameType int) map[string]interface{} {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("Expected a stats message: %v", err)
+			}
+			if messageType != frameType {
+				t.Fatalf("Expected frame type %d, got %d", frameType, messageType)
+			}
+			var fields interface{}
+			if frameType == websocket.BinaryMessage {
+				fields, _, err = decodeMsgpack(data)
+			} else {
+				err = json.Unmarshal(data, &fields)
+			}
+			if err != nil {
+				t.Fatalf("Failed to decode message: %v", err)
+			}
+			if m := fields.(map[string]interface{}); m["type"] == string(MessageTypeStats) {
+				return m
+			}
+		}
+	}
+	got, expected := read(packed, websocket.BinaryMessage), read(plain, websocket.TextMessage)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
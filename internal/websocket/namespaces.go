@@ -0,0 +1,161 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"orderbook/internal/config"
+)
+
+// Namespaces serves several independent servers, each with its own books,
+// clients, symbol control and exchange control, from shared listeners. Namespace
+// n is served under /ws/n and /n/ (e.g., /n/api/books, /n/admin/state). The default
+// namespace also answers the plain routes, so single-tenant clients keep working.
+type Namespaces struct {
+	mu        sync.RWMutex
+	servers   map[string]*Server
+	fallback  string                          // Namespace answering the plain routes
+	handlers  map[namespaceRoute]http.Handler // Routes of each server, built on first use
+	port      string
+	listeners []Listener
+}
+
+// namespaceRoute identifies the routes of a server for a listener permission
+type namespaceRoute struct {
+	namespace  string
+	permission Permission
+}
+
+// NewNamespaces creates an empty set of namespaces served on listeners, or on
+// port with control permission when there are none
+func NewNamespaces(port string, listeners []Listener) *Namespaces {
+	return &Namespaces{
+		servers:   make(map[string]*Server),
+		handlers:  make(map[namespaceRoute]http.Handler),
+		port:      port,
+		listeners: listeners,
+	}
+}
+
+// Add serves s under name and starts its broadcasts. The listeners configured on
+// s are ignored; it is reached through the listeners of the namespaces.
+func (n *Namespaces) Add(name string, s *Server) error {
+	if err := config.ValidateNamespace(name); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.servers[name]; ok {
+		return fmt.Errorf("namespace %s already added", name)
+	}
+	n.servers[name] = s
+	if n.fallback == "" {
+		n.fallback = name
+	}
+	s.run()
+	log.Printf("Serving namespace %s on /ws/%s", name, name)
+	return nil
+}
+
+// Start serves the namespaces on every listener, returning when one fails
+func (n *Namespaces) Start() error {
+	listeners := n.listeners
+	if len(listeners) == 0 {
+		listeners = []Listener{{Network: "tcp", Address: ":" + n.port, Permission: PermissionControl}}
+	}
+
+	errs := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		ln, err := listener.listen()
+		if err != nil {
+			return err
+		}
+		log.Printf("WebSocket server listening on %s (namespaced)", listener)
+		go func() {
+			errs <- http.Serve(ln, n.handler(listener.Permission))
+		}()
+	}
+	return <-errs
+}
+
+// handler returns the HTTP routes of every namespace served on a listener
+func (n *Namespaces) handler(permission Permission) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/{namespace}", func(w http.ResponseWriter, r *http.Request) {
+		s := n.server(r.PathValue("namespace"))
+		if s == nil {
+			http.Error(w, "unknown namespace", http.StatusNotFound)
+			return
+		}
+		s.serveWebSocket(w, r, permission)
+	})
+	mux.HandleFunc("/{namespace}/", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("namespace")
+		if n.server(name) == nil {
+			// Plain routes such as /api/books belong to the default namespace
+			n.routes(n.defaultNamespace(), permission).ServeHTTP(w, r)
+			return
+		}
+		http.StripPrefix("/"+name, n.routes(name, permission)).ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		n.routes(n.defaultNamespace(), permission).ServeHTTP(w, r)
+	})
+	return mux
+}
+
+// server returns the server of a namespace, nil if unknown
+func (n *Namespaces) server(name string) *Server {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.servers[name]
+}
+
+// defaultNamespace returns the namespace answering the plain routes
+func (n *Namespaces) defaultNamespace() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.fallback
+}
+
+// SetDefault makes name answer the plain routes instead of the first namespace added
+func (n *Namespaces) SetDefault(name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.fallback = name
+}
+
+// routes returns the routes of a namespace's server for a permission, or a
+// handler answering 404 when there is no such namespace
+func (n *Namespaces) routes(name string, permission Permission) http.Handler {
+	key := namespaceRoute{namespace: name, permission: permission}
+	n.mu.RLock()
+	handler, ok := n.handlers[key]
+	s := n.servers[name]
+	n.mu.RUnlock()
+	if ok {
+		return handler
+	}
+	if s == nil {
+		return http.NotFoundHandler()
+	}
+
+	handler = s.handler(permission)
+	n.mu.Lock()
+	n.handlers[key] = handler
+	n.mu.Unlock()
+	return handler
+}
+
+// Names returns the namespaces in no particular order
+func (n *Namespaces) Names() []string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	names := make([]string, 0, len(n.servers))
+	for name := range n.servers {
+		names = append(names, name)
+	}
+	return names
+}
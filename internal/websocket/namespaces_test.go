@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"orderbook/internal/orderbook"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestNamespaces(t *testing.T) {
+	namespaces := NewNamespaces("0", nil)
+	if err := namespaces.Add("spot", newDepthServer(t)); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	perps := NewServer(newRegistry(map[string]*orderbook.OrderBook{"bybit": orderbook.New()}), "0", nil)
+	if err := namespaces.Add("perps", perps); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	for _, name := range []string{"spot", "api", "Desk", "a/b", ""} {
+		if err := namespaces.Add(name, NewServer(newRegistry(nil), "0", nil)); err == nil {
+			t.Errorf("Expected an error adding namespace %q", name)
+		}
+	}
+
+	srv := httptest.NewServer(namespaces.handler(PermissionControl))
+	defer srv.Close()
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"/spot/api/depth/binance", http.StatusOK},
+		{"/perps/api/depth/binance", http.StatusNotFound},
+		{"/perps/api/depth/bybit", http.StatusServiceUnavailable},
+		{"/api/depth/binance", http.StatusOK}, // The first namespace answers the plain routes
+		{"/perps/health", http.StatusServiceUnavailable},
+		{"/desk/api/depth/binance", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + tt.url)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", tt.url, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: Expected status %d, got %d", tt.url, tt.status, resp.StatusCode)
+		}
+	}
+
+	// Clients of a namespace join its server only
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"/ws/perps", nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	if _, _, err := websocket.DefaultDialer.Dial(wsURL+"/ws/desk", nil); err == nil {
+		t.Errorf("Expected an unknown namespace to be rejected")
+	}
+	clients := func(s *Server) int {
+		s.clientsMux.RLock()
+		defer s.clientsMux.RUnlock()
+		return len(s.clients)
+	}
+	for deadline := time.Now().Add(2 * time.Second); clients(perps) != 1; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 1 client on perps, got %d", clients(perps))
+		}
+	}
+	if count := clients(namespaces.server("spot")); count != 0 {
+		t.Errorf("Expected no clients on spot, got %d", count)
+	}
+}
@@ -0,0 +1,75 @@
+package websocket
+
+import (
+	"orderbook/internal/conversion"
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// priceAdjustment converts the prices of one exchange for publishing: to the
+// common quote currency first, then net of taker fees
+type priceAdjustment struct {
+	factor decimal.Decimal   // Quote conversion multiplier, zero when the book is not converted
+	fee    types.FeeSchedule // Zero unless fee-adjusted prices are enabled
+}
+
+// identity reports whether the adjustment leaves prices unchanged
+func (a priceAdjustment) identity() bool {
+	return a.factor.IsZero() && a.fee.TakerBps == 0
+}
+
+// convert applies the quote conversion only
+func (a priceAdjustment) convert(price decimal.Decimal) decimal.Decimal {
+	if a.factor.IsZero() {
+		return price
+	}
+	return price.Mul(a.factor)
+}
+
+// bid converts a bid price and lowers it by the taker fee
+func (a priceAdjustment) bid(price decimal.Decimal) decimal.Decimal {
+	return a.fee.AdjustBid(a.convert(price))
+}
+
+// ask converts an ask price and raises it by the taker fee
+func (a priceAdjustment) ask(price decimal.Decimal) decimal.Decimal {
+	return a.fee.AdjustAsk(a.convert(price))
+}
+
+// SetConverter normalizes published prices to the converter's quote currency.
+// It must be called before Start.
+func (s *Server) SetConverter(converter *conversion.Converter) {
+	s.converter = converter
+}
+
+// quoteAdjustment returns the conversion of an exchange's prices to the common quote
+func (s *Server) quoteAdjustment(exchange string) priceAdjustment {
+	var adjustment priceAdjustment
+	if s.converter == nil {
+		return adjustment
+	}
+	if factor, ok := s.converter.Factor(exchange); ok && !factor.Equal(decimal.NewFromInt(1)) {
+		adjustment.factor = factor
+	}
+	return adjustment
+}
+
+// priceAdjustment returns the adjustment of an exchange's published prices: the
+// quote conversion, plus the taker fee when fee-adjusted prices are enabled
+func (s *Server) priceAdjustment(exchange string) priceAdjustment {
+	adjustment := s.quoteAdjustment(exchange)
+	if s.feeAdjusted {
+		adjustment.fee = s.fees[exchange]
+	}
+	return adjustment
+}
+
+// adjustLevels returns a copy of levels with adjusted prices
+func adjustLevels(levels []types.PriceLevel, adjust func(decimal.Decimal) decimal.Decimal) []types.PriceLevel {
+	result := make([]types.PriceLevel, len(levels))
+	for i, level := range levels {
+		result[i] = types.PriceLevel{Price: adjust(level.Price), Quantity: level.Quantity}
+	}
+	return result
+}
@@ -0,0 +1,361 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// protoFrameMessages are the messages with a protobuf schema, the one at index i
+// being field i+1 of the Frame oneof
+var protoFrameMessages = []struct {
+	msgType MessageType
+	typ     reflect.Type
+}{
+	{MessageTypeOrderbook, reflect.TypeOf(OrderbookMessage{})},
+	{MessageTypeStats, reflect.TypeOf(StatsMessage{})},
+}
+
+// protoJSONField is the Frame field carrying the JSON encoding of the messages
+// without a protobuf schema
+const protoJSONField = 15
+
+// protoNumbers lists the JSON properties of each struct with a protobuf schema,
+// the property at index i being field i+1. Properties are only ever appended so
+// that field numbers keep their meaning for existing clients.
+var protoNumbers = map[reflect.Type][]string{
+	reflect.TypeOf(OrderbookMessage{}): {
+		"type", "v", "exchange", "seq", "checksum", "bids", "asks", "timestamp",
+	},
+	reflect.TypeOf(StatsMessage{}): {
+		"type", "v", "exchange", "bestBid", "bestAsk", "midPrice", "spread",
+		"bidLiquidity05Pct", "askLiquidity05Pct", "deltaLiquidity05Pct",
+		"bidLiquidity2Pct", "askLiquidity2Pct", "deltaLiquidity2Pct",
+		"bidLiquidity10Pct", "askLiquidity10Pct", "deltaLiquidity10Pct",
+		"totalBidsQty", "totalAsksQty", "totalDelta",
+		"effectiveSpreadBps", "realizedSpreadBps", "averages",
+		"fairValue", "fairValueDeviationBps", "fairValueAlert",
+		"resilienceSweeps", "resilienceRecovered", "resilienceMs",
+		"bidConcentration2Pct", "askConcentration2Pct",
+		"openInterest", "openInterestChange", "prunedLevels",
+		"eventLatencyMs", "eventsPerSecond", "eventsProcessed", "eventsBuffered", "eventsDropped",
+		"gaps", "resyncs", "bufferOverflows", "crossedBook", "crossedBooks",
+		"malformedLevels", "malformedMessages", "applyTimeNs", "timestamp",
+	},
+	reflect.TypeOf(PriceLevel{}): {"price", "quantity", "cumulative", "tail"},
+	reflect.TypeOf(WindowAverage{}): {
+		"window", "coveredMs", "spreadBps",
+		"bidLiquidity05Pct", "askLiquidity05Pct", "bidLiquidity2Pct",
+		"askLiquidity2Pct", "bidLiquidity10Pct", "askLiquidity10Pct",
+	},
+}
+
+// protoKind is the protobuf type of a field
+type protoKind int
+
+const (
+	protoString protoKind = iota
+	protoInt64
+	protoUint32
+	protoBool
+	protoDouble
+	protoStringMap
+	protoMessageKind
+)
+
+// protoKindNames are the .proto types of the scalar and map kinds
+var protoKindNames = map[protoKind]string{
+	protoString:    "string",
+	protoInt64:     "int64",
+	protoUint32:    "uint32",
+	protoBool:      "bool",
+	protoDouble:    "double",
+	protoStringMap: "map<string, string>",
+}
+
+// protoMessage is the protobuf schema of a wire struct
+type protoMessage struct {
+	name   string
+	fields []protoField // By number
+}
+
+// protoField is a field of a protobuf message, named like its JSON property
+type protoField struct {
+	name     string
+	number   int
+	kind     protoKind
+	repeated bool
+	message  *protoMessage // Type of message fields
+}
+
+// protoSchemas are the protobuf schemas of the messages in protoFrameMessages,
+// built once so that a wire struct without field numbers fails at startup
+var protoSchemas = buildProtoSchemas()
+
+func buildProtoSchemas() map[MessageType]*protoMessage {
+	built := make(map[reflect.Type]*protoMessage)
+	schemas := make(map[MessageType]*protoMessage, len(protoFrameMessages))
+	for _, m := range protoFrameMessages {
+		schemas[m.msgType] = buildProtoMessage(m.typ, built)
+	}
+	return schemas
+}
+
+// buildProtoMessage returns the schema of struct t, building those of the structs it uses
+func buildProtoMessage(t reflect.Type, built map[reflect.Type]*protoMessage) *protoMessage {
+	if m, ok := built[t]; ok {
+		return m
+	}
+	numbers, ok := protoNumbers[t]
+	if !ok {
+		panic(fmt.Sprintf("protobuf: no field numbers for %s", t))
+	}
+	m := &protoMessage{name: t.Name()}
+	built[t] = m
+
+	types := make(map[string]reflect.Type)
+	for _, f := range wireFields(t) {
+		types[f.name] = f.typ
+	}
+	if len(types) != len(numbers) {
+		panic(fmt.Sprintf("protobuf: %s has %d properties but %d field numbers", t, len(types), len(numbers)))
+	}
+	for i, name := range numbers {
+		typ, ok := types[name]
+		if !ok {
+			panic(fmt.Sprintf("protobuf: %s has no property %q", t, name))
+		}
+		f := protoField{name: name, number: i + 1}
+		if typ.Kind() == reflect.Slice && isNamedStruct(typ.Elem()) {
+			f.repeated = true
+			typ = typ.Elem()
+		}
+		switch {
+		case isNamedStruct(typ):
+			f.kind = protoMessageKind
+			f.message = buildProtoMessage(typ, built)
+		case typ.Kind() == reflect.String:
+			f.kind = protoString
+		case typ.Kind() == reflect.Bool:
+			f.kind = protoBool
+		case typ.Kind() == reflect.Int, typ.Kind() == reflect.Int32, typ.Kind() == reflect.Int64:
+			f.kind = protoInt64
+		case typ.Kind() == reflect.Uint32:
+			f.kind = protoUint32
+		case typ.Kind() == reflect.Float64:
+			f.kind = protoDouble
+		case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String && typ.Elem().Kind() == reflect.String:
+			f.kind = protoStringMap
+		default:
+			panic(fmt.Sprintf("protobuf: unsupported type %s of %s.%s", typ, t, name))
+		}
+		m.fields = append(m.fields, f)
+	}
+	return m
+}
+
+// Proto returns the protobuf schema of the binary frames sent to format=pb clients
+func Proto() string {
+	var b strings.Builder
+	b.WriteString("// Code generated by cmd/schemagen from internal/websocket; DO NOT EDIT.\n\n")
+	b.WriteString("syntax = \"proto3\";\n\npackage orderbook;\n\n")
+	b.WriteString("// Frame is the payload of each binary frame sent to ?format=pb clients.\n")
+	b.WriteString("// Messages without a schema below carry their JSON encoding in json.\n")
+	b.WriteString("message Frame {\n  oneof message {\n")
+	for i, m := range protoFrameMessages {
+		fmt.Fprintf(&b, "    %s %s = %d;\n", protoSchemas[m.msgType].name, m.msgType, i+1)
+	}
+	fmt.Fprintf(&b, "    bytes json = %d;\n  }\n}\n", protoJSONField)
+
+	written := make(map[*protoMessage]bool)
+	for _, m := range protoFrameMessages {
+		writeProto(&b, protoSchemas[m.msgType], written)
+	}
+	return b.String()
+}
+
+// writeProto writes the declaration of message m followed by those of the messages it uses
+func writeProto(b *strings.Builder, m *protoMessage, written map[*protoMessage]bool) {
+	if written[m] {
+		return
+	}
+	written[m] = true
+
+	fmt.Fprintf(b, "\nmessage %s {\n", m.name)
+	for _, f := range m.fields {
+		typ := protoKindNames[f.kind]
+		if f.kind == protoMessageKind {
+			typ = f.message.name
+		}
+		if f.repeated {
+			typ = "repeated " + typ
+		}
+		fmt.Fprintf(b, "  %s %s = %d;\n", typ, f.name, f.number)
+	}
+	b.WriteString("}\n")
+
+	for _, f := range m.fields {
+		if f.message != nil {
+			writeProto(b, f.message, written)
+		}
+	}
+}
+
+// handleProto serves the protobuf schema of the format=pb frames
+func (s *Server) handleProto(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(Proto()))
+}
+
+// appendProtobuf appends the protobuf Frame encoding of a JSON message to buf.
+// Transcoding the JSON encoding keeps the formats carrying the same values for
+// every protocol version; as in proto3, empty strings, zeros and false are left out.
+func appendProtobuf(buf []byte, data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	msgType, _ := fields["type"].(string)
+	for i, m := range protoFrameMessages {
+		if string(m.msgType) != msgType {
+			continue
+		}
+		message, err := appendProtoMessage(nil, protoSchemas[m.msgType], fields)
+		if err != nil {
+			return nil, err
+		}
+		return appendProtoBytes(buf, i+1, message), nil
+	}
+	return appendProtoBytes(buf, protoJSONField, data), nil
+}
+
+// appendProtoMessage appends the fields of a JSON object encoded as message m
+func appendProtoMessage(buf []byte, m *protoMessage, fields map[string]interface{}) ([]byte, error) {
+	for _, f := range m.fields {
+		value := fields[f.name]
+		if value == nil {
+			continue
+		}
+		var err error
+		if f.repeated {
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("protobuf: %s.%s is not an array", m.name, f.name)
+			}
+			for _, item := range items {
+				if buf, err = appendProtoValue(buf, m, f, item); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if buf, err = appendProtoValue(buf, m, f, value); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// appendProtoValue appends a value of field f, unless it is the proto3 default
+func appendProtoValue(buf []byte, m *protoMessage, f protoField, value interface{}) ([]byte, error) {
+	mismatch := func() error {
+		return fmt.Errorf("protobuf: unexpected %T value of %s.%s", value, m.name, f.name)
+	}
+	switch f.kind {
+	case protoString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, mismatch()
+		}
+		if s == "" {
+			return buf, nil
+		}
+		return appendProtoBytes(buf, f.number, []byte(s)), nil
+	case protoBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, mismatch()
+		}
+		if !b {
+			return buf, nil
+		}
+		return append(appendProtoTag(buf, f.number, 0), 1), nil
+	case protoInt64, protoUint32:
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, mismatch()
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			return buf, nil
+		}
+		return binary.AppendUvarint(appendProtoTag(buf, f.number, 0), uint64(i)), nil
+	case protoDouble:
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, mismatch()
+		}
+		v, err := n.Float64()
+		if err != nil {
+			return nil, err
+		}
+		if v == 0 {
+			return buf, nil
+		}
+		return binary.LittleEndian.AppendUint64(appendProtoTag(buf, f.number, 1), math.Float64bits(v)), nil
+	case protoStringMap:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, mismatch()
+		}
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s, ok := entries[key].(string)
+			if !ok {
+				return nil, mismatch()
+			}
+			entry := appendProtoBytes(appendProtoBytes(nil, 1, []byte(key)), 2, []byte(s))
+			buf = appendProtoBytes(buf, f.number, entry)
+		}
+		return buf, nil
+	case protoMessageKind:
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, mismatch()
+		}
+		message, err := appendProtoMessage(nil, f.message, nested)
+		if err != nil {
+			return nil, err
+		}
+		return appendProtoBytes(buf, f.number, message), nil
+	}
+	return nil, mismatch()
+}
+
+// appendProtoTag appends the key of field number with the wire type
+func appendProtoTag(buf []byte, number int, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(number)<<3|uint64(wireType))
+}
+
+// appendProtoBytes appends a length-delimited field
+func appendProtoBytes(buf []byte, number int, data []byte) []byte {
+	buf = appendProtoTag(buf, number, 2)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
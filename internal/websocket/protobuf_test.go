@@ -0,0 +1,212 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"orderbook/internal/orderbook"
+)
+
+// decodeProto decodes a message encoded by appendProtoMessage into the values
+// encoding/json produces, numbers as float64
+func decodeProto(data []byte, m *protoMessage) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid key")
+		}
+		data = data[n:]
+		var f *protoField
+		for i := range m.fields {
+			if m.fields[i].number == int(key>>3) {
+				f = &m.fields[i]
+			}
+		}
+		if f == nil {
+			return nil, fmt.Errorf("unknown field %d of %s", key>>3, m.name)
+		}
+
+		var value interface{}
+		switch key & 7 {
+		case 0:
+			u, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, fmt.Errorf("invalid varint")
+			}
+			data = data[n:]
+			if f.kind == protoBool {
+				value = u == 1
+			} else {
+				value = float64(int64(u))
+			}
+		case 1:
+			if len(data) < 8 {
+				return nil, fmt.Errorf("unexpected end of data")
+			}
+			value = math.Float64frombits(binary.LittleEndian.Uint64(data))
+			data = data[8:]
+		case 2:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return nil, fmt.Errorf("invalid length")
+			}
+			payload := data[n : n+int(size)]
+			data = data[n+int(size):]
+			switch f.kind {
+			case protoString:
+				value = string(payload)
+			case protoStringMap:
+				entry, err := decodeProto(payload, &protoMessage{name: "entry", fields: []protoField{
+					{name: "key", number: 1, kind: protoString},
+					{name: "value", number: 2, kind: protoString},
+				}})
+				if err != nil {
+					return nil, err
+				}
+				entries, _ := fields[f.name].(map[string]interface{})
+				if entries == nil {
+					entries = make(map[string]interface{})
+				}
+				entries[entry["key"].(string)] = entry["value"]
+				value = entries
+			default:
+				nested, err := decodeProto(payload, f.message)
+				if err != nil {
+					return nil, err
+				}
+				value = nested
+			}
+		default:
+			return nil, fmt.Errorf("unexpected wire type %d", key&7)
+		}
+
+		if f.repeated {
+			items, _ := fields[f.name].([]interface{})
+			value = append(items, value)
+		}
+		fields[f.name] = value
+	}
+	return fields, nil
+}
+
+// withoutDefaults drops the proto3 default values left out of the protobuf encoding
+func withoutDefaults(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		fields := make(map[string]interface{})
+		for key, field := range v {
+			switch field {
+			case nil, "", false, float64(0):
+				continue
+			}
+			if items, ok := field.([]interface{}); ok && len(items) == 0 {
+				continue
+			}
+			fields[key] = withoutDefaults(field)
+		}
+		return fields
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = withoutDefaults(item)
+		}
+		return items
+	}
+	return value
+}
+
+// decodeFrame decodes a Frame, returning the field set and its payload
+func decodeFrame(t *testing.T, data []byte) (int, []byte) {
+	t.Helper()
+	key, n := binary.Uvarint(data)
+	if n <= 0 || key&7 != 2 {
+		t.Fatalf("Expected a length-delimited Frame field, got key %d", key)
+	}
+	size, m := binary.Uvarint(data[n:])
+	if m <= 0 || uint64(len(data)-n-m) != size {
+		t.Fatalf("Expected one Frame field of %d bytes, got %d bytes", size, len(data)-n-m)
+	}
+	return int(key >> 3), data[n+m:]
+}
+
+func TestProtobufMatchesJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  interface{}
+	}{
+		{"orderbook", makeOrderbookMessage(50)},
+		{"stats", makeStatsMessage()},
+		{"versioned orderbook", OrderbookMessage{Type: MessageTypeOrderbook, Version: ProtocolV2, Exchange: "okx", Seq: 7, Checksum: 4294967295, Bids: []PriceLevel{{Price: "1", Tail: true}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.msg)
+			if err != nil {
+				t.Fatalf("json.Marshal() failed: %v", err)
+			}
+			var expected map[string]interface{}
+			if err := json.Unmarshal(data, &expected); err != nil {
+				t.Fatalf("json.Unmarshal() failed: %v", err)
+			}
+
+			bufPtr, err := encodeMessageAs(tt.msg, FormatProtobuf)
+			if err != nil {
+				t.Fatalf("encodeMessageAs() failed: %v", err)
+			}
+			defer releaseBuffer(bufPtr)
+			number, payload := decodeFrame(t, *bufPtr)
+			msgType := MessageType(expected["type"].(string))
+			if want := protoFrameMessages[number-1].msgType; want != msgType {
+				t.Fatalf("Expected Frame field of %s, got %s", msgType, want)
+			}
+			got, err := decodeProto(payload, protoSchemas[msgType])
+			if err != nil {
+				t.Fatalf("Failed to decode protobuf: %v", err)
+			}
+			if want := withoutDefaults(expected); !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestProtobufCarriesOtherMessagesAsJSON(t *testing.T) {
+	msg := TickLevelsMessage{Type: MessageTypeTicks, Levels: []float64{0.1, 1}, Current: 1}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+
+	bufPtr, err := encodeMessageAs(msg, FormatProtobuf)
+	if err != nil {
+		t.Fatalf("encodeMessageAs() failed: %v", err)
+	}
+	defer releaseBuffer(bufPtr)
+	number, payload := decodeFrame(t, *bufPtr)
+	if number != protoJSONField || string(payload) != string(data) {
+		t.Errorf("Expected field %d with %s, got field %d with %s", protoJSONField, data, number, payload)
+	}
+}
+
+func TestHandleProto(t *testing.T) {
+	s := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	rec := httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/schema.proto", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	for _, want := range []string{"OrderbookMessage orderbook = 1;", "repeated PriceLevel bids = 6;", "map<string, string> realizedSpreadBps = 21;", "repeated WindowAverage averages = 22;"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected the schema to declare %q", want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package websocket
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"orderbook/internal/types"
+
+	"github.com/gorilla/websocket"
+)
+
+// Protocol versions spoken with WebSocket clients
+const (
+	// ProtocolV1 is the original schema, assumed for clients that never send hello.
+	// Messages carry no version field and stats stop at totalDelta.
+	ProtocolV1 = 1
+	// ProtocolV2 adds the "v" field, orderbook sequence numbers and checksums, the
+	// execution quality, fair value and health stats, the leadlag and ticks messages,
+	// and the bookdelta channel.
+	ProtocolV2 = 2
+
+	// ProtocolVersion is the newest version served
+	ProtocolVersion = ProtocolV2
+)
+
+// SupportedProtocolVersions lists the versions a client may negotiate
+var SupportedProtocolVersions = []int{ProtocolV1, ProtocolV2}
+
+// WelcomeMessage answers a client hello with the negotiated protocol version
+type WelcomeMessage struct {
+	Type      MessageType `json:"type"`
+	Version   int         `json:"version"`
+	Supported []int       `json:"supported"`
+	// FeeAdjusted reports that published prices are net of taker fees
+	FeeAdjusted bool `json:"feeAdjusted,omitempty"`
+	// Quote is the currency every published price is normalized to
+	Quote string `json:"quote,omitempty"`
+	// Unit is the unit of the client's orderbook and stats quantities, changed with set_unit
+	Unit string `json:"unit,omitempty"`
+	// Rate is the rate profile of the client's orderbook, stats and ladder pushes, chosen on the URL
+	Rate string `json:"rate,omitempty"`
+}
+
+// negotiateVersion returns the newest version supported by both sides
+func negotiateVersion(requested int) int {
+	switch {
+	case requested < ProtocolV1:
+		return ProtocolV1
+	case requested > ProtocolVersion:
+		return ProtocolVersion
+	default:
+		return requested
+	}
+}
+
+// withVersion returns msg as encoded for clients speaking version, or false if
+// the message type does not exist in that version
+func withVersion(msg interface{}, version int) (interface{}, bool) {
+	if version < ProtocolV2 {
+		switch m := msg.(type) {
+		case OrderbookMessage:
+			m.Version = 0
+			m.Seq = 0
+			m.Checksum = 0
+			return m, true
+		case StatsMessage:
+			m.Version = 0
+			return m, true
+		case LeadLagMessage, TickLevelsMessage, RankingMessage, BookDeltaMessage, CandleMessage, IcebergMessage, SignalMessage, ExchangeStatusMessage, AnomalyMessage, LadderMessage:
+			return nil, false
+		}
+		return msg, true
+	}
+
+	switch m := msg.(type) {
+	case OrderbookMessage:
+		m.Version = version
+		return m, true
+	case StatsMessage:
+		m.Version = version
+		return m, true
+	case LeadLagMessage:
+		m.Version = version
+		return m, true
+	case TickLevelsMessage:
+		m.Version = version
+		return m, true
+	case RankingMessage:
+		m.Version = version
+		return m, true
+	case BookDeltaMessage:
+		m.Version = version
+		return m, true
+	case CandleMessage:
+		m.Version = version
+		return m, true
+	case IcebergMessage:
+		m.Version = version
+		return m, true
+	case SignalMessage:
+		m.Version = version
+		return m, true
+	case ExchangeStatusMessage:
+		m.Version = version
+		return m, true
+	case AnomalyMessage:
+		m.Version = version
+		return m, true
+	case LadderMessage:
+		m.Version = version
+		return m, true
+	case SnapshotMessage:
+		m.Version = version
+		return m, true
+	}
+	return msg, true
+}
+
+// client is a connected WebSocket client and its negotiated protocol version
+type client struct {
+	conn       *websocket.Conn
+	permission Permission
+	version    atomic.Int32
+	bookDelta  atomic.Bool  // Subscribed to ChannelBookDelta
+	ladder     atomic.Bool  // Subscribed to ChannelLadder
+	unit       atomic.Int32 // Index of the quantity unit in types.QuantityUnits
+	format     Format       // Encoding of the messages sent, chosen when connecting
+	compress   bool         // permessage-deflate requested when connecting
+	rate       RateProfile  // Rate of the periodic pushes, chosen when connecting
+	writeMu    sync.Mutex   // Serializes writes from the broadcaster and handshake replies
+}
+
+func newClient(conn *websocket.Conn) *client {
+	c := &client{conn: conn}
+	c.version.Store(ProtocolV1)
+	return c
+}
+
+// protocolVersion returns the negotiated protocol version
+func (c *client) protocolVersion() int {
+	return int(c.version.Load())
+}
+
+// quantityUnit returns the unit of the client's orderbook and stats quantities
+func (c *client) quantityUnit() types.QuantityUnit {
+	return types.QuantityUnits[c.unit.Load()]
+}
+
+// write sends a message encoded in the client's format, giving up after writeTimeout
+func (c *client) write(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteMessage(c.format.frameType(), data)
+}
+
+// writePrepared sends a message whose frames are shared with other clients
+func (c *client) writePrepared(pm *websocket.PreparedMessage) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WritePreparedMessage(pm)
+}
+
+// heartbeat pings the client every interval until done is closed. A failed ping
+// closes the connection so the read loop exits and the client is removed.
+func (c *client) heartbeat(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				c.conn.Close()
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// send encodes msg for the client's protocol version and writes it
+func (c *client) send(msg interface{}) error {
+	versioned, ok := withVersion(msg, c.protocolVersion())
+	if !ok {
+		return nil
+	}
+
+	bufPtr, err := encodeMessageAs(versioned, c.format)
+	if err != nil {
+		return err
+	}
+	defer releaseBuffer(bufPtr)
+	return c.write(*bufPtr)
+}
@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RateProfile is the rate at which a client receives the periodic orderbook,
+// stats and ladder pushes, chosen with the ?rate= parameter of the WebSocket URL.
+// Other messages are sent to every client as they happen.
+type RateProfile int
+
+const (
+	// RateNormal pushes every 200ms (5 Hz), the default
+	RateNormal RateProfile = iota
+	// RateSlow pushes every second (1 Hz), e.g. for mobile clients
+	RateSlow
+	// RateFast pushes every 100ms (10 Hz), e.g. for trading UIs
+	RateFast
+
+	rateCount = iota
+)
+
+// pushInterval is the interval of the data push rounds, the period of RateFast
+const pushInterval = 100 * time.Millisecond
+
+// rateNames lists the ?rate= value of each profile
+var rateNames = [rateCount]string{"normal", "slow", "fast"}
+
+// rateDivisors is the number of push rounds between two pushes of each profile
+var rateDivisors = [rateCount]int64{2, 10, 1}
+
+// String returns the ?rate= value of the profile
+func (r RateProfile) String() string {
+	return rateNames[r]
+}
+
+// ParseRateProfile parses a ?rate= value, empty selecting RateNormal
+func ParseRateProfile(value string) (RateProfile, error) {
+	if value == "" {
+		return RateNormal, nil
+	}
+	for rate, name := range rateNames {
+		if strings.EqualFold(value, name) {
+			return RateProfile(rate), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rate %q (slow, normal or fast)", value)
+}
+
+// rateSet is a set of rate profiles
+type rateSet uint8
+
+// has reports whether the set holds rate. The empty set stands for every profile,
+// so messages that are not throttled reach every client.
+func (rs rateSet) has(rate RateProfile) bool {
+	return rs == 0 || rs&(1<<rate) != 0
+}
+
+// messageRates returns the profiles a broadcast message is pushed to
+func messageRates(msg interface{}) rateSet {
+	switch m := msg.(type) {
+	case OrderbookMessage:
+		return m.rates
+	case StatsMessage:
+		return m.rates
+	case LadderMessage:
+		return m.rates
+	}
+	return 0
+}
+
+// dueRates returns the profiles pushed at round that reach a client or, at the
+// normal rate, the recorder. It is empty when the round has nothing to push.
+func (s *Server) dueRates(round int64) rateSet {
+	var due rateSet
+	if s.recorder != nil && round%rateDivisors[RateNormal] == 0 {
+		due |= 1 << RateNormal
+	}
+	s.clientsMux.RLock()
+	defer s.clientsMux.RUnlock()
+	for _, c := range s.clients {
+		if round%rateDivisors[c.rate] == 0 {
+			due |= 1 << c.rate
+		}
+	}
+	return due
+}
@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Recorder appends every broadcast message to a JSON Lines file for later replay.
+// Each line is {"t":<unix millis>,"data":<message as sent to ProtocolVersion clients>}.
+// A chunked recorder writes the same lines to compressed, indexed chunks instead.
+type Recorder struct {
+	mu     sync.Mutex
+	file   *os.File
+	w      *bufio.Writer
+	chunks *chunkWriter // Set for chunked recordings
+	buf    []byte
+}
+
+// NewRecorder creates (or truncates) the recording file at path
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	return &Recorder{
+		file: file,
+		w:    bufio.NewWriterSize(file, 256*1024),
+	}, nil
+}
+
+// NewChunkedRecorder creates a recording in dir as compressed chunk files of about
+// chunkSize bytes (DefaultChunkSize when not positive), each with an index of its
+// blocks for time-based seeks. dir is created if needed and must hold no recording.
+func NewChunkedRecorder(dir string, chunkSize int64) (*Recorder, error) {
+	chunks, err := newChunkWriter(dir, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{chunks: chunks}, nil
+}
+
+// Record appends an encoded message stamped with the broadcast time
+func (r *Recorder) Record(t time.Time, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf = append(r.buf[:0], `{"t":`...)
+	r.buf = strconv.AppendInt(r.buf, t.UnixMilli(), 10)
+	r.buf = append(r.buf, `,"data":`...)
+	r.buf = append(r.buf, data...)
+	r.buf = append(r.buf, "}\n"...)
+
+	if r.chunks != nil {
+		return r.chunks.write(t.UnixMilli(), r.buf)
+	}
+	if _, err := r.w.Write(r.buf); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// Close flushes buffered messages and closes the file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.chunks != nil {
+		return r.chunks.close()
+	}
+	if err := r.w.Flush(); err != nil {
+		r.file.Close()
+		return fmt.Errorf("failed to flush recording: %w", err)
+	}
+	return r.file.Close()
+}
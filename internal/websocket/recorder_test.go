@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder() failed: %v", err)
+	}
+
+	start := time.UnixMilli(1700000000000)
+	messages := []string{
+		`{"type":"orderbook","v":2,"exchange":"okx","bids":null,"asks":null,"timestamp":1}`,
+		`{"type":"stats","v":2,"exchange":"okx"}`,
+		`{"type":"leadlag","v":2,"pairs":[],"scores":{},"timestamp":3}`,
+	}
+	for i, msg := range messages {
+		if err := recorder.Record(start.Add(time.Duration(i)*100*time.Millisecond), []byte(msg)); err != nil {
+			t.Fatalf("Record() failed: %v", err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	var replayed []string
+	began := time.Now()
+	err = Replay(context.Background(), path, 10, func(data []byte) {
+		replayed = append(replayed, string(data))
+	})
+	if err != nil {
+		t.Fatalf("Replay() failed: %v", err)
+	}
+
+	if len(replayed) != len(messages) {
+		t.Fatalf("Expected %d messages, got %d", len(messages), len(replayed))
+	}
+	for i, msg := range messages {
+		if replayed[i] != msg {
+			t.Errorf("Expected message %d to be %s, got %s", i, msg, replayed[i])
+		}
+	}
+
+	// 200ms of recorded gaps at 10x speed
+	if elapsed := time.Since(began); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected replay to keep recorded timing, finished in %v", elapsed)
+	}
+}
+
+func TestChunkedRecordAndSeek(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "session")
+	// Tiny chunks so the recording spans several chunk files
+	recorder, err := NewChunkedRecorder(dir, 1)
+	if err != nil {
+		t.Fatalf("NewChunkedRecorder() failed: %v", err)
+	}
+
+	start := time.UnixMilli(1700000000000)
+	padding := strings.Repeat("x", recordBlockSize/4)
+	const count = 20
+	for i := 0; i < count; i++ {
+		msg := fmt.Sprintf(`{"type":"stats","seq":%d,"pad":"%s"}`, i, padding)
+		if err := recorder.Record(start.Add(time.Duration(i)*time.Millisecond), []byte(msg)); err != nil {
+			t.Fatalf("Record() failed: %v", err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if _, err := NewChunkedRecorder(dir, 1); err == nil {
+		t.Errorf("Expected an error recording into a directory holding a recording")
+	}
+
+	chunks, _ := chunkFiles(dir)
+	if len(chunks) < 2 {
+		t.Fatalf("Expected several chunks, got %d", len(chunks))
+	}
+
+	replay := func(from time.Time) []int {
+		var seqs []int
+		err := ReplayFrom(context.Background(), dir, from, 1000, func(data []byte) {
+			var msg struct{ Seq int }
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatalf("Expected a recorded message, got %v", err)
+			}
+			seqs = append(seqs, msg.Seq)
+		})
+		if err != nil {
+			t.Fatalf("ReplayFrom() failed: %v", err)
+		}
+		return seqs
+	}
+
+	if seqs := replay(time.Time{}); len(seqs) != count || seqs[0] != 0 || seqs[count-1] != count-1 {
+		t.Errorf("Expected every message in order, got %v", seqs)
+	}
+	if seqs := replay(start.Add(13 * time.Millisecond)); len(seqs) != count-13 || seqs[0] != 13 {
+		t.Errorf("Expected the messages from 13 on, got %v", seqs)
+	}
+}
+
+func TestChunkIndexCodec(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "session")
+	recorder, err := NewChunkedRecorder(dir, 0)
+	if err != nil {
+		t.Fatalf("NewChunkedRecorder() failed: %v", err)
+	}
+	if err := recorder.Record(time.UnixMilli(1700000000000), []byte(`{"type":"stats","seq":1}`)); err != nil {
+		t.Fatalf("Record() failed: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	chunks, _ := chunkFiles(dir)
+	if len(chunks) != 1 {
+		t.Fatalf("Expected one chunk, got %d", len(chunks))
+	}
+	indexPath := strings.TrimSuffix(chunks[0], chunkExt) + indexExt
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	if string(data[:4]) != indexMagic || data[4] != indexVersion || chunkCodec(data[5]) != codecFlate {
+		t.Fatalf("Expected a version %d DEFLATE header, got %v", indexVersion, data[:indexHeaderSize])
+	}
+
+	replayed := func() error {
+		return ReplayFrom(context.Background(), dir, time.Time{}, 1000, func([]byte) {})
+	}
+
+	// Indexes written before the header was added are read as DEFLATE
+	if err := os.WriteFile(indexPath, data[indexHeaderSize:], 0o644); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+	if err := replayed(); err != nil {
+		t.Errorf("Expected a headerless index to replay, got %v", err)
+	}
+
+	data[5] = 9
+	if err := os.WriteFile(indexPath, data, 0o644); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+	if err := replayed(); err == nil || !strings.Contains(err.Error(), "unsupported codec 9") {
+		t.Errorf("Expected an unsupported codec error, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// maxRecordedLine bounds a single recorded message (deep orderbook messages are large)
+const maxRecordedLine = 16 * 1024 * 1024
+
+// RecordedMessage is one line of a recording made by Recorder
+type RecordedMessage struct {
+	Time int64           `json:"t"`    // Broadcast time in Unix milliseconds
+	Data json.RawMessage `json:"data"` // Encoded message
+}
+
+// ReplayConfig holds the settings of a replay server
+type ReplayConfig struct {
+	Path  string    // Recording file or chunked recording directory written by Recorder
+	Port  string    // Port serving /ws
+	Speed float64   // Playback speed multiplier (1 = real time)
+	Loop  bool      // Restart from the beginning when the recording ends
+	From  time.Time // Skip the messages recorded before this time, zero plays everything
+}
+
+// ReplayServer serves a recording over /ws with the original message timing, so
+// front-ends can be developed against reproducible data. Messages are replayed as
+// recorded (ProtocolVersion schema) to every client; client messages are ignored.
+type ReplayServer struct {
+	config     ReplayConfig
+	upgrader   websocket.Upgrader
+	clients    map[*websocket.Conn]*client
+	clientsMux sync.RWMutex
+}
+
+// NewReplayServer creates a replay server for the recording in config
+func NewReplayServer(config ReplayConfig) *ReplayServer {
+	if config.Speed <= 0 {
+		config.Speed = 1
+	}
+	return &ReplayServer{
+		config:  config,
+		clients: make(map[*websocket.Conn]*client),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+	}
+}
+
+// Start plays the recording and serves it until ctx is cancelled
+func (s *ReplayServer) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	server := &http.Server{Addr: ":" + s.config.Port, Handler: mux}
+
+	go func() {
+		for {
+			if err := ReplayFrom(ctx, s.config.Path, s.config.From, s.config.Speed, s.broadcast); err != nil {
+				log.Printf("Replay error: %v", err)
+				break
+			}
+			if !s.config.Loop || ctx.Err() != nil {
+				break
+			}
+			log.Printf("Replay finished, restarting %s", s.config.Path)
+		}
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("Replay server starting on port %s (%s at %.1fx)", s.config.Port, s.config.Path, s.config.Speed)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *ReplayServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
+	}
+
+	s.clientsMux.Lock()
+	s.clients[conn] = newClient(conn)
+	s.clientsMux.Unlock()
+	log.Printf("New replay client connected from %s", r.RemoteAddr)
+
+	defer func() {
+		s.clientsMux.Lock()
+		delete(s.clients, conn)
+		s.clientsMux.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// broadcast sends a recorded message to every client
+func (s *ReplayServer) broadcast(data []byte) {
+	s.clientsMux.RLock()
+	defer s.clientsMux.RUnlock()
+
+	for conn, c := range s.clients {
+		if err := c.write(data); err != nil {
+			conn.Close()
+		}
+	}
+}
+
+// errReplayStopped ends a replay whose context was cancelled
+var errReplayStopped = errors.New("replay stopped")
+
+// Replay reads the recording at path and passes each message to send, sleeping
+// between messages for the recorded gap divided by speed
+func Replay(ctx context.Context, path string, speed float64, send func(data []byte)) error {
+	return ReplayFrom(ctx, path, time.Time{}, speed, send)
+}
+
+// ReplayFrom is Replay starting at the first message recorded at or after from.
+// path may be a recording file or a chunked recording directory, whose indexes
+// let it skip to from without decompressing the earlier blocks.
+func ReplayFrom(ctx context.Context, path string, from time.Time, speed float64, send func(data []byte)) error {
+	var start int64
+	if !from.IsZero() {
+		start = from.UnixMilli()
+	}
+	var last int64
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %w", err)
+	}
+	if info.IsDir() {
+		err = chunkBlocks(path, start, func(block io.Reader) error {
+			return replayLines(ctx, block, start, speed, send, &last)
+		})
+	} else {
+		var file *os.File
+		if file, err = os.Open(path); err != nil {
+			return fmt.Errorf("failed to open recording: %w", err)
+		}
+		defer file.Close()
+		err = replayLines(ctx, file, start, speed, send, &last)
+	}
+	if errors.Is(err, errReplayStopped) {
+		return nil
+	}
+	return err
+}
+
+// replayLines sends the recorded lines of r at or after from, keeping the gaps
+// since the message last sent
+func replayLines(ctx context.Context, r io.Reader, from int64, speed float64, send func(data []byte), last *int64) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordedLine)
+
+	for line := 1; scanner.Scan(); line++ {
+		var msg RecordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return fmt.Errorf("failed to decode recording line %d: %w", line, err)
+		}
+		if msg.Time < from {
+			continue
+		}
+
+		if *last != 0 && msg.Time > *last {
+			gap := time.Duration(float64(time.Duration(msg.Time-*last)*time.Millisecond) / speed)
+			select {
+			case <-time.After(gap):
+			case <-ctx.Done():
+				return errReplayStopped
+			}
+		}
+		*last = msg.Time
+		send(msg.Data)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read recording: %w", err)
+	}
+	return nil
+}
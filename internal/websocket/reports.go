@@ -0,0 +1,64 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"orderbook/internal/reporting"
+)
+
+// ReportsResponse is the body of the report list endpoint
+type ReportsResponse struct {
+	Dates []string `json:"dates"` // Dates of the available reports, oldest first, ending with the day in progress
+}
+
+// SetReports serves the daily feed SLA reports of tracker at GET /api/reports
+// and GET /api/reports/{date}. It must be called before Start.
+func (s *Server) SetReports(tracker *reporting.Tracker) {
+	s.reports = tracker
+}
+
+// handleReports lists the dates of the available reports
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	if s.reports == nil {
+		http.Error(w, "reports not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ReportsResponse{Dates: s.reports.Dates()}); err != nil {
+		log.Printf("Error writing reports response: %v", err)
+	}
+}
+
+// handleReport serves the report of a day as JSON, or as Markdown with ?format=markdown
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if s.reports == nil {
+		http.Error(w, "reports not enabled", http.StatusNotFound)
+		return
+	}
+	date := r.PathValue("date")
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "markdown" {
+		http.Error(w, "invalid format: "+format, http.StatusBadRequest)
+		return
+	}
+	report, ok := s.reports.Report(date)
+	if !ok {
+		http.Error(w, "no report for "+date, http.StatusNotFound)
+		return
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		if err := reporting.WriteMarkdown(w, report); err != nil {
+			log.Printf("Error writing report response: %v", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error writing report response: %v", err)
+	}
+}
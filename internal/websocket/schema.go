@@ -0,0 +1,313 @@
+package websocket
+
+//go:generate go run ../../cmd/schemagen -schema ../../frontend/src/types/protocol.schema.json -ts ../../frontend/src/types/protocol.d.ts -proto ../../frontend/src/types/protocol.proto
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"orderbook/internal/analytics"
+	"orderbook/internal/routing"
+
+	"github.com/shopspring/decimal"
+)
+
+// SchemaVersion identifies the JSON Schema dialect of Schema
+const SchemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+// wireTypes are the messages and REST bodies described by the schema, in output order
+var wireTypes = []interface{}{
+	ClientMessage{},
+	WelcomeMessage{},
+	OrderbookMessage{},
+	StatsMessage{},
+	TickLevelsMessage{},
+	LeadLagMessage{},
+	RankingMessage{},
+	BookDeltaMessage{},
+	CandleMessage{},
+	IcebergMessage{},
+	SignalMessage{},
+	ExchangeStatusMessage{},
+	AnomalyMessage{},
+	LadderMessage{},
+	SnapshotMessage{},
+	DepthResponse{},
+	LiquidityResponse{},
+	BooksResponse{},
+	CandlesResponse{},
+	ReportsResponse{},
+	HealthResponse{},
+	FeedEventsResponse{},
+	RouteRequest{},
+	RouteResponse{},
+}
+
+// wireEnums lists the values of string types with a closed set of values
+var wireEnums = map[reflect.Type][]string{
+	reflect.TypeOf(MessageType("")): {
+		string(MessageTypeOrderbook),
+		string(MessageTypeStats),
+		string(MessageTypeLeadLag),
+		string(MessageTypeTicks),
+		string(MessageTypeRanking),
+		string(MessageTypeWelcome),
+		string(MessageTypeBookDelta),
+		string(MessageTypeCandle),
+		string(MessageTypeIceberg),
+		string(MessageTypeSignal),
+		string(MessageTypeExchangeStatus),
+		string(MessageTypeAnomaly),
+		string(MessageTypeLadder),
+		string(MessageTypeSnapshot),
+	},
+	reflect.TypeOf(ExchangeStatus("")): {
+		string(StatusConnecting),
+		string(StatusConnected),
+		string(StatusInitialized),
+		string(StatusStale),
+		string(StatusResyncing),
+		string(StatusDisconnected),
+	},
+	reflect.TypeOf(BookSide("")):     {string(SideBid), string(SideAsk)},
+	reflect.TypeOf(routing.Side("")): {string(routing.Buy), string(routing.Sell)},
+	reflect.TypeOf(analytics.AnomalyMetric("")): {
+		string(analytics.AnomalySpread),
+		string(analytics.AnomalyDepth),
+	},
+}
+
+var (
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// wireField is a JSON property of a wire struct
+type wireField struct {
+	name     string
+	typ      reflect.Type
+	optional bool // Tagged omitempty or a pointer
+}
+
+// wireFields returns the JSON properties of a struct in declaration order,
+// flattening embedded structs the way encoding/json does
+func wireFields(t reflect.Type) []wireField {
+	var fields []wireField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, wireFields(f.Type)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, wireField{
+			name:     name,
+			typ:      f.Type,
+			optional: strings.Contains(opts, "omitempty") || f.Type.Kind() == reflect.Pointer,
+		})
+	}
+	return fields
+}
+
+// isNamedStruct reports whether t is encoded as an object with its own definition
+func isNamedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != decimalType && t != timeType
+}
+
+// Schema returns a JSON Schema describing every wire message and REST body, with
+// one definition per Go struct
+func Schema() map[string]interface{} {
+	defs := make(map[string]interface{})
+	var names []string
+	for _, msg := range wireTypes {
+		t := reflect.TypeOf(msg)
+		schemaDefinition(t, defs)
+		names = append(names, t.Name())
+	}
+
+	refs := make([]interface{}, len(names))
+	for i, name := range names {
+		refs[i] = map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+	return map[string]interface{}{
+		"$schema": SchemaVersion,
+		"title":   "orderbook wire protocol",
+		"anyOf":   refs,
+		"$defs":   defs,
+	}
+}
+
+// schemaDefinition adds the definition of struct t and the structs it uses to defs
+func schemaDefinition(t reflect.Type, defs map[string]interface{}) {
+	if _, ok := defs[t.Name()]; ok {
+		return
+	}
+	properties := make(map[string]interface{})
+	required := []string{}
+	def := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	defs[t.Name()] = def
+
+	for _, f := range wireFields(t) {
+		properties[f.name] = schemaType(f.typ, defs)
+		if !f.optional {
+			required = append(required, f.name)
+		}
+	}
+	if len(required) > 0 {
+		def["required"] = required
+	}
+}
+
+// schemaType returns the schema of a value of type t
+func schemaType(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	if values, ok := wireEnums[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+	switch {
+	case t == decimalType:
+		return map[string]interface{}{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]+)?$`}
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case isNamedStruct(t):
+		schemaDefinition(t, defs)
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaType(t.Elem(), defs)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaType(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaType(t.Elem(), defs)}
+	}
+	panic(fmt.Sprintf("schema: unsupported wire type %s", t))
+}
+
+// TypeScript returns TypeScript type declarations of every wire message and REST
+// body, one type per Go struct plus a union per enumerated string type
+func TypeScript() string {
+	var b strings.Builder
+	b.WriteString("// Code generated by cmd/schemagen from internal/websocket; DO NOT EDIT.\n")
+
+	enums := make([]reflect.Type, 0, len(wireEnums))
+	for t := range wireEnums {
+		enums = append(enums, t)
+	}
+	sort.Slice(enums, func(i, j int) bool { return enums[i].Name() < enums[j].Name() })
+	for _, t := range enums {
+		quoted := make([]string, len(wireEnums[t]))
+		for i, value := range wireEnums[t] {
+			quoted[i] = "'" + value + "'"
+		}
+		fmt.Fprintf(&b, "\nexport type %s = %s;\n", t.Name(), strings.Join(quoted, " | "))
+	}
+
+	written := make(map[string]bool)
+	for _, msg := range wireTypes {
+		writeTypeScript(&b, reflect.TypeOf(msg), written)
+	}
+	return b.String()
+}
+
+// writeTypeScript writes the declaration of struct t after those of the structs it uses
+func writeTypeScript(b *strings.Builder, t reflect.Type, written map[string]bool) {
+	if written[t.Name()] {
+		return
+	}
+	written[t.Name()] = true
+
+	fields := wireFields(t)
+	for _, f := range fields {
+		for _, nested := range nestedStructs(f.typ) {
+			writeTypeScript(b, nested, written)
+		}
+	}
+
+	fmt.Fprintf(b, "\nexport type %s = {\n", t.Name())
+	for _, f := range fields {
+		optional := ""
+		if f.optional {
+			optional = "?"
+		}
+		fmt.Fprintf(b, "  %s%s: %s;\n", f.name, optional, typeScriptType(f.typ))
+	}
+	b.WriteString("};\n")
+}
+
+// nestedStructs returns the structs with their own declaration used by type t
+func nestedStructs(t reflect.Type) []reflect.Type {
+	switch {
+	case isNamedStruct(t):
+		return []reflect.Type{t}
+	case t.Kind() == reflect.Pointer, t.Kind() == reflect.Slice, t.Kind() == reflect.Array, t.Kind() == reflect.Map:
+		return nestedStructs(t.Elem())
+	}
+	return nil
+}
+
+// typeScriptType returns the TypeScript type of a value of type t
+func typeScriptType(t reflect.Type) string {
+	if _, ok := wireEnums[t]; ok {
+		return t.Name()
+	}
+	switch {
+	case t == decimalType, t == timeType:
+		return "string"
+	case isNamedStruct(t):
+		return t.Name()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeScriptType(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return typeScriptType(t.Elem()) + "[]"
+	case reflect.Map:
+		return "Record<string, " + typeScriptType(t.Elem()) + ">"
+	default:
+		return "number"
+	}
+}
+
+// handleSchema serves the JSON Schema of the wire protocol
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Schema()); err != nil {
+		log.Printf("Error writing schema response: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"orderbook/internal/orderbook"
+)
+
+func TestHandleSchema(t *testing.T) {
+	s := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	rec := httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/schema", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+			Required   []string                          `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &schema); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+
+	book, ok := schema.Defs["OrderbookMessage"]
+	if !ok || book.Properties["bids"]["items"].(map[string]interface{})["$ref"] != "#/$defs/PriceLevel" {
+		t.Errorf("Expected OrderbookMessage bids to reference PriceLevel, got %+v", book)
+	}
+	if _, ok := book.Properties["seq"]; !ok || contains(book.Required, "seq") || !contains(book.Required, "exchange") {
+		t.Errorf("Expected seq optional and exchange required, got %v", book.Required)
+	}
+
+	// Embedded routing.Plan fields are flattened into the response
+	route := schema.Defs["RouteResponse"]
+	if route.Properties["cost"]["type"] != "string" || route.Properties["side"]["enum"] == nil {
+		t.Errorf("Expected a decimal cost and an enumerated side, got %+v", route.Properties)
+	}
+}
+
+// TestGeneratedFilesUpToDate fails when the wire structs changed without running go generate
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	schema, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode schema: %v", err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"../../frontend/src/types/protocol.schema.json", string(schema) + "\n"},
+		{"../../frontend/src/types/protocol.d.ts", TypeScript()},
+		{"../../frontend/src/types/protocol.proto", Proto()},
+	}
+	for _, tt := range tests {
+		got, err := os.ReadFile(tt.path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", tt.path, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Expected %s to match the wire structs, run go generate ./internal/websocket", tt.path)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
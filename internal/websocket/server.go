@@ -2,14 +2,25 @@ package websocket
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
-	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"orderbook/internal/aggregation"
+	"orderbook/internal/analytics"
+	"orderbook/internal/clock"
+	"orderbook/internal/conversion"
+	"orderbook/internal/distributed"
 	"orderbook/internal/orderbook"
+	"orderbook/internal/reporting"
+	"orderbook/internal/routing"
+	"orderbook/internal/scripting"
+	"orderbook/internal/shard"
+	"orderbook/internal/tracing"
 	"orderbook/internal/types"
 
 	"github.com/gorilla/websocket"
@@ -19,105 +30,490 @@ import (
 type MessageType string
 
 const (
-	MessageTypeOrderbook MessageType = "orderbook"
-	MessageTypeStats     MessageType = "stats"
+	MessageTypeOrderbook      MessageType = "orderbook"
+	MessageTypeStats          MessageType = "stats"
+	MessageTypeLeadLag        MessageType = "leadlag"
+	MessageTypeTicks          MessageType = "ticks"
+	MessageTypeRanking        MessageType = "ranking"
+	MessageTypeWelcome        MessageType = "welcome"
+	MessageTypeBookDelta      MessageType = "bookdelta"
+	MessageTypeCandle         MessageType = "candle"
+	MessageTypeIceberg        MessageType = "iceberg"
+	MessageTypeSignal         MessageType = "signal"
+	MessageTypeExchangeStatus MessageType = "exchange_status"
+	MessageTypeAnomaly        MessageType = "anomaly"
+	MessageTypeLadder         MessageType = "ladder"
+	MessageTypeSnapshot       MessageType = "snapshot"
 )
 
 // ClientMessage represents messages sent from client to server
 type ClientMessage struct {
-	Type   string  `json:"type"`
-	Tick   float64 `json:"tick,omitempty"`
-	Symbol string  `json:"symbol,omitempty"`
+	Type     string  `json:"type"`
+	Tick     float64 `json:"tick,omitempty"`
+	Symbol   string  `json:"symbol,omitempty"`
+	Version  int     `json:"version,omitempty"`  // Requested protocol version (hello)
+	Exchange string  `json:"exchange,omitempty"` // Exchange to act on (resync, get_snapshot)
+	Channel  string  `json:"channel,omitempty"`  // Channel to join or leave (subscribe, unsubscribe)
+	Unit     string  `json:"unit,omitempty"`     // Quantity unit of orderbook and stats messages (set_unit)
+	ID       string  `json:"id,omitempty"`       // Request ID echoed in the answer (get_snapshot)
+	Levels   int     `json:"levels,omitempty"`   // Levels per side (get_snapshot)
 }
 
+// Client heartbeat defaults
+const (
+	defaultPingInterval = 30 * time.Second // Interval between server pings
+	defaultPongTimeout  = 60 * time.Second // Silence after which a client is considered dead
+	writeTimeout        = 10 * time.Second // Deadline for a single write to a client
+)
+
+// Depth endpoint limits
+const (
+	defaultDepthLevels = 50
+	maxDepthLevels     = 5000
+)
+
+// DepthResponse is the body of the aggregated depth REST endpoint
+type DepthResponse struct {
+	Exchange  string       `json:"exchange"`
+	Tick      float64      `json:"tick"`
+	Bids      []PriceLevel `json:"bids"`
+	Asks      []PriceLevel `json:"asks"`
+	Timestamp int64        `json:"timestamp"`
+	// FeeAdjusted reports that prices are net of taker fees
+	FeeAdjusted bool `json:"feeAdjusted,omitempty"`
+}
+
+// LiquidityResponse is the body of the depth-at-price endpoint: the liquidity of one
+// side of a book at or better than a price
+type LiquidityResponse struct {
+	Exchange  string   `json:"exchange"`
+	Side      BookSide `json:"side"`
+	Price     string   `json:"price"`
+	Quantity  string   `json:"quantity"` // Cumulative quantity of the levels crossed
+	Notional  string   `json:"notional"` // Sum of price times quantity of the levels crossed
+	Levels    int      `json:"levels"`   // Number of levels crossed
+	Timestamp int64    `json:"timestamp"`
+	// FeeAdjusted reports that prices are net of taker fees
+	FeeAdjusted bool `json:"feeAdjusted,omitempty"`
+}
+
+// BookInfo describes a running book
+type BookInfo struct {
+	Exchange     string `json:"exchange"`
+	Symbol       string `json:"symbol"`
+	Initialized  bool   `json:"initialized"`
+	Ready        bool   `json:"ready"` // Initialized and warmed up, so it is broadcast
+	LastUpdateID int64  `json:"lastUpdateId"`
+	BidLevels    int    `json:"bidLevels"`
+	AskLevels    int    `json:"askLevels"`
+}
+
+// BooksResponse is the body of the books endpoint, sorted by exchange and symbol
+type BooksResponse struct {
+	Books     []BookInfo `json:"books"`
+	Timestamp int64      `json:"timestamp"`
+}
+
+// HealthResponse is the body of the health endpoint used by container health checks
+type HealthResponse struct {
+	Status      string          `json:"status"`      // "ok" once any book is initialized, "starting" otherwise
+	Exchanges   map[string]bool `json:"exchanges"`   // Initialization state per exchange
+	Initialized int             `json:"initialized"` // Number of initialized books
+}
+
+// FeedEvent is a gap, buffer overflow or resync of an exchange feed
+type FeedEvent struct {
+	Kind      string `json:"kind"` // "gap", "buffer_overflow", "resync", "resync_failed", "reset", "crossed" or "expired"
+	Detail    string `json:"detail,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// FeedEventsResponse is the body of the feed event log endpoint
+type FeedEventsResponse struct {
+	Exchange        string      `json:"exchange"`
+	Gaps            int64       `json:"gaps"`
+	Resyncs         int64       `json:"resyncs"`
+	BufferOverflows int64       `json:"bufferOverflows"`
+	Events          []FeedEvent `json:"events"` // Latest events, oldest first
+}
+
+// RouteRequest is the body of the execution routing endpoint
+type RouteRequest struct {
+	Side     routing.Side    `json:"side"` // "buy" or "sell"
+	Quantity decimal.Decimal `json:"quantity"`
+}
+
+// RouteResponse is the venue split suggested by the execution routing endpoint
+type RouteResponse struct {
+	routing.Plan
+	Timestamp int64 `json:"timestamp"`
+}
+
+// OrderbookMessage Seq and Checksum are only sent to ProtocolV2 clients
 type OrderbookMessage struct {
 	Type      MessageType  `json:"type"`
+	Version   int          `json:"v,omitempty"`
 	Exchange  string       `json:"exchange"`
+	Seq       int64        `json:"seq,omitempty"`      // Per-exchange sequence number, incremented by one per message of the client's rate profile
+	Checksum  uint32       `json:"checksum,omitempty"` // Checksum of the top levels, see Checksum
 	Bids      []PriceLevel `json:"bids"`
 	Asks      []PriceLevel `json:"asks"`
 	Timestamp int64        `json:"timestamp"`
+
+	rates rateSet // Rate profiles the message is pushed to
 }
 
+// StatsMessage fields after TotalDelta are only sent to ProtocolV2 clients
 type StatsMessage struct {
-	Type                 MessageType `json:"type"`
-	Exchange             string      `json:"exchange"`
-	BestBid              string      `json:"bestBid"`
-	BestAsk              string      `json:"bestAsk"`
-	MidPrice             string      `json:"midPrice"`
-	Spread               string      `json:"spread"`
-	BidLiquidity05Pct    string      `json:"bidLiquidity05Pct"`
-	AskLiquidity05Pct    string      `json:"askLiquidity05Pct"`
-	DeltaLiquidity05Pct  string      `json:"deltaLiquidity05Pct"`
-	BidLiquidity2Pct     string      `json:"bidLiquidity2Pct"`
-	AskLiquidity2Pct     string      `json:"askLiquidity2Pct"`
-	DeltaLiquidity2Pct   string      `json:"deltaLiquidity2Pct"`
-	BidLiquidity10Pct    string      `json:"bidLiquidity10Pct"`
-	AskLiquidity10Pct    string      `json:"askLiquidity10Pct"`
-	DeltaLiquidity10Pct  string      `json:"deltaLiquidity10Pct"`
-	TotalBidsQty         string      `json:"totalBidsQty"`
-	TotalAsksQty         string      `json:"totalAsksQty"`
-	TotalDelta           string      `json:"totalDelta"`
-	Timestamp            int64       `json:"timestamp"`
+	Type                  MessageType       `json:"type"`
+	Version               int               `json:"v,omitempty"`
+	Exchange              string            `json:"exchange"`
+	BestBid               string            `json:"bestBid"`
+	BestAsk               string            `json:"bestAsk"`
+	MidPrice              string            `json:"midPrice"`
+	Spread                string            `json:"spread"`
+	BidLiquidity05Pct     string            `json:"bidLiquidity05Pct"`
+	AskLiquidity05Pct     string            `json:"askLiquidity05Pct"`
+	DeltaLiquidity05Pct   string            `json:"deltaLiquidity05Pct"`
+	BidLiquidity2Pct      string            `json:"bidLiquidity2Pct"`
+	AskLiquidity2Pct      string            `json:"askLiquidity2Pct"`
+	DeltaLiquidity2Pct    string            `json:"deltaLiquidity2Pct"`
+	BidLiquidity10Pct     string            `json:"bidLiquidity10Pct"`
+	AskLiquidity10Pct     string            `json:"askLiquidity10Pct"`
+	DeltaLiquidity10Pct   string            `json:"deltaLiquidity10Pct"`
+	TotalBidsQty          string            `json:"totalBidsQty"`
+	TotalAsksQty          string            `json:"totalAsksQty"`
+	TotalDelta            string            `json:"totalDelta"`
+	EffectiveSpreadBps    string            `json:"effectiveSpreadBps"`
+	RealizedSpreadBps     map[string]string `json:"realizedSpreadBps"`
+	Averages              []WindowAverage   `json:"averages"` // Time-weighted averages, shortest window first
+	FairValue             string            `json:"fairValue"`
+	FairValueDeviationBps string            `json:"fairValueDeviationBps"`
+	FairValueAlert        bool              `json:"fairValueAlert"`
+	ResilienceSweeps      int               `json:"resilienceSweeps"`     // Sweeps measured
+	ResilienceRecovered   string            `json:"resilienceRecovered"`  // Fraction of the sweeps replenished in time
+	ResilienceMs          int64             `json:"resilienceMs"`         // Median time to replenish
+	BidConcentration2Pct  string            `json:"bidConcentration2Pct"` // Herfindahl index of the bid levels within 2% of mid
+	AskConcentration2Pct  string            `json:"askConcentration2Pct"` // Herfindahl index of the ask levels within 2% of mid
+	OpenInterest          string            `json:"openInterest"`         // Open interest in base units, 0 when not polled
+	OpenInterestChange    string            `json:"openInterestChange"`   // Change of the open interest over the configured window
+	PrunedLevels          int64             `json:"prunedLevels"`
+	EventLatencyMs        int64             `json:"eventLatencyMs"`
+	EventsPerSecond       string            `json:"eventsPerSecond"`
+	EventsProcessed       int64             `json:"eventsProcessed"`
+	EventsBuffered        int64             `json:"eventsBuffered"`
+	EventsDropped         int64             `json:"eventsDropped"`
+	Gaps                  int64             `json:"gaps"`
+	Resyncs               int64             `json:"resyncs"`
+	BufferOverflows       int64             `json:"bufferOverflows"`
+	CrossedBook           bool              `json:"crossedBook"`
+	CrossedBooks          int64             `json:"crossedBooks"`
+	MalformedLevels       int64             `json:"malformedLevels"`
+	MalformedMessages     int64             `json:"malformedMessages"`
+	ApplyTimeNs           int64             `json:"applyTimeNs"`
+	Timestamp             int64             `json:"timestamp"`
+
+	rates rateSet // Rate profiles the message is pushed to
+}
+
+// TickLevelsMessage publishes the tick levels available for the current symbol
+type TickLevelsMessage struct {
+	Type    MessageType `json:"type"`
+	Version int         `json:"v,omitempty"`
+	Levels  []float64   `json:"levels"`
+	Current float64     `json:"current"`
+}
+
+// LeadLagMessage publishes cross-venue price discovery leadership
+type LeadLagMessage struct {
+	Type      MessageType        `json:"type"`
+	Version   int                `json:"v,omitempty"`
+	Pairs     []LeadLagPair      `json:"pairs"`
+	Scores    map[string]float64 `json:"scores"`
+	Timestamp int64              `json:"timestamp"`
+}
+
+// WindowAverage is the wire format of the time-weighted spread and band
+// liquidity averages over a trailing window, at venue prices
+type WindowAverage struct {
+	Window            string `json:"window"`    // e.g., "5m"
+	CoveredMs         int64  `json:"coveredMs"` // Time within the window with a two-sided book
+	SpreadBps         string `json:"spreadBps"`
+	BidLiquidity05Pct string `json:"bidLiquidity05Pct"`
+	AskLiquidity05Pct string `json:"askLiquidity05Pct"`
+	BidLiquidity2Pct  string `json:"bidLiquidity2Pct"`
+	AskLiquidity2Pct  string `json:"askLiquidity2Pct"`
+	BidLiquidity10Pct string `json:"bidLiquidity10Pct"`
+	AskLiquidity10Pct string `json:"askLiquidity10Pct"`
+}
+
+// LeadLagPair is the wire format of a single venue pair relationship
+type LeadLagPair struct {
+	Leader      string  `json:"leader"`
+	Follower    string  `json:"follower"`
+	LagMs       int64   `json:"lagMs"`
+	Correlation float64 `json:"correlation"`
+}
+
+// RankingMessage publishes venues ranked by composite liquidity score, best first.
+// It is also the body of the ranking REST endpoint.
+type RankingMessage struct {
+	Type      MessageType    `json:"type"`
+	Version   int            `json:"v,omitempty"`
+	Venues    []VenueRanking `json:"venues"`
+	Timestamp int64          `json:"timestamp"`
+}
+
+// VenueRanking is the wire format of a single venue's liquidity score
+type VenueRanking struct {
+	Exchange        string  `json:"exchange"`
+	Rank            int     `json:"rank"`
+	Score           float64 `json:"score"` // 0-100
+	SpreadBps       float64 `json:"spreadBps"`
+	SpreadScore     float64 `json:"spreadScore"` // Components in [0, 1]
+	Depth05PctScore float64 `json:"depth05PctScore"`
+	Depth2PctScore  float64 `json:"depth2PctScore"`
+	UptimeScore     float64 `json:"uptimeScore"`
+	FreshnessScore  float64 `json:"freshnessScore"`
+}
+
+// CandleMessage publishes the candles closed since the previous message
+type CandleMessage struct {
+	Type      MessageType `json:"type"`
+	Version   int         `json:"v,omitempty"`
+	Candles   []CandleBar `json:"candles"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// CandleBar is the wire format of an OHLC candle of one exchange's price
+type CandleBar struct {
+	Exchange string `json:"exchange"`
+	Source   string `json:"source"`   // "mid" or "microprice"
+	Interval string `json:"interval"` // e.g. "1s", "5s", "1m"
+	Start    int64  `json:"start"`    // Start of the interval in Unix milliseconds
+	Open     string `json:"open"`
+	High     string `json:"high"`
+	Low      string `json:"low"`
+	Close    string `json:"close"`
+	Samples  int    `json:"samples"`
+	Closed   bool   `json:"closed"` // False for the candle still being built
+}
+
+// CandlesResponse is the body of the candle history endpoint, oldest candle first
+type CandlesResponse struct {
+	Exchange  string      `json:"exchange"`
+	Source    string      `json:"source"`
+	Interval  string      `json:"interval"`
+	Candles   []CandleBar `json:"candles"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// IcebergMessage flags a level near the touch that was repeatedly consumed and
+// refilled to a similar size, at venue prices
+type IcebergMessage struct {
+	Type      MessageType `json:"type"`
+	Version   int         `json:"v,omitempty"`
+	Exchange  string      `json:"exchange"`
+	Side      BookSide    `json:"side"`
+	Price     string      `json:"price"`
+	Displayed string      `json:"displayed"` // Size shown before each consumption
+	Refills   int         `json:"refills"`
+	Refilled  string      `json:"refilled"` // Quantity added back by the refills
+	Timestamp int64       `json:"timestamp"`
+}
+
+// AnomalyMessage flags a spread or depth value far from the venue's recent
+// distribution, at venue prices
+type AnomalyMessage struct {
+	Type      MessageType             `json:"type"`
+	Version   int                     `json:"v,omitempty"`
+	Exchange  string                  `json:"exchange"`
+	Metric    analytics.AnomalyMetric `json:"metric"`
+	Value     float64                 `json:"value"`
+	Mean      float64                 `json:"mean"`   // Rolling mean before the value
+	StdDev    float64                 `json:"stdDev"` // Rolling standard deviation before the value
+	ZScore    float64                 `json:"zScore"`
+	Timestamp int64                   `json:"timestamp"`
+}
+
+// SignalMessage is a value computed by a user script on a venue's book
+type SignalMessage struct {
+	Type      MessageType `json:"type"`
+	Version   int         `json:"v,omitempty"`
+	Script    string      `json:"script"`
+	Exchange  string      `json:"exchange"`
+	Value     float64     `json:"value"`
+	Timestamp int64       `json:"timestamp"`
 }
 
 type PriceLevel struct {
 	Price      string `json:"price"`
 	Quantity   string `json:"quantity"`
 	Cumulative string `json:"cumulative"`
+	Tail       bool   `json:"tail,omitempty"` // The level sums every level at or beyond its price
 }
 
 type Server struct {
-	orderbooks   map[string]*orderbook.OrderBook
+	books        *orderbook.BookRegistry
 	port         string
 	upgrader     websocket.Upgrader
-	clients      map[*websocket.Conn]bool
+	clients      map[*websocket.Conn]*client
 	clientsMux   sync.RWMutex
 	broadcast    chan interface{}
 	aggregator   *aggregation.Aggregator
+	tickLevels   []types.TickLevel // Tick levels clients may select, guarded by tickMux
 	tickMux      sync.RWMutex
 	symbolChange chan string
+	pingInterval time.Duration
+	pongTimeout  time.Duration
+	recorder     *Recorder       // Records every broadcast when set
+	ranking      *RankingMessage // Latest liquidity ranking, guarded by rankingMux
+	rankingMux   sync.RWMutex
+	statuses     map[string]ExchangeStatusMessage // Latest lifecycle state per exchange, guarded by statusMux
+	statusMux    sync.RWMutex
+	candles      *analytics.CandleBuilder     // Serves the candle history endpoint when set
+	reports      *reporting.Tracker           // Serves the SLA report endpoints when set
+	fees         map[string]types.FeeSchedule // Fees per exchange, used by routing and fee-adjusted prices
+	feeAdjusted  bool                         // Publish prices net of taker fees
+	converter    *conversion.Converter        // Normalizes prices to a common quote currency when set
+	adminToken   string                       // Bearer token of the admin endpoints, empty disables them
+	control      ExchangeController           // Running exchanges, used by resync requests and the admin endpoints
+	pool         *shard.Pool                  // Update workers reported in the admin state when set
+	collectors   *distributed.Hub             // Accepts collector connections on /collect when set
+	clock        clock.Clock                  // Time source of the data push and message timestamps
+
+	quantityUnit  types.QuantityUnit         // Default unit of orderbook and stats quantities
+	contractSizes map[string]decimal.Decimal // Base units per contract of each exchange
+
+	deltaSubscribers  atomic.Int32 // Clients subscribed to ChannelBookDelta
+	ladderSubscribers atomic.Int32 // Clients subscribed to ChannelLadder
+
+	listeners     []Listener
+	seqs          [rateCount]map[string]int64 // Last orderbook message sequence per rate profile and exchange, owned by startDataPush
+	checksumDepth int                         // Levels per side covered by checksums, 0 disables
+	ladderBuckets int                         // Buckets on each side of the mid bucket of ladder messages
+	depthFilter   orderbook.FilterConfig      // Levels published in orderbook messages and depth responses, zero publishes all
+	tailDistance  float64                     // Collapse published levels beyond this fraction of mid into a tail level, 0 = disabled
 }
 
-func NewServer(orderbooks map[string]*orderbook.OrderBook, port string, symbolChange chan string) *Server {
-	return &Server{
-		orderbooks:   orderbooks,
-		port:         port,
-		clients:      make(map[*websocket.Conn]bool),
-		broadcast:    make(chan interface{}, 100),
-		aggregator:   aggregation.New(types.Tick1), // Default to 1.0 tick
-		symbolChange: symbolChange,
+// NewServer creates a server publishing the books of the registry
+func NewServer(books *orderbook.BookRegistry, port string, symbolChange chan string) *Server {
+	s := &Server{
+		books:         books,
+		port:          port,
+		clients:       make(map[*websocket.Conn]*client),
+		broadcast:     make(chan interface{}, 100),
+		aggregator:    aggregation.New(types.DefaultTickLevels[0]),
+		tickLevels:    types.DefaultTickLevels,
+		symbolChange:  symbolChange,
+		pingInterval:  defaultPingInterval,
+		pongTimeout:   defaultPongTimeout,
+		statuses:      make(map[string]ExchangeStatusMessage),
+		checksumDepth: DefaultChecksumDepth,
+		ladderBuckets: types.DefaultLadderBuckets,
+		clock:         clock.Real,
+		quantityUnit:  types.UnitBase,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
 			},
+			// Negotiated for every client but only used by those asking ?compression=deflate
+			EnableCompression: true,
 		},
 	}
+	for rate := range s.seqs {
+		s.seqs[rate] = make(map[string]int64)
+	}
+	return s
+}
+
+// AddListener adds an address to serve on. Without listeners the server serves
+// every client with control permission on the port passed to NewServer.
+// It must be called before Start.
+func (s *Server) AddListener(listener Listener) {
+	s.listeners = append(s.listeners, listener)
 }
 
+// Start opens every listener and serves them until one fails
 func (s *Server) Start() error {
-	http.HandleFunc("/ws", s.handleWebSocket)
+	listeners := s.listeners
+	if len(listeners) == 0 {
+		listeners = []Listener{{Network: "tcp", Address: ":" + s.port, Permission: PermissionControl}}
+	}
+
+	errs := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		ln, err := listener.listen()
+		if err != nil {
+			return err
+		}
+		log.Printf("WebSocket server listening on %s", listener)
+		go func() {
+			errs <- http.Serve(ln, s.handler(listener.Permission))
+		}()
+	}
+
+	s.run()
+	return <-errs
+}
 
+// run starts broadcasting to the clients and pushing periodic data
+func (s *Server) run() {
 	go s.broadcastMessages()
 	go s.startDataPush()
-
-	log.Printf("WebSocket server starting on port %s", s.port)
-	return http.ListenAndServe(":"+s.port, nil)
 }
 
-func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+// serveWebSocket upgrades a connection and serves a client with the listener's
+// permission, in the format, compression and rate profile of the ?format=,
+// ?compression= and ?rate= parameters
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request, permission Permission) {
+	format, err := ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	compress, err := parseCompression(r.URL.Query().Get("compression"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rate, err := ParseRateProfile(r.URL.Query().Get("rate"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
+	conn.EnableWriteCompression(compress)
 
+	c := newClient(conn)
+	c.permission = permission
+	c.format = format
+	c.compress = compress
+	c.rate = rate
+	c.unit.Store(int32(unitIndex(s.quantityUnit)))
 	s.clientsMux.Lock()
-	s.clients[conn] = true
+	s.clients[conn] = c
 	s.clientsMux.Unlock()
 
 	log.Printf("New WebSocket client connected from %s", r.RemoteAddr)
 
+	// Any message or pong proves the client is alive; silence past the timeout ends the read loop
+	conn.SetReadDeadline(time.Now().Add(s.pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(s.pongTimeout))
+	})
+	heartbeatDone := make(chan struct{})
+	go c.heartbeat(s.pingInterval, heartbeatDone)
+
 	defer func() {
+		close(heartbeatDone)
+		s.subscribe(c, ChannelBookDelta, false)
+		s.subscribe(c, ChannelLadder, false)
 		s.clientsMux.Lock()
 		delete(s.clients, conn)
 		s.clientsMux.Unlock()
@@ -130,6 +526,7 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			break
 		}
+		conn.SetReadDeadline(time.Now().Add(s.pongTimeout))
 
 		var clientMsg ClientMessage
 		if err := json.Unmarshal(message, &clientMsg); err != nil {
@@ -137,11 +534,33 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		s.handleClientMessage(clientMsg)
+		s.handleClientMessage(c, clientMsg)
+	}
+}
+
+func (s *Server) handleClientMessage(c *client, msg ClientMessage) {
+	switch msg.Type {
+	case "hello":
+		s.handleHello(c, msg.Version)
+	case "subscribe", "unsubscribe":
+		s.subscribe(c, msg.Channel, msg.Type == "subscribe")
+	case "set_unit":
+		s.setQuantityUnit(c, msg.Unit)
+	case "get_snapshot":
+		s.handleGetSnapshot(c, msg)
+	case "set_tick", "change_symbol", "resync":
+		if c.permission < PermissionControl {
+			log.Printf("Rejected %s from read-only client", msg.Type)
+			return
+		}
+		s.handleControlMessage(msg)
+	default:
+		log.Printf("Unknown message type: %s", msg.Type)
 	}
 }
 
-func (s *Server) handleClientMessage(msg ClientMessage) {
+// handleControlMessage applies a message that changes the server state for every client
+func (s *Server) handleControlMessage(msg ClientMessage) {
 	switch msg.Type {
 	case "set_tick":
 		s.setTickLevel(msg.Tick)
@@ -150,167 +569,1042 @@ func (s *Server) handleClientMessage(msg ClientMessage) {
 			log.Printf("Symbol change request: %s", msg.Symbol)
 			s.symbolChange <- msg.Symbol
 		}
-	default:
-		log.Printf("Unknown message type: %s", msg.Type)
+	case "resync":
+		if err := s.exchangeAction(msg.Exchange, actionResync); err != nil {
+			log.Printf("Rejected resync: %v", err)
+		}
+	}
+}
+
+// handleHello negotiates the protocol version of a client and welcomes it
+func (s *Server) handleHello(c *client, requested int) {
+	version := negotiateVersion(requested)
+	c.version.Store(int32(version))
+	log.Printf("Client negotiated protocol v%d (requested v%d)", version, requested)
+
+	welcome := WelcomeMessage{
+		Type:        MessageTypeWelcome,
+		Version:     version,
+		Supported:   SupportedProtocolVersions,
+		FeeAdjusted: s.feeAdjusted,
+		Unit:        string(c.quantityUnit()),
+		Rate:        c.rate.String(),
+	}
+	if s.converter != nil {
+		welcome.Quote = s.converter.Target()
+	}
+	if err := c.send(welcome); err != nil {
+		log.Printf("Error writing to client: %v", err)
+		return
+	}
+	if err := c.send(s.tickLevelsMessage()); err != nil {
+		log.Printf("Error writing to client: %v", err)
+		return
+	}
+	for _, status := range s.exchangeStatuses() {
+		if err := c.send(status); err != nil {
+			log.Printf("Error writing to client: %v", err)
+			return
+		}
 	}
 }
 
 func (s *Server) setTickLevel(tick float64) {
 	tickLevel := types.TickLevel(tick)
 
-	// Validate tick level
-	validTick := false
-	for _, available := range types.AvailableTickLevels {
-		if available == tickLevel {
-			validTick = true
-			break
-		}
+	s.tickMux.Lock()
+	if !types.ContainsTickLevel(s.tickLevels, tickLevel) {
+		s.tickMux.Unlock()
+		log.Printf("Invalid tick level: %f, keeping current", tick)
+		return
 	}
+	s.aggregator.SetTickLevel(tickLevel)
+	s.tickMux.Unlock()
+
+	log.Printf("Tick level changed to: %f", tick)
+}
 
-	if !validTick {
-		log.Printf("Invalid tick level: %f, using default", tick)
+// SetTickLevels replaces the selectable tick levels (e.g., after a symbol change).
+// The current tick falls back to the finest level when it is no longer available.
+func (s *Server) SetTickLevels(levels []types.TickLevel) {
+	if len(levels) == 0 {
 		return
 	}
 
 	s.tickMux.Lock()
-	s.aggregator.SetTickLevel(tickLevel)
+	s.tickLevels = levels
+	if !types.ContainsTickLevel(levels, s.aggregator.GetTickLevel()) {
+		s.aggregator.SetTickLevel(levels[0])
+	}
 	s.tickMux.Unlock()
 
-	log.Printf("Tick level changed to: %f", tick)
+	log.Printf("Tick levels set to: %v", levels)
+	s.broadcast <- s.tickLevelsMessage()
+}
+
+// tickLevelsMessage builds the message announcing the selectable tick levels
+func (s *Server) tickLevelsMessage() TickLevelsMessage {
+	s.tickMux.RLock()
+	defer s.tickMux.RUnlock()
+
+	levels := make([]float64, len(s.tickLevels))
+	for i, level := range s.tickLevels {
+		levels[i] = float64(level)
+	}
+	return TickLevelsMessage{
+		Type:    MessageTypeTicks,
+		Levels:  levels,
+		Current: float64(s.aggregator.GetTickLevel()),
+	}
 }
 
 func (s *Server) broadcastMessages() {
+	var failed []*websocket.Conn
+
 	for msg := range s.broadcast {
+		span := tracing.Start("broadcast")
+		written := 0
+
+		// Encode once per protocol version, quantity unit and format into pooled buffers and share the bytes across clients
+		var encoded encodings
+		dependent := unitDependent(msg)
+		rates := messageRates(msg)
+
+		failed = failed[:0]
 		s.clientsMux.RLock()
-		for client := range s.clients {
-			err := client.WriteJSON(msg)
+		for conn, c := range s.clients {
+			if !c.wants(msg) || !rates.has(c.rate) {
+				continue
+			}
+			version, unit := c.protocolVersion(), 0
+			if dependent {
+				unit = int(c.unit.Load())
+			}
+			e := s.encode(&encoded, msg, version, unit, c.format)
+			if e == nil {
+				continue
+			}
+
+			var err error
+			if c.compress {
+				var pm *websocket.PreparedMessage
+				if pm, err = e.preparedMessage(c.format); err == nil {
+					err = c.writePrepared(pm)
+				}
+			} else {
+				err = c.write(*e.buf)
+			}
 			if err != nil {
 				log.Printf("Error writing to client: %v", err)
-				client.Close()
-				s.clientsMux.Lock()
-				delete(s.clients, client)
-				s.clientsMux.Unlock()
+				failed = append(failed, conn)
 			}
+			written++
 		}
 		s.clientsMux.RUnlock()
+		if span != nil {
+			span.SetAttribute("message", fmt.Sprintf("%T", msg))
+			span.SetAttribute("clients", strconv.Itoa(written))
+			span.End()
+		}
+
+		if s.recorder != nil && rates.has(RateNormal) {
+			s.record(msg, &encoded)
+		}
+
+		encoded.release()
+
+		if len(failed) > 0 {
+			s.clientsMux.Lock()
+			for _, conn := range failed {
+				conn.Close()
+				delete(s.clients, conn)
+			}
+			s.clientsMux.Unlock()
+		}
+	}
+}
+
+// SetClock replaces the time source of the data push and message timestamps,
+// letting tests drive the push with a fake clock. It must be called before Start.
+func (s *Server) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetChecksumDepth sets the levels per side covered by orderbook message
+// checksums (0 disables them). It must be called before Start.
+func (s *Server) SetChecksumDepth(depth int) {
+	s.checksumDepth = depth
+}
+
+// SetDepthFilter limits orderbook messages and depth responses to the levels
+// within filter.MaxDistancePct of mid and of at least filter.MinQuantity, before
+// aggregation, so the long tail of full-depth feeds is neither aggregated nor
+// sent. It must be called before Start.
+func (s *Server) SetDepthFilter(filter orderbook.FilterConfig) {
+	s.depthFilter = filter
+}
+
+// SetTailBucket collapses the aggregated levels of each side at or beyond
+// distancePct of mid into a single tail level at the band edge, whose quantity
+// is the sum of the collapsed levels, in orderbook messages and depth
+// responses. Messages stay small while the total liquidity of the book is
+// kept. 0 disables it. It must be called before Start.
+func (s *Server) SetTailBucket(distancePct float64) {
+	s.tailDistance = distancePct
+}
+
+// SetFees sets the fees of each exchange charged by the routing endpoint and
+// fee-adjusted prices. It must be called before Start.
+func (s *Server) SetFees(fees map[string]types.FeeSchedule) {
+	s.fees = fees
+}
+
+// SetFeeAdjusted publishes prices net of taker fees when enabled: bids are lowered
+// and asks raised by the exchange's taker fee, so prices compared across venues are
+// the ones actually capturable. It must be called before Start.
+func (s *Server) SetFeeAdjusted(enabled bool) {
+	s.feeAdjusted = enabled
+}
+
+// SetCandles serves the candle history of builder at GET /api/candles/{exchange}.
+// It must be called before Start.
+func (s *Server) SetCandles(builder *analytics.CandleBuilder) {
+	s.candles = builder
+}
+
+// SetRecorder records every broadcast message to r. It must be called before Start.
+func (s *Server) SetRecorder(r *Recorder) {
+	s.recorder = r
+}
+
+// record writes msg to the recorder in base units as JSON, reusing its ProtocolVersion encoding when available
+func (s *Server) record(msg interface{}, encoded *encodings) {
+	e := &encoded[ProtocolVersion][0][FormatJSON]
+	if e.buf == nil {
+		versioned, _ := withVersion(msg, ProtocolVersion)
+		bufPtr, err := encodeMessage(versioned)
+		if err != nil {
+			log.Printf("Error encoding message: %v", err)
+			return
+		}
+		e.buf = bufPtr
+	}
+
+	if err := s.recorder.Record(s.clock.Now(), *e.buf); err != nil {
+		log.Printf("Error recording message: %v", err)
+	}
+}
+
+// hasListeners reports whether broadcasts reach any client or the recorder
+func (s *Server) hasListeners() bool {
+	if s.recorder != nil {
+		return true
+	}
+	s.clientsMux.RLock()
+	defer s.clientsMux.RUnlock()
+	return len(s.clients) > 0
+}
+
+// Publish queues a message for broadcast to all connected clients
+func (s *Server) Publish(msg interface{}) {
+	if !s.hasListeners() {
+		return
+	}
+
+	select {
+	case s.broadcast <- msg:
+	default:
+		log.Printf("Warning: broadcast channel full, dropping message")
+	}
+}
+
+// NewLeadLagMessage converts a lead-lag report to wire format
+func NewLeadLagMessage(report analytics.LeadLagReport) LeadLagMessage {
+	pairs := make([]LeadLagPair, 0, len(report.Pairs))
+	for _, pair := range report.Pairs {
+		pairs = append(pairs, LeadLagPair{
+			Leader:      pair.Leader,
+			Follower:    pair.Follower,
+			LagMs:       pair.Lag.Milliseconds(),
+			Correlation: pair.Correlation,
+		})
+	}
+
+	return LeadLagMessage{
+		Type:      MessageTypeLeadLag,
+		Pairs:     pairs,
+		Scores:    report.Scores,
+		Timestamp: report.Timestamp.UnixMilli(),
+	}
+}
+
+// NewRankingMessage converts a liquidity ranking to wire format
+func NewRankingMessage(ranking analytics.LiquidityRanking) RankingMessage {
+	venues := make([]VenueRanking, 0, len(ranking.Scores))
+	for _, score := range ranking.Scores {
+		venues = append(venues, VenueRanking{
+			Exchange:        score.Venue,
+			Rank:            score.Rank,
+			Score:           score.Score,
+			SpreadBps:       score.SpreadBps,
+			SpreadScore:     score.Spread,
+			Depth05PctScore: score.Depth05,
+			Depth2PctScore:  score.Depth2,
+			UptimeScore:     score.Uptime,
+			FreshnessScore:  score.Staleness,
+		})
+	}
+
+	return RankingMessage{
+		Type:      MessageTypeRanking,
+		Venues:    venues,
+		Timestamp: ranking.Timestamp.UnixMilli(),
+	}
+}
+
+// PublishRanking stores the ranking for the REST endpoint and broadcasts it
+func (s *Server) PublishRanking(ranking analytics.LiquidityRanking) {
+	msg := NewRankingMessage(ranking)
+	s.rankingMux.Lock()
+	s.ranking = &msg
+	s.rankingMux.Unlock()
+	s.Publish(msg)
+}
+
+// NewCandleBar converts a candle to wire format
+func NewCandleBar(candle analytics.Candle) CandleBar {
+	return CandleBar{
+		Exchange: candle.Venue,
+		Source:   string(candle.Source),
+		Interval: formatInterval(candle.Interval),
+		Start:    candle.Start.UnixMilli(),
+		Open:     candle.Open.String(),
+		High:     candle.High.String(),
+		Low:      candle.Low.String(),
+		Close:    candle.Close.String(),
+		Samples:  candle.Samples,
+		Closed:   candle.Closed,
+	}
+}
+
+// PublishCandles broadcasts closed candles in a single message
+func (s *Server) PublishCandles(candles []analytics.Candle) {
+	if len(candles) == 0 {
+		return
+	}
+	bars := make([]CandleBar, 0, len(candles))
+	for _, candle := range candles {
+		bars = append(bars, NewCandleBar(candle))
+	}
+	s.Publish(CandleMessage{
+		Type:      MessageTypeCandle,
+		Candles:   bars,
+		Timestamp: s.clock.Now().UnixMilli(),
+	})
+}
+
+// NewIcebergMessage converts an iceberg signal to wire format
+func NewIcebergMessage(signal analytics.IcebergSignal) IcebergMessage {
+	side := SideAsk
+	if signal.IsBid {
+		side = SideBid
+	}
+	return IcebergMessage{
+		Type:      MessageTypeIceberg,
+		Exchange:  signal.Venue,
+		Side:      side,
+		Price:     signal.Price.String(),
+		Displayed: signal.Displayed.String(),
+		Refills:   signal.Refills,
+		Refilled:  signal.Refilled.String(),
+		Timestamp: signal.Time.UnixMilli(),
+	}
+}
+
+// NewAnomalyMessage converts an anomaly signal to wire format
+func NewAnomalyMessage(signal analytics.AnomalySignal) AnomalyMessage {
+	return AnomalyMessage{
+		Type:      MessageTypeAnomaly,
+		Exchange:  signal.Venue,
+		Metric:    signal.Metric,
+		Value:     signal.Value,
+		Mean:      signal.Mean,
+		StdDev:    signal.StdDev,
+		ZScore:    signal.ZScore,
+		Timestamp: signal.Time.UnixMilli(),
+	}
+}
+
+// NewSignalMessage converts a script signal to wire format
+func NewSignalMessage(signal scripting.Signal) SignalMessage {
+	return SignalMessage{
+		Type:      MessageTypeSignal,
+		Script:    signal.Script,
+		Exchange:  signal.Venue,
+		Value:     signal.Value,
+		Timestamp: signal.Time.UnixMilli(),
+	}
+}
+
+// formatInterval writes a candle interval in its shortest unit (e.g., "5s", "1m")
+func formatInterval(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d >= time.Minute && d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	default:
+		return d.String()
 	}
 }
 
 func (s *Server) startDataPush() {
-	ticker := time.NewTicker(200 * time.Millisecond)
+	ticker := s.clock.NewTicker(pushInterval)
 	defer ticker.Stop()
+	changes, unsubscribe := s.books.Subscribe(16)
+	defer unsubscribe()
 
-	for range ticker.C {
-		s.clientsMux.RLock()
-		hasClients := len(s.clients) > 0
-		s.clientsMux.RUnlock()
-
-		if !hasClients {
+	var round int64
+	for {
+		select {
+		case change := <-changes:
+			// A book registered again starts a new message sequence
+			if change.Kind == orderbook.BookRemoved {
+				for _, seqs := range s.seqs {
+					delete(seqs, change.Key.Exchange)
+				}
+			}
+			continue
+		case <-ticker.C():
+		}
+		round++
+		// Rounds are only built for the rate profiles due and listened to
+		due := s.dueRates(round)
+		if due == 0 {
 			continue
 		}
 
-		timestamp := time.Now().UnixMilli()
+		timestamp := s.clock.Now().UnixMilli()
 
-		for exchangeName, ob := range s.orderbooks {
-			if !ob.IsInitialized() {
+		for _, entry := range s.books.List() {
+			if !entry.Book.IsReady() {
 				continue
 			}
 
-			orderbookMsg := s.buildOrderbookMessage(exchangeName, ob, timestamp)
-			s.broadcast <- orderbookMsg
+			// Each profile numbers the books it receives, so downsampled clients see no gaps
+			orderbookMsg := s.buildOrderbookMessage(entry.Key.Exchange, entry.Book, timestamp)
+			for rate := range s.seqs {
+				if due&(1<<rate) == 0 {
+					continue
+				}
+				s.seqs[rate][entry.Key.Exchange]++
+				orderbookMsg.Seq = s.seqs[rate][entry.Key.Exchange]
+				orderbookMsg.rates = 1 << rate
+				s.broadcast <- orderbookMsg
+			}
 
-			statsMsg := s.buildStatsMessage(exchangeName, ob, timestamp)
+			statsMsg := s.buildStatsMessage(entry.Key.Exchange, entry.Book, timestamp)
+			statsMsg.rates = due
 			s.broadcast <- statsMsg
 		}
+
+		// The consolidated ladder is only built for subscribed clients
+		if s.ladderSubscribers.Load() > 0 {
+			if ladderMsg, ok := s.buildLadderMessage(timestamp); ok {
+				ladderMsg.rates = due
+				s.broadcast <- ladderMsg
+			}
+		}
 	}
 }
 
 func (s *Server) buildOrderbookMessage(exchange string, ob *orderbook.OrderBook, timestamp int64) OrderbookMessage {
-	bidsMap := ob.GetBids()
-	asksMap := ob.GetAsks()
+	s.tickMux.RLock()
+	tick := s.aggregator.GetTickLevel()
+	s.tickMux.RUnlock()
+
+	// Keep the pushed tick aggregated incrementally by the orderbook, unless
+	// only the filtered levels are aggregated
+	if _, _, ok := ob.GetAggregatedLevels(tick); !ok && s.depthFilter == (orderbook.FilterConfig{}) {
+		ob.EnableIncrementalAggregation(tick)
+	}
+	bids, asks := buildDepth(ob, tick, 0, s.priceAdjustment(exchange), s.depthFilter, s.tailDistance)
 
-	// Convert maps to slices of types.PriceLevel
-	bidLevels := make([]types.PriceLevel, 0, len(bidsMap))
-	for _, bid := range bidsMap {
-		bidLevels = append(bidLevels, bid)
+	msg := OrderbookMessage{
+		Type:      MessageTypeOrderbook,
+		Exchange:  exchange,
+		Bids:      bids,
+		Asks:      asks,
+		Timestamp: timestamp,
+	}
+	if s.checksumDepth > 0 {
+		msg.Checksum = Checksum(bids, asks, s.checksumDepth)
 	}
+	return msg
+}
 
-	askLevels := make([]types.PriceLevel, 0, len(asksMap))
-	for _, ask := range asksMap {
-		askLevels = append(askLevels, ask)
+// handleDepth serves the aggregated book of one exchange at the requested tick size
+func (s *Server) handleDepth(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	ob, ok := s.books.Find(exchange, r.URL.Query().Get("symbol"))
+	if !ok {
+		http.Error(w, "unknown exchange: "+exchange, http.StatusNotFound)
+		return
+	}
+	if !ob.IsReady() {
+		http.Error(w, "orderbook not ready", http.StatusServiceUnavailable)
+		return
 	}
 
-	// Apply aggregation
 	s.tickMux.RLock()
-	aggregatedBids := s.aggregator.AggregateBids(bidLevels)
-	aggregatedAsks := s.aggregator.AggregateAsks(askLevels)
+	tick := s.aggregator.GetTickLevel()
 	s.tickMux.RUnlock()
+	if value := r.URL.Query().Get("tick"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid tick: "+value, http.StatusBadRequest)
+			return
+		}
+		tick = types.TickLevel(parsed)
+	}
 
-	// Sort bids by price descending (highest first)
-	sort.Slice(aggregatedBids, func(i, j int) bool {
-		return aggregatedBids[i].Price.GreaterThan(aggregatedBids[j].Price)
-	})
+	levels := defaultDepthLevels
+	if value := r.URL.Query().Get("levels"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxDepthLevels {
+			http.Error(w, "invalid levels: "+value, http.StatusBadRequest)
+			return
+		}
+		levels = parsed
+	}
 
-	// Sort asks by price ascending (lowest first)
-	sort.Slice(aggregatedAsks, func(i, j int) bool {
-		return aggregatedAsks[i].Price.LessThan(aggregatedAsks[j].Price)
-	})
+	filter := s.depthFilter
+	if value := r.URL.Query().Get("maxDistancePct"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, "invalid maxDistancePct: "+value, http.StatusBadRequest)
+			return
+		}
+		filter.MaxDistancePct = parsed
+	}
+	if value := r.URL.Query().Get("minQuantity"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, "invalid minQuantity: "+value, http.StatusBadRequest)
+			return
+		}
+		filter.MinQuantity = parsed
+	}
+	tailDistance := s.tailDistance
+	if value := r.URL.Query().Get("tailDistancePct"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, "invalid tailDistancePct: "+value, http.StatusBadRequest)
+			return
+		}
+		tailDistance = parsed
+	}
 
-	// Convert bids to wire format with cumulative sums
-	bids := make([]PriceLevel, 0, len(aggregatedBids))
-	bidCumulative := decimal.Zero
-	for _, bid := range aggregatedBids {
-		bidCumulative = bidCumulative.Add(bid.Quantity)
-		bids = append(bids, PriceLevel{
-			Price:      bid.Price.String(),
-			Quantity:   bid.Quantity.String(),
-			Cumulative: bidCumulative.String(),
-		})
+	// Aggregated on demand unless the tick matches the push channel
+	bids, asks := buildDepth(ob, tick, levels, s.priceAdjustment(exchange), filter, tailDistance)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(DepthResponse{
+		Exchange:    exchange,
+		Tick:        float64(tick),
+		Bids:        bids,
+		Asks:        asks,
+		Timestamp:   s.clock.Now().UnixMilli(),
+		FeeAdjusted: s.feeAdjusted,
+	}); err != nil {
+		log.Printf("Error writing depth response: %v", err)
+	}
+}
+
+// handleLiquidity serves the quantity and notional of one side of an exchange's
+// book at or better than ?price= (bids at or above it, asks at or below it)
+func (s *Server) handleLiquidity(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	query := r.URL.Query()
+	ob, ok := s.books.Find(exchange, query.Get("symbol"))
+	if !ok {
+		http.Error(w, "unknown exchange: "+exchange, http.StatusNotFound)
+		return
+	}
+
+	side := BookSide(query.Get("side"))
+	if side != SideBid && side != SideAsk {
+		http.Error(w, "invalid side: "+string(side)+", expected bid or ask", http.StatusBadRequest)
+		return
+	}
+	price, err := decimal.NewFromString(query.Get("price"))
+	if err != nil || !price.IsPositive() {
+		http.Error(w, "invalid price: "+query.Get("price"), http.StatusBadRequest)
+		return
+	}
+	if !ob.IsReady() {
+		http.Error(w, "orderbook not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Prices are compared as published, after quote conversion and fees
+	adjustment := s.priceAdjustment(exchange)
+	bookSide, adjust := orderbook.Bids, adjustment.bid
+	if side == SideAsk {
+		bookSide, adjust = orderbook.Asks, adjustment.ask
+	}
+	quantity, notional, levels := liquidityAt(ob, bookSide, price, adjust)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(LiquidityResponse{
+		Exchange:    exchange,
+		Side:        side,
+		Price:       price.String(),
+		Quantity:    quantity.String(),
+		Notional:    notional.String(),
+		Levels:      levels,
+		Timestamp:   s.clock.Now().UnixMilli(),
+		FeeAdjusted: s.feeAdjusted,
+	}); err != nil {
+		log.Printf("Error writing liquidity response: %v", err)
 	}
+}
+
+// handleBooks lists the running books, optionally only those of the exchange or
+// symbol query parameters
+func (s *Server) handleBooks(w http.ResponseWriter, r *http.Request) {
+	exchange := r.URL.Query().Get("exchange")
+	symbol := r.URL.Query().Get("symbol")
 
-	// Convert asks to wire format with cumulative sums
-	asks := make([]PriceLevel, 0, len(aggregatedAsks))
-	askCumulative := decimal.Zero
-	for _, ask := range aggregatedAsks {
-		askCumulative = askCumulative.Add(ask.Quantity)
-		asks = append(asks, PriceLevel{
-			Price:      ask.Price.String(),
-			Quantity:   ask.Quantity.String(),
-			Cumulative: askCumulative.String(),
+	resp := BooksResponse{Books: []BookInfo{}, Timestamp: s.clock.Now().UnixMilli()}
+	for _, entry := range s.books.List() {
+		if (exchange != "" && entry.Key.Exchange != exchange) || (symbol != "" && entry.Key.Symbol != symbol) {
+			continue
+		}
+		stats := entry.Book.GetStats()
+		resp.Books = append(resp.Books, BookInfo{
+			Exchange:     entry.Key.Exchange,
+			Symbol:       entry.Key.Symbol,
+			Initialized:  entry.Book.IsInitialized(),
+			Ready:        entry.Book.IsReady(),
+			LastUpdateID: entry.Book.GetLastUpdateID(),
+			BidLevels:    stats.BidLevels,
+			AskLevels:    stats.AskLevels,
 		})
 	}
 
-	return OrderbookMessage{
-		Type:      MessageTypeOrderbook,
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error writing books response: %v", err)
+	}
+}
+
+// handleHealth reports 200 once any orderbook is initialized and 503 before that
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	books := s.books.List()
+	resp := HealthResponse{
+		Status:    "starting",
+		Exchanges: make(map[string]bool, len(books)),
+	}
+	for _, entry := range books {
+		initialized := entry.Book.IsInitialized()
+		resp.Exchanges[entry.Key.Exchange] = initialized
+		if initialized {
+			resp.Initialized++
+		}
+	}
+
+	status := http.StatusServiceUnavailable
+	if resp.Initialized > 0 {
+		resp.Status = "ok"
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error writing health response: %v", err)
+	}
+}
+
+// handleEvents serves the latest gaps, buffer overflows and resyncs of an exchange
+// so the reliability of its feed can be judged
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	ob, ok := s.books.Find(exchange, r.URL.Query().Get("symbol"))
+	if !ok {
+		http.Error(w, "unknown exchange: "+exchange, http.StatusNotFound)
+		return
+	}
+
+	stats := ob.GetStats()
+	events := ob.FeedEvents()
+	resp := FeedEventsResponse{
+		Exchange:        exchange,
+		Gaps:            stats.Gaps,
+		Resyncs:         stats.Resyncs,
+		BufferOverflows: stats.BufferOverflows,
+		Events:          make([]FeedEvent, len(events)),
+	}
+	for i, event := range events {
+		resp.Events[i] = FeedEvent{
+			Kind:      string(event.Kind),
+			Detail:    event.Detail,
+			Timestamp: event.Time.UnixMilli(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error writing events response: %v", err)
+	}
+}
+
+// handleRanking serves the latest liquidity ranking, or 503 before the first one
+func (s *Server) handleRanking(w http.ResponseWriter, r *http.Request) {
+	s.rankingMux.RLock()
+	ranking := s.ranking
+	s.rankingMux.RUnlock()
+	if ranking == nil {
+		http.Error(w, "ranking not available yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ranking); err != nil {
+		log.Printf("Error writing ranking response: %v", err)
+	}
+}
+
+// handleCandles serves the candle history of an exchange, selected with
+// ?interval= (default 1m) and ?source= (default mid), the latest ?limit= only
+func (s *Server) handleCandles(w http.ResponseWriter, r *http.Request) {
+	if s.candles == nil {
+		http.Error(w, "candles not enabled", http.StatusNotFound)
+		return
+	}
+	exchange := r.PathValue("exchange")
+	query := r.URL.Query()
+
+	interval := time.Minute
+	if value := query.Get("interval"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			http.Error(w, "invalid interval: "+value, http.StatusBadRequest)
+			return
+		}
+		interval = parsed
+	}
+	source := analytics.CandleMid
+	if value := query.Get("source"); value != "" {
+		source = analytics.CandleSource(value)
+	}
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid limit: "+value, http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	candles, ok := s.candles.History(exchange, source, interval, limit)
+	if !ok {
+		http.Error(w, formatInterval(interval)+" "+string(source)+" candles are not built", http.StatusBadRequest)
+		return
+	}
+	resp := CandlesResponse{
 		Exchange:  exchange,
-		Bids:      bids,
-		Asks:      asks,
-		Timestamp: timestamp,
+		Source:    string(source),
+		Interval:  formatInterval(interval),
+		Candles:   make([]CandleBar, 0, len(candles)),
+		Timestamp: s.clock.Now().UnixMilli(),
+	}
+	for _, candle := range candles {
+		resp.Candles = append(resp.Candles, NewCandleBar(candle))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error writing candles response: %v", err)
+	}
+}
+
+// handleRoute suggests the venue split with the lowest expected cost, fees included,
+// for an order of the requested side and quantity
+func (s *Server) handleRoute(w http.ResponseWriter, r *http.Request) {
+	var req RouteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bookSide := orderbook.Asks
+	if req.Side == routing.Sell {
+		bookSide = orderbook.Bids
+	}
+	books := s.books.List()
+	venues := make([]routing.Venue, 0, len(books))
+	for _, entry := range books {
+		name, ob := entry.Key.Exchange, entry.Book
+		if !ob.IsReady() {
+			continue
+		}
+		// Books are consolidated in the common quote; the router applies the fees
+		levels := routeLevels(ob, bookSide, req.Quantity)
+		if adjustment := s.quoteAdjustment(name); !adjustment.identity() {
+			levels = adjustLevels(levels, adjustment.convert)
+		}
+		venues = append(venues, routing.Venue{
+			Name:   name,
+			Levels: levels,
+			FeeBps: s.fees[name].TakerBps,
+		})
+	}
+
+	if len(venues) == 0 {
+		http.Error(w, "no orderbook initialized", http.StatusServiceUnavailable)
+		return
+	}
+	plan, err := routing.Route(req.Side, req.Quantity, venues)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(RouteResponse{Plan: plan, Timestamp: s.clock.Now().UnixMilli()}); err != nil {
+		log.Printf("Error writing route response: %v", err)
 	}
 }
 
+// handleResync reloads the book of an exchange from a fresh snapshot, for
+// clients of control listeners
+func (s *Server) handleResync(w http.ResponseWriter, r *http.Request, permission Permission) {
+	if permission < PermissionControl {
+		http.Error(w, "resync requires a control listener", http.StatusForbidden)
+		return
+	}
+	s.writeExchangeAction(w, r.PathValue("exchange"), actionResync)
+}
+
+// routeLevels returns the best levels of side, best first, covering at least quantity
+func routeLevels(ob *orderbook.OrderBook, side orderbook.Side, quantity decimal.Decimal) []types.PriceLevel {
+	if view := ob.View(); view != nil {
+		levels := view.Asks
+		if side == orderbook.Bids {
+			levels = view.Bids
+		}
+		remaining := quantity
+		for i, level := range levels {
+			remaining = remaining.Sub(level.Quantity)
+			if !remaining.IsPositive() {
+				return levels[:i+1]
+			}
+		}
+		return levels
+	}
+
+	var levels []types.PriceLevel
+	remaining := quantity
+	ob.Range(side, decimal.Zero, decimal.Zero, func(level types.PriceLevel) bool {
+		levels = append(levels, level)
+		remaining = remaining.Sub(level.Quantity)
+		return remaining.IsPositive()
+	})
+	return levels
+}
+
+// liquidityAt sums the levels of side, best first, whose adjusted price is at or
+// better than price, reading the published view when there is one
+func liquidityAt(ob *orderbook.OrderBook, side orderbook.Side, price decimal.Decimal, adjust func(decimal.Decimal) decimal.Decimal) (decimal.Decimal, decimal.Decimal, int) {
+	quantity, notional, levels := decimal.Zero, decimal.Zero, 0
+	visit := func(level types.PriceLevel) bool {
+		levelPrice := adjust(level.Price)
+		if (side == orderbook.Bids && levelPrice.LessThan(price)) || (side == orderbook.Asks && levelPrice.GreaterThan(price)) {
+			return false
+		}
+		quantity = quantity.Add(level.Quantity)
+		notional = notional.Add(levelPrice.Mul(level.Quantity))
+		levels++
+		return true
+	}
+
+	if view := ob.View(); view != nil {
+		sideLevels := view.Asks
+		if side == orderbook.Bids {
+			sideLevels = view.Bids
+		}
+		for _, level := range sideLevels {
+			if !visit(level) {
+				break
+			}
+		}
+	} else {
+		ob.Range(side, decimal.Zero, decimal.Zero, visit)
+	}
+	return quantity, notional, levels
+}
+
+// buildDepth aggregates the book at tick and converts it to wire format with
+// cumulative sums, keeping the best levels per side (0 keeps all) and adjusting
+// their prices for publishing. A non-zero filter aggregates only the levels it keeps.
+func buildDepth(ob *orderbook.OrderBook, tick types.TickLevel, levels int, adjustment priceAdjustment, filter orderbook.FilterConfig, tailDistancePct float64) ([]PriceLevel, []PriceLevel) {
+	var aggregatedBids, aggregatedAsks []types.PriceLevel
+	ok := false
+	if filter == (orderbook.FilterConfig{}) {
+		aggregatedBids, aggregatedAsks, ok = ob.GetAggregatedLevels(tick)
+	}
+	if !ok {
+		aggregator := aggregation.New(tick)
+		if filter != (orderbook.FilterConfig{}) {
+			// Only the kept levels are read and aggregated
+			aggregatedBids = aggregator.AggregateBids(ob.GetBidsFiltered(filter))
+			aggregatedAsks = aggregator.AggregateAsks(ob.GetAsksFiltered(filter))
+		} else if view := ob.View(); view != nil {
+			// Aggregate the published view without holding the book lock
+			aggregatedBids = aggregator.AggregateBids(view.Bids)
+			aggregatedAsks = aggregator.AggregateAsks(view.Asks)
+		} else {
+			aggregatedBids = aggregator.AggregateBids(levelSlice(ob.GetBids()))
+			aggregatedAsks = aggregator.AggregateAsks(levelSlice(ob.GetAsks()))
+		}
+	}
+
+	// Levels beyond the band around mid are collapsed into one tail level per side
+	var bidTail, askTail bool
+	if mid := ob.MidPrice(); tailDistancePct > 0 && !mid.IsZero() {
+		aggregator := aggregation.New(tick)
+		distance := mid.Mul(decimal.NewFromFloat(tailDistancePct))
+		aggregatedBids, bidTail = aggregator.CollapseTail(aggregatedBids, mid.Sub(distance), true)
+		aggregatedAsks, askTail = aggregator.CollapseTail(aggregatedAsks, mid.Add(distance), false)
+	}
+
+	if levels > 0 {
+		aggregatedBids = truncateLevels(aggregatedBids, levels, bidTail)
+		aggregatedAsks = truncateLevels(aggregatedAsks, levels, askTail)
+	}
+	if !adjustment.identity() {
+		aggregatedBids = adjustLevels(aggregatedBids, adjustment.bid)
+		aggregatedAsks = adjustLevels(aggregatedAsks, adjustment.ask)
+	}
+
+	return toWireLevels(aggregatedBids, bidTail), toWireLevels(aggregatedAsks, askTail)
+}
+
+// truncateLevels keeps the best n levels of a side, and its tail level if any
+func truncateLevels(levels []types.PriceLevel, n int, tail bool) []types.PriceLevel {
+	if !tail {
+		return levels[:min(n, len(levels))]
+	}
+	if len(levels)-1 <= n {
+		return levels
+	}
+	return append(levels[:n:n], levels[len(levels)-1])
+}
+
+// levelSlice converts a side of the book to a slice
+func levelSlice(levels map[string]types.PriceLevel) []types.PriceLevel {
+	result := make([]types.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		result = append(result, level)
+	}
+	return result
+}
+
+// toWireLevels converts sorted levels to wire format with cumulative sums,
+// flagging the last level when it is a tail
+func toWireLevels(levels []types.PriceLevel, tail bool) []PriceLevel {
+	cumulative := aggregation.Cumulative(levels)
+	result := make([]PriceLevel, len(levels))
+	for i, level := range levels {
+		result[i] = PriceLevel{
+			Price:      level.Price.String(),
+			Quantity:   level.Quantity.String(),
+			Cumulative: cumulative[i].String(),
+		}
+	}
+	if tail && len(result) > 0 {
+		result[len(result)-1].Tail = true
+	}
+	return result
+}
+
 func (s *Server) buildStatsMessage(exchange string, ob *orderbook.OrderBook, timestamp int64) StatsMessage {
 	stats := ob.GetStats()
 
+	realized := make(map[string]string, len(stats.RealizedSpreadBps))
+	for _, rs := range stats.RealizedSpreadBps {
+		realized[rs.Horizon.String()] = rs.Bps.StringFixed(4)
+	}
+	averages := make([]WindowAverage, len(stats.Averages))
+	for i, avg := range stats.Averages {
+		averages[i] = WindowAverage{
+			Window:            formatInterval(avg.Window),
+			CoveredMs:         avg.Covered.Milliseconds(),
+			SpreadBps:         avg.SpreadBps.StringFixed(4),
+			BidLiquidity05Pct: avg.BidLiquidity05Pct.String(),
+			AskLiquidity05Pct: avg.AskLiquidity05Pct.String(),
+			BidLiquidity2Pct:  avg.BidLiquidity2Pct.String(),
+			AskLiquidity2Pct:  avg.AskLiquidity2Pct.String(),
+			BidLiquidity10Pct: avg.BidLiquidity10Pct.String(),
+			AskLiquidity10Pct: avg.AskLiquidity10Pct.String(),
+		}
+	}
+
+	adjustment := s.priceAdjustment(exchange)
+	bestBid, bestAsk, spread := adjustment.bid(stats.BestBid), adjustment.ask(stats.BestAsk), stats.Spread
+	if !adjustment.identity() && !stats.BestBid.IsZero() && !stats.BestAsk.IsZero() {
+		spread = bestAsk.Sub(bestBid)
+	}
+
 	return StatsMessage{
-		Type:                 MessageTypeStats,
-		Exchange:             exchange,
-		BestBid:              stats.BestBid.String(),
-		BestAsk:              stats.BestAsk.String(),
-		MidPrice:             stats.BestBid.Add(stats.BestAsk).Div(decimal.NewFromInt(2)).String(),
-		Spread:               stats.Spread.String(),
-		BidLiquidity05Pct:    stats.BidLiquidity05Pct.String(),
-		AskLiquidity05Pct:    stats.AskLiquidity05Pct.String(),
-		DeltaLiquidity05Pct:  stats.DeltaLiquidity05Pct.String(),
-		BidLiquidity2Pct:     stats.BidLiquidity2Pct.String(),
-		AskLiquidity2Pct:     stats.AskLiquidity2Pct.String(),
-		DeltaLiquidity2Pct:   stats.DeltaLiquidity2Pct.String(),
-		BidLiquidity10Pct:    stats.BidLiquidity10Pct.String(),
-		AskLiquidity10Pct:    stats.AskLiquidity10Pct.String(),
-		DeltaLiquidity10Pct:  stats.DeltaLiquidity10Pct.String(),
-		TotalBidsQty:         stats.TotalBidsQty.String(),
-		TotalAsksQty:         stats.TotalAsksQty.String(),
-		TotalDelta:           stats.TotalDelta.String(),
-		Timestamp:            timestamp,
+		Type:                  MessageTypeStats,
+		Exchange:              exchange,
+		BestBid:               bestBid.String(),
+		BestAsk:               bestAsk.String(),
+		MidPrice:              bestBid.Add(bestAsk).Div(decimal.NewFromInt(2)).String(),
+		Spread:                spread.String(),
+		BidLiquidity05Pct:     stats.BidLiquidity05Pct.String(),
+		AskLiquidity05Pct:     stats.AskLiquidity05Pct.String(),
+		DeltaLiquidity05Pct:   stats.DeltaLiquidity05Pct.String(),
+		BidLiquidity2Pct:      stats.BidLiquidity2Pct.String(),
+		AskLiquidity2Pct:      stats.AskLiquidity2Pct.String(),
+		DeltaLiquidity2Pct:    stats.DeltaLiquidity2Pct.String(),
+		BidLiquidity10Pct:     stats.BidLiquidity10Pct.String(),
+		AskLiquidity10Pct:     stats.AskLiquidity10Pct.String(),
+		DeltaLiquidity10Pct:   stats.DeltaLiquidity10Pct.String(),
+		TotalBidsQty:          stats.TotalBidsQty.String(),
+		TotalAsksQty:          stats.TotalAsksQty.String(),
+		TotalDelta:            stats.TotalDelta.String(),
+		EffectiveSpreadBps:    stats.EffectiveSpreadBps.StringFixed(4),
+		RealizedSpreadBps:     realized,
+		Averages:              averages,
+		FairValue:             stats.FairValue.String(),
+		FairValueDeviationBps: stats.FairValueDeviationBps.StringFixed(2),
+		FairValueAlert:        stats.FairValueAlert,
+		ResilienceSweeps:      stats.ResilienceSweeps,
+		ResilienceRecovered:   strconv.FormatFloat(stats.ResilienceRecovered, 'f', 2, 64),
+		ResilienceMs:          stats.ResilienceMedian.Milliseconds(),
+		BidConcentration2Pct:  strconv.FormatFloat(stats.BidConcentration2Pct, 'f', 4, 64),
+		AskConcentration2Pct:  strconv.FormatFloat(stats.AskConcentration2Pct, 'f', 4, 64),
+		OpenInterest:          stats.OpenInterest.String(),
+		OpenInterestChange:    stats.OpenInterestChange.String(),
+		PrunedLevels:          stats.PrunedLevels,
+		EventLatencyMs:        stats.EventLatency.Milliseconds(),
+		EventsPerSecond:       strconv.FormatFloat(stats.EventsPerSecond, 'f', 1, 64),
+		EventsProcessed:       stats.EventsProcessed,
+		EventsBuffered:        stats.EventsBuffered,
+		EventsDropped:         stats.EventsDropped,
+		Gaps:                  stats.Gaps,
+		Resyncs:               stats.Resyncs,
+		BufferOverflows:       stats.BufferOverflows,
+		CrossedBook:           stats.CrossedBook,
+		CrossedBooks:          stats.CrossedBooks,
+		MalformedLevels:       stats.MalformedLevels,
+		MalformedMessages:     stats.MalformedMessages,
+		ApplyTimeNs:           stats.ApplyTime.Nanoseconds(),
+		Timestamp:             timestamp,
 	}
 }
@@ -0,0 +1,1087 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"orderbook/internal/analytics"
+	"orderbook/internal/clock"
+	"orderbook/internal/conversion"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/reporting"
+	"orderbook/internal/types"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+)
+
+// newRegistry registers books by exchange under BTCUSDT
+func newRegistry(books map[string]*orderbook.OrderBook) *orderbook.BookRegistry {
+	registry := orderbook.NewBookRegistry()
+	for name, ob := range books {
+		registry.Set(orderbook.BookKey{Exchange: name, Symbol: "BTCUSDT"}, ob)
+	}
+	return registry
+}
+
+// findBook returns the book of exchange, nil if it is not registered
+func findBook(s *Server, exchange string) *orderbook.OrderBook {
+	ob, _ := s.books.Find(exchange, "")
+	return ob
+}
+
+func newDepthTestServer(t *testing.T) *http.ServeMux {
+	t.Helper()
+	s := newDepthServer(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/depth/{exchange}", s.handleDepth)
+	mux.HandleFunc("POST /api/route", s.handleRoute)
+	return mux
+}
+
+func newDepthServer(t *testing.T) *Server {
+	t.Helper()
+	ob := orderbook.New()
+	err := ob.LoadSnapshot(&exchange.Snapshot{
+		Exchange: exchange.Binance,
+		Symbol:   "BTCUSDT",
+		Bids: []exchange.PriceLevel{
+			{Price: "50009.5", Quantity: "1"},
+			{Price: "50001", Quantity: "2"},
+			{Price: "49995", Quantity: "3"},
+		},
+		Asks: []exchange.PriceLevel{
+			{Price: "50010.5", Quantity: "1"},
+			{Price: "50019", Quantity: "2"},
+			{Price: "50025", Quantity: "4"},
+		},
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	ob.ProcessBufferedEvents()
+
+	return NewServer(newRegistry(map[string]*orderbook.OrderBook{"binance": ob, "okx": orderbook.New()}), "0", nil)
+}
+
+func TestHandleDepth(t *testing.T) {
+	mux := newDepthTestServer(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/depth/binance?tick=10&levels=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp DepthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Tick != 10 || len(resp.Bids) != 1 || len(resp.Asks) != 1 {
+		t.Fatalf("Expected tick 10 with 1 level per side, got %+v", resp)
+	}
+	if resp.Bids[0].Price != "50000" || resp.Bids[0].Quantity != "3" {
+		t.Errorf("Expected best bid 50000@3, got %s@%s", resp.Bids[0].Price, resp.Bids[0].Quantity)
+	}
+	if resp.Asks[0].Price != "50020" || resp.Asks[0].Quantity != "3" {
+		t.Errorf("Expected best ask 50020@3, got %s@%s", resp.Asks[0].Price, resp.Asks[0].Quantity)
+	}
+}
+
+func TestHandleDepthFiltered(t *testing.T) {
+	mux := newDepthTestServer(t)
+
+	// Within 0.02% of mid 50010 and of at least 2
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/depth/binance?tick=0.5&maxDistancePct=0.0002&minQuantity=2", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp DepthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Bids) != 1 || resp.Bids[0].Price != "50001" {
+		t.Errorf("Expected the single bid 50001, got %+v", resp.Bids)
+	}
+	if len(resp.Asks) != 1 || resp.Asks[0].Price != "50019" {
+		t.Errorf("Expected the single ask 50019, got %+v", resp.Asks)
+	}
+}
+
+func TestHandleDepthTail(t *testing.T) {
+	mux := newDepthTestServer(t)
+
+	// Levels at or beyond 0.02% of mid 50010 fold into the buckets of the band edges
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/depth/binance?tick=0.5&levels=1&tailDistancePct=0.0002", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp DepthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	// The tail is kept past levels and the cumulative quantity covers the whole side
+	if len(resp.Bids) != 2 || resp.Bids[0].Tail || resp.Bids[0].Price != "50009.5" {
+		t.Fatalf("Expected best bid and a tail, got %+v", resp.Bids)
+	}
+	if tail := resp.Bids[1]; !tail.Tail || tail.Price != "49999.5" || tail.Quantity != "3" || tail.Cumulative != "4" {
+		t.Errorf("Expected bid tail 49999.5@3 (cumulative 4), got %+v", tail)
+	}
+	if len(resp.Asks) != 2 || !resp.Asks[1].Tail || resp.Asks[1].Price != "50020.5" || resp.Asks[1].Quantity != "4" {
+		t.Errorf("Expected ask tail 50020.5@4, got %+v", resp.Asks)
+	}
+}
+
+func TestFeeAdjustedPrices(t *testing.T) {
+	s := newDepthServer(t)
+	s.SetFees(map[string]types.FeeSchedule{"binance": {MakerBps: 2, TakerBps: 10}})
+
+	// Prices are only adjusted once enabled
+	stats := s.buildStatsMessage("binance", findBook(s, "binance"), 0)
+	if stats.BestBid != "50009.5" || stats.Spread != "1" {
+		t.Errorf("Expected unadjusted bid 50009.5 and spread 1, got %s and %s", stats.BestBid, stats.Spread)
+	}
+
+	s.SetFeeAdjusted(true)
+	stats = s.buildStatsMessage("binance", findBook(s, "binance"), 0)
+	if stats.BestBid != "49959.4905" || stats.BestAsk != "50060.5105" || stats.Spread != "101.02" {
+		t.Errorf("Expected bid 49959.4905, ask 50060.5105, spread 101.02, got %s, %s, %s", stats.BestBid, stats.BestAsk, stats.Spread)
+	}
+
+	bids, asks := buildDepth(findBook(s, "binance"), types.TickLevel(0.5), 1, s.priceAdjustment("binance"), orderbook.FilterConfig{}, 0)
+	if bids[0].Price != "49959.4905" || asks[0].Price != "50060.5105" || bids[0].Quantity != "1" {
+		t.Errorf("Expected adjusted top levels, got %+v %+v", bids, asks)
+	}
+}
+
+func TestQuoteConvertedPrices(t *testing.T) {
+	s := newDepthServer(t)
+	converter := conversion.NewConverter("USDT")
+	converter.SetQuote("binance", "USD")
+	s.SetConverter(converter)
+
+	// Prices stay in USD until the rate is known
+	stats := s.buildStatsMessage("binance", findBook(s, "binance"), 0)
+	if stats.BestBid != "50009.5" {
+		t.Errorf("Expected unconverted bid 50009.5, got %s", stats.BestBid)
+	}
+
+	converter.SetRate("USD", decimal.NewFromInt(2))
+	stats = s.buildStatsMessage("binance", findBook(s, "binance"), 0)
+	if stats.BestBid != "100019" || stats.BestAsk != "100021" || stats.Spread != "2" {
+		t.Errorf("Expected bid 100019, ask 100021, spread 2, got %s, %s, %s", stats.BestBid, stats.BestAsk, stats.Spread)
+	}
+}
+
+func TestQuantityUnits(t *testing.T) {
+	s := newDepthServer(t)
+	s.SetContractSizes(map[string]decimal.Decimal{"binance": decimal.RequireFromString("0.01")})
+	ob := findBook(s, "binance")
+	book := s.buildOrderbookMessage("binance", ob, 0)
+	stats := s.buildStatsMessage("binance", ob, 0)
+
+	tests := []struct {
+		unit       types.QuantityUnit
+		quantity   string // Best bid
+		cumulative string // Second bid
+		totalBids  string
+	}{
+		{types.UnitBase, "1", "3", "6"},
+		{types.UnitQuote, "50009", "150011", "300060"},
+		{types.UnitContracts, "100", "300", "600"},
+	}
+	for _, tt := range tests {
+		converted := s.withUnit(book, tt.unit).(OrderbookMessage)
+		if converted.Bids[0].Quantity != tt.quantity || converted.Bids[1].Cumulative != tt.cumulative {
+			t.Errorf("%s: Expected best bid quantity %s and cumulative %s, got %+v", tt.unit, tt.quantity, tt.cumulative, converted.Bids[:2])
+		}
+		if converted.Checksum != Checksum(converted.Bids, converted.Asks, s.checksumDepth) {
+			t.Errorf("%s: Expected the checksum of the converted levels", tt.unit)
+		}
+		if totalBids := s.withUnit(stats, tt.unit).(StatsMessage).TotalBidsQty; totalBids != tt.totalBids {
+			t.Errorf("%s: Expected total bids %s, got %s", tt.unit, tt.totalBids, totalBids)
+		}
+	}
+	if book.Bids[0].Quantity != "1" {
+		t.Errorf("Expected the base message unchanged, got %+v", book.Bids[0])
+	}
+}
+
+func TestHandleLiquidity(t *testing.T) {
+	s := newDepthServer(t)
+	tests := []struct {
+		query    string
+		status   int
+		quantity string
+		notional string
+		levels   int
+	}{
+		{"?side=bid&price=50001", http.StatusOK, "3", "150011.5", 2},
+		{"?side=ask&price=50019", http.StatusOK, "3", "150048.5", 2},
+		{"?side=ask&price=60000", http.StatusOK, "7", "350148.5", 3},
+		{"?side=bid&price=50010", http.StatusOK, "0", "0", 0},
+		{"?side=mid&price=50000", http.StatusBadRequest, "", "", 0},
+		{"?side=bid", http.StatusBadRequest, "", "", 0},
+		{"?side=bid&price=-1", http.StatusBadRequest, "", "", 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/liquidity/binance"+tt.query, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%q: Expected status %d, got %d: %s", tt.query, tt.status, rec.Code, rec.Body.String())
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var resp LiquidityResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Quantity != tt.quantity || resp.Notional != tt.notional || resp.Levels != tt.levels {
+			t.Errorf("%q: Expected %s (notional %s) over %d levels, got %s (notional %s) over %d levels", tt.query, tt.quantity, tt.notional, tt.levels, resp.Quantity, resp.Notional, resp.Levels)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/liquidity/okx?side=bid&price=1", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 for an uninitialized book, got %d", rec.Code)
+	}
+}
+
+func TestHandleDepthErrors(t *testing.T) {
+	mux := newDepthTestServer(t)
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"/api/depth/kraken", http.StatusNotFound},
+		{"/api/depth/okx", http.StatusServiceUnavailable},
+		{"/api/depth/binance?tick=0", http.StatusBadRequest},
+		{"/api/depth/binance?tick=abc", http.StatusBadRequest},
+		{"/api/depth/binance?levels=-1", http.StatusBadRequest},
+		{"/api/depth/binance?levels=100000", http.StatusBadRequest},
+		{"/api/depth/binance?maxDistancePct=abc", http.StatusBadRequest},
+		{"/api/depth/binance?minQuantity=-1", http.StatusBadRequest},
+		{"/api/depth/binance?tailDistancePct=x", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.url, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: Expected status %d, got %d", tt.url, tt.status, rec.Code)
+		}
+	}
+}
+
+func TestHandleRoute(t *testing.T) {
+	mux := newDepthTestServer(t)
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"side":"buy","quantity":"2"}`, http.StatusOK},
+		{`{"side":"sell","quantity":10}`, http.StatusOK},
+		{`{"side":"short","quantity":"1"}`, http.StatusBadRequest},
+		{`{"side":"buy","quantity":"0"}`, http.StatusBadRequest},
+		{`{"side":"buy"`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/route", strings.NewReader(tt.body)))
+		if rec.Code != tt.status {
+			t.Errorf("%s: Expected status %d, got %d", tt.body, tt.status, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/route", strings.NewReader(`{"side":"buy","quantity":"2"}`)))
+	var resp RouteResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Fills) != 1 || resp.Fills[0].Venue != "binance" || resp.Notional.String() != "100029.5" {
+		t.Errorf("Expected 2 bought on binance for 100029.5, got %+v", resp.Plan)
+	}
+
+	// Only the levels covering the quantity are routed
+	s := newDepthServer(t)
+	if levels := routeLevels(findBook(s, "binance"), orderbook.Bids, decimal.NewFromInt(2)); len(levels) != 2 {
+		t.Errorf("Expected the 2 best bids to cover 2, got %v", levels)
+	}
+
+	// Without a ready book the request fails before routing, even when invalid
+	empty := NewServer(newRegistry(map[string]*orderbook.OrderBook{"okx": orderbook.New()}), "0", nil)
+	rec = httptest.NewRecorder()
+	empty.handleRoute(rec, httptest.NewRequest("POST", "/api/route", strings.NewReader(`{"side":"buy","quantity":"0"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without a ready book, got %d", rec.Code)
+	}
+}
+
+func TestHandleHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		orderbooks  map[string]*orderbook.OrderBook
+		status      int
+		initialized int
+	}{
+		{"starting", map[string]*orderbook.OrderBook{"okx": orderbook.New()}, http.StatusServiceUnavailable, 0},
+		{"empty", map[string]*orderbook.OrderBook{}, http.StatusServiceUnavailable, 0},
+	}
+
+	for _, tt := range tests {
+		s := NewServer(newRegistry(tt.orderbooks), "0", nil)
+		rec := httptest.NewRecorder()
+		s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: Expected status %d, got %d", tt.name, tt.status, rec.Code)
+		}
+	}
+
+	ob := orderbook.New()
+	if err := ob.LoadSnapshot(&exchange.Snapshot{
+		Exchange:  exchange.Binance,
+		Symbol:    "BTCUSDT",
+		Bids:      []exchange.PriceLevel{{Price: "50000", Quantity: "1"}},
+		Asks:      []exchange.PriceLevel{{Price: "50001", Quantity: "1"}},
+		Timestamp: time.Now(),
+	}); err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	ob.ProcessBufferedEvents()
+	s := NewServer(newRegistry(map[string]*orderbook.OrderBook{"binance": ob, "okx": orderbook.New()}), "0", nil)
+	rec := httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp HealthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Status != "ok" || resp.Initialized != 1 || !resp.Exchanges["binance"] || resp.Exchanges["okx"] {
+		t.Errorf("Expected ok with only binance initialized, got %+v", resp)
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	s := newDepthServer(t)
+	rec := httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/events/kraken", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown exchange, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/events/binance", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"events":[]`) {
+		t.Errorf("Expected an empty event log, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleRanking(t *testing.T) {
+	s := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	rec := httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/ranking", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 before the first ranking, got %d", rec.Code)
+	}
+
+	s.PublishRanking(analytics.LiquidityRanking{
+		Timestamp: time.UnixMilli(1700000000000),
+		Scores: []analytics.LiquidityScore{
+			{Venue: "okx", Rank: 1, Score: 80},
+			{Venue: "binance", Rank: 2, Score: 70},
+		},
+	})
+	rec = httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/ranking", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp RankingMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Type != MessageTypeRanking || len(resp.Venues) != 2 || resp.Venues[0].Exchange != "okx" || resp.Venues[0].Score != 80 {
+		t.Errorf("Expected okx ranked first with score 80, got %+v", resp)
+	}
+}
+
+func TestHandleReports(t *testing.T) {
+	s := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	rec := httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/reports", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without reports, got %d", rec.Code)
+	}
+
+	tracker := reporting.NewTracker(7)
+	s.SetReports(tracker)
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		tracker.Sample(reporting.Sample{Exchange: "okx", Symbol: "BTCUSDT", Time: at.Add(time.Duration(i) * time.Minute), Up: true})
+	}
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"/api/reports", http.StatusOK, `{"dates":["2024-01-01"]}`},
+		{"/api/reports/2024-01-01", http.StatusOK, `"uptimePct":100`},
+		{"/api/reports/2024-01-01?format=markdown", http.StatusOK, "| okx | BTCUSDT | 100.00% |"},
+		{"/api/reports/2024-01-01?format=csv", http.StatusBadRequest, ""},
+		{"/api/reports/2023-12-31", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", tt.url, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: Expected status %d, got %d: %s", tt.url, tt.status, rec.Code, rec.Body.String())
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), tt.body) {
+			t.Errorf("%s: Expected body containing %s, got %s", tt.url, tt.body, rec.Body.String())
+		}
+	}
+}
+
+func TestHandleCandles(t *testing.T) {
+	s := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	builder := analytics.NewCandleBuilder(analytics.CandleConfig{Intervals: []time.Duration{time.Second, time.Minute}, History: 10})
+	s.SetCandles(builder)
+
+	base := time.UnixMilli(1700000000000)
+	for i, mid := range []int64{100, 102, 99, 101} {
+		builder.Sample("okx", base.Add(time.Duration(i)*400*time.Millisecond), decimal.NewFromInt(mid), decimal.Zero)
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		count  int
+	}{
+		{"?interval=1s", http.StatusOK, 2},
+		{"?interval=1s&limit=1", http.StatusOK, 1},
+		{"", http.StatusOK, 1},
+		{"?interval=5s", http.StatusBadRequest, 0},
+		{"?source=microprice", http.StatusBadRequest, 0},
+		{"?interval=soon", http.StatusBadRequest, 0},
+		{"?limit=0", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/candles/okx"+tt.query, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%q: Expected status %d, got %d: %s", tt.query, tt.status, rec.Code, rec.Body.String())
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var resp CandlesResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(resp.Candles) != tt.count {
+			t.Errorf("%q: Expected %d candles, got %+v", tt.query, tt.count, resp.Candles)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler(PermissionReadOnly).ServeHTTP(rec, httptest.NewRequest("GET", "/api/candles/okx?interval=1s", nil))
+	var resp CandlesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	first := resp.Candles[0]
+	if resp.Interval != "1s" || first.Start != 1700000000000 || first.Open != "100" || first.High != "102" || first.Low != "99" || first.Close != "99" || !first.Closed {
+		t.Errorf("Expected a closed 1s candle 100/102/99/99, got %+v", resp)
+	}
+	if resp.Candles[1].Closed || resp.Candles[1].Close != "101" {
+		t.Errorf("Expected the open candle to close at 101, got %+v", resp.Candles[1])
+	}
+}
+
+func TestHeartbeatRemovesDeadClients(t *testing.T) {
+	s := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	s.pingInterval = 20 * time.Millisecond
+	s.pongTimeout = 100 * time.Millisecond
+	ts := httptest.NewServer(s.handler(PermissionControl))
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	// A reading client answers pings automatically
+	alive, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer alive.Close()
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// A client that never reads never answers pings
+	dead, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer dead.Close()
+
+	waitForClients := func(expected int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			s.clientsMux.RLock()
+			count := len(s.clients)
+			s.clientsMux.RUnlock()
+			if count == expected {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d clients, got %d", expected, count)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitForClients(2)
+	waitForClients(1)
+
+	time.Sleep(3 * s.pongTimeout)
+	s.clientsMux.RLock()
+	count := len(s.clients)
+	s.clientsMux.RUnlock()
+	if count != 1 {
+		t.Errorf("Expected the responsive client to stay connected, got %d clients", count)
+	}
+}
+
+func TestParseListener(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected Listener
+		wantErr  bool
+	}{
+		{":8086", Listener{Network: "tcp", Address: ":8086", Permission: PermissionReadOnly}, false},
+		{"127.0.0.1:9000=control", Listener{Network: "tcp", Address: "127.0.0.1:9000", Permission: PermissionControl}, false},
+		{"unix:/tmp/ob.sock=readonly", Listener{Network: "unix", Address: "/tmp/ob.sock", Permission: PermissionReadOnly}, false},
+		{":8086=admin", Listener{}, true},
+		{"unix:", Listener{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseListener(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tt.spec, tt.wantErr, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected %+v, got %+v", tt.spec, tt.expected, got)
+		}
+	}
+}
+
+func TestReadOnlyListenerOverUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ob.sock")
+	symbolChange := make(chan string, 1)
+	s := NewServer(orderbook.NewBookRegistry(), "0", symbolChange)
+	s.AddListener(Listener{Network: "unix", Address: path, Permission: PermissionReadOnly})
+	go s.Start()
+
+	dialer := websocket.Dialer{
+		NetDial: func(network, addr string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		},
+	}
+	var conn *websocket.Conn
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var err error
+		conn, _, err = dialer.Dial("ws://unix/ws", nil)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Dial() failed: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(ClientMessage{Type: "change_symbol", Symbol: "ETHUSDT"}); err != nil {
+		t.Fatalf("WriteJSON() failed: %v", err)
+	}
+	// Messages are handled in order, so the welcome proves the change was processed
+	if err := conn.WriteJSON(ClientMessage{Type: "hello", Version: ProtocolV2}); err != nil {
+		t.Fatalf("WriteJSON() failed: %v", err)
+	}
+	var welcome WelcomeMessage
+	if err := conn.ReadJSON(&welcome); err != nil || welcome.Type != MessageTypeWelcome {
+		t.Fatalf("Expected welcome, got %+v (%v)", welcome, err)
+	}
+
+	select {
+	case symbol := <-symbolChange:
+		t.Errorf("Expected read-only client to be rejected, got symbol change to %s", symbol)
+	default:
+	}
+}
+
+func TestUnixListenerReplacesOnlyStaleSockets(t *testing.T) {
+	dir := t.TempDir()
+	listener := func(name string) Listener {
+		return Listener{Network: "unix", Address: filepath.Join(dir, name)}
+	}
+
+	// A regular file, e.g. from a mistyped spec, is left in place
+	file := listener("config.yaml")
+	if err := os.WriteFile(file.Address, []byte("keep"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := file.listen(); err == nil {
+		t.Errorf("Expected an error listening on a regular file")
+	}
+	if data, err := os.ReadFile(file.Address); err != nil || string(data) != "keep" {
+		t.Errorf("Expected the file to be kept, got %q (%v)", data, err)
+	}
+
+	// The socket of a running instance is not taken over
+	live := listener("live.sock")
+	running, err := net.Listen("unix", live.Address)
+	if err != nil {
+		t.Fatalf("Listen() failed: %v", err)
+	}
+	defer running.Close()
+	if _, err := live.listen(); err == nil {
+		t.Errorf("Expected an error listening on a socket in use")
+	}
+
+	// A socket left by a run that exited is replaced
+	stale := listener("stale.sock")
+	previous, err := net.Listen("unix", stale.Address)
+	if err != nil {
+		t.Fatalf("Listen() failed: %v", err)
+	}
+	previous.(*net.UnixListener).SetUnlinkOnClose(false)
+	previous.Close()
+	ln, err := stale.listen()
+	if err != nil {
+		t.Fatalf("Expected the stale socket to be replaced, got %v", err)
+	}
+	ln.Close()
+}
+
+func TestBookDeltaSubscription(t *testing.T) {
+	s := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	go s.broadcastMessages()
+	ts := httptest.NewServer(s.handler(PermissionReadOnly))
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	for _, msg := range []ClientMessage{{Type: "hello", Version: ProtocolV2}, {Type: "subscribe", Channel: ChannelBookDelta}} {
+		if err := conn.WriteJSON(msg); err != nil {
+			t.Fatalf("WriteJSON() failed: %v", err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for s.deltaSubscribers.Load() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected one subscriber, got %d", s.deltaSubscribers.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	s.PublishBookDelta("binance", orderbook.BookDelta{
+		Seq:  7,
+		Time: time.UnixMilli(1700000000000),
+		Changes: []orderbook.LevelChange{
+			{IsBid: true, Price: decimal.RequireFromString("50000.1"), OldQuantity: decimal.Zero, NewQuantity: decimal.RequireFromString("1.5")},
+			{IsBid: false, Price: decimal.RequireFromString("50000.2"), OldQuantity: decimal.RequireFromString("2"), NewQuantity: decimal.Zero},
+		},
+	})
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var delta BookDeltaMessage
+	for delta.Type != MessageTypeBookDelta {
+		if err := conn.ReadJSON(&delta); err != nil {
+			t.Fatalf("Expected a bookdelta message: %v", err)
+		}
+	}
+	expected := []LevelChange{
+		{Side: SideBid, Price: "50000.1", OldQuantity: "0", NewQuantity: "1.5"},
+		{Side: SideAsk, Price: "50000.2", OldQuantity: "2", NewQuantity: "0"},
+	}
+	if delta.Exchange != "binance" || delta.Seq != 7 || delta.Version != ProtocolV2 || delta.Timestamp != 1700000000000 || len(delta.Changes) != 2 {
+		t.Fatalf("Unexpected bookdelta message %+v", delta)
+	}
+	for i, change := range delta.Changes {
+		if change != expected[i] {
+			t.Errorf("Expected change %+v, got %+v", expected[i], change)
+		}
+	}
+
+	conn.Close()
+	deadline = time.Now().Add(2 * time.Second)
+	for s.deltaSubscribers.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the subscription dropped with the client, got %d subscribers", s.deltaSubscribers.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDataPushFollowsClock(t *testing.T) {
+	s := newDepthServer(t)
+	fake := clock.NewFake(time.UnixMilli(1700000000000))
+	s.SetClock(fake)
+	recorder, err := NewRecorder(filepath.Join(t.TempDir(), "session.jsonl"))
+	if err != nil {
+		t.Fatalf("NewRecorder() failed: %v", err)
+	}
+	defer recorder.Close()
+	s.SetRecorder(recorder)
+
+	go s.startDataPush()
+	deadline := time.Now().Add(time.Second)
+	for fake.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the data push to wait on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	fake.Advance(199 * time.Millisecond)
+	select {
+	case msg := <-s.broadcast:
+		t.Fatalf("Expected no push before the interval, got %T", msg)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	fake.Advance(time.Millisecond)
+	want := fake.Now().UnixMilli()
+	for _, expected := range []MessageType{MessageTypeOrderbook, MessageTypeStats} {
+		select {
+		case msg := <-s.broadcast:
+			switch m := msg.(type) {
+			case OrderbookMessage:
+				if m.Type != expected || m.Exchange != "binance" || m.Timestamp != want {
+					t.Errorf("Expected %s for binance at %d, got %s for %s at %d", expected, want, m.Type, m.Exchange, m.Timestamp)
+				}
+			case StatsMessage:
+				if m.Type != expected || m.Exchange != "binance" {
+					t.Errorf("Expected %s for binance, got %s for %s", expected, m.Type, m.Exchange)
+				}
+			default:
+				t.Errorf("Expected %s, got %T", expected, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %s after advancing the clock", expected)
+		}
+	}
+}
+
+func TestHandleBooks(t *testing.T) {
+	s := newDepthServer(t)
+	s.books.Set(orderbook.BookKey{Exchange: "binance", Symbol: "ETHUSDT"}, orderbook.New())
+	handler := s.handler(PermissionReadOnly)
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"binance:BTCUSDT", "binance:ETHUSDT", "okx:BTCUSDT"}},
+		{"?exchange=binance", []string{"binance:BTCUSDT", "binance:ETHUSDT"}},
+		{"?symbol=BTCUSDT", []string{"binance:BTCUSDT", "okx:BTCUSDT"}},
+		{"?exchange=kraken", []string{}},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/books"+tt.query, nil))
+		var resp BooksResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%q: failed to decode response: %v", tt.query, err)
+		}
+		got := make([]string, len(resp.Books))
+		for i, book := range resp.Books {
+			got[i] = book.Exchange + ":" + book.Symbol
+		}
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("%q: Expected %v, got %v", tt.query, tt.expected, got)
+		}
+	}
+
+	// Depth of a symbol that is not running
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/depth/binance?symbol=SOLUSDT", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown symbol, got %d", rec.Code)
+	}
+}
+
+func TestExchangeStatus(t *testing.T) {
+	s := NewServer(orderbook.NewBookRegistry(), "0", nil)
+	go s.broadcastMessages()
+	s.PublishExchangeStatus("okx", "BTC-USDT", StatusStale, "")
+	s.PublishExchangeStatus("binance", "BTCUSDT", StatusConnecting, "")
+	s.PublishExchangeStatus("binance", "BTCUSDT", StatusConnected, "")
+	first := s.statuses["binance"].Timestamp
+	s.PublishExchangeStatus("binance", "BTCUSDT", StatusConnected, "")
+	if s.statuses["binance"].Timestamp != first {
+		t.Errorf("Expected a repeated status to be ignored")
+	}
+	if _, ok := withVersion(s.statuses["binance"], ProtocolV1); ok {
+		t.Errorf("Expected exchange_status to be dropped for v1 clients")
+	}
+
+	ts := httptest.NewServer(s.handler(PermissionReadOnly))
+	defer ts.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(ClientMessage{Type: "hello", Version: ProtocolV2}); err != nil {
+		t.Fatalf("WriteJSON() failed: %v", err)
+	}
+
+	// The latest status of every exchange follows the welcome, by exchange name
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var statuses []ExchangeStatusMessage
+	for len(statuses) < 2 {
+		var msg ExchangeStatusMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Expected exchange_status messages: %v", err)
+		}
+		if msg.Type == MessageTypeExchangeStatus {
+			statuses = append(statuses, msg)
+		}
+	}
+	if statuses[0].Exchange != "binance" || statuses[0].Status != StatusConnected || statuses[0].Version != ProtocolV2 {
+		t.Errorf("Expected binance connected, got %+v", statuses[0])
+	}
+	if statuses[1].Exchange != "okx" || statuses[1].Status != StatusStale {
+		t.Errorf("Expected okx stale, got %+v", statuses[1])
+	}
+
+	// Changes are broadcast once the client is connected
+	s.PublishExchangeStatus("binance", "BTCUSDT", StatusDisconnected, "connection closed")
+	var msg ExchangeStatusMessage
+	for msg.Type != MessageTypeExchangeStatus {
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Expected an exchange_status message: %v", err)
+		}
+	}
+	if msg.Status != StatusDisconnected || msg.Detail != "connection closed" {
+		t.Errorf("Expected binance disconnected, got %+v", msg)
+	}
+}
+
+func TestLadderMessage(t *testing.T) {
+	s := newDepthServer(t)
+	okx := orderbook.New()
+	err := okx.LoadSnapshot(&exchange.Snapshot{
+		Exchange:  exchange.OKX,
+		Symbol:    "BTCUSDT",
+		Bids:      []exchange.PriceLevel{{Price: "50009.2", Quantity: "3"}},
+		Asks:      []exchange.PriceLevel{{Price: "50009.9", Quantity: "1"}},
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+	okx.ProcessBufferedEvents()
+	s.books.Set(orderbook.BookKey{Exchange: "okx", Symbol: "BTCUSDT"}, okx)
+	s.SetLadderBuckets(10)
+
+	msg, ok := s.buildLadderMessage(0)
+	if !ok {
+		t.Fatal("Expected a ladder message")
+	}
+	// Best bid 50009.5 (binance) and best ask 50009.9 (okx) center the ladder on bucket 50009
+	if msg.Mid != "50009.7" || len(msg.Venues) != 2 || len(msg.Buckets) != 21 {
+		t.Fatalf("Expected mid 50009.7 over 2 venues in 21 buckets, got %s, %v, %d", msg.Mid, msg.Venues, len(msg.Buckets))
+	}
+	if msg.Buckets[0].Price != "50019" || msg.Buckets[20].Price != "49999" {
+		t.Errorf("Expected buckets from 50019 down to 49999, got %s to %s", msg.Buckets[0].Price, msg.Buckets[20].Price)
+	}
+
+	byPrice := make(map[string]LadderBucket)
+	for _, bucket := range msg.Buckets {
+		byPrice[bucket.Price] = bucket
+	}
+	expected := map[string]LadderBucket{
+		"50019": {Price: "50019", Bids: "0", Asks: "2", Imbalance: "-1.0000"},
+		"50010": {Price: "50010", Bids: "0", Asks: "1", Imbalance: "-1.0000"}, // The binance ask at 50010.5
+		"50005": {Price: "50005", Bids: "0", Asks: "0", Imbalance: "0.0000"},
+		"50009": {Price: "50009", Bids: "4", Asks: "1", Imbalance: "0.6000"}, // Bids of both venues, the okx ask
+		"50001": {Price: "50001", Bids: "2", Asks: "0", Imbalance: "1.0000"},
+	}
+	for price, bucket := range expected {
+		if byPrice[price] != bucket {
+			t.Errorf("Expected bucket %+v, got %+v", bucket, byPrice[price])
+		}
+	}
+	// Ladder messages are v2 only
+	if _, ok := withVersion(msg, ProtocolV1); ok {
+		t.Error("Expected ladder messages not to be sent to v1 clients")
+	}
+}
+
+func TestGetSnapshot(t *testing.T) {
+	s := newDepthServer(t)
+	ts := httptest.NewServer(s.handler(PermissionReadOnly))
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	for _, msg := range []ClientMessage{
+		{Type: "hello", Version: ProtocolV2},
+		{Type: "get_snapshot", ID: "a", Exchange: "binance", Tick: 10, Levels: 1},
+		{Type: "set_unit", Unit: "quote"},
+		{Type: "get_snapshot", ID: "b", Exchange: "binance", Tick: 10, Levels: 1},
+		{Type: "get_snapshot", ID: "c", Exchange: "okx"},
+		{Type: "get_snapshot", ID: "d", Exchange: "kraken"},
+		{Type: "get_snapshot", ID: "e", Exchange: "binance", Levels: maxDepthLevels + 1},
+	} {
+		if err := conn.WriteJSON(msg); err != nil {
+			t.Fatalf("WriteJSON() failed: %v", err)
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	snapshots := make(map[string]SnapshotMessage)
+	for len(snapshots) < 5 {
+		var snapshot SnapshotMessage
+		if err := conn.ReadJSON(&snapshot); err != nil {
+			t.Fatalf("Expected 5 snapshot messages, got %d: %v", len(snapshots), err)
+		}
+		if snapshot.Type == MessageTypeSnapshot {
+			snapshots[snapshot.ID] = snapshot
+		}
+	}
+
+	base := snapshots["a"]
+	if base.Error != "" || base.Version != ProtocolV2 || base.Exchange != "binance" || base.Tick != 10 || len(base.Bids) != 1 || len(base.Asks) != 1 {
+		t.Fatalf("Expected a v2 snapshot at tick 10 with 1 level per side, got %+v", base)
+	}
+	if base.Bids[0].Price != "50000" || base.Bids[0].Quantity != "3" || base.Asks[0].Price != "50020" || base.Asks[0].Quantity != "3" {
+		t.Errorf("Expected 50000@3 / 50020@3, got %+v / %+v", base.Bids[0], base.Asks[0])
+	}
+	// Quantities follow the unit of the connection
+	if quote := snapshots["b"]; quote.Error != "" || len(quote.Bids) != 1 || quote.Bids[0].Quantity != "150000" {
+		t.Errorf("Expected the bid in quote notional 150000, got %+v", quote)
+	}
+	for id, expected := range map[string]string{"c": "orderbook not ready: okx", "d": "unknown exchange: kraken", "e": "invalid levels: 5001"} {
+		if got := snapshots[id]; got.Error != expected || len(got.Bids) != 0 {
+			t.Errorf("Expected request %s to fail with %q, got %+v", id, expected, got)
+		}
+	}
+}
+
+func TestRateProfiles(t *testing.T) {
+	for value, expected := range map[string]RateProfile{"": RateNormal, "slow": RateSlow, "Normal": RateNormal, "fast": RateFast} {
+		if got, err := ParseRateProfile(value); err != nil || got != expected {
+			t.Errorf("ParseRateProfile(%q) = %v, %v; expected %v", value, got, err, expected)
+		}
+	}
+	if _, err := ParseRateProfile("realtime"); err == nil {
+		t.Error("Expected an error for rate realtime")
+	}
+
+	s := newDepthServer(t)
+	fake := clock.NewFake(time.UnixMilli(1700000000000))
+	s.SetClock(fake)
+	// The push only reads the profiles of the clients
+	for _, rate := range []RateProfile{RateSlow, RateFast} {
+		s.clients[&websocket.Conn{}] = &client{rate: rate}
+	}
+
+	go s.startDataPush()
+	deadline := time.Now().Add(time.Second)
+	for fake.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the data push to wait on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Fast clients get every round, slow clients every tenth and normal clients none, as none is connected
+	seqs := make(map[RateProfile][]int64)
+	for round := 1; round <= 20; round++ {
+		fake.Advance(pushInterval)
+		for done := false; !done; {
+			select {
+			case msg := <-s.broadcast:
+				switch m := msg.(type) {
+				case OrderbookMessage:
+					for rate := RateProfile(0); rate < rateCount; rate++ {
+						if m.rates == 1<<rate {
+							seqs[rate] = append(seqs[rate], m.Seq)
+						}
+					}
+				case StatsMessage:
+					expected := rateSet(1 << RateFast)
+					if round%10 == 0 {
+						expected |= 1 << RateSlow
+					}
+					if m.rates != expected {
+						t.Errorf("Round %d: expected stats for profiles %b, got %b", round, expected, m.rates)
+					}
+					done = true
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Round %d: expected a push", round)
+			}
+		}
+	}
+
+	if len(seqs[RateFast]) != 20 || seqs[RateFast][19] != 20 {
+		t.Errorf("Expected fast seqs 1 to 20, got %v", seqs[RateFast])
+	}
+	if len(seqs[RateSlow]) != 2 || seqs[RateSlow][0] != 1 || seqs[RateSlow][1] != 2 {
+		t.Errorf("Expected consecutive slow seqs 1 and 2, got %v", seqs[RateSlow])
+	}
+	if len(seqs[RateNormal]) != 0 {
+		t.Errorf("Expected no normal push, got %v", seqs[RateNormal])
+	}
+
+	// Throttled messages only reach the clients of their profiles, other messages reach everyone
+	fast := messageRates(OrderbookMessage{rates: 1 << RateFast})
+	if !fast.has(RateFast) || fast.has(RateSlow) || !messageRates(RankingMessage{}).has(RateSlow) {
+		t.Errorf("Expected fast books for fast clients only and rankings for everyone")
+	}
+}
@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+
+	"orderbook/internal/types"
+)
+
+// SnapshotMessage answers a get_snapshot request with the aggregated book of one
+// exchange, as served by the depth endpoint and in the client's quantity unit.
+// ID echoes the request ID; Error replaces the levels when the request failed.
+type SnapshotMessage struct {
+	Type        MessageType  `json:"type"`
+	Version     int          `json:"v,omitempty"`
+	ID          string       `json:"id,omitempty"`
+	Exchange    string       `json:"exchange"`
+	Symbol      string       `json:"symbol,omitempty"`
+	Tick        float64      `json:"tick,omitempty"`
+	Bids        []PriceLevel `json:"bids,omitempty"`
+	Asks        []PriceLevel `json:"asks,omitempty"`
+	Timestamp   int64        `json:"timestamp"`
+	FeeAdjusted bool         `json:"feeAdjusted,omitempty"` // Prices are net of taker fees
+	Error       string       `json:"error,omitempty"`
+}
+
+// handleGetSnapshot answers a get_snapshot request with one snapshot of the
+// requested exchange, at the current tick and defaultDepthLevels unless the
+// request sets them
+func (s *Server) handleGetSnapshot(c *client, msg ClientMessage) {
+	snapshot, err := s.buildSnapshot(c, msg)
+	if err != nil {
+		log.Printf("Rejected get_snapshot: %v", err)
+		snapshot = SnapshotMessage{
+			Type:      MessageTypeSnapshot,
+			ID:        msg.ID,
+			Exchange:  msg.Exchange,
+			Symbol:    msg.Symbol,
+			Timestamp: s.clock.Now().UnixMilli(),
+			Error:     err.Error(),
+		}
+	}
+	if err := c.send(snapshot); err != nil {
+		log.Printf("Error writing to client: %v", err)
+	}
+}
+
+// buildSnapshot builds the snapshot requested by msg
+func (s *Server) buildSnapshot(c *client, msg ClientMessage) (SnapshotMessage, error) {
+	ob, ok := s.books.Find(msg.Exchange, msg.Symbol)
+	if !ok {
+		return SnapshotMessage{}, fmt.Errorf("unknown exchange: %s", msg.Exchange)
+	}
+	if !ob.IsReady() {
+		return SnapshotMessage{}, fmt.Errorf("orderbook not ready: %s", msg.Exchange)
+	}
+
+	s.tickMux.RLock()
+	tick := s.aggregator.GetTickLevel()
+	s.tickMux.RUnlock()
+	if msg.Tick < 0 {
+		return SnapshotMessage{}, fmt.Errorf("invalid tick: %v", msg.Tick)
+	} else if msg.Tick > 0 {
+		tick = types.TickLevel(msg.Tick)
+	}
+
+	levels := defaultDepthLevels
+	if msg.Levels < 0 || msg.Levels > maxDepthLevels {
+		return SnapshotMessage{}, fmt.Errorf("invalid levels: %d", msg.Levels)
+	} else if msg.Levels > 0 {
+		levels = msg.Levels
+	}
+
+	bids, asks := buildDepth(ob, tick, levels, s.priceAdjustment(msg.Exchange), s.depthFilter, s.tailDistance)
+	if unit := c.quantityUnit(); unit != types.UnitBase {
+		size := s.contractSizes[msg.Exchange]
+		bids = convertLevels(bids, unit, size)
+		asks = convertLevels(asks, unit, size)
+	}
+	return SnapshotMessage{
+		Type:        MessageTypeSnapshot,
+		ID:          msg.ID,
+		Exchange:    msg.Exchange,
+		Symbol:      msg.Symbol,
+		Tick:        float64(tick),
+		Bids:        bids,
+		Asks:        asks,
+		Timestamp:   s.clock.Now().UnixMilli(),
+		FeeAdjusted: s.feeAdjusted,
+	}, nil
+}
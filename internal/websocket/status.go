@@ -0,0 +1,73 @@
+package websocket
+
+import (
+	"log"
+	"sort"
+)
+
+// ExchangeStatus is the lifecycle state of an exchange feed
+type ExchangeStatus string
+
+// Exchange lifecycle states, in the order a healthy feed goes through them
+const (
+	StatusConnecting   ExchangeStatus = "connecting"   // Opening the connection
+	StatusConnected    ExchangeStatus = "connected"    // Connected, waiting for the snapshot
+	StatusInitialized  ExchangeStatus = "initialized"  // Book loaded and receiving updates
+	StatusStale        ExchangeStatus = "stale"        // Connection lost or stalled, the book is frozen
+	StatusResyncing    ExchangeStatus = "resyncing"    // Reloading the book from a snapshot
+	StatusDisconnected ExchangeStatus = "disconnected" // Feed stopped
+)
+
+// ExchangeStatusMessage announces a change of an exchange's lifecycle state, so
+// front-ends can grey out venues whose data is not live
+type ExchangeStatusMessage struct {
+	Type      MessageType    `json:"type"`
+	Version   int            `json:"v,omitempty"`
+	Exchange  string         `json:"exchange"`
+	Symbol    string         `json:"symbol,omitempty"`
+	Status    ExchangeStatus `json:"status"`
+	Detail    string         `json:"detail,omitempty"` // Reason of a disconnect or resync
+	Timestamp int64          `json:"timestamp"`
+}
+
+// PublishExchangeStatus broadcasts the state of an exchange when it changed. The
+// latest state of every exchange is replayed to clients negotiating v2.
+func (s *Server) PublishExchangeStatus(exchange, symbol string, status ExchangeStatus, detail string) {
+	s.statusMux.Lock()
+	if last, ok := s.statuses[exchange]; ok && last.Status == status && last.Symbol == symbol {
+		s.statusMux.Unlock()
+		return
+	}
+	msg := ExchangeStatusMessage{
+		Type:      MessageTypeExchangeStatus,
+		Exchange:  exchange,
+		Symbol:    symbol,
+		Status:    status,
+		Detail:    detail,
+		Timestamp: s.clock.Now().UnixMilli(),
+	}
+	s.statuses[exchange] = msg
+	s.statusMux.Unlock()
+
+	if detail != "" {
+		log.Printf("[%s] Status: %s (%s)", exchange, status, detail)
+	} else {
+		log.Printf("[%s] Status: %s", exchange, status)
+	}
+	s.Publish(msg)
+}
+
+// exchangeStatuses returns the latest state of every exchange, by name
+func (s *Server) exchangeStatuses() []ExchangeStatusMessage {
+	s.statusMux.RLock()
+	defer s.statusMux.RUnlock()
+
+	statuses := make([]ExchangeStatusMessage, 0, len(s.statuses))
+	for _, msg := range s.statuses {
+		statuses = append(statuses, msg)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Exchange < statuses[j].Exchange
+	})
+	return statuses
+}
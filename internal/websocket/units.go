@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"log"
+	"slices"
+
+	"orderbook/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// unitCount is the number of quantity units, one encoding each per broadcast
+const unitCount = 3
+
+// SetQuantityUnit sets the unit of the orderbook and stats quantities sent to clients
+// that do not select one. It must be called before Start.
+func (s *Server) SetQuantityUnit(unit types.QuantityUnit) {
+	s.quantityUnit = unit
+}
+
+// SetContractSizes sets the base units per contract of each exchange, used by
+// clients selecting types.UnitContracts. It must be called before Start.
+func (s *Server) SetContractSizes(sizes map[string]decimal.Decimal) {
+	s.contractSizes = sizes
+}
+
+// setQuantityUnit selects the unit of the quantities sent to a client
+func (s *Server) setQuantityUnit(c *client, unit string) {
+	parsed, err := types.ParseQuantityUnit(unit)
+	if err != nil {
+		log.Printf("Rejected set_unit: %v", err)
+		return
+	}
+	c.unit.Store(int32(unitIndex(parsed)))
+}
+
+// unitIndex returns the position of unit in types.QuantityUnits, base for unknown units
+func unitIndex(unit types.QuantityUnit) int {
+	return max(slices.Index(types.QuantityUnits, unit), 0)
+}
+
+// unitDependent reports whether msg carries quantities that depend on the unit
+func unitDependent(msg interface{}) bool {
+	switch msg.(type) {
+	case OrderbookMessage, StatsMessage:
+		return true
+	}
+	return false
+}
+
+// withUnit returns msg with its quantities converted from base units to unit.
+// Orderbook checksums are recomputed over the converted levels; stats quantities
+// are valued at the mid price for types.UnitQuote.
+func (s *Server) withUnit(msg interface{}, unit types.QuantityUnit) interface{} {
+	if unit == types.UnitBase {
+		return msg
+	}
+	switch m := msg.(type) {
+	case OrderbookMessage:
+		size := s.contractSizes[m.Exchange]
+		m.Bids = convertLevels(m.Bids, unit, size)
+		m.Asks = convertLevels(m.Asks, unit, size)
+		if m.Checksum != 0 {
+			m.Checksum = Checksum(m.Bids, m.Asks, s.checksumDepth)
+		}
+		return m
+	case StatsMessage:
+		size := s.contractSizes[m.Exchange]
+		mid, _ := decimal.NewFromString(m.MidPrice)
+		for _, field := range []*string{
+			&m.BidLiquidity05Pct, &m.AskLiquidity05Pct, &m.DeltaLiquidity05Pct,
+			&m.BidLiquidity2Pct, &m.AskLiquidity2Pct, &m.DeltaLiquidity2Pct,
+			&m.BidLiquidity10Pct, &m.AskLiquidity10Pct, &m.DeltaLiquidity10Pct,
+			&m.TotalBidsQty, &m.TotalAsksQty, &m.TotalDelta,
+		} {
+			if quantity, err := decimal.NewFromString(*field); err == nil {
+				*field = unit.Convert(quantity, mid, size).String()
+			}
+		}
+		// Averaged liquidity is valued at the current mid; the slice is shared across units
+		averages := make([]WindowAverage, len(m.Averages))
+		for i, avg := range m.Averages {
+			for _, field := range []*string{
+				&avg.BidLiquidity05Pct, &avg.AskLiquidity05Pct,
+				&avg.BidLiquidity2Pct, &avg.AskLiquidity2Pct,
+				&avg.BidLiquidity10Pct, &avg.AskLiquidity10Pct,
+			} {
+				if quantity, err := decimal.NewFromString(*field); err == nil {
+					*field = unit.Convert(quantity, mid, size).String()
+				}
+			}
+			averages[i] = avg
+		}
+		if m.Averages != nil {
+			m.Averages = averages
+		}
+		return m
+	}
+	return msg
+}
+
+// convertLevels returns wire levels with quantities and cumulative sums in unit
+func convertLevels(levels []PriceLevel, unit types.QuantityUnit, contractSize decimal.Decimal) []PriceLevel {
+	result := make([]PriceLevel, len(levels))
+	cumulative := decimal.Zero
+	for i, level := range levels {
+		price, err := decimal.NewFromString(level.Price)
+		if err != nil {
+			result[i] = level
+			continue
+		}
+		quantity, err := decimal.NewFromString(level.Quantity)
+		if err != nil {
+			result[i] = level
+			continue
+		}
+		converted := unit.Convert(quantity, price, contractSize)
+		cumulative = cumulative.Add(converted)
+		result[i] = PriceLevel{
+			Price:      level.Price,
+			Quantity:   converted.String(),
+			Cumulative: cumulative.String(),
+			Tail:       level.Tail,
+		}
+	}
+	return result
+}
@@ -0,0 +1,404 @@
+// Package client is a Go client for the orderbook server's WebSocket endpoint.
+//
+// The server pushes the full aggregated book of every exchange on each tick, so
+// the client keeps the latest book per exchange rather than merging deltas. It
+// negotiates protocol v2, verifies each book against its checksum, detects gaps
+// in the per-exchange sequence numbers, and reconnects with backoff until its
+// context is cancelled. Setting Config.OnBookDelta also subscribes to the raw
+// level changes of every book, and Config.OnLadder to the consolidated ladder.
+// RequestSnapshot fetches a book on demand over the same connection.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ProtocolVersion is the protocol version requested from the server
+const ProtocolVersion = 2
+
+// Defaults applied to zero Config fields
+const (
+	DefaultChecksumDepth     = 10
+	DefaultReconnectDelay    = time.Second
+	DefaultMaxReconnectDelay = 30 * time.Second
+)
+
+// ErrChecksumMismatch is reported when a book does not match its checksum; the book is discarded
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// GapError is reported when sequence numbers of an exchange were skipped. Books are
+// complete, so the next book is still applied; a gap in book deltas means level
+// changes were lost and a book rebuilt from them is no longer exact.
+type GapError struct {
+	Exchange string
+	Expected int64
+	Got      int64
+	Delta    bool // Gap in bookdelta messages rather than books
+}
+
+func (e *GapError) Error() string {
+	if e.Delta {
+		return fmt.Sprintf("%s: bookdelta sequence gap, expected %d, got %d", e.Exchange, e.Expected, e.Got)
+	}
+	return fmt.Sprintf("%s: sequence gap, expected %d, got %d", e.Exchange, e.Expected, e.Got)
+}
+
+// Config holds the client configuration. Callbacks are optional and run on the
+// read goroutine, so they should return quickly.
+type Config struct {
+	URL               string        // WebSocket endpoint, e.g. ws://localhost:8086/ws
+	ChecksumDepth     int           // Levels per side covered by server checksums
+	ReconnectDelay    time.Duration // Delay before the first reconnect, doubled after each failure
+	MaxReconnectDelay time.Duration // Upper bound of the reconnect delay
+
+	OnConnect    func(version int)                 // Called once the server answered the hello
+	OnDisconnect func(err error)                   // Called when the connection is lost
+	OnBook       func(book Book)                   // Called for every verified book
+	OnStats      func(stats Stats)                 // Called for every stats message
+	OnTicks      func(ticks TickLevels)            // Called when the tick levels are announced
+	OnBookDelta  func(delta BookDelta)             // Subscribes to the bookdelta channel and is called for every level change batch
+	OnLadder     func(ladder Ladder)               // Subscribes to the ladder channel and is called for every consolidated ladder
+	OnSnapshot   func(snapshot Snapshot)           // Called with the answer of every RequestSnapshot
+	OnMessage    func(msgType string, data []byte) // Called for other message types (e.g., leadlag, ranking)
+	OnError      func(err error)                   // Called for checksum mismatches, gaps and undecodable messages
+}
+
+// Client maintains a connection to the server and the latest book and stats per exchange
+type Client struct {
+	cfg Config
+
+	mu    sync.RWMutex
+	books map[string]Book
+	stats map[string]Stats
+	seqs  map[string]int64 // Last sequence per exchange on the current connection
+
+	deltaSeqs map[string]int64 // Last bookdelta sequence per exchange on the current connection
+
+	connMu  sync.Mutex // Guards conn and serializes writes
+	conn    *websocket.Conn
+	version int
+
+	requestID atomic.Int64 // Last snapshot request ID
+}
+
+// New creates a new Client instance
+func New(cfg Config) *Client {
+	if cfg.ChecksumDepth <= 0 {
+		cfg.ChecksumDepth = DefaultChecksumDepth
+	}
+	if cfg.ReconnectDelay <= 0 {
+		cfg.ReconnectDelay = DefaultReconnectDelay
+	}
+	if cfg.MaxReconnectDelay <= 0 {
+		cfg.MaxReconnectDelay = DefaultMaxReconnectDelay
+	}
+	return &Client{
+		cfg:   cfg,
+		books: make(map[string]Book),
+		stats: make(map[string]Stats),
+		seqs:  make(map[string]int64),
+
+		deltaSeqs: make(map[string]int64),
+	}
+}
+
+// Run connects to the server and processes messages, reconnecting with backoff
+// whenever the connection is lost, until ctx is cancelled
+func (c *Client) Run(ctx context.Context) error {
+	delay := c.cfg.ReconnectDelay
+	for {
+		connected, err := c.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.cfg.OnDisconnect != nil {
+			c.cfg.OnDisconnect(err)
+		}
+		if connected {
+			delay = c.cfg.ReconnectDelay
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		delay = min(delay*2, c.cfg.MaxReconnectDelay)
+	}
+}
+
+// session runs one connection until it fails, reporting whether it was established
+func (c *Client) session(ctx context.Context) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.cfg.URL, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read loop when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c.mu.Lock()
+	clear(c.seqs)
+	clear(c.deltaSeqs)
+	c.mu.Unlock()
+	c.connMu.Lock()
+	c.conn = conn
+	c.version = 0
+	c.connMu.Unlock()
+	defer func() {
+		c.connMu.Lock()
+		c.conn = nil
+		c.connMu.Unlock()
+	}()
+
+	if err := c.send(clientMessage{Type: "hello", Version: ProtocolVersion}); err != nil {
+		return false, fmt.Errorf("failed to send hello: %w", err)
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		c.handleMessage(data)
+	}
+}
+
+// handleMessage dispatches one server message
+func (c *Client) handleMessage(data []byte) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		c.reportError(fmt.Errorf("failed to decode message: %w", err))
+		return
+	}
+
+	switch env.Type {
+	case "welcome":
+		var msg welcomeMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.reportError(fmt.Errorf("failed to decode welcome: %w", err))
+			return
+		}
+		c.connMu.Lock()
+		c.version = msg.Version
+		c.connMu.Unlock()
+		if c.cfg.OnBookDelta != nil {
+			if err := c.send(clientMessage{Type: "subscribe", Channel: "bookdelta"}); err != nil {
+				c.reportError(fmt.Errorf("failed to subscribe to bookdelta: %w", err))
+			}
+		}
+		if c.cfg.OnLadder != nil {
+			if err := c.send(clientMessage{Type: "subscribe", Channel: "ladder"}); err != nil {
+				c.reportError(fmt.Errorf("failed to subscribe to ladder: %w", err))
+			}
+		}
+		if c.cfg.OnConnect != nil {
+			c.cfg.OnConnect(msg.Version)
+		}
+	case "orderbook":
+		c.handleBook(data)
+	case "bookdelta":
+		c.handleBookDelta(data)
+	case "stats":
+		var stats Stats
+		if err := json.Unmarshal(data, &stats); err != nil {
+			c.reportError(fmt.Errorf("failed to decode stats: %w", err))
+			return
+		}
+		c.mu.Lock()
+		c.stats[stats.Exchange] = stats
+		c.mu.Unlock()
+		if c.cfg.OnStats != nil {
+			c.cfg.OnStats(stats)
+		}
+	case "ladder":
+		var ladder Ladder
+		if err := json.Unmarshal(data, &ladder); err != nil {
+			c.reportError(fmt.Errorf("failed to decode ladder: %w", err))
+			return
+		}
+		if c.cfg.OnLadder != nil {
+			c.cfg.OnLadder(ladder)
+		}
+	case "snapshot":
+		var msg snapshotMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.reportError(fmt.Errorf("failed to decode snapshot: %w", err))
+			return
+		}
+		snapshot, err := msg.toSnapshot()
+		if err != nil {
+			c.reportError(fmt.Errorf("%s: failed to decode snapshot: %w", msg.Exchange, err))
+			return
+		}
+		if c.cfg.OnSnapshot != nil {
+			c.cfg.OnSnapshot(snapshot)
+		}
+	case "ticks":
+		var ticks TickLevels
+		if err := json.Unmarshal(data, &ticks); err != nil {
+			c.reportError(fmt.Errorf("failed to decode ticks: %w", err))
+			return
+		}
+		if c.cfg.OnTicks != nil {
+			c.cfg.OnTicks(ticks)
+		}
+	default:
+		if c.cfg.OnMessage != nil {
+			c.cfg.OnMessage(env.Type, data)
+		}
+	}
+}
+
+// handleBook verifies a book and replaces the exchange's latest book with it
+func (c *Client) handleBook(data []byte) {
+	var msg orderbookMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.reportError(fmt.Errorf("failed to decode orderbook: %w", err))
+		return
+	}
+	if msg.Checksum != 0 && checksum(msg.Bids, msg.Asks, c.cfg.ChecksumDepth) != msg.Checksum {
+		c.reportError(fmt.Errorf("%s: %w", msg.Exchange, ErrChecksumMismatch))
+		return
+	}
+	book, err := msg.toBook()
+	if err != nil {
+		c.reportError(fmt.Errorf("%s: failed to decode orderbook: %w", msg.Exchange, err))
+		return
+	}
+
+	c.mu.Lock()
+	last, seen := c.seqs[msg.Exchange]
+	if msg.Seq != 0 {
+		c.seqs[msg.Exchange] = msg.Seq
+	}
+	c.books[msg.Exchange] = book
+	c.mu.Unlock()
+
+	if seen && msg.Seq != 0 && msg.Seq != last+1 {
+		c.reportError(&GapError{Exchange: msg.Exchange, Expected: last + 1, Got: msg.Seq})
+	}
+	if c.cfg.OnBook != nil {
+		c.cfg.OnBook(book)
+	}
+}
+
+// handleBookDelta checks the sequence of a bookdelta message and passes it on
+func (c *Client) handleBookDelta(data []byte) {
+	var msg bookDeltaMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.reportError(fmt.Errorf("failed to decode bookdelta: %w", err))
+		return
+	}
+	delta, err := msg.toBookDelta()
+	if err != nil {
+		c.reportError(fmt.Errorf("%s: failed to decode bookdelta: %w", msg.Exchange, err))
+		return
+	}
+
+	c.mu.Lock()
+	last, seen := c.deltaSeqs[msg.Exchange]
+	c.deltaSeqs[msg.Exchange] = msg.Seq
+	c.mu.Unlock()
+
+	if seen && msg.Seq != last+1 {
+		c.reportError(&GapError{Exchange: msg.Exchange, Expected: last + 1, Got: msg.Seq, Delta: true})
+	}
+	if c.cfg.OnBookDelta != nil {
+		c.cfg.OnBookDelta(delta)
+	}
+}
+
+func (c *Client) reportError(err error) {
+	if c.cfg.OnError != nil {
+		c.cfg.OnError(err)
+	}
+}
+
+// Book returns the latest book of an exchange
+func (c *Client) Book(exchange string) (Book, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	book, ok := c.books[exchange]
+	return book, ok
+}
+
+// Stats returns the latest stats of an exchange
+func (c *Client) Stats(exchange string) (Stats, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stats, ok := c.stats[exchange]
+	return stats, ok
+}
+
+// Exchanges returns the exchanges a book has been received for
+func (c *Client) Exchanges() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.books))
+	for name := range c.books {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Version returns the negotiated protocol version, or 0 while disconnected
+func (c *Client) Version() int {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.version
+}
+
+// SetTick asks the server to aggregate books at tick. It requires a control listener.
+func (c *Client) SetTick(tick float64) error {
+	return c.send(clientMessage{Type: "set_tick", Tick: tick})
+}
+
+// ChangeSymbol asks the server to switch every exchange to symbol. It requires a control listener.
+func (c *Client) ChangeSymbol(symbol string) error {
+	return c.send(clientMessage{Type: "change_symbol", Symbol: symbol})
+}
+
+// Resync asks the server to reload an exchange's book from a fresh snapshot. It requires a control listener.
+func (c *Client) Resync(exchange string) error {
+	return c.send(clientMessage{Type: "resync", Exchange: exchange})
+}
+
+// SetQuantityUnit asks the server to send the quantities of this connection's books and
+// stats in unit: "base", "quote" (notional) or "contracts". Reconnections start in the
+// server's default unit.
+func (c *Client) SetQuantityUnit(unit string) error {
+	return c.send(clientMessage{Type: "set_unit", Unit: unit})
+}
+
+// RequestSnapshot asks the server for the aggregated book of an exchange and
+// returns the ID of the request, echoed in the Snapshot passed to
+// Config.OnSnapshot. Zero levels and tick select the server's defaults: 50
+// levels per side at the current tick.
+func (c *Client) RequestSnapshot(exchange string, levels int, tick float64) (string, error) {
+	id := strconv.FormatInt(c.requestID.Add(1), 10)
+	return id, c.send(clientMessage{Type: "get_snapshot", ID: id, Exchange: exchange, Levels: levels, Tick: tick})
+}
+
+// send writes a message on the current connection
+func (c *Client) send(msg clientMessage) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.conn == nil {
+		return errors.New("not connected")
+	}
+	return c.conn.WriteJSON(msg)
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ws "orderbook/internal/websocket"
+
+	"github.com/gorilla/websocket"
+)
+
+// newBookMessage builds an orderbook message the way the server does
+func newBookMessage(seq int64, bid string) ws.OrderbookMessage {
+	bids := []ws.PriceLevel{{Price: bid, Quantity: "1.5", Cumulative: "1.5"}}
+	asks := []ws.PriceLevel{{Price: "50001", Quantity: "2", Cumulative: "2"}}
+	return ws.OrderbookMessage{
+		Type:      ws.MessageTypeOrderbook,
+		Version:   ws.ProtocolV2,
+		Exchange:  "binance",
+		Seq:       seq,
+		Checksum:  ws.Checksum(bids, asks, ws.DefaultChecksumDepth),
+		Bids:      bids,
+		Asks:      asks,
+		Timestamp: 1700000000000,
+	}
+}
+
+func TestClient(t *testing.T) {
+	var connections int
+	var mu sync.Mutex
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		mu.Lock()
+		connections++
+		first := connections == 1
+		mu.Unlock()
+
+		var hello clientMessage
+		if err := conn.ReadJSON(&hello); err != nil || hello.Type != "hello" {
+			t.Errorf("Expected hello, got %+v (%v)", hello, err)
+			return
+		}
+		conn.WriteJSON(ws.WelcomeMessage{Type: ws.MessageTypeWelcome, Version: ws.ProtocolV2})
+		if !first {
+			// Keep the second connection open until the client stops
+			conn.ReadMessage()
+			return
+		}
+
+		corrupted := newBookMessage(2, "50000")
+		corrupted.Checksum++
+		conn.WriteJSON(newBookMessage(1, "49999"))
+		conn.WriteJSON(corrupted)
+		conn.WriteJSON(newBookMessage(4, "50000"))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"stats","exchange":"binance","bestBid":"50000","bestAsk":"50001","midPrice":"50000.5","spread":"1"}`))
+		conn.WriteJSON(ws.RankingMessage{Type: ws.MessageTypeRanking})
+	}))
+	defer server.Close()
+
+	var (
+		errs     []error
+		books    []Book
+		versions []int
+		other    []string
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(Config{
+		URL:            "ws" + strings.TrimPrefix(server.URL, "http"),
+		ReconnectDelay: 10 * time.Millisecond,
+		OnConnect: func(version int) {
+			mu.Lock()
+			versions = append(versions, version)
+			if len(versions) == 2 {
+				cancel()
+			}
+			mu.Unlock()
+		},
+		OnBook:    func(book Book) { books = append(books, book) },
+		OnMessage: func(msgType string, data []byte) { other = append(other, msgType) },
+		OnError:   func(err error) { errs = append(errs, err) },
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the client to reconnect")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(versions) != 2 || versions[0] != ws.ProtocolV2 {
+		t.Errorf("Expected 2 connections at v2, got %v", versions)
+	}
+	if len(books) != 2 || books[1].Seq != 4 || books[1].Bids[0].Price.String() != "50000" || books[1].Bids[0].Quantity.String() != "1.5" {
+		t.Errorf("Expected 2 verified books ending at seq 4, got %+v", books)
+	}
+
+	// The corrupted book is dropped, then the jump from 1 to 4 is a gap
+	var gap *GapError
+	if len(errs) != 2 || !errors.Is(errs[0], ErrChecksumMismatch) || !errors.As(errs[1], &gap) || gap.Expected != 2 || gap.Got != 4 {
+		t.Errorf("Expected a checksum mismatch then a gap from 2 to 4, got %v", errs)
+	}
+	if len(other) != 1 || other[0] != "ranking" {
+		t.Errorf("Expected the ranking message passed through, got %v", other)
+	}
+
+	stats, ok := c.Stats("binance")
+	if !ok || stats.Spread.String() != "1" {
+		t.Errorf("Expected binance stats with spread 1, got %+v", stats)
+	}
+	if book, ok := c.Book("binance"); !ok || book.Seq != 4 {
+		t.Errorf("Expected latest binance book at seq 4, got %+v", book)
+	}
+}
+
+func TestBookDelta(t *testing.T) {
+	var deltas []BookDelta
+	var errs []error
+	c := New(Config{
+		OnBookDelta: func(delta BookDelta) { deltas = append(deltas, delta) },
+		OnError:     func(err error) { errs = append(errs, err) },
+	})
+
+	for _, seq := range []int64{1, 2, 5} {
+		data, err := json.Marshal(ws.BookDeltaMessage{
+			Type:      ws.MessageTypeBookDelta,
+			Version:   ws.ProtocolV2,
+			Exchange:  "okx",
+			Seq:       seq,
+			Changes:   []ws.LevelChange{{Side: ws.SideBid, Price: "50000.1", OldQuantity: "0", NewQuantity: "1.5"}},
+			Timestamp: 1700000000000,
+		})
+		if err != nil {
+			t.Fatalf("Marshal() failed: %v", err)
+		}
+		c.handleMessage(data)
+	}
+
+	if len(deltas) != 3 || !deltas[0].Changes[0].IsBid || deltas[0].Changes[0].NewQuantity.String() != "1.5" || !deltas[0].Changes[0].OldQuantity.IsZero() {
+		t.Errorf("Expected 3 deltas adding a 1.5 bid, got %+v", deltas)
+	}
+	var gap *GapError
+	if len(errs) != 1 || !errors.As(errs[0], &gap) || !gap.Delta || gap.Expected != 3 || gap.Got != 5 {
+		t.Errorf("Expected a bookdelta gap from 3 to 5, got %v", errs)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	var snapshots []Snapshot
+	c := New(Config{
+		OnSnapshot: func(snapshot Snapshot) { snapshots = append(snapshots, snapshot) },
+		OnError:    func(err error) { t.Errorf("Unexpected error: %v", err) },
+	})
+
+	for _, msg := range []ws.SnapshotMessage{
+		{Type: ws.MessageTypeSnapshot, ID: "1", Exchange: "okx", Tick: 10, Bids: []ws.PriceLevel{{Price: "50000", Quantity: "2", Cumulative: "2"}}, Timestamp: 1700000000000},
+		{Type: ws.MessageTypeSnapshot, ID: "2", Exchange: "kraken", Error: "unknown exchange: kraken"},
+	} {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatalf("Marshal() failed: %v", err)
+		}
+		c.handleMessage(data)
+	}
+
+	if len(snapshots) != 2 {
+		t.Fatalf("Expected 2 snapshots, got %+v", snapshots)
+	}
+	if got := snapshots[0]; got.ID != "1" || got.Err != nil || got.Tick != 10 || len(got.Bids) != 1 || got.Bids[0].Quantity.String() != "2" || len(got.Asks) != 0 {
+		t.Errorf("Expected snapshot 1 with a bid of 2, got %+v", got)
+	}
+	if got := snapshots[1]; got.ID != "2" || got.Err == nil || got.Err.Error() != "unknown exchange: kraken" {
+		t.Errorf("Expected snapshot 2 to carry the server error, got %+v", got)
+	}
+	if _, err := c.RequestSnapshot("okx", 10, 0); err == nil {
+		t.Error("Expected RequestSnapshot to fail while disconnected")
+	}
+}
@@ -0,0 +1,314 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Level is one aggregated price level of a book
+type Level struct {
+	Price      decimal.Decimal
+	Quantity   decimal.Decimal
+	Cumulative decimal.Decimal // Quantity from the best level down to this one
+	Tail       bool            // The level sums every level at or beyond its price, see the server's tail bucket
+}
+
+// Book is the latest aggregated book of one exchange
+type Book struct {
+	Exchange  string
+	Seq       int64   // Server sequence number, incremented by one per message and exchange
+	Bids      []Level // Best first
+	Asks      []Level // Best first
+	Timestamp time.Time
+}
+
+// WindowAverage holds the time-weighted spread and band liquidity of an exchange
+// over a trailing window
+type WindowAverage struct {
+	Window            string          `json:"window"`    // e.g., "5m"
+	CoveredMs         int64           `json:"coveredMs"` // Time within the window with a two-sided book
+	SpreadBps         decimal.Decimal `json:"spreadBps"`
+	BidLiquidity05Pct decimal.Decimal `json:"bidLiquidity05Pct"`
+	AskLiquidity05Pct decimal.Decimal `json:"askLiquidity05Pct"`
+	BidLiquidity2Pct  decimal.Decimal `json:"bidLiquidity2Pct"`
+	AskLiquidity2Pct  decimal.Decimal `json:"askLiquidity2Pct"`
+	BidLiquidity10Pct decimal.Decimal `json:"bidLiquidity10Pct"`
+	AskLiquidity10Pct decimal.Decimal `json:"askLiquidity10Pct"`
+}
+
+// Stats is the latest statistics message of one exchange
+type Stats struct {
+	Exchange              string                     `json:"exchange"`
+	BestBid               decimal.Decimal            `json:"bestBid"`
+	BestAsk               decimal.Decimal            `json:"bestAsk"`
+	MidPrice              decimal.Decimal            `json:"midPrice"`
+	Spread                decimal.Decimal            `json:"spread"`
+	BidLiquidity05Pct     decimal.Decimal            `json:"bidLiquidity05Pct"`
+	AskLiquidity05Pct     decimal.Decimal            `json:"askLiquidity05Pct"`
+	DeltaLiquidity05Pct   decimal.Decimal            `json:"deltaLiquidity05Pct"`
+	BidLiquidity2Pct      decimal.Decimal            `json:"bidLiquidity2Pct"`
+	AskLiquidity2Pct      decimal.Decimal            `json:"askLiquidity2Pct"`
+	DeltaLiquidity2Pct    decimal.Decimal            `json:"deltaLiquidity2Pct"`
+	BidLiquidity10Pct     decimal.Decimal            `json:"bidLiquidity10Pct"`
+	AskLiquidity10Pct     decimal.Decimal            `json:"askLiquidity10Pct"`
+	DeltaLiquidity10Pct   decimal.Decimal            `json:"deltaLiquidity10Pct"`
+	TotalBidsQty          decimal.Decimal            `json:"totalBidsQty"`
+	TotalAsksQty          decimal.Decimal            `json:"totalAsksQty"`
+	TotalDelta            decimal.Decimal            `json:"totalDelta"`
+	EffectiveSpreadBps    decimal.Decimal            `json:"effectiveSpreadBps"`
+	RealizedSpreadBps     map[string]decimal.Decimal `json:"realizedSpreadBps"` // Keyed by horizon (e.g., "5s")
+	Averages              []WindowAverage            `json:"averages"`          // Time-weighted averages, shortest window first
+	FairValue             decimal.Decimal            `json:"fairValue"`
+	FairValueDeviationBps decimal.Decimal            `json:"fairValueDeviationBps"`
+	FairValueAlert        bool                       `json:"fairValueAlert"`
+	ResilienceSweeps      int                        `json:"resilienceSweeps"`
+	ResilienceRecovered   decimal.Decimal            `json:"resilienceRecovered"`
+	ResilienceMs          int64                      `json:"resilienceMs"`
+	BidConcentration2Pct  decimal.Decimal            `json:"bidConcentration2Pct"`
+	AskConcentration2Pct  decimal.Decimal            `json:"askConcentration2Pct"`
+	OpenInterest          decimal.Decimal            `json:"openInterest"`
+	OpenInterestChange    decimal.Decimal            `json:"openInterestChange"`
+	PrunedLevels          int64                      `json:"prunedLevels"`
+	EventLatencyMs        int64                      `json:"eventLatencyMs"`
+	EventsPerSecond       decimal.Decimal            `json:"eventsPerSecond"`
+	EventsProcessed       int64                      `json:"eventsProcessed"`
+	EventsBuffered        int64                      `json:"eventsBuffered"`
+	EventsDropped         int64                      `json:"eventsDropped"`
+	Gaps                  int64                      `json:"gaps"`
+	Resyncs               int64                      `json:"resyncs"`
+	BufferOverflows       int64                      `json:"bufferOverflows"`
+	CrossedBook           bool                       `json:"crossedBook"`
+	CrossedBooks          int64                      `json:"crossedBooks"`
+	MalformedLevels       int64                      `json:"malformedLevels"`
+	MalformedMessages     int64                      `json:"malformedMessages"`
+	ApplyTimeNs           int64                      `json:"applyTimeNs"`
+	Timestamp             int64                      `json:"timestamp"` // Unix milliseconds
+}
+
+// LevelChange is one price level changed in an exchange's book
+type LevelChange struct {
+	IsBid       bool
+	Price       decimal.Decimal
+	OldQuantity decimal.Decimal // Zero for a new level
+	NewQuantity decimal.Decimal // Zero for a removed level
+}
+
+// BookDelta is a batch of level changes of one exchange, at venue prices
+type BookDelta struct {
+	Exchange  string
+	Seq       int64 // Server sequence number, incremented by one per delta and exchange
+	Snapshot  bool  // Changes diff the previous book against a snapshot
+	Changes   []LevelChange
+	Timestamp time.Time
+}
+
+// Snapshot is the answer to a RequestSnapshot: the aggregated book of one
+// exchange, or the reason the server could not serve it
+type Snapshot struct {
+	ID        string // ID returned by RequestSnapshot
+	Exchange  string
+	Tick      float64
+	Bids      []Level // Best first
+	Asks      []Level // Best first
+	Timestamp time.Time
+	Err       error // Set when the request was rejected, e.g. for an unknown exchange
+}
+
+// Ladder is the consolidated book of all venues bucketed at the current tick
+// around the consolidated mid, highest price first, at published prices
+type Ladder struct {
+	Tick      float64         `json:"tick"`
+	Mid       decimal.Decimal `json:"mid"`
+	Venues    []string        `json:"venues"`
+	Buckets   []LadderBucket  `json:"buckets"`
+	Timestamp int64           `json:"timestamp"` // Unix milliseconds
+}
+
+// LadderBucket is the consolidated liquidity of prices in [Price, Price + tick)
+type LadderBucket struct {
+	Price     decimal.Decimal `json:"price"`
+	Bids      decimal.Decimal `json:"bids"`      // Base units
+	Asks      decimal.Decimal `json:"asks"`      // Base units
+	Imbalance decimal.Decimal `json:"imbalance"` // (bids - asks) / (bids + asks)
+}
+
+// TickLevels lists the tick sizes the server offers for the current symbol
+type TickLevels struct {
+	Levels  []float64 `json:"levels"`
+	Current float64   `json:"current"`
+}
+
+// envelope is decoded first to dispatch a message by type
+type envelope struct {
+	Type string `json:"type"`
+}
+
+// welcomeMessage answers the hello sent on connect
+type welcomeMessage struct {
+	Version int `json:"version"`
+}
+
+// wireLevel is a price level as sent by the server
+type wireLevel struct {
+	Price      string `json:"price"`
+	Quantity   string `json:"quantity"`
+	Cumulative string `json:"cumulative"`
+	Tail       bool   `json:"tail,omitempty"`
+}
+
+// orderbookMessage is the wire format of a book
+type orderbookMessage struct {
+	Exchange  string      `json:"exchange"`
+	Seq       int64       `json:"seq"`
+	Checksum  uint32      `json:"checksum"`
+	Bids      []wireLevel `json:"bids"`
+	Asks      []wireLevel `json:"asks"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// wireLevelChange is a changed level as sent by the server
+type wireLevelChange struct {
+	Side        string `json:"side"`
+	Price       string `json:"price"`
+	OldQuantity string `json:"oldQuantity"`
+	NewQuantity string `json:"newQuantity"`
+}
+
+// bookDeltaMessage is the wire format of a book delta
+type bookDeltaMessage struct {
+	Exchange  string            `json:"exchange"`
+	Seq       int64             `json:"seq"`
+	Snapshot  bool              `json:"snapshot"`
+	Changes   []wireLevelChange `json:"changes"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+// snapshotMessage is the wire format of a snapshot
+type snapshotMessage struct {
+	ID        string      `json:"id"`
+	Exchange  string      `json:"exchange"`
+	Tick      float64     `json:"tick"`
+	Bids      []wireLevel `json:"bids"`
+	Asks      []wireLevel `json:"asks"`
+	Timestamp int64       `json:"timestamp"`
+	Error     string      `json:"error"`
+}
+
+// clientMessage is a message sent to the server
+type clientMessage struct {
+	Type     string  `json:"type"`
+	Tick     float64 `json:"tick,omitempty"`
+	Symbol   string  `json:"symbol,omitempty"`
+	Version  int     `json:"version,omitempty"`
+	Exchange string  `json:"exchange,omitempty"`
+	Channel  string  `json:"channel,omitempty"`
+	Unit     string  `json:"unit,omitempty"`
+	ID       string  `json:"id,omitempty"`
+	Levels   int     `json:"levels,omitempty"`
+}
+
+// checksum computes the server's CRC32 over the top depth levels of each side,
+// written as "price:quantity" and joined with ":", from the strings as received
+func checksum(bids, asks []wireLevel, depth int) uint32 {
+	buf := make([]byte, 0, 2*depth*24)
+	for _, side := range [][]wireLevel{bids[:min(depth, len(bids))], asks[:min(depth, len(asks))]} {
+		for _, level := range side {
+			if len(buf) > 0 {
+				buf = append(buf, ':')
+			}
+			buf = append(buf, level.Price...)
+			buf = append(buf, ':')
+			buf = append(buf, level.Quantity...)
+		}
+	}
+	return crc32.ChecksumIEEE(buf)
+}
+
+// toBook parses a wire book
+func (m orderbookMessage) toBook() (Book, error) {
+	bids, err := toLevels(m.Bids)
+	if err != nil {
+		return Book{}, err
+	}
+	asks, err := toLevels(m.Asks)
+	if err != nil {
+		return Book{}, err
+	}
+	return Book{
+		Exchange:  m.Exchange,
+		Seq:       m.Seq,
+		Bids:      bids,
+		Asks:      asks,
+		Timestamp: time.UnixMilli(m.Timestamp),
+	}, nil
+}
+
+// toLevels parses wire levels
+func toLevels(levels []wireLevel) ([]Level, error) {
+	result := make([]Level, len(levels))
+	for i, level := range levels {
+		var err error
+		if result[i].Price, err = decimal.NewFromString(level.Price); err != nil {
+			return nil, fmt.Errorf("invalid price %q: %w", level.Price, err)
+		}
+		if result[i].Quantity, err = decimal.NewFromString(level.Quantity); err != nil {
+			return nil, fmt.Errorf("invalid quantity %q: %w", level.Quantity, err)
+		}
+		if result[i].Cumulative, err = decimal.NewFromString(level.Cumulative); err != nil {
+			return nil, fmt.Errorf("invalid cumulative %q: %w", level.Cumulative, err)
+		}
+		result[i].Tail = level.Tail
+	}
+	return result, nil
+}
+
+// toBookDelta parses a wire book delta
+func (m bookDeltaMessage) toBookDelta() (BookDelta, error) {
+	changes := make([]LevelChange, len(m.Changes))
+	for i, change := range m.Changes {
+		var err error
+		changes[i].IsBid = change.Side == "bid"
+		if changes[i].Price, err = decimal.NewFromString(change.Price); err != nil {
+			return BookDelta{}, fmt.Errorf("invalid price %q: %w", change.Price, err)
+		}
+		if changes[i].OldQuantity, err = decimal.NewFromString(change.OldQuantity); err != nil {
+			return BookDelta{}, fmt.Errorf("invalid quantity %q: %w", change.OldQuantity, err)
+		}
+		if changes[i].NewQuantity, err = decimal.NewFromString(change.NewQuantity); err != nil {
+			return BookDelta{}, fmt.Errorf("invalid quantity %q: %w", change.NewQuantity, err)
+		}
+	}
+	return BookDelta{
+		Exchange:  m.Exchange,
+		Seq:       m.Seq,
+		Snapshot:  m.Snapshot,
+		Changes:   changes,
+		Timestamp: time.UnixMilli(m.Timestamp),
+	}, nil
+}
+
+// toSnapshot parses a wire snapshot
+func (m snapshotMessage) toSnapshot() (Snapshot, error) {
+	snapshot := Snapshot{
+		ID:        m.ID,
+		Exchange:  m.Exchange,
+		Tick:      m.Tick,
+		Timestamp: time.UnixMilli(m.Timestamp),
+	}
+	if m.Error != "" {
+		snapshot.Err = errors.New(m.Error)
+		return snapshot, nil
+	}
+	var err error
+	if snapshot.Bids, err = toLevels(m.Bids); err != nil {
+		return Snapshot{}, err
+	}
+	if snapshot.Asks, err = toLevels(m.Asks); err != nil {
+		return Snapshot{}, err
+	}
+	return snapshot, nil
+}